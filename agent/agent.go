@@ -61,6 +61,14 @@ type Options struct {
 	ReconnectingPTYTimeout time.Duration
 	EnvironmentVariables   map[string]string
 	Logger                 slog.Logger
+	// WorkspaceNetworkingAddress is the address of the SOCKS5 proxy that
+	// reaches the other workspaces of the owner, when the template allows
+	// workspace networking.
+	WorkspaceNetworkingAddress string
+	// WorkspaceNetworkingDNSAddress is the UDP address of the resolver for
+	// the names of the other workspaces of the owner, when the template
+	// allows workspace networking.
+	WorkspaceNetworkingDNSAddress string
 }
 
 type Client interface {
@@ -69,6 +77,9 @@ type Client interface {
 	AgentReportStats(ctx context.Context, log slog.Logger, stats func() *codersdk.AgentStats) (io.Closer, error)
 	PostWorkspaceAgentAppHealth(ctx context.Context, req codersdk.PostWorkspaceAppHealthsRequest) error
 	PostWorkspaceAgentVersion(ctx context.Context, version string) error
+	WorkspaceAgentWatchDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error)
+	WorkspaceAgentPeers(ctx context.Context) ([]codersdk.WorkspaceAgentPeer, error)
+	DialWorkspaceAgentPeer(ctx context.Context, agentID uuid.UUID) (net.Conn, error)
 }
 
 func New(options Options) io.Closer {
@@ -78,6 +89,12 @@ func New(options Options) io.Closer {
 	if options.Filesystem == nil {
 		options.Filesystem = afero.NewOsFs()
 	}
+	if options.WorkspaceNetworkingAddress == "" {
+		options.WorkspaceNetworkingAddress = "127.0.0.1:1080"
+	}
+	if options.WorkspaceNetworkingDNSAddress == "" {
+		options.WorkspaceNetworkingDNSAddress = "127.0.0.1:53"
+	}
 	if options.ExchangeToken == nil {
 		options.ExchangeToken = func(ctx context.Context) (string, error) {
 			return "", nil
@@ -94,6 +111,9 @@ func New(options Options) io.Closer {
 		exchangeToken:          options.ExchangeToken,
 		filesystem:             options.Filesystem,
 		stats:                  &Stats{},
+
+		workspaceNetworkingAddress:    options.WorkspaceNetworkingAddress,
+		workspaceNetworkingDNSAddress: options.WorkspaceNetworkingDNSAddress,
 	}
 	server.init(ctx)
 	return server
@@ -121,6 +141,12 @@ type agent struct {
 
 	network *tailnet.Conn
 	stats   *Stats
+
+	workspaceNetworkingAddress    string
+	workspaceNetworkingDNSAddress string
+	// peers is set once workspace networking is served.
+	peers      *peerNetwork
+	peersServe sync.Once
 }
 
 // runLoop attempts to start the agent in a retry loop.
@@ -204,7 +230,7 @@ func (a *agent) run(ctx context.Context) error {
 	a.closeMutex.Unlock()
 	if a.network == nil {
 		a.logger.Debug(ctx, "creating tailnet")
-		network, err = a.createTailnet(ctx, metadata.DERPMap)
+		network, err = a.createTailnet(ctx, metadata.AgentID, metadata.DERPMap, metadata.DisableDirectConnections)
 		if err != nil {
 			return xerrors.Errorf("create tailnet: %w", err)
 		}
@@ -216,6 +242,16 @@ func (a *agent) run(ctx context.Context) error {
 		network.SetDERPMap(metadata.DERPMap)
 	}
 
+	if metadata.WorkspaceNetworking && metadata.AgentID != uuid.Nil {
+		a.peersServe.Do(func() {
+			a.servePeers(ctx, metadata.DERPMap, metadata.DisableDirectConnections)
+		})
+	}
+
+	derpMapCtx, derpMapCtxCancel := context.WithCancel(ctx)
+	defer derpMapCtxCancel()
+	go a.watchDERPMap(derpMapCtx, network)
+
 	a.logger.Debug(ctx, "running coordinator")
 	err = a.runCoordinator(ctx, network)
 	if err != nil {
@@ -225,16 +261,45 @@ func (a *agent) run(ctx context.Context) error {
 	return nil
 }
 
-func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*tailnet.Conn, error) {
+// watchDERPMap updates the DERP map of the network whenever regions are
+// changed through the API, until ctx is canceled.
+func (a *agent) watchDERPMap(ctx context.Context, network *tailnet.Conn) {
+	maps, err := a.client.WorkspaceAgentWatchDERPMap(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			a.logger.Warn(ctx, "watch derp map", slog.Error(err))
+		}
+		return
+	}
+	for derpMap := range maps {
+		a.logger.Debug(ctx, "updating derp map", slog.F("derpmap", derpMap))
+		network.SetDERPMap(derpMap)
+		a.closeMutex.Lock()
+		peers := a.peers
+		a.closeMutex.Unlock()
+		if peers != nil {
+			peers.network.SetDERPMap(derpMap)
+		}
+	}
+}
+
+func (a *agent) createTailnet(ctx context.Context, agentID uuid.UUID, derpMap *tailcfg.DERPMap, disableDirectConnections bool) (*tailnet.Conn, error) {
 	a.closeMutex.Lock()
 	if a.isClosed() {
 		a.closeMutex.Unlock()
 		return nil, xerrors.New("closed")
 	}
+	addresses := []netip.Prefix{netip.PrefixFrom(codersdk.TailnetIP, 128)}
+	if agentID != uuid.Nil {
+		// Other agents dial this agent at its own address, since they have
+		// TailnetIP themselves.
+		addresses = append(addresses, netip.PrefixFrom(codersdk.WorkspaceAgentIP(agentID), 128))
+	}
 	network, err := tailnet.NewConn(&tailnet.Options{
-		Addresses: []netip.Prefix{netip.PrefixFrom(codersdk.TailnetIP, 128)},
-		DERPMap:   derpMap,
-		Logger:    a.logger.Named("tailnet"),
+		Addresses:      addresses,
+		DERPMap:        derpMap,
+		Logger:         a.logger.Named("tailnet"),
+		BlockEndpoints: disableDirectConnections,
 	})
 	if err != nil {
 		a.closeMutex.Unlock()
@@ -246,7 +311,7 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 			// If a listener already exists, we would double-wrap the conn.
 			return conn
 		}
-		return a.stats.wrapConn(conn)
+		return a.stats.wrapConn(conn, &a.stats.SessionCountApp)
 	})
 	a.connCloseWait.Add(4)
 	a.closeMutex.Unlock()
@@ -262,7 +327,7 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 			if err != nil {
 				return
 			}
-			go a.sshServer.HandleConn(a.stats.wrapConn(conn))
+			go a.sshServer.HandleConn(a.stats.wrapConn(conn, &a.stats.SessionCountSSH))
 		}
 	}()
 
@@ -278,23 +343,26 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 				a.logger.Debug(ctx, "accept pty failed", slog.Error(err))
 				return
 			}
-			conn = a.stats.wrapConn(conn)
+			conn = a.stats.wrapConn(conn, &a.stats.SessionCountReconnectingPTY)
 			// This cannot use a JSON decoder, since that can
 			// buffer additional data that is required for the PTY.
 			rawLen := make([]byte, 2)
 			_, err = conn.Read(rawLen)
 			if err != nil {
+				_ = conn.Close()
 				continue
 			}
 			length := binary.LittleEndian.Uint16(rawLen)
 			data := make([]byte, length)
 			_, err = conn.Read(data)
 			if err != nil {
+				_ = conn.Close()
 				continue
 			}
 			var msg codersdk.ReconnectingPTYInit
 			err = json.Unmarshal(data, &msg)
 			if err != nil {
+				_ = conn.Close()
 				continue
 			}
 			go a.handleReconnectingPTY(ctx, msg, conn)
@@ -370,6 +438,62 @@ func (a *agent) runCoordinator(ctx context.Context, network *tailnet.Conn) error
 	}
 }
 
+// servePeers runs the SOCKS5 proxy and the resolver for workspace networking
+// until ctx is canceled.
+func (a *agent) servePeers(ctx context.Context, derpMap *tailcfg.DERPMap, disableDirectConnections bool) {
+	a.closeMutex.Lock()
+	if a.isClosed() {
+		a.closeMutex.Unlock()
+		return
+	}
+	peers, err := newPeerNetwork(ctx, a.logger.Named("peers"), a.client, derpMap, disableDirectConnections)
+	if err != nil {
+		a.closeMutex.Unlock()
+		a.logger.Warn(ctx, "create workspace networking", slog.Error(err))
+		return
+	}
+	a.peers = peers
+	a.closeMutex.Unlock()
+
+	listener, err := net.Listen("tcp", a.workspaceNetworkingAddress)
+	if err != nil {
+		a.logger.Warn(ctx, "listen for workspace networking",
+			slog.F("address", a.workspaceNetworkingAddress), slog.Error(err))
+		return
+	}
+	a.logger.Info(ctx, "serving workspace networking", slog.F("address", listener.Addr().String()))
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	go func() {
+		err := peers.serve(listener)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			a.logger.Warn(ctx, "serve workspace networking", slog.Error(err))
+		}
+	}()
+
+	// The proxy works without the resolver, e.g. when the agent may not
+	// listen on the DNS port, so failing to listen isn't fatal.
+	dnsConn, err := net.ListenPacket("udp", a.workspaceNetworkingDNSAddress)
+	if err != nil {
+		a.logger.Warn(ctx, "listen for workspace networking dns",
+			slog.F("address", a.workspaceNetworkingDNSAddress), slog.Error(err))
+		return
+	}
+	a.logger.Info(ctx, "serving workspace networking dns", slog.F("address", dnsConn.LocalAddr().String()))
+	go func() {
+		<-ctx.Done()
+		_ = dnsConn.Close()
+	}()
+	go func() {
+		err := peers.serveDNS(dnsConn)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			a.logger.Warn(ctx, "serve workspace networking dns", slog.Error(err))
+		}
+	}()
+}
+
 func (a *agent) runStartupScript(ctx context.Context, script string) error {
 	if script == "" {
 		return nil
@@ -417,10 +541,12 @@ func (a *agent) init(ctx context.Context) {
 	}
 	sshLogger := a.logger.Named("ssh-server")
 	forwardHandler := &ssh.ForwardedTCPHandler{}
+	unixForwardHandler := newForwardedUnixHandler(sshLogger)
 	a.sshServer = &ssh.Server{
 		ChannelHandlers: map[string]ssh.ChannelHandler{
-			"direct-tcpip": ssh.DirectTCPIPHandler,
-			"session":      ssh.DefaultSessionHandler,
+			"direct-tcpip":               ssh.DirectTCPIPHandler,
+			directStreamLocalChannelType: directStreamLocalHandler(sshLogger),
+			"session":                    ssh.DefaultSessionHandler,
 		},
 		ConnectionFailedCallback: func(conn net.Conn, err error) {
 			sshLogger.Info(ctx, "ssh connection ended", slog.Error(err))
@@ -460,8 +586,10 @@ func (a *agent) init(ctx context.Context) {
 			return true
 		},
 		RequestHandlers: map[string]ssh.RequestHandler{
-			"tcpip-forward":        forwardHandler.HandleSSHRequest,
-			"cancel-tcpip-forward": forwardHandler.HandleSSHRequest,
+			"tcpip-forward":               forwardHandler.HandleSSHRequest,
+			"cancel-tcpip-forward":        forwardHandler.HandleSSHRequest,
+			streamLocalForwardRequestType: unixForwardHandler.HandleSSHRequest,
+			cancelStreamLocalForwardType:  unixForwardHandler.HandleSSHRequest,
 		},
 		ServerConfigCallback: func(ctx ssh.Context) *gossh.ServerConfig {
 			return &gossh.ServerConfig{
@@ -887,6 +1015,9 @@ func (a *agent) Close() error {
 	if a.network != nil {
 		_ = a.network.Close()
 	}
+	if a.peers != nil {
+		_ = a.peers.Close()
+	}
 	_ = a.sshServer.Close()
 	a.connCloseWait.Wait()
 	return nil
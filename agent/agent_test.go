@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/netip"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/user"
@@ -73,6 +76,19 @@ func TestAgent(t *testing.T) {
 			assert.EqualValues(t, 1, (<-stats).NumConns)
 			assert.Greater(t, (<-stats).RxBytes, int64(0))
 			assert.Greater(t, (<-stats).TxBytes, int64(0))
+			assert.EqualValues(t, 1, (<-stats).SessionCountSSH)
+
+			// Closing the connection ends the session.
+			_ = session.Close()
+			_ = sshClient.Close()
+			var s *codersdk.AgentStats
+			require.Eventuallyf(t, func() bool {
+				var ok bool
+				s, ok = (<-stats)
+				return ok && s.SessionCountSSH == 0
+			}, testutil.WaitLong, testutil.IntervalFast,
+				"never saw stats: %+v", s,
+			)
 		})
 
 		t.Run("ReconnectingPTY", func(t *testing.T) {
@@ -98,7 +114,7 @@ func TestAgent(t *testing.T) {
 			require.Eventuallyf(t, func() bool {
 				var ok bool
 				s, ok = (<-stats)
-				return ok && s.NumConns > 0 && s.RxBytes > 0 && s.TxBytes > 0
+				return ok && s.NumConns > 0 && s.RxBytes > 0 && s.TxBytes > 0 && s.SessionCountReconnectingPTY == 1
 			}, testutil.WaitLong, testutil.IntervalFast,
 				"never saw stats: %+v", s,
 			)
@@ -221,6 +237,130 @@ func TestAgent(t *testing.T) {
 		<-done
 	})
 
+	t.Run("UnixLocalForwarding", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("unix domain sockets are not fully supported on Windows")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		remoteSocketPath := filepath.Join(tempDirUnixSocket(t), "remote.sock")
+		listener, err := net.Listen("unix", remoteSocketPath)
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				testAccept(t, conn)
+			}
+		}()
+
+		agentConn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		sshClient, err := agentConn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+
+		conn, err := sshClient.Dial("unix", remoteSocketPath)
+		require.NoError(t, err)
+		defer conn.Close()
+		testDial(t, conn)
+
+		_, err = sshClient.Dial("unix", filepath.Join(filepath.Dir(remoteSocketPath), "missing.sock"))
+		require.Error(t, err)
+	})
+
+	t.Run("UnixRemoteForwarding", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("unix domain sockets are not fully supported on Windows")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The directory of the socket is created by the agent, and a stale
+		// socket is replaced.
+		remoteSocketPath := filepath.Join(tempDirUnixSocket(t), "gnupg", "S.gpg-agent")
+		require.NoError(t, os.MkdirAll(filepath.Dir(remoteSocketPath), 0o700))
+		stale, err := net.Listen("unix", remoteSocketPath)
+		require.NoError(t, err)
+		unixListener, ok := stale.(*net.UnixListener)
+		require.True(t, ok)
+		unixListener.SetUnlinkOnClose(false)
+		_ = stale.Close()
+
+		agentConn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		sshClient, err := agentConn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+
+		listener, err := sshClient.ListenUnix(remoteSocketPath)
+		require.NoError(t, err)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				testAccept(t, conn)
+			}
+		}()
+
+		conn, err := net.Dial("unix", remoteSocketPath)
+		require.NoError(t, err)
+		testDial(t, conn)
+		_ = conn.Close()
+
+		require.NoError(t, listener.Close())
+		require.Eventually(t, func() bool {
+			conn, err := net.Dial("unix", remoteSocketPath)
+			if err != nil {
+				return true
+			}
+			_ = conn.Close()
+			return false
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("UnixRemoteForwardingInUse", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("unix domain sockets are not fully supported on Windows")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// A socket something still listens on isn't replaced.
+		remoteSocketPath := filepath.Join(tempDirUnixSocket(t), "in-use.sock")
+		existing, err := net.Listen("unix", remoteSocketPath)
+		require.NoError(t, err)
+		defer existing.Close()
+		go func() {
+			for {
+				conn, err := existing.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.Close()
+			}
+		}()
+
+		agentConn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		sshClient, err := agentConn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+
+		_, err = sshClient.ListenUnix(remoteSocketPath)
+		require.Error(t, err)
+
+		conn, err := net.Dial("unix", remoteSocketPath)
+		require.NoError(t, err)
+		_ = conn.Close()
+	})
+
 	t.Run("SFTP", func(t *testing.T) {
 		t.Parallel()
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
@@ -603,6 +743,176 @@ func TestAgent(t *testing.T) {
 			return err == nil
 		}, testutil.WaitShort, testutil.IntervalFast)
 	})
+
+	t.Run("UpdatedDERPMap", func(t *testing.T) {
+		t.Parallel()
+		// The agent and the client start on different relays with direct
+		// connections disabled, so they only reach each other once the
+		// agent receives the DERP map of the client.
+		agentDERPMap := tailnettest.RunDERPOnly(t)
+		clientDERPMap := tailnettest.RunDERPOnly(t)
+		coordinator := tailnet.NewCoordinator()
+		agentID := uuid.New()
+		derpMaps := make(chan *tailcfg.DERPMap)
+		closer := agent.New(agent.Options{
+			Client: &client{
+				t:       t,
+				agentID: agentID,
+				metadata: codersdk.WorkspaceAgentMetadata{
+					DERPMap:                  agentDERPMap,
+					DisableDirectConnections: true,
+				},
+				statsChan:   make(chan *codersdk.AgentStats),
+				coordinator: coordinator,
+				derpMaps:    derpMaps,
+			},
+			Logger: slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+		conn, err := tailnet.NewConn(&tailnet.Options{
+			Addresses:      []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
+			DERPMap:        clientDERPMap,
+			Logger:         slogtest.Make(t, nil).Named("client").Leveled(slog.LevelDebug),
+			BlockEndpoints: true,
+		})
+		require.NoError(t, err)
+		clientConn, serverConn := net.Pipe()
+		t.Cleanup(func() {
+			_ = clientConn.Close()
+			_ = serverConn.Close()
+			_ = conn.Close()
+		})
+		go coordinator.ServeClient(serverConn, uuid.New(), agentID)
+		sendNode, _ := tailnet.ServeCoordinator(clientConn, func(node []*tailnet.Node) error {
+			return conn.UpdateNodes(node)
+		})
+		conn.SetNodeCallback(sendNode)
+		agentConn := &codersdk.AgentConn{Conn: conn}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the agent to watch the DERP map")
+		case derpMaps <- clientDERPMap:
+		}
+		require.Eventually(t, func() bool {
+			pingCtx, pingCancel := context.WithTimeout(ctx, testutil.IntervalMedium)
+			defer pingCancel()
+			_, err := agentConn.Ping(pingCtx)
+			return err == nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+
+	t.Run("WorkspaceNetworking", func(t *testing.T) {
+		t.Parallel()
+		derpMap := tailnettest.RunDERPAndSTUN(t)
+		coordinator := tailnet.NewCoordinator()
+		frontendID, backendID := uuid.New(), uuid.New()
+		peers := []codersdk.WorkspaceAgentPeer{{
+			WorkspaceName: "backend",
+			AgentName:     "main",
+			AgentID:       backendID,
+		}, {
+			WorkspaceName: "frontend",
+			AgentName:     "main",
+			AgentID:       frontendID,
+		}}
+		startAgent := func(agentID uuid.UUID, address, dnsAddress string) {
+			closer := agent.New(agent.Options{
+				Client: &client{
+					t:       t,
+					agentID: agentID,
+					metadata: codersdk.WorkspaceAgentMetadata{
+						DERPMap:             derpMap,
+						AgentID:             agentID,
+						WorkspaceNetworking: true,
+					},
+					statsChan:   make(chan *codersdk.AgentStats),
+					coordinator: coordinator,
+					peers:       peers,
+				},
+				Logger:                        slogtest.Make(t, nil).Named(agentID.String()).Leveled(slog.LevelDebug),
+				WorkspaceNetworkingAddress:    address,
+				WorkspaceNetworkingDNSAddress: dnsAddress,
+			})
+			t.Cleanup(func() {
+				_ = closer.Close()
+			})
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		proxyAddress := listener.Addr().String()
+		_ = listener.Close()
+		dnsConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		dnsAddress := dnsConn.LocalAddr().String()
+		_ = dnsConn.Close()
+		startAgent(frontendID, proxyAddress, dnsAddress)
+		startAgent(backendID, "127.0.0.1:0", "127.0.0.1:0")
+
+		// Both agents run on this host, so the backend forwards connections
+		// to the server.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("backend"))
+		}))
+		t.Cleanup(server.Close)
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+		transport := &http.Transport{
+			Proxy:             http.ProxyURL(&url.URL{Scheme: "socks5", Host: proxyAddress}),
+			DisableKeepAlives: true,
+		}
+		t.Cleanup(transport.CloseIdleConnections)
+		httpClient := &http.Client{Transport: transport}
+
+		require.Eventually(t, func() bool {
+			res, err := httpClient.Get("http://backend.main.coder:" + port)
+			if err != nil {
+				return false
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			return err == nil && string(body) == "backend"
+		}, testutil.WaitLong, testutil.IntervalMedium)
+
+		// The agent may be omitted for workspaces with a single agent, and
+		// unknown workspaces fail to resolve.
+		res, err := httpClient.Get("http://backend.coder:" + port)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		_, err = httpClient.Get("http://unknown.main.coder:" + port)
+		require.Error(t, err)
+
+		// The proxy only dials peers.
+		_, err = httpClient.Get(server.URL)
+		require.Error(t, err)
+
+		// Names resolve to the address of the peer, which the proxy dials
+		// too.
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "udp", dnsAddress)
+			},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		addrs, err := resolver.LookupNetIP(ctx, "ip6", "backend.main.coder")
+		require.NoError(t, err)
+		require.Equal(t, []netip.Addr{codersdk.WorkspaceAgentIP(backendID)}, addrs)
+		_, err = resolver.LookupNetIP(ctx, "ip6", "unknown.main.coder")
+		require.Error(t, err)
+		res, err = httpClient.Get("http://" + net.JoinHostPort(addrs[0].String(), port))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "backend", string(body))
+	})
 }
 
 func setupSSHCommand(t *testing.T, beforeArgs []string, afterArgs []string) *exec.Cmd {
@@ -712,6 +1022,26 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 	}, statsCh
 }
 
+// tempDirUnixSocket returns a temporary directory that can safely hold unix
+// sockets (probably).
+//
+// During tests on darwin we hit the max path length limit for unix sockets
+// pretty easily in the default location, so this function uses /tmp instead to
+// get shorter paths.
+func tempDirUnixSocket(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "darwin" {
+		testName := strings.ReplaceAll(t.Name(), "/", "_")
+		dir, err := os.MkdirTemp("/tmp", fmt.Sprintf("coder-test-%s-", testName))
+		require.NoError(t, err, "create temp dir for unix socket test")
+		t.Cleanup(func() {
+			_ = os.RemoveAll(dir)
+		})
+		return dir
+	}
+	return t.TempDir()
+}
+
 var dialTestPayload = []byte("dean-was-here123")
 
 func testDial(t *testing.T, c net.Conn) {
@@ -750,6 +1080,8 @@ type client struct {
 	statsChan          chan *codersdk.AgentStats
 	coordinator        tailnet.Coordinator
 	lastWorkspaceAgent func()
+	derpMaps           chan *tailcfg.DERPMap
+	peers              []codersdk.WorkspaceAgentPeer
 }
 
 func (c *client) WorkspaceAgentMetadata(_ context.Context) (codersdk.WorkspaceAgentMetadata, error) {
@@ -812,3 +1144,37 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (c *client) WorkspaceAgentWatchDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	maps := make(chan *tailcfg.DERPMap)
+	go func() {
+		defer close(maps)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case derpMap := <-c.derpMaps:
+				select {
+				case <-ctx.Done():
+					return
+				case maps <- derpMap:
+				}
+			}
+		}
+	}()
+	return maps, nil
+}
+
+func (c *client) WorkspaceAgentPeers(_ context.Context) ([]codersdk.WorkspaceAgentPeer, error) {
+	return c.peers, nil
+}
+
+func (c *client) DialWorkspaceAgentPeer(_ context.Context, agentID uuid.UUID) (net.Conn, error) {
+	clientConn, serverConn := net.Pipe()
+	c.t.Cleanup(func() {
+		_ = serverConn.Close()
+		_ = clientConn.Close()
+	})
+	go c.coordinator.ServeClient(serverConn, uuid.New(), agentID)
+	return clientConn, nil
+}
@@ -0,0 +1,397 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/xerrors"
+	"tailscale.com/net/socks5"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+)
+
+// peerDomain is the domain other workspaces of the owner are reachable at
+// through workspace networking, as <workspace>.<agent>.coder. The proxy and
+// serveDNS both resolve these names.
+const peerDomain = ".coder"
+
+// peersCacheTTL is how long the list of peers is reused across dials.
+// Workspaces created since are resolved once it expires.
+const peersCacheTTL = 5 * time.Second
+
+// peerNetwork lets the agent dial the agents of the other workspaces of its
+// owner at the address returned by codersdk.WorkspaceAgentIP. The agent
+// connects to them as a client through a tailnet connection of its own, since
+// connections from its main one could have codersdk.TailnetIP, which every
+// agent shares, as their source address.
+type peerNetwork struct {
+	ctx     context.Context
+	logger  slog.Logger
+	client  Client
+	network *tailnet.Conn
+
+	mutex sync.Mutex
+	// node is the last node of the connection, as sent to peers.
+	node  *tailnet.Node
+	peers map[uuid.UUID]*peerCoordination
+
+	listMutex sync.Mutex
+	list      []codersdk.WorkspaceAgentPeer
+	listedAt  time.Time
+}
+
+// peerCoordination is a connection to the coordinator for a single peer.
+type peerCoordination struct {
+	conn     net.Conn
+	sendNode func(node *tailnet.Node)
+	// sendMutex orders sends, so a stale node never replaces a newer one.
+	sendMutex sync.Mutex
+	// ready is closed once the node of the peer is known.
+	ready     chan struct{}
+	readyOnce sync.Once
+	done      chan struct{}
+}
+
+func newPeerNetwork(ctx context.Context, logger slog.Logger, client Client, derpMap *tailcfg.DERPMap, disableDirectConnections bool) (*peerNetwork, error) {
+	network, err := tailnet.NewConn(&tailnet.Options{
+		Addresses:      []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
+		DERPMap:        derpMap,
+		Logger:         logger.Named("tailnet"),
+		BlockEndpoints: disableDirectConnections,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("create tailnet: %w", err)
+	}
+	p := &peerNetwork{
+		ctx:     ctx,
+		logger:  logger,
+		client:  client,
+		network: network,
+		peers:   map[uuid.UUID]*peerCoordination{},
+	}
+	network.SetNodeCallback(p.setNode)
+	return p, nil
+}
+
+// Close closes the connections to all peers.
+func (p *peerNetwork) Close() error {
+	return p.network.Close()
+}
+
+// serve runs a SOCKS5 proxy on the listener that dials peers by name, or by
+// the address serveDNS resolves their names to. Other destinations are
+// refused, so the proxy can't be used to reach hosts through the agent.
+func (p *peerNetwork) serve(listener net.Listener) error {
+	server := &socks5.Server{
+		Logf:   tailnet.Logger(p.logger.Named("socks5")),
+		Dialer: p.dial,
+	}
+	return server.Serve(listener)
+}
+
+// serveDNS answers queries for <workspace>.<agent>.coder names on the
+// connection with the address of the peer, so tools that resolve names
+// themselves can dial peers through the proxy. Queries for other names are
+// refused, since the agent isn't a recursive resolver.
+func (p *peerNetwork) serveDNS(conn net.PacketConn) error {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		query := append([]byte(nil), buf[:n]...)
+		go func() {
+			// Resolvers give up on a query after a few seconds.
+			ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+			defer cancel()
+			response, err := p.answerDNS(ctx, query)
+			if err != nil {
+				p.logger.Debug(ctx, "answer dns query", slog.F("addr", addr.String()), slog.Error(err))
+				return
+			}
+			_, _ = conn.WriteTo(response, addr)
+		}()
+	}
+}
+
+// answerDNS builds the response to a DNS query. Names of peers only have an
+// AAAA record, since codersdk.WorkspaceAgentIP is an IPv6 address.
+func (p *peerNetwork) answerDNS(ctx context.Context, query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, xerrors.Errorf("parse header: %w", err)
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, xerrors.Errorf("parse question: %w", err)
+	}
+
+	answer, rcode := p.lookupDNS(ctx, question)
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               header.ID,
+		Response:         true,
+		Authoritative:    rcode != dnsmessage.RCodeRefused,
+		RecursionDesired: header.RecursionDesired,
+		RCode:            rcode,
+	})
+	builder.EnableCompression()
+	err = builder.StartQuestions()
+	if err != nil {
+		return nil, xerrors.Errorf("start questions: %w", err)
+	}
+	err = builder.Question(question)
+	if err != nil {
+		return nil, xerrors.Errorf("add question: %w", err)
+	}
+	if answer.IsValid() {
+		err = builder.StartAnswers()
+		if err != nil {
+			return nil, xerrors.Errorf("start answers: %w", err)
+		}
+		err = builder.AAAAResource(dnsmessage.ResourceHeader{
+			Name:  question.Name,
+			Type:  dnsmessage.TypeAAAA,
+			Class: dnsmessage.ClassINET,
+			TTL:   uint32(peersCacheTTL / time.Second),
+		}, dnsmessage.AAAAResource{
+			AAAA: answer.As16(),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("add answer: %w", err)
+		}
+	}
+	return builder.Finish()
+}
+
+// lookupDNS finds the address of the peer a question is for, if the question
+// is for an AAAA record.
+func (p *peerNetwork) lookupDNS(ctx context.Context, question dnsmessage.Question) (netip.Addr, dnsmessage.RCode) {
+	name := strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))
+	if !strings.HasSuffix(name, peerDomain) {
+		return netip.Addr{}, dnsmessage.RCodeRefused
+	}
+	_, err := p.listPeers(ctx)
+	if err != nil {
+		p.logger.Debug(ctx, "list peers for dns query", slog.Error(err))
+		return netip.Addr{}, dnsmessage.RCodeServerFailure
+	}
+	peer, err := p.resolve(ctx, strings.TrimSuffix(name, peerDomain))
+	if err != nil {
+		return netip.Addr{}, dnsmessage.RCodeNameError
+	}
+	if question.Type != dnsmessage.TypeAAAA {
+		return netip.Addr{}, dnsmessage.RCodeSuccess
+	}
+	return codersdk.WorkspaceAgentIP(peer.AgentID), dnsmessage.RCodeSuccess
+}
+
+// setNode sends the node of the connection to all peers.
+func (p *peerNetwork) setNode(node *tailnet.Node) {
+	p.mutex.Lock()
+	p.node = node
+	peers := make([]*peerCoordination, 0, len(p.peers))
+	for _, peer := range p.peers {
+		peers = append(peers, peer)
+	}
+	p.mutex.Unlock()
+
+	for _, peer := range peers {
+		p.sendNode(peer)
+	}
+}
+
+// sendNode sends the latest node of the connection to a peer, if it's known
+// yet.
+func (p *peerNetwork) sendNode(peer *peerCoordination) {
+	peer.sendMutex.Lock()
+	defer peer.sendMutex.Unlock()
+	p.mutex.Lock()
+	node := p.node
+	p.mutex.Unlock()
+	if node != nil {
+		peer.sendNode(node)
+	}
+}
+
+func (p *peerNetwork) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, rawPort, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return nil, xerrors.Errorf("parse port %q: %w", rawPort, err)
+	}
+	var peer codersdk.WorkspaceAgentPeer
+	// Clients that resolve names themselves, e.g. through serveDNS, pass
+	// the address of the peer instead.
+	if addr, err := netip.ParseAddr(host); err == nil {
+		peer, err = p.resolveAddr(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !strings.HasSuffix(host, peerDomain) {
+			return nil, xerrors.Errorf("%q isn't a workspace, only <workspace>.<agent>%s names are proxied", host, peerDomain)
+		}
+		peer, err = p.resolve(ctx, strings.TrimSuffix(host, peerDomain))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.coordinate(ctx, peer.AgentID)
+	if err != nil {
+		return nil, xerrors.Errorf("coordinate with %s.%s: %w", peer.WorkspaceName, peer.AgentName, err)
+	}
+	conn, err := p.network.DialContextTCP(ctx, netip.AddrPortFrom(codersdk.WorkspaceAgentIP(peer.AgentID), uint16(port)))
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// resolve finds the peer for a name of the form <workspace>.<agent>. The
+// agent may be omitted for workspaces with a single agent.
+func (p *peerNetwork) resolve(ctx context.Context, name string) (codersdk.WorkspaceAgentPeer, error) {
+	workspaceName, agentName, _ := strings.Cut(name, ".")
+	peers, err := p.listPeers(ctx)
+	if err != nil {
+		return codersdk.WorkspaceAgentPeer{}, err
+	}
+	var matches []codersdk.WorkspaceAgentPeer
+	for _, peer := range peers {
+		if peer.WorkspaceName != workspaceName {
+			continue
+		}
+		if agentName != "" && peer.AgentName != agentName {
+			continue
+		}
+		matches = append(matches, peer)
+	}
+	switch len(matches) {
+	case 0:
+		return codersdk.WorkspaceAgentPeer{}, xerrors.Errorf("no workspace agent found for %q", name+peerDomain)
+	case 1:
+		return matches[0], nil
+	default:
+		return codersdk.WorkspaceAgentPeer{}, xerrors.Errorf("workspace %q has multiple agents, dial <workspace>.<agent>%s", workspaceName, peerDomain)
+	}
+}
+
+// resolveAddr finds the peer with the address from
+// codersdk.WorkspaceAgentIP.
+func (p *peerNetwork) resolveAddr(ctx context.Context, addr netip.Addr) (codersdk.WorkspaceAgentPeer, error) {
+	peers, err := p.listPeers(ctx)
+	if err != nil {
+		return codersdk.WorkspaceAgentPeer{}, err
+	}
+	for _, peer := range peers {
+		if codersdk.WorkspaceAgentIP(peer.AgentID) == addr.Unmap() {
+			return peer, nil
+		}
+	}
+	return codersdk.WorkspaceAgentPeer{}, xerrors.Errorf("%s isn't a workspace, only workspace agent addresses are proxied", addr)
+}
+
+// listPeers returns the peers of the agent, listing them again once the
+// last list is older than peersCacheTTL.
+func (p *peerNetwork) listPeers(ctx context.Context) ([]codersdk.WorkspaceAgentPeer, error) {
+	p.listMutex.Lock()
+	defer p.listMutex.Unlock()
+	if p.list != nil && time.Since(p.listedAt) < peersCacheTTL {
+		return p.list, nil
+	}
+	peers, err := p.client.WorkspaceAgentPeers(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("list peers: %w", err)
+	}
+	p.list = peers
+	p.listedAt = time.Now()
+	return peers, nil
+}
+
+// coordinate connects to the coordinator as a client of the peer, if not
+// already connected, and waits until the node of the peer is known.
+func (p *peerNetwork) coordinate(ctx context.Context, agentID uuid.UUID) error {
+	p.mutex.Lock()
+	peer, ok := p.peers[agentID]
+	p.mutex.Unlock()
+	if !ok {
+		var err error
+		peer, err = p.startCoordination(agentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-peer.done:
+		return xerrors.New("coordination closed")
+	case <-peer.ready:
+		return nil
+	}
+}
+
+// startCoordination connects to the coordinator as a client of the peer. The
+// connection is dialed without holding the mutex, so a slow dial doesn't
+// block other peers. If a concurrent dial registered the peer first, its
+// coordination is used instead.
+func (p *peerNetwork) startCoordination(agentID uuid.UUID) (*peerCoordination, error) {
+	// The coordination outlives the dial, so it's bound to the agent.
+	conn, err := p.client.DialWorkspaceAgentPeer(p.ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if peer, ok := p.peers[agentID]; ok {
+		_ = conn.Close()
+		return peer, nil
+	}
+	peer := &peerCoordination{
+		conn:  conn,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	sendNode, errChan := tailnet.ServeCoordinator(conn, func(nodes []*tailnet.Node) error {
+		err := p.network.UpdateNodes(codersdk.WithoutTailnetIP(nodes))
+		peer.readyOnce.Do(func() {
+			close(peer.ready)
+		})
+		return err
+	})
+	peer.sendNode = sendNode
+	p.peers[agentID] = peer
+	go p.sendNode(peer)
+	go func() {
+		defer close(peer.done)
+		select {
+		case <-p.ctx.Done():
+		case err := <-errChan:
+			p.logger.Debug(p.ctx, "peer coordination exited", slog.F("agent_id", agentID), slog.Error(err))
+		}
+		_ = conn.Close()
+		p.mutex.Lock()
+		if p.peers[agentID] == peer {
+			delete(p.peers, agentID)
+		}
+		p.mutex.Unlock()
+	}()
+	return peer, nil
+}
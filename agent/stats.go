@@ -11,6 +11,11 @@ import (
 type statsConn struct {
 	*Stats
 	net.Conn `json:"-"`
+
+	// sessions is the session counter of Stats the connection is
+	// counted in, and is decremented once the connection is closed.
+	sessions *int64
+	closed   int32
 }
 
 var _ net.Conn = new(statsConn)
@@ -27,6 +32,13 @@ func (c *statsConn) Write(b []byte) (n int, err error) {
 	return n, err
 }
 
+func (c *statsConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		atomic.AddInt64(c.sessions, -1)
+	}
+	return c.Conn.Close()
+}
+
 var _ net.Conn = new(statsConn)
 
 // Stats records the Agent's network connection statistics for use in
@@ -36,22 +48,32 @@ type Stats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+
+	SessionCountSSH             int64 `json:"session_count_ssh"`
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	SessionCountApp             int64 `json:"session_count_app"`
 }
 
 func (s *Stats) Copy() *codersdk.AgentStats {
 	return &codersdk.AgentStats{
-		NumConns: atomic.LoadInt64(&s.NumConns),
-		RxBytes:  atomic.LoadInt64(&s.RxBytes),
-		TxBytes:  atomic.LoadInt64(&s.TxBytes),
+		NumConns:                    atomic.LoadInt64(&s.NumConns),
+		RxBytes:                     atomic.LoadInt64(&s.RxBytes),
+		TxBytes:                     atomic.LoadInt64(&s.TxBytes),
+		SessionCountSSH:             atomic.LoadInt64(&s.SessionCountSSH),
+		SessionCountReconnectingPTY: atomic.LoadInt64(&s.SessionCountReconnectingPTY),
+		SessionCountApp:             atomic.LoadInt64(&s.SessionCountApp),
 	}
 }
 
-// wrapConn returns a new connection that records statistics.
-func (s *Stats) wrapConn(conn net.Conn) net.Conn {
+// wrapConn returns a new connection that records statistics, and is counted
+// as an active session in sessions until it is closed.
+func (s *Stats) wrapConn(conn net.Conn, sessions *int64) net.Conn {
 	atomic.AddInt64(&s.NumConns, 1)
+	atomic.AddInt64(sessions, 1)
 	cs := &statsConn{
-		Stats: s,
-		Conn:  conn,
+		Stats:    s,
+		Conn:     conn,
+		sessions: sessions,
 	}
 
 	return cs
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Unix socket forwarding is an OpenSSH extension described in
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL
+const (
+	directStreamLocalChannelType    = "direct-streamlocal@openssh.com"
+	forwardedStreamLocalChannelType = "forwarded-streamlocal@openssh.com"
+	streamLocalForwardRequestType   = "streamlocal-forward@openssh.com"
+	cancelStreamLocalForwardType    = "cancel-streamlocal-forward@openssh.com"
+)
+
+// directStreamLocalChannelData is the payload of a
+// direct-streamlocal@openssh.com channel.
+type directStreamLocalChannelData struct {
+	SocketPath string
+	Reserved0  string
+	Reserved1  uint32
+}
+
+// streamLocalForwardPayload is the payload of the streamlocal-forward and
+// cancel-streamlocal-forward requests.
+type streamLocalForwardPayload struct {
+	SocketPath string
+}
+
+// forwardedStreamLocalChannelData is the payload of a
+// forwarded-streamlocal@openssh.com channel.
+type forwardedStreamLocalChannelData struct {
+	SocketPath string
+	Reserved0  string
+}
+
+// directStreamLocalHandler connects a channel opened by the client to a Unix
+// socket in the workspace, like ssh -L /local.sock:/remote.sock.
+func directStreamLocalHandler(logger slog.Logger) ssh.ChannelHandler {
+	return func(_ *ssh.Server, _ *gossh.ServerConn, newChan gossh.NewChannel, ctx ssh.Context) {
+		var data directStreamLocalChannelData
+		err := gossh.Unmarshal(newChan.ExtraData(), &data)
+		if err != nil {
+			_ = newChan.Reject(gossh.ConnectionFailed, "parse streamlocal data: "+err.Error())
+			return
+		}
+		logger.Debug(ctx, "local unix socket forward", slog.F("socket-path", data.SocketPath))
+
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "unix", data.SocketPath)
+		if err != nil {
+			_ = newChan.Reject(gossh.ConnectionFailed, err.Error())
+			return
+		}
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		go gossh.DiscardRequests(requests)
+		go Bicopy(ctx, channel, conn)
+	}
+}
+
+// forwardedUnixHandler listens on Unix sockets in the workspace and forwards
+// their connections to the client, like ssh -R /remote.sock:/local.sock. It
+// handles the streamlocal-forward and cancel-streamlocal-forward requests.
+type forwardedUnixHandler struct {
+	logger slog.Logger
+
+	mutex    sync.Mutex
+	forwards map[string]net.Listener
+}
+
+func newForwardedUnixHandler(logger slog.Logger) *forwardedUnixHandler {
+	return &forwardedUnixHandler{
+		logger:   logger,
+		forwards: map[string]net.Listener{},
+	}
+}
+
+func (h *forwardedUnixHandler) HandleSSHRequest(ctx ssh.Context, _ *ssh.Server, req *gossh.Request) (bool, []byte) {
+	conn, ok := ctx.Value(ssh.ContextKeyConn).(*gossh.ServerConn)
+	if !ok {
+		return false, nil
+	}
+	var payload streamLocalForwardPayload
+	err := gossh.Unmarshal(req.Payload, &payload)
+	if err != nil {
+		h.logger.Warn(ctx, "parse streamlocal forward payload", slog.F("type", req.Type), slog.Error(err))
+		return false, nil
+	}
+	// Listeners are keyed by connection so clients can't cancel each
+	// other's forwards.
+	key := ctx.SessionID() + ":" + payload.SocketPath
+
+	switch req.Type {
+	case streamLocalForwardRequestType:
+		h.logger.Debug(ctx, "remote unix socket forward", slog.F("socket-path", payload.SocketPath))
+		listener, err := listenUnix(payload.SocketPath)
+		if err != nil {
+			h.logger.Warn(ctx, "listen on forwarded unix socket",
+				slog.F("socket-path", payload.SocketPath), slog.Error(err))
+			return false, nil
+		}
+		h.mutex.Lock()
+		if _, exists := h.forwards[key]; exists {
+			h.mutex.Unlock()
+			_ = listener.Close()
+			return false, nil
+		}
+		h.forwards[key] = listener
+		h.mutex.Unlock()
+
+		go func() {
+			<-ctx.Done()
+			_ = listener.Close()
+		}()
+		go h.serve(ctx, conn, key, payload.SocketPath, listener)
+		return true, nil
+
+	case cancelStreamLocalForwardType:
+		h.mutex.Lock()
+		listener, ok := h.forwards[key]
+		h.mutex.Unlock()
+		if !ok {
+			return false, nil
+		}
+		_ = listener.Close()
+		return true, nil
+
+	default:
+		return false, nil
+	}
+}
+
+func (h *forwardedUnixHandler) serve(ctx context.Context, conn *gossh.ServerConn, key, socketPath string, listener net.Listener) {
+	defer func() {
+		h.mutex.Lock()
+		delete(h.forwards, key)
+		h.mutex.Unlock()
+		_ = listener.Close()
+	}()
+	payload := gossh.Marshal(&forwardedStreamLocalChannelData{
+		SocketPath: socketPath,
+	})
+	for {
+		unixConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			channel, requests, err := conn.OpenChannel(forwardedStreamLocalChannelType, payload)
+			if err != nil {
+				h.logger.Debug(ctx, "open forwarded unix socket channel",
+					slog.F("socket-path", socketPath), slog.Error(err))
+				_ = unixConn.Close()
+				return
+			}
+			go gossh.DiscardRequests(requests)
+			Bicopy(ctx, channel, unixConn)
+		}()
+	}
+}
+
+// listenUnix listens on a Unix socket, creating its directory if necessary.
+// Stale sockets left behind by processes that exited, like a stopped
+// gpg-agent, are replaced. Sockets that something still listens on are
+// never removed.
+func listenUnix(path string) (net.Listener, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, xerrors.Errorf("create socket directory: %w", err)
+	}
+	info, err := os.Lstat(path)
+	if err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, xerrors.Errorf("%q exists and is not a socket", path)
+		}
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil, xerrors.Errorf("%q is in use", path)
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return nil, xerrors.Errorf("remove existing socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, xerrors.Errorf("listen: %w", err)
+	}
+	return listener, nil
+}
@@ -27,9 +27,11 @@ import (
 
 func workspaceAgent() *cobra.Command {
 	var (
-		auth         string
-		pprofAddress string
-		noReap       bool
+		auth                          string
+		pprofAddress                  string
+		noReap                        bool
+		workspaceNetworkingAddress    string
+		workspaceNetworkingDNSAddress string
 	)
 	cmd := &cobra.Command{
 		Use: "agent",
@@ -165,6 +167,8 @@ func workspaceAgent() *cobra.Command {
 				EnvironmentVariables: map[string]string{
 					"GIT_ASKPASS": executablePath,
 				},
+				WorkspaceNetworkingAddress:    workspaceNetworkingAddress,
+				WorkspaceNetworkingDNSAddress: workspaceNetworkingDNSAddress,
 			})
 			<-cmd.Context().Done()
 			return closer.Close()
@@ -174,5 +178,7 @@ func workspaceAgent() *cobra.Command {
 	cliflag.StringVarP(cmd.Flags(), &auth, "auth", "", "CODER_AGENT_AUTH", "token", "Specify the authentication type to use for the agent")
 	cliflag.BoolVarP(cmd.Flags(), &noReap, "no-reap", "", "", false, "Do not start a process reaper.")
 	cliflag.StringVarP(cmd.Flags(), &pprofAddress, "pprof-address", "", "CODER_AGENT_PPROF_ADDRESS", "127.0.0.1:6060", "The address to serve pprof.")
+	cliflag.StringVarP(cmd.Flags(), &workspaceNetworkingAddress, "workspace-networking-address", "", "CODER_AGENT_WORKSPACE_NETWORKING_ADDRESS", "127.0.0.1:1080", "The address to serve the SOCKS5 proxy that reaches other workspaces, when the template allows workspace networking.")
+	cliflag.StringVarP(cmd.Flags(), &workspaceNetworkingDNSAddress, "workspace-networking-dns-address", "", "CODER_AGENT_WORKSPACE_NETWORKING_DNS_ADDRESS", "127.0.0.1:53", "The UDP address to serve DNS for the names of other workspaces, when the template allows workspace networking.")
 	return cmd
 }
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+// agentConnPathInterval is how often the path of a connection is checked
+// for changes with --verbose.
+var agentConnPathInterval = 5 * time.Second
+
+// dialWorkspaceAgent connects to an agent, respecting the global flags. With
+// --verbose, the path of the connection is written to stderr whenever it
+// changes until ctx is canceled.
+func dialWorkspaceAgent(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, agentID uuid.UUID, options *codersdk.DialWorkspaceAgentOptions) (*codersdk.AgentConn, error) {
+	if options == nil {
+		options = &codersdk.DialWorkspaceAgentOptions{}
+	}
+	if cliflag.IsSetBool(cmd, varDisableDirect) {
+		options.BlockEndpoints = true
+	}
+	conn, err := client.DialWorkspaceAgent(ctx, agentID, options)
+	if err != nil {
+		return nil, err
+	}
+	if cliflag.IsSetBool(cmd, varVerbose) {
+		go watchAgentConnPath(ctx, cmd.ErrOrStderr(), conn)
+	}
+	return conn, nil
+}
+
+// watchAgentConnPath writes the path of the connection whenever it changes,
+// e.g. when a relayed connection becomes direct.
+func watchAgentConnPath(ctx context.Context, w io.Writer, conn *codersdk.AgentConn) {
+	if conn.BlockEndpoints() {
+		_, _ = fmt.Fprintln(w, cliui.Styles.Placeholder.Render("Direct connections are disabled, traffic is relayed through DERP."))
+	}
+	var (
+		last  codersdk.AgentConnPath
+		known bool
+	)
+	for {
+		// The agent isn't a peer until the coordinator sent its node, and
+		// pings sent before then are never answered. Retry quickly until
+		// the first ping succeeds.
+		wait := agentConnPathInterval
+		if !known {
+			wait = 250 * time.Millisecond
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, wait)
+		path, err := conn.Path(pingCtx)
+		cancel()
+		if err == nil && (!known || path.Endpoint != last.Endpoint || path.DERPRegionID != last.DERPRegionID) {
+			last, known = path, true
+			_, _ = fmt.Fprintln(w, cliui.Styles.Placeholder.Render("Connected to the workspace agent "+path.String()+"."))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func autoupdate() *cobra.Command {
+	return &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "autoupdate <workspace> { enable | disable }",
+		Args:        cobra.ExactArgs(2),
+		Short:       "Update a workspace to the active template version whenever it's started",
+		Example: formatExamples(
+			example{
+				Command: "coder autoupdate my-workspace enable",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var enable bool
+			switch args[1] {
+			case "enable":
+				enable = true
+			case "disable":
+			default:
+				return xerrors.Errorf("invalid option %q, must be \"enable\" or \"disable\"", args[1])
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			err = client.UpdateWorkspaceAutomaticUpdates(cmd.Context(), workspace.ID, codersdk.UpdateWorkspaceAutomaticUpdatesRequest{
+				AutomaticUpdates: enable,
+			})
+			if err != nil {
+				return err
+			}
+
+			if enable {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Workspace %q will be updated to the active template version whenever it's started.\n", workspace.Name)
+			} else {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Workspace %q will keep its template version when it's started.\n", workspace.Name)
+			}
+			return nil
+		},
+	}
+}
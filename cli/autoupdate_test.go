@@ -0,0 +1,46 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+)
+
+func TestAutoUpdate(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	require.False(t, workspace.AutomaticUpdates)
+
+	for _, enable := range []bool{true, false} {
+		option := "disable"
+		if enable {
+			option = "enable"
+		}
+		cmd, root := clitest.New(t, "autoupdate", workspace.Name, option)
+		clitest.SetupConfig(t, client, root)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, stdout.String(), workspace.Name)
+
+		updated, err := client.Workspace(context.Background(), workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, enable, updated.AutomaticUpdates)
+	}
+
+	cmd, root := clitest.New(t, "autoupdate", workspace.Name, "sometimes")
+	clitest.SetupConfig(t, client, root)
+	require.Error(t, cmd.Execute())
+}
@@ -22,6 +22,7 @@ func create() *cobra.Command {
 		startAt       string
 		stopAfter     time.Duration
 		workspaceName string
+		copyFrom      string
 	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
@@ -63,6 +64,10 @@ func create() *cobra.Command {
 				return xerrors.Errorf("A workspace already exists named %q!", workspaceName)
 			}
 
+			if copyFrom != "" {
+				return cloneWorkspace(cmd, client, copyFrom, workspaceName, templateName, parameterFile, startAt, stopAfter)
+			}
+
 			var template codersdk.Template
 			if templateName == "" {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Wrap.Render("Select a template below to preview the provisioned infrastructure:"))
@@ -165,9 +170,60 @@ func create() *cobra.Command {
 	cliflag.StringVarP(cmd.Flags(), &parameterFile, "parameter-file", "", "CODER_PARAMETER_FILE", "", "Specify a file path with parameter values.")
 	cliflag.StringVarP(cmd.Flags(), &startAt, "start-at", "", "CODER_WORKSPACE_START_AT", "", "Specify the workspace autostart schedule. Check `coder schedule start --help` for the syntax.")
 	cliflag.DurationVarP(cmd.Flags(), &stopAfter, "stop-after", "", "CODER_WORKSPACE_STOP_AFTER", 8*time.Hour, "Specify a duration after which the workspace should shut down (e.g. 8h).")
+	cmd.Flags().StringVar(&copyFrom, "copy-from", "", "Create the workspace with the template version, parameters and schedule of an existing workspace.")
 	return cmd
 }
 
+// cloneWorkspace creates a workspace from an existing one. The schedule of
+// the source workspace is kept unless --start-at or --stop-after is set.
+func cloneWorkspace(cmd *cobra.Command, client *codersdk.Client, source, workspaceName, templateName, parameterFile, startAt string, stopAfter time.Duration) error {
+	if templateName != "" {
+		return xerrors.New("--template can't be used with --copy-from")
+	}
+	if parameterFile != "" {
+		return xerrors.New("--parameter-file can't be used with --copy-from")
+	}
+	sourceWorkspace, err := namedWorkspace(cmd, client, source)
+	if err != nil {
+		return err
+	}
+
+	req := codersdk.CloneWorkspaceRequest{
+		Name: workspaceName,
+	}
+	if cmd.Flags().Changed("start-at") {
+		sched, err := parseCLISchedule(startAt)
+		if err != nil {
+			return err
+		}
+		req.AutostartSchedule = ptr.Ref(sched.String())
+	}
+	if cmd.Flags().Changed("stop-after") {
+		req.TTLMillis = ptr.Ref(stopAfter.Milliseconds())
+	}
+
+	_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+		Text:      fmt.Sprintf("Confirm create a copy of %s?", cliui.Styles.Keyword.Render(sourceWorkspace.Name)),
+		IsConfirm: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	workspace, err := client.CloneWorkspace(cmd.Context(), sourceWorkspace.ID, req)
+	if err != nil {
+		return err
+	}
+
+	err = cliui.WorkspaceBuild(cmd.Context(), cmd.OutOrStdout(), client, workspace.LatestBuild.ID)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nThe %s workspace has been created from %s at %s!\n", cliui.Styles.Keyword.Render(workspace.Name), cliui.Styles.Keyword.Render(sourceWorkspace.Name), cliui.Styles.DateTimeStamp.Render(time.Now().Format(time.Stamp)))
+	return nil
+}
+
 type prepWorkspaceBuildArgs struct {
 	Template         codersdk.Template
 	ExistingParams   []codersdk.Parameter
@@ -12,6 +12,7 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
@@ -78,6 +79,32 @@ func TestCreate(t *testing.T) {
 		}
 	})
 
+	t.Run("CopyFrom", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		source := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, source.LatestBuild.ID)
+
+		cmd, root := clitest.New(t, "create", "my-copy", "--copy-from", source.Name, "--stop-after", "2h", "-y")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+
+		ws, err := client.WorkspaceByOwnerAndName(context.Background(), codersdk.Me, "my-copy", codersdk.WorkspaceOptions{})
+		require.NoError(t, err)
+		require.Equal(t, source.LatestBuild.TemplateVersionID, ws.LatestBuild.TemplateVersionID)
+		require.Equal(t, source.AutostartSchedule, ws.AutostartSchedule)
+		require.Equal(t, ptr.Ref(2*time.Hour.Milliseconds()), ws.TTLMillis)
+
+		cmd, root = clitest.New(t, "create", "other-copy", "--copy-from", source.Name, "--template", template.Name, "-y")
+		clitest.SetupConfig(t, client, root)
+		require.Error(t, cmd.Execute())
+	})
+
 	t.Run("CreateFromListWithSkip", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
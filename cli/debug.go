@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func debug() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Inspect the internal state of the deployment",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(debugCoordinator())
+	return cmd
+}
+
+func debugCoordinator() *cobra.Command {
+	return &cobra.Command{
+		Use:   "coordinator [workspace]",
+		Short: "Show the agents and clients connected to the tailnet coordinator",
+		Long: "Shows the agents and clients connected to the tailnet coordinator of the replica serving the request, " +
+			"and how they may be reached. Only owners may use this command.",
+		Args: cobra.MaximumNArgs(1),
+		Example: formatExamples(
+			example{
+				Description: "Check whether the agents of a workspace registered with the coordinator",
+				Command:     "coder debug coordinator alice/dev",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			var workspace *codersdk.Workspace
+			if len(args) == 1 {
+				found, err := namedWorkspace(cmd, client, args[0])
+				if err != nil {
+					return xerrors.Errorf("get workspace: %w", err)
+				}
+				workspace = &found
+			}
+			info, err := client.DebugCoordinator(cmd.Context())
+			if err != nil {
+				return xerrors.Errorf("get coordinator debug info: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if info.ReplicaID == uuid.Nil {
+				_, _ = fmt.Fprintln(out, "Coordinator: single replica")
+			} else {
+				_, _ = fmt.Fprintf(out, "Coordinator: replica %s\n", info.ReplicaID)
+			}
+			shown := 0
+			for _, agent := range info.Agents {
+				if workspace != nil && agent.WorkspaceID != workspace.ID {
+					continue
+				}
+				shown++
+				_, _ = fmt.Fprintln(out)
+				writeDebugAgent(out, agent)
+			}
+			if shown == 0 {
+				_, _ = fmt.Fprintln(out)
+				_, _ = fmt.Fprintln(out, "No agents are known to the coordinator.")
+			}
+			return nil
+		},
+	}
+}
+
+func writeDebugAgent(out io.Writer, agent codersdk.DebugCoordinatorAgent) {
+	name := "unknown workspace"
+	if agent.WorkspaceName != "" {
+		name = fmt.Sprintf("%s/%s.%s", agent.OwnerName, agent.WorkspaceName, agent.Name)
+	}
+	_, _ = fmt.Fprintf(out, "%s %s (%s)\n", cliui.Styles.Bold.Render("Agent"), cliui.Styles.Keyword.Render(name), agent.ID)
+	switch {
+	case agent.Connected:
+		_, _ = fmt.Fprintln(out, "\t* Connected: to this replica")
+	case agent.ReplicaID != uuid.Nil:
+		_, _ = fmt.Fprintf(out, "\t* Connected: to replica %s\n", agent.ReplicaID)
+	default:
+		_, _ = fmt.Fprintln(out, "\t* Connected: no")
+	}
+	writeDebugNode(out, "\t", agent.Node)
+	_, _ = fmt.Fprintf(out, "\t* Clients: %d\n", len(agent.Clients))
+	for _, client := range agent.Clients {
+		_, _ = fmt.Fprintf(out, "\t\t- %s\n", client.ID)
+		writeDebugNode(out, "\t\t  ", client.Node)
+	}
+}
+
+// writeDebugNode writes a node in the style of "tailscale netcheck".
+func writeDebugNode(out io.Writer, indent string, node *codersdk.DebugNode) {
+	if node == nil {
+		_, _ = fmt.Fprintf(out, "%s* Node: not registered\n", indent)
+		return
+	}
+	_, _ = fmt.Fprintf(out, "%s* Nearest DERP: %d\n", indent, node.PreferredDERP)
+	regions := make([]string, 0, len(node.DERPLatency))
+	for region := range node.DERPLatency {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return node.DERPLatency[regions[i]] < node.DERPLatency[regions[j]]
+	})
+	_, _ = fmt.Fprintf(out, "%s* DERP latency:\n", indent)
+	for _, region := range regions {
+		latency := time.Duration(node.DERPLatency[region] * float64(time.Second))
+		_, _ = fmt.Fprintf(out, "%s\t- %s: %s\n", indent, region, latency.Round(100*time.Microsecond))
+	}
+	endpoints := "none, only reachable through DERP"
+	if len(node.Endpoints) > 0 {
+		endpoints = strings.Join(node.Endpoints, ", ")
+	}
+	_, _ = fmt.Fprintf(out, "%s* Endpoints: %s\n", indent, endpoints)
+	_, _ = fmt.Fprintf(out, "%s* Addresses: %s\n", indent, strings.Join(node.Addresses, ", "))
+	_, _ = fmt.Fprintf(out, "%s* As of: %s ago\n", indent, time.Since(node.AsOf).Round(time.Second))
+}
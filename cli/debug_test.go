@@ -0,0 +1,40 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+)
+
+func TestDebugCoordinator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Owner", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "debug", "coordinator")
+		clitest.SetupConfig(t, client, root)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, stdout.String(), "Coordinator: single replica")
+		require.Contains(t, stdout.String(), "No agents are known to the coordinator.")
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		cmd, root := clitest.New(t, "debug", "coordinator")
+		clitest.SetupConfig(t, member, root)
+		require.Error(t, cmd.Execute())
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/codersdk"
@@ -12,15 +13,33 @@ import (
 
 // nolint
 func deleteWorkspace() *cobra.Command {
-	var orphan bool
+	var (
+		orphan bool
+		batch  workspaceBatchFlags
+	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "delete <workspace>",
 		Short:       "Delete a workspace",
 		Aliases:     []string{"rm"},
-		Args:        cobra.ExactArgs(1),
+		Args:        cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := cliui.Prompt(cmd, cliui.PromptOptions{
+			isBatch, err := batch.args(args)
+			if err != nil {
+				return err
+			}
+			if isBatch {
+				if orphan {
+					return xerrors.New("--orphan can't be used with --search")
+				}
+				client, err := CreateClient(cmd)
+				if err != nil {
+					return err
+				}
+				return batch.run(cmd, client, codersdk.WorkspaceBatchActionDelete)
+			}
+
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
 				Text:      "Confirm delete workspace?",
 				IsConfirm: true,
 				Default:   cliui.ConfirmNo,
@@ -69,6 +88,7 @@ func deleteWorkspace() *cobra.Command {
 		`Delete a workspace without deleting its resources. This can delete a
 workspace in a broken state, but may also lead to unaccounted cloud resources.`,
 	)
+	batch.attach(cmd)
 	cliui.AllowSkipPrompt(cmd)
 	return cmd
 }
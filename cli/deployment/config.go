@@ -95,6 +95,11 @@ func newConfig() *codersdk.DeploymentConfig {
 					Usage: "Path to read a DERP mapping from. See: https://tailscale.com/kb/1118/custom-derp-servers/",
 					Flag:  "derp-config-path",
 				},
+				BlockDirect: &codersdk.DeploymentConfigField[bool]{
+					Name:  "DERP Config Block Direct",
+					Usage: "Block direct (peer-to-peer) connections between clients and workspaces, so all traffic is relayed through DERP. Use on networks that forbid peer-to-peer traffic.",
+					Flag:  "block-direct-connections",
+				},
 			},
 		},
 		GitAuth: &codersdk.DeploymentConfigField[[]codersdk.GitAuthConfig]{
@@ -157,6 +162,20 @@ func newConfig() *codersdk.DeploymentConfig {
 			Flag:   "postgres-url",
 			Secret: true,
 		},
+		Pubsub: &codersdk.PubsubConfig{
+			Backend: &codersdk.DeploymentConfigField[string]{
+				Name:    "Pubsub Backend",
+				Usage:   "The message broker replicas use to notify each other of changes. \"postgres\" uses LISTEN/NOTIFY of the database, \"redis\" uses the server at pubsub redis url, and \"memory\" only delivers messages within this replica. Ignored with the in-memory database.",
+				Flag:    "pubsub-backend",
+				Default: "postgres",
+			},
+			RedisURL: &codersdk.DeploymentConfigField[string]{
+				Name:   "Pubsub Redis URL",
+				Usage:  "URL of a server that speaks the Redis protocol, e.g. redis://:password@localhost:6379. Use rediss:// to connect with TLS.",
+				Flag:   "pubsub-redis-url",
+				Secret: true,
+			},
+		},
 		OAuth2: &codersdk.OAuth2Config{
 			Github: &codersdk.OAuth2GithubConfig{
 				ClientID: &codersdk.DeploymentConfigField[string]{
@@ -227,6 +246,112 @@ func newConfig() *codersdk.DeploymentConfig {
 				Default: []string{oidc.ScopeOpenID, "profile", "email"},
 			},
 		},
+		LDAP: &codersdk.LDAPConfig{
+			URL: &codersdk.DeploymentConfigField[string]{
+				Name:  "LDAP URL",
+				Usage: "URL of the LDAP directory to use for Login with LDAP, e.g. \"ldaps://ldap.example.com:636\".",
+				Flag:  "ldap-url",
+			},
+			StartTLS: &codersdk.DeploymentConfigField[bool]{
+				Name:  "LDAP StartTLS",
+				Usage: "Whether to upgrade \"ldap://\" connections to TLS with StartTLS.",
+				Flag:  "ldap-start-tls",
+			},
+			InsecureSkipVerify: &codersdk.DeploymentConfigField[bool]{
+				Name:  "LDAP Insecure Skip Verify",
+				Usage: "Skip verification of the LDAP server certificate.",
+				Flag:  "ldap-insecure-skip-verify",
+			},
+			BindDN: &codersdk.DeploymentConfigField[string]{
+				Name:  "LDAP Bind DN",
+				Usage: "Distinguished name of the service account used to search for users.",
+				Flag:  "ldap-bind-dn",
+			},
+			BindPassword: &codersdk.DeploymentConfigField[string]{
+				Name:   "LDAP Bind Password",
+				Usage:  "Password of the service account used to search for users.",
+				Flag:   "ldap-bind-password",
+				Secret: true,
+			},
+			SearchBaseDN: &codersdk.DeploymentConfigField[string]{
+				Name:  "LDAP Search Base DN",
+				Usage: "Base distinguished name to search for users in.",
+				Flag:  "ldap-search-base-dn",
+			},
+			SearchFilter: &codersdk.DeploymentConfigField[string]{
+				Name:    "LDAP Search Filter",
+				Usage:   "Filter used to find the user logging in. Every \"%s\" is replaced with the email they entered.",
+				Flag:    "ldap-search-filter",
+				Default: "(mail=%s)",
+			},
+			UsernameAttribute: &codersdk.DeploymentConfigField[string]{
+				Name:    "LDAP Username Attribute",
+				Usage:   "Attribute of the user entry to use as their username.",
+				Flag:    "ldap-username-attribute",
+				Default: "uid",
+			},
+			EmailAttribute: &codersdk.DeploymentConfigField[string]{
+				Name:    "LDAP Email Attribute",
+				Usage:   "Attribute of the user entry to use as their email.",
+				Flag:    "ldap-email-attribute",
+				Default: "mail",
+			},
+			GroupAttribute: &codersdk.DeploymentConfigField[string]{
+				Name:    "LDAP Group Attribute",
+				Usage:   "Attribute of the user entry that lists the groups they are a member of.",
+				Flag:    "ldap-group-attribute",
+				Default: "memberOf",
+			},
+			GroupSync: &codersdk.DeploymentConfigField[bool]{
+				Name:  "LDAP Group Sync",
+				Usage: "Whether to sync the LDAP groups of a user to Coder groups with the same name on login.",
+				Flag:  "ldap-group-sync",
+			},
+			AllowSignups: &codersdk.DeploymentConfigField[bool]{
+				Name:    "LDAP Allow Signups",
+				Usage:   "Whether new users can sign up with LDAP.",
+				Flag:    "ldap-allow-signups",
+				Default: true,
+			},
+		},
+		SMTP: &codersdk.SMTPConfig{
+			Address: &codersdk.DeploymentConfigField[string]{
+				Name:  "SMTP Address",
+				Usage: "Address of the SMTP relay used to send invitation, password reset and notification emails, e.g. \"smtp.example.com:587\". Emails aren't sent when unset.",
+				Flag:  "smtp-address",
+			},
+			From: &codersdk.DeploymentConfigField[string]{
+				Name:  "SMTP From",
+				Usage: "Address emails are sent from, e.g. \"Coder <coder@example.com>\".",
+				Flag:  "smtp-from",
+			},
+			Username: &codersdk.DeploymentConfigField[string]{
+				Name:  "SMTP Username",
+				Usage: "Username to authenticate with the SMTP relay.",
+				Flag:  "smtp-username",
+			},
+			Password: &codersdk.DeploymentConfigField[string]{
+				Name:   "SMTP Password",
+				Usage:  "Password to authenticate with the SMTP relay.",
+				Flag:   "smtp-password",
+				Secret: true,
+			},
+			ForceTLS: &codersdk.DeploymentConfigField[bool]{
+				Name:  "SMTP Force TLS",
+				Usage: "Connect to the SMTP relay with implicit TLS instead of upgrading the connection with STARTTLS.",
+				Flag:  "smtp-force-tls",
+			},
+			InsecureSkipVerify: &codersdk.DeploymentConfigField[bool]{
+				Name:  "SMTP Insecure Skip Verify",
+				Usage: "Skip verification of the SMTP relay certificate.",
+				Flag:  "smtp-insecure-skip-verify",
+			},
+		},
+		NotificationWebhookURL: &codersdk.DeploymentConfigField[string]{
+			Name:  "Notification Webhook URL",
+			Usage: "URL that receives a JSON POST request for every notification sent to users, e.g. when a workspace is about to stop or fails to build.",
+			Flag:  "notification-webhook-url",
+		},
 
 		Telemetry: &codersdk.TelemetryConfig{
 			Enable: &codersdk.DeploymentConfigField[bool]{
@@ -306,6 +431,28 @@ func newConfig() *codersdk.DeploymentConfig {
 			Usage: "Controls if the 'Secure' property is set on browser session cookies.",
 			Flag:  "secure-auth-cookie",
 		},
+		TwoFactorRequired: &codersdk.DeploymentConfigField[bool]{
+			Name:  "Two-Factor Required",
+			Usage: "Require users that log in with a password to enroll in two-factor authentication. Users that haven't enrolled are only able to enroll until they do.",
+			Flag:  "two-factor-required",
+		},
+		UserInvitationLifetime: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:    "User Invitation Lifetime",
+			Usage:   "How long the signup tokens sent in user invitation emails are valid for.",
+			Flag:    "user-invitation-lifetime",
+			Default: 72 * time.Hour,
+		},
+		PasswordResetLifetime: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:    "Password Reset Lifetime",
+			Usage:   "How long the codes sent in password reset emails are valid for.",
+			Flag:    "password-reset-lifetime",
+			Default: time.Hour,
+		},
+		MaxTokenLifetime: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:  "Max Token Lifetime",
+			Usage: "The maximum lifetime of API tokens and login sessions. Longer lifetimes are shortened to it. There is no maximum when unset.",
+			Flag:  "max-token-lifetime",
+		},
 		SSHKeygenAlgorithm: &codersdk.DeploymentConfigField[string]{
 			Name:    "SSH Keygen Algorithm",
 			Usage:   "The algorithm to use for generating ssh keys. Accepted values are \"ed25519\", \"ecdsa\", or \"rsa4096\".",
@@ -332,6 +479,11 @@ func newConfig() *codersdk.DeploymentConfig {
 			Hidden:  true,
 			Default: 10 * time.Minute,
 		},
+		AgentConnectionCacheSize: &codersdk.DeploymentConfigField[int]{
+			Name:  "Agent Connection Cache Size",
+			Usage: "The maximum number of workspace agent connections each replica keeps open to proxy apps and terminals. The least recently used idle connections are closed once it's exceeded. There is no limit when unset.",
+			Flag:  "agent-connection-cache-size",
+		},
 		AuditLogging: &codersdk.DeploymentConfigField[bool]{
 			Name:       "Audit Logging",
 			Usage:      "Specifies whether audit logging is enabled.",
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func forgotPassword() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forgot-password <url>",
+		Short: "Reset your password with a code sent to your email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isTTY(cmd) {
+				return xerrors.New("passwords cannot be reset in non-interactive mode. use the API")
+			}
+			serverURL, err := parseServerURL(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := createUnauthenticatedClient(cmd, serverURL)
+			if err != nil {
+				return err
+			}
+
+			email, err := cliui.Prompt(cmd, cliui.PromptOptions{
+				Text: "What's your " + cliui.Styles.Field.Render("email") + "?",
+				Validate: func(s string) error {
+					err := validator.New().Var(s, "email")
+					if err != nil {
+						return xerrors.New("That's not a valid email address!")
+					}
+					return err
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("specify email prompt: %w", err)
+			}
+			err = client.ForgotPassword(cmd.Context(), codersdk.ForgotPasswordRequest{
+				Email: email,
+			})
+			if err != nil {
+				return xerrors.Errorf("request password reset: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), Caret+"If an account exists for %s, a password reset code has been sent to it.\n", cliui.Styles.Keyword.Render(email))
+
+			code, err := cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:     "Enter the code from the email:",
+				Validate: cliui.ValidateNotEmpty,
+			})
+			if err != nil {
+				return xerrors.Errorf("reset code prompt: %w", err)
+			}
+			password, err := promptNewPassword(cmd)
+			if err != nil {
+				return err
+			}
+			err = client.ResetPassword(cmd.Context(), codersdk.ResetPasswordRequest{
+				Token:    code,
+				Password: password,
+			})
+			if err != nil {
+				return xerrors.Errorf("reset password: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), Caret+"Your password has been reset! Run %s to authenticate.\n", cliui.Styles.Code.Render("coder login "+serverURL.String()))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,77 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	srv := mailertest.New(t)
+	client := coderdtest.New(t, &coderdtest.Options{
+		Mailer: &mailer.SMTP{
+			Addr: srv.Addr,
+			From: "coder@coder.com",
+		},
+	})
+	coderdtest.CreateFirstUser(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	doneChan := make(chan struct{})
+	cmd, _ := clitest.New(t, "forgot-password", "--force-tty", client.URL.String())
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	go func() {
+		defer close(doneChan)
+		err := cmd.ExecuteContext(ctx)
+		assert.NoError(t, err)
+	}()
+	pty.ExpectMatch("email")
+	pty.WriteLine(coderdtest.FirstUserParams.Email)
+	pty.ExpectMatch("code from the email")
+
+	var token string
+	require.Eventually(t, func() bool {
+		messages := srv.Messages()
+		if len(messages) == 0 {
+			return false
+		}
+		token = emailTokenRegex.FindString(messages[0].Body)
+		return true
+	}, testutil.WaitShort, testutil.IntervalFast)
+	require.NotEmpty(t, token)
+
+	pty.WriteLine(token)
+
+	matches := []string{
+		"password", "MyNewSecurePassword!",
+		"password", "MyNewSecurePassword!", // Confirm.
+	}
+	for i := 0; i < len(matches); i += 2 {
+		pty.ExpectMatch(matches[i])
+		pty.WriteLine(matches[i+1])
+	}
+	pty.ExpectMatch("Your password has been reset")
+	<-doneChan
+
+	_, err := codersdk.New(client.URL).LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+		Email:    coderdtest.FirstUserParams.Email,
+		Password: "MyNewSecurePassword!",
+	})
+	require.NoError(t, err)
+}
@@ -39,31 +39,19 @@ func init() {
 
 func login() *cobra.Command {
 	var (
-		email    string
-		username string
-		password string
+		email      string
+		username   string
+		password   string
+		invitation string
 	)
 	cmd := &cobra.Command{
 		Use:   "login <url>",
 		Short: "Authenticate with Coder deployment",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			rawURL := args[0]
-
-			if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
-				scheme := "https"
-				if strings.HasPrefix(rawURL, "localhost") {
-					scheme = "http"
-				}
-				rawURL = fmt.Sprintf("%s://%s", scheme, rawURL)
-			}
-			serverURL, err := url.Parse(rawURL)
+			serverURL, err := parseServerURL(args[0])
 			if err != nil {
-				return xerrors.Errorf("parse raw url %q: %w", rawURL, err)
-			}
-			// Default to HTTPs. Enables simple URLs like: master.cdr.dev
-			if serverURL.Scheme == "" {
-				serverURL.Scheme = "https"
+				return err
 			}
 
 			client, err := createUnauthenticatedClient(cmd, serverURL)
@@ -81,6 +69,10 @@ func login() *cobra.Command {
 				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), cliui.Styles.Warn.Render(err.Error()))
 			}
 
+			if invitation != "" {
+				return acceptInvitation(cmd, client, serverURL, invitation)
+			}
+
 			hasInitialUser, err := client.HasFirstUser(cmd.Context())
 			if err != nil {
 				return xerrors.Errorf("Failed to check server %q for first user, is the URL correct and is coder accessible from your browser? Error - has initial user: %w", serverURL.String(), err)
@@ -136,29 +128,9 @@ func login() *cobra.Command {
 				}
 
 				if password == "" {
-					var matching bool
-
-					for !matching {
-						password, err = cliui.Prompt(cmd, cliui.PromptOptions{
-							Text:     "Enter a " + cliui.Styles.Field.Render("password") + ":",
-							Secret:   true,
-							Validate: cliui.ValidateNotEmpty,
-						})
-						if err != nil {
-							return xerrors.Errorf("specify password prompt: %w", err)
-						}
-						confirm, err := cliui.Prompt(cmd, cliui.PromptOptions{
-							Text:   "Confirm " + cliui.Styles.Field.Render("password") + ":",
-							Secret: true,
-						})
-						if err != nil {
-							return xerrors.Errorf("confirm password prompt: %w", err)
-						}
-
-						matching = confirm == password
-						if !matching {
-							_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Error.Render("Passwords do not match"))
-						}
+					password, err = promptNewPassword(cmd)
+					if err != nil {
+						return err
 					}
 				}
 
@@ -178,6 +150,22 @@ func login() *cobra.Command {
 				if err != nil {
 					return xerrors.Errorf("login with password: %w", err)
 				}
+				if resp.TwoFactorEnrollmentRequired {
+					client.SetSessionToken(resp.SessionToken)
+					err := enrollTwoFactor(cmd, client)
+					if err != nil {
+						return xerrors.Errorf("enroll in two-factor authentication: %w", err)
+					}
+					// The enrollment session can't be used for anything else, so
+					// login again with the next code.
+					resp, err = loginWithTwoFactor(cmd, client, codersdk.LoginWithPasswordRequest{
+						Email:    email,
+						Password: password,
+					})
+					if err != nil {
+						return xerrors.Errorf("login with password: %w", err)
+					}
+				}
 
 				sessionToken := resp.SessionToken
 				config := createConfig(cmd)
@@ -251,9 +239,174 @@ func login() *cobra.Command {
 	cliflag.StringVarP(cmd.Flags(), &email, "first-user-email", "", "CODER_FIRST_USER_EMAIL", "", "Specifies an email address to use if creating the first user for the deployment.")
 	cliflag.StringVarP(cmd.Flags(), &username, "first-user-username", "", "CODER_FIRST_USER_USERNAME", "", "Specifies a username to use if creating the first user for the deployment.")
 	cliflag.StringVarP(cmd.Flags(), &password, "first-user-password", "", "CODER_FIRST_USER_PASSWORD", "", "Specifies a password to use if creating the first user for the deployment.")
+	cliflag.StringVarP(cmd.Flags(), &invitation, "invitation", "", "CODER_INVITATION", "", "Specifies the token from an invitation email to sign up with.")
 	return cmd
 }
 
+// parseServerURL parses the URL of a deployment provided by the user,
+// defaulting to HTTPS when the scheme is omitted.
+func parseServerURL(rawURL string) (*url.URL, error) {
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		scheme := "https"
+		if strings.HasPrefix(rawURL, "localhost") {
+			scheme = "http"
+		}
+		rawURL = fmt.Sprintf("%s://%s", scheme, rawURL)
+	}
+	serverURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, xerrors.Errorf("parse raw url %q: %w", rawURL, err)
+	}
+	// Default to HTTPs. Enables simple URLs like: master.cdr.dev
+	if serverURL.Scheme == "" {
+		serverURL.Scheme = "https"
+	}
+	return serverURL, nil
+}
+
+// acceptInvitation signs up with the token from an invitation email and
+// persists the session of the new user.
+func acceptInvitation(cmd *cobra.Command, client *codersdk.Client, serverURL *url.URL, token string) error {
+	if !isTTY(cmd) {
+		return xerrors.New("invitations cannot be accepted in non-interactive mode. use the API")
+	}
+	currentUser, err := user.Current()
+	if err != nil {
+		return xerrors.Errorf("get current user: %w", err)
+	}
+	username, err := cliui.Prompt(cmd, cliui.PromptOptions{
+		Text:    "What " + cliui.Styles.Field.Render("username") + " would you like?",
+		Default: currentUser.Username,
+	})
+	if err != nil {
+		return xerrors.Errorf("pick username prompt: %w", err)
+	}
+	password, err := promptNewPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.AcceptUserInvitation(cmd.Context(), codersdk.AcceptUserInvitationRequest{
+		Token:    token,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return xerrors.Errorf("accept invitation: %w", err)
+	}
+	if resp.TwoFactorEnrollmentRequired {
+		client.SetSessionToken(resp.SessionToken)
+		me, err := client.User(cmd.Context(), codersdk.Me)
+		if err != nil {
+			return xerrors.Errorf("get user: %w", err)
+		}
+		err = enrollTwoFactor(cmd, client)
+		if err != nil {
+			return xerrors.Errorf("enroll in two-factor authentication: %w", err)
+		}
+		resp, err = loginWithTwoFactor(cmd, client, codersdk.LoginWithPasswordRequest{
+			Email:    me.Email,
+			Password: password,
+		})
+		if err != nil {
+			return xerrors.Errorf("login with password: %w", err)
+		}
+	}
+
+	config := createConfig(cmd)
+	err = config.Session().Write(resp.SessionToken)
+	if err != nil {
+		return xerrors.Errorf("write session token: %w", err)
+	}
+	err = config.URL().Write(serverURL.String())
+	if err != nil {
+		return xerrors.Errorf("write server url: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), Caret+"Welcome to Coder, %s! You're authenticated.\n", cliui.Styles.Keyword.Render(username))
+	return nil
+}
+
+// promptNewPassword asks for a password until it's confirmed correctly.
+func promptNewPassword(cmd *cobra.Command) (string, error) {
+	for {
+		password, err := cliui.Prompt(cmd, cliui.PromptOptions{
+			Text:     "Enter a " + cliui.Styles.Field.Render("password") + ":",
+			Secret:   true,
+			Validate: cliui.ValidateNotEmpty,
+		})
+		if err != nil {
+			return "", xerrors.Errorf("specify password prompt: %w", err)
+		}
+		confirm, err := cliui.Prompt(cmd, cliui.PromptOptions{
+			Text:   "Confirm " + cliui.Styles.Field.Render("password") + ":",
+			Secret: true,
+		})
+		if err != nil {
+			return "", xerrors.Errorf("confirm password prompt: %w", err)
+		}
+		if confirm == password {
+			return password, nil
+		}
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Error.Render("Passwords do not match"))
+	}
+}
+
+// enrollTwoFactor interactively enrolls the authenticated user in two-factor
+// authentication.
+func enrollTwoFactor(cmd *cobra.Command, client *codersdk.Client) error {
+	enrollment, err := client.EnrollUserTwoFactor(cmd.Context(), codersdk.Me)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n\t%s\n\n",
+		cliui.Styles.Paragraph.Render("This deployment requires two-factor authentication. Scan the following URL as a QR code or enter the secret "+cliui.Styles.Code.Render(enrollment.Secret)+" in your authenticator app:"),
+		enrollment.URL)
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n\t%s\n\n",
+		cliui.Styles.Paragraph.Render("Store these recovery codes somewhere safe. Each can be used once in place of a code if you lose your device:"),
+		strings.Join(enrollment.RecoveryCodes, "\n\t"))
+
+	_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+		Text: "Enter a code from your authenticator app:",
+		Validate: func(code string) error {
+			_, err := client.VerifyUserTwoFactor(cmd.Context(), codersdk.Me, codersdk.VerifyUserTwoFactorRequest{
+				Code: code,
+			})
+			if err != nil {
+				return xerrors.New("That code is incorrect!")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return xerrors.Errorf("two-factor code prompt: %w", err)
+	}
+	return nil
+}
+
+// loginWithTwoFactor prompts for a two-factor code until logging in with it
+// succeeds. Codes are only accepted once, so the one enrollment was verified
+// with can't be used.
+func loginWithTwoFactor(cmd *cobra.Command, client *codersdk.Client, req codersdk.LoginWithPasswordRequest) (codersdk.LoginWithPasswordResponse, error) {
+	var resp codersdk.LoginWithPasswordResponse
+	_, err := cliui.Prompt(cmd, cliui.PromptOptions{
+		Text: "Enter the next code from your authenticator app to log in:",
+		Validate: func(code string) error {
+			req.TwoFactorCode = code
+			var err error
+			resp, err = client.LoginWithPassword(cmd.Context(), req)
+			if err != nil {
+				return xerrors.New("That code is incorrect or was already used!")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return codersdk.LoginWithPasswordResponse{}, xerrors.Errorf("two-factor code prompt: %w", err)
+	}
+	return resp, nil
+}
+
 // isWSL determines if coder-cli is running within Windows Subsystem for Linux
 func isWSL() (bool, error) {
 	if runtime.GOOS == goosDarwin || runtime.GOOS == goosWindows {
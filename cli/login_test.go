@@ -3,7 +3,10 @@ package cli_test
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,6 +14,7 @@ import (
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/totp"
 	"github.com/coder/coder/pty/ptytest"
 )
 
@@ -67,6 +71,37 @@ func TestLogin(t *testing.T) {
 		<-doneChan
 	})
 
+	t.Run("InitialUserTwoFactorRequired", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{TwoFactorRequired: true})
+		doneChan := make(chan struct{})
+		root, _ := clitest.New(t, "login", "--force-tty", client.URL.String(), "--first-user-username", "testuser", "--first-user-email", "user@coder.com", "--first-user-password", "password")
+		pty := ptytest.New(t)
+		root.SetIn(pty.Input())
+		root.SetOut(pty.Output())
+		go func() {
+			defer close(doneChan)
+			err := root.Execute()
+			assert.NoError(t, err)
+		}()
+		pty.ExpectMatch("otpauth://")
+		rawURL := "otpauth://" + strings.TrimSpace(pty.ExpectMatch("\n"))
+		parsed, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		secret := parsed.Query().Get("secret")
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+		pty.ExpectMatch("authenticator app:")
+		pty.WriteLine(code)
+		// The code enrollment was verified with can't be used again.
+		code, err = totp.Code(secret, time.Now().Add(totp.Period*time.Second))
+		require.NoError(t, err)
+		pty.ExpectMatch("to log in:")
+		pty.WriteLine(code)
+		pty.ExpectMatch("Welcome to Coder")
+		<-doneChan
+	})
+
 	t.Run("InitialUserFlags", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
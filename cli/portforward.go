@@ -14,6 +14,7 @@ import (
 
 	"github.com/pion/udp"
 	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/agent"
@@ -24,8 +25,12 @@ import (
 
 func portForward() *cobra.Command {
 	var (
-		tcpForwards []string // <port>:<port>
-		udpForwards []string // <port>:<port>
+		tcpForwards  []string // <port>:<port>
+		udpForwards  []string // <port>:<port>
+		unixForwards []string // <path>:<path> or <port>:<path>
+		// remoteTCPForwards listen in the workspace and forward to the local
+		// machine.
+		remoteTCPForwards []string // <port>:<port>
 	)
 	cmd := &cobra.Command{
 		Use:     "port-forward <workspace>",
@@ -45,6 +50,14 @@ func portForward() *cobra.Command {
 				Description: "Port forward multiple TCP ports and a UDP port",
 				Command:     "coder port-forward <workspace> --tcp 8080:8080 --tcp 9000:3000 --udp 5353:53",
 			},
+			example{
+				Description: "Forward the Docker socket of the workspace to a local socket, and to local TCP port 2375",
+				Command:     "coder port-forward <workspace> --unix ./docker.sock:/var/run/docker.sock --unix 2375:/var/run/docker.sock",
+			},
+			example{
+				Description: "Expose port 5432 on your local machine as port 5432 in the workspace",
+				Command:     "coder port-forward <workspace> --remote-tcp 5432",
+			},
 			example{
 				Description: "Port forward multiple ports (TCP or UDP) in condensed syntax",
 				Command:     "coder port-forward <workspace> --tcp 8080,9000:3000,9090-9092,10000-10002:10010-10012",
@@ -54,7 +67,7 @@ func portForward() *cobra.Command {
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
 
-			specs, err := parsePortForwards(tcpForwards, udpForwards)
+			specs, err := parsePortForwards(tcpForwards, udpForwards, unixForwards, remoteTCPForwards)
 			if err != nil {
 				return xerrors.Errorf("parse port-forward specs: %w", err)
 			}
@@ -95,12 +108,24 @@ func portForward() *cobra.Command {
 				return xerrors.Errorf("await agent: %w", err)
 			}
 
-			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
+			conn, err := dialWorkspaceAgent(ctx, cmd, client, workspaceAgent.ID, nil)
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
+			// The agent only accepts connections to TCP and UDP ports over
+			// the tailnet, so Unix sockets are dialed and listeners in the
+			// workspace are requested through SSH.
+			var sshClient *gossh.Client
+			if len(unixForwards) > 0 || len(remoteTCPForwards) > 0 {
+				sshClient, err = conn.SSHClient(ctx)
+				if err != nil {
+					return xerrors.Errorf("create ssh client: %w", err)
+				}
+				defer sshClient.Close()
+			}
+
 			// Start all listeners.
 			var (
 				wg                = new(sync.WaitGroup)
@@ -117,7 +142,7 @@ func portForward() *cobra.Command {
 			defer closeAllListeners()
 
 			for i, spec := range specs {
-				l, err := listenAndPortForward(ctx, cmd, conn, wg, spec)
+				l, err := listenAndPortForward(ctx, cmd, conn, sshClient, wg, spec)
 				if err != nil {
 					return err
 				}
@@ -169,20 +194,34 @@ func portForward() *cobra.Command {
 
 	cliflag.StringArrayVarP(cmd.Flags(), &tcpForwards, "tcp", "p", "CODER_PORT_FORWARD_TCP", nil, "Forward TCP port(s) from the workspace to the local machine")
 	cliflag.StringArrayVarP(cmd.Flags(), &udpForwards, "udp", "", "CODER_PORT_FORWARD_UDP", nil, "Forward UDP port(s) from the workspace to the local machine. The UDP connection has TCP-like semantics to support stateful UDP protocols")
+	cliflag.StringArrayVarP(cmd.Flags(), &remoteTCPForwards, "remote-tcp", "", "CODER_PORT_FORWARD_REMOTE_TCP", nil, "Forward TCP port(s) from the workspace to the local machine's ports, specified as <workspace port>:<local port>")
+	cliflag.StringArrayVarP(cmd.Flags(), &unixForwards, "unix", "", "CODER_PORT_FORWARD_UNIX", nil, "Forward Unix socket(s) from the workspace to a local socket or TCP port, specified as <local path or port>:<remote path>")
 	return cmd
 }
 
-func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersdk.AgentConn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
-	_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+// listenAndPortForward listens for connections and forwards them. sshClient
+// must be set for specs that dial Unix sockets or listen in the workspace.
+func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersdk.AgentConn, sshClient *gossh.Client, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
+	dialLocation := "in the workspace"
+	if spec.reverse {
+		dialLocation = "locally"
+		_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding '%v://%v' in the workspace to '%v://%v' locally\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+	} else {
+		_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+	}
 
 	var (
 		l   net.Listener
 		err error
 	)
-	switch spec.listenNetwork {
-	case "tcp":
+	switch {
+	case spec.reverse:
+		// The agent listens in the workspace and opens a channel for
+		// every connection it accepts.
+		l, err = sshClient.Listen(spec.listenNetwork, spec.listenAddress)
+	case spec.listenNetwork == "tcp", spec.listenNetwork == "unix":
 		l, err = net.Listen(spec.listenNetwork, spec.listenAddress)
-	case "udp":
+	case spec.listenNetwork == "udp":
 		var host, port string
 		host, port, err = net.SplitHostPort(spec.listenAddress)
 		if err != nil {
@@ -223,9 +262,21 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 
 			go func(netConn net.Conn) {
 				defer netConn.Close()
-				remoteConn, err := conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				var (
+					remoteConn net.Conn
+					err        error
+				)
+				switch {
+				case spec.reverse:
+					var dialer net.Dialer
+					remoteConn, err = dialer.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				case spec.dialNetwork == "unix":
+					remoteConn, err = sshClient.Dial(spec.dialNetwork, spec.dialAddress)
+				default:
+					remoteConn, err = conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				}
 				if err != nil {
-					_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Failed to dial '%v://%v' in workspace: %s\n", spec.dialNetwork, spec.dialAddress, err)
+					_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Failed to dial '%v://%v' %s: %s\n", spec.dialNetwork, spec.dialAddress, dialLocation, err)
 					return
 				}
 				defer remoteConn.Close()
@@ -239,14 +290,17 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 }
 
 type portForwardSpec struct {
-	listenNetwork string // tcp, udp
+	listenNetwork string // tcp, udp, unix
 	listenAddress string // <ip>:<port> or path
 
-	dialNetwork string // tcp, udp
+	dialNetwork string // tcp, udp, unix
 	dialAddress string // <ip>:<port> or path
+
+	// reverse specs listen in the workspace and dial on the local machine.
+	reverse bool
 }
 
-func parsePortForwards(tcpSpecs, udpSpecs []string) ([]portForwardSpec, error) {
+func parsePortForwards(tcpSpecs, udpSpecs, unixSpecs, remoteTCPSpecs []string) ([]portForwardSpec, error) {
 	specs := []portForwardSpec{}
 
 	for _, specEntry := range tcpSpecs {
@@ -285,12 +339,50 @@ func parsePortForwards(tcpSpecs, udpSpecs []string) ([]portForwardSpec, error) {
 		}
 	}
 
+	for _, spec := range unixSpecs {
+		local, remote, err := parseUnixSpec(spec)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse Unix socket forward specification %q: %w", spec, err)
+		}
+		specs = append(specs, portForwardSpec{
+			listenNetwork: local.network,
+			listenAddress: local.address,
+			dialNetwork:   "unix",
+			dialAddress:   remote,
+		})
+	}
+
+	for _, specEntry := range remoteTCPSpecs {
+		for _, spec := range strings.Split(specEntry, ",") {
+			// The first port is the one in the workspace, like the
+			// listening side of local specs.
+			ports, err := parseSrcDestPorts(spec)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse remote TCP port-forward specification %q: %w", spec, err)
+			}
+
+			for _, port := range ports {
+				specs = append(specs, portForwardSpec{
+					listenNetwork: "tcp",
+					listenAddress: fmt.Sprintf("127.0.0.1:%v", port.local),
+					dialNetwork:   "tcp",
+					dialAddress:   fmt.Sprintf("127.0.0.1:%v", port.remote),
+					reverse:       true,
+				})
+			}
+		}
+	}
+
 	// Check for duplicate entries.
 	locals := map[string]struct{}{}
 	for _, spec := range specs {
-		localStr := fmt.Sprintf("%v:%v", spec.listenNetwork, spec.listenAddress)
+		side := "local"
+		if spec.reverse {
+			side = "workspace"
+		}
+		localStr := fmt.Sprintf("%v:%v:%v", side, spec.listenNetwork, spec.listenAddress)
 		if _, ok := locals[localStr]; ok {
-			return nil, xerrors.Errorf("local %v %v is specified twice", spec.listenNetwork, spec.listenAddress)
+			return nil, xerrors.Errorf("%v %v %v is specified twice", side, spec.listenNetwork, spec.listenAddress)
 		}
 		locals[localStr] = struct{}{}
 	}
@@ -310,6 +402,37 @@ func parsePort(in string) (uint16, error) {
 	return uint16(port), nil
 }
 
+type parsedUnixListen struct {
+	network, address string
+}
+
+// parseUnixSpec parses <local>:<remote> or <path>, where local is a path or
+// a TCP port and remote is the path of a socket in the workspace.
+func parseUnixSpec(in string) (parsedUnixListen, string, error) {
+	parts := strings.Split(in, ":")
+	if len(parts) > 2 {
+		return parsedUnixListen{}, "", xerrors.Errorf("invalid Unix socket specification %q", in)
+	}
+	if len(parts) == 1 {
+		// Duplicate the single part
+		parts = append(parts, parts[0])
+	}
+	local, remote := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if local == "" || remote == "" {
+		return parsedUnixListen{}, "", xerrors.Errorf("invalid Unix socket specification %q", in)
+	}
+	if port, err := parsePort(local); err == nil {
+		return parsedUnixListen{
+			network: "tcp",
+			address: fmt.Sprintf("127.0.0.1:%v", port),
+		}, remote, nil
+	}
+	return parsedUnixListen{
+		network: "unix",
+		address: local,
+	}, remote, nil
+}
+
 type parsedSrcDestPort struct {
 	local, remote uint16
 }
@@ -13,14 +13,18 @@ func Test_parsePortForwards(t *testing.T) {
 
 	portForwardSpecToString := func(v []portForwardSpec) (out []string) {
 		for _, p := range v {
-			require.Equal(t, p.listenNetwork, p.dialNetwork)
+			if p.dialNetwork != "unix" {
+				require.Equal(t, p.listenNetwork, p.dialNetwork)
+			}
 			out = append(out, fmt.Sprintf("%s:%s", strings.Replace(p.listenAddress, "127.0.0.1:", "", 1), strings.Replace(p.dialAddress, "127.0.0.1:", "", 1)))
 		}
 		return out
 	}
 	type args struct {
-		tcpSpecs []string
-		udpSpecs []string
+		tcpSpecs       []string
+		udpSpecs       []string
+		unixSpecs      []string
+		remoteTCPSpecs []string
 	}
 	tests := []struct {
 		name    string
@@ -58,6 +62,64 @@ func Test_parsePortForwards(t *testing.T) {
 				"8081:8081",
 			},
 		},
+		{
+			name: "Unix sockets and TCP port to Unix socket",
+			args: args{
+				unixSpecs: []string{
+					"/tmp/docker.sock:/var/run/docker.sock",
+					"2375:/var/run/docker.sock",
+					"/tmp/same.sock",
+				},
+			},
+			want: []string{
+				"/tmp/docker.sock:/var/run/docker.sock",
+				"2375:/var/run/docker.sock",
+				"/tmp/same.sock:/tmp/same.sock",
+			},
+		},
+		{
+			name: "Bad Unix socket specification",
+			args: args{
+				unixSpecs: []string{"/a.sock:/b.sock:/c.sock"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate local Unix socket",
+			args: args{
+				unixSpecs: []string{"/tmp/a.sock:/a.sock", "/tmp/a.sock:/b.sock"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Remote TCP with port range",
+			args: args{
+				remoteTCPSpecs: []string{"5432,8000-8001:9000-9001"},
+			},
+			want: []string{
+				"5432:5432",
+				"8000:9000",
+				"8001:9001",
+			},
+		},
+		{
+			name: "Same port locally and in the workspace",
+			args: args{
+				tcpSpecs:       []string{"8080"},
+				remoteTCPSpecs: []string{"8080"},
+			},
+			want: []string{
+				"8080:8080",
+				"8080:8080",
+			},
+		},
+		{
+			name: "Duplicate remote TCP port",
+			args: args{
+				remoteTCPSpecs: []string{"8080", "8080:9090"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Bad port range",
 			args: args{
@@ -78,7 +140,7 @@ func Test_parsePortForwards(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := parsePortForwards(tt.args.tcpSpecs, tt.args.udpSpecs)
+			got, err := parsePortForwards(tt.args.tcpSpecs, tt.args.udpSpecs, tt.args.unixSpecs, tt.args.remoteTCPSpecs)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("parsePortForwards() error = %v, wantErr %v", err, tt.wantErr)
 				return
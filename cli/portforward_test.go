@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -109,6 +110,20 @@ func TestPortForward(t *testing.T) {
 				return l.Addr().String(), port
 			},
 		},
+		{
+			name:    "Unix",
+			network: "unix",
+			flag:    "--unix=%v:%v",
+			setupRemote: func(t *testing.T) net.Listener {
+				l, err := net.Listen("unix", filepath.Join(t.TempDir(), "remote.sock"))
+				require.NoError(t, err, "create Unix listener")
+				return l
+			},
+			setupLocal: func(t *testing.T) (string, string) {
+				path := filepath.Join(t.TempDir(), "local.sock")
+				return path, path
+			},
+		},
 	}
 
 	// Setup agent once to be shared between test-cases (avoid expensive
@@ -217,6 +232,44 @@ func TestPortForward(t *testing.T) {
 		})
 	}
 
+	//nolint:paralleltest
+	t.Run("RemoteTCP", func(t *testing.T) {
+		// The local service is exposed in the workspace, which is this
+		// machine too.
+		localPort := setupTestListener(t, cases[0].setupRemote(t))
+		workspaceAddress, workspacePort := cases[0].setupLocal(t)
+
+		cmd, root := clitest.New(t, "-v", "port-forward", workspace.Name, fmt.Sprintf("--remote-tcp=%v:%v", workspacePort, localPort))
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+		cmd.SetErr(pty.Output())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errC := make(chan error)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		pty.ExpectMatch("Ready!")
+
+		t.Parallel() // Port is reserved, enable parallel execution.
+
+		d := net.Dialer{Timeout: testutil.WaitShort}
+		c1, err := d.DialContext(ctx, "tcp", workspaceAddress)
+		require.NoError(t, err, "open connection 1 to workspace listener")
+		defer c1.Close()
+		c2, err := d.DialContext(ctx, "tcp", workspaceAddress)
+		require.NoError(t, err, "open connection 2 to workspace listener")
+		defer c2.Close()
+		testDial(t, c2)
+		testDial(t, c1)
+
+		cancel()
+		err = <-errC
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
 	// Test doing TCP and UDP at the same time.
 	//nolint:paralleltest
 	t.Run("All", func(t *testing.T) {
@@ -374,6 +427,9 @@ func setupTestListener(t *testing.T, l net.Listener) string {
 	}()
 
 	addr := l.Addr().String()
+	if l.Addr().Network() == "unix" {
+		return addr
+	}
 	_, port, err := net.SplitHostPort(addr)
 	require.NoErrorf(t, err, "split non-Unix listen path %q", addr)
 	addr = port
@@ -50,6 +50,7 @@ const (
 	varNoFeatureWarning = "no-feature-warning"
 	varForceTty         = "force-tty"
 	varVerbose          = "verbose"
+	varDisableDirect    = "disable-direct-connections"
 	notLoggedInMessage  = "You are not logged in. Try logging in using 'coder login <url>'."
 
 	envNoVersionCheck   = "CODER_NO_VERSION_WARNING"
@@ -70,10 +71,13 @@ func init() {
 func Core() []*cobra.Command {
 	// Please re-sort this list alphabetically if you change it!
 	return []*cobra.Command{
+		autoupdate(),
 		configSSH(),
 		create(),
+		debug(),
 		deleteWorkspace(),
 		dotfiles(),
+		forgotPassword(),
 		gitssh(),
 		list(),
 		loadtest(),
@@ -93,9 +97,11 @@ func Core() []*cobra.Command {
 		stop(),
 		templates(),
 		tokens(),
+		transferWorkspace(),
 		update(),
 		users(),
 		versionCmd(),
+		vpn(),
 		workspaceAgent(),
 	}
 }
@@ -215,6 +221,7 @@ func Root(subcommands []*cobra.Command) *cobra.Command {
 	cmd.PersistentFlags().Bool(varNoOpen, false, "Block automatically opening URLs in the browser.")
 	_ = cmd.PersistentFlags().MarkHidden(varNoOpen)
 	cliflag.Bool(cmd.PersistentFlags(), varVerbose, "v", "CODER_VERBOSE", false, "Enable verbose output.")
+	cliflag.Bool(cmd.PersistentFlags(), varDisableDirect, "", "CODER_DISABLE_DIRECT_CONNECTIONS", false, "Disable direct (peer-to-peer) connections to workspaces, so all traffic is relayed through DERP.")
 
 	return cmd
 }
@@ -3,10 +3,13 @@ package cli
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
@@ -50,13 +53,24 @@ When enabling scheduled stop, enter a duration in one of the following formats:
   * The new stop time is calculated from *now*.
   * The new stop time must be at least 30 minutes in the future.
   * The workspace template may restrict the maximum workspace runtime.
+`
+	scheduleWindowDescriptionLong = `Manage the windows a workspace runs in, in addition to its start schedule.
+  * The workspace is started every time the start schedule of a window fires,
+    and stopped when its stop schedule fires.
+  * Schedules are standard five-field cron specs (minute hour day-of-month month day-of-week),
+    optionally prefixed by a location, e.g. "CRON_TZ=Europe/Dublin 0 9 * * 1-5".
+  * A window ending as another begins keeps the workspace running.
+`
+	scheduleSkipDescriptionLong = `Skip automatic starts of a workspace on the given dates, e.g. holidays.
+  * Dates are in the YYYY-MM-DD format, and are compared with the date in the location of each schedule.
+  * Scheduled stops still happen on skipped dates.
 `
 )
 
 func schedules() *cobra.Command {
 	scheduleCmd := &cobra.Command{
 		Annotations: workspaceCommand,
-		Use:         "schedule { show | start | stop | override } <workspace>",
+		Use:         "schedule { show | start | stop | override | window | skip | upcoming } <workspace>",
 		Short:       "Schedule automated start and stop times for workspaces",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
@@ -68,6 +82,9 @@ func schedules() *cobra.Command {
 		scheduleStart(),
 		scheduleStop(),
 		scheduleOverride(),
+		scheduleWindow(),
+		scheduleSkip(),
+		scheduleUpcoming(),
 	)
 
 	return scheduleCmd
@@ -250,6 +267,230 @@ func scheduleOverride() *cobra.Command {
 	return overrideCmd
 }
 
+func scheduleWindow() *cobra.Command {
+	windowCmd := &cobra.Command{
+		Use:   "window { list | add | remove | clear }",
+		Short: "Manage workspace schedule windows",
+		Long:  scheduleWindowDescriptionLong,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	windowCmd.AddCommand(
+		scheduleWindowList(),
+		scheduleWindowAdd(),
+		scheduleWindowRemove(),
+		scheduleWindowClear(),
+	)
+	return windowCmd
+}
+
+func scheduleWindowList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <workspace-name>",
+		Short: "List workspace schedule windows and skipped dates",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			sched, err := client.WorkspaceSchedule(cmd.Context(), workspace.ID)
+			if err != nil {
+				return err
+			}
+			return displayWindows(sched, cmd.OutOrStdout())
+		},
+	}
+}
+
+func scheduleWindowAdd() *cobra.Command {
+	var (
+		start string
+		stop  string
+	)
+	cmd := &cobra.Command{
+		Use: "add <workspace-name> --start <cron> --stop <cron>",
+		Example: formatExamples(
+			example{
+				Description: "Run the workspace from 8am to 12pm and from 4pm to 8pm (in Dublin) from Monday to Friday",
+				Command: `coder schedule window add my-workspace --start "CRON_TZ=Europe/Dublin 0 8 * * 1-5" --stop "CRON_TZ=Europe/Dublin 0 12 * * 1-5"` + "\n" +
+					`coder schedule window add my-workspace --start "CRON_TZ=Europe/Dublin 0 16 * * 1-5" --stop "CRON_TZ=Europe/Dublin 0 20 * * 1-5"`,
+			},
+		),
+		Short: "Add a workspace schedule window",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if start == "" || stop == "" {
+				return xerrors.New("both --start and --stop must be specified")
+			}
+			return updateWorkspaceSchedule(cmd, args[0], func(sched *codersdk.WorkspaceSchedule) error {
+				sched.Windows = append(sched.Windows, codersdk.WorkspaceScheduleWindow{
+					StartSchedule: start,
+					StopSchedule:  stop,
+				})
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&start, "start", "", "Cron spec the workspace is started at.")
+	cmd.Flags().StringVar(&stop, "stop", "", "Cron spec the workspace is stopped at.")
+	return cmd
+}
+
+func scheduleWindowRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <workspace-name> <number>",
+		Short: "Remove a workspace schedule window by its number in \"coder schedule window list\"",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			number, err := strconv.Atoi(args[1])
+			if err != nil {
+				return xerrors.Errorf("parse window number %q: %w", args[1], err)
+			}
+			return updateWorkspaceSchedule(cmd, args[0], func(sched *codersdk.WorkspaceSchedule) error {
+				if number < 1 || number > len(sched.Windows) {
+					return xerrors.Errorf("workspace has no window %d", number)
+				}
+				sched.Windows = append(sched.Windows[:number-1], sched.Windows[number:]...)
+				return nil
+			})
+		},
+	}
+}
+
+func scheduleWindowClear() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear <workspace-name>",
+		Short: "Remove all workspace schedule windows",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateWorkspaceSchedule(cmd, args[0], func(sched *codersdk.WorkspaceSchedule) error {
+				sched.Windows = nil
+				return nil
+			})
+		},
+	}
+}
+
+func scheduleSkip() *cobra.Command {
+	var remove bool
+	cmd := &cobra.Command{
+		Use: "skip <workspace-name> <date>...",
+		Example: formatExamples(
+			example{
+				Description: "Don't start the workspace over Christmas",
+				Command:     "coder schedule skip my-workspace 2022-12-25 2022-12-26",
+			},
+		),
+		Short: "Skip automatic workspace starts on dates",
+		Long:  scheduleSkipDescriptionLong,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateWorkspaceSchedule(cmd, args[0], func(sched *codersdk.WorkspaceSchedule) error {
+				if !remove {
+					sched.SkipDates = append(sched.SkipDates, args[1:]...)
+					return nil
+				}
+				skipDates := sched.SkipDates[:0]
+				for _, skipDate := range sched.SkipDates {
+					if !slices.Contains(args[1:], skipDate) {
+						skipDates = append(skipDates, skipDate)
+					}
+				}
+				sched.SkipDates = skipDates
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&remove, "remove", false, "Start the workspace on the dates again.")
+	return cmd
+}
+
+func scheduleUpcoming() *cobra.Command {
+	var count int
+	cmd := &cobra.Command{
+		Use:   "upcoming <workspace-name>",
+		Short: "List upcoming automatic workspace starts and stops",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			transitions, err := client.UpcomingWorkspaceTransitions(cmd.Context(), workspace.ID, count)
+			if err != nil {
+				return err
+			}
+			if len(transitions) == 0 {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No upcoming automatic starts or stops.")
+				return nil
+			}
+
+			loc, err := tz.TimezoneIANA()
+			if err != nil {
+				loc = time.UTC // best effort
+			}
+			tw := cliui.Table()
+			tw.AppendHeader(table.Row{"Transition", "At"})
+			for _, transition := range transitions {
+				tw.AppendRow(table.Row{transition.Transition, transition.At.In(loc).Format(timeFormat + " on " + dateFormat)})
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), tw.Render())
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&count, "count", "n", 10, "Number of upcoming starts and stops to list.")
+	return cmd
+}
+
+// updateWorkspaceSchedule applies update to the schedule of the workspace,
+// and displays the result.
+func updateWorkspaceSchedule(cmd *cobra.Command, workspaceName string, update func(sched *codersdk.WorkspaceSchedule) error) error {
+	client, err := CreateClient(cmd)
+	if err != nil {
+		return err
+	}
+	workspace, err := namedWorkspace(cmd, client, workspaceName)
+	if err != nil {
+		return err
+	}
+	sched, err := client.WorkspaceSchedule(cmd.Context(), workspace.ID)
+	if err != nil {
+		return err
+	}
+	err = update(&sched)
+	if err != nil {
+		return err
+	}
+	updated, err := client.UpdateWorkspaceSchedule(cmd.Context(), workspace.ID, sched)
+	if err != nil {
+		return err
+	}
+	return displayWindows(updated, cmd.OutOrStdout())
+}
+
+func displayWindows(sched codersdk.WorkspaceSchedule, out io.Writer) error {
+	tw := cliui.Table()
+	tw.AppendHeader(table.Row{"#", "Start", "Stop"})
+	for i, window := range sched.Windows {
+		tw.AppendRow(table.Row{i + 1, window.StartSchedule, window.StopSchedule})
+	}
+	_, _ = fmt.Fprintln(out, tw.Render())
+	if len(sched.SkipDates) > 0 {
+		_, _ = fmt.Fprintf(out, "\nSkipped dates: %s\n", strings.Join(sched.SkipDates, ", "))
+	}
+	return nil
+}
+
 func displaySchedule(workspace codersdk.Workspace, out io.Writer) error {
 	loc, err := tz.TimezoneIANA()
 	if err != nil {
@@ -217,6 +217,56 @@ func TestScheduleStop(t *testing.T) {
 	}
 }
 
+func TestScheduleWindow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		client    = coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user      = coderdtest.CreateFirstUser(t, client)
+		version   = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_         = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		project   = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace = coderdtest.CreateWorkspace(t, client, user.OrganizationID, project.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = nil
+		})
+		_ = coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	)
+	run := func(args ...string) string {
+		stdoutBuf := &bytes.Buffer{}
+		cmd, root := clitest.New(t, append([]string{"schedule"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		cmd.SetOut(stdoutBuf)
+		err := cmd.Execute()
+		require.NoError(t, err, "unexpected error")
+		return stdoutBuf.String()
+	}
+
+	run("window", "add", workspace.Name, "--start", "CRON_TZ=UTC 0 8 * * 1-5", "--stop", "CRON_TZ=UTC 0 12 * * 1-5")
+	out := run("window", "add", workspace.Name, "--start", "CRON_TZ=UTC 0 16 * * 1-5", "--stop", "CRON_TZ=UTC 0 20 * * 1-5")
+	assert.Contains(t, out, "CRON_TZ=UTC 0 8 * * 1-5")
+	assert.Contains(t, out, "CRON_TZ=UTC 0 20 * * 1-5")
+
+	out = run("skip", workspace.Name, "2022-12-25", "2022-12-26")
+	assert.Contains(t, out, "Skipped dates: 2022-12-25, 2022-12-26")
+	out = run("skip", workspace.Name, "2022-12-26", "--remove")
+	assert.Contains(t, out, "Skipped dates: 2022-12-25")
+	assert.NotContains(t, out, "2022-12-26")
+
+	out = run("window", "remove", workspace.Name, "1")
+	assert.NotContains(t, out, "CRON_TZ=UTC 0 8 * * 1-5")
+	assert.Contains(t, out, "CRON_TZ=UTC 0 16 * * 1-5")
+
+	out = run("upcoming", workspace.Name, "--count", "3")
+	assert.Contains(t, out, "start")
+	assert.Contains(t, out, "stop")
+
+	run("window", "clear", workspace.Name)
+	sched, err := client.WorkspaceSchedule(context.Background(), workspace.ID)
+	require.NoError(t, err)
+	require.Empty(t, sched.Windows)
+	require.Equal(t, []string{"2022-12-25"}, sched.SkipDates)
+}
+
 func TestScheduleOverride(t *testing.T) {
 	t.Parallel()
 
@@ -60,6 +60,9 @@ import (
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ldapauth"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/prometheusmetrics"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
@@ -350,18 +353,24 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				Logger:                      logger.Named("coderd"),
 				Database:                    databasefake.New(),
 				DERPMap:                     derpMap,
+				DERPBlockDirect:             cfg.DERP.Config.BlockDirect.Value,
 				Pubsub:                      database.NewPubsubInMemory(),
 				CacheDir:                    cfg.CacheDirectory.Value,
 				GoogleTokenValidator:        googleTokenValidator,
 				GitAuthConfigs:              gitAuthConfigs,
 				RealIPConfig:                realIPConfig,
 				SecureAuthCookie:            cfg.SecureAuthCookie.Value,
+				TwoFactorRequired:           cfg.TwoFactorRequired.Value,
+				UserInvitationLifetime:      cfg.UserInvitationLifetime.Value,
+				PasswordResetLifetime:       cfg.PasswordResetLifetime.Value,
+				MaxTokenLifetime:            cfg.MaxTokenLifetime.Value,
 				SSHKeygenAlgorithm:          sshKeygenAlgorithm,
 				TracerProvider:              tracerProvider,
 				Telemetry:                   telemetry.NewNoop(),
 				AutoImportTemplates:         validatedAutoImportTemplates,
 				MetricsCacheRefreshInterval: cfg.MetricsCacheRefreshInterval.Value,
 				AgentStatsRefreshInterval:   cfg.AgentStatRefreshInterval.Value,
+				AgentConnectionCacheSize:    cfg.AgentConnectionCacheSize.Value,
 				DeploymentConfig:            cfg,
 				PrometheusRegistry:          prometheus.NewRegistry(),
 				APIRateLimit:                cfg.APIRateLimit.Value,
@@ -416,6 +425,40 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				}
 			}
 
+			if cfg.LDAP.URL.Value != "" {
+				if cfg.LDAP.SearchBaseDN.Value == "" {
+					return xerrors.Errorf("LDAP search base DN must be set!")
+				}
+				options.LDAPConfig = &ldapauth.Config{
+					URL:                cfg.LDAP.URL.Value,
+					StartTLS:           cfg.LDAP.StartTLS.Value,
+					InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify.Value,
+					BindDN:             cfg.LDAP.BindDN.Value,
+					BindPassword:       cfg.LDAP.BindPassword.Value,
+					SearchBaseDN:       cfg.LDAP.SearchBaseDN.Value,
+					SearchFilter:       cfg.LDAP.SearchFilter.Value,
+					UsernameAttribute:  cfg.LDAP.UsernameAttribute.Value,
+					EmailAttribute:     cfg.LDAP.EmailAttribute.Value,
+					GroupAttribute:     cfg.LDAP.GroupAttribute.Value,
+					GroupSync:          cfg.LDAP.GroupSync.Value,
+					AllowSignups:       cfg.LDAP.AllowSignups.Value,
+				}
+			}
+
+			if cfg.SMTP.Address.Value != "" {
+				if cfg.SMTP.From.Value == "" {
+					return xerrors.Errorf("SMTP from address must be set!")
+				}
+				options.Mailer = &mailer.SMTP{
+					Addr:               cfg.SMTP.Address.Value,
+					From:               cfg.SMTP.From.Value,
+					Username:           cfg.SMTP.Username.Value,
+					Password:           cfg.SMTP.Password.Value,
+					ForceTLS:           cfg.SMTP.ForceTLS.Value,
+					InsecureSkipVerify: cfg.SMTP.InsecureSkipVerify.Value,
+				}
+			}
+
 			if cfg.InMemoryDatabase.Value {
 				options.Database = databasefake.New()
 				options.Pubsub = database.NewPubsubInMemory()
@@ -471,11 +514,24 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				sqlDB.SetMaxIdleConns(3)
 
 				options.Database = database.New(sqlDB)
-				options.Pubsub, err = database.NewPubsub(ctx, sqlDB, cfg.PostgresURL.Value)
+				switch cfg.Pubsub.Backend.Value {
+				case "postgres":
+					options.Pubsub, err = database.NewPubsub(ctx, sqlDB, cfg.PostgresURL.Value)
+				case "redis":
+					if cfg.Pubsub.RedisURL.Value == "" {
+						return xerrors.Errorf("the redis pubsub backend requires --%s", cfg.Pubsub.RedisURL.Flag)
+					}
+					options.Pubsub, err = database.NewPubsubRedis(ctx, cfg.Pubsub.RedisURL.Value)
+				case "memory":
+					options.Pubsub = database.NewPubsubInMemory()
+				default:
+					return xerrors.Errorf("unknown pubsub backend %q, must be \"postgres\", \"redis\" or \"memory\"", cfg.Pubsub.Backend.Value)
+				}
 				if err != nil {
 					return xerrors.Errorf("create pubsub: %w", err)
 				}
 				defer options.Pubsub.Close()
+				options.Pubsub = database.NewPubsubWithMetrics(options.Pubsub, options.PrometheusRegistry, cfg.Pubsub.Backend.Value)
 			}
 
 			deploymentID, err := options.Database.GetDeploymentID(ctx)
@@ -551,6 +607,25 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				), cfg.Prometheus.Address.Value, "prometheus")()
 			}
 
+			notifierOptions := notifications.Options{
+				Database: options.Database,
+				Logger:   logger.Named("notifications"),
+				Mailer:   options.Mailer,
+			}
+			if cfg.NotificationWebhookURL.Value != "" {
+				notifierOptions.WebhookURL, err = url.Parse(cfg.NotificationWebhookURL.Value)
+				if err != nil {
+					return xerrors.Errorf("parse notification webhook url: %w", err)
+				}
+			}
+			options.Notifier = notifications.New(notifierOptions)
+			defer func() {
+				// Give pending emails and webhooks a chance to be delivered.
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				options.Notifier.Close(ctx)
+			}()
+
 			// We use a separate coderAPICloser so the Enterprise API
 			// can have it's own close functions. This is cleaner
 			// than abstracting the Coder API itself.
@@ -654,7 +729,7 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 
 			autobuildPoller := time.NewTicker(cfg.AutobuildPollInterval.Value)
 			defer autobuildPoller.Stop()
-			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C)
+			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C).WithNotifier(options.Notifier)
 			autobuildExecutor.Run()
 
 			// This is helpful for tests, but can be silently ignored.
@@ -56,12 +56,16 @@ func speedtest() *cobra.Command {
 				logger = logger.Leveled(slog.LevelDebug)
 			}
 			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{
-				Logger: logger,
+				Logger:         logger,
+				BlockEndpoints: cliflag.IsSetBool(cmd, varDisableDirect),
 			})
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
+			if direct && conn.BlockEndpoints() {
+				return xerrors.New("direct connections are disabled, so --direct can't be used")
+			}
 			ticker := time.NewTicker(time.Second)
 			defer ticker.Stop()
 			for {
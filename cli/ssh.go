@@ -1,13 +1,17 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -21,6 +25,7 @@ import (
 	"golang.org/x/term"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/agent"
 	"github.com/coder/coder/cli/cliflag"
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/coderd/autobuild/notify"
@@ -39,6 +44,7 @@ func ssh() *cobra.Command {
 		stdio          bool
 		shuffle        bool
 		forwardAgent   bool
+		forwardGPG     bool
 		identityAgent  string
 		wsPollInterval time.Duration
 	)
@@ -90,7 +96,7 @@ func ssh() *cobra.Command {
 				return xerrors.Errorf("await agent: %w", err)
 			}
 
-			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
+			conn, err := dialWorkspaceAgent(ctx, cmd, client, workspaceAgent.ID, nil)
 			if err != nil {
 				return err
 			}
@@ -146,6 +152,21 @@ func ssh() *cobra.Command {
 				}
 			}
 
+			if forwardGPG {
+				if workspaceAgent.OperatingSystem == "windows" {
+					return xerrors.New("GPG forwarding is not supported for Windows workspaces")
+				}
+				err = uploadGPGKeys(ctx, sshClient)
+				if err != nil {
+					return xerrors.Errorf("upload GPG public keys and ownertrust to workspace: %w", err)
+				}
+				closer, err := forwardGPGAgent(ctx, cmd.ErrOrStderr(), sshClient)
+				if err != nil {
+					return xerrors.Errorf("forward GPG socket: %w", err)
+				}
+				defer closer.Close()
+			}
+
 			stdoutFile, validOut := cmd.OutOrStdout().(*os.File)
 			stdinFile, validIn := cmd.InOrStdin().(*os.File)
 			if validOut && validIn && isatty.IsTerminal(stdoutFile.Fd()) {
@@ -216,6 +237,7 @@ func ssh() *cobra.Command {
 	cliflag.BoolVarP(cmd.Flags(), &shuffle, "shuffle", "", "CODER_SSH_SHUFFLE", false, "Specifies whether to choose a random workspace")
 	_ = cmd.Flags().MarkHidden("shuffle")
 	cliflag.BoolVarP(cmd.Flags(), &forwardAgent, "forward-agent", "A", "CODER_SSH_FORWARD_AGENT", false, "Specifies whether to forward the SSH agent specified in $SSH_AUTH_SOCK")
+	cliflag.BoolVarP(cmd.Flags(), &forwardGPG, "forward-gpg", "G", "CODER_SSH_FORWARD_GPG", false, "Specifies whether to forward the local GPG agent and public keys. Requires gpg and gpgconf on both machines, and is unsupported on Windows. A GPG agent running in the workspace is stopped.")
 	cliflag.StringVarP(cmd.Flags(), &identityAgent, "identity-agent", "", "CODER_SSH_IDENTITY_AGENT", "", "Specifies which identity agent to use (overrides $SSH_AUTH_SOCK), forward agent must also be enabled")
 	cliflag.DurationVarP(cmd.Flags(), &wsPollInterval, "workspace-poll-interval", "", "CODER_WORKSPACE_POLL_INTERVAL", workspacePollInterval, "Specifies how often to poll for workspace automated shutdown.")
 	return cmd
@@ -364,3 +386,115 @@ func verifyWorkspaceOutdated(client *codersdk.Client, workspace codersdk.Workspa
 func buildWorkspaceLink(serverURL *url.URL, workspace codersdk.Workspace) *url.URL {
 	return serverURL.ResolveReference(&url.URL{Path: fmt.Sprintf("@%s/%s", workspace.OwnerName, workspace.Name)})
 }
+
+// uploadGPGKeys imports the local public keys and ownertrust into the keyring
+// of the workspace. gpg needs the public keys to use the secret keys of the
+// forwarded agent.
+func uploadGPGKeys(ctx context.Context, sshClient *gossh.Client) error {
+	publicKeys, err := runLocal(ctx, nil, "gpg", "--armor", "--export")
+	if err != nil {
+		return xerrors.Errorf("export local public keys: %w", err)
+	}
+	if len(publicKeys) == 0 {
+		return xerrors.New("no local public keys found")
+	}
+	_, err = runRemote(sshClient, bytes.NewReader(publicKeys), "gpg --import")
+	if err != nil {
+		return xerrors.Errorf("import public keys in workspace: %w", err)
+	}
+
+	ownerTrust, err := runLocal(ctx, nil, "gpg", "--export-ownertrust")
+	if err != nil {
+		return xerrors.Errorf("export local ownertrust: %w", err)
+	}
+	_, err = runRemote(sshClient, bytes.NewReader(ownerTrust), "gpg --import-ownertrust")
+	if err != nil {
+		return xerrors.Errorf("import ownertrust in workspace: %w", err)
+	}
+	return nil
+}
+
+// forwardGPGAgent serves the socket of the GPG agent in the workspace with the
+// extra socket of the local agent, which restricts the commands remote
+// clients may use. Closing the returned listener stops forwarding.
+func forwardGPGAgent(ctx context.Context, stderr io.Writer, sshClient *gossh.Client) (io.Closer, error) {
+	if runtime.GOOS == "windows" {
+		return nil, xerrors.New("GPG forwarding is not supported on Windows")
+	}
+	// The agent creates its sockets when it starts.
+	_, err := runLocal(ctx, nil, "gpgconf", "--launch", "gpg-agent")
+	if err != nil {
+		return nil, xerrors.Errorf("launch local GPG agent: %w", err)
+	}
+	localSocket, err := runLocal(ctx, nil, "gpgconf", "--list-dir", "agent-extra-socket")
+	if err != nil {
+		return nil, xerrors.Errorf("get local GPG agent extra socket: %w", err)
+	}
+	remoteSocket, err := runRemote(sshClient, nil, "gpgconf --list-dir agent-socket")
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace GPG agent socket: %w", err)
+	}
+	// A running agent would keep answering on its own socket once it noticed
+	// the socket was replaced.
+	_, err = runRemote(sshClient, nil, "gpgconf --kill gpg-agent")
+	if err != nil {
+		return nil, xerrors.Errorf("stop workspace GPG agent: %w", err)
+	}
+
+	localPath, remotePath := string(bytes.TrimSpace(localSocket)), string(bytes.TrimSpace(remoteSocket))
+	listener, err := sshClient.ListenUnix(remotePath)
+	if err != nil {
+		return nil, xerrors.Errorf("listen on workspace socket %q: %w", remotePath, err)
+	}
+	go func() {
+		for {
+			remoteConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				localConn, err := net.Dial("unix", localPath)
+				if err != nil {
+					_, _ = fmt.Fprintf(stderr, "Failed to dial local GPG agent socket %q: %v\n", localPath, err)
+					_ = remoteConn.Close()
+					return
+				}
+				agent.Bicopy(ctx, localConn, remoteConn)
+			}()
+		}
+	}()
+	return listener, nil
+}
+
+// runLocal runs a command on this machine and returns its output.
+func runLocal(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return nil, xerrors.Errorf("%s: %w: %s", cmd.String(), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// runRemote runs a command in the workspace and returns its output.
+func runRemote(sshClient *gossh.Client, stdin io.Reader, command string) ([]byte, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, xerrors.Errorf("create ssh session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run(command)
+	if err != nil {
+		return nil, xerrors.Errorf("%s: %w: %s", command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
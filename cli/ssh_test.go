@@ -8,6 +8,8 @@ import (
 	"errors"
 	"io"
 	"net"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -105,6 +107,40 @@ func TestSSH(t *testing.T) {
 		pty.WriteLine("exit")
 		<-cmdDone
 	})
+	t.Run("VerboseRelayOnly", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+		cmd, root := clitest.New(t, "ssh", workspace.Name, "--verbose", "--disable-direct-connections")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetErr(pty.Output())
+		cmd.SetOut(pty.Output())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+
+		agentClient := codersdk.New(client.URL)
+		agentClient.SetSessionToken(agentToken)
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer func() {
+			_ = agentCloser.Close()
+		}()
+
+		pty.ExpectMatch("Direct connections are disabled")
+		pty.ExpectMatch("relayed through DERP region")
+		pty.WriteLine("exit")
+		<-cmdDone
+	})
 	t.Run("ShowTroubleshootingURLAfterTimeout", func(t *testing.T) {
 		t.Parallel()
 
@@ -283,6 +319,73 @@ func TestSSH(t *testing.T) {
 	})
 }
 
+//nolint:paralleltest // This test uses t.Setenv.
+func TestSSH_ForwardGPG(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("GPG forwarding is not supported on Windows")
+	}
+	for _, name := range []string{"gpg", "gpgconf"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not found", name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Both "machines" are this one, so they are told apart by their GPG home
+	// directories. Short paths keep the sockets below the length limit.
+	gnupgHome := func() string {
+		dir, err := os.MkdirTemp("", "gpg-")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cmd := exec.Command("gpgconf", "--kill", "gpg-agent")
+			cmd.Env = append(os.Environ(), "GNUPGHOME="+dir)
+			_ = cmd.Run()
+			_ = os.RemoveAll(dir)
+		})
+		return dir
+	}
+	localHome, remoteHome := gnupgHome(), gnupgHome()
+	t.Setenv("GNUPGHOME", localHome)
+	out, err := exec.CommandContext(ctx, "gpg", "--batch", "--passphrase", "",
+		"--quick-gen-key", "Coder Test <test@coder.com>", "default", "default", "never").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+		EnvironmentVariables: map[string]string{
+			"GNUPGHOME": remoteHome,
+		},
+	})
+	defer agentCloser.Close()
+
+	cmd, root := clitest.New(t, "ssh", workspace.Name, "--forward-gpg")
+	clitest.SetupConfig(t, client, root)
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	cmd.SetErr(pty.Output())
+	cmdDone := tGo(t, func() {
+		err := cmd.ExecuteContext(ctx)
+		assert.NoError(t, err, "ssh command failed")
+	})
+
+	// The public key was imported, and the secret key of the local agent
+	// signs in the workspace.
+	pty.WriteLine("gpg --list-keys")
+	pty.ExpectMatch("test@coder.com")
+	pty.WriteLine("echo hello | gpg --batch --clearsign --local-user test@coder.com")
+	pty.ExpectMatch("BEGIN PGP SIGNATURE")
+
+	pty.WriteLine("exit")
+	<-cmdDone
+}
+
 // tGoContext runs fn in a goroutine passing a context that will be
 // canceled on test completion and wait until fn has finished executing.
 // Done and cancel are returned for optionally waiting until completion
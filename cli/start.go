@@ -5,26 +5,52 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/codersdk"
 )
 
 func start() *cobra.Command {
+	var batch workspaceBatchFlags
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "start <workspace>",
 		Short:       "Start a workspace",
-		Args:        cobra.ExactArgs(1),
+		Args:        cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			isBatch, err := batch.args(args)
+			if err != nil {
+				return err
+			}
 			client, err := CreateClient(cmd)
 			if err != nil {
 				return err
 			}
+			if isBatch {
+				return batch.run(cmd, client, codersdk.WorkspaceBatchActionStart)
+			}
 			workspace, err := namedWorkspace(cmd, client, args[0])
 			if err != nil {
 				return err
 			}
+			if workspace.DormantAt != nil {
+				// Dormant workspaces can't be built until their owner
+				// confirms they are still in use.
+				_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:      fmt.Sprintf("The %s workspace is dormant. Confirm it is still in use?", cliui.Styles.Keyword.Render(workspace.Name)),
+					IsConfirm: true,
+				})
+				if err != nil {
+					return err
+				}
+				err = client.UpdateWorkspaceDormancy(cmd.Context(), workspace.ID, codersdk.UpdateWorkspaceDormancyRequest{
+					Dormant: false,
+				})
+				if err != nil {
+					return xerrors.Errorf("confirm dormant workspace: %w", err)
+				}
+			}
 			build, err := client.CreateWorkspaceBuild(cmd.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
 				Transition: codersdk.WorkspaceTransitionStart,
 			})
@@ -41,6 +67,7 @@ func start() *cobra.Command {
 			return nil
 		},
 	}
+	batch.attach(cmd)
 	cliui.AllowSkipPrompt(cmd)
 	return cmd
 }
@@ -11,13 +11,26 @@ import (
 )
 
 func stop() *cobra.Command {
+	var batch workspaceBatchFlags
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "stop <workspace>",
 		Short:       "Stop a workspace",
-		Args:        cobra.ExactArgs(1),
+		Args:        cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := cliui.Prompt(cmd, cliui.PromptOptions{
+			isBatch, err := batch.args(args)
+			if err != nil {
+				return err
+			}
+			if isBatch {
+				client, err := CreateClient(cmd)
+				if err != nil {
+					return err
+				}
+				return batch.run(cmd, client, codersdk.WorkspaceBatchActionStop)
+			}
+
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
 				Text:      "Confirm stop workspace?",
 				IsConfirm: true,
 			})
@@ -49,6 +62,7 @@ func stop() *cobra.Command {
 			return nil
 		},
 	}
+	batch.attach(cmd)
 	cliui.AllowSkipPrompt(cmd)
 	return cmd
 }
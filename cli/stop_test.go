@@ -0,0 +1,63 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestStop(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Search", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		cmd, root := clitest.New(t, "stop", "--search", "template:"+template.Name, "--dry-run")
+		clitest.SetupConfig(t, client, root)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), workspace.Name)
+		require.Contains(t, stdout.String(), string(codersdk.WorkspaceBatchItemStatusPending))
+		updated, err := client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, updated.LatestBuild.Transition)
+
+		cmd, root = clitest.New(t, "stop", "--search", "template:"+template.Name, "--yes")
+		clitest.SetupConfig(t, client, root)
+		stdout = &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), string(codersdk.WorkspaceBatchItemStatusSucceeded))
+		updated, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStop, updated.LatestBuild.Transition)
+	})
+
+	t.Run("SearchWithWorkspace", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "stop", "my-workspace", "--search", "owner:me")
+		clitest.SetupConfig(t, client, root)
+		require.Error(t, cmd.Execute())
+	})
+}
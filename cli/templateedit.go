@@ -8,6 +8,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -18,6 +19,20 @@ func templateEdit() *cobra.Command {
 		description string
 		icon        string
 		defaultTTL  time.Duration
+
+		maxTTL                time.Duration
+		minAutostartInterval  time.Duration
+		quietHours            string
+		allowUserAutostop     bool
+		inactivityTTL         time.Duration
+		dormancyDeletionTTL   time.Duration
+		activityBump          time.Duration
+		activityBumpThreshold time.Duration
+		requireActiveVersion  bool
+		buildRetries          int
+		buildRetryBackoff     time.Duration
+		rollbackFailedUpdates bool
+		allowWorkspaceNetwork bool
 	)
 
 	cmd := &cobra.Command{
@@ -38,6 +53,12 @@ func templateEdit() *cobra.Command {
 				return xerrors.Errorf("get workspace template: %w", err)
 			}
 
+			// The default TTL is always sent, so keep the current one unless
+			// it is being edited.
+			if !cmd.Flags().Changed("default-ttl") {
+				defaultTTL = time.Duration(template.DefaultTTLMillis) * time.Millisecond
+			}
+
 			// NOTE: coderd will ignore empty fields.
 			req := codersdk.UpdateTemplateMeta{
 				Name:             name,
@@ -46,6 +67,45 @@ func templateEdit() *cobra.Command {
 				Icon:             icon,
 				DefaultTTLMillis: defaultTTL.Milliseconds(),
 			}
+			if cmd.Flags().Changed("max-ttl") {
+				req.MaxTTLMillis = ptr.Ref(maxTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("min-autostart-interval") {
+				req.MinAutostartIntervalMillis = ptr.Ref(minAutostartInterval.Milliseconds())
+			}
+			if cmd.Flags().Changed("quiet-hours") {
+				req.QuietHoursSchedule = ptr.Ref(quietHours)
+			}
+			if cmd.Flags().Changed("allow-user-autostop") {
+				req.AllowUserAutostop = ptr.Ref(allowUserAutostop)
+			}
+			if cmd.Flags().Changed("inactivity-ttl") {
+				req.InactivityTTLMillis = ptr.Ref(inactivityTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("dormancy-deletion-ttl") {
+				req.DormancyDeletionTTLMillis = ptr.Ref(dormancyDeletionTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("activity-bump") {
+				req.ActivityBumpMillis = ptr.Ref(activityBump.Milliseconds())
+			}
+			if cmd.Flags().Changed("activity-bump-threshold") {
+				req.ActivityBumpThresholdMillis = ptr.Ref(activityBumpThreshold.Milliseconds())
+			}
+			if cmd.Flags().Changed("require-active-version") {
+				req.RequireActiveVersion = ptr.Ref(requireActiveVersion)
+			}
+			if cmd.Flags().Changed("build-retries") {
+				req.BuildRetries = ptr.Ref(buildRetries)
+			}
+			if cmd.Flags().Changed("build-retry-backoff") {
+				req.BuildRetryBackoffMillis = ptr.Ref(buildRetryBackoff.Milliseconds())
+			}
+			if cmd.Flags().Changed("rollback-failed-updates") {
+				req.RollbackFailedUpdates = ptr.Ref(rollbackFailedUpdates)
+			}
+			if cmd.Flags().Changed("allow-workspace-networking") {
+				req.AllowWorkspaceNetworking = ptr.Ref(allowWorkspaceNetwork)
+			}
 
 			_, err = client.UpdateTemplateMeta(cmd.Context(), template.ID, req)
 			if err != nil {
@@ -61,6 +121,19 @@ func templateEdit() *cobra.Command {
 	cmd.Flags().StringVarP(&description, "description", "", "", "Edit the template description")
 	cmd.Flags().StringVarP(&icon, "icon", "", "", "Edit the template icon path")
 	cmd.Flags().DurationVarP(&defaultTTL, "default-ttl", "", 0, "Edit the template default time before shutdown - workspaces created from this template to this value.")
+	cmd.Flags().DurationVarP(&maxTTL, "max-ttl", "", 0, "Edit the maximum time workspaces created from this template may run before they are stopped, regardless of activity. 0 disables the limit.")
+	cmd.Flags().DurationVarP(&minAutostartInterval, "min-autostart-interval", "", 0, "Edit the minimum interval between two autostarts of workspaces created from this template. 0 disables the limit.")
+	cmd.Flags().StringVarP(&quietHours, "quiet-hours", "", "", `Edit the schedule at which workspaces created from this template are stopped, e.g. "CRON_TZ=Europe/London 0 2 * * *". An empty value disables quiet hours.`)
+	cmd.Flags().BoolVarP(&allowUserAutostop, "allow-user-autostop", "", true, "Edit whether users may disable autostop for workspaces created from this template.")
+	cmd.Flags().DurationVarP(&inactivityTTL, "inactivity-ttl", "", 0, "Edit how long workspaces created from this template may go unused before they are marked dormant and stopped. 0 disables dormancy.")
+	cmd.Flags().DurationVarP(&dormancyDeletionTTL, "dormancy-deletion-ttl", "", 0, "Edit how long workspaces created from this template may stay dormant before they are deleted. 0 disables automatic deletion.")
+	cmd.Flags().DurationVarP(&activityBump, "activity-bump", "", 0, "Edit how far the deadline of running workspaces created from this template is pushed back while they have active sessions. 0 disables activity bumping.")
+	cmd.Flags().DurationVarP(&activityBumpThreshold, "activity-bump-threshold", "", 0, "Edit how close the deadline must be before activity pushes it back.")
+	cmd.Flags().BoolVarP(&requireActiveVersion, "require-active-version", "", false, "Edit whether workspaces created from this template are always started on its active version.")
+	cmd.Flags().IntVarP(&buildRetries, "build-retries", "", 0, "Edit how many times failed start builds of workspaces created from this template are retried. 0 disables retries.")
+	cmd.Flags().DurationVarP(&buildRetryBackoff, "build-retry-backoff", "", 0, "Edit the delay before the first retry of a failed start build. It doubles with every retry.")
+	cmd.Flags().BoolVarP(&rollbackFailedUpdates, "rollback-failed-updates", "", false, "Edit whether workspaces created from this template are rolled back to their last successful build when an update fails.")
+	cmd.Flags().BoolVarP(&allowWorkspaceNetwork, "allow-workspace-networking", "", false, "Edit whether agents of workspaces created from this template may connect to the other workspaces of their owner.")
 	cliui.AllowSkipPrompt(cmd)
 
 	return cmd
@@ -10,6 +10,7 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -92,6 +93,55 @@ func TestTemplateEdit(t *testing.T) {
 		assert.Equal(t, template.Icon, updated.Icon)
 		assert.Equal(t, template.DefaultTTLMillis, updated.DefaultTTLMillis)
 	})
+	t.Run("SchedulePolicy", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.DefaultTTLMillis = ptr.Ref(time.Hour.Milliseconds())
+		})
+
+		cmd, root := clitest.New(t, "templates", "edit", template.Name,
+			"--max-ttl", "8h",
+			"--min-autostart-interval", "24h",
+			"--quiet-hours", "CRON_TZ=Europe/London 0 2 * * *",
+			"--allow-user-autostop=false",
+			"--inactivity-ttl", "168h",
+			"--dormancy-deletion-ttl", "720h",
+			"--activity-bump", "2h",
+			"--activity-bump-threshold", "30m",
+			"--require-active-version",
+			"--build-retries", "3",
+			"--build-retry-backoff", "1m",
+			"--rollback-failed-updates",
+			"--allow-workspace-networking",
+		)
+		clitest.SetupConfig(t, client, root)
+
+		ctx, _ := testutil.Context(t)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		// The default TTL is kept since it wasn't edited.
+		updated, err := client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour.Milliseconds(), updated.DefaultTTLMillis)
+		assert.Equal(t, (8 * time.Hour).Milliseconds(), updated.MaxTTLMillis)
+		assert.Equal(t, (24 * time.Hour).Milliseconds(), updated.MinAutostartIntervalMillis)
+		assert.Equal(t, "CRON_TZ=Europe/London 0 2 * * *", updated.QuietHoursSchedule)
+		assert.False(t, updated.AllowUserAutostop)
+		assert.Equal(t, (168 * time.Hour).Milliseconds(), updated.InactivityTTLMillis)
+		assert.Equal(t, (720 * time.Hour).Milliseconds(), updated.DormancyDeletionTTLMillis)
+		assert.Equal(t, (2 * time.Hour).Milliseconds(), updated.ActivityBumpMillis)
+		assert.Equal(t, (30 * time.Minute).Milliseconds(), updated.ActivityBumpThresholdMillis)
+		assert.True(t, updated.RequireActiveVersion)
+		assert.Equal(t, 3, updated.BuildRetries)
+		assert.Equal(t, time.Minute.Milliseconds(), updated.BuildRetryBackoffMillis)
+		assert.True(t, updated.RollbackFailedUpdates)
+		assert.True(t, updated.AllowWorkspaceNetworking)
+	})
 	t.Run("InvalidDisplayName", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/cli/cliflag"
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/codersdk"
 )
@@ -31,6 +32,14 @@ func tokens() *cobra.Command {
 				Description: "Remove a token by ID",
 				Command:     "coder tokens rm WuoWs4ZsMX",
 			},
+			example{
+				Description: "List the tokens of every user",
+				Command:     "coder tokens ls --all --login-type token",
+			},
+			example{
+				Description: "Revoke every token and session of a user",
+				Command:     "coder tokens revoke --user alice",
+			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
@@ -40,12 +49,14 @@ func tokens() *cobra.Command {
 		createToken(),
 		listTokens(),
 		removeToken(),
+		revokeTokens(),
 	)
 
 	return cmd
 }
 
 func createToken() *cobra.Command {
+	var lifetime time.Duration
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a tokens",
@@ -55,7 +66,9 @@ func createToken() *cobra.Command {
 				return xerrors.Errorf("create codersdk client: %w", err)
 			}
 
-			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{})
+			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{
+				Lifetime: lifetime,
+			})
 			if err != nil {
 				return xerrors.Errorf("create tokens: %w", err)
 			}
@@ -74,9 +87,24 @@ func createToken() *cobra.Command {
 		},
 	}
 
+	cliflag.DurationVarP(cmd.Flags(), &lifetime, "lifetime", "", "CODER_TOKEN_LIFETIME", 0,
+		"Specify how long the token will be valid for. Defaults to the maximum token lifetime of the deployment, or 100 years if there is none.")
 	return cmd
 }
 
+// addAPIKeysFilterFlags adds the flags that filter the API keys of every
+// user.
+func addAPIKeysFilterFlags(cmd *cobra.Command, filter *codersdk.APIKeysFilter) {
+	cmd.Flags().StringVar(&filter.User, "user", "", "Only include keys owned by this username or user ID.")
+	cmd.Flags().StringVar((*string)(&filter.LoginType), "login-type", "",
+		`Only include keys with this login type. Tokens have the "token" login type, and sessions have the login type used to log in.`)
+	cmd.Flags().StringVar((*string)(&filter.Scope), "scope", "", "Only include keys with this scope.")
+	cmd.Flags().StringVar(&filter.LastUsedBefore, "last-used-before", "", "Only include keys last used before this date (YYYY-MM-DD).")
+	cmd.Flags().StringVar(&filter.LastUsedAfter, "last-used-after", "", "Only include keys last used after this date (YYYY-MM-DD).")
+	cmd.Flags().StringVar(&filter.ExpiresBefore, "expires-before", "", "Only include keys that expire before this date (YYYY-MM-DD).")
+	cmd.Flags().StringVar(&filter.ExpiresAfter, "expires-after", "", "Only include keys that expire after this date (YYYY-MM-DD).")
+}
+
 type tokenRow struct {
 	ID        string    `table:"ID"`
 	LastUsed  time.Time `table:"Last Used"`
@@ -84,7 +112,21 @@ type tokenRow struct {
 	CreatedAt time.Time `table:"Created At"`
 }
 
+type apiKeyRow struct {
+	ID        string    `table:"ID"`
+	Owner     string    `table:"Owner"`
+	LoginType string    `table:"Login Type"`
+	Scope     string    `table:"Scope"`
+	LastUsed  time.Time `table:"Last Used"`
+	ExpiresAt time.Time `table:"Expires At"`
+	CreatedAt time.Time `table:"Created At"`
+}
+
 func listTokens() *cobra.Command {
+	var (
+		all    bool
+		filter codersdk.APIKeysFilter
+	)
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -95,30 +137,66 @@ func listTokens() *cobra.Command {
 				return xerrors.Errorf("create codersdk client: %w", err)
 			}
 
-			keys, err := client.GetTokens(cmd.Context(), codersdk.Me)
-			if err != nil {
-				return xerrors.Errorf("create tokens: %w", err)
+			if filter != (codersdk.APIKeysFilter{}) && !all {
+				return xerrors.New("Filters can only be used with --all.")
 			}
 
-			if len(keys) == 0 {
-				cmd.Println(cliui.Styles.Wrap.Render(
-					"No tokens found.",
-				))
-			}
+			var out string
+			if all {
+				keys, err := client.APIKeys(cmd.Context(), filter)
+				if err != nil {
+					return xerrors.Errorf("list api keys: %w", err)
+				}
 
-			var rows []tokenRow
-			for _, key := range keys {
-				rows = append(rows, tokenRow{
-					ID:        key.ID,
-					LastUsed:  key.LastUsed,
-					ExpiresAt: key.ExpiresAt,
-					CreatedAt: key.CreatedAt,
-				})
-			}
+				if len(keys) == 0 {
+					cmd.Println(cliui.Styles.Wrap.Render(
+						"No tokens found.",
+					))
+				}
 
-			out, err := cliui.DisplayTable(rows, "", nil)
-			if err != nil {
-				return err
+				var rows []apiKeyRow
+				for _, key := range keys {
+					rows = append(rows, apiKeyRow{
+						ID:        key.ID,
+						Owner:     key.Username,
+						LoginType: string(key.LoginType),
+						Scope:     string(key.Scope),
+						LastUsed:  key.LastUsed,
+						ExpiresAt: key.ExpiresAt,
+						CreatedAt: key.CreatedAt,
+					})
+				}
+
+				out, err = cliui.DisplayTable(rows, "", nil)
+				if err != nil {
+					return err
+				}
+			} else {
+				keys, err := client.GetTokens(cmd.Context(), codersdk.Me)
+				if err != nil {
+					return xerrors.Errorf("create tokens: %w", err)
+				}
+
+				if len(keys) == 0 {
+					cmd.Println(cliui.Styles.Wrap.Render(
+						"No tokens found.",
+					))
+				}
+
+				var rows []tokenRow
+				for _, key := range keys {
+					rows = append(rows, tokenRow{
+						ID:        key.ID,
+						LastUsed:  key.LastUsed,
+						ExpiresAt: key.ExpiresAt,
+						CreatedAt: key.CreatedAt,
+					})
+				}
+
+				out, err = cliui.DisplayTable(rows, "", nil)
+				if err != nil {
+					return err
+				}
 			}
 
 			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
@@ -126,6 +204,9 @@ func listTokens() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVarP(&all, "all", "a", false,
+		"List the tokens and sessions of every user. Requires the owner role.")
+	addAPIKeysFilterFlags(cmd, &filter)
 	return cmd
 }
 
@@ -156,3 +237,75 @@ func removeToken() *cobra.Command {
 
 	return cmd
 }
+
+func revokeTokens() *cobra.Command {
+	var filter codersdk.APIKeysFilter
+	cmd := &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke a token of any user, or every token and session that matches the filters",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			var ids []string
+			switch {
+			case len(args) == 1 && filter != (codersdk.APIKeysFilter{}):
+				return xerrors.New("Specify either a token ID or filters, not both.")
+			case len(args) == 1:
+				ids = append(ids, args[0])
+			case filter != (codersdk.APIKeysFilter{}):
+				keys, err := client.APIKeys(cmd.Context(), filter)
+				if err != nil {
+					return xerrors.Errorf("list api keys: %w", err)
+				}
+				if len(keys) == 0 {
+					cmd.Println(cliui.Styles.Wrap.Render(
+						"No tokens found.",
+					))
+					return nil
+				}
+				// Revoke the session of this CLI last, in case it matches.
+				sessionID, _, _ := strings.Cut(client.SessionToken(), "-")
+				for _, key := range keys {
+					if key.ID != sessionID {
+						ids = append(ids, key.ID)
+					}
+				}
+				if len(ids) < len(keys) {
+					ids = append(ids, sessionID)
+				}
+			default:
+				return xerrors.New("Specify a token ID or at least one filter.")
+			}
+
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Revoke %d token(s)?", len(ids)),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				err = client.RevokeAPIKey(cmd.Context(), id)
+				if err != nil {
+					return xerrors.Errorf("revoke api key %q: %w", id, err)
+				}
+			}
+
+			cmd.Println(cliui.Styles.Wrap.Render(
+				fmt.Sprintf("%d token(s) have been revoked.", len(ids)),
+			))
+
+			return nil
+		},
+	}
+
+	addAPIKeysFilterFlags(cmd, &filter)
+	cliui.AllowSkipPrompt(cmd)
+	return cmd
+}
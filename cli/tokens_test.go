@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
 	"regexp"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
 )
 
 func TestTokens(t *testing.T) {
@@ -64,3 +66,44 @@ func TestTokens(t *testing.T) {
 	require.NotEmpty(t, res)
 	require.Contains(t, res, "deleted")
 }
+
+func TestTokensAll(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	_, err := other.CreateToken(context.Background(), codersdk.Me, codersdk.CreateTokenRequest{})
+	require.NoError(t, err)
+	otherUser, err := other.User(context.Background(), codersdk.Me)
+	require.NoError(t, err)
+
+	cmd, root := clitest.New(t, "tokens", "ls", "--all", "--login-type", "token")
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	res := buf.String()
+	require.Contains(t, res, "OWNER")
+	require.Contains(t, res, otherUser.Username)
+
+	// Filters require --all.
+	cmd, root = clitest.New(t, "tokens", "ls", "--user", otherUser.Username)
+	clitest.SetupConfig(t, client, root)
+	err = cmd.Execute()
+	require.Error(t, err)
+
+	cmd, root = clitest.New(t, "tokens", "revoke", "--user", otherUser.Username, "--yes")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "2 token(s) have been revoked")
+
+	keys, err := client.APIKeys(context.Background(), codersdk.APIKeysFilter{
+		User: otherUser.Username,
+	})
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
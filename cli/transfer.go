@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func transferWorkspace() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "transfer",
+		Short:       "Transfer workspaces to other users",
+		Long: "Transferring a workspace makes another user its owner, and builds it again for them. " +
+			"Users who may create workspaces for the new owner, e.g. admins, transfer workspaces immediately. " +
+			"Otherwise the new owner has to accept the transfer.",
+		Example: formatExamples(
+			example{
+				Description: "Transfer a workspace to another user",
+				Command:     "coder transfer create my-workspace bob",
+			},
+			example{
+				Description: "List the workspaces being transferred to you",
+				Command:     "coder transfer ls",
+			},
+			example{
+				Description: "Accept a workspace transferred to you",
+				Command:     "coder transfer accept alice/my-workspace",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		createTransfer(),
+		listTransfers(),
+		acceptTransfer(),
+		declineTransfer(),
+		cancelTransfer(),
+	)
+	return cmd
+}
+
+func createTransfer() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <workspace> <user>",
+		Short: "Transfer a workspace to another user",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return xerrors.Errorf("get workspace: %w", err)
+			}
+			user, err := client.User(cmd.Context(), args[1])
+			if err != nil {
+				return xerrors.Errorf("get user: %w", err)
+			}
+
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Transfer workspace %s to %s? It will be built again for its new owner.", workspace.Name, user.Username),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			})
+			if err != nil {
+				return err
+			}
+
+			transfer, err := client.TransferWorkspace(cmd.Context(), workspace.ID, codersdk.TransferWorkspaceRequest{
+				OwnerID: user.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("transfer workspace: %w", err)
+			}
+			if transfer.Status == codersdk.WorkspaceTransferStatusPending {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nThe %s workspace will be transferred once %s accepts it.\n",
+					cliui.Styles.Keyword.Render(workspace.Name), cliui.Styles.Keyword.Render(user.Username))
+				return nil
+			}
+			err = cliui.WorkspaceBuild(cmd.Context(), cmd.OutOrStdout(), client, *transfer.BuildID)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nThe %s workspace has been transferred to %s!\n",
+				cliui.Styles.Keyword.Render(workspace.Name), cliui.Styles.Keyword.Render(user.Username))
+			return nil
+		},
+	}
+	cliui.AllowSkipPrompt(cmd)
+	return cmd
+}
+
+type transferRow struct {
+	Workspace string `table:"workspace"`
+	From      string `table:"from"`
+	CreatedAt string `table:"created at"`
+}
+
+func listTransfers() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List the workspaces being transferred to you",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			transfers, err := client.WorkspaceTransfers(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("get workspace transfers: %w", err)
+			}
+			if len(transfers) == 0 {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "No workspaces are being transferred to you.")
+				return nil
+			}
+			rows := make([]transferRow, 0, len(transfers))
+			for _, transfer := range transfers {
+				rows = append(rows, transferRow{
+					Workspace: transfer.WorkspaceName,
+					From:      transfer.FromUsername,
+					CreatedAt: transfer.CreatedAt.Format("January 2, 2006"),
+				})
+			}
+			out, err := cliui.DisplayTable(rows, "workspace", nil)
+			if err != nil {
+				return xerrors.Errorf("render table: %w", err)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+}
+
+func acceptTransfer() *cobra.Command {
+	return &cobra.Command{
+		Use:   "accept <owner>/<workspace>",
+		Short: "Accept a workspace transferred to you",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			transfer, err := incomingTransfer(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			transfer, err = client.AcceptWorkspaceTransfer(cmd.Context(), transfer.WorkspaceID)
+			if err != nil {
+				return xerrors.Errorf("accept workspace transfer: %w", err)
+			}
+			err = cliui.WorkspaceBuild(cmd.Context(), cmd.OutOrStdout(), client, *transfer.BuildID)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nYou are now the owner of the %s workspace!\n", cliui.Styles.Keyword.Render(transfer.WorkspaceName))
+			return nil
+		},
+	}
+}
+
+func declineTransfer() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decline <owner>/<workspace>",
+		Short: "Decline a workspace transferred to you",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			transfer, err := incomingTransfer(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			err = client.DeleteWorkspaceTransfer(cmd.Context(), transfer.WorkspaceID)
+			if err != nil {
+				return xerrors.Errorf("decline workspace transfer: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Declined the %s workspace.\n", cliui.Styles.Keyword.Render(transfer.WorkspaceName))
+			return nil
+		},
+	}
+}
+
+func cancelTransfer() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <workspace>",
+		Short: "Cancel the transfer of a workspace awaiting acceptance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return xerrors.Errorf("get workspace: %w", err)
+			}
+			err = client.DeleteWorkspaceTransfer(cmd.Context(), workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("cancel workspace transfer: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Canceled the transfer of the %s workspace.\n", cliui.Styles.Keyword.Render(workspace.Name))
+			return nil
+		},
+	}
+}
+
+// incomingTransfer finds the transfer of a workspace to the authenticated
+// user. Users can't read the workspaces of others, so it's looked up among
+// their transfers instead of by name.
+func incomingTransfer(cmd *cobra.Command, client *codersdk.Client, identifier string) (codersdk.WorkspaceTransfer, error) {
+	owner, name, ok := strings.Cut(identifier, "/")
+	if !ok {
+		owner, name = "", identifier
+	}
+	transfers, err := client.WorkspaceTransfers(cmd.Context(), codersdk.Me)
+	if err != nil {
+		return codersdk.WorkspaceTransfer{}, xerrors.Errorf("get workspace transfers: %w", err)
+	}
+	var found []codersdk.WorkspaceTransfer
+	for _, transfer := range transfers {
+		if transfer.WorkspaceName == name && (owner == "" || transfer.FromUsername == owner) {
+			found = append(found, transfer)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return codersdk.WorkspaceTransfer{}, xerrors.Errorf("no workspace %q is being transferred to you", identifier)
+	case 1:
+		return found[0], nil
+	default:
+		return codersdk.WorkspaceTransfer{}, xerrors.Errorf("several workspaces named %q are being transferred to you, use <owner>/<workspace>", name)
+	}
+}
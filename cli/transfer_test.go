@@ -0,0 +1,87 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTransfer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Admin", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		cmd, root := clitest.New(t, "transfer", "create", workspace.Name, member.Username, "--yes")
+		clitest.SetupConfig(t, client, root)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), "has been transferred")
+
+		workspace, err := client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, member.ID, workspace.OwnerID)
+		require.Equal(t, codersdk.BuildReasonTransfer, workspace.LatestBuild.Reason)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		fromClient, fromUser := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		toClient, toUser := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, fromClient, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		cmd, root := clitest.New(t, "transfer", "create", workspace.Name, toUser.Username, "--yes")
+		clitest.SetupConfig(t, fromClient, root)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), "once "+toUser.Username+" accepts it")
+
+		cmd, root = clitest.New(t, "transfer", "ls")
+		clitest.SetupConfig(t, toClient, root)
+		stdout = &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), workspace.Name)
+		require.Contains(t, stdout.String(), fromUser.Username)
+
+		cmd, root = clitest.New(t, "transfer", "accept", fromUser.Username+"/"+workspace.Name)
+		clitest.SetupConfig(t, toClient, root)
+		stdout = &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		require.NoError(t, cmd.ExecuteContext(ctx))
+		require.Contains(t, stdout.String(), "You are now the owner")
+
+		workspace, err := toClient.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, toUser.ID, workspace.OwnerID)
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -13,18 +15,29 @@ func update() *cobra.Command {
 	var (
 		parameterFile string
 		alwaysPrompt  bool
+		batch         workspaceBatchFlags
 	)
 
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "update <workspace>",
-		Args:        cobra.ExactArgs(1),
+		Args:        cobra.MaximumNArgs(1),
 		Short:       "Update a workspace",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			isBatch, err := batch.args(args)
+			if err != nil {
+				return err
+			}
 			client, err := CreateClient(cmd)
 			if err != nil {
 				return err
 			}
+			if isBatch {
+				if alwaysPrompt || parameterFile != "" {
+					return xerrors.New("parameters can't be set with --search")
+				}
+				return batch.run(cmd, client, codersdk.WorkspaceBatchActionUpdate)
+			}
 			workspace, err := namedWorkspace(cmd, client, args[0])
 			if err != nil {
 				return err
@@ -82,5 +95,7 @@ func update() *cobra.Command {
 
 	cmd.Flags().BoolVar(&alwaysPrompt, "always-prompt", false, "Always prompt all parameters. Does not pull parameter values from existing workspace")
 	cliflag.StringVarP(cmd.Flags(), &parameterFile, "parameter-file", "", "CODER_PARAMETER_FILE", "", "Specify a file path with parameter values.")
+	batch.attach(cmd)
+	cliui.AllowSkipPrompt(cmd)
 	return cmd
 }
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func userInvite() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "invite <email>",
+		Short: "Invite a new user to sign up by email",
+		Args:  cobra.ExactArgs(1),
+		Example: formatExamples(
+			example{
+				Command: "coder users invite colin@coder.com",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			organization, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return err
+			}
+
+			invitation, err := client.CreateUserInvitation(cmd.Context(), codersdk.CreateUserInvitationRequest{
+				Email:          args[0],
+				OrganizationID: organization.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("create invitation: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "An invitation has been sent to %s! It expires on %s.\n",
+				cliui.Styles.Keyword.Render(invitation.Email), invitation.ExpiresAt.Local().Format("Jan 2 15:04 MST"))
+			return nil
+		},
+	}
+	return cmd
+}
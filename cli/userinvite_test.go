@@ -0,0 +1,84 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+// emailTokenRegex matches the token in invitation and password reset emails.
+var emailTokenRegex = regexp.MustCompile(`[0-9a-zA-Z]{10}-[0-9a-zA-Z]{22}`)
+
+func TestUserInvite(t *testing.T) {
+	t.Parallel()
+
+	srv := mailertest.New(t)
+	client := coderdtest.New(t, &coderdtest.Options{
+		Mailer: &mailer.SMTP{
+			Addr: srv.Addr,
+			From: "coder@coder.com",
+		},
+	})
+	coderdtest.CreateFirstUser(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	cmd, root := clitest.New(t, "users", "invite", "invited@coder.com")
+	clitest.SetupConfig(t, client, root)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	err := cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "invited@coder.com")
+
+	messages := srv.Messages()
+	require.Len(t, messages, 1)
+	token := emailTokenRegex.FindString(messages[0].Body)
+	require.NotEmpty(t, token)
+	require.Contains(t, messages[0].Body, "--invitation "+token)
+
+	// The invited user signs up with the token from the email.
+	doneChan := make(chan struct{})
+	cmd, root = clitest.New(t, "login", "--force-tty", client.URL.String(), "--invitation", token)
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	go func() {
+		defer close(doneChan)
+		err := cmd.ExecuteContext(ctx)
+		assert.NoError(t, err)
+	}()
+	matches := []string{
+		"username", "invited",
+		"password", "SomeSecurePassword!",
+		"password", "SomeSecurePassword!", // Confirm.
+	}
+	for i := 0; i < len(matches); i += 2 {
+		pty.ExpectMatch(matches[i])
+		pty.WriteLine(matches[i+1])
+	}
+	pty.ExpectMatch("Welcome to Coder")
+	<-doneChan
+
+	invited := codersdk.New(client.URL)
+	sessionToken, err := root.Session().Read()
+	require.NoError(t, err)
+	invited.SetSessionToken(sessionToken)
+	user, err := invited.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Equal(t, "invited", user.Username)
+	require.Equal(t, "invited@coder.com", user.Email)
+}
@@ -21,6 +21,8 @@ func users() *cobra.Command {
 		userSingle(),
 		createUserStatusCommand(codersdk.UserStatusActive),
 		createUserStatusCommand(codersdk.UserStatusSuspended),
+		userResetTwoFactor(),
+		userInvite(),
 	)
 	return cmd
 }
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func userResetTwoFactor() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset-two-factor <username|user_id>",
+		Short: "Remove two-factor authentication from a user so they can enroll again, e.g. after losing their device",
+		Args:  cobra.ExactArgs(1),
+		Example: formatExamples(
+			example{
+				Command: "coder users reset-two-factor example_user",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			user, err := client.User(cmd.Context(), args[0])
+			if err != nil {
+				return xerrors.Errorf("fetch user: %w", err)
+			}
+
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Are you sure you want to reset two-factor authentication for %s?", cliui.Styles.Keyword.Render(user.Username)),
+				IsConfirm: true,
+				Default:   cliui.ConfirmYes,
+			})
+			if err != nil {
+				return err
+			}
+
+			var req codersdk.ResetUserTwoFactorRequest
+			me, err := client.User(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("fetch current user: %w", err)
+			}
+			// Removing two-factor authentication from your own account
+			// requires proving you're in control of it.
+			if me.ID == user.ID {
+				req.Password, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:   "Password:",
+					Secret: true,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			err = client.ResetUserTwoFactor(cmd.Context(), user.ID.String(), req)
+			if err != nil {
+				return xerrors.Errorf("reset two-factor authentication: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nTwo-factor authentication for %s has been reset!\n", cliui.Styles.Keyword.Render(user.Username))
+			return nil
+		},
+	}
+	return cmd
+}
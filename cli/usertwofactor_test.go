@@ -0,0 +1,44 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/totp"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestUserResetTwoFactor(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, nil)
+	admin := coderdtest.CreateFirstUser(t, client)
+	other, user := coderdtest.CreateAnotherUserWithUser(t, client, admin.OrganizationID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	enrollment, err := other.EnrollUserTwoFactor(ctx, codersdk.Me)
+	require.NoError(t, err)
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	_, err = other.VerifyUserTwoFactor(ctx, codersdk.Me, codersdk.VerifyUserTwoFactorRequest{Code: code})
+	require.NoError(t, err)
+
+	cmd, root := clitest.New(t, "users", "reset-two-factor", user.Username)
+	clitest.SetupConfig(t, client, root)
+	cmd.SetIn(bytes.NewReader([]byte("yes\n")))
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+
+	status, err := other.UserTwoFactor(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.False(t, status.Enabled)
+}
@@ -0,0 +1,626 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"tailscale.com/net/socks5"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/retry"
+)
+
+// vpnDomain is the domain workspaces are reachable at through coder vpn, as
+// <workspace>.coder or <workspace>.<agent>.coder.
+const vpnDomain = ".coder"
+
+// vpnWorkspacePollInterval is how often the workspaces of the user are listed
+// to pick up new ones. Known workspaces are watched for changes instead.
+var vpnWorkspacePollInterval = 30 * time.Second
+
+func vpn() *cobra.Command {
+	var (
+		socksAddress string
+		httpAddress  string
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "vpn",
+		Short:       "Reach your running workspaces by name through a local proxy",
+		Long: "Connect to all of your running workspaces and serve a SOCKS5 proxy, and optionally an HTTP proxy, " +
+			"that resolves <workspace>.coder and <workspace>.<agent>.coder. Other hosts are dialed directly, " +
+			"so the proxy can be used for all traffic. No root privileges are required.",
+		Args: cobra.NoArgs,
+		Example: formatExamples(
+			example{
+				Description: "Serve a SOCKS5 proxy on 127.0.0.1:1080 and reach port 8080 of the workspace \"dev\"",
+				Command:     "coder vpn\ncurl --proxy socks5h://127.0.0.1:1080 http://dev.coder:8080",
+			},
+			example{
+				Description: "Also serve an HTTP proxy for tools that don't support SOCKS5",
+				Command:     "coder vpn --http-address 127.0.0.1:8888\nhttps_proxy=http://127.0.0.1:8888 git clone https://dev.coder:3000/repo.git",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			logger := slog.Make()
+			if cliflag.IsSetBool(cmd, varVerbose) {
+				logger = slog.Make(sloghuman.Sink(cmd.ErrOrStderr())).Leveled(slog.LevelDebug)
+			}
+
+			tunnel := newVPNTunnel(ctx, client, logger, cmd.ErrOrStderr(), cliflag.IsSetBool(cmd, varDisableDirect))
+			defer tunnel.Close()
+
+			var (
+				wg        = new(sync.WaitGroup)
+				listeners []net.Listener
+			)
+			defer func() {
+				for _, listener := range listeners {
+					_ = listener.Close()
+				}
+			}()
+
+			socksListener, err := net.Listen("tcp", socksAddress)
+			if err != nil {
+				return xerrors.Errorf("listen for socks5 connections on %q: %w", socksAddress, err)
+			}
+			listeners = append(listeners, socksListener)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				server := &socks5.Server{
+					Logf:   tailnet.Logger(logger.Named("socks5")),
+					Dialer: tunnel.dial,
+				}
+				_ = server.Serve(socksListener)
+			}()
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "SOCKS5 proxy listening on %s\n", socksListener.Addr())
+
+			if httpAddress != "" {
+				httpListener, err := net.Listen("tcp", httpAddress)
+				if err != nil {
+					return xerrors.Errorf("listen for http connections on %q: %w", httpAddress, err)
+				}
+				listeners = append(listeners, httpListener)
+				server := &http.Server{
+					Handler:           tunnel.httpProxy(),
+					ReadHeaderTimeout: 20 * time.Second,
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_ = server.Serve(httpListener)
+				}()
+				defer server.Close()
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "HTTP proxy listening on %s\n", httpListener.Addr())
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tunnel.run()
+			}()
+
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigs)
+			var closeErr error
+			select {
+			case <-ctx.Done():
+				closeErr = ctx.Err()
+			case <-sigs:
+				_, _ = fmt.Fprintln(cmd.OutOrStderr(), "\nReceived signal, closing all connections")
+			}
+			cancel()
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+			wg.Wait()
+			tunnel.Close()
+			return closeErr
+		},
+	}
+
+	cliflag.StringVarP(cmd.Flags(), &socksAddress, "socks-address", "", "CODER_VPN_SOCKS_ADDRESS", "127.0.0.1:1080", "The address to serve the SOCKS5 proxy on.")
+	cliflag.StringVarP(cmd.Flags(), &httpAddress, "http-address", "", "CODER_VPN_HTTP_ADDRESS", "", "The address to serve an HTTP proxy on, which supports CONNECT. Disabled if empty.")
+	return cmd
+}
+
+// vpnTunnel keeps a single tailnet connection to every agent of the running
+// workspaces of the user. Agents share codersdk.TailnetIP, so they're dialed
+// at codersdk.WorkspaceAgentIP instead.
+type vpnTunnel struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	client         *codersdk.Client
+	logger         slog.Logger
+	out            io.Writer
+	blockEndpoints bool
+	wg             sync.WaitGroup
+	// transport forwards requests to the HTTP proxy that aren't tunneled.
+	transport *http.Transport
+
+	connMutex sync.Mutex
+	conn      *tailnet.Conn
+
+	mutex sync.Mutex
+	// node is the last node of the connection, as sent to agents.
+	node *tailnet.Node
+	// watches are the IDs of the workspaces being watched.
+	watches map[uuid.UUID]struct{}
+	// workspaces are the running workspaces, by ID.
+	workspaces map[uuid.UUID]vpnWorkspace
+	agents     map[uuid.UUID]*vpnAgent
+}
+
+type vpnWorkspace struct {
+	name   string
+	agents []codersdk.WorkspaceAgent
+}
+
+// vpnAgent is the coordination with a single agent.
+type vpnAgent struct {
+	cancel context.CancelFunc
+	// sendNode is set while connected to the coordinator.
+	sendNode func(node *tailnet.Node)
+	// sendMutex orders sends, so a stale node never replaces a newer one.
+	sendMutex sync.Mutex
+	// ready is closed once the node of the agent is known.
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func newVPNTunnel(ctx context.Context, client *codersdk.Client, logger slog.Logger, out io.Writer, blockEndpoints bool) *vpnTunnel {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &vpnTunnel{
+		ctx:            ctx,
+		cancel:         cancel,
+		client:         client,
+		logger:         logger,
+		out:            out,
+		blockEndpoints: blockEndpoints,
+		watches:        map[uuid.UUID]struct{}{},
+		workspaces:     map[uuid.UUID]vpnWorkspace{},
+		agents:         map[uuid.UUID]*vpnAgent{},
+	}
+	t.transport = &http.Transport{
+		DialContext: t.dial,
+	}
+	return t
+}
+
+// run watches the workspaces of the user until the tunnel is closed.
+func (t *vpnTunnel) run() {
+	ticker := time.NewTicker(vpnWorkspacePollInterval)
+	defer ticker.Stop()
+	for {
+		err := t.watchWorkspaces()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			_, _ = fmt.Fprintln(t.out, cliui.Styles.Warn.Render("Failed to list workspaces: "+err.Error()))
+		}
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close disconnects from all workspaces.
+func (t *vpnTunnel) Close() {
+	t.cancel()
+	t.wg.Wait()
+	t.transport.CloseIdleConnections()
+	t.connMutex.Lock()
+	defer t.connMutex.Unlock()
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+}
+
+// watchWorkspaces starts watching the workspaces of the user that aren't
+// watched yet.
+func (t *vpnTunnel) watchWorkspaces() error {
+	res, err := t.client.Workspaces(t.ctx, codersdk.WorkspaceFilter{
+		Owner: codersdk.Me,
+	})
+	if err != nil {
+		return err
+	}
+	listed := make(map[uuid.UUID]struct{}, len(res.Workspaces))
+	for _, workspace := range res.Workspaces {
+		listed[workspace.ID] = struct{}{}
+	}
+	t.dropUnlisted(listed)
+	for _, workspace := range res.Workspaces {
+		t.mutex.Lock()
+		_, watching := t.watches[workspace.ID]
+		t.watches[workspace.ID] = struct{}{}
+		t.mutex.Unlock()
+		if watching {
+			continue
+		}
+		t.update(workspace)
+		t.wg.Add(1)
+		go t.watchWorkspace(workspace.ID)
+	}
+	return nil
+}
+
+// dropUnlisted disconnects from workspaces that are no longer watched and
+// weren't listed, e.g. because they were deleted while their watch was
+// closed.
+func (t *vpnTunnel) dropUnlisted(listed map[uuid.UUID]struct{}) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for workspaceID, workspace := range t.workspaces {
+		if _, ok := listed[workspaceID]; ok {
+			continue
+		}
+		if _, ok := t.watches[workspaceID]; ok {
+			continue
+		}
+		for _, workspaceAgent := range workspace.agents {
+			if vpnAgent, ok := t.agents[workspaceAgent.ID]; ok {
+				vpnAgent.cancel()
+				delete(t.agents, workspaceAgent.ID)
+			}
+		}
+		delete(t.workspaces, workspaceID)
+		_, _ = fmt.Fprintf(t.out, "Workspace %s is gone\n", workspace.name)
+	}
+}
+
+func (t *vpnTunnel) watchWorkspace(workspaceID uuid.UUID) {
+	defer t.wg.Done()
+	defer func() {
+		// The workspace is watched again when the workspaces are listed
+		// next, or dropped if it's no longer listed.
+		t.mutex.Lock()
+		delete(t.watches, workspaceID)
+		t.mutex.Unlock()
+	}()
+	updates, err := t.client.WatchWorkspace(t.ctx, workspaceID)
+	if err != nil {
+		t.logger.Debug(t.ctx, "watch workspace", slog.F("workspace_id", workspaceID), slog.Error(err))
+		return
+	}
+	for workspace := range updates {
+		t.update(workspace)
+	}
+}
+
+// update connects to the agents of a workspace once it's started, and
+// disconnects once it's stopped.
+func (t *vpnTunnel) update(workspace codersdk.Workspace) {
+	var agents []codersdk.WorkspaceAgent
+	if workspace.LatestBuild.Transition == codersdk.WorkspaceTransitionStart &&
+		workspace.LatestBuild.Job.Status == codersdk.ProvisionerJobSucceeded {
+		for _, resource := range workspace.LatestBuild.Resources {
+			agents = append(agents, resource.Agents...)
+		}
+	}
+	var conn *tailnet.Conn
+	if len(agents) > 0 {
+		var err error
+		conn, err = t.ensureConn(agents[0].ID)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				_, _ = fmt.Fprintln(t.out, cliui.Styles.Warn.Render(fmt.Sprintf("Failed to connect to %s: %s", workspace.Name, err)))
+			}
+			return
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.ctx.Err() != nil {
+		return
+	}
+	previous, wasRunning := t.workspaces[workspace.ID]
+	running := map[uuid.UUID]struct{}{}
+	for _, workspaceAgent := range agents {
+		running[workspaceAgent.ID] = struct{}{}
+		if _, ok := t.agents[workspaceAgent.ID]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(t.ctx)
+		vpnAgent := &vpnAgent{
+			cancel: cancel,
+			ready:  make(chan struct{}),
+		}
+		t.agents[workspaceAgent.ID] = vpnAgent
+		t.wg.Add(1)
+		go t.coordinate(ctx, conn, workspaceAgent.ID, vpnAgent)
+	}
+	for _, workspaceAgent := range previous.agents {
+		if _, ok := running[workspaceAgent.ID]; ok {
+			continue
+		}
+		if vpnAgent, ok := t.agents[workspaceAgent.ID]; ok {
+			vpnAgent.cancel()
+			delete(t.agents, workspaceAgent.ID)
+		}
+	}
+
+	if len(agents) == 0 {
+		delete(t.workspaces, workspace.ID)
+		if wasRunning {
+			_, _ = fmt.Fprintf(t.out, "Workspace %s stopped\n", workspace.Name)
+		}
+		return
+	}
+	t.workspaces[workspace.ID] = vpnWorkspace{
+		name:   workspace.Name,
+		agents: agents,
+	}
+	if !wasRunning {
+		_, _ = fmt.Fprintf(t.out, "Workspace %s is reachable at %s\n", workspace.Name, strings.Join(vpnNames(workspace.Name, agents), ", "))
+	}
+}
+
+// ensureConn creates the tailnet connection with the connection info of the
+// first agent.
+func (t *vpnTunnel) ensureConn(agentID uuid.UUID) (*tailnet.Conn, error) {
+	t.connMutex.Lock()
+	defer t.connMutex.Unlock()
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	connInfo, err := t.client.WorkspaceAgentConnectionInfo(t.ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := tailnet.NewConn(&tailnet.Options{
+		Addresses:      []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
+		DERPMap:        connInfo.DERPMap,
+		Logger:         t.logger.Named("tailnet"),
+		BlockEndpoints: t.blockEndpoints || connInfo.DisableDirectConnections,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("create tailnet: %w", err)
+	}
+	conn.SetNodeCallback(t.setNode)
+	t.conn = conn
+
+	// Regions managed through the API change the DERP map while the
+	// connection is open.
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		maps, err := t.client.WatchWorkspaceAgentDERPMap(t.ctx, agentID)
+		if err != nil {
+			t.logger.Debug(t.ctx, "watch derp map", slog.Error(err))
+			return
+		}
+		for derpMap := range maps {
+			conn.SetDERPMap(derpMap)
+		}
+	}()
+	return conn, nil
+}
+
+// setNode sends the node of the connection to every agent.
+func (t *vpnTunnel) setNode(node *tailnet.Node) {
+	t.mutex.Lock()
+	t.node = node
+	agents := make([]*vpnAgent, 0, len(t.agents))
+	for _, vpnAgent := range t.agents {
+		agents = append(agents, vpnAgent)
+	}
+	t.mutex.Unlock()
+
+	for _, vpnAgent := range agents {
+		t.sendNode(vpnAgent)
+	}
+}
+
+// sendNode sends the latest node of the connection to an agent, if both are
+// known yet.
+func (t *vpnTunnel) sendNode(vpnAgent *vpnAgent) {
+	vpnAgent.sendMutex.Lock()
+	defer vpnAgent.sendMutex.Unlock()
+	t.mutex.Lock()
+	node, sendNode := t.node, vpnAgent.sendNode
+	t.mutex.Unlock()
+	if node != nil && sendNode != nil {
+		sendNode(node)
+	}
+}
+
+// coordinate exchanges nodes with an agent until ctx is canceled,
+// reconnecting to the coordinator as necessary.
+func (t *vpnTunnel) coordinate(ctx context.Context, conn *tailnet.Conn, agentID uuid.UUID, vpnAgent *vpnAgent) {
+	defer t.wg.Done()
+	for retrier := retry.New(50*time.Millisecond, 10*time.Second); retrier.Wait(ctx); {
+		coordinator, err := t.client.DialWorkspaceAgentCoordinator(ctx, agentID)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				t.logger.Debug(ctx, "dial coordinator", slog.F("agent_id", agentID), slog.Error(err))
+			}
+			continue
+		}
+		sendNode, errChan := tailnet.ServeCoordinator(coordinator, func(nodes []*tailnet.Node) error {
+			err := conn.UpdateNodes(codersdk.WithoutTailnetIP(nodes))
+			vpnAgent.readyOnce.Do(func() {
+				close(vpnAgent.ready)
+			})
+			return err
+		})
+		t.mutex.Lock()
+		vpnAgent.sendNode = sendNode
+		t.mutex.Unlock()
+		t.sendNode(vpnAgent)
+
+		select {
+		case <-ctx.Done():
+		case err = <-errChan:
+			t.logger.Debug(ctx, "coordinator disconnected", slog.F("agent_id", agentID), slog.Error(err))
+		}
+		t.mutex.Lock()
+		vpnAgent.sendNode = nil
+		t.mutex.Unlock()
+		_ = coordinator.Close()
+	}
+}
+
+// dial connects to an agent for names in vpnDomain, and directly to other
+// hosts.
+func (t *vpnTunnel) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, rawPort, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.HasSuffix(host, vpnDomain) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, address)
+	}
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return nil, xerrors.Errorf("parse port %q: %w", rawPort, err)
+	}
+	agentID, err := t.resolve(strings.TrimSuffix(host, vpnDomain))
+	if err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	vpnAgent, ok := t.agents[agentID]
+	t.mutex.Unlock()
+	if !ok {
+		return nil, xerrors.Errorf("not connected to %q", host)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-vpnAgent.ready:
+	}
+	t.connMutex.Lock()
+	conn := t.conn
+	t.connMutex.Unlock()
+	if conn == nil {
+		return nil, xerrors.New("tunnel closed")
+	}
+	tcpConn, err := conn.DialContextTCP(ctx, netip.AddrPortFrom(codersdk.WorkspaceAgentIP(agentID), uint16(port)))
+	if err != nil {
+		return nil, err
+	}
+	return tcpConn, nil
+}
+
+// resolve finds the agent for a name of the form <workspace>.<agent>. The
+// agent may be omitted for workspaces with a single agent.
+func (t *vpnTunnel) resolve(name string) (uuid.UUID, error) {
+	workspaceName, agentName, _ := strings.Cut(name, ".")
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, workspace := range t.workspaces {
+		if workspace.name != workspaceName {
+			continue
+		}
+		if agentName == "" {
+			if len(workspace.agents) > 1 {
+				return uuid.Nil, xerrors.Errorf("workspace %q has multiple agents, use <workspace>.<agent>%s", workspaceName, vpnDomain)
+			}
+			return workspace.agents[0].ID, nil
+		}
+		for _, workspaceAgent := range workspace.agents {
+			if workspaceAgent.Name == agentName {
+				return workspaceAgent.ID, nil
+			}
+		}
+	}
+	return uuid.Nil, xerrors.Errorf("no running workspace agent found for %q", name+vpnDomain)
+}
+
+// httpProxy serves an HTTP proxy that dials through the tunnel. CONNECT
+// requests are tunneled, and other requests are forwarded.
+func (t *vpnTunnel) httpProxy() http.Handler {
+	forward := &httputil.ReverseProxy{
+		// Requests to proxies have absolute URLs, so they're forwarded as is.
+		Director:  func(*http.Request) {},
+		Transport: t.transport,
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			if !r.URL.IsAbs() {
+				http.Error(rw, "This is a proxy, requests must have an absolute URL.", http.StatusBadRequest)
+				return
+			}
+			forward.ServeHTTP(rw, r)
+			return
+		}
+		target, err := t.dial(r.Context(), "tcp", r.Host)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadGateway)
+			return
+		}
+		hijacker, ok := rw.(http.Hijacker)
+		if !ok {
+			_ = target.Close()
+			http.Error(rw, "Connection can't be hijacked.", http.StatusInternalServerError)
+			return
+		}
+		conn, buffered, err := hijacker.Hijack()
+		if err != nil {
+			_ = target.Close()
+			return
+		}
+		_, err = conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		if err == nil && buffered.Reader.Buffered() > 0 {
+			// The client may have sent data along with the request.
+			_, err = io.CopyN(target, buffered, int64(buffered.Reader.Buffered()))
+		}
+		if err != nil {
+			_ = conn.Close()
+			_ = target.Close()
+			return
+		}
+		agent.Bicopy(t.ctx, conn, target)
+	})
+}
+
+// vpnNames returns the names the agents of a workspace are reachable at.
+func vpnNames(workspaceName string, agents []codersdk.WorkspaceAgent) []string {
+	var names []string
+	if len(agents) == 1 {
+		names = append(names, workspaceName+vpnDomain)
+	}
+	for _, workspaceAgent := range agents {
+		names = append(names, workspaceName+"."+workspaceAgent.Name+vpnDomain)
+	}
+	sort.Strings(names[len(names)-len(agents):])
+	return names
+}
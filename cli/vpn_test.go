@@ -0,0 +1,99 @@
+package cli_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestVPN(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+
+	// The agent dials ports on its own host, which is this one.
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	socksAddress := freeTCPAddress(t)
+	httpAddress := freeTCPAddress(t)
+	cmd, root := clitest.New(t, "vpn", "--socks-address", socksAddress, "--http-address", httpAddress)
+	clitest.SetupConfig(t, client, root)
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	cmd.SetErr(pty.Output())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	errC := make(chan error)
+	go func() {
+		errC <- cmd.ExecuteContext(ctx)
+	}()
+	pty.ExpectMatch(fmt.Sprintf("Workspace %s is reachable at %s.coder", workspace.Name, workspace.Name))
+
+	target := fmt.Sprintf("http://%s.coder:%s", workspace.Name, port)
+	for _, proxy := range []string{"socks5://" + socksAddress, "http://" + httpAddress} {
+		proxyURL, err := url.Parse(proxy)
+		require.NoError(t, err)
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyURL(proxyURL),
+			},
+		}
+		require.Eventually(t, func() bool {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+			if !assert.NoError(t, err) {
+				return false
+			}
+			res, err := httpClient.Do(req)
+			if err != nil {
+				return false
+			}
+			_ = res.Body.Close()
+			return res.StatusCode == http.StatusTeapot
+		}, testutil.WaitLong, testutil.IntervalFast, "request through %s", proxy)
+		httpClient.CloseIdleConnections()
+	}
+
+	cancel()
+	err = <-errC
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// freeTCPAddress returns a local address that was free when checked.
+func freeTCPAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
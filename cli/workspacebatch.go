@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+// workspaceBatchFlags apply a workspace command to every workspace matching
+// a search query instead of a single workspace.
+type workspaceBatchFlags struct {
+	search      string
+	dryRun      bool
+	concurrency int
+}
+
+func (f *workspaceBatchFlags) attach(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.search, "search", "", `Apply the command to every workspace matching a search query instead of a single workspace, e.g. "template:docker status:running".`)
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "List the workspaces matching --search without building them.")
+	cmd.Flags().IntVar(&f.concurrency, "concurrency", codersdk.DefaultWorkspaceBatchConcurrency, "Number of workspaces matching --search to build at once.")
+}
+
+// args validates that the command is given either a workspace or a search
+// query, and returns whether it's a batch.
+func (f *workspaceBatchFlags) args(args []string) (bool, error) {
+	if f.search == "" {
+		if f.dryRun {
+			return false, xerrors.New("--dry-run requires --search")
+		}
+		if len(args) != 1 {
+			return false, xerrors.New("a workspace or --search is required")
+		}
+		return false, nil
+	}
+	if len(args) != 0 {
+		return false, xerrors.New("a workspace can't be given with --search")
+	}
+	return true, nil
+}
+
+type workspaceBatchRow struct {
+	Workspace string `table:"workspace"`
+	Status    string `table:"status"`
+	Detail    string `table:"detail"`
+}
+
+func displayWorkspaceBatch(cmd *cobra.Command, batch codersdk.WorkspaceBatch) error {
+	rows := make([]workspaceBatchRow, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		rows = append(rows, workspaceBatchRow{
+			Workspace: item.OwnerName + "/" + item.WorkspaceName,
+			Status:    string(item.Status),
+			Detail:    item.Error,
+		})
+	}
+	out, err := cliui.DisplayTable(rows, "workspace", nil)
+	if err != nil {
+		return xerrors.Errorf("render table: %w", err)
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+	return err
+}
+
+// run applies action to the workspaces matching the search query, and waits
+// for the builds to complete.
+func (f *workspaceBatchFlags) run(cmd *cobra.Command, client *codersdk.Client, action codersdk.WorkspaceBatchAction) error {
+	ctx := cmd.Context()
+	plan, err := client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+		Query:       f.search,
+		Action:      action,
+		Concurrency: f.concurrency,
+		DryRun:      true,
+	})
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, item := range plan.Items {
+		if item.Status == codersdk.WorkspaceBatchItemStatusPending {
+			pending++
+		}
+	}
+	if len(plan.Items) == 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No workspaces match the search query.")
+		return nil
+	}
+	err = displayWorkspaceBatch(cmd, plan)
+	if err != nil {
+		return err
+	}
+	if f.dryRun || pending == 0 {
+		return nil
+	}
+
+	_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+		Text:      fmt.Sprintf("Confirm %s %d workspaces?", action, pending),
+		IsConfirm: true,
+		Default:   cliui.ConfirmNo,
+	})
+	if err != nil {
+		return err
+	}
+
+	batch, err := client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+		Query:       f.search,
+		Action:      action,
+		Concurrency: f.concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	completed := -1
+	for batch.CompletedAt == nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		batch, err = client.WorkspaceBatch(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+		done := 0
+		for _, item := range batch.Items {
+			if item.Status == codersdk.WorkspaceBatchItemStatusSucceeded || item.Status == codersdk.WorkspaceBatchItemStatusFailed {
+				done++
+			}
+		}
+		if done != completed {
+			completed = done
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d of %d builds completed\n", done, pending)
+		}
+	}
+
+	_, _ = fmt.Fprintln(cmd.OutOrStdout())
+	err = displayWorkspaceBatch(cmd, batch)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, item := range batch.Items {
+		if item.Status == codersdk.WorkspaceBatchItemStatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return xerrors.Errorf("%d of %d workspaces failed to %s", failed, pending, action)
+	}
+	return nil
+}
@@ -9,11 +9,12 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 )
 
 // activityBumpWorkspace automatically bumps the workspace's auto-off timer
-// if it is set to expire soon.
+// if it is set to expire soon, as configured by the template.
 func activityBumpWorkspace(log slog.Logger, db database.Store, workspace database.Workspace) {
 	// We set a short timeout so if the app is under load, these
 	// low priority operations fail first.
@@ -42,18 +43,20 @@ func activityBumpWorkspace(log slog.Logger, db database.Store, workspace databas
 			return nil
 		}
 
-		// We sent bumpThreshold slightly under bumpAmount to minimize DB writes.
-		const (
-			bumpAmount    = time.Hour
-			bumpThreshold = time.Hour - (time.Minute * 10)
-		)
+		template, err := s.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			return xerrors.Errorf("get template: %w", err)
+		}
+		policy, err := schedule.Policy(template)
+		if err != nil {
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
 
-		if !build.Deadline.Before(time.Now().Add(bumpThreshold)) {
+		newDeadline := policy.BumpDeadline(job.CompletedAt.Time, build.Deadline, database.Now())
+		if newDeadline.IsZero() {
 			return nil
 		}
 
-		newDeadline := database.Now().Add(bumpAmount)
-
 		if _, err := s.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
 			ID:               build.ID,
 			UpdatedAt:        database.Now(),
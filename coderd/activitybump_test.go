@@ -5,12 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"cdr.dev/slog/sloggers/slogtest"
 
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -20,7 +22,7 @@ func TestWorkspaceActivityBump(t *testing.T) {
 
 	ctx := context.Background()
 
-	setupActivityTest := func(t *testing.T) (client *codersdk.Client, workspace codersdk.Workspace, assertBumped func(want bool)) {
+	setupActivityTest := func(t *testing.T, bump time.Duration) (client *codersdk.Client, workspace codersdk.Workspace, assertBumped func(want bool)) {
 		var ttlMillis int64 = 60 * 1000
 
 		client = coderdtest.New(t, &coderdtest.Options{
@@ -35,6 +37,17 @@ func TestWorkspaceActivityBump(t *testing.T) {
 			cwr.TTLMillis = &ttlMillis
 		})
 
+		if bump != time.Hour {
+			template, err := client.Template(ctx, workspace.TemplateID)
+			require.NoError(t, err)
+			_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+				DefaultTTLMillis:            template.DefaultTTLMillis,
+				ActivityBumpMillis:          ptr.Ref(bump.Milliseconds()),
+				ActivityBumpThresholdMillis: ptr.Ref(bump.Milliseconds()),
+			})
+			require.NoError(t, err)
+		}
+
 		// Sanity-check that deadline is near.
 		workspace, err := client.Workspace(ctx, workspace.ID)
 		require.NoError(t, err)
@@ -72,14 +85,14 @@ func TestWorkspaceActivityBump(t *testing.T) {
 				"deadline %v never updated", firstDeadline,
 			)
 
-			require.WithinDuration(t, database.Now().Add(time.Hour), workspace.LatestBuild.Deadline.Time, 3*time.Second)
+			require.WithinDuration(t, database.Now().Add(bump), workspace.LatestBuild.Deadline.Time, 3*time.Second)
 		}
 	}
 
 	t.Run("Dial", func(t *testing.T) {
 		t.Parallel()
 
-		client, workspace, assertBumped := setupActivityTest(t)
+		client, workspace, assertBumped := setupActivityTest(t, time.Hour)
 
 		resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
 		conn, err := client.DialWorkspaceAgent(ctx, resources[0].Agents[0].ID, &codersdk.DialWorkspaceAgentOptions{
@@ -98,7 +111,7 @@ func TestWorkspaceActivityBump(t *testing.T) {
 	t.Run("NoBump", func(t *testing.T) {
 		t.Parallel()
 
-		client, workspace, assertBumped := setupActivityTest(t)
+		client, workspace, assertBumped := setupActivityTest(t, time.Hour)
 
 		// Benign operations like retrieving workspace must not
 		// bump the deadline.
@@ -107,4 +120,33 @@ func TestWorkspaceActivityBump(t *testing.T) {
 
 		assertBumped(false)
 	})
+	t.Run("TemplateBump", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, assertBumped := setupActivityTest(t, 3*time.Hour)
+
+		// An open session bumps the deadline by the amount configured on
+		// the template.
+		resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+		conn, err := client.DialWorkspaceAgent(ctx, resources[0].Agents[0].ID, &codersdk.DialWorkspaceAgentOptions{
+			Logger: slogtest.Make(t, nil),
+		})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		sshConn, err := conn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshConn.Close()
+
+		assertBumped(true)
+
+		// The idle session keeps the workspace in use.
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		lastUsedAt := workspace.LastUsedAt
+		require.Eventually(t, func() bool {
+			workspace, err = client.Workspace(ctx, workspace.ID)
+			return assert.NoError(t, err) && workspace.LastUsedAt.After(lastUsedAt)
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
 }
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,6 +17,7 @@ import (
 	"github.com/tabbed/pqtype"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -24,7 +27,8 @@ import (
 	"github.com/coder/coder/cryptorand"
 )
 
-// Creates a new token API key that effectively doesn't expire.
+// Creates a new token API key. Tokens last 100 years unless a shorter
+// lifetime is requested or the deployment has a maximum token lifetime.
 func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := httpmw.UserParam(r)
@@ -40,12 +44,28 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	scope := database.APIKeyScopeAll
-	if scope != "" {
+	if createToken.Scope != "" {
 		scope = database.APIKeyScope(createToken.Scope)
 	}
 
 	// tokens last 100 years
 	lifeTime := time.Hour * 876000
+	if api.MaxTokenLifetime > 0 {
+		lifeTime = api.MaxTokenLifetime
+	}
+	if createToken.Lifetime != 0 {
+		if createToken.Lifetime < 0 || (api.MaxTokenLifetime > 0 && createToken.Lifetime > api.MaxTokenLifetime) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid token lifetime.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "lifetime",
+					Detail: fmt.Sprintf("Lifetime must be positive and at most %s.", api.MaxTokenLifetime),
+				}},
+			})
+			return
+		}
+		lifeTime = createToken.Lifetime
+	}
 	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:          user.ID,
 		LoginType:       database.LoginTypeToken,
@@ -80,6 +100,7 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 		UserID:     user.ID,
 		LoginType:  database.LoginTypePassword,
 		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
 		// All api generated keys will last 1 week. Browser login tokens have
 		// a shorter life.
 		ExpiresAt:       database.Now().Add(lifeTime),
@@ -113,7 +134,7 @@ func (api *API) apiKey(rw http.ResponseWriter, r *http.Request) {
 
 	keyID := chi.URLParam(r, "keyid")
 	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && key.UserID != user.ID) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -139,11 +160,10 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	keys, err := api.Database.GetAPIKeysByLoginType(ctx, database.LoginTypeToken)
-	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusOK, []codersdk.APIKey{})
-		return
-	}
+	keys, err := api.Database.GetAPIKeys(ctx, database.GetAPIKeysParams{
+		UserID:    user.ID,
+		LoginType: []database.LoginType{database.LoginTypeToken},
+	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching API keys.",
@@ -152,7 +172,7 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var apiKeys []codersdk.APIKey
+	apiKeys := make([]codersdk.APIKey, 0, len(keys))
 	for _, key := range keys {
 		apiKeys = append(apiKeys, convertAPIKey(key))
 	}
@@ -172,11 +192,146 @@ func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	keyID := chi.URLParam(r, "keyid")
-	err := api.Database.DeleteAPIKeyByID(ctx, keyID)
+	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && key.UserID != user.ID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Database.DeleteAPIKeyByID(ctx, keyID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// allAPIKeys lists the API keys of every user, so site owners can find
+// tokens to revoke.
+func (api *API) allAPIKeys(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAPIKey) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	filter, user, errs := apiKeySearchQuery(r.URL.Query().Get("q"))
+	if len(errs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid API key search query.",
+			Validations: errs,
+		})
+		return
+	}
+
+	if user != "" {
+		userID, err := uuid.Parse(user)
+		if err == nil {
+			filter.UserID = userID
+		} else {
+			dbUser, err := api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+				Username: user,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				httpapi.Write(ctx, rw, http.StatusOK, []codersdk.APIKeyWithOwner{})
+				return
+			}
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching user.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+			filter.UserID = dbUser.ID
+		}
+	}
+
+	keys, err := api.Database.GetAPIKeys(ctx, filter)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API keys.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(keys))
+	for _, key := range keys {
+		userIDs = append(userIDs, key.UserID)
+	}
+	users, err := api.Database.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	usernames := make(map[uuid.UUID]string, len(users))
+	for _, owner := range users {
+		usernames[owner.ID] = owner.Username
+	}
+
+	apiKeys := make([]codersdk.APIKeyWithOwner, 0, len(keys))
+	for _, key := range keys {
+		apiKeys = append(apiKeys, codersdk.APIKeyWithOwner{
+			APIKey:   convertAPIKey(key),
+			Username: usernames[key.UserID],
+		})
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, apiKeys)
+}
+
+// revokeAPIKey deletes an API key by ID without knowing the user that owns
+// it.
+func (api *API) revokeAPIKey(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+
+	keyID := chi.URLParam(r, "keyid")
+	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
 	if errors.Is(err, sql.ErrNoRows) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = key
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceAPIKey.WithOwner(key.UserID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	err = api.Database.DeleteAPIKeyByID(ctx, keyID)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error deleting API key.",
@@ -188,6 +343,84 @@ func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
 }
 
+// apiKeySearchQuery takes a query string and returns the API key filter, and
+// the username or ID of the user that owns the keys. It also can return the
+// list of validation errors to return to the api.
+func apiKeySearchQuery(query string) (database.GetAPIKeysParams, string, []codersdk.ValidationError) {
+	searchParams := make(url.Values)
+	if query == "" {
+		// No filter
+		return database.GetAPIKeysParams{}, "", nil
+	}
+	query = strings.ToLower(query)
+	// Because we do this in 2 passes, we want to maintain quotes on the first
+	// pass.Further splitting occurs on the second pass and quotes will be
+	// dropped.
+	elements := splitQueryParameterByDelimiter(query, ' ', true)
+	for _, element := range elements {
+		parts := splitQueryParameterByDelimiter(element, ':', false)
+		switch len(parts) {
+		case 1:
+			// No key:value pair. It is a user.
+			searchParams.Set("user", parts[0])
+		case 2:
+			searchParams.Set(parts[0], parts[1])
+		default:
+			return database.GetAPIKeysParams{}, "", []codersdk.ValidationError{
+				{Field: "q", Detail: fmt.Sprintf("Query element %q can only contain 1 ':'", element)},
+			}
+		}
+	}
+
+	// Using the query param parser here just returns consistent errors with
+	// other parsing.
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetAPIKeysParams{
+		LoginType:      httpapi.ParseCustom(parser, searchParams, []database.LoginType{}, "login_type", parseLoginTypes),
+		Scope:          httpapi.ParseCustom(parser, searchParams, []database.APIKeyScope{}, "scope", parseAPIKeyScopes),
+		LastUsedBefore: httpapi.ParseCustom(parser, searchParams, time.Time{}, "last_used_before", parseDate),
+		LastUsedAfter:  httpapi.ParseCustom(parser, searchParams, time.Time{}, "last_used_after", parseDate),
+		ExpiresBefore:  httpapi.ParseCustom(parser, searchParams, time.Time{}, "expires_before", parseDate),
+		ExpiresAfter:   httpapi.ParseCustom(parser, searchParams, time.Time{}, "expires_after", parseDate),
+	}
+
+	return filter, parser.String(searchParams, "", "user"), parser.Errors
+}
+
+// parseLoginTypes ensures proper enums are used for login types
+func parseLoginTypes(v string) ([]database.LoginType, error) {
+	var loginTypes []database.LoginType
+	for _, part := range strings.Split(v, ",") {
+		switch loginType := database.LoginType(part); loginType {
+		case database.LoginTypePassword, database.LoginTypeGithub, database.LoginTypeOIDC,
+			database.LoginTypeToken, database.LoginTypeLDAP:
+			loginTypes = append(loginTypes, loginType)
+		default:
+			return []database.LoginType{}, xerrors.Errorf("%q is not a valid login type", part)
+		}
+	}
+	return loginTypes, nil
+}
+
+// parseAPIKeyScopes ensures proper enums are used for API key scopes
+func parseAPIKeyScopes(v string) ([]database.APIKeyScope, error) {
+	var scopes []database.APIKeyScope
+	for _, part := range strings.Split(v, ",") {
+		switch scope := database.APIKeyScope(part); scope {
+		case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect, database.APIKeyScopeTwoFactorEnrollment:
+			scopes = append(scopes, scope)
+		default:
+			return []database.APIKeyScope{}, xerrors.Errorf("%q is not a valid scope", part)
+		}
+	}
+	return scopes, nil
+}
+
+// parseDate parses dates with the format YYYY-MM-DD in UTC.
+func parseDate(v string) (time.Time, error) {
+	return time.Parse("2006-01-02", v)
+}
+
 // Generates a new ID and secret for an API key.
 func generateAPIKeyIDSecret() (id string, secret string, err error) {
 	// Length of an API Key ID.
@@ -206,6 +439,7 @@ func generateAPIKeyIDSecret() (id string, secret string, err error) {
 type createAPIKeyParams struct {
 	UserID     uuid.UUID
 	RemoteAddr string
+	UserAgent  string
 	LoginType  database.LoginType
 
 	// Optional.
@@ -230,6 +464,21 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		}
 	}
 
+	// Keys are created to expire within the maximum lifetime of the
+	// deployment, and the API key middleware never refreshes them past it.
+	// The lifetime is capped too, since keys are refreshed by it when used.
+	if api.MaxTokenLifetime > 0 {
+		maxLifetimeSeconds := int64(api.MaxTokenLifetime.Seconds())
+		// A lifetime of 0 defaults to 24hrs in the database.
+		if (params.LifetimeSeconds == 0 && api.MaxTokenLifetime < 24*time.Hour) ||
+			params.LifetimeSeconds > maxLifetimeSeconds {
+			params.LifetimeSeconds = maxLifetimeSeconds
+		}
+		if maxExpiresAt := database.Now().Add(api.MaxTokenLifetime); params.ExpiresAt.After(maxExpiresAt) {
+			params.ExpiresAt = maxExpiresAt
+		}
+	}
+
 	ip := net.ParseIP(params.RemoteAddr)
 	if ip == nil {
 		ip = net.IPv4(0, 0, 0, 0)
@@ -241,7 +490,7 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		scope = params.Scope
 	}
 	switch scope {
-	case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect:
+	case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect, database.APIKeyScopeTwoFactorEnrollment:
 	default:
 		return nil, xerrors.Errorf("invalid API key scope: %q", scope)
 	}
@@ -264,6 +513,7 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		HashedSecret: hashed[:],
 		LoginType:    params.LoginType,
 		Scope:        scope,
+		UserAgent:    params.UserAgent,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("insert API key: %w", err)
@@ -2,6 +2,8 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -69,6 +71,229 @@ func TestTokens(t *testing.T) {
 		require.Greater(t, keys[0].ExpiresAt, time.Now().Add(time.Hour*438300))
 		require.Equal(t, keys[0].Scope, codersdk.APIKeyScopeApplicationConnect)
 	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		_, err := other.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+		otherKeys, err := other.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, otherKeys, 1)
+
+		// Tokens of other users are neither listed nor deletable through
+		// the routes of this user.
+		keys, err := client.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+		err = client.DeleteAPIKey(ctx, codersdk.Me, otherKeys[0].ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Lifetime", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Lifetime: time.Hour,
+		})
+		require.NoError(t, err)
+
+		keys, err := client.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.WithinDuration(t, time.Now().Add(time.Hour), keys[0].ExpiresAt, time.Minute)
+		require.EqualValues(t, 3600, keys[0].LifetimeSeconds)
+	})
+
+	t.Run("MaxLifetime", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			MaxTokenLifetime: 7 * 24 * time.Hour,
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Lifetime: 8 * 24 * time.Hour,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		// Tokens default to the maximum lifetime.
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+		keys, err := client.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.WithinDuration(t, time.Now().Add(7*24*time.Hour), keys[0].ExpiresAt, time.Minute)
+	})
+
+	t.Run("MaxLifetimeSession", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			MaxTokenLifetime: time.Hour,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		// Sessions are shortened to the maximum lifetime instead of failing.
+		res, err := client.CreateAPIKey(ctx, codersdk.Me)
+		require.NoError(t, err)
+		key, err := client.GetAPIKey(ctx, user.UserID.String(), strings.Split(res.Key, "-")[0])
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), key.ExpiresAt, time.Minute)
+		require.EqualValues(t, 3600, key.LifetimeSeconds)
+	})
+}
+
+func TestAllAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Filter", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := other.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scope: codersdk.APIKeyScopeApplicationConnect,
+		})
+		require.NoError(t, err)
+		otherUser, err := other.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		keys, err := client.APIKeys(ctx, codersdk.APIKeysFilter{})
+		require.NoError(t, err)
+		// Both users have a session, and the other user has a token.
+		require.Len(t, keys, 3)
+
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			User: otherUser.Username,
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		for _, key := range keys {
+			require.Equal(t, otherUser.ID, key.UserID)
+			require.Equal(t, otherUser.Username, key.Username)
+		}
+
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			User:      otherUser.ID.String(),
+			LoginType: codersdk.LoginTypeToken,
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, codersdk.APIKeyScopeApplicationConnect, keys[0].Scope)
+
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			Scope: codersdk.APIKeyScopeApplicationConnect,
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		// Sessions expire long before tokens do.
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			ExpiresBefore: time.Now().AddDate(1, 0, 0).Format("2006-01-02"),
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			LastUsedBefore: time.Now().AddDate(0, 0, -1).Format("2006-01-02"),
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, codersdk.LoginTypeToken, keys[0].LoginType)
+
+		keys, err = client.APIKeys(ctx, codersdk.APIKeysFilter{
+			User: "doesnotexist",
+		})
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		_, err := client.APIKeys(ctx, codersdk.APIKeysFilter{
+			LoginType:     "magic",
+			ExpiresBefore: "tomorrow",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 2)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		err := client.RevokeAPIKey(ctx, strings.Split(other.SessionToken(), "-")[0])
+		require.NoError(t, err)
+
+		_, err = other.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		err = client.RevokeAPIKey(ctx, "doesnotexist")
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("MemberForbidden", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		_, err := other.APIKeys(ctx, codersdk.APIKeysFilter{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		err = other.RevokeAPIKey(ctx, strings.Split(client.SessionToken(), "-")[0])
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Members can still revoke their own keys.
+		err = other.RevokeAPIKey(ctx, strings.Split(other.SessionToken(), "-")[0])
+		require.NoError(t, err)
+	})
 }
 
 func TestAPIKey(t *testing.T) {
@@ -241,8 +241,9 @@ func auditLogDescription(alog database.GetAuditLogsOffsetRow) string {
 	}
 
 	// We don't display the name for git ssh keys. It's fairly long and doesn't
-	// make too much sense to display.
-	if alog.ResourceType != database.ResourceTypeGitSshKey {
+	// make too much sense to display. Two-factor authentication has no name.
+	if alog.ResourceType != database.ResourceTypeGitSshKey &&
+		alog.ResourceType != database.ResourceTypeUserTwoFactor {
 		str += " {target}"
 	}
 
@@ -330,6 +331,8 @@ func resourceTypeFromString(resourceTypeString string) string {
 		return resourceTypeString
 	case codersdk.ResourceTypeAPIKey:
 		return resourceTypeString
+	case codersdk.ResourceTypeUserTwoFactor:
+		return resourceTypeString
 	}
 	return ""
 }
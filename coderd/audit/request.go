@@ -32,6 +32,24 @@ type Request[T Auditable] struct {
 	New T
 }
 
+// BackgroundAuditParams describes a change made on behalf of a request
+// after it was handled, e.g. by a batch of workspace builds.
+type BackgroundAuditParams[T Auditable] struct {
+	Audit Auditor
+	Log   slog.Logger
+
+	UserID           uuid.UUID
+	RequestID        uuid.UUID
+	IP               string
+	UserAgent        string
+	Status           int
+	Action           database.AuditAction
+	AdditionalFields json.RawMessage
+
+	Old T
+	New T
+}
+
 func ResourceTarget[T Auditable](tgt T) string {
 	switch typed := any(tgt).(type) {
 	case database.Organization:
@@ -49,8 +67,13 @@ func ResourceTarget[T Auditable](tgt T) string {
 		return ""
 	case database.GitSSHKey:
 		return typed.PublicKey
+	case database.UserTwoFactor:
+		// this isn't used
+		return ""
 	case database.Group:
 		return typed.Name
+	case database.APIKey:
+		return typed.ID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -72,8 +95,12 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 		return typed.ID
 	case database.GitSSHKey:
 		return typed.UserID
+	case database.UserTwoFactor:
+		return typed.UserID
 	case database.Group:
 		return typed.ID
+	case database.APIKey:
+		return typed.UserID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -95,8 +122,12 @@ func ResourceType[T Auditable](tgt T) database.ResourceType {
 		return database.ResourceTypeWorkspaceBuild
 	case database.GitSSHKey:
 		return database.ResourceTypeGitSshKey
+	case database.UserTwoFactor:
+		return database.ResourceTypeUserTwoFactor
 	case database.Group:
 		return database.ResourceTypeGroup
+	case database.APIKey:
+		return database.ResourceTypeApiKey
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -164,6 +195,51 @@ func InitRequest[T Auditable](w http.ResponseWriter, p *RequestParams) (*Request
 	}
 }
 
+// BackgroundAudit commits an audit log for a change that isn't made while
+// handling a request.
+func BackgroundAudit[T Auditable](ctx context.Context, p *BackgroundAuditParams[T]) {
+	// If no resources were provided, there's nothing we can audit.
+	if ResourceID(p.Old) == uuid.Nil && ResourceID(p.New) == uuid.Nil {
+		return
+	}
+
+	var diffRaw = []byte("{}")
+	// Only generate diffs if the change succeeded.
+	if p.Status < 400 {
+		diff := Diff(p.Audit, p.Old, p.New)
+
+		var err error
+		diffRaw, err = json.Marshal(diff)
+		if err != nil {
+			p.Log.Warn(ctx, "marshal diff", slog.Error(err))
+			diffRaw = []byte("{}")
+		}
+	}
+
+	if p.AdditionalFields == nil {
+		p.AdditionalFields = json.RawMessage("{}")
+	}
+
+	err := p.Audit.Export(ctx, database.AuditLog{
+		ID:               uuid.New(),
+		Time:             database.Now(),
+		UserID:           p.UserID,
+		Ip:               parseIP(p.IP),
+		UserAgent:        p.UserAgent,
+		ResourceType:     either(p.Old, p.New, ResourceType[T]),
+		ResourceID:       either(p.Old, p.New, ResourceID[T]),
+		ResourceTarget:   either(p.Old, p.New, ResourceTarget[T]),
+		Action:           p.Action,
+		Diff:             diffRaw,
+		StatusCode:       int32(p.Status),
+		RequestID:        p.RequestID,
+		AdditionalFields: p.AdditionalFields,
+	})
+	if err != nil {
+		p.Log.Error(ctx, "export audit log", slog.Error(err))
+	}
+}
+
 func either[T Auditable, R any](old, new T, fn func(T) R) R {
 	if ResourceID(new) != uuid.Nil {
 		return fn(new)
@@ -2,7 +2,10 @@ package executor
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,15 +15,22 @@ import (
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/coderd/parameter"
 )
 
+// autostopNotice is how long before a workspace is stopped automatically
+// its owner is notified.
+const autostopNotice = 30 * time.Minute
+
 // Executor automatically starts or stops workspaces.
 type Executor struct {
-	ctx     context.Context
-	db      database.Store
-	log     slog.Logger
-	tick    <-chan time.Time
-	statsCh chan<- Stats
+	ctx      context.Context
+	db       database.Store
+	log      slog.Logger
+	tick     <-chan time.Time
+	statsCh  chan<- Stats
+	notifier *notifications.Notifier
 }
 
 // Stats contains information about one run of Executor.
@@ -48,6 +58,13 @@ func (e *Executor) WithStatsChannel(ch chan<- Stats) *Executor {
 	return e
 }
 
+// WithNotifier will cause Executor to notify the owners of workspaces that
+// are about to stop, become dormant, or fail to start.
+func (e *Executor) WithNotifier(n *notifications.Notifier) *Executor {
+	e.notifier = n
+	return e
+}
+
 // Run will cause executor to start or stop workspaces on every
 // tick from its channel. It will stop when its context is Done, or when
 // its channel is closed.
@@ -107,9 +124,40 @@ func (e *Executor) runOnce(t time.Time) Stats {
 		return stats
 	}
 
+	// Templates may stop workspaces that have no TTL of their own.
+	templates, err := e.db.GetTemplates(e.ctx)
+	if err != nil {
+		e.log.Error(e.ctx, "get templates for autostop", slog.Error(err))
+		return stats
+	}
+	policies := make(map[uuid.UUID]schedule.TemplatePolicy, len(templates))
+	for _, template := range templates {
+		policy, err := schedule.Policy(template)
+		if err != nil {
+			e.log.Warn(e.ctx, "parse template schedule policy", slog.F("template_id", template.ID), slog.Error(err))
+			continue
+		}
+		policies[template.ID] = policy
+	}
+
+	// Workspaces may be started and stopped by windows alone.
+	workspaceIDs := make([]uuid.UUID, 0, len(workspaces))
+	for _, ws := range workspaces {
+		workspaceIDs = append(workspaceIDs, ws.ID)
+	}
+	windows, err := e.db.GetWorkspaceScheduleWindowsByWorkspaceIDs(e.ctx, workspaceIDs)
+	if err != nil {
+		e.log.Error(e.ctx, "get workspace schedule windows", slog.Error(err))
+		return stats
+	}
+	hasWindows := make(map[uuid.UUID]bool, len(windows))
+	for _, window := range windows {
+		hasWindows[window.WorkspaceID] = true
+	}
+
 	var eligibleWorkspaceIDs []uuid.UUID
 	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
+		if isEligibleForAutoStartStop(ws, policies[ws.TemplateID], hasWindows[ws.ID]) {
 			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
 		}
 	}
@@ -125,6 +173,9 @@ func (e *Executor) runOnce(t time.Time) Stats {
 		log := e.log.With(slog.F("workspace_id", wsID))
 
 		eg.Go(func() error {
+			// Notifications are sent once the transaction is committed, so
+			// they are never sent for changes that are rolled back.
+			var pending []notifications.Notification
 			err := e.db.InTx(func(db database.Store) error {
 				// Re-check eligibility since the first check was outside the
 				// transaction and the workspace settings may have changed.
@@ -133,7 +184,27 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
+				template, err := db.GetTemplateByID(e.ctx, ws.TemplateID)
+				if err != nil {
+					log.Error(e.ctx, "get workspace template", slog.Error(err))
+					return nil
+				}
+				policy, err := schedule.Policy(template)
+				if err != nil {
+					log.Warn(e.ctx, "parse template schedule policy", slog.Error(err))
+					return nil
+				}
+				windows, err := db.GetWorkspaceScheduleWindowsByWorkspaceIDs(e.ctx, []uuid.UUID{ws.ID})
+				if err != nil {
+					log.Error(e.ctx, "get workspace schedule windows", slog.Error(err))
+					return nil
+				}
+				if !isEligibleForAutoStartStop(ws, policy, len(windows) > 0) {
+					return nil
+				}
+				skipDates, err := db.GetWorkspaceScheduleSkipDatesByWorkspaceID(e.ctx, ws.ID)
+				if err != nil {
+					log.Error(e.ctx, "get workspace schedule skip dates", slog.Error(err))
 					return nil
 				}
 
@@ -150,13 +221,115 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
+				if !ws.DormantAt.Valid && policy.InactivityTTL > 0 {
+					// Builds started by the executor, like autostarts, don't
+					// mean anybody uses the workspace.
+					lastInitiated, err := db.GetLatestWorkspaceBuildByWorkspaceIDAndReason(e.ctx, database.GetLatestWorkspaceBuildByWorkspaceIDAndReasonParams{
+						WorkspaceID: ws.ID,
+						Reason:      database.BuildReasonInitiator,
+					})
+					if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+						log.Error(e.ctx, "get latest initiated workspace build", slog.Error(err))
+						return nil
+					}
+					dormantAt := policy.DormantAt(lastActive(ws, lastInitiated))
+					if !currentTick.Before(dormantAt) {
+						log.Info(e.ctx, "marking workspace dormant", slog.F("last_used_at", ws.LastUsedAt))
+						ws.DormantAt = sql.NullTime{Time: currentTick, Valid: true}
+						err = db.UpdateWorkspaceDormantAt(e.ctx, database.UpdateWorkspaceDormantAtParams{
+							ID:        ws.ID,
+							DormantAt: ws.DormantAt,
+						})
+						if err != nil {
+							log.Error(e.ctx, "mark workspace dormant", slog.Error(err))
+							return nil
+						}
+						pending = append(pending, notifications.Notification{
+							Kind:        database.NotificationKindWorkspaceDormant,
+							UserID:      ws.OwnerID,
+							WorkspaceID: ws.ID,
+							Title:       fmt.Sprintf("Workspace %q is dormant", ws.Name),
+							Body:        fmt.Sprintf("Workspace %q hasn't been used since %s and was marked dormant. Start it to keep using it.", ws.Name, lastActive(ws, lastInitiated).Format(time.RFC1123)),
+						})
+					}
+				}
+				// Dormant workspaces are neither started nor stopped on
+				// schedule until their owner confirms they are still in use.
+				if ws.DormantAt.Valid {
+					transition, reason, ok := getDormantTransition(ws, policy, priorHistory, priorJob, currentTick)
+					if !ok {
+						return nil
+					}
+					log.Info(e.ctx, "scheduling dormant workspace transition", slog.F("transition", transition))
+					stats.Transitions[ws.ID] = transition
+					if err := build(e.ctx, db, ws, transition, reason, priorHistory, priorHistory.TemplateVersionID); err != nil {
+						log.Error(e.ctx, "unable to transition dormant workspace",
+							slog.F("transition", transition),
+							slog.Error(err),
+						)
+					}
+					return nil
+				}
+
+				// Failed start builds are retried and failed updates are
+				// rolled back once the recovery decided on when they failed
+				// is due, regardless of the workspace schedule.
+				recovery, ok, err := getRecovery(e.ctx, db, priorHistory, priorJob, currentTick)
+				if err != nil {
+					log.Error(e.ctx, "get recovery for failed workspace build", slog.Error(err))
+					return nil
+				}
+				if ok {
+					log.Info(e.ctx, "recovering failed workspace build",
+						slog.F("reason", recovery.reason),
+						slog.F("template_version_id", recovery.templateVersionID),
+					)
+					if recovery.reason == database.BuildReasonRollback {
+						err = restoreParameters(e.ctx, db, ws.ID, recovery.target)
+						if err != nil {
+							log.Error(e.ctx, "restore workspace parameters", slog.Error(err))
+							return nil
+						}
+					}
+					stats.Transitions[ws.ID] = database.WorkspaceTransitionStart
+					if err := build(e.ctx, db, ws, database.WorkspaceTransitionStart, recovery.reason, priorHistory, recovery.templateVersionID); err != nil {
+						log.Error(e.ctx, "unable to recover failed workspace build", slog.Error(err))
+						return nil
+					}
+					if recovery.reason == database.BuildReasonRollback {
+						pending = append(pending, notifications.Notification{
+							Kind:        database.NotificationKindWorkspaceBuildFailed,
+							UserID:      ws.OwnerID,
+							WorkspaceID: ws.ID,
+							Title:       fmt.Sprintf("Workspace %q was rolled back", ws.Name),
+							Body: fmt.Sprintf("Build #%d of workspace %q failed to update it, so it was rolled back to the template version and parameters of build #%d.",
+								priorHistory.BuildNumber, ws.Name, recovery.target.BuildNumber),
+						})
+					}
+					return nil
+				}
+
+				sched, err := schedule.ForWorkspace(ws, windows, skipDates)
+				if err != nil {
+					log.Warn(e.ctx, "parse workspace schedule", slog.Error(err))
+					return nil
+				}
+				validTransition, nextTransition, err := getNextTransition(sched, policy, priorHistory, priorJob)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
 					return nil
 				}
 
 				if currentTick.Before(nextTransition) {
+					if validTransition == database.WorkspaceTransitionStop && isAutostopNoticeDue(currentTick, nextTransition) {
+						pending = append(pending, notifications.Notification{
+							Kind:        database.NotificationKindWorkspaceAutostop,
+							UserID:      ws.OwnerID,
+							WorkspaceID: ws.ID,
+							Title:       fmt.Sprintf("Workspace %q is stopping soon", ws.Name),
+							Body:        fmt.Sprintf("Workspace %q will stop automatically at %s.", ws.Name, nextTransition.Format(time.RFC1123)),
+						})
+					}
 					log.Debug(e.ctx, "skipping workspace: too early",
 						slog.F("next_transition_at", nextTransition),
 						slog.F("transition", validTransition),
@@ -165,14 +338,66 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", validTransition))
+				templateVersionID := priorHistory.TemplateVersionID
+				if validTransition == database.WorkspaceTransitionStart {
+					var missing []string
+					templateVersionID, missing, err = startVersion(e.ctx, db, ws, template, priorHistory)
+					if err != nil {
+						log.Error(e.ctx, "get template version to start workspace with", slog.Error(err))
+						return nil
+					}
+					if len(missing) > 0 {
+						log.Warn(e.ctx, "active template version has parameters without a value", slog.F("parameters", missing))
+						outcome := "was started on its current version"
+						if template.RequireActiveVersion {
+							outcome = "wasn't started"
+						}
+						notification := notifications.Notification{
+							Kind:        database.NotificationKindWorkspaceUpdateFailed,
+							UserID:      ws.OwnerID,
+							WorkspaceID: ws.ID,
+							Title:       fmt.Sprintf("Workspace %q couldn't be updated", ws.Name),
+							Body: fmt.Sprintf("The active version of template %q requires values for parameters %s, so workspace %q %s. Run \"coder update %s\" to set them.",
+								template.Name, strings.Join(missing, ", "), ws.Name, outcome, ws.Name),
+						}
+						if template.RequireActiveVersion {
+							// The template doesn't allow starting the
+							// workspace on its current version either. The
+							// start stays due until the parameters are set,
+							// so the owner is only notified once.
+							if currentTick.Equal(nextTransition) {
+								pending = append(pending, notification)
+							}
+							return nil
+						}
+						pending = append(pending, notification)
+					}
+				}
+
+				log.Info(e.ctx, "scheduling workspace transition",
+					slog.F("transition", validTransition),
+					slog.F("template_version_id", templateVersionID),
+				)
 
 				stats.Transitions[ws.ID] = validTransition
-				if err := build(e.ctx, db, ws, validTransition, priorHistory, priorJob); err != nil {
+				reason := database.BuildReasonAutostart
+				if validTransition == database.WorkspaceTransitionStop {
+					reason = database.BuildReasonAutostop
+				}
+				if err := build(e.ctx, db, ws, validTransition, reason, priorHistory, templateVersionID); err != nil {
 					log.Error(e.ctx, "unable to transition workspace",
 						slog.F("transition", validTransition),
 						slog.Error(err),
 					)
+					if validTransition == database.WorkspaceTransitionStart {
+						pending = append(pending, notifications.Notification{
+							Kind:        database.NotificationKindWorkspaceAutostartFailed,
+							UserID:      ws.OwnerID,
+							WorkspaceID: ws.ID,
+							Title:       fmt.Sprintf("Workspace %q failed to start", ws.Name),
+							Body:        fmt.Sprintf("Workspace %q couldn't be started on schedule: %s", ws.Name, err),
+						})
+					}
 					return nil
 				}
 
@@ -180,6 +405,10 @@ func (e *Executor) runOnce(t time.Time) Stats {
 			})
 			if err != nil {
 				log.Error(e.ctx, "workspace scheduling failed", slog.Error(err))
+				return nil
+			}
+			for _, notification := range pending {
+				e.notifier.Notify(e.ctx, notification)
 			}
 			return nil
 		})
@@ -194,12 +423,150 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+func isEligibleForAutoStartStop(ws database.Workspace, policy schedule.TemplatePolicy, hasWindows bool) bool {
+	if ws.Deleted {
+		return false
+	}
+	if ws.DormantAt.Valid || policy.InactivityTTL > 0 || hasWindows {
+		return true
+	}
+	if policy.BuildRetries > 0 || policy.RollbackFailedUpdates {
+		return true
+	}
+	return ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0 || policy.RequiresAutostop()
 }
 
-func getNextTransition(
+// isAutostopNoticeDue returns true if the owner of a workspace stopping at
+// deadline should be notified on the tick. Ticks are a minute apart, so the
+// notice is due on exactly one tick.
+func isAutostopNoticeDue(currentTick, deadline time.Time) bool {
+	untilDeadline := deadline.Sub(currentTick)
+	return untilDeadline > autostopNotice-time.Minute && untilDeadline <= autostopNotice
+}
+
+// lastActive returns the last time the workspace was either used or built
+// by a user. lastInitiated is the latest build a user started, if any.
+func lastActive(ws database.Workspace, lastInitiated database.WorkspaceBuild) time.Time {
+	if ws.LastUsedAt.After(lastInitiated.CreatedAt) {
+		return ws.LastUsedAt
+	}
+	return lastInitiated.CreatedAt
+}
+
+// getDormantTransition returns the transition a dormant workspace needs, if
+// any. Running dormant workspaces are stopped, and dormant workspaces past
+// the deletion TTL of their template are deleted.
+func getDormantTransition(
 	ws database.Workspace,
+	policy schedule.TemplatePolicy,
+	priorHistory database.WorkspaceBuild,
+	priorJob database.ProvisionerJob,
+	currentTick time.Time,
+) (database.WorkspaceTransition, database.BuildReason, bool) {
+	if !priorJob.CompletedAt.Valid || priorHistory.Transition == database.WorkspaceTransitionDelete {
+		return "", "", false
+	}
+	deletingAt := policy.DeletingAt(ws.DormantAt.Time)
+	if !deletingAt.IsZero() && !currentTick.Before(deletingAt) {
+		return database.WorkspaceTransitionDelete, database.BuildReasonAutodelete, true
+	}
+	if priorHistory.Transition == database.WorkspaceTransitionStart && priorJob.Error.String == "" {
+		return database.WorkspaceTransitionStop, database.BuildReasonDormancy, true
+	}
+	return "", "", false
+}
+
+// recovery is a build that recovers a workspace from a failed start build.
+type recovery struct {
+	reason            database.BuildReason
+	templateVersionID uuid.UUID
+	// target is the build a rollback returns to.
+	target database.WorkspaceBuild
+}
+
+// getRecovery returns the build that recovers a workspace whose latest
+// build failed, if it's due. provisionerdserver decides on the recovery when
+// the build fails.
+func getRecovery(
+	ctx context.Context,
+	store database.Store,
+	priorHistory database.WorkspaceBuild,
+	priorJob database.ProvisionerJob,
+	currentTick time.Time,
+) (recovery, bool, error) {
+	if !priorJob.CompletedAt.Valid || priorJob.Error.String == "" || priorJob.CanceledAt.Valid {
+		return recovery{}, false, nil
+	}
+	buildRecovery, err := store.GetWorkspaceBuildRecoveryByBuildID(ctx, priorHistory.ID)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return recovery{}, false, nil
+	}
+	if err != nil {
+		return recovery{}, false, xerrors.Errorf("get workspace build recovery: %w", err)
+	}
+	if currentTick.Before(buildRecovery.RecoverAt) {
+		return recovery{}, false, nil
+	}
+	found := recovery{
+		reason:            buildRecovery.Reason,
+		templateVersionID: buildRecovery.TemplateVersionID,
+	}
+	if buildRecovery.TargetBuildID.Valid {
+		found.target, err = store.GetWorkspaceBuildByID(ctx, buildRecovery.TargetBuildID.UUID)
+		if err != nil {
+			return recovery{}, false, xerrors.Errorf("get rollback target build: %w", err)
+		}
+	}
+	return found, true, nil
+}
+
+// restoreParameters replaces the parameter values of the workspace with the
+// ones the build was created with. Builds that predate the snapshotting of
+// parameters leave the current values untouched.
+func restoreParameters(ctx context.Context, store database.Store, workspaceID uuid.UUID, build database.WorkspaceBuild) error {
+	params, err := store.GetWorkspaceBuildParameters(ctx, build.ID)
+	if err != nil {
+		return xerrors.Errorf("get workspace build parameters: %w", err)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	existing, err := store.ParameterValues(ctx, database.ParameterValuesParams{
+		Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+		ScopeIds: []uuid.UUID{workspaceID},
+	})
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get workspace parameter values: %w", err)
+	}
+	for _, value := range existing {
+		err = store.DeleteParameterValueByID(ctx, value.ID)
+		if err != nil {
+			return xerrors.Errorf("delete parameter value %q: %w", value.Name, err)
+		}
+	}
+	now := database.Now()
+	for _, param := range params {
+		_, err = store.InsertParameterValue(ctx, database.InsertParameterValueParams{
+			ID:                uuid.New(),
+			Name:              param.Name,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			Scope:             database.ParameterScopeWorkspace,
+			ScopeID:           workspaceID,
+			SourceScheme:      param.SourceScheme,
+			SourceValue:       param.SourceValue,
+			DestinationScheme: param.DestinationScheme,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert parameter value %q: %w", param.Name, err)
+		}
+	}
+	return nil
+}
+
+func getNextTransition(
+	sched schedule.WorkspaceSchedule,
+	policy schedule.TemplatePolicy,
 	priorHistory database.WorkspaceBuild,
 	priorJob database.ProvisionerJob,
 ) (
@@ -213,33 +580,85 @@ func getNextTransition(
 
 	switch priorHistory.Transition {
 	case database.WorkspaceTransitionStart:
-		if priorHistory.Deadline.IsZero() {
+		// The template policy may have changed since the build started, or
+		// activity may have bumped the deadline.
+		deadline := policy.ClampDeadline(priorJob.CompletedAt.Time, priorHistory.Deadline)
+		// Windows stop the workspace even if its TTL hasn't elapsed.
+		if stop := sched.NextStop(priorHistory.CreatedAt); !stop.IsZero() && (deadline.IsZero() || stop.Before(deadline)) {
+			deadline = stop
+		}
+		if deadline.IsZero() {
 			return "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
 		}
 		// For stopping, do not truncate. This is inconsistent with autostart, but
 		// it ensures we will not stop too early.
-		return database.WorkspaceTransitionStop, priorHistory.Deadline, nil
+		return database.WorkspaceTransitionStop, deadline, nil
 	case database.WorkspaceTransitionStop:
-		sched, err := schedule.Weekly(ws.AutostartSchedule.String)
-		if err != nil {
-			return "", time.Time{}, xerrors.Errorf("workspace has invalid autostart schedule: %w", err)
+		for _, start := range sched.Starts() {
+			if err := policy.ValidateAutostart(start); err != nil {
+				return "", time.Time{}, xerrors.Errorf("workspace autostart schedule not allowed: %w", err)
+			}
+		}
+		nextStart := sched.NextStart(priorHistory.CreatedAt)
+		if nextStart.IsZero() {
+			return "", time.Time{}, xerrors.Errorf("workspace has no autostart schedule")
 		}
 		// Round down to the nearest minute, as this is the finest granularity cron supports.
 		// Truncate is probably not necessary here, but doing it anyway to be sure.
-		nextTransition = sched.Next(priorHistory.CreatedAt).Truncate(time.Minute)
-		return database.WorkspaceTransitionStart, nextTransition, nil
+		return database.WorkspaceTransitionStart, nextStart.Truncate(time.Minute), nil
 	default:
 		return "", time.Time{}, xerrors.Errorf("last transition not valid for autostart or autostop")
 	}
 }
 
+// startVersion returns the template version to start the workspace with.
+// Workspaces are updated to the active version of their template if either
+// the template or the workspace asks for it, unless parameters of the
+// active version have no value. Those parameters are returned instead, and
+// the workspace stays on its current version.
+func startVersion(ctx context.Context, store database.Store, workspace database.Workspace, template database.Template, priorHistory database.WorkspaceBuild) (uuid.UUID, []string, error) {
+	if !template.RequireActiveVersion && !workspace.AutomaticUpdates {
+		return priorHistory.TemplateVersionID, nil, nil
+	}
+	if priorHistory.TemplateVersionID == template.ActiveVersionID {
+		return priorHistory.TemplateVersionID, nil, nil
+	}
+
+	activeVersion, err := store.GetTemplateVersionByID(ctx, template.ActiveVersionID)
+	if err != nil {
+		return uuid.Nil, nil, xerrors.Errorf("get active template version: %w", err)
+	}
+	// Parameter values are scoped to the workspace, so they carry over to
+	// the new version.
+	missing, err := parameter.Missing(ctx, store, parameter.ComputeScope{
+		TemplateImportJobID: activeVersion.JobID,
+		TemplateID:          uuid.NullUUID{UUID: template.ID, Valid: true},
+		WorkspaceID:         uuid.NullUUID{UUID: workspace.ID, Valid: true},
+	})
+	if err != nil {
+		return uuid.Nil, nil, xerrors.Errorf("compute missing parameters: %w", err)
+	}
+	if len(missing) > 0 {
+		return priorHistory.TemplateVersionID, missing, nil
+	}
+	return activeVersion.ID, nil, nil
+}
+
 // TODO(cian): this function duplicates most of api.postWorkspaceBuilds. Refactor.
 // See: https://github.com/coder/coder/issues/1401
-func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
+func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, buildReason database.BuildReason, priorHistory database.WorkspaceBuild, templateVersionID uuid.UUID) error {
 	template, err := store.GetTemplateByID(ctx, workspace.TemplateID)
 	if err != nil {
 		return xerrors.Errorf("get workspace template: %w", err)
 	}
+	templateVersion, err := store.GetTemplateVersionByID(ctx, templateVersionID)
+	if err != nil {
+		return xerrors.Errorf("get template version: %w", err)
+	}
+	templateVersionJob, err := store.GetProvisionerJobByID(ctx, templateVersion.JobID)
+	if err != nil {
+		return xerrors.Errorf("get template version job: %w", err)
+	}
 
 	priorBuildNumber := priorHistory.BuildNumber
 
@@ -257,16 +676,6 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 	provisionerJobID := uuid.New()
 	now := database.Now()
 
-	var buildReason database.BuildReason
-	switch trans {
-	case database.WorkspaceTransitionStart:
-		buildReason = database.BuildReasonAutostart
-	case database.WorkspaceTransitionStop:
-		buildReason = database.BuildReasonAutostop
-	default:
-		return xerrors.Errorf("Unsupported transition: %q", trans)
-	}
-
 	newProvisionerJob, err := store.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
 		ID:             provisionerJobID,
 		CreatedAt:      now,
@@ -275,8 +684,8 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		OrganizationID: template.OrganizationID,
 		Provisioner:    template.Provisioner,
 		Type:           database.ProvisionerJobTypeWorkspaceBuild,
-		StorageMethod:  priorJob.StorageMethod,
-		FileID:         priorJob.FileID,
+		StorageMethod:  templateVersionJob.StorageMethod,
+		FileID:         templateVersionJob.FileID,
 		Input:          input,
 	})
 	if err != nil {
@@ -287,7 +696,7 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		CreatedAt:         now,
 		UpdatedAt:         now,
 		WorkspaceID:       workspace.ID,
-		TemplateVersionID: priorHistory.TemplateVersionID,
+		TemplateVersionID: templateVersion.ID,
 		BuildNumber:       priorBuildNumber + 1,
 		ProvisionerState:  priorHistory.ProvisionerState,
 		InitiatorID:       workspace.OwnerID,
@@ -298,5 +707,12 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 	if err != nil {
 		return xerrors.Errorf("insert workspace build: %w", err)
 	}
+	err = store.InsertWorkspaceBuildParameters(ctx, database.InsertWorkspaceBuildParametersParams{
+		WorkspaceBuildID: workspaceBuildID,
+		WorkspaceID:      workspace.ID,
+	})
+	if err != nil {
+		return xerrors.Errorf("insert workspace build parameters: %w", err)
+	}
 	return nil
 }
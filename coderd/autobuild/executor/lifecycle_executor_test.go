@@ -2,18 +2,25 @@ package executor_test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/goleak"
 
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,6 +112,131 @@ func TestExecutorAutostartTemplateUpdated(t *testing.T) {
 	assert.Equal(t, workspace.LatestBuild.TemplateVersionID, ws.LatestBuild.TemplateVersionID, "expected workspace build to be using the old template version")
 }
 
+func TestExecutorAutostartAutomaticUpdates(t *testing.T) {
+	t.Parallel()
+
+	// A template version with a parameter that has no value. It fails to
+	// import, but may still be promoted.
+	withParameter := &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					ParameterSchemas: []*proto.ParameterSchema{{
+						Name: "region",
+						DefaultDestination: &proto.ParameterDestination{
+							Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+						},
+					}},
+				},
+			},
+		}},
+		ProvisionApply: echo.ProvisionComplete,
+	}
+
+	for _, tc := range []struct {
+		name                 string
+		requireActiveVersion bool
+		newVersion           *echo.Responses
+		// expectUpdate is whether the workspace is started on the new
+		// version. Nil if it isn't started at all.
+		expectUpdate       *bool
+		expectNotification bool
+	}{
+		{
+			name:         "Update",
+			expectUpdate: ptr.Ref(true),
+		},
+		{
+			name:               "MissingParameter",
+			newVersion:         withParameter,
+			expectUpdate:       ptr.Ref(false),
+			expectNotification: true,
+		},
+		{
+			name:                 "RequireActiveVersionMissingParameter",
+			requireActiveVersion: true,
+			newVersion:           withParameter,
+			expectNotification:   true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				sched   = mustSchedule(t, "CRON_TZ=UTC 0 * * * *")
+				ctx     = context.Background()
+				tickCh  = make(chan time.Time)
+				statsCh = make(chan executor.Stats)
+				client  = coderdtest.New(t, &coderdtest.Options{
+					AutobuildTicker:          tickCh,
+					IncludeProvisionerDaemon: true,
+					AutobuildStats:           statsCh,
+				})
+				// Given: we have a user with a workspace that has autostart
+				// and automatic updates enabled
+				workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+					cwr.AutostartSchedule = ptr.Ref(sched.String())
+				})
+			)
+			require.NoError(t, client.UpdateWorkspaceAutomaticUpdates(ctx, workspace.ID, codersdk.UpdateWorkspaceAutomaticUpdatesRequest{
+				AutomaticUpdates: !tc.requireActiveVersion,
+			}))
+			_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+				RequireActiveVersion: ptr.Ref(tc.requireActiveVersion),
+			})
+			require.NoError(t, err)
+
+			// Given: workspace is stopped
+			workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+
+			// Given: the workspace template has been updated
+			orgs, err := client.OrganizationsByUser(ctx, workspace.OwnerID.String())
+			require.NoError(t, err)
+			require.Len(t, orgs, 1)
+			newVersion := coderdtest.UpdateTemplateVersion(t, client, orgs[0].ID, tc.newVersion, workspace.TemplateID)
+			coderdtest.AwaitTemplateVersionJob(t, client, newVersion.ID)
+			require.NoError(t, client.UpdateActiveTemplateVersion(ctx, workspace.TemplateID, codersdk.UpdateActiveTemplateVersion{
+				ID: newVersion.ID,
+			}))
+
+			// When: the autobuild executor ticks at the scheduled time
+			go func() {
+				tickCh <- sched.Next(workspace.LatestBuild.CreatedAt)
+				close(tickCh)
+			}()
+
+			stats := <-statsCh
+			assert.NoError(t, stats.Error)
+			ws := coderdtest.MustWorkspace(t, client, workspace.ID)
+			if tc.expectUpdate == nil {
+				// Then: the workspace should not be started
+				assert.Len(t, stats.Transitions, 0)
+				assert.Equal(t, workspace.LatestBuild.ID, ws.LatestBuild.ID)
+			} else {
+				// Then: the workspace should be started on the expected version
+				assert.Len(t, stats.Transitions, 1)
+				assert.Equal(t, database.WorkspaceTransitionStart, stats.Transitions[workspace.ID])
+				if *tc.expectUpdate {
+					assert.Equal(t, newVersion.ID, ws.LatestBuild.TemplateVersionID)
+				} else {
+					assert.Equal(t, workspace.LatestBuild.TemplateVersionID, ws.LatestBuild.TemplateVersionID)
+				}
+			}
+
+			notifications, err := client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+			require.NoError(t, err)
+			if tc.expectNotification {
+				require.Len(t, notifications, 1)
+				require.Equal(t, codersdk.NotificationKindWorkspaceUpdateFailed, notifications[0].Kind)
+				require.Contains(t, notifications[0].Body, "region")
+			} else {
+				require.Empty(t, notifications)
+			}
+		})
+	}
+}
+
 func TestExecutorAutostartAlreadyRunning(t *testing.T) {
 	t.Parallel()
 
@@ -333,6 +465,453 @@ func TestExecutorAutostopNotEnabled(t *testing.T) {
 	assert.Len(t, stats.Transitions, 0)
 }
 
+func TestExecutorAutostopTemplateMaxTTL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace that has no TTL set
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+	)
+	require.Nil(t, workspace.TTLMillis)
+	require.Zero(t, workspace.LatestBuild.Deadline)
+	require.NotNil(t, workspace.LatestBuild.Job.CompletedAt)
+
+	// Given: the template enforces a maximum TTL after the workspace started
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxTTLMillis: ptr.Ref(time.Hour.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks past the maximum TTL
+	go func() {
+		tickCh <- workspace.LatestBuild.Job.CompletedAt.Add(time.Hour + time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+}
+
+func TestExecutorDormancy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a running workspace that has no TTL set
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+	)
+	require.Nil(t, workspace.DormantAt)
+
+	// Given: the template marks workspaces dormant after an hour of
+	// inactivity, and deletes them a day later
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis:       ptr.Ref(time.Hour.Milliseconds()),
+		DormancyDeletionTTLMillis: ptr.Ref((24 * time.Hour).Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks after the inactivity TTL
+	go func() {
+		tickCh <- workspace.LatestBuild.CreatedAt.Add(2 * time.Hour)
+	}()
+
+	// Then: the workspace should be marked dormant and stopped
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DormantAt)
+	require.NotNil(t, workspace.DeletingAt)
+	require.Equal(t, workspace.DormantAt.Add(24*time.Hour), *workspace.DeletingAt)
+	require.Equal(t, codersdk.BuildReasonDormancy, workspace.LatestBuild.Reason)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	// Then: the owner should be notified
+	notifications, err := client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, codersdk.NotificationKindWorkspaceDormant, notifications[0].Kind)
+
+	// When: the autobuild executor ticks before the deletion TTL
+	go func() {
+		tickCh <- workspace.DormantAt.Add(time.Hour)
+	}()
+
+	// Then: nothing should happen
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// When: the autobuild executor ticks after the deletion TTL
+	go func() {
+		tickCh <- workspace.DeletingAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be deleted
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionDelete, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.BuildReasonAutodelete, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorDormancyIgnoresAutostart(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx        = context.Background()
+		tickCh     = make(chan time.Time)
+		statsCh    = make(chan executor.Stats)
+		db, pubsub = dbtestutil.NewDB(t)
+		client     = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			Database:                 db,
+			Pubsub:                   pubsub,
+		})
+		// Given: we have a user with a running workspace that has no TTL set
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+		createdAt = workspace.LatestBuild.CreatedAt
+	)
+
+	// Given: the template marks workspaces dormant after two hours of
+	// inactivity
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: ptr.Ref((2 * time.Hour).Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// Given: the workspace was autostarted an hour later, but nobody used it
+	autostartedAt := createdAt.Add(time.Hour)
+	latestJob, err := db.GetProvisionerJobByID(ctx, workspace.LatestBuild.Job.ID)
+	require.NoError(t, err)
+	buildID := uuid.New()
+	input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
+		WorkspaceBuildID: buildID,
+	})
+	require.NoError(t, err)
+	job, err := db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:             uuid.New(),
+		CreatedAt:      autostartedAt,
+		UpdatedAt:      autostartedAt,
+		InitiatorID:    latestJob.InitiatorID,
+		OrganizationID: latestJob.OrganizationID,
+		// The echo provisioner daemon doesn't acquire it.
+		Provisioner:   database.ProvisionerTypeTerraform,
+		StorageMethod: latestJob.StorageMethod,
+		FileID:        latestJob.FileID,
+		Type:          database.ProvisionerJobTypeWorkspaceBuild,
+		Input:         input,
+	})
+	require.NoError(t, err)
+	err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+		ID:          job.ID,
+		UpdatedAt:   autostartedAt,
+		CompletedAt: sql.NullTime{Time: autostartedAt, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+		ID:                buildID,
+		CreatedAt:         autostartedAt,
+		UpdatedAt:         autostartedAt,
+		WorkspaceID:       workspace.ID,
+		TemplateVersionID: workspace.LatestBuild.TemplateVersionID,
+		BuildNumber:       workspace.LatestBuild.BuildNumber + 1,
+		ProvisionerState:  []byte{},
+		InitiatorID:       workspace.LatestBuild.InitiatorID,
+		Transition:        database.WorkspaceTransitionStart,
+		JobID:             job.ID,
+		Reason:            database.BuildReasonAutostart,
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks two hours after the workspace was
+	// created
+	go func() {
+		tickCh <- createdAt.Add(2*time.Hour + time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be marked dormant and stopped, since the
+	// autostart doesn't count as activity
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DormantAt)
+	require.Equal(t, codersdk.BuildReasonDormancy, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorBuildRetryAndRollback(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx        = context.Background()
+		tickCh     = make(chan time.Time)
+		statsCh    = make(chan executor.Stats)
+		db, pubsub = dbtestutil.NewDB(t)
+		client     = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			Database:                 db,
+			Pubsub:                   pubsub,
+		})
+		// Given: we have a user with a workspace that has a parameter value
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.ParameterValues = []codersdk.CreateParameterRequest{{
+				Name:              "region",
+				SourceValue:       "eu",
+				SourceScheme:      codersdk.ParameterSourceSchemeData,
+				DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+			}}
+		})
+	)
+
+	// Given: the template retries failed builds once
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		BuildRetries:            ptr.Ref(1),
+		BuildRetryBackoffMillis: ptr.Ref(time.Minute.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// Given: the workspace is stopped
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+
+	// Given: the workspace failed to update to a broken template version
+	// with a new parameter value
+	orgs, err := client.OrganizationsByUser(ctx, workspace.OwnerID.String())
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	brokenVersion := coderdtest.UpdateTemplateVersion(t, client, orgs[0].ID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Error: "apply error",
+				},
+			},
+		}},
+	}, workspace.TemplateID)
+	coderdtest.AwaitTemplateVersionJob(t, client, brokenVersion.ID)
+	update, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		TemplateVersionID: brokenVersion.ID,
+		Transition:        codersdk.WorkspaceTransitionStart,
+		ParameterValues: []codersdk.CreateParameterRequest{{
+			Name:              "region",
+			SourceValue:       "us",
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+		}},
+	})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, update.ID)
+	update, err = client.WorkspaceBuild(ctx, update.ID)
+	require.NoError(t, err)
+	require.NotNil(t, update.Job.CompletedAt)
+	require.NotEmpty(t, update.Job.Error)
+
+	// Given: the update failed transiently, which the echo provisioner
+	// can't do, so the retry is recorded the way provisionerdserver does
+	err = db.InsertWorkspaceBuildRecovery(ctx, database.InsertWorkspaceBuildRecoveryParams{
+		WorkspaceBuildID:  update.ID,
+		Reason:            database.BuildReasonRetry,
+		TemplateVersionID: brokenVersion.ID,
+		RecoverAt:         update.Job.CompletedAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// Given: the template rolls back failed updates as well
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		RollbackFailedUpdates: ptr.Ref(true),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks before the backoff elapsed
+	go func() {
+		tickCh <- update.Job.CompletedAt.Add(30 * time.Second)
+	}()
+
+	// Then: nothing should happen
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// When: the autobuild executor ticks after the backoff elapsed
+	go func() {
+		tickCh <- update.Job.CompletedAt.Add(2 * time.Minute)
+	}()
+
+	// Then: the update should be retried
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStart, stats.Transitions[workspace.ID])
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.BuildReasonRetry, workspace.LatestBuild.Reason)
+	require.Equal(t, brokenVersion.ID, workspace.LatestBuild.TemplateVersionID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	retry, err := client.WorkspaceBuild(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, retry.Job.Error)
+
+	// When: the autobuild executor ticks after the retry failed on the
+	// apply error, which isn't retried again
+	go func() {
+		tickCh <- retry.Job.CompletedAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be rolled back to its previous version and
+	// parameter values
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStart, stats.Transitions[workspace.ID])
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.BuildReasonRollback, workspace.LatestBuild.Reason)
+	require.NotEqual(t, brokenVersion.ID, workspace.LatestBuild.TemplateVersionID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	rollback, err := client.WorkspaceBuild(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	require.Empty(t, rollback.Job.Error)
+
+	params, err := db.ParameterValues(ctx, database.ParameterValuesParams{
+		Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+		ScopeIds: []uuid.UUID{workspace.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, params, 1)
+	require.Equal(t, "region", params[0].Name)
+	require.Equal(t, "eu", params[0].SourceValue)
+
+	// Then: the owner should be notified of the rollback
+	notifications, err := client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, notifications)
+	require.Contains(t, notifications[0].Title, "rolled back")
+}
+
+func TestExecutorBuildRetryOnlyTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a stopped workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	orgs, err := client.OrganizationsByUser(ctx, workspace.OwnerID.String())
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	failBuild := func(responses *echo.Responses) codersdk.WorkspaceBuild {
+		version := coderdtest.UpdateTemplateVersion(t, client, orgs[0].ID, responses, workspace.TemplateID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			TemplateVersionID: version.ID,
+			Transition:        codersdk.WorkspaceTransitionStart,
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+		build, err = client.WorkspaceBuild(ctx, build.ID)
+		require.NoError(t, err)
+		require.NotNil(t, build.Job.CompletedAt)
+		require.NotEmpty(t, build.Job.Error)
+		return build
+	}
+
+	// Given: the template retries failed builds
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		BuildRetries:            ptr.Ref(1),
+		BuildRetryBackoffMillis: ptr.Ref(time.Minute.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// Given: a build that failed on an error of the provisioner, which
+	// fails the same way when retried
+	failed := failBuild(&echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Error: "apply error",
+				},
+			},
+		}},
+	})
+
+	// When: the autobuild executor ticks after the backoff elapsed
+	go func() {
+		tickCh <- failed.Job.CompletedAt.Add(2 * time.Minute)
+	}()
+
+	// Then: nothing should happen
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// Given: a build that failed since the provisioner returned an error
+	failed = failBuild(&echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{},
+	})
+
+	// When: the autobuild executor ticks after the backoff elapsed
+	go func() {
+		tickCh <- failed.Job.CompletedAt.Add(2 * time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: nothing should happen
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, failed.ID, workspace.LatestBuild.ID)
+}
+
 func TestExecutorWorkspaceDeleted(t *testing.T) {
 	t.Parallel()
 
@@ -425,6 +1004,47 @@ func TestExecutorWorkspaceAutostopBeforeDeadline(t *testing.T) {
 	assert.Len(t, stats.Transitions, 0)
 }
 
+func TestExecutorAutostopNotification(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	require.NotZero(t, workspace.LatestBuild.Deadline)
+
+	// When: the autobuild executor ticks 30 minutes before the deadline.
+	// Ticks are truncated to the minute.
+	go func() {
+		tickCh <- workspace.LatestBuild.Deadline.Time.Add(-29*time.Minute - time.Nanosecond)
+		tickCh <- workspace.LatestBuild.Deadline.Time.Add(-28*time.Minute - time.Nanosecond)
+		close(tickCh)
+	}()
+
+	// Then: the workspace shouldn't stop yet
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// Then: the owner should be notified exactly once
+	notifications, err := client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, codersdk.NotificationKindWorkspaceAutostop, notifications[0].Kind)
+	require.Equal(t, workspace.ID, *notifications[0].WorkspaceID)
+}
+
 func TestExecutorWorkspaceAutostopNoWaitChangedMyMind(t *testing.T) {
 	t.Parallel()
 
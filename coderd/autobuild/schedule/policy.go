@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+const (
+	// DefaultActivityBump is the activity bump of new templates.
+	DefaultActivityBump = time.Hour
+	// DefaultActivityBumpThreshold is the activity bump threshold of new
+	// templates. It is slightly under the bump to minimize database writes.
+	DefaultActivityBumpThreshold = DefaultActivityBump - 10*time.Minute
+	// MaxBuildRetries is the most times a template may retry a failed
+	// start build.
+	MaxBuildRetries = 10
+	// maxBuildRetryBackoff caps the delay between two retries, which
+	// otherwise doubles with every retry.
+	maxBuildRetryBackoff = 24 * time.Hour
+)
+
+// TemplatePolicy is the scheduling policy a template enforces on the
+// workspaces created from it.
+type TemplatePolicy struct {
+	// MaxTTL is the longest a workspace build may run before it is stopped.
+	// Zero means no limit.
+	MaxTTL time.Duration
+	// MinAutostartInterval is the shortest interval allowed between two
+	// autostarts of a workspace. Zero means no limit.
+	MinAutostartInterval time.Duration
+	// QuietHours stops running workspaces regardless of their deadline.
+	// Nil if the template has no quiet hours.
+	QuietHours *Schedule
+	// AllowUserAutostop is whether users may disable autostop.
+	AllowUserAutostop bool
+	// InactivityTTL is how long a workspace may go unused before it is
+	// marked dormant. Zero disables dormancy.
+	InactivityTTL time.Duration
+	// DormancyDeletionTTL is how long a workspace may stay dormant before it
+	// is deleted. Zero disables automatic deletion.
+	DormancyDeletionTTL time.Duration
+	// ActivityBump is how far from now the deadline of a running workspace
+	// is pushed back while it has active sessions. Zero disables bumping.
+	ActivityBump time.Duration
+	// ActivityBumpThreshold is how close to now the deadline must be before
+	// activity bumps it.
+	ActivityBumpThreshold time.Duration
+	// BuildRetries is how many times a failed start build is retried.
+	BuildRetries int
+	// BuildRetryBackoff is the delay before the first retry. It doubles
+	// with every retry.
+	BuildRetryBackoff time.Duration
+	// RollbackFailedUpdates is whether workspaces are rolled back to their
+	// last successful build when an update fails and can't be retried.
+	RollbackFailedUpdates bool
+}
+
+// Policy returns the scheduling policy of the template.
+func Policy(template database.Template) (TemplatePolicy, error) {
+	policy := TemplatePolicy{
+		MaxTTL:                time.Duration(template.MaxTtl),
+		MinAutostartInterval:  time.Duration(template.MinAutostartInterval),
+		AllowUserAutostop:     template.AllowUserAutostop,
+		InactivityTTL:         time.Duration(template.InactivityTtl),
+		DormancyDeletionTTL:   time.Duration(template.DormancyDeletionTtl),
+		ActivityBump:          time.Duration(template.ActivityBump),
+		ActivityBumpThreshold: time.Duration(template.ActivityBumpThreshold),
+		BuildRetries:          int(template.BuildRetries),
+		BuildRetryBackoff:     time.Duration(template.BuildRetryBackoff),
+		RollbackFailedUpdates: template.RollbackFailedUpdates,
+	}
+	if template.QuietHoursSchedule != "" {
+		quietHours, err := Weekly(template.QuietHoursSchedule)
+		if err != nil {
+			return TemplatePolicy{}, xerrors.Errorf("parse quiet hours schedule: %w", err)
+		}
+		policy.QuietHours = quietHours
+	}
+	return policy, nil
+}
+
+// RequiresAutostop returns true if the policy stops workspaces
+// eventually, even if they don't have a TTL.
+func (p TemplatePolicy) RequiresAutostop() bool {
+	return p.MaxTTL > 0 || p.QuietHours != nil
+}
+
+// MaxDeadline returns the latest deadline allowed for a workspace build
+// started at start, or the zero time if there is no limit.
+func (p TemplatePolicy) MaxDeadline(start time.Time) time.Time {
+	var deadline time.Time
+	if p.MaxTTL > 0 {
+		deadline = start.Add(p.MaxTTL)
+	}
+	if p.QuietHours != nil {
+		next := p.QuietHours.Next(start)
+		if deadline.IsZero() || next.Before(deadline) {
+			deadline = next
+		}
+	}
+	return deadline
+}
+
+// ClampDeadline shortens the deadline of a workspace build started at
+// start to comply with the policy. A zero deadline means the build never
+// stops on its own.
+func (p TemplatePolicy) ClampDeadline(start, deadline time.Time) time.Time {
+	maxDeadline := p.MaxDeadline(start)
+	if maxDeadline.IsZero() {
+		return deadline
+	}
+	if deadline.IsZero() || deadline.After(maxDeadline) {
+		return maxDeadline
+	}
+	return deadline
+}
+
+// DormantAt returns when a workspace that was last active at lastActive
+// becomes dormant, or the zero time if the policy has no dormancy.
+func (p TemplatePolicy) DormantAt(lastActive time.Time) time.Time {
+	if p.InactivityTTL <= 0 {
+		return time.Time{}
+	}
+	return lastActive.Add(p.InactivityTTL)
+}
+
+// DeletingAt returns when a workspace that became dormant at dormantAt is
+// deleted, or the zero time if the policy has no automatic deletion.
+func (p TemplatePolicy) DeletingAt(dormantAt time.Time) time.Time {
+	if p.DormancyDeletionTTL <= 0 {
+		return time.Time{}
+	}
+	return dormantAt.Add(p.DormancyDeletionTTL)
+}
+
+// BumpDeadline returns the deadline of a workspace build started at start
+// after activity at now, or the zero time if activity doesn't change it.
+func (p TemplatePolicy) BumpDeadline(start, deadline, now time.Time) time.Time {
+	if p.ActivityBump <= 0 || deadline.IsZero() || !deadline.Before(now.Add(p.ActivityBumpThreshold)) {
+		return time.Time{}
+	}
+	// Activity never keeps a workspace running past the maximum allowed by
+	// the policy.
+	newDeadline := p.ClampDeadline(start, now.Add(p.ActivityBump))
+	if !newDeadline.After(deadline) {
+		return time.Time{}
+	}
+	return newDeadline
+}
+
+// RetryAt returns when a start build that failed at failedAt is retried,
+// given the number of retries that preceded it, or the zero time if it
+// isn't retried.
+func (p TemplatePolicy) RetryAt(failedAt time.Time, retries int) time.Time {
+	if retries < 0 || retries >= p.BuildRetries {
+		return time.Time{}
+	}
+	backoff := p.BuildRetryBackoff
+	for i := 0; i < retries && backoff < maxBuildRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBuildRetryBackoff {
+		backoff = maxBuildRetryBackoff
+	}
+	return failedAt.Add(backoff)
+}
+
+// ValidateTTL returns an error if the policy doesn't allow users to set
+// their workspace TTL to ttl. A zero TTL disables autostop.
+func (p TemplatePolicy) ValidateTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		if !p.AllowUserAutostop {
+			return xerrors.New("template requires autostop to be enabled")
+		}
+		return nil
+	}
+	if p.MaxTTL > 0 && ttl > p.MaxTTL {
+		return xerrors.Errorf("time until shutdown must be at most %s, the maximum allowed by the template", p.MaxTTL)
+	}
+	return nil
+}
+
+// ValidateAutostart returns an error if the policy doesn't allow the
+// autostart schedule.
+func (p TemplatePolicy) ValidateAutostart(sched *Schedule) error {
+	if p.MinAutostartInterval > 0 && sched.Min() < p.MinAutostartInterval {
+		return xerrors.Errorf("autostart must be at least %s apart, as required by the template", p.MinAutostartInterval)
+	}
+	return nil
+}
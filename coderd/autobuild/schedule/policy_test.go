@@ -0,0 +1,139 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/autobuild/schedule"
+	"github.com/coder/coder/coderd/database"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{AllowUserAutostop: true})
+		require.NoError(t, err)
+		require.False(t, policy.RequiresAutostop())
+		require.True(t, policy.MaxDeadline(start).IsZero())
+		require.True(t, policy.ClampDeadline(start, time.Time{}).IsZero())
+		require.Equal(t, start.Add(time.Hour), policy.ClampDeadline(start, start.Add(time.Hour)))
+		require.NoError(t, policy.ValidateTTL(0))
+		require.NoError(t, policy.ValidateTTL(48*time.Hour))
+	})
+
+	t.Run("MaxTTL", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{
+			MaxTtl:            int64(8 * time.Hour),
+			AllowUserAutostop: true,
+		})
+		require.NoError(t, err)
+		require.True(t, policy.RequiresAutostop())
+		require.Equal(t, start.Add(8*time.Hour), policy.ClampDeadline(start, time.Time{}))
+		require.Equal(t, start.Add(8*time.Hour), policy.ClampDeadline(start, start.Add(9*time.Hour)))
+		require.Equal(t, start.Add(time.Hour), policy.ClampDeadline(start, start.Add(time.Hour)))
+		require.NoError(t, policy.ValidateTTL(8*time.Hour))
+		require.Error(t, policy.ValidateTTL(9*time.Hour))
+	})
+
+	t.Run("QuietHours", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{
+			MaxTtl:             int64(24 * time.Hour),
+			QuietHoursSchedule: "CRON_TZ=UTC 0 22 * * *",
+			AllowUserAutostop:  true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2022, 4, 1, 22, 0, 0, 0, time.UTC), policy.MaxDeadline(start))
+		require.Equal(t, start.Add(time.Hour), policy.ClampDeadline(start, start.Add(time.Hour)))
+
+		_, err = schedule.Policy(database.Template{QuietHoursSchedule: "nightly"})
+		require.Error(t, err)
+	})
+
+	t.Run("DisallowUserAutostop", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{})
+		require.NoError(t, err)
+		require.Error(t, policy.ValidateTTL(0))
+		require.NoError(t, policy.ValidateTTL(time.Hour))
+	})
+
+	t.Run("Dormancy", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{})
+		require.NoError(t, err)
+		require.True(t, policy.DormantAt(start).IsZero())
+		require.True(t, policy.DeletingAt(start).IsZero())
+
+		policy, err = schedule.Policy(database.Template{
+			InactivityTtl:       int64(7 * 24 * time.Hour),
+			DormancyDeletionTtl: int64(30 * 24 * time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, start.Add(7*24*time.Hour), policy.DormantAt(start))
+		require.Equal(t, start.Add(30*24*time.Hour), policy.DeletingAt(start))
+	})
+
+	t.Run("BumpDeadline", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{
+			MaxTtl:                int64(8 * time.Hour),
+			ActivityBump:          int64(time.Hour),
+			ActivityBumpThreshold: int64(50 * time.Minute),
+		})
+		require.NoError(t, err)
+		now := start.Add(2 * time.Hour)
+		// The deadline is too far away to bump.
+		require.True(t, policy.BumpDeadline(start, now.Add(55*time.Minute), now).IsZero())
+		require.Equal(t, now.Add(time.Hour), policy.BumpDeadline(start, now.Add(10*time.Minute), now))
+		// Workspaces without a deadline aren't bumped.
+		require.True(t, policy.BumpDeadline(start, time.Time{}, now).IsZero())
+		// The bump is clamped to the maximum TTL.
+		now = start.Add(7*time.Hour + 30*time.Minute)
+		require.Equal(t, start.Add(8*time.Hour), policy.BumpDeadline(start, now.Add(10*time.Minute), now))
+
+		policy.ActivityBump = 0
+		require.True(t, policy.BumpDeadline(start, now.Add(10*time.Minute), now).IsZero())
+	})
+
+	t.Run("MinAutostartInterval", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{
+			MinAutostartInterval: int64(24 * time.Hour),
+		})
+		require.NoError(t, err)
+		daily, err := schedule.Weekly("CRON_TZ=UTC 0 9 * * *")
+		require.NoError(t, err)
+		require.NoError(t, policy.ValidateAutostart(daily))
+		twiceDaily, err := schedule.Weekly("CRON_TZ=UTC 0 9,13 * * *")
+		require.NoError(t, err)
+		require.Error(t, policy.ValidateAutostart(twiceDaily))
+	})
+
+	t.Run("RetryAt", func(t *testing.T) {
+		t.Parallel()
+		policy, err := schedule.Policy(database.Template{
+			BuildRetries:      3,
+			BuildRetryBackoff: int64(time.Minute),
+		})
+		require.NoError(t, err)
+		require.Equal(t, start.Add(time.Minute), policy.RetryAt(start, 0))
+		require.Equal(t, start.Add(2*time.Minute), policy.RetryAt(start, 1))
+		require.Equal(t, start.Add(4*time.Minute), policy.RetryAt(start, 2))
+		require.True(t, policy.RetryAt(start, 3).IsZero())
+
+		policy.BuildRetries = 30
+		policy.BuildRetryBackoff = time.Hour
+		require.Equal(t, start.Add(24*time.Hour), policy.RetryAt(start, 20))
+
+		policy.BuildRetries = 0
+		require.True(t, policy.RetryAt(start, 0).IsZero())
+	})
+}
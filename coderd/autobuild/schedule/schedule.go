@@ -40,7 +40,20 @@ func Weekly(raw string) (*Schedule, error) {
 	if err := validateWeeklySpec(raw); err != nil {
 		return nil, xerrors.Errorf("validate weekly schedule: %w", err)
 	}
+	return parse(raw)
+}
+
+// Cron parses a Schedule from a standard five-field cron spec, optionally
+// prefixed by a timezone. Unlike Weekly, the day of month and month may be
+// restricted too, e.g. "CRON_TZ=Europe/Dublin 0 9 1-15 * 1-5".
+func Cron(raw string) (*Schedule, error) {
+	if len(strings.Fields(raw)) < 5 {
+		return nil, xerrors.Errorf("expected schedule to consist of 5 fields with an optional CRON_TZ=<timezone> prefix")
+	}
+	return parse(raw)
+}
 
+func parse(raw string) (*Schedule, error) {
 	// If schedule does not specify a timezone, default to UTC. Otherwise,
 	// the library will default to time.Local which we want to avoid.
 	if !strings.HasPrefix(raw, "CRON_TZ=") {
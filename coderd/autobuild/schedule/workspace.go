@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// maxSkipped bounds the search for a transition that isn't skipped, so
+// schedules that only fire on skip dates don't loop forever.
+const maxSkipped = 1000
+
+// Window is a period a workspace runs in every time Start fires, until Stop
+// fires.
+type Window struct {
+	Start *Schedule
+	Stop  *Schedule
+}
+
+// WorkspaceSchedule is every schedule that starts or stops a workspace.
+type WorkspaceSchedule struct {
+	// Autostart starts the workspace, which then stops once its TTL has
+	// elapsed. Nil if the workspace has no autostart schedule.
+	Autostart *Schedule
+	Windows   []Window
+	// SkipDates are dates the workspace isn't started automatically on,
+	// e.g. holidays. They are compared with the date a start fires on in
+	// the location of its schedule. Stops still fire on skip dates.
+	SkipDates []time.Time
+}
+
+// Transition is a scheduled start or stop of a workspace.
+type Transition struct {
+	Transition database.WorkspaceTransition
+	At         time.Time
+}
+
+// ForWorkspace returns the schedule of the workspace.
+func ForWorkspace(ws database.Workspace, windows []database.WorkspaceScheduleWindow, skipDates []database.WorkspaceScheduleSkipDate) (WorkspaceSchedule, error) {
+	var sched WorkspaceSchedule
+	if ws.AutostartSchedule.String != "" {
+		autostart, err := Weekly(ws.AutostartSchedule.String)
+		if err != nil {
+			return WorkspaceSchedule{}, xerrors.Errorf("parse autostart schedule: %w", err)
+		}
+		sched.Autostart = autostart
+	}
+	for _, window := range windows {
+		start, err := Cron(window.StartSchedule)
+		if err != nil {
+			return WorkspaceSchedule{}, xerrors.Errorf("parse window start schedule: %w", err)
+		}
+		stop, err := Cron(window.StopSchedule)
+		if err != nil {
+			return WorkspaceSchedule{}, xerrors.Errorf("parse window stop schedule: %w", err)
+		}
+		sched.Windows = append(sched.Windows, Window{Start: start, Stop: stop})
+	}
+	for _, skipDate := range skipDates {
+		sched.SkipDates = append(sched.SkipDates, skipDate.Date)
+	}
+	return sched, nil
+}
+
+// Starts returns every schedule that starts the workspace.
+func (s WorkspaceSchedule) Starts() []*Schedule {
+	var starts []*Schedule
+	if s.Autostart != nil {
+		starts = append(starts, s.Autostart)
+	}
+	for _, window := range s.Windows {
+		starts = append(starts, window.Start)
+	}
+	return starts
+}
+
+// NextStart returns the next time after t the workspace is started, or the
+// zero time if it's never started automatically.
+func (s WorkspaceSchedule) NextStart(t time.Time) time.Time {
+	var next time.Time
+	for _, start := range s.Starts() {
+		candidate := s.nextUnskipped(start, t)
+		if !candidate.IsZero() && (next.IsZero() || candidate.Before(next)) {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// NextStop returns the next time after t a window stops the workspace, or
+// the zero time if the workspace has no windows. Stops after the TTL of the
+// workspace aren't included. A window ending as another begins doesn't stop
+// the workspace.
+func (s WorkspaceSchedule) NextStop(t time.Time) time.Time {
+	for i := 0; i < maxSkipped; i++ {
+		var next time.Time
+		for _, window := range s.Windows {
+			candidate := window.Stop.Next(t)
+			if !candidate.IsZero() && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+		if next.IsZero() || !s.NextStart(next.Add(-time.Nanosecond)).Equal(next) {
+			return next
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// Upcoming returns up to n transitions scheduled after t, in chronological
+// order.
+func (s WorkspaceSchedule) Upcoming(t time.Time, n int) []Transition {
+	var transitions []Transition
+	for len(transitions) < n {
+		start, stop := s.NextStart(t), s.NextStop(t)
+		switch {
+		case start.IsZero() && stop.IsZero():
+			return transitions
+		case start.IsZero() || (!stop.IsZero() && stop.Before(start)):
+			transitions = append(transitions, Transition{Transition: database.WorkspaceTransitionStop, At: stop})
+			t = stop
+		default:
+			t = start
+			if s.continuesWindow(start) {
+				// The workspace is already running.
+				continue
+			}
+			transitions = append(transitions, Transition{Transition: database.WorkspaceTransitionStart, At: start})
+		}
+	}
+	return transitions
+}
+
+// continuesWindow returns true if a window stops at t, so a window starting
+// at t continues it.
+func (s WorkspaceSchedule) continuesWindow(t time.Time) bool {
+	for _, window := range s.Windows {
+		if window.Stop.Next(t.Add(-time.Nanosecond)).Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextUnskipped returns the next time after t sched fires on a date that
+// isn't skipped.
+func (s WorkspaceSchedule) nextUnskipped(sched *Schedule, t time.Time) time.Time {
+	for i := 0; i < maxSkipped; i++ {
+		next := sched.Next(t)
+		if next.IsZero() || !s.skipped(next.In(sched.Location())) {
+			return next
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+func (s WorkspaceSchedule) skipped(t time.Time) bool {
+	year, month, day := t.Date()
+	for _, skipDate := range s.SkipDates {
+		skipYear, skipMonth, skipDay := skipDate.Date()
+		if year == skipYear && month == skipMonth && day == skipDay {
+			return true
+		}
+	}
+	return false
+}
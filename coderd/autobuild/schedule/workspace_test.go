@@ -0,0 +1,97 @@
+package schedule_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/autobuild/schedule"
+	"github.com/coder/coder/coderd/database"
+)
+
+func TestWorkspaceSchedule(t *testing.T) {
+	t.Parallel()
+
+	// A Friday.
+	now := time.Date(2022, 4, 1, 7, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2022, 4, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		sched, err := schedule.ForWorkspace(database.Workspace{}, nil, nil)
+		require.NoError(t, err)
+		require.True(t, sched.NextStart(now).IsZero())
+		require.True(t, sched.NextStop(now).IsZero())
+		require.Empty(t, sched.Upcoming(now, 10))
+	})
+
+	t.Run("Windows", func(t *testing.T) {
+		t.Parallel()
+		// Split shifts on weekdays.
+		sched, err := schedule.ForWorkspace(database.Workspace{}, []database.WorkspaceScheduleWindow{
+			{StartSchedule: "CRON_TZ=UTC 0 8 * * 1-5", StopSchedule: "CRON_TZ=UTC 0 12 * * 1-5"},
+			{StartSchedule: "CRON_TZ=UTC 0 16 * * 1-5", StopSchedule: "CRON_TZ=UTC 0 20 * * 1-5"},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, at(1, 8), sched.NextStart(now))
+		require.Equal(t, at(1, 12), sched.NextStop(now))
+		require.Equal(t, []schedule.Transition{
+			{Transition: database.WorkspaceTransitionStart, At: at(1, 8)},
+			{Transition: database.WorkspaceTransitionStop, At: at(1, 12)},
+			{Transition: database.WorkspaceTransitionStart, At: at(1, 16)},
+			{Transition: database.WorkspaceTransitionStop, At: at(1, 20)},
+			// The weekend is skipped.
+			{Transition: database.WorkspaceTransitionStart, At: at(4, 8)},
+		}, sched.Upcoming(now, 5))
+	})
+
+	t.Run("AdjacentWindows", func(t *testing.T) {
+		t.Parallel()
+		sched, err := schedule.ForWorkspace(database.Workspace{}, []database.WorkspaceScheduleWindow{
+			{StartSchedule: "0 8 * * *", StopSchedule: "0 12 * * *"},
+			{StartSchedule: "0 12 * * *", StopSchedule: "0 16 * * *"},
+		}, nil)
+		require.NoError(t, err)
+		// The workspace keeps running from one window to the next.
+		require.Equal(t, at(1, 16), sched.NextStop(at(1, 8)))
+		require.Equal(t, []schedule.Transition{
+			{Transition: database.WorkspaceTransitionStart, At: at(1, 8)},
+			{Transition: database.WorkspaceTransitionStop, At: at(1, 16)},
+			{Transition: database.WorkspaceTransitionStart, At: at(2, 8)},
+		}, sched.Upcoming(now, 3))
+	})
+
+	t.Run("SkipDates", func(t *testing.T) {
+		t.Parallel()
+		sched, err := schedule.ForWorkspace(database.Workspace{
+			AutostartSchedule: sql.NullString{String: "CRON_TZ=UTC 0 9 * * *", Valid: true},
+		}, []database.WorkspaceScheduleWindow{
+			{StartSchedule: "0 14 * * *", StopSchedule: "0 18 * * *"},
+		}, []database.WorkspaceScheduleSkipDate{
+			{Date: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)},
+		})
+		require.NoError(t, err)
+		// Starts are skipped on skip dates, stops aren't.
+		require.Equal(t, at(2, 9), sched.NextStart(now))
+		require.Equal(t, at(1, 18), sched.NextStop(now))
+	})
+
+	t.Run("FullCron", func(t *testing.T) {
+		t.Parallel()
+		// Only in the first half of the month.
+		sched, err := schedule.ForWorkspace(database.Workspace{}, []database.WorkspaceScheduleWindow{
+			{StartSchedule: "0 9 1-15 * *", StopSchedule: "0 17 1-15 * *"},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2022, 5, 1, 9, 0, 0, 0, time.UTC), sched.NextStart(at(15, 10)))
+
+		_, err = schedule.ForWorkspace(database.Workspace{}, []database.WorkspaceScheduleWindow{
+			{StartSchedule: "0 9", StopSchedule: "0 17 * * *"},
+		}, nil)
+		require.Error(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -36,7 +37,10 @@ import (
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ldapauth"
+	"github.com/coder/coder/coderd/mailer"
 	"github.com/coder/coder/coderd/metricscache"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
@@ -79,8 +83,11 @@ type Options struct {
 	GoogleTokenValidator *idtoken.Validator
 	GithubOAuth2Config   *GithubOAuth2Config
 	OIDCConfig           *OIDCConfig
+	LDAPConfig           *ldapauth.Config
 	PrometheusRegistry   *prometheus.Registry
 	SecureAuthCookie     bool
+	TwoFactorRequired    bool
+	MaxTokenLifetime     time.Duration
 	SSHKeygenAlgorithm   gitsshkey.Algorithm
 	Telemetry            telemetry.Reporter
 	TracerProvider       trace.TracerProvider
@@ -88,11 +95,35 @@ type Options struct {
 	GitAuthConfigs       []*gitauth.Config
 	RealIPConfig         *httpmw.RealIPConfig
 
+	// Mailer sends user invitation and password reset emails. Both are
+	// disabled when it's nil.
+	Mailer                 mailer.Mailer
+	UserInvitationLifetime time.Duration
+	PasswordResetLifetime  time.Duration
+	// Notifier delivers notifications about workspaces to their owners.
+	// Notifications are discarded when it's nil.
+	Notifier *notifications.Notifier
+
 	// TLSCertificates is used to mesh DERP servers securely.
 	TLSCertificates    []tls.Certificate
 	TailnetCoordinator tailnet.Coordinator
 	DERPServer         *derp.Server
-	DERPMap            *tailcfg.DERPMap
+	// DERPMap is the static DERP map. Regions managed through the API are
+	// merged into it, see API.CurrentDERPMap.
+	DERPMap *tailcfg.DERPMap
+	// DERPBlockDirect forces agents and clients to relay all traffic
+	// through DERP instead of connecting directly.
+	DERPBlockDirect bool
+	// DERPHealthCheckInterval is how often the DERP nodes are checked.
+	DERPHealthCheckInterval time.Duration
+	// AgentConnectionCacheSize is the maximum number of agent connections
+	// kept for proxying apps and terminals. There is no limit when it's
+	// zero.
+	AgentConnectionCacheSize int
+	// WorkspaceBatchHeartbeatInterval is how often the replica running a
+	// workspace batch records that it's alive. Batches that miss three
+	// heartbeats are resumed by another replica.
+	WorkspaceBatchHeartbeatInterval time.Duration
 
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
@@ -124,12 +155,24 @@ func New(options *Options) *API {
 	if options.APIRateLimit == 0 {
 		options.APIRateLimit = 512
 	}
+	if options.UserInvitationLifetime == 0 {
+		options.UserInvitationLifetime = 72 * time.Hour
+	}
+	if options.PasswordResetLifetime == 0 {
+		options.PasswordResetLifetime = time.Hour
+	}
 	if options.AgentStatsRefreshInterval == 0 {
 		options.AgentStatsRefreshInterval = 10 * time.Minute
 	}
 	if options.MetricsCacheRefreshInterval == 0 {
 		options.MetricsCacheRefreshInterval = time.Hour
 	}
+	if options.DERPHealthCheckInterval == 0 {
+		options.DERPHealthCheckInterval = time.Minute
+	}
+	if options.WorkspaceBatchHeartbeatInterval == 0 {
+		options.WorkspaceBatchHeartbeatInterval = 10 * time.Second
+	}
 	if options.Authorizer == nil {
 		options.Authorizer = rbac.NewAuthorizer()
 	}
@@ -175,30 +218,65 @@ func New(options *Options) *API {
 			Logger:     options.Logger,
 		},
 		metricsCache:           metricsCache,
+		workspaceBatches:       newWorkspaceBatches(),
 		Auditor:                atomic.Pointer[audit.Auditor]{},
 		WorkspaceQuotaEnforcer: atomic.Pointer[workspacequota.Enforcer]{},
 	}
 	api.Auditor.Store(&options.Auditor)
 	api.WorkspaceQuotaEnforcer.Store(&options.WorkspaceQuotaEnforcer)
-	api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
+	api.workspaceAgentCache = wsconncache.NewWithOptions(api.dialWorkspaceAgentTailnet, wsconncache.Options{
+		MaxConns: options.AgentConnectionCacheSize,
+		// In-flight app requests get a chance to finish when the
+		// replica shuts down.
+		DrainTimeout: 10 * time.Second,
+		Registerer:   options.PrometheusRegistry,
+	})
 	api.TailnetCoordinator.Store(&options.TailnetCoordinator)
+
+	api.currentDERPMap.Store(mergeDERPMap(options.DERPMap, nil, nil))
+	api.derpMapListeners = map[chan<- struct{}]struct{}{}
+	err = api.refreshDERPMap(context.Background())
+	if err != nil {
+		options.Logger.Warn(context.Background(), "load derp regions", slog.Error(err))
+	}
+	api.derpMapUnsubscribe, err = options.Pubsub.Subscribe(derpMapChannel, func(ctx context.Context, _ []byte) {
+		err := api.refreshDERPMap(ctx)
+		if err != nil {
+			api.Logger.Warn(ctx, "refresh derp map", slog.Error(err))
+		}
+	})
+	if err != nil {
+		panic(xerrors.Errorf("subscribe to derp map updates: %w", err))
+	}
+	derpMapUpdates, unsubscribeDERPMap := api.subscribeDERPMap()
+	api.derpHealth = newDERPHealthChecker(options.Logger.Named("derp_health"), options.DERPHealthCheckInterval, api.CurrentDERPMap, derpMapUpdates)
+	api.derpHealthUnsubscribe = unsubscribeDERPMap
+
+	api.workspaceBatches.wg.Add(1)
+	go func() {
+		defer api.workspaceBatches.wg.Done()
+		api.resumeWorkspaceBatches(api.workspaceBatches.ctx)
+	}()
+
 	oauthConfigs := &httpmw.OAuth2Configs{
 		Github: options.GithubOAuth2Config,
 		OIDC:   options.OIDCConfig,
 	}
 
 	apiKeyMiddleware := httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
-		DB:              options.Database,
-		OAuth2Configs:   oauthConfigs,
-		RedirectToLogin: false,
-		Optional:        false,
+		DB:               options.Database,
+		OAuth2Configs:    oauthConfigs,
+		RedirectToLogin:  false,
+		Optional:         false,
+		MaxTokenLifetime: options.MaxTokenLifetime,
 	})
 	// Same as above but it redirects to the login page.
 	apiKeyMiddlewareRedirect := httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
-		DB:              options.Database,
-		OAuth2Configs:   oauthConfigs,
-		RedirectToLogin: true,
-		Optional:        false,
+		DB:               options.Database,
+		OAuth2Configs:    oauthConfigs,
+		RedirectToLogin:  true,
+		Optional:         false,
+		MaxTokenLifetime: options.MaxTokenLifetime,
 	})
 
 	r.Use(
@@ -217,8 +295,9 @@ func New(options *Options) *API {
 				OAuth2Configs: oauthConfigs,
 				// The code handles the the case where the user is not
 				// authenticated automatically.
-				RedirectToLogin: false,
-				Optional:        true,
+				RedirectToLogin:  false,
+				Optional:         true,
+				MaxTokenLifetime: options.MaxTokenLifetime,
 			}),
 			httpmw.ExtractUserParam(api.Database, false),
 			httpmw.ExtractWorkspaceAndAgentParam(api.Database),
@@ -245,8 +324,9 @@ func New(options *Options) *API {
 				// Optional is true to allow for public apps. If an
 				// authorization check fails and the user is not authenticated,
 				// they will be redirected to the login page by the app handler.
-				RedirectToLogin: false,
-				Optional:        true,
+				RedirectToLogin:  false,
+				Optional:         true,
+				MaxTokenLifetime: options.MaxTokenLifetime,
 			}),
 			// Redirect to the login page if the user tries to open an app with
 			// "me" as the username and they are not logged in.
@@ -310,6 +390,27 @@ func New(options *Options) *API {
 			r.Use(apiKeyMiddleware)
 			r.Get("/deployment", api.deploymentConfig)
 		})
+		r.Route("/debug", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/coordinator", api.debugCoordinator)
+		})
+		r.Route("/derp", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Route("/regions", func(r chi.Router) {
+				r.Get("/", api.derpRegions)
+				r.Post("/", api.postDERPRegion)
+				r.Route("/{region}", func(r chi.Router) {
+					r.Get("/", api.derpRegion)
+					r.Patch("/", api.patchDERPRegion)
+					r.Delete("/", api.deleteDERPRegion)
+					r.Route("/nodes", func(r chi.Router) {
+						r.Post("/", api.postDERPNode)
+						r.Patch("/{node}", api.patchDERPNode)
+						r.Delete("/{node}", api.deleteDERPNode)
+					})
+				})
+			})
+		})
 		r.Route("/audit", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -419,6 +520,9 @@ func New(options *Options) *API {
 				// Making this too small can break tests.
 				r.Use(httpmw.RateLimit(60, time.Minute))
 				r.Post("/login", api.postLogin)
+				r.Post("/invitations/accept", api.postUserInvitationAccept)
+				r.Post("/forgot-password", api.postForgotPassword)
+				r.Post("/reset-password", api.postResetPassword)
 			})
 			r.Get("/authmethods", api.userAuthMethods)
 			r.Route("/oauth2", func(r chi.Router) {
@@ -439,6 +543,14 @@ func New(options *Options) *API {
 				r.Get("/", api.users)
 				r.Get("/count", api.userCount)
 				r.Post("/logout", api.postLogout)
+				r.Get("/invitations", api.userInvitations)
+				r.Post("/invitations", api.postUserInvitation)
+				r.Delete("/invitations/{invitation}", api.deleteUserInvitation)
+				// These routes manage the API keys of every user.
+				r.Route("/keys", func(r chi.Router) {
+					r.Get("/", api.allAPIKeys)
+					r.Delete("/{keyid}", api.revokeAPIKey)
+				})
 				// These routes query information about site wide roles.
 				r.Route("/roles", func(r chi.Router) {
 					r.Get("/", api.assignableSiteRoles)
@@ -471,6 +583,20 @@ func New(options *Options) *API {
 						})
 					})
 
+					r.Route("/sessions", func(r chi.Router) {
+						r.Get("/", api.sessions)
+						r.Delete("/", api.deleteSessions)
+						r.Delete("/{session}", api.deleteSession)
+					})
+
+					r.Route("/notifications", func(r chi.Router) {
+						r.Get("/", api.notifications)
+						r.Put("/read", api.putNotificationsRead)
+						r.Get("/preferences", api.notificationPreferences)
+						r.Put("/preferences", api.putNotificationPreferences)
+					})
+					r.Get("/workspacetransfers", api.workspaceTransfers)
+
 					r.Route("/organizations", func(r chi.Router) {
 						r.Get("/", api.organizationsByUser)
 						r.Get("/{organizationname}", api.organizationByUserAndName)
@@ -481,6 +607,12 @@ func New(options *Options) *API {
 					})
 					r.Get("/gitsshkey", api.gitSSHKey)
 					r.Put("/gitsshkey", api.regenerateGitSSHKey)
+					r.Route("/twofactor", func(r chi.Router) {
+						r.Get("/", api.userTwoFactor)
+						r.Post("/", api.postUserTwoFactor)
+						r.Delete("/", api.deleteUserTwoFactor)
+						r.Post("/verify", api.postUserTwoFactorVerify)
+					})
 				})
 			})
 		})
@@ -497,6 +629,15 @@ func New(options *Options) *API {
 				r.Get("/gitsshkey", api.agentGitSSHKey)
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
 				r.Get("/report-stats", api.workspaceAgentReportStats)
+				r.Get("/derp-map", api.watchDERPMap)
+				r.Get("/peers", api.workspaceAgentPeers)
+				r.Route("/peers/{workspaceagent}", func(r chi.Router) {
+					r.Use(
+						httpmw.ExtractWorkspaceAgentParam(options.Database),
+						httpmw.ExtractWorkspaceParam(options.Database),
+					)
+					r.Get("/coordinate", api.workspaceAgentPeerCoordinate)
+				})
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
 				r.Use(
@@ -508,6 +649,7 @@ func New(options *Options) *API {
 				r.Get("/pty", api.workspaceAgentPTY)
 				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
 				r.Get("/connection", api.workspaceAgentConnection)
+				r.Get("/derp-map", api.workspaceAgentClientWatchDERPMap)
 				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
 				// TODO: This can be removed in October. It allows for a friendly
 				// error message when transitioning from WebRTC to Tailscale. See:
@@ -524,12 +666,23 @@ func New(options *Options) *API {
 				apiKeyMiddleware,
 			)
 			r.Get("/", api.workspaces)
+			r.Route("/batch", func(r chi.Router) {
+				r.Post("/", api.postWorkspaceBatch)
+				r.Get("/{batch}", api.workspaceBatch)
+			})
 			r.Route("/{workspace}", func(r chi.Router) {
 				r.Use(
 					httpmw.ExtractWorkspaceParam(options.Database),
 				)
 				r.Get("/", api.workspace)
 				r.Patch("/", api.patchWorkspace)
+				r.Post("/clone", api.postWorkspaceClone)
+				r.Route("/transfer", func(r chi.Router) {
+					r.Post("/", api.postWorkspaceTransfer)
+					r.Get("/", api.workspaceTransfer)
+					r.Delete("/", api.deleteWorkspaceTransfer)
+					r.Post("/accept", api.postWorkspaceTransferAccept)
+				})
 				r.Route("/builds", func(r chi.Router) {
 					r.Get("/", api.workspaceBuilds)
 					r.Post("/", api.postWorkspaceBuilds)
@@ -542,6 +695,13 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Put("/dormant", api.putWorkspaceDormancy)
+				r.Put("/autoupdates", api.putWorkspaceAutomaticUpdates)
+				r.Route("/schedule", func(r chi.Router) {
+					r.Get("/", api.workspaceSchedule)
+					r.Put("/", api.putWorkspaceSchedule)
+					r.Get("/upcoming", api.workspaceScheduleUpcoming)
+				})
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -603,21 +763,41 @@ type API struct {
 	siteHandler         http.Handler
 	websocketWaitMutex  sync.Mutex
 	websocketWaitGroup  sync.WaitGroup
+	mailWaitGroup       sync.WaitGroup
 	workspaceAgentCache *wsconncache.Cache
+	agentTailnet        agentTailnet
+	workspaceBatches    *workspaceBatches
+
+	currentDERPMap        atomic.Pointer[tailcfg.DERPMap]
+	derpMapMutex          sync.Mutex
+	derpMapListeners      map[chan<- struct{}]struct{}
+	derpMapUnsubscribe    func()
+	derpHealth            *derpHealthChecker
+	derpHealthUnsubscribe func()
 }
 
-// Close waits for all WebSocket connections to drain before returning.
+// Close waits for all WebSocket connections to drain and pending emails to
+// be sent before returning.
 func (api *API) Close() error {
 	api.websocketWaitMutex.Lock()
 	api.websocketWaitGroup.Wait()
 	api.websocketWaitMutex.Unlock()
+	api.mailWaitGroup.Wait()
 
+	api.workspaceBatches.Close()
 	api.metricsCache.Close()
+	api.derpMapUnsubscribe()
+	api.derpHealthUnsubscribe()
+	api.derpHealth.Close()
 	coordinator := api.TailnetCoordinator.Load()
 	if coordinator != nil {
 		_ = (*coordinator).Close()
 	}
-	return api.workspaceAgentCache.Close()
+	err := api.workspaceAgentCache.Close()
+	if err != nil {
+		return err
+	}
+	return api.closeAgentTailnet()
 }
 
 func compressHandler(h http.Handler) http.Handler {
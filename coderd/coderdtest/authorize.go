@@ -44,9 +44,13 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"POST:/api/v2/users/first":      {NoAuthorize: true},
 		"POST:/api/v2/users/login":      {NoAuthorize: true},
 		"GET:/api/v2/users/authmethods": {NoAuthorize: true},
-		"POST:/api/v2/csp/reports":      {NoAuthorize: true},
-		"POST:/api/v2/authcheck":        {NoAuthorize: true},
-		"GET:/api/v2/applications/host": {NoAuthorize: true},
+		// Authorized by the token sent by email.
+		"POST:/api/v2/users/invitations/accept": {NoAuthorize: true},
+		"POST:/api/v2/users/forgot-password":    {NoAuthorize: true},
+		"POST:/api/v2/users/reset-password":     {NoAuthorize: true},
+		"POST:/api/v2/csp/reports":              {NoAuthorize: true},
+		"POST:/api/v2/authcheck":                {NoAuthorize: true},
+		"GET:/api/v2/applications/host":         {NoAuthorize: true},
 		// This is a dummy endpoint for compatibility with older CLI versions.
 		"GET:/api/v2/workspaceagents/{workspaceagent}/dial": {NoAuthorize: true},
 
@@ -65,10 +69,59 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"POST:/api/v2/workspaceagents/me/version":               {NoAuthorize: true},
 		"POST:/api/v2/workspaceagents/me/app-health":            {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/report-stats":           {NoAuthorize: true},
+		"GET:/api/v2/workspaceagents/me/derp-map":               {NoAuthorize: true},
+		"GET:/api/v2/workspaceagents/me/peers":                  {NoAuthorize: true},
+		// Peers are authorized as the owner of the agent, which the
+		// tester can't authenticate as.
+		"GET:/api/v2/workspaceagents/me/peers/{workspaceagent}/coordinate": {NoAuthorize: true},
 
 		// These endpoints have more assertions. This is good, add more endpoints to assert if you can!
 		"GET:/api/v2/organizations/{organization}": {AssertObject: rbac.ResourceOrganization.InOrg(a.Admin.OrganizationID)},
 		"GET:/api/v2/users/{user}/organizations":   {StatusCode: http.StatusOK, AssertObject: rbac.ResourceOrganization},
+		"GET:/api/v2/users/keys": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceAPIKey,
+		},
+		"GET:/api/v2/debug/coordinator": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceDebugInfo,
+		},
+		"GET:/api/v2/derp/regions": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"POST:/api/v2/derp/regions": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"GET:/api/v2/derp/regions/{region}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"PATCH:/api/v2/derp/regions/{region}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"DELETE:/api/v2/derp/regions/{region}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"POST:/api/v2/derp/regions/{region}/nodes": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"PATCH:/api/v2/derp/regions/{region}/nodes/{node}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"DELETE:/api/v2/derp/regions/{region}/nodes/{node}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceDERPRegion,
+		},
+		"DELETE:/api/v2/users/keys/{keyid}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceAPIKey.WithOwner(a.Admin.UserID.String()),
+		},
 		"GET:/api/v2/users/{user}/workspace/{workspacename}": {
 			AssertObject: rbac.ResourceWorkspace,
 			AssertAction: rbac.ActionRead,
@@ -93,6 +146,22 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"POST:/api/v2/workspaces/{workspace}/clone": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"POST:/api/v2/workspaces/{workspace}/transfer": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaces/{workspace}/transfer": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"DELETE:/api/v2/workspaces/{workspace}/transfer": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
 		"PUT:/api/v2/workspaces/{workspace}/autostart": {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: workspaceRBACObj,
@@ -101,6 +170,26 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: workspaceRBACObj,
 		},
+		"PUT:/api/v2/workspaces/{workspace}/autoupdates": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"PUT:/api/v2/workspaces/{workspace}/dormant": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaces/{workspace}/schedule": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"PUT:/api/v2/workspaces/{workspace}/schedule": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaces/{workspace}/schedule/upcoming": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"PATCH:/api/v2/workspacebuilds/{workspacebuild}/cancel": {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: workspaceRBACObj,
@@ -125,6 +214,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
 		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/derp-map": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"GET:/api/v2/organizations/{organization}/templates": {
 			StatusCode:   http.StatusOK,
 			AssertAction: rbac.ActionRead,
@@ -242,6 +335,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"PUT:/api/v2/organizations/{organization}/members/{user}/roles": {NoAuthorize: true},
 		"POST:/api/v2/workspaces/{workspace}/builds":                    {StatusCode: http.StatusBadRequest, NoAuthorize: true},
 		"POST:/api/v2/organizations/{organization}/templateversions":    {StatusCode: http.StatusBadRequest, NoAuthorize: true},
+		"POST:/api/v2/workspaces/batch":                                 {StatusCode: http.StatusBadRequest, NoAuthorize: true},
+
+		// Batches are only visible to the users that started them.
+		"GET:/api/v2/workspaces/batch/{batch}": {StatusCode: http.StatusBadRequest, NoAuthorize: true},
 
 		// Endpoints that use the SQLQuery filter.
 		"GET:/api/v2/workspaces/": {StatusCode: http.StatusOK, NoAuthorize: true},
@@ -379,6 +476,7 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		"{jobID}":               templateVersionDryRun.ID.String(),
 		"{templatename}":        template.Name,
 		"{workspace_and_agent}": workspace.Name + "." + workspace.LatestBuild.Resources[0].Agents[0].Name,
+		"{keyid}":               strings.Split(client.SessionToken(), "-")[0],
 		// Only checking template scoped params here
 		"parameters/{scope}/{id}": fmt.Sprintf("parameters/%s/%s",
 			string(templateParam.Scope), templateParam.ScopeID.String()),
@@ -62,6 +62,9 @@ import (
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ldapauth"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/util/ptr"
@@ -85,6 +88,10 @@ type Options struct {
 	GithubOAuth2Config   *coderd.GithubOAuth2Config
 	RealIPConfig         *httpmw.RealIPConfig
 	OIDCConfig           *coderd.OIDCConfig
+	LDAPConfig           *ldapauth.Config
+	TwoFactorRequired    bool
+	Mailer               mailer.Mailer
+	MaxTokenLifetime     time.Duration
 	GoogleTokenValidator *idtoken.Validator
 	SSHKeygenAlgorithm   gitsshkey.Algorithm
 	APIRateLimit         int
@@ -94,6 +101,10 @@ type Options struct {
 	Auditor              audit.Auditor
 	TLSCertificates      []tls.Certificate
 	GitAuthConfigs       []*gitauth.Config
+	DERPBlockDirect      bool
+
+	// NotificationWebhookURL receives the notifications sent to users.
+	NotificationWebhookURL *url.URL
 
 	// IncludeProvisionerDaemon when true means to start an in-memory provisionerD
 	IncludeProvisionerDaemon    bool
@@ -101,6 +112,8 @@ type Options struct {
 	AgentStatsRefreshInterval   time.Duration
 	DeploymentConfig            *codersdk.DeploymentConfig
 
+	WorkspaceBatchHeartbeatInterval time.Duration
+
 	// Overriding the database is heavily discouraged.
 	// It should only be used in cases where multiple Coder
 	// test instances are running against the same database.
@@ -167,13 +180,23 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 		options.DeploymentConfig = DeploymentConfig(t)
 	}
 
+	notifier := notifications.New(notifications.Options{
+		Database:   options.Database,
+		Logger:     slogtest.Make(t, nil).Named("notifications").Leveled(slog.LevelDebug),
+		Mailer:     options.Mailer,
+		WebhookURL: options.NotificationWebhookURL,
+	})
+	t.Cleanup(func() {
+		notifier.Close(context.Background())
+	})
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	lifecycleExecutor := executor.New(
 		ctx,
 		options.Database,
 		slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
 		options.AutobuildTicker,
-	).WithStatsChannel(options.AutobuildStats)
+	).WithStatsChannel(options.AutobuildStats).WithNotifier(notifier)
 	lifecycleExecutor.Run()
 
 	var mutex sync.RWMutex
@@ -251,6 +274,11 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			GithubOAuth2Config:   options.GithubOAuth2Config,
 			RealIPConfig:         options.RealIPConfig,
 			OIDCConfig:           options.OIDCConfig,
+			LDAPConfig:           options.LDAPConfig,
+			TwoFactorRequired:    options.TwoFactorRequired,
+			Mailer:               options.Mailer,
+			Notifier:             notifier,
+			MaxTokenLifetime:     options.MaxTokenLifetime,
 			GoogleTokenValidator: options.GoogleTokenValidator,
 			SSHKeygenAlgorithm:   options.SSHKeygenAlgorithm,
 			DERPServer:           derpServer,
@@ -281,6 +309,9 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			MetricsCacheRefreshInterval: options.MetricsCacheRefreshInterval,
 			AgentStatsRefreshInterval:   options.AgentStatsRefreshInterval,
 			DeploymentConfig:            options.DeploymentConfig,
+			DERPBlockDirect:             options.DERPBlockDirect,
+
+			WorkspaceBatchHeartbeatInterval: options.WorkspaceBatchHeartbeatInterval,
 		}
 }
 
@@ -86,6 +86,9 @@ type data struct {
 	organizationMembers []database.OrganizationMember
 	users               []database.User
 	userLinks           []database.UserLink
+	userTwoFactors      []database.UserTwoFactor
+	userInvitations     []database.UserInvitation
+	userPasswordResets  []database.UserPasswordReset
 
 	// New tables
 	agentStats                     []database.AgentStat
@@ -106,10 +109,24 @@ type data struct {
 	templateVersions               []database.TemplateVersion
 	templates                      []database.Template
 	workspaceBuilds                []database.WorkspaceBuild
+	workspaceBuildParameters       []database.WorkspaceBuildParameter
+	workspaceBuildRecoveries       []database.WorkspaceBuildRecovery
 	workspaceApps                  []database.WorkspaceApp
 	workspaces                     []database.Workspace
 	licenses                       []database.License
 	replicas                       []database.Replica
+	notifications                  []database.Notification
+	notificationPreferences        []database.UserNotificationPreference
+	workspaceScheduleWindows       []database.WorkspaceScheduleWindow
+	workspaceScheduleSkipDates     []database.WorkspaceScheduleSkipDate
+	workspaceTransfers             []database.WorkspaceTransfer
+	tailnetCoordinators            []database.TailnetCoordinator
+	tailnetAgents                  []database.TailnetAgent
+	tailnetClients                 []database.TailnetClient
+	derpRegions                    []database.DERPRegion
+	derpNodes                      []database.DERPNode
+	workspaceBatches               []database.WorkspaceBatch
+	workspaceBatchItems            []database.WorkspaceBatchItem
 
 	deploymentID  string
 	derpMeshKey   string
@@ -343,6 +360,61 @@ func (q *fakeQuerier) GetAPIKeysLastUsedAfter(_ context.Context, after time.Time
 	return apiKeys, nil
 }
 
+func (q *fakeQuerier) GetSessionsByUserID(_ context.Context, userID uuid.UUID) ([]database.APIKey, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	sessions := make([]database.APIKey, 0)
+	for _, key := range q.apiKeys {
+		if key.UserID != userID || key.LoginType == database.LoginTypeToken || !key.ExpiresAt.After(database.Now()) {
+			continue
+		}
+		sessions = append(sessions, key)
+	}
+	slices.SortFunc(sessions, func(a, b database.APIKey) bool {
+		return a.LastUsed.After(b.LastUsed)
+	})
+	return sessions, nil
+}
+
+func (q *fakeQuerier) GetAPIKeys(_ context.Context, arg database.GetAPIKeysParams) ([]database.APIKey, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	apiKeys := make([]database.APIKey, 0)
+	for _, key := range q.apiKeys {
+		if arg.UserID != uuid.Nil && key.UserID != arg.UserID {
+			continue
+		}
+		if len(arg.LoginType) > 0 && !slices.Contains(arg.LoginType, key.LoginType) {
+			continue
+		}
+		if len(arg.Scope) > 0 && !slices.Contains(arg.Scope, key.Scope) {
+			continue
+		}
+		if !arg.LastUsedBefore.IsZero() && !key.LastUsed.Before(arg.LastUsedBefore) {
+			continue
+		}
+		if !arg.LastUsedAfter.IsZero() && !key.LastUsed.After(arg.LastUsedAfter) {
+			continue
+		}
+		if !arg.ExpiresBefore.IsZero() && !key.ExpiresAt.Before(arg.ExpiresBefore) {
+			continue
+		}
+		if !arg.ExpiresAfter.IsZero() && !key.ExpiresAt.After(arg.ExpiresAfter) {
+			continue
+		}
+		apiKeys = append(apiKeys, key)
+	}
+	slices.SortFunc(apiKeys, func(a, b database.APIKey) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return apiKeys, nil
+}
+
 func (q *fakeQuerier) GetAPIKeysByLoginType(_ context.Context, t database.LoginType) ([]database.APIKey, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -384,6 +456,20 @@ func (q *fakeQuerier) DeleteAPIKeysByUserID(_ context.Context, userID uuid.UUID)
 	return nil
 }
 
+func (q *fakeQuerier) DeleteSessionsByUserID(_ context.Context, arg database.DeleteSessionsByUserIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i := len(q.apiKeys) - 1; i >= 0; i-- {
+		key := q.apiKeys[i]
+		if key.UserID == arg.UserID && key.LoginType != database.LoginTypeToken && key.ID != arg.ExceptID {
+			q.apiKeys = append(q.apiKeys[:i], q.apiKeys[i+1:]...)
+		}
+	}
+
+	return nil
+}
+
 func (q *fakeQuerier) GetFileByHashAndCreator(_ context.Context, arg database.GetFileByHashAndCreatorParams) (database.File, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -732,6 +818,10 @@ func (q *fakeQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg database.
 			continue
 		}
 
+		if arg.Dormant && !workspace.DormantAt.Valid {
+			continue
+		}
+
 		if arg.Status != "" {
 			build, err := q.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
 			if err != nil {
@@ -1035,6 +1125,24 @@ func (q *fakeQuerier) GetLatestWorkspaceBuildByWorkspaceID(_ context.Context, wo
 	return row, nil
 }
 
+func (q *fakeQuerier) GetLatestWorkspaceBuildByWorkspaceIDAndReason(_ context.Context, arg database.GetLatestWorkspaceBuildByWorkspaceIDAndReasonParams) (database.WorkspaceBuild, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var row database.WorkspaceBuild
+	var buildNum int32 = -1
+	for _, workspaceBuild := range q.workspaceBuilds {
+		if workspaceBuild.WorkspaceID == arg.WorkspaceID && workspaceBuild.Reason == arg.Reason && workspaceBuild.BuildNumber > buildNum {
+			row = workspaceBuild
+			buildNum = workspaceBuild.BuildNumber
+		}
+	}
+	if buildNum == -1 {
+		return database.WorkspaceBuild{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
 func (q *fakeQuerier) GetLatestWorkspaceBuilds(_ context.Context) ([]database.WorkspaceBuild, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1163,6 +1271,35 @@ func (q *fakeQuerier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(_ context.Con
 	return database.WorkspaceBuild{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetWorkspaceBuildParameters(_ context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	params := make([]database.WorkspaceBuildParameter, 0)
+	for _, param := range q.workspaceBuildParameters {
+		if param.WorkspaceBuildID != workspaceBuildID {
+			continue
+		}
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceBuildRecoveryByBuildID(_ context.Context, workspaceBuildID uuid.UUID) (database.WorkspaceBuildRecovery, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recovery := range q.workspaceBuildRecoveries {
+		if recovery.WorkspaceBuildID == workspaceBuildID {
+			return recovery, nil
+		}
+	}
+	return database.WorkspaceBuildRecovery{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) GetWorkspaceBuildsCreatedAfter(_ context.Context, after time.Time) ([]database.WorkspaceBuild, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1307,6 +1444,19 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.Description = arg.Description
 		tpl.Icon = arg.Icon
 		tpl.DefaultTtl = arg.DefaultTtl
+		tpl.MaxTtl = arg.MaxTtl
+		tpl.MinAutostartInterval = arg.MinAutostartInterval
+		tpl.QuietHoursSchedule = arg.QuietHoursSchedule
+		tpl.AllowUserAutostop = arg.AllowUserAutostop
+		tpl.InactivityTtl = arg.InactivityTtl
+		tpl.DormancyDeletionTtl = arg.DormancyDeletionTtl
+		tpl.ActivityBump = arg.ActivityBump
+		tpl.ActivityBumpThreshold = arg.ActivityBumpThreshold
+		tpl.RequireActiveVersion = arg.RequireActiveVersion
+		tpl.BuildRetries = arg.BuildRetries
+		tpl.BuildRetryBackoff = arg.BuildRetryBackoff
+		tpl.RollbackFailedUpdates = arg.RollbackFailedUpdates
+		tpl.AllowWorkspaceNetworking = arg.AllowWorkspaceNetworking
 		q.templates[idx] = tpl
 		return tpl, nil
 	}
@@ -2001,6 +2151,7 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 		LastUsed:        arg.LastUsed,
 		LoginType:       arg.LoginType,
 		Scope:           arg.Scope,
+		UserAgent:       arg.UserAgent,
 	}
 	q.apiKeys = append(q.apiKeys, key)
 	return key, nil
@@ -2079,18 +2230,31 @@ func (q *fakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 
 	//nolint:gosimple
 	template := database.Template{
-		ID:              arg.ID,
-		CreatedAt:       arg.CreatedAt,
-		UpdatedAt:       arg.UpdatedAt,
-		OrganizationID:  arg.OrganizationID,
-		Name:            arg.Name,
-		Provisioner:     arg.Provisioner,
-		ActiveVersionID: arg.ActiveVersionID,
-		Description:     arg.Description,
-		DefaultTtl:      arg.DefaultTtl,
-		CreatedBy:       arg.CreatedBy,
-		UserACL:         arg.UserACL,
-		GroupACL:        arg.GroupACL,
+		ID:                       arg.ID,
+		CreatedAt:                arg.CreatedAt,
+		UpdatedAt:                arg.UpdatedAt,
+		OrganizationID:           arg.OrganizationID,
+		Name:                     arg.Name,
+		Provisioner:              arg.Provisioner,
+		ActiveVersionID:          arg.ActiveVersionID,
+		Description:              arg.Description,
+		DefaultTtl:               arg.DefaultTtl,
+		CreatedBy:                arg.CreatedBy,
+		UserACL:                  arg.UserACL,
+		GroupACL:                 arg.GroupACL,
+		MaxTtl:                   arg.MaxTtl,
+		MinAutostartInterval:     arg.MinAutostartInterval,
+		QuietHoursSchedule:       arg.QuietHoursSchedule,
+		AllowUserAutostop:        arg.AllowUserAutostop,
+		InactivityTtl:            arg.InactivityTtl,
+		DormancyDeletionTtl:      arg.DormancyDeletionTtl,
+		ActivityBump:             arg.ActivityBump,
+		ActivityBumpThreshold:    arg.ActivityBumpThreshold,
+		RequireActiveVersion:     arg.RequireActiveVersion,
+		BuildRetries:             arg.BuildRetries,
+		BuildRetryBackoff:        arg.BuildRetryBackoff,
+		RollbackFailedUpdates:    arg.RollbackFailedUpdates,
+		AllowWorkspaceNetworking: arg.AllowWorkspaceNetworking,
 	}
 	q.templates = append(q.templates, template)
 	return template, nil
@@ -2425,6 +2589,44 @@ func (q *fakeQuerier) InsertWorkspaceBuild(_ context.Context, arg database.Inser
 	return workspaceBuild, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceBuildParameters(_ context.Context, arg database.InsertWorkspaceBuildParametersParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, value := range q.parameterValues {
+		if value.Scope != database.ParameterScopeWorkspace || value.ScopeID != arg.WorkspaceID {
+			continue
+		}
+		q.workspaceBuildParameters = append(q.workspaceBuildParameters, database.WorkspaceBuildParameter{
+			WorkspaceBuildID:  arg.WorkspaceBuildID,
+			Name:              value.Name,
+			SourceScheme:      value.SourceScheme,
+			SourceValue:       value.SourceValue,
+			DestinationScheme: value.DestinationScheme,
+		})
+	}
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceBuildRecovery(_ context.Context, arg database.InsertWorkspaceBuildRecoveryParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, recovery := range q.workspaceBuildRecoveries {
+		if recovery.WorkspaceBuildID == arg.WorkspaceBuildID {
+			return errDuplicateKey
+		}
+	}
+	q.workspaceBuildRecoveries = append(q.workspaceBuildRecoveries, database.WorkspaceBuildRecovery{
+		WorkspaceBuildID:  arg.WorkspaceBuildID,
+		Reason:            arg.Reason,
+		TemplateVersionID: arg.TemplateVersionID,
+		TargetBuildID:     arg.TargetBuildID,
+		RecoverAt:         arg.RecoverAt,
+	})
+	return nil
+}
+
 func (q *fakeQuerier) InsertWorkspaceApp(_ context.Context, arg database.InsertWorkspaceAppParams) (database.WorkspaceApp, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2480,6 +2682,7 @@ func (q *fakeQuerier) UpdateAPIKeyByID(_ context.Context, arg database.UpdateAPI
 		apiKey.LastUsed = arg.LastUsed
 		apiKey.ExpiresAt = arg.ExpiresAt
 		apiKey.IPAddress = arg.IPAddress
+		apiKey.UserAgent = arg.UserAgent
 		q.apiKeys[index] = apiKey
 		return nil
 	}
@@ -2601,6 +2804,32 @@ func (q *fakeQuerier) UpdateWorkspaceAgentConnectionByID(_ context.Context, arg
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) RegenerateWorkspaceAgentAuthTokensByWorkspaceID(_ context.Context, workspaceID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobIDs := map[uuid.UUID]struct{}{}
+	for _, build := range q.workspaceBuilds {
+		if build.WorkspaceID == workspaceID {
+			jobIDs[build.JobID] = struct{}{}
+		}
+	}
+	resourceIDs := map[uuid.UUID]struct{}{}
+	for _, resource := range q.provisionerJobResources {
+		if _, ok := jobIDs[resource.JobID]; ok {
+			resourceIDs[resource.ID] = struct{}{}
+		}
+	}
+	for index, agent := range q.provisionerJobAgents {
+		if _, ok := resourceIDs[agent.ResourceID]; !ok {
+			continue
+		}
+		agent.AuthToken = uuid.New()
+		q.provisionerJobAgents[index] = agent
+	}
+	return nil
+}
+
 func (q *fakeQuerier) UpdateWorkspaceAgentVersionByID(_ context.Context, arg database.UpdateWorkspaceAgentVersionByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2691,6 +2920,22 @@ func (q *fakeQuerier) UpdateWorkspace(_ context.Context, arg database.UpdateWork
 	return database.Workspace{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceAutomaticUpdates(_ context.Context, arg database.UpdateWorkspaceAutomaticUpdatesParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.AutomaticUpdates = arg.AutomaticUpdates
+		q.workspaces[index] = workspace
+		return nil
+	}
+
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateWorkspaceAutostart(_ context.Context, arg database.UpdateWorkspaceAutostartParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2707,6 +2952,22 @@ func (q *fakeQuerier) UpdateWorkspaceAutostart(_ context.Context, arg database.U
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceOwner(_ context.Context, arg database.UpdateWorkspaceOwnerParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.OwnerID = arg.OwnerID
+		q.workspaces[index] = workspace
+		return nil
+	}
+
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateWorkspaceTTL(_ context.Context, arg database.UpdateWorkspaceTTLParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2723,6 +2984,22 @@ func (q *fakeQuerier) UpdateWorkspaceTTL(_ context.Context, arg database.UpdateW
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceDormantAt(_ context.Context, arg database.UpdateWorkspaceDormantAtParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.DormantAt = arg.DormantAt
+		q.workspaces[index] = workspace
+		return nil
+	}
+
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateWorkspaceLastUsedAt(_ context.Context, arg database.UpdateWorkspaceLastUsedAtParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2848,6 +3125,19 @@ func (q *fakeQuerier) DeleteGroupMember(_ context.Context, userID uuid.UUID) err
 	return nil
 }
 
+func (q *fakeQuerier) DeleteGroupMemberFromGroup(_ context.Context, arg database.DeleteGroupMemberFromGroupParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.groupMembers {
+		if member.UserID == arg.UserID && member.GroupID == arg.GroupID {
+			q.groupMembers = append(q.groupMembers[:i], q.groupMembers[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *fakeQuerier) UpdateGroupByID(_ context.Context, arg database.UpdateGroupByIDParams) (database.Group, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -3176,81 +3466,331 @@ func (q *fakeQuerier) UpdateUserLink(_ context.Context, params database.UpdateUs
 	return database.UserLink{}, sql.ErrNoRows
 }
 
-func (q *fakeQuerier) GetGroupByID(_ context.Context, id uuid.UUID) (database.Group, error) {
+func (q *fakeQuerier) GetUserTwoFactorByUserID(_ context.Context, userID uuid.UUID) (database.UserTwoFactor, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
-	for _, group := range q.groups {
-		if group.ID == id {
-			return group, nil
+	for _, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID == userID {
+			return twoFactor, nil
 		}
 	}
-
-	return database.Group{}, sql.ErrNoRows
+	return database.UserTwoFactor{}, sql.ErrNoRows
 }
 
-func (q *fakeQuerier) GetGroupByOrgAndName(_ context.Context, arg database.GetGroupByOrgAndNameParams) (database.Group, error) {
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+func (q *fakeQuerier) InsertUserTwoFactor(_ context.Context, arg database.InsertUserTwoFactorParams) (database.UserTwoFactor, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 
-	for _, group := range q.groups {
-		if group.OrganizationID == arg.OrganizationID &&
-			group.Name == arg.Name {
-			return group, nil
+	for _, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID == arg.UserID {
+			return database.UserTwoFactor{}, errDuplicateKey
 		}
 	}
 
-	return database.Group{}, sql.ErrNoRows
-}
-
-func (q *fakeQuerier) InsertAllUsersGroup(ctx context.Context, orgID uuid.UUID) (database.Group, error) {
-	return q.InsertGroup(ctx, database.InsertGroupParams{
-		ID:             orgID,
-		Name:           database.AllUsersGroup,
-		OrganizationID: orgID,
-	})
+	twoFactor := database.UserTwoFactor{
+		UserID:              arg.UserID,
+		CreatedAt:           arg.CreatedAt,
+		UpdatedAt:           arg.UpdatedAt,
+		TOTPSecret:          arg.TOTPSecret,
+		HashedRecoveryCodes: append([]string{}, arg.HashedRecoveryCodes...),
+	}
+	q.userTwoFactors = append(q.userTwoFactors, twoFactor)
+	return twoFactor, nil
 }
 
-func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupParams) (database.Group, error) {
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+func (q *fakeQuerier) UpdateUserTwoFactorEnabled(_ context.Context, arg database.UpdateUserTwoFactorEnabledParams) (database.UserTwoFactor, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 
-	for _, group := range q.groups {
-		if group.OrganizationID == arg.OrganizationID &&
-			group.Name == arg.Name {
-			return database.Group{}, errDuplicateKey
+	for i, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID != arg.UserID {
+			continue
 		}
+		twoFactor.Enabled = arg.Enabled
+		twoFactor.UpdatedAt = arg.UpdatedAt
+		q.userTwoFactors[i] = twoFactor
+		return twoFactor, nil
 	}
+	return database.UserTwoFactor{}, sql.ErrNoRows
+}
 
-	//nolint:gosimple
-	group := database.Group{
-		ID:             arg.ID,
-		Name:           arg.Name,
-		OrganizationID: arg.OrganizationID,
-		AvatarURL:      arg.AvatarURL,
+func (q *fakeQuerier) UpdateUserTwoFactorLastTOTPStep(_ context.Context, arg database.UpdateUserTwoFactorLastTOTPStepParams) (database.UserTwoFactor, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID != arg.UserID || twoFactor.LastTOTPStep >= arg.LastTOTPStep {
+			continue
+		}
+		twoFactor.LastTOTPStep = arg.LastTOTPStep
+		twoFactor.UpdatedAt = arg.UpdatedAt
+		q.userTwoFactors[i] = twoFactor
+		return twoFactor, nil
 	}
+	return database.UserTwoFactor{}, sql.ErrNoRows
+}
 
-	q.groups = append(q.groups, group)
+func (q *fakeQuerier) ConsumeUserTwoFactorRecoveryCode(_ context.Context, arg database.ConsumeUserTwoFactorRecoveryCodeParams) (database.UserTwoFactor, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 
-	return group, nil
+	for i, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID != arg.UserID {
+			continue
+		}
+		index := slices.Index(twoFactor.HashedRecoveryCodes, arg.Code)
+		if index == -1 {
+			continue
+		}
+		twoFactor.HashedRecoveryCodes = slices.Delete(slices.Clone(twoFactor.HashedRecoveryCodes), index, index+1)
+		twoFactor.UpdatedAt = arg.UpdatedAt
+		q.userTwoFactors[i] = twoFactor
+		return twoFactor, nil
+	}
+	return database.UserTwoFactor{}, sql.ErrNoRows
 }
 
-func (*fakeQuerier) GetUserGroups(_ context.Context, _ uuid.UUID) ([]database.Group, error) {
-	panic("not implemented")
+func (q *fakeQuerier) DeleteUserTwoFactorByUserID(_ context.Context, userID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, twoFactor := range q.userTwoFactors {
+		if twoFactor.UserID != userID {
+			continue
+		}
+		q.userTwoFactors = append(q.userTwoFactors[:i], q.userTwoFactors[i+1:]...)
+		return nil
+	}
+	return nil
 }
 
-func (q *fakeQuerier) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]database.User, error) {
+func (q *fakeQuerier) GetUserInvitationByID(_ context.Context, id string) (database.UserInvitation, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
-	var members []database.GroupMember
-	for _, member := range q.groupMembers {
-		if member.GroupID == groupID {
-			members = append(members, member)
+	for _, invitation := range q.userInvitations {
+		if invitation.ID == id {
+			return invitation, nil
 		}
 	}
+	return database.UserInvitation{}, sql.ErrNoRows
+}
 
-	users := make([]database.User, 0, len(members))
+func (q *fakeQuerier) GetUserInvitations(_ context.Context) ([]database.UserInvitation, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	invitations := make([]database.UserInvitation, len(q.userInvitations))
+	copy(invitations, q.userInvitations)
+	sort.SliceStable(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+	})
+	return invitations, nil
+}
+
+func (q *fakeQuerier) InsertUserInvitation(_ context.Context, arg database.InsertUserInvitationParams) (database.UserInvitation, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, invitation := range q.userInvitations {
+		if invitation.ID == arg.ID {
+			return database.UserInvitation{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	invitation := database.UserInvitation{
+		ID:             arg.ID,
+		HashedSecret:   arg.HashedSecret,
+		Email:          arg.Email,
+		OrganizationID: arg.OrganizationID,
+		CreatedBy:      arg.CreatedBy,
+		CreatedAt:      arg.CreatedAt,
+		ExpiresAt:      arg.ExpiresAt,
+	}
+	q.userInvitations = append(q.userInvitations, invitation)
+	return invitation, nil
+}
+
+func (q *fakeQuerier) ConsumeUserInvitationByID(_ context.Context, id string) (database.UserInvitation, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, invitation := range q.userInvitations {
+		if invitation.ID != id {
+			continue
+		}
+		q.userInvitations = append(q.userInvitations[:i], q.userInvitations[i+1:]...)
+		return invitation, nil
+	}
+	return database.UserInvitation{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteUserInvitationByID(_ context.Context, id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, invitation := range q.userInvitations {
+		if invitation.ID != id {
+			continue
+		}
+		q.userInvitations = append(q.userInvitations[:i], q.userInvitations[i+1:]...)
+		return nil
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetUserPasswordResetByID(_ context.Context, id string) (database.UserPasswordReset, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, reset := range q.userPasswordResets {
+		if reset.ID == id {
+			return reset, nil
+		}
+	}
+	return database.UserPasswordReset{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertUserPasswordReset(_ context.Context, arg database.InsertUserPasswordResetParams) (database.UserPasswordReset, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, reset := range q.userPasswordResets {
+		if reset.ID == arg.ID {
+			return database.UserPasswordReset{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	reset := database.UserPasswordReset{
+		ID:           arg.ID,
+		HashedSecret: arg.HashedSecret,
+		UserID:       arg.UserID,
+		CreatedAt:    arg.CreatedAt,
+		ExpiresAt:    arg.ExpiresAt,
+	}
+	q.userPasswordResets = append(q.userPasswordResets, reset)
+	return reset, nil
+}
+
+func (q *fakeQuerier) ConsumeUserPasswordResetByID(_ context.Context, id string) (database.UserPasswordReset, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, reset := range q.userPasswordResets {
+		if reset.ID != id {
+			continue
+		}
+		q.userPasswordResets = append(q.userPasswordResets[:i], q.userPasswordResets[i+1:]...)
+		return reset, nil
+	}
+	return database.UserPasswordReset{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteUserPasswordResetsByUserID(_ context.Context, userID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i := len(q.userPasswordResets) - 1; i >= 0; i-- {
+		if q.userPasswordResets[i].UserID == userID {
+			q.userPasswordResets = append(q.userPasswordResets[:i], q.userPasswordResets[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetGroupByID(_ context.Context, id uuid.UUID) (database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, group := range q.groups {
+		if group.ID == id {
+			return group, nil
+		}
+	}
+
+	return database.Group{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetGroupByOrgAndName(_ context.Context, arg database.GetGroupByOrgAndNameParams) (database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID &&
+			group.Name == arg.Name {
+			return group, nil
+		}
+	}
+
+	return database.Group{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertAllUsersGroup(ctx context.Context, orgID uuid.UUID) (database.Group, error) {
+	return q.InsertGroup(ctx, database.InsertGroupParams{
+		ID:             orgID,
+		Name:           database.AllUsersGroup,
+		OrganizationID: orgID,
+	})
+}
+
+func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupParams) (database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID &&
+			group.Name == arg.Name {
+			return database.Group{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	group := database.Group{
+		ID:             arg.ID,
+		Name:           arg.Name,
+		OrganizationID: arg.OrganizationID,
+		AvatarURL:      arg.AvatarURL,
+	}
+
+	q.groups = append(q.groups, group)
+
+	return group, nil
+}
+
+func (q *fakeQuerier) GetUserGroups(_ context.Context, userID uuid.UUID) ([]database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var groups []database.Group
+	for _, member := range q.groupMembers {
+		if member.UserID != userID {
+			continue
+		}
+		for _, group := range q.groups {
+			if group.ID == member.GroupID {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+	return groups, nil
+}
+
+func (q *fakeQuerier) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]database.User, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var members []database.GroupMember
+	for _, member := range q.groupMembers {
+		if member.GroupID == groupID {
+			members = append(members, member)
+		}
+	}
+
+	users := make([]database.User, 0, len(members))
 
 	for _, member := range members {
 		for _, user := range q.users {
@@ -3428,3 +3968,829 @@ func (q *fakeQuerier) UpdateGitAuthLink(_ context.Context, arg database.UpdateGi
 	}
 	return nil
 }
+
+func (q *fakeQuerier) GetNotificationsByUserID(_ context.Context, arg database.GetNotificationsByUserIDParams) ([]database.Notification, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	notifications := make([]database.Notification, 0)
+	for _, notification := range q.notifications {
+		if notification.UserID != arg.UserID {
+			continue
+		}
+		if arg.Unread && notification.ReadAt.Valid {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+	slices.SortFunc(notifications, func(a, b database.Notification) bool {
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+	if arg.LimitOpt > 0 && len(notifications) > int(arg.LimitOpt) {
+		notifications = notifications[:arg.LimitOpt]
+	}
+	return notifications, nil
+}
+
+func (q *fakeQuerier) GetUserNotificationPreferences(_ context.Context, userID uuid.UUID) ([]database.UserNotificationPreference, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	preferences := make([]database.UserNotificationPreference, 0)
+	for _, preference := range q.notificationPreferences {
+		if preference.UserID == userID {
+			preferences = append(preferences, preference)
+		}
+	}
+	return preferences, nil
+}
+
+func (q *fakeQuerier) InsertNotification(_ context.Context, arg database.InsertNotificationParams) (database.Notification, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	notification := database.Notification{
+		ID:          arg.ID,
+		UserID:      arg.UserID,
+		WorkspaceID: arg.WorkspaceID,
+		Kind:        arg.Kind,
+		Title:       arg.Title,
+		Body:        arg.Body,
+		CreatedAt:   arg.CreatedAt,
+	}
+	q.notifications = append(q.notifications, notification)
+	return notification, nil
+}
+
+func (q *fakeQuerier) UpdateNotificationsReadAtByUserID(_ context.Context, arg database.UpdateNotificationsReadAtByUserIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, notification := range q.notifications {
+		if notification.UserID != arg.UserID || notification.ReadAt.Valid {
+			continue
+		}
+		if len(arg.IDs) > 0 && !slices.Contains(arg.IDs, notification.ID) {
+			continue
+		}
+		notification.ReadAt = arg.ReadAt
+		q.notifications[i] = notification
+	}
+	return nil
+}
+
+func (q *fakeQuerier) UpsertUserNotificationPreference(_ context.Context, arg database.UpsertUserNotificationPreferenceParams) (database.UserNotificationPreference, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	preference := database.UserNotificationPreference{
+		UserID:  arg.UserID,
+		Kind:    arg.Kind,
+		Inbox:   arg.Inbox,
+		Email:   arg.Email,
+		Webhook: arg.Webhook,
+	}
+	for i, existing := range q.notificationPreferences {
+		if existing.UserID == arg.UserID && existing.Kind == arg.Kind {
+			q.notificationPreferences[i] = preference
+			return preference, nil
+		}
+	}
+	q.notificationPreferences = append(q.notificationPreferences, preference)
+	return preference, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceScheduleWindowsByWorkspaceIDs(_ context.Context, ids []uuid.UUID) ([]database.WorkspaceScheduleWindow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	windows := make([]database.WorkspaceScheduleWindow, 0)
+	for _, window := range q.workspaceScheduleWindows {
+		for _, id := range ids {
+			if window.WorkspaceID == id {
+				windows = append(windows, window)
+				break
+			}
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].CreatedAt.Equal(windows[j].CreatedAt) {
+			return windows[i].ID.String() < windows[j].ID.String()
+		}
+		return windows[i].CreatedAt.Before(windows[j].CreatedAt)
+	})
+	return windows, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceScheduleWindow(_ context.Context, arg database.InsertWorkspaceScheduleWindowParams) (database.WorkspaceScheduleWindow, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	window := database.WorkspaceScheduleWindow{
+		ID:            arg.ID,
+		WorkspaceID:   arg.WorkspaceID,
+		StartSchedule: arg.StartSchedule,
+		StopSchedule:  arg.StopSchedule,
+		CreatedAt:     arg.CreatedAt,
+	}
+	q.workspaceScheduleWindows = append(q.workspaceScheduleWindows, window)
+	return window, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceScheduleWindowsByWorkspaceID(_ context.Context, workspaceID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	windows := make([]database.WorkspaceScheduleWindow, 0, len(q.workspaceScheduleWindows))
+	for _, window := range q.workspaceScheduleWindows {
+		if window.WorkspaceID != workspaceID {
+			windows = append(windows, window)
+		}
+	}
+	q.workspaceScheduleWindows = windows
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceScheduleSkipDatesByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.WorkspaceScheduleSkipDate, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	skipDates := make([]database.WorkspaceScheduleSkipDate, 0)
+	for _, skipDate := range q.workspaceScheduleSkipDates {
+		if skipDate.WorkspaceID == workspaceID {
+			skipDates = append(skipDates, skipDate)
+		}
+	}
+	sort.Slice(skipDates, func(i, j int) bool {
+		return skipDates[i].Date.Before(skipDates[j].Date)
+	})
+	return skipDates, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceScheduleSkipDate(_ context.Context, arg database.InsertWorkspaceScheduleSkipDateParams) (database.WorkspaceScheduleSkipDate, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	// Postgres drops the time of day from dates.
+	year, month, day := arg.Date.Date()
+	skipDate := database.WorkspaceScheduleSkipDate{
+		WorkspaceID: arg.WorkspaceID,
+		Date:        time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+	}
+	for _, existing := range q.workspaceScheduleSkipDates {
+		if existing.WorkspaceID == skipDate.WorkspaceID && existing.Date.Equal(skipDate.Date) {
+			return database.WorkspaceScheduleSkipDate{}, errDuplicateKey
+		}
+	}
+	q.workspaceScheduleSkipDates = append(q.workspaceScheduleSkipDates, skipDate)
+	return skipDate, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceScheduleSkipDatesByWorkspaceID(_ context.Context, workspaceID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	skipDates := make([]database.WorkspaceScheduleSkipDate, 0, len(q.workspaceScheduleSkipDates))
+	for _, skipDate := range q.workspaceScheduleSkipDates {
+		if skipDate.WorkspaceID != workspaceID {
+			skipDates = append(skipDates, skipDate)
+		}
+	}
+	q.workspaceScheduleSkipDates = skipDates
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceTransferByWorkspaceID(_ context.Context, workspaceID uuid.UUID) (database.WorkspaceTransfer, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, transfer := range q.workspaceTransfers {
+		if transfer.WorkspaceID == workspaceID {
+			return transfer, nil
+		}
+	}
+	return database.WorkspaceTransfer{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceTransfersByToUserID(_ context.Context, toUserID uuid.UUID) ([]database.WorkspaceTransfer, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	transfers := make([]database.WorkspaceTransfer, 0)
+	for _, transfer := range q.workspaceTransfers {
+		if transfer.ToUserID == toUserID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].CreatedAt.Before(transfers[j].CreatedAt)
+	})
+	return transfers, nil
+}
+
+func (q *fakeQuerier) UpsertWorkspaceTransfer(_ context.Context, arg database.UpsertWorkspaceTransferParams) (database.WorkspaceTransfer, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	transfer := database.WorkspaceTransfer{
+		WorkspaceID: arg.WorkspaceID,
+		FromUserID:  arg.FromUserID,
+		ToUserID:    arg.ToUserID,
+		CreatedAt:   arg.CreatedAt,
+	}
+	for index, existing := range q.workspaceTransfers {
+		if existing.WorkspaceID == arg.WorkspaceID {
+			q.workspaceTransfers[index] = transfer
+			return transfer, nil
+		}
+	}
+	q.workspaceTransfers = append(q.workspaceTransfers, transfer)
+	return transfer, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceTransferByWorkspaceID(_ context.Context, workspaceID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	transfers := make([]database.WorkspaceTransfer, 0, len(q.workspaceTransfers))
+	for _, transfer := range q.workspaceTransfers {
+		if transfer.WorkspaceID != workspaceID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	q.workspaceTransfers = transfers
+	return nil
+}
+
+func (q *fakeQuerier) UpsertTailnetCoordinator(_ context.Context, arg database.UpsertTailnetCoordinatorParams) (database.TailnetCoordinator, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	coordinator := database.TailnetCoordinator{
+		ID:          arg.ID,
+		HeartbeatAt: arg.HeartbeatAt,
+	}
+	for index, existing := range q.tailnetCoordinators {
+		if existing.ID == arg.ID {
+			q.tailnetCoordinators[index] = coordinator
+			return coordinator, nil
+		}
+	}
+	q.tailnetCoordinators = append(q.tailnetCoordinators, coordinator)
+	return coordinator, nil
+}
+
+func (q *fakeQuerier) UpdateTailnetCoordinatorHeartbeat(_ context.Context, arg database.UpdateTailnetCoordinatorHeartbeatParams) (database.TailnetCoordinator, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, coordinator := range q.tailnetCoordinators {
+		if coordinator.ID != arg.ID {
+			continue
+		}
+		coordinator.HeartbeatAt = arg.HeartbeatAt
+		q.tailnetCoordinators[index] = coordinator
+		return coordinator, nil
+	}
+	return database.TailnetCoordinator{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteTailnetCoordinator(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.deleteTailnetCoordinators(func(coordinator database.TailnetCoordinator) bool {
+		return coordinator.ID == id
+	})
+	return nil
+}
+
+func (q *fakeQuerier) DeleteTailnetCoordinatorsHeartbeatBefore(_ context.Context, heartbeatAt time.Time) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.deleteTailnetCoordinators(func(coordinator database.TailnetCoordinator) bool {
+		return coordinator.HeartbeatAt.Before(heartbeatAt)
+	})
+	return nil
+}
+
+// deleteTailnetCoordinators deletes the matching coordinators and, like the
+// foreign keys, their agents and clients. q.mutex must be held.
+func (q *fakeQuerier) deleteTailnetCoordinators(match func(database.TailnetCoordinator) bool) {
+	deleted := map[uuid.UUID]struct{}{}
+	coordinators := make([]database.TailnetCoordinator, 0, len(q.tailnetCoordinators))
+	for _, coordinator := range q.tailnetCoordinators {
+		if match(coordinator) {
+			deleted[coordinator.ID] = struct{}{}
+			continue
+		}
+		coordinators = append(coordinators, coordinator)
+	}
+	q.tailnetCoordinators = coordinators
+
+	agents := make([]database.TailnetAgent, 0, len(q.tailnetAgents))
+	for _, agent := range q.tailnetAgents {
+		if _, ok := deleted[agent.CoordinatorID]; !ok {
+			agents = append(agents, agent)
+		}
+	}
+	q.tailnetAgents = agents
+
+	clients := make([]database.TailnetClient, 0, len(q.tailnetClients))
+	for _, client := range q.tailnetClients {
+		if _, ok := deleted[client.CoordinatorID]; !ok {
+			clients = append(clients, client)
+		}
+	}
+	q.tailnetClients = clients
+}
+
+// tailnetCoordinatorExists emulates the foreign keys of the tailnet peer
+// tables. q.mutex must be held.
+func (q *fakeQuerier) tailnetCoordinatorExists(id uuid.UUID) error {
+	for _, coordinator := range q.tailnetCoordinators {
+		if coordinator.ID == id {
+			return nil
+		}
+	}
+	return xerrors.Errorf("tailnet coordinator %s does not exist", id)
+}
+
+func (q *fakeQuerier) UpsertTailnetAgent(_ context.Context, arg database.UpsertTailnetAgentParams) (database.TailnetAgent, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err := q.tailnetCoordinatorExists(arg.CoordinatorID); err != nil {
+		return database.TailnetAgent{}, err
+	}
+	agent := database.TailnetAgent{
+		ID:            arg.ID,
+		CoordinatorID: arg.CoordinatorID,
+		UpdatedAt:     arg.UpdatedAt,
+		Node:          arg.Node,
+	}
+	for index, existing := range q.tailnetAgents {
+		if existing.ID == arg.ID && existing.CoordinatorID == arg.CoordinatorID {
+			q.tailnetAgents[index] = agent
+			return agent, nil
+		}
+	}
+	q.tailnetAgents = append(q.tailnetAgents, agent)
+	return agent, nil
+}
+
+func (q *fakeQuerier) DeleteTailnetAgent(_ context.Context, arg database.DeleteTailnetAgentParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	agents := make([]database.TailnetAgent, 0, len(q.tailnetAgents))
+	for _, agent := range q.tailnetAgents {
+		if agent.ID == arg.ID && agent.CoordinatorID == arg.CoordinatorID {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	q.tailnetAgents = agents
+	return nil
+}
+
+func (q *fakeQuerier) GetTailnetAgents(_ context.Context, id uuid.UUID) ([]database.TailnetAgent, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	agents := make([]database.TailnetAgent, 0)
+	for _, agent := range q.tailnetAgents {
+		if agent.ID == id {
+			agents = append(agents, agent)
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].UpdatedAt.After(agents[j].UpdatedAt)
+	})
+	return agents, nil
+}
+
+func (q *fakeQuerier) GetTailnetAgentsByIDs(_ context.Context, ids []uuid.UUID) ([]database.TailnetAgent, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	agents := make([]database.TailnetAgent, 0)
+	for _, agent := range q.tailnetAgents {
+		if slices.Contains(ids, agent.ID) {
+			agents = append(agents, agent)
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].UpdatedAt.After(agents[j].UpdatedAt)
+	})
+	return agents, nil
+}
+
+func (q *fakeQuerier) UpsertTailnetClient(_ context.Context, arg database.UpsertTailnetClientParams) (database.TailnetClient, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err := q.tailnetCoordinatorExists(arg.CoordinatorID); err != nil {
+		return database.TailnetClient{}, err
+	}
+	client := database.TailnetClient{
+		ID:            arg.ID,
+		CoordinatorID: arg.CoordinatorID,
+		AgentID:       arg.AgentID,
+		UpdatedAt:     arg.UpdatedAt,
+		Node:          arg.Node,
+	}
+	for index, existing := range q.tailnetClients {
+		if existing.ID == arg.ID && existing.CoordinatorID == arg.CoordinatorID {
+			q.tailnetClients[index] = client
+			return client, nil
+		}
+	}
+	q.tailnetClients = append(q.tailnetClients, client)
+	return client, nil
+}
+
+func (q *fakeQuerier) DeleteTailnetClient(_ context.Context, arg database.DeleteTailnetClientParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	clients := make([]database.TailnetClient, 0, len(q.tailnetClients))
+	for _, client := range q.tailnetClients {
+		if client.ID == arg.ID && client.CoordinatorID == arg.CoordinatorID {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	q.tailnetClients = clients
+	return nil
+}
+
+func (q *fakeQuerier) GetTailnetClientsForAgent(_ context.Context, agentID uuid.UUID) ([]database.TailnetClient, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	clients := make([]database.TailnetClient, 0)
+	for _, client := range q.tailnetClients {
+		if client.AgentID == agentID {
+			clients = append(clients, client)
+		}
+	}
+	return clients, nil
+}
+
+func (q *fakeQuerier) GetDERPRegions(_ context.Context) ([]database.DERPRegion, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	regions := make([]database.DERPRegion, len(q.derpRegions))
+	copy(regions, q.derpRegions)
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].ID < regions[j].ID
+	})
+	return regions, nil
+}
+
+func (q *fakeQuerier) GetDERPRegionByID(_ context.Context, id int32) (database.DERPRegion, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, region := range q.derpRegions {
+		if region.ID == id {
+			return region, nil
+		}
+	}
+	return database.DERPRegion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertDERPRegion(_ context.Context, arg database.InsertDERPRegionParams) (database.DERPRegion, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, region := range q.derpRegions {
+		if region.ID == arg.ID || region.Code == arg.Code {
+			return database.DERPRegion{}, errDuplicateKey
+		}
+	}
+	//nolint:gosimple
+	region := database.DERPRegion{
+		ID:        arg.ID,
+		Code:      arg.Code,
+		Name:      arg.Name,
+		Disabled:  arg.Disabled,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+	}
+	q.derpRegions = append(q.derpRegions, region)
+	return region, nil
+}
+
+func (q *fakeQuerier) UpdateDERPRegionByID(_ context.Context, arg database.UpdateDERPRegionByIDParams) (database.DERPRegion, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, region := range q.derpRegions {
+		if region.ID != arg.ID && region.Code == arg.Code {
+			return database.DERPRegion{}, errDuplicateKey
+		}
+	}
+	for index, region := range q.derpRegions {
+		if region.ID != arg.ID {
+			continue
+		}
+		region.Code = arg.Code
+		region.Name = arg.Name
+		region.Disabled = arg.Disabled
+		region.UpdatedAt = arg.UpdatedAt
+		q.derpRegions[index] = region
+		return region, nil
+	}
+	return database.DERPRegion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteDERPRegionByID(_ context.Context, id int32) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	regions := make([]database.DERPRegion, 0, len(q.derpRegions))
+	for _, region := range q.derpRegions {
+		if region.ID != id {
+			regions = append(regions, region)
+		}
+	}
+	q.derpRegions = regions
+
+	nodes := make([]database.DERPNode, 0, len(q.derpNodes))
+	for _, node := range q.derpNodes {
+		if node.RegionID != id {
+			nodes = append(nodes, node)
+		}
+	}
+	q.derpNodes = nodes
+	return nil
+}
+
+func (q *fakeQuerier) GetDERPNodes(_ context.Context) ([]database.DERPNode, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	nodes := make([]database.DERPNode, len(q.derpNodes))
+	copy(nodes, q.derpNodes)
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].RegionID != nodes[j].RegionID {
+			return nodes[i].RegionID < nodes[j].RegionID
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+func (q *fakeQuerier) GetDERPNodeByName(_ context.Context, name string) (database.DERPNode, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, node := range q.derpNodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return database.DERPNode{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertDERPNode(_ context.Context, arg database.InsertDERPNodeParams) (database.DERPNode, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, node := range q.derpNodes {
+		if node.Name == arg.Name {
+			return database.DERPNode{}, errDuplicateKey
+		}
+	}
+	regionExists := false
+	for _, region := range q.derpRegions {
+		if region.ID == arg.RegionID {
+			regionExists = true
+			break
+		}
+	}
+	if !regionExists {
+		return database.DERPNode{}, &pq.Error{
+			Code:    "23503",
+			Message: "insert or update on table \"derp_nodes\" violates foreign key constraint",
+		}
+	}
+	//nolint:gosimple
+	node := database.DERPNode{
+		Name:      arg.Name,
+		RegionID:  arg.RegionID,
+		HostName:  arg.HostName,
+		IPv4:      arg.IPv4,
+		IPv6:      arg.IPv6,
+		DERPPort:  arg.DERPPort,
+		STUNPort:  arg.STUNPort,
+		STUNOnly:  arg.STUNOnly,
+		ForceHTTP: arg.ForceHTTP,
+		Disabled:  arg.Disabled,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+	}
+	q.derpNodes = append(q.derpNodes, node)
+	return node, nil
+}
+
+func (q *fakeQuerier) UpdateDERPNodeByName(_ context.Context, arg database.UpdateDERPNodeByNameParams) (database.DERPNode, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, node := range q.derpNodes {
+		if node.Name != arg.Name {
+			continue
+		}
+		node.HostName = arg.HostName
+		node.IPv4 = arg.IPv4
+		node.IPv6 = arg.IPv6
+		node.DERPPort = arg.DERPPort
+		node.STUNPort = arg.STUNPort
+		node.STUNOnly = arg.STUNOnly
+		node.ForceHTTP = arg.ForceHTTP
+		node.Disabled = arg.Disabled
+		node.UpdatedAt = arg.UpdatedAt
+		q.derpNodes[index] = node
+		return node, nil
+	}
+	return database.DERPNode{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteDERPNodeByName(_ context.Context, name string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	nodes := make([]database.DERPNode, 0, len(q.derpNodes))
+	for _, node := range q.derpNodes {
+		if node.Name != name {
+			nodes = append(nodes, node)
+		}
+	}
+	q.derpNodes = nodes
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceBatch(_ context.Context, arg database.InsertWorkspaceBatchParams) (database.WorkspaceBatch, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	batch := database.WorkspaceBatch{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		InitiatorID: arg.InitiatorID,
+		Action:      arg.Action,
+		Query:       arg.Query,
+		Concurrency: arg.Concurrency,
+		HeartbeatAt: arg.HeartbeatAt,
+		Scope:       arg.Scope,
+	}
+	q.workspaceBatches = append(q.workspaceBatches, batch)
+	return batch, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceBatchItem(_ context.Context, arg database.InsertWorkspaceBatchItemParams) (database.WorkspaceBatchItem, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, item := range q.workspaceBatchItems {
+		if item.BatchID == arg.BatchID && item.WorkspaceID == arg.WorkspaceID {
+			return database.WorkspaceBatchItem{}, errDuplicateKey
+		}
+	}
+	//nolint:gosimple
+	item := database.WorkspaceBatchItem{
+		BatchID:       arg.BatchID,
+		WorkspaceID:   arg.WorkspaceID,
+		Position:      arg.Position,
+		WorkspaceName: arg.WorkspaceName,
+		OwnerName:     arg.OwnerName,
+		Status:        arg.Status,
+		Error:         arg.Error,
+	}
+	q.workspaceBatchItems = append(q.workspaceBatchItems, item)
+	return item, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceBatchByID(_ context.Context, id uuid.UUID) (database.WorkspaceBatch, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, batch := range q.workspaceBatches {
+		if batch.ID == id {
+			return batch, nil
+		}
+	}
+	return database.WorkspaceBatch{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceBatchItemsByBatchID(_ context.Context, batchID uuid.UUID) ([]database.WorkspaceBatchItem, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	items := make([]database.WorkspaceBatchItem, 0)
+	for _, item := range q.workspaceBatchItems {
+		if item.BatchID == batchID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Position < items[j].Position
+	})
+	return items, nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceBatchItem(_ context.Context, arg database.UpdateWorkspaceBatchItemParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, item := range q.workspaceBatchItems {
+		if item.BatchID != arg.BatchID || item.WorkspaceID != arg.WorkspaceID {
+			continue
+		}
+		item.Status = arg.Status
+		item.BuildID = arg.BuildID
+		item.Error = arg.Error
+		q.workspaceBatchItems[i] = item
+		return nil
+	}
+	return nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceBatchHeartbeat(_ context.Context, arg database.UpdateWorkspaceBatchHeartbeatParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, batch := range q.workspaceBatches {
+		if batch.ID == arg.ID {
+			q.workspaceBatches[i].HeartbeatAt = arg.HeartbeatAt
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceBatchCompletedAt(_ context.Context, arg database.UpdateWorkspaceBatchCompletedAtParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, batch := range q.workspaceBatches {
+		if batch.ID == arg.ID {
+			q.workspaceBatches[i].CompletedAt = arg.CompletedAt
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) AcquireStaleWorkspaceBatch(_ context.Context, arg database.AcquireStaleWorkspaceBatchParams) (database.WorkspaceBatch, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	index := -1
+	for i, batch := range q.workspaceBatches {
+		if batch.CompletedAt.Valid || !batch.HeartbeatAt.Before(arg.StaleBefore) {
+			continue
+		}
+		if index < 0 || batch.CreatedAt.Before(q.workspaceBatches[index].CreatedAt) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return database.WorkspaceBatch{}, sql.ErrNoRows
+	}
+	q.workspaceBatches[index].HeartbeatAt = arg.Now
+	return q.workspaceBatches[index], nil
+}
+
+func (q *fakeQuerier) DeleteOldWorkspaceBatches(_ context.Context, completedBefore time.Time) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	deleted := map[uuid.UUID]struct{}{}
+	batches := make([]database.WorkspaceBatch, 0, len(q.workspaceBatches))
+	for _, batch := range q.workspaceBatches {
+		if batch.CompletedAt.Valid && batch.CompletedAt.Time.Before(completedBefore) {
+			deleted[batch.ID] = struct{}{}
+			continue
+		}
+		batches = append(batches, batch)
+	}
+	q.workspaceBatches = batches
+	items := make([]database.WorkspaceBatchItem, 0, len(q.workspaceBatchItems))
+	for _, item := range q.workspaceBatchItems {
+		if _, ok := deleted[item.BatchID]; !ok {
+			items = append(items, item)
+		}
+	}
+	q.workspaceBatchItems = items
+	return nil
+}
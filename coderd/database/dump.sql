@@ -2,7 +2,8 @@
 
 CREATE TYPE api_key_scope AS ENUM (
     'all',
-    'application_connect'
+    'application_connect',
+    'two_factor_enrollment'
 );
 
 CREATE TYPE app_sharing_level AS ENUM (
@@ -22,7 +23,12 @@ CREATE TYPE audit_action AS ENUM (
 CREATE TYPE build_reason AS ENUM (
     'initiator',
     'autostart',
-    'autostop'
+    'autostop',
+    'dormancy',
+    'autodelete',
+    'retry',
+    'rollback',
+    'transfer'
 );
 
 CREATE TYPE log_level AS ENUM (
@@ -42,7 +48,17 @@ CREATE TYPE login_type AS ENUM (
     'password',
     'github',
     'oidc',
-    'token'
+    'token',
+    'ldap'
+);
+
+CREATE TYPE notification_kind AS ENUM (
+    'workspace_autostop',
+    'workspace_autostart_failed',
+    'workspace_build_failed',
+    'workspace_dormant',
+    'workspace_update_failed',
+    'workspace_transfer'
 );
 
 CREATE TYPE parameter_destination_scheme AS ENUM (
@@ -91,7 +107,8 @@ CREATE TYPE resource_type AS ENUM (
     'git_ssh_key',
     'api_key',
     'group',
-    'workspace_build'
+    'workspace_build',
+    'user_two_factor'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -133,11 +150,14 @@ CREATE TABLE api_keys (
     login_type login_type NOT NULL,
     lifetime_seconds bigint DEFAULT 86400 NOT NULL,
     ip_address inet DEFAULT '0.0.0.0'::inet NOT NULL,
-    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL
+    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL,
+    user_agent text DEFAULT ''::text NOT NULL
 );
 
 COMMENT ON COLUMN api_keys.hashed_secret IS 'hashed_secret contains a SHA256 hash of the key secret. This is considered a secret and MUST NOT be returned from the API as it is used for API key encryption in app proxying code.';
 
+COMMENT ON COLUMN api_keys.user_agent IS 'user_agent is the User-Agent header of the last request made with the key.';
+
 CREATE TABLE audit_logs (
     id uuid NOT NULL,
     "time" timestamp with time zone NOT NULL,
@@ -156,6 +176,34 @@ CREATE TABLE audit_logs (
     resource_icon text NOT NULL
 );
 
+CREATE TABLE derp_nodes (
+    name text NOT NULL,
+    region_id integer NOT NULL,
+    host_name text NOT NULL,
+    ipv4 text DEFAULT ''::text NOT NULL,
+    ipv6 text DEFAULT ''::text NOT NULL,
+    derp_port integer DEFAULT 0 NOT NULL,
+    stun_port integer DEFAULT 0 NOT NULL,
+    stun_only boolean DEFAULT false NOT NULL,
+    force_http boolean DEFAULT false NOT NULL,
+    disabled boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON COLUMN derp_nodes.stun_port IS 'The STUN port of the node. 0 uses the default port, and -1 disables STUN.';
+
+CREATE TABLE derp_regions (
+    id integer NOT NULL,
+    code text NOT NULL,
+    name text NOT NULL,
+    disabled boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE derp_regions IS 'DERP regions added through the API. They are merged into the DERP map of the deployment, and their IDs must not conflict with the regions of the static map.';
+
 CREATE TABLE files (
     hash character varying(64) NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -214,6 +262,19 @@ CREATE SEQUENCE licenses_id_seq
 
 ALTER SEQUENCE licenses_id_seq OWNED BY licenses.id;
 
+CREATE TABLE notifications (
+    id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    workspace_id uuid,
+    kind notification_kind NOT NULL,
+    title text NOT NULL,
+    body text NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    read_at timestamp with time zone
+);
+
+COMMENT ON COLUMN notifications.read_at IS 'When the user read the notification in their inbox. Null if unread.';
+
 CREATE TABLE organization_members (
     user_id uuid NOT NULL,
     organization_id uuid NOT NULL,
@@ -327,6 +388,30 @@ CREATE TABLE site_configs (
     value character varying(8192) NOT NULL
 );
 
+CREATE TABLE tailnet_agents (
+    id uuid NOT NULL,
+    coordinator_id uuid NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    node jsonb NOT NULL
+);
+
+CREATE TABLE tailnet_clients (
+    id uuid NOT NULL,
+    coordinator_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    node jsonb
+);
+
+COMMENT ON COLUMN tailnet_clients.node IS 'The node of the client, or NULL if the client hasn''t sent one yet.';
+
+CREATE TABLE tailnet_coordinators (
+    id uuid NOT NULL,
+    heartbeat_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE tailnet_coordinators IS 'The tailnet coordinators of running replicas. Coordinators that stop heartbeating are deleted along with their peers.';
+
 CREATE TABLE template_versions (
     id uuid NOT NULL,
     template_id uuid,
@@ -354,13 +439,64 @@ CREATE TABLE templates (
     icon character varying(256) DEFAULT ''::character varying NOT NULL,
     user_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
     group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
-    display_name character varying(64) DEFAULT ''::character varying NOT NULL
+    display_name character varying(64) DEFAULT ''::character varying NOT NULL,
+    max_ttl bigint DEFAULT 0 NOT NULL,
+    min_autostart_interval bigint DEFAULT 0 NOT NULL,
+    quiet_hours_schedule text DEFAULT ''::text NOT NULL,
+    allow_user_autostop boolean DEFAULT true NOT NULL,
+    inactivity_ttl bigint DEFAULT 0 NOT NULL,
+    dormancy_deletion_ttl bigint DEFAULT 0 NOT NULL,
+    activity_bump bigint DEFAULT '3600000000000'::bigint NOT NULL,
+    activity_bump_threshold bigint DEFAULT '3000000000000'::bigint NOT NULL,
+    require_active_version boolean DEFAULT false NOT NULL,
+    build_retries integer DEFAULT 0 NOT NULL,
+    build_retry_backoff bigint DEFAULT 0 NOT NULL,
+    rollback_failed_updates boolean DEFAULT false NOT NULL,
+    allow_workspace_networking boolean DEFAULT false NOT NULL
 );
 
 COMMENT ON COLUMN templates.default_ttl IS 'The default duration for auto-stop for workspaces created from this template.';
 
 COMMENT ON COLUMN templates.display_name IS 'Display name is a custom, human-friendly template name that user can set.';
 
+COMMENT ON COLUMN templates.max_ttl IS 'The maximum duration a workspace build may run before it is stopped. Zero means no limit.';
+
+COMMENT ON COLUMN templates.min_autostart_interval IS 'The minimum interval between two autostarts of a workspace. Zero means no limit.';
+
+COMMENT ON COLUMN templates.quiet_hours_schedule IS 'Cron schedule at which running workspaces are stopped, regardless of their deadline.';
+
+COMMENT ON COLUMN templates.allow_user_autostop IS 'Whether users may disable autostop for their workspaces.';
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration of inactivity after which workspaces are marked dormant and stopped. Zero disables dormancy.';
+
+COMMENT ON COLUMN templates.dormancy_deletion_ttl IS 'The duration after which dormant workspaces are deleted. Zero disables automatic deletion.';
+
+COMMENT ON COLUMN templates.activity_bump IS 'The duration the deadline of a workspace build is bumped to when its agent reports active sessions. Zero disables activity bumping.';
+
+COMMENT ON COLUMN templates.activity_bump_threshold IS 'How close the deadline of a workspace build must be before activity bumps it.';
+
+COMMENT ON COLUMN templates.require_active_version IS 'Whether workspaces must be updated to the active template version when they are started.';
+
+COMMENT ON COLUMN templates.build_retries IS 'The number of times a failed start build of a workspace is retried.';
+
+COMMENT ON COLUMN templates.build_retry_backoff IS 'The delay before the first retry of a failed start build. It doubles with every retry.';
+
+COMMENT ON COLUMN templates.rollback_failed_updates IS 'Whether workspaces are rolled back to their last successful build when an update fails.';
+
+COMMENT ON COLUMN templates.allow_workspace_networking IS 'Whether agents of workspaces created from this template may connect to other workspaces of their owner.';
+
+CREATE TABLE user_invitations (
+    id text NOT NULL,
+    hashed_secret bytea NOT NULL,
+    email text NOT NULL,
+    organization_id uuid NOT NULL,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON COLUMN user_invitations.hashed_secret IS 'SHA256 hash of the secret sent in the invitation email. Invitations are deleted once they are accepted.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -370,6 +506,44 @@ CREATE TABLE user_links (
     oauth_expiry timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
 );
 
+CREATE TABLE user_notification_preferences (
+    user_id uuid NOT NULL,
+    kind notification_kind NOT NULL,
+    inbox boolean DEFAULT true NOT NULL,
+    email boolean DEFAULT true NOT NULL,
+    webhook boolean DEFAULT true NOT NULL
+);
+
+COMMENT ON TABLE user_notification_preferences IS 'Channels users receive notifications of each kind through. Every channel is enabled for kinds without a row.';
+
+CREATE TABLE user_password_resets (
+    id text NOT NULL,
+    hashed_secret bytea NOT NULL,
+    user_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON COLUMN user_password_resets.hashed_secret IS 'SHA256 hash of the secret sent in the password reset email. Resets are deleted once they are used.';
+
+CREATE TABLE user_two_factors (
+    user_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    totp_secret text NOT NULL,
+    hashed_recovery_codes text[] DEFAULT '{}'::text[] NOT NULL,
+    enabled boolean DEFAULT false NOT NULL,
+    last_totp_step bigint DEFAULT 0 NOT NULL
+);
+
+COMMENT ON COLUMN user_two_factors.totp_secret IS 'The base32 encoded TOTP secret. This is considered a secret and MUST NOT be returned from the API after enrollment.';
+
+COMMENT ON COLUMN user_two_factors.hashed_recovery_codes IS 'SHA256 hashes of the single-use recovery codes that have not been used yet.';
+
+COMMENT ON COLUMN user_two_factors.enabled IS 'Enrollment is pending until the user verifies a code generated from the secret.';
+
+COMMENT ON COLUMN user_two_factors.last_totp_step IS 'The time step of the last TOTP code accepted. Codes of this or earlier steps are rejected to prevent replays.';
+
 CREATE TABLE users (
     id uuid NOT NULL,
     email text NOT NULL,
@@ -432,6 +606,55 @@ CREATE TABLE workspace_apps (
     slug text NOT NULL
 );
 
+CREATE TABLE workspace_batch_items (
+    batch_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    "position" integer NOT NULL,
+    workspace_name text NOT NULL,
+    owner_name text NOT NULL,
+    status text NOT NULL,
+    build_id uuid,
+    error text DEFAULT ''::text NOT NULL
+);
+
+CREATE TABLE workspace_batches (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    initiator_id uuid NOT NULL,
+    action text NOT NULL,
+    query text NOT NULL,
+    concurrency integer NOT NULL,
+    heartbeat_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL
+);
+
+COMMENT ON COLUMN workspace_batches.heartbeat_at IS 'Updated by the replica running the batch. Batches that stopped heartbeating are resumed by another replica.';
+
+COMMENT ON COLUMN workspace_batches.scope IS 'The scope of the API key that started the batch. Builds of resumed batches are authorized with it.';
+
+CREATE TABLE workspace_build_parameters (
+    workspace_build_id uuid NOT NULL,
+    name character varying(64) NOT NULL,
+    source_scheme parameter_source_scheme NOT NULL,
+    source_value text NOT NULL,
+    destination_scheme parameter_destination_scheme NOT NULL
+);
+
+COMMENT ON TABLE workspace_build_parameters IS 'The workspace parameter values a workspace build was created with.';
+
+CREATE TABLE workspace_build_recoveries (
+    workspace_build_id uuid NOT NULL,
+    reason build_reason NOT NULL,
+    template_version_id uuid NOT NULL,
+    target_build_id uuid,
+    recover_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_build_recoveries IS 'The retry or rollback decided on when a workspace build failed, which the lifecycle executor runs once it is due.';
+
+COMMENT ON COLUMN workspace_build_recoveries.target_build_id IS 'The build a rollback restores the parameter values of.';
+
 CREATE TABLE workspace_builds (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -466,6 +689,32 @@ CREATE TABLE workspace_resources (
     instance_type character varying(256)
 );
 
+CREATE TABLE workspace_schedule_skip_dates (
+    workspace_id uuid NOT NULL,
+    date date NOT NULL
+);
+
+COMMENT ON TABLE workspace_schedule_skip_dates IS 'Dates workspaces are not started automatically on, e.g. holidays.';
+
+CREATE TABLE workspace_schedule_windows (
+    id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    start_schedule text NOT NULL,
+    stop_schedule text NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_schedule_windows IS 'Periods workspaces are started and stopped automatically in, in addition to their autostart schedule.';
+
+CREATE TABLE workspace_transfers (
+    workspace_id uuid NOT NULL,
+    from_user_id uuid NOT NULL,
+    to_user_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_transfers IS 'Transfers of workspaces to another owner that are waiting for the new owner to accept them.';
+
 CREATE TABLE workspaces (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -477,9 +726,15 @@ CREATE TABLE workspaces (
     name character varying(64) NOT NULL,
     autostart_schedule text,
     ttl bigint,
-    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    dormant_at timestamp with time zone,
+    automatic_updates boolean DEFAULT false NOT NULL
 );
 
+COMMENT ON COLUMN workspaces.dormant_at IS 'When the workspace was marked dormant. Dormant workspaces can only be deleted until their owner confirms they are still in use.';
+
+COMMENT ON COLUMN workspaces.automatic_updates IS 'Whether the workspace is updated to the active template version when it is started.';
+
 ALTER TABLE ONLY licenses ALTER COLUMN id SET DEFAULT nextval('licenses_id_seq'::regclass);
 
 ALTER TABLE ONLY provisioner_job_logs ALTER COLUMN id SET DEFAULT nextval('provisioner_job_logs_id_seq'::regclass);
@@ -493,6 +748,15 @@ ALTER TABLE ONLY api_keys
 ALTER TABLE ONLY audit_logs
     ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY derp_nodes
+    ADD CONSTRAINT derp_nodes_pkey PRIMARY KEY (name);
+
+ALTER TABLE ONLY derp_regions
+    ADD CONSTRAINT derp_regions_code_key UNIQUE (code);
+
+ALTER TABLE ONLY derp_regions
+    ADD CONSTRAINT derp_regions_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY files
     ADD CONSTRAINT files_hash_created_by_key UNIQUE (hash, created_by);
 
@@ -520,6 +784,9 @@ ALTER TABLE ONLY licenses
 ALTER TABLE ONLY licenses
     ADD CONSTRAINT licenses_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY notifications
+    ADD CONSTRAINT notifications_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_pkey PRIMARY KEY (organization_id, user_id);
 
@@ -553,6 +820,15 @@ ALTER TABLE ONLY provisioner_jobs
 ALTER TABLE ONLY site_configs
     ADD CONSTRAINT site_configs_key_key UNIQUE (key);
 
+ALTER TABLE ONLY tailnet_agents
+    ADD CONSTRAINT tailnet_agents_pkey PRIMARY KEY (id, coordinator_id);
+
+ALTER TABLE ONLY tailnet_clients
+    ADD CONSTRAINT tailnet_clients_pkey PRIMARY KEY (id, coordinator_id);
+
+ALTER TABLE ONLY tailnet_coordinators
+    ADD CONSTRAINT tailnet_coordinators_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_pkey PRIMARY KEY (id);
 
@@ -562,9 +838,21 @@ ALTER TABLE ONLY template_versions
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY user_invitations
+    ADD CONSTRAINT user_invitations_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_pkey PRIMARY KEY (user_id, login_type);
 
+ALTER TABLE ONLY user_notification_preferences
+    ADD CONSTRAINT user_notification_preferences_pkey PRIMARY KEY (user_id, kind);
+
+ALTER TABLE ONLY user_password_resets
+    ADD CONSTRAINT user_password_resets_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY user_two_factors
+    ADD CONSTRAINT user_two_factors_pkey PRIMARY KEY (user_id);
+
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
@@ -577,6 +865,18 @@ ALTER TABLE ONLY workspace_apps
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_batch_items
+    ADD CONSTRAINT workspace_batch_items_pkey PRIMARY KEY (batch_id, workspace_id);
+
+ALTER TABLE ONLY workspace_batches
+    ADD CONSTRAINT workspace_batches_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_pkey PRIMARY KEY (workspace_build_id, name);
+
+ALTER TABLE ONLY workspace_build_recoveries
+    ADD CONSTRAINT workspace_build_recoveries_pkey PRIMARY KEY (workspace_build_id);
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 
@@ -592,6 +892,15 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_schedule_skip_dates
+    ADD CONSTRAINT workspace_schedule_skip_dates_pkey PRIMARY KEY (workspace_id, date);
+
+ALTER TABLE ONLY workspace_schedule_windows
+    ADD CONSTRAINT workspace_schedule_windows_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY workspace_transfers
+    ADD CONSTRAINT workspace_transfers_pkey PRIMARY KEY (workspace_id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
@@ -609,6 +918,10 @@ CREATE INDEX idx_audit_log_user_id ON audit_logs USING btree (user_id);
 
 CREATE INDEX idx_audit_logs_time_desc ON audit_logs USING btree ("time" DESC);
 
+CREATE INDEX idx_derp_nodes_region ON derp_nodes USING btree (region_id);
+
+CREATE INDEX idx_notifications_user_id_created_at ON notifications USING btree (user_id, created_at DESC);
+
 CREATE INDEX idx_organization_member_organization_id_uuid ON organization_members USING btree (organization_id);
 
 CREATE INDEX idx_organization_member_user_id_uuid ON organization_members USING btree (user_id);
@@ -617,10 +930,22 @@ CREATE UNIQUE INDEX idx_organization_name ON organizations USING btree (name);
 
 CREATE UNIQUE INDEX idx_organization_name_lower ON organizations USING btree (lower(name));
 
+CREATE INDEX idx_tailnet_agents_coordinator ON tailnet_agents USING btree (coordinator_id);
+
+CREATE INDEX idx_tailnet_clients_agent ON tailnet_clients USING btree (agent_id);
+
+CREATE INDEX idx_tailnet_clients_coordinator ON tailnet_clients USING btree (coordinator_id);
+
+CREATE INDEX idx_user_password_resets_user_id ON user_password_resets USING btree (user_id);
+
 CREATE UNIQUE INDEX idx_users_email ON users USING btree (email) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX idx_users_username ON users USING btree (username) WHERE (deleted = false);
 
+CREATE INDEX idx_workspace_batches_completed_at ON workspace_batches USING btree (completed_at);
+
+CREATE INDEX idx_workspace_schedule_windows_workspace_id ON workspace_schedule_windows USING btree (workspace_id);
+
 CREATE UNIQUE INDEX templates_organization_id_name_idx ON templates USING btree (organization_id, lower((name)::text)) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX users_email_lower_idx ON users USING btree (lower(email)) WHERE (deleted = false);
@@ -634,6 +959,9 @@ CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (own
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_user_id_uuid_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY derp_nodes
+    ADD CONSTRAINT derp_nodes_region_id_fkey FOREIGN KEY (region_id) REFERENCES derp_regions(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
@@ -646,6 +974,12 @@ ALTER TABLE ONLY group_members
 ALTER TABLE ONLY groups
     ADD CONSTRAINT groups_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY notifications
+    ADD CONSTRAINT notifications_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY notifications
+    ADD CONSTRAINT notifications_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_organization_id_uuid_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
@@ -661,6 +995,12 @@ ALTER TABLE ONLY provisioner_job_logs
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY tailnet_agents
+    ADD CONSTRAINT tailnet_agents_coordinator_id_fkey FOREIGN KEY (coordinator_id) REFERENCES tailnet_coordinators(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY tailnet_clients
+    ADD CONSTRAINT tailnet_clients_coordinator_id_fkey FOREIGN KEY (coordinator_id) REFERENCES tailnet_coordinators(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
 
@@ -676,15 +1016,45 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY user_invitations
+    ADD CONSTRAINT user_invitations_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY user_invitations
+    ADD CONSTRAINT user_invitations_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY user_notification_preferences
+    ADD CONSTRAINT user_notification_preferences_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY user_password_resets
+    ADD CONSTRAINT user_password_resets_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY user_two_factors
+    ADD CONSTRAINT user_two_factors_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_resource_id_fkey FOREIGN KEY (resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_batch_items
+    ADD CONSTRAINT workspace_batch_items_batch_id_fkey FOREIGN KEY (batch_id) REFERENCES workspace_batches(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_batches
+    ADD CONSTRAINT workspace_batches_initiator_id_fkey FOREIGN KEY (initiator_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_build_recoveries
+    ADD CONSTRAINT workspace_build_recoveries_target_build_id_fkey FOREIGN KEY (target_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_build_recoveries
+    ADD CONSTRAINT workspace_build_recoveries_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
@@ -700,6 +1070,21 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_schedule_skip_dates
+    ADD CONSTRAINT workspace_schedule_skip_dates_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_schedule_windows
+    ADD CONSTRAINT workspace_schedule_windows_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_transfers
+    ADD CONSTRAINT workspace_transfers_from_user_id_fkey FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_transfers
+    ADD CONSTRAINT workspace_transfers_to_user_id_fkey FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_transfers
+    ADD CONSTRAINT workspace_transfers_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
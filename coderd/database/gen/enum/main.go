@@ -112,6 +112,8 @@ func nameFromSnakeCase(s string) string {
 			ret += "JWT"
 		case "idx":
 			ret += "Index"
+		case "derp":
+			ret += "DERP"
 		default:
 			ret += strings.Title(ss)
 		}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS user_two_factors (
+	user_id uuid NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	totp_secret text NOT NULL,
+	hashed_recovery_codes text[] DEFAULT '{}'::text[] NOT NULL,
+	enabled boolean DEFAULT false NOT NULL
+);
+
+COMMENT ON COLUMN user_two_factors.totp_secret
+IS 'The base32 encoded TOTP secret. This is considered a secret and MUST NOT be returned from the API after enrollment.';
+COMMENT ON COLUMN user_two_factors.hashed_recovery_codes
+IS 'SHA256 hashes of the single-use recovery codes that have not been used yet.';
+COMMENT ON COLUMN user_two_factors.enabled
+IS 'Enrollment is pending until the user verifies a code generated from the secret.';
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'user_two_factor';
+ALTER TYPE api_key_scope ADD VALUE IF NOT EXISTS 'two_factor_enrollment';
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_invitations (
+	id text NOT NULL PRIMARY KEY,
+	hashed_secret bytea NOT NULL,
+	email text NOT NULL,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	created_by uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL
+);
+
+COMMENT ON COLUMN user_invitations.hashed_secret
+IS 'SHA256 hash of the secret sent in the invitation email. Invitations are deleted once they are accepted.';
+
+CREATE TABLE IF NOT EXISTS user_password_resets (
+	id text NOT NULL PRIMARY KEY,
+	hashed_secret bytea NOT NULL,
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL
+);
+
+COMMENT ON COLUMN user_password_resets.hashed_secret
+IS 'SHA256 hash of the secret sent in the password reset email. Resets are deleted once they are used.';
+
+CREATE INDEX idx_user_password_resets_user_id ON user_password_resets USING btree (user_id);
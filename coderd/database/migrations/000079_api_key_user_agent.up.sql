@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN user_agent text DEFAULT '' NOT NULL;
+
+COMMENT ON COLUMN api_keys.user_agent IS 'user_agent is the User-Agent header of the last request made with the key.';
@@ -0,0 +1,4 @@
+ALTER TABLE templates DROP COLUMN IF EXISTS max_ttl;
+ALTER TABLE templates DROP COLUMN IF EXISTS min_autostart_interval;
+ALTER TABLE templates DROP COLUMN IF EXISTS quiet_hours_schedule;
+ALTER TABLE templates DROP COLUMN IF EXISTS allow_user_autostop;
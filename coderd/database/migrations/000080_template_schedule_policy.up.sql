@@ -0,0 +1,9 @@
+ALTER TABLE templates ADD COLUMN max_ttl bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN min_autostart_interval bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN quiet_hours_schedule text DEFAULT '' NOT NULL;
+ALTER TABLE templates ADD COLUMN allow_user_autostop boolean DEFAULT true NOT NULL;
+
+COMMENT ON COLUMN templates.max_ttl IS 'The maximum duration a workspace build may run before it is stopped. Zero means no limit.';
+COMMENT ON COLUMN templates.min_autostart_interval IS 'The minimum interval between two autostarts of a workspace. Zero means no limit.';
+COMMENT ON COLUMN templates.quiet_hours_schedule IS 'Cron schedule at which running workspaces are stopped, regardless of their deadline.';
+COMMENT ON COLUMN templates.allow_user_autostop IS 'Whether users may disable autostop for their workspaces.';
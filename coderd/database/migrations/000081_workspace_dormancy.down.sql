@@ -0,0 +1,3 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS dormant_at;
+ALTER TABLE templates DROP COLUMN IF EXISTS inactivity_ttl;
+ALTER TABLE templates DROP COLUMN IF EXISTS dormancy_deletion_ttl;
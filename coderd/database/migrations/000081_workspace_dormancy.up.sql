@@ -0,0 +1,14 @@
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'dormancy';
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autodelete';
+
+ALTER TABLE templates ADD COLUMN inactivity_ttl bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN dormancy_deletion_ttl bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration of inactivity after which workspaces are marked dormant and stopped. Zero disables dormancy.';
+COMMENT ON COLUMN templates.dormancy_deletion_ttl IS 'The duration after which dormant workspaces are deleted. Zero disables automatic deletion.';
+
+ALTER TABLE workspaces ADD COLUMN dormant_at timestamp with time zone;
+
+COMMENT ON COLUMN workspaces.dormant_at IS 'When the workspace was marked dormant. Dormant workspaces can only be deleted until their owner confirms they are still in use.';
@@ -0,0 +1,2 @@
+ALTER TABLE templates DROP COLUMN activity_bump_threshold;
+ALTER TABLE templates DROP COLUMN activity_bump;
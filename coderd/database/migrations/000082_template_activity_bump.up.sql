@@ -0,0 +1,5 @@
+ALTER TABLE templates ADD COLUMN activity_bump bigint DEFAULT 3600000000000 NOT NULL;
+ALTER TABLE templates ADD COLUMN activity_bump_threshold bigint DEFAULT 3000000000000 NOT NULL;
+
+COMMENT ON COLUMN templates.activity_bump IS 'The duration the deadline of a workspace build is bumped to when its agent reports active sessions. Zero disables activity bumping.';
+COMMENT ON COLUMN templates.activity_bump_threshold IS 'How close the deadline of a workspace build must be before activity bumps it.';
@@ -0,0 +1,34 @@
+CREATE TYPE notification_kind AS ENUM (
+	'workspace_autostop',
+	'workspace_autostart_failed',
+	'workspace_build_failed',
+	'workspace_dormant'
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id uuid NOT NULL PRIMARY KEY,
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	workspace_id uuid REFERENCES workspaces (id) ON DELETE CASCADE,
+	kind notification_kind NOT NULL,
+	title text NOT NULL,
+	body text NOT NULL,
+	created_at timestamptz NOT NULL,
+	read_at timestamptz
+);
+
+COMMENT ON COLUMN notifications.read_at
+IS 'When the user read the notification in their inbox. Null if unread.';
+
+CREATE INDEX idx_notifications_user_id_created_at ON notifications USING btree (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS user_notification_preferences (
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	kind notification_kind NOT NULL,
+	inbox boolean DEFAULT true NOT NULL,
+	email boolean DEFAULT true NOT NULL,
+	webhook boolean DEFAULT true NOT NULL,
+	PRIMARY KEY (user_id, kind)
+);
+
+COMMENT ON TABLE user_notification_preferences
+IS 'Channels users receive notifications of each kind through. Every channel is enabled for kinds without a row.';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_schedule_skip_dates;
+DROP TABLE IF EXISTS workspace_schedule_windows;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS workspace_schedule_windows (
+	id uuid NOT NULL PRIMARY KEY,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	start_schedule text NOT NULL,
+	stop_schedule text NOT NULL,
+	created_at timestamptz NOT NULL
+);
+
+COMMENT ON TABLE workspace_schedule_windows
+IS 'Periods workspaces are started and stopped automatically in, in addition to their autostart schedule.';
+
+CREATE INDEX idx_workspace_schedule_windows_workspace_id ON workspace_schedule_windows USING btree (workspace_id);
+
+CREATE TABLE IF NOT EXISTS workspace_schedule_skip_dates (
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	date date NOT NULL,
+	PRIMARY KEY (workspace_id, date)
+);
+
+COMMENT ON TABLE workspace_schedule_skip_dates
+IS 'Dates workspaces are not started automatically on, e.g. holidays.';
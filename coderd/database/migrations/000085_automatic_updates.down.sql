@@ -0,0 +1,5 @@
+ALTER TABLE workspaces DROP COLUMN automatic_updates;
+ALTER TABLE templates DROP COLUMN require_active_version;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
@@ -0,0 +1,7 @@
+ALTER TYPE notification_kind ADD VALUE IF NOT EXISTS 'workspace_update_failed';
+
+ALTER TABLE templates ADD COLUMN require_active_version boolean DEFAULT false NOT NULL;
+ALTER TABLE workspaces ADD COLUMN automatic_updates boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN templates.require_active_version IS 'Whether workspaces must be updated to the active template version when they are started.';
+COMMENT ON COLUMN workspaces.automatic_updates IS 'Whether the workspace is updated to the active template version when it is started.';
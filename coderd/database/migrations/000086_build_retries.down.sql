@@ -0,0 +1,8 @@
+DROP TABLE workspace_build_parameters;
+
+ALTER TABLE templates DROP COLUMN rollback_failed_updates;
+ALTER TABLE templates DROP COLUMN build_retry_backoff;
+ALTER TABLE templates DROP COLUMN build_retries;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
@@ -0,0 +1,22 @@
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'retry';
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'rollback';
+
+ALTER TABLE templates ADD COLUMN build_retries integer DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN build_retry_backoff bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN rollback_failed_updates boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN templates.build_retries IS 'The number of times a failed start build of a workspace is retried.';
+COMMENT ON COLUMN templates.build_retry_backoff IS 'The delay before the first retry of a failed start build. It doubles with every retry.';
+COMMENT ON COLUMN templates.rollback_failed_updates IS 'Whether workspaces are rolled back to their last successful build when an update fails.';
+
+CREATE TABLE IF NOT EXISTS workspace_build_parameters (
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	name character varying(64) NOT NULL,
+	source_scheme parameter_source_scheme NOT NULL,
+	source_value text NOT NULL,
+	destination_scheme parameter_destination_scheme NOT NULL,
+	PRIMARY KEY (workspace_build_id, name)
+);
+
+COMMENT ON TABLE workspace_build_parameters
+IS 'The workspace parameter values a workspace build was created with.';
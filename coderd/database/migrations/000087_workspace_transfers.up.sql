@@ -0,0 +1,12 @@
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'transfer';
+ALTER TYPE notification_kind ADD VALUE IF NOT EXISTS 'workspace_transfer';
+
+CREATE TABLE IF NOT EXISTS workspace_transfers (
+	workspace_id uuid NOT NULL PRIMARY KEY REFERENCES workspaces (id) ON DELETE CASCADE,
+	from_user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	to_user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_transfers
+IS 'Transfers of workspaces to another owner that are waiting for the new owner to accept them.';
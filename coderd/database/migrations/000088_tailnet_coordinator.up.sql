@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS tailnet_coordinators (
+	id uuid NOT NULL PRIMARY KEY,
+	heartbeat_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE tailnet_coordinators
+IS 'The tailnet coordinators of running replicas. Coordinators that stop heartbeating are deleted along with their peers.';
+
+CREATE TABLE IF NOT EXISTS tailnet_agents (
+	id uuid NOT NULL,
+	coordinator_id uuid NOT NULL REFERENCES tailnet_coordinators (id) ON DELETE CASCADE,
+	updated_at timestamp with time zone NOT NULL,
+	node jsonb NOT NULL,
+	PRIMARY KEY (id, coordinator_id)
+);
+
+CREATE INDEX idx_tailnet_agents_coordinator ON tailnet_agents (coordinator_id);
+
+CREATE TABLE IF NOT EXISTS tailnet_clients (
+	id uuid NOT NULL,
+	coordinator_id uuid NOT NULL REFERENCES tailnet_coordinators (id) ON DELETE CASCADE,
+	agent_id uuid NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	node jsonb,
+	PRIMARY KEY (id, coordinator_id)
+);
+
+CREATE INDEX idx_tailnet_clients_agent ON tailnet_clients (agent_id);
+
+CREATE INDEX idx_tailnet_clients_coordinator ON tailnet_clients (coordinator_id);
+
+COMMENT ON COLUMN tailnet_clients.node
+IS 'The node of the client, or NULL if the client hasn''t sent one yet.';
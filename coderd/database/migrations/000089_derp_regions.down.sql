@@ -0,0 +1,2 @@
+DROP TABLE derp_nodes;
+DROP TABLE derp_regions;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS derp_regions (
+	id integer NOT NULL PRIMARY KEY,
+	code text NOT NULL UNIQUE,
+	name text NOT NULL,
+	disabled boolean NOT NULL DEFAULT false,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE derp_regions
+IS 'DERP regions added through the API. They are merged into the DERP map of the deployment, and their IDs must not conflict with the regions of the static map.';
+
+CREATE TABLE IF NOT EXISTS derp_nodes (
+	name text NOT NULL PRIMARY KEY,
+	region_id integer NOT NULL REFERENCES derp_regions (id) ON DELETE CASCADE,
+	host_name text NOT NULL,
+	ipv4 text NOT NULL DEFAULT '',
+	ipv6 text NOT NULL DEFAULT '',
+	derp_port integer NOT NULL DEFAULT 0,
+	stun_port integer NOT NULL DEFAULT 0,
+	stun_only boolean NOT NULL DEFAULT false,
+	force_http boolean NOT NULL DEFAULT false,
+	disabled boolean NOT NULL DEFAULT false,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX idx_derp_nodes_region ON derp_nodes (region_id);
+
+COMMENT ON COLUMN derp_nodes.stun_port
+IS 'The STUN port of the node. 0 uses the default port, and -1 disables STUN.';
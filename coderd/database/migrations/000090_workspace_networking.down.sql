@@ -0,0 +1 @@
+ALTER TABLE templates DROP COLUMN allow_workspace_networking;
@@ -0,0 +1,3 @@
+ALTER TABLE templates ADD COLUMN allow_workspace_networking boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN templates.allow_workspace_networking IS 'Whether agents of workspaces created from this template may connect to other workspaces of their owner.';
@@ -0,0 +1 @@
+ALTER TABLE user_two_factors DROP COLUMN IF EXISTS last_totp_step;
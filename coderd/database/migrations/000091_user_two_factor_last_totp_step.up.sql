@@ -0,0 +1,4 @@
+ALTER TABLE user_two_factors ADD COLUMN IF NOT EXISTS last_totp_step bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN user_two_factors.last_totp_step
+IS 'The time step of the last TOTP code accepted. Codes of this or earlier steps are rejected to prevent replays.';
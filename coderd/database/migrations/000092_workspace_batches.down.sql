@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_batch_items;
+DROP TABLE IF EXISTS workspace_batches;
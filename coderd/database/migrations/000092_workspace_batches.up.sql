@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS workspace_batches (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	initiator_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	action text NOT NULL,
+	query text NOT NULL,
+	concurrency integer NOT NULL,
+	heartbeat_at timestamp with time zone NOT NULL,
+	completed_at timestamp with time zone,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN workspace_batches.heartbeat_at
+IS 'Updated by the replica running the batch. Batches that stopped heartbeating are resumed by another replica.';
+
+CREATE TABLE IF NOT EXISTS workspace_batch_items (
+	batch_id uuid NOT NULL REFERENCES workspace_batches (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL,
+	position integer NOT NULL,
+	workspace_name text NOT NULL,
+	owner_name text NOT NULL,
+	status text NOT NULL,
+	build_id uuid,
+	error text DEFAULT ''::text NOT NULL,
+	PRIMARY KEY (batch_id, workspace_id)
+);
+
+CREATE INDEX idx_workspace_batches_completed_at ON workspace_batches (completed_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS workspace_build_recoveries (
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	reason build_reason NOT NULL,
+	template_version_id uuid NOT NULL,
+	target_build_id uuid REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	recover_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (workspace_build_id)
+);
+
+COMMENT ON TABLE workspace_build_recoveries
+IS 'The retry or rollback decided on when a workspace build failed, which the lifecycle executor runs once it is due.';
+
+COMMENT ON COLUMN workspace_build_recoveries.target_build_id
+IS 'The build a rollback restores the parameter values of.';
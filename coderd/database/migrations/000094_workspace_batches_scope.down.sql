@@ -0,0 +1 @@
+ALTER TABLE workspace_batches DROP COLUMN IF EXISTS scope;
@@ -0,0 +1,6 @@
+-- Batches are resumed with the scope of the API key that started them.
+-- Batches started before were resumed with the "all" scope.
+ALTER TABLE workspace_batches ADD COLUMN IF NOT EXISTS scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL;
+
+COMMENT ON COLUMN workspace_batches.scope
+IS 'The scope of the API key that started the batch. Builds of resumed batches are authorized with it.';
@@ -12,6 +12,8 @@ func (s APIKeyScope) ToRBAC() rbac.Scope {
 		return rbac.ScopeAll
 	case APIKeyScopeApplicationConnect:
 		return rbac.ScopeApplicationConnect
+	case APIKeyScopeTwoFactorEnrollment:
+		return rbac.ScopeTwoFactorEnrollment
 	default:
 		panic("developer error: unknown scope type " + string(s))
 	}
@@ -132,6 +132,7 @@ func (q *sqlQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg GetWorkspa
 		arg.TemplateName,
 		pq.Array(arg.TemplateIds),
 		arg.Name,
+		arg.Dormant,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -154,6 +155,8 @@ func (q *sqlQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg GetWorkspa
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.DormantAt,
+			&i.AutomaticUpdates,
 		); err != nil {
 			return nil, err
 		}
@@ -18,8 +18,9 @@ import (
 type APIKeyScope string
 
 const (
-	APIKeyScopeAll                APIKeyScope = "all"
-	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	APIKeyScopeAll                 APIKeyScope = "all"
+	APIKeyScopeApplicationConnect  APIKeyScope = "application_connect"
+	APIKeyScopeTwoFactorEnrollment APIKeyScope = "two_factor_enrollment"
 )
 
 func (e *APIKeyScope) Scan(src interface{}) error {
@@ -79,9 +80,14 @@ func (e *AuditAction) Scan(src interface{}) error {
 type BuildReason string
 
 const (
-	BuildReasonInitiator BuildReason = "initiator"
-	BuildReasonAutostart BuildReason = "autostart"
-	BuildReasonAutostop  BuildReason = "autostop"
+	BuildReasonInitiator  BuildReason = "initiator"
+	BuildReasonAutostart  BuildReason = "autostart"
+	BuildReasonAutostop   BuildReason = "autostop"
+	BuildReasonDormancy   BuildReason = "dormancy"
+	BuildReasonAutodelete BuildReason = "autodelete"
+	BuildReasonRetry      BuildReason = "retry"
+	BuildReasonRollback   BuildReason = "rollback"
+	BuildReasonTransfer   BuildReason = "transfer"
 )
 
 func (e *BuildReason) Scan(src interface{}) error {
@@ -144,6 +150,7 @@ const (
 	LoginTypeGithub   LoginType = "github"
 	LoginTypeOIDC     LoginType = "oidc"
 	LoginTypeToken    LoginType = "token"
+	LoginTypeLDAP     LoginType = "ldap"
 )
 
 func (e *LoginType) Scan(src interface{}) error {
@@ -158,6 +165,29 @@ func (e *LoginType) Scan(src interface{}) error {
 	return nil
 }
 
+type NotificationKind string
+
+const (
+	NotificationKindWorkspaceAutostop        NotificationKind = "workspace_autostop"
+	NotificationKindWorkspaceAutostartFailed NotificationKind = "workspace_autostart_failed"
+	NotificationKindWorkspaceBuildFailed     NotificationKind = "workspace_build_failed"
+	NotificationKindWorkspaceDormant         NotificationKind = "workspace_dormant"
+	NotificationKindWorkspaceUpdateFailed    NotificationKind = "workspace_update_failed"
+	NotificationKindWorkspaceTransfer        NotificationKind = "workspace_transfer"
+)
+
+func (e *NotificationKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationKind(s)
+	case string:
+		*e = NotificationKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationKind: %T", src)
+	}
+	return nil
+}
+
 type ParameterDestinationScheme string
 
 const (
@@ -305,6 +335,7 @@ const (
 	ResourceTypeApiKey          ResourceType = "api_key"
 	ResourceTypeGroup           ResourceType = "group"
 	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
+	ResourceTypeUserTwoFactor   ResourceType = "user_two_factor"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -392,6 +423,8 @@ type APIKey struct {
 	LifetimeSeconds int64       `db:"lifetime_seconds" json:"lifetime_seconds"`
 	IPAddress       pqtype.Inet `db:"ip_address" json:"ip_address"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	// user_agent is the User-Agent header of the last request made with the key.
+	UserAgent string `db:"user_agent" json:"user_agent"`
 }
 
 type AgentStat struct {
@@ -422,6 +455,32 @@ type AuditLog struct {
 	ResourceIcon     string          `db:"resource_icon" json:"resource_icon"`
 }
 
+type DERPNode struct {
+	Name     string `db:"name" json:"name"`
+	RegionID int32  `db:"region_id" json:"region_id"`
+	HostName string `db:"host_name" json:"host_name"`
+	IPv4     string `db:"ipv4" json:"ipv4"`
+	IPv6     string `db:"ipv6" json:"ipv6"`
+	DERPPort int32  `db:"derp_port" json:"derp_port"`
+	// The STUN port of the node. 0 uses the default port, and -1 disables STUN.
+	STUNPort  int32     `db:"stun_port" json:"stun_port"`
+	STUNOnly  bool      `db:"stun_only" json:"stun_only"`
+	ForceHTTP bool      `db:"force_http" json:"force_http"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// DERP regions added through the API. They are merged into the DERP map of the deployment, and their IDs must not conflict with the regions of the static map.
+type DERPRegion struct {
+	ID        int32     `db:"id" json:"id"`
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
 type File struct {
 	Hash      string    `db:"hash" json:"hash"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
@@ -469,6 +528,18 @@ type License struct {
 	Exp time.Time `db:"exp" json:"exp"`
 }
 
+type Notification struct {
+	ID          uuid.UUID        `db:"id" json:"id"`
+	UserID      uuid.UUID        `db:"user_id" json:"user_id"`
+	WorkspaceID uuid.NullUUID    `db:"workspace_id" json:"workspace_id"`
+	Kind        NotificationKind `db:"kind" json:"kind"`
+	Title       string           `db:"title" json:"title"`
+	Body        string           `db:"body" json:"body"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	// When the user read the notification in their inbox. Null if unread.
+	ReadAt sql.NullTime `db:"read_at" json:"read_at"`
+}
+
 type Organization struct {
 	ID          uuid.UUID `db:"id" json:"id"`
 	Name        string    `db:"name" json:"name"`
@@ -573,6 +644,28 @@ type SiteConfig struct {
 	Value string `db:"value" json:"value"`
 }
 
+type TailnetAgent struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+type TailnetClient struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CoordinatorID uuid.UUID `db:"coordinator_id" json:"coordinator_id"`
+	AgentID       uuid.UUID `db:"agent_id" json:"agent_id"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	// The node of the client, or NULL if the client hasn't sent one yet.
+	Node pqtype.NullRawMessage `db:"node" json:"node"`
+}
+
+// The tailnet coordinators of running replicas. Coordinators that stop heartbeating are deleted along with their peers.
+type TailnetCoordinator struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
 type Template struct {
 	ID              uuid.UUID       `db:"id" json:"id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
@@ -591,6 +684,32 @@ type Template struct {
 	GroupACL   TemplateACL `db:"group_acl" json:"group_acl"`
 	// Display name is a custom, human-friendly template name that user can set.
 	DisplayName string `db:"display_name" json:"display_name"`
+	// The maximum duration a workspace build may run before it is stopped. Zero means no limit.
+	MaxTtl int64 `db:"max_ttl" json:"max_ttl"`
+	// The minimum interval between two autostarts of a workspace. Zero means no limit.
+	MinAutostartInterval int64 `db:"min_autostart_interval" json:"min_autostart_interval"`
+	// Cron schedule at which running workspaces are stopped, regardless of their deadline.
+	QuietHoursSchedule string `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	// Whether users may disable autostop for their workspaces.
+	AllowUserAutostop bool `db:"allow_user_autostop" json:"allow_user_autostop"`
+	// The duration of inactivity after which workspaces are marked dormant and stopped. Zero disables dormancy.
+	InactivityTtl int64 `db:"inactivity_ttl" json:"inactivity_ttl"`
+	// The duration after which dormant workspaces are deleted. Zero disables automatic deletion.
+	DormancyDeletionTtl int64 `db:"dormancy_deletion_ttl" json:"dormancy_deletion_ttl"`
+	// The duration the deadline of a workspace build is bumped to when its agent reports active sessions. Zero disables activity bumping.
+	ActivityBump int64 `db:"activity_bump" json:"activity_bump"`
+	// How close the deadline of a workspace build must be before activity bumps it.
+	ActivityBumpThreshold int64 `db:"activity_bump_threshold" json:"activity_bump_threshold"`
+	// Whether workspaces must be updated to the active template version when they are started.
+	RequireActiveVersion bool `db:"require_active_version" json:"require_active_version"`
+	// The number of times a failed start build of a workspace is retried.
+	BuildRetries int32 `db:"build_retries" json:"build_retries"`
+	// The delay before the first retry of a failed start build. It doubles with every retry.
+	BuildRetryBackoff int64 `db:"build_retry_backoff" json:"build_retry_backoff"`
+	// Whether workspaces are rolled back to their last successful build when an update fails.
+	RollbackFailedUpdates bool `db:"rollback_failed_updates" json:"rollback_failed_updates"`
+	// Whether agents of workspaces created from this template may connect to other workspaces of their owner.
+	AllowWorkspaceNetworking bool `db:"allow_workspace_networking" json:"allow_workspace_networking"`
 }
 
 type TemplateVersion struct {
@@ -620,6 +739,17 @@ type User struct {
 	LastSeenAt     time.Time      `db:"last_seen_at" json:"last_seen_at"`
 }
 
+type UserInvitation struct {
+	ID string `db:"id" json:"id"`
+	// SHA256 hash of the secret sent in the invitation email. Invitations are deleted once they are accepted.
+	HashedSecret   []byte    `db:"hashed_secret" json:"hashed_secret"`
+	Email          string    `db:"email" json:"email"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	CreatedBy      uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at" json:"expires_at"`
+}
+
 type UserLink struct {
 	UserID            uuid.UUID `db:"user_id" json:"user_id"`
 	LoginType         LoginType `db:"login_type" json:"login_type"`
@@ -629,6 +759,38 @@ type UserLink struct {
 	OAuthExpiry       time.Time `db:"oauth_expiry" json:"oauth_expiry"`
 }
 
+// Channels users receive notifications of each kind through. Every channel is enabled for kinds without a row.
+type UserNotificationPreference struct {
+	UserID  uuid.UUID        `db:"user_id" json:"user_id"`
+	Kind    NotificationKind `db:"kind" json:"kind"`
+	Inbox   bool             `db:"inbox" json:"inbox"`
+	Email   bool             `db:"email" json:"email"`
+	Webhook bool             `db:"webhook" json:"webhook"`
+}
+
+type UserPasswordReset struct {
+	ID string `db:"id" json:"id"`
+	// SHA256 hash of the secret sent in the password reset email. Resets are deleted once they are used.
+	HashedSecret []byte    `db:"hashed_secret" json:"hashed_secret"`
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type UserTwoFactor struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// The base32 encoded TOTP secret. This is considered a secret and MUST NOT be returned from the API after enrollment.
+	TOTPSecret string `db:"totp_secret" json:"totp_secret"`
+	// SHA256 hashes of the single-use recovery codes that have not been used yet.
+	HashedRecoveryCodes []string `db:"hashed_recovery_codes" json:"hashed_recovery_codes"`
+	// Enrollment is pending until the user verifies a code generated from the secret.
+	Enabled bool `db:"enabled" json:"enabled"`
+	// The time step of the last TOTP code accepted. Codes of this or earlier steps are rejected to prevent replays.
+	LastTOTPStep int64 `db:"last_totp_step" json:"last_totp_step"`
+}
+
 type Workspace struct {
 	ID                uuid.UUID      `db:"id" json:"id"`
 	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
@@ -641,6 +803,10 @@ type Workspace struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	// When the workspace was marked dormant. Dormant workspaces can only be deleted until their owner confirms they are still in use.
+	DormantAt sql.NullTime `db:"dormant_at" json:"dormant_at"`
+	// Whether the workspace is updated to the active template version when it is started.
+	AutomaticUpdates bool `db:"automatic_updates" json:"automatic_updates"`
 }
 
 type WorkspaceAgent struct {
@@ -687,6 +853,49 @@ type WorkspaceApp struct {
 	Slug                 string             `db:"slug" json:"slug"`
 }
 
+// The workspace parameter values a workspace build was created with.
+type WorkspaceBuildParameter struct {
+	WorkspaceBuildID  uuid.UUID                  `db:"workspace_build_id" json:"workspace_build_id"`
+	Name              string                     `db:"name" json:"name"`
+	SourceScheme      ParameterSourceScheme      `db:"source_scheme" json:"source_scheme"`
+	SourceValue       string                     `db:"source_value" json:"source_value"`
+	DestinationScheme ParameterDestinationScheme `db:"destination_scheme" json:"destination_scheme"`
+}
+
+type WorkspaceBuildRecovery struct {
+	WorkspaceBuildID  uuid.UUID   `db:"workspace_build_id" json:"workspace_build_id"`
+	Reason            BuildReason `db:"reason" json:"reason"`
+	TemplateVersionID uuid.UUID   `db:"template_version_id" json:"template_version_id"`
+	// The build a rollback restores the parameter values of.
+	TargetBuildID uuid.NullUUID `db:"target_build_id" json:"target_build_id"`
+	RecoverAt     time.Time     `db:"recover_at" json:"recover_at"`
+}
+
+type WorkspaceBatch struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	InitiatorID uuid.UUID `db:"initiator_id" json:"initiator_id"`
+	Action      string    `db:"action" json:"action"`
+	Query       string    `db:"query" json:"query"`
+	Concurrency int32     `db:"concurrency" json:"concurrency"`
+	// Updated by the replica running the batch. Batches that stopped heartbeating are resumed by another replica.
+	HeartbeatAt time.Time    `db:"heartbeat_at" json:"heartbeat_at"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	// The scope of the API key that started the batch. Builds of resumed batches are authorized with it.
+	Scope APIKeyScope `db:"scope" json:"scope"`
+}
+
+type WorkspaceBatchItem struct {
+	BatchID       uuid.UUID     `db:"batch_id" json:"batch_id"`
+	WorkspaceID   uuid.UUID     `db:"workspace_id" json:"workspace_id"`
+	Position      int32         `db:"position" json:"position"`
+	WorkspaceName string        `db:"workspace_name" json:"workspace_name"`
+	OwnerName     string        `db:"owner_name" json:"owner_name"`
+	Status        string        `db:"status" json:"status"`
+	BuildID       uuid.NullUUID `db:"build_id" json:"build_id"`
+	Error         string        `db:"error" json:"error"`
+}
+
 type WorkspaceBuild struct {
 	ID                uuid.UUID           `db:"id" json:"id"`
 	CreatedAt         time.Time           `db:"created_at" json:"created_at"`
@@ -720,3 +929,26 @@ type WorkspaceResourceMetadatum struct {
 	Value               sql.NullString `db:"value" json:"value"`
 	Sensitive           bool           `db:"sensitive" json:"sensitive"`
 }
+
+// Dates workspaces are not started automatically on, e.g. holidays.
+type WorkspaceScheduleSkipDate struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	Date        time.Time `db:"date" json:"date"`
+}
+
+// Periods workspaces are started and stopped automatically in, in addition to their autostart schedule.
+type WorkspaceScheduleWindow struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	WorkspaceID   uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	StartSchedule string    `db:"start_schedule" json:"start_schedule"`
+	StopSchedule  string    `db:"stop_schedule" json:"stop_schedule"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// Transfers of workspaces to another owner that are waiting for the new owner to accept them.
+type WorkspaceTransfer struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	FromUserID  uuid.UUID `db:"from_user_id" json:"from_user_id"`
+	ToUserID    uuid.UUID `db:"to_user_id" json:"to_user_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
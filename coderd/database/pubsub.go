@@ -127,6 +127,7 @@ func (p *pgPubsub) listenReceive(ctx context.Context, notif *pq.Notification) {
 }
 
 // NewPubsub creates a new Pubsub implementation using a PostgreSQL connection.
+// Messages larger than NOTIFY accepts are chunked.
 func NewPubsub(ctx context.Context, database *sql.DB, connectURL string) (Pubsub, error) {
 	// Creates a new listener using pq.
 	errCh := make(chan error)
@@ -156,5 +157,5 @@ func NewPubsub(ctx context.Context, database *sql.DB, connectURL string) (Pubsub
 	}
 	go pgPubsub.listen(ctx)
 
-	return pgPubsub, nil
+	return NewPubsubChunked(pgPubsub, pgNotifyMaxPayload), nil
 }
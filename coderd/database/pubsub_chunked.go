@@ -0,0 +1,158 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// pgNotifyMaxPayload is the largest message PostgreSQL NOTIFY accepts,
+	// less some room for the channel name.
+	pgNotifyMaxPayload = 7900
+	// chunkPrefix marks messages that are chunks of a larger message. It's
+	// printable, so chunks are valid PostgreSQL text.
+	chunkPrefix = "\x1echunk|"
+	// chunkHeaderSize is the maximum size of the header of a chunk:
+	// <prefix><message id>|<index>|<total>|
+	chunkHeaderSize = len(chunkPrefix) + 36 + 3*11
+	// chunkTimeout is how long the chunks of a message are kept while
+	// waiting for the rest of them.
+	chunkTimeout = 30 * time.Second
+)
+
+// chunkedPubsub splits messages that are too large for the underlying Pubsub
+// into chunks, and reassembles them for subscribers.
+type chunkedPubsub struct {
+	Pubsub
+	maxMessageSize int
+}
+
+// NewPubsubChunked wraps a Pubsub so messages larger than maxMessageSize are
+// split into chunks no larger than it. Chunks are base64 encoded, so any
+// message can be sent over a Pubsub that only accepts text.
+func NewPubsubChunked(pubsub Pubsub, maxMessageSize int) Pubsub {
+	return &chunkedPubsub{
+		Pubsub:         pubsub,
+		maxMessageSize: maxMessageSize,
+	}
+}
+
+func (c *chunkedPubsub) Publish(event string, message []byte) error {
+	// Messages that look like chunks are always chunked, so subscribers
+	// don't mistake them for one.
+	if len(message) <= c.maxMessageSize && !bytes.HasPrefix(message, []byte(chunkPrefix)) {
+		return c.Pubsub.Publish(event, message)
+	}
+
+	chunkSize := (c.maxMessageSize - chunkHeaderSize) / 4 * 3
+	if chunkSize <= 0 {
+		return xerrors.Errorf("max message size %d is too small to chunk messages", c.maxMessageSize)
+	}
+	total := (len(message) + chunkSize - 1) / chunkSize
+	id := uuid.New().String()
+	for index := 0; index < total; index++ {
+		end := (index + 1) * chunkSize
+		if end > len(message) {
+			end = len(message)
+		}
+		var chunk bytes.Buffer
+		chunk.WriteString(chunkPrefix)
+		chunk.WriteString(id + "|")
+		chunk.WriteString(strconv.Itoa(index) + "|")
+		chunk.WriteString(strconv.Itoa(total) + "|")
+		chunk.WriteString(base64.StdEncoding.EncodeToString(message[index*chunkSize : end]))
+		err := c.Pubsub.Publish(event, chunk.Bytes())
+		if err != nil {
+			return xerrors.Errorf("publish chunk %d of %d: %w", index+1, total, err)
+		}
+	}
+	return nil
+}
+
+func (c *chunkedPubsub) Subscribe(event string, listener Listener) (cancel func(), err error) {
+	assembler := &chunkAssembler{
+		messages: map[string]*chunkedMessage{},
+	}
+	return c.Pubsub.Subscribe(event, func(ctx context.Context, message []byte) {
+		if !bytes.HasPrefix(message, []byte(chunkPrefix)) {
+			listener(ctx, message)
+			return
+		}
+		message, ok := assembler.add(message)
+		if ok {
+			listener(ctx, message)
+		}
+	})
+}
+
+// chunkAssembler reassembles the chunked messages of a subscription. Chunks
+// may arrive in any order.
+type chunkAssembler struct {
+	mutex    sync.Mutex
+	messages map[string]*chunkedMessage
+}
+
+type chunkedMessage struct {
+	firstSeen time.Time
+	chunks    [][]byte
+	received  int
+}
+
+// add adds a chunk, and returns the message once all of its chunks arrived.
+// Malformed chunks are dropped.
+func (a *chunkAssembler) add(chunk []byte) ([]byte, bool) {
+	parts := bytes.SplitN(chunk[len(chunkPrefix):], []byte("|"), 4)
+	if len(parts) != 4 {
+		return nil, false
+	}
+	id := string(parts[0])
+	index, err := strconv.Atoi(string(parts[1]))
+	if err != nil {
+		return nil, false
+	}
+	total, err := strconv.Atoi(string(parts[2]))
+	if err != nil || total <= 0 || index < 0 || index >= total {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(string(parts[3]))
+	if err != nil {
+		return nil, false
+	}
+	if total == 1 {
+		return data, true
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	now := time.Now()
+	for id, message := range a.messages {
+		if now.Sub(message.firstSeen) > chunkTimeout {
+			delete(a.messages, id)
+		}
+	}
+	message, ok := a.messages[id]
+	if !ok {
+		message = &chunkedMessage{
+			firstSeen: now,
+			chunks:    make([][]byte, total),
+		}
+		a.messages[id] = message
+	}
+	if len(message.chunks) != total || message.chunks[index] != nil {
+		return nil, false
+	}
+	message.chunks[index] = data
+	message.received++
+	if message.received < total {
+		return nil, false
+	}
+	delete(a.messages, id)
+	return bytes.Join(message.chunks, nil), true
+}
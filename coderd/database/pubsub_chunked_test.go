@@ -0,0 +1,63 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPubsubChunked(t *testing.T) {
+	t.Parallel()
+
+	const maxMessageSize = 256
+	cases := []struct {
+		name    string
+		message []byte
+	}{
+		{name: "Small", message: []byte("testing")},
+		{name: "Large", message: bytes.Repeat([]byte("0123456789"), 100)},
+		{name: "Binary", message: bytes.Repeat([]byte{0, 0xff, '|'}, 500)},
+		{name: "LooksLikeChunk", message: []byte("\x1echunk|not|a|chunk")},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+			defer cancel()
+
+			underlying := database.NewPubsubInMemory()
+			pubsub := database.NewPubsubChunked(underlying, maxMessageSize)
+
+			// No message on the underlying pubsub may be too large.
+			cancelRaw, err := underlying.Subscribe("test", func(_ context.Context, message []byte) {
+				if len(message) > maxMessageSize {
+					t.Errorf("message of %d bytes exceeds the maximum", len(message))
+				}
+			})
+			require.NoError(t, err)
+			defer cancelRaw()
+
+			messageChannel := make(chan []byte, 1)
+			cancelSub, err := pubsub.Subscribe("test", func(_ context.Context, message []byte) {
+				messageChannel <- message
+			})
+			require.NoError(t, err)
+			defer cancelSub()
+
+			err = pubsub.Publish("test", c.message)
+			require.NoError(t, err)
+			select {
+			case message := <-messageChannel:
+				require.Equal(t, c.message, message)
+			case <-ctx.Done():
+				t.Fatal("message never arrived")
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsPubsub records the messages published and received through a
+// Pubsub.
+type metricsPubsub struct {
+	Pubsub
+
+	publishes     *prometheus.CounterVec
+	publishBytes  prometheus.Counter
+	received      prometheus.Counter
+	receivedBytes prometheus.Counter
+	subscribers   prometheus.Gauge
+}
+
+// NewPubsubWithMetrics wraps a Pubsub to record its deliveries with the
+// registerer. Backend is the name of the underlying implementation, e.g.
+// "postgres".
+func NewPubsubWithMetrics(pubsub Pubsub, registerer prometheus.Registerer, backend string) Pubsub {
+	auto := promauto.With(prometheus.WrapRegistererWith(prometheus.Labels{"backend": backend}, registerer))
+	return &metricsPubsub{
+		Pubsub: pubsub,
+		publishes: auto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "pubsub",
+			Name:      "publishes_total",
+			Help:      "The number of messages published, by whether publishing succeeded.",
+		}, []string{"success"}),
+		publishBytes: auto.NewCounter(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "pubsub",
+			Name:      "published_bytes_total",
+			Help:      "The size of the messages published successfully.",
+		}),
+		received: auto.NewCounter(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "pubsub",
+			Name:      "messages_received_total",
+			Help:      "The number of messages delivered to subscribers.",
+		}),
+		receivedBytes: auto.NewCounter(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "pubsub",
+			Name:      "received_bytes_total",
+			Help:      "The size of the messages delivered to subscribers.",
+		}),
+		subscribers: auto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "pubsub",
+			Name:      "subscribers",
+			Help:      "The number of active subscriptions.",
+		}),
+	}
+}
+
+func (m *metricsPubsub) Publish(event string, message []byte) error {
+	err := m.Pubsub.Publish(event, message)
+	if err != nil {
+		m.publishes.WithLabelValues("false").Inc()
+		return err
+	}
+	m.publishes.WithLabelValues("true").Inc()
+	m.publishBytes.Add(float64(len(message)))
+	return nil
+}
+
+func (m *metricsPubsub) Subscribe(event string, listener Listener) (cancel func(), err error) {
+	cancelSubscribe, err := m.Pubsub.Subscribe(event, func(ctx context.Context, message []byte) {
+		m.received.Inc()
+		m.receivedBytes.Add(float64(len(message)))
+		listener(ctx, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.subscribers.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.subscribers.Dec()
+			cancelSubscribe()
+		})
+	}, nil
+}
@@ -0,0 +1,51 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+func TestPubsubWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	pubsub := database.NewPubsubWithMetrics(database.NewPubsubInMemory(), registry, "memory")
+
+	received := make(chan struct{})
+	cancel, err := pubsub.Subscribe("test", func(_ context.Context, _ []byte) {
+		close(received)
+	})
+	require.NoError(t, err)
+	err = pubsub.Publish("test", []byte("testing"))
+	require.NoError(t, err)
+	<-received
+	cancel()
+
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range metrics {
+		for _, metric := range family.GetMetric() {
+			require.Equal(t, "backend", metric.GetLabel()[0].GetName())
+			require.Equal(t, "memory", metric.GetLabel()[0].GetValue())
+			switch {
+			case metric.GetCounter() != nil:
+				values[family.GetName()] += metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				values[family.GetName()] += metric.GetGauge().GetValue()
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{
+		"coderd_pubsub_publishes_total":         1,
+		"coderd_pubsub_published_bytes_total":   7,
+		"coderd_pubsub_messages_received_total": 1,
+		"coderd_pubsub_received_bytes_total":    7,
+		"coderd_pubsub_subscribers":             0,
+	}, values)
+}
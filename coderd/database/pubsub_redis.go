@@ -0,0 +1,453 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// redisTimeout limits how long commands, and the confirmations of
+// subscriptions, may take.
+const redisTimeout = 10 * time.Second
+
+// redisPubsub is a Pubsub implementation for message brokers that speak the
+// Redis protocol, like Redis, KeyDB and Dragonfly. Unlike PostgreSQL NOTIFY,
+// they don't limit the size of messages.
+type redisPubsub struct {
+	address   string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeWait sync.WaitGroup
+
+	publishMut  sync.Mutex
+	publishConn *redisConn
+
+	mut       sync.Mutex
+	listeners map[string]map[uuid.UUID]Listener
+	// subscribeConn is the connection subscribed to the channels of the
+	// listeners. It's nil while reconnecting.
+	subscribeConn *redisConn
+	// subscribing maps channels to a channel that's closed when the server
+	// confirms the subscription.
+	subscribing map[string]chan struct{}
+}
+
+// NewPubsubRedis creates a new Pubsub implementation using a server that
+// speaks the Redis protocol, e.g. "redis://:password@localhost:6379". Use the
+// "rediss" scheme to connect with TLS.
+func NewPubsubRedis(ctx context.Context, rawURL string) (Pubsub, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, xerrors.Errorf("parse redis url: %w", err)
+	}
+	ps := &redisPubsub{
+		address:     parsed.Host,
+		listeners:   make(map[string]map[uuid.UUID]Listener),
+		subscribing: make(map[string]chan struct{}),
+	}
+	switch parsed.Scheme {
+	case "redis":
+	case "rediss":
+		ps.tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: parsed.Hostname(),
+		}
+	default:
+		return nil, xerrors.Errorf("unsupported redis url scheme %q", parsed.Scheme)
+	}
+	if parsed.Port() == "" {
+		ps.address = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		password, ok := parsed.User.Password()
+		if ok {
+			ps.username = parsed.User.Username()
+			ps.password = password
+		} else {
+			// "redis://password@host" is common shorthand.
+			ps.password = parsed.User.Username()
+		}
+	}
+
+	// Fail early if the server can't be reached.
+	ps.publishConn, err = ps.dial(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("connect to redis: %w", err)
+	}
+	err = ps.publishConn.SetReadDeadline(time.Now().Add(redisTimeout))
+	if err == nil {
+		_, err = ps.publishConn.do("PING")
+	}
+	if err != nil {
+		_ = ps.publishConn.Close()
+		return nil, xerrors.Errorf("ping redis: %w", err)
+	}
+	subscribeConn, err := ps.dial(ctx)
+	if err != nil {
+		_ = ps.publishConn.Close()
+		return nil, xerrors.Errorf("connect to redis: %w", err)
+	}
+
+	ps.ctx, ps.cancel = context.WithCancel(context.Background())
+	ps.subscribeConn = subscribeConn
+	ps.closeWait.Add(1)
+	go ps.listen(subscribeConn)
+	return ps, nil
+}
+
+// Subscribe returns once the server confirmed the subscription, so messages
+// published afterwards are received.
+func (p *redisPubsub) Subscribe(event string, listener Listener) (cancel func(), err error) {
+	p.mut.Lock()
+	eventListeners, ok := p.listeners[event]
+	if !ok {
+		eventListeners = map[uuid.UUID]Listener{}
+		p.listeners[event] = eventListeners
+		p.subscribing[event] = make(chan struct{})
+		if p.subscribeConn != nil {
+			// The confirmation is read by the listen loop. If writing fails,
+			// the loop reconnects and subscribes again.
+			_ = p.subscribeConn.write("SUBSCRIBE", event)
+		}
+	}
+	subscribed := p.subscribing[event]
+
+	var id uuid.UUID
+	for {
+		id = uuid.New()
+		if _, ok = eventListeners[id]; !ok {
+			break
+		}
+	}
+	eventListeners[id] = listener
+	p.mut.Unlock()
+
+	cancel = func() {
+		p.mut.Lock()
+		defer p.mut.Unlock()
+		listeners := p.listeners[event]
+		delete(listeners, id)
+		if len(listeners) == 0 {
+			delete(p.listeners, event)
+			delete(p.subscribing, event)
+			if p.subscribeConn != nil {
+				_ = p.subscribeConn.write("UNSUBSCRIBE", event)
+			}
+		}
+	}
+	if subscribed == nil {
+		return cancel, nil
+	}
+	timer := time.NewTimer(redisTimeout)
+	defer timer.Stop()
+	select {
+	case <-subscribed:
+		return cancel, nil
+	case <-timer.C:
+		cancel()
+		return nil, xerrors.Errorf("subscribe to %q: timed out waiting for confirmation", event)
+	case <-p.ctx.Done():
+		cancel()
+		return nil, xerrors.Errorf("subscribe to %q: %w", event, p.ctx.Err())
+	}
+}
+
+func (p *redisPubsub) Publish(event string, message []byte) error {
+	p.publishMut.Lock()
+	defer p.publishMut.Unlock()
+
+	// Retry once, because the connection may have been closed while it was
+	// idle.
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.publishConn == nil {
+			p.publishConn, err = p.dial(p.ctx)
+			if err != nil {
+				return xerrors.Errorf("connect to redis: %w", err)
+			}
+		}
+		// A server that stopped responding would block every publisher.
+		err = p.publishConn.SetReadDeadline(time.Now().Add(redisTimeout))
+		if err == nil {
+			_, err = p.publishConn.do("PUBLISH", event, string(message))
+		}
+		if err == nil {
+			return nil
+		}
+		var redisErr redisError
+		if errors.As(err, &redisErr) {
+			return xerrors.Errorf("publish: %w", err)
+		}
+		_ = p.publishConn.Close()
+		p.publishConn = nil
+	}
+	return xerrors.Errorf("publish: %w", err)
+}
+
+// Close closes the pubsub instance.
+func (p *redisPubsub) Close() error {
+	p.cancel()
+	p.mut.Lock()
+	if p.subscribeConn != nil {
+		_ = p.subscribeConn.Close()
+	}
+	p.mut.Unlock()
+	p.closeWait.Wait()
+
+	p.publishMut.Lock()
+	defer p.publishMut.Unlock()
+	if p.publishConn != nil {
+		_ = p.publishConn.Close()
+		p.publishConn = nil
+	}
+	return nil
+}
+
+// listen receives messages on the subscription connection, and reconnects
+// when it's lost. Messages published while reconnecting are lost.
+func (p *redisPubsub) listen(conn *redisConn) {
+	defer p.closeWait.Done()
+	for {
+		_ = p.receive(conn)
+		p.mut.Lock()
+		p.subscribeConn = nil
+		p.mut.Unlock()
+		_ = conn.Close()
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		for {
+			timer := time.NewTimer(time.Second)
+			select {
+			case <-p.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			conn, err = p.dial(p.ctx)
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// receive subscribes the connection to the channels of the listeners, and
+// dispatches messages until the connection fails.
+func (p *redisPubsub) receive(conn *redisConn) error {
+	p.mut.Lock()
+	if p.ctx.Err() != nil {
+		p.mut.Unlock()
+		return p.ctx.Err()
+	}
+	p.subscribeConn = conn
+	if len(p.listeners) > 0 {
+		args := make([]string, 0, len(p.listeners)+1)
+		args = append(args, "SUBSCRIBE")
+		for event := range p.listeners {
+			args = append(args, event)
+		}
+		err := conn.write(args...)
+		if err != nil {
+			p.mut.Unlock()
+			return err
+		}
+	}
+	p.mut.Unlock()
+
+	for {
+		reply, err := conn.read()
+		if err != nil {
+			return err
+		}
+		// Pushed messages are ["message", <channel>, <payload>], and
+		// subscriptions are confirmed with ["subscribe", <channel>, <count>].
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		kind, _ := parts[0].([]byte)
+		channel, _ := parts[1].([]byte)
+		if string(kind) == "subscribe" {
+			p.mut.Lock()
+			subscribed, ok := p.subscribing[string(channel)]
+			if ok {
+				close(subscribed)
+				delete(p.subscribing, string(channel))
+			}
+			p.mut.Unlock()
+			continue
+		}
+		if string(kind) != "message" {
+			continue
+		}
+		payload, _ := parts[2].([]byte)
+		p.mut.Lock()
+		for _, listener := range p.listeners[string(channel)] {
+			go listener(p.ctx, payload)
+		}
+		p.mut.Unlock()
+	}
+}
+
+func (p *redisPubsub) dial(ctx context.Context) (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var (
+		netConn net.Conn
+		err     error
+	)
+	if p.tlsConfig != nil {
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: p.tlsConfig}).DialContext(ctx, "tcp", p.address)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", p.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{
+		Conn:   netConn,
+		reader: bufio.NewReader(netConn),
+	}
+	if p.password == "" {
+		return conn, nil
+	}
+	err = conn.SetReadDeadline(time.Now().Add(redisTimeout))
+	if err == nil {
+		if p.username != "" {
+			_, err = conn.do("AUTH", p.username, p.password)
+		} else {
+			_, err = conn.do("AUTH", p.password)
+		}
+	}
+	if err == nil {
+		// The subscription connection waits for messages indefinitely.
+		err = conn.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("authenticate: %w", err)
+	}
+	return conn, nil
+}
+
+// redisError is an error reply of the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// redisConn speaks RESP, the Redis serialization protocol.
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do sends a command and reads its reply.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	err := c.write(args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// write sends a command as an array of bulk strings.
+func (c *redisConn) write(args ...string) error {
+	err := c.SetWriteDeadline(time.Now().Add(redisTimeout))
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err = c.Write(buf)
+	return err
+}
+
+// read reads a reply. Simple strings are returned as strings, bulk strings
+// as []byte, integers as int64 and arrays as []interface{}. Error replies are
+// returned as redisError.
+func (c *redisConn) read() (interface{}, error) {
+	return readRESP(c.reader)
+}
+
+func readRESP(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, xerrors.Errorf("malformed reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return nil, redisError(value)
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, xerrors.Errorf("malformed bulk string size %q", value)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, xerrors.Errorf("malformed array size %q", value)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRESP(reader)
+			if err != nil {
+				var redisErr redisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+				item = redisErr
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, xerrors.Errorf("unknown reply type %q", kind)
+	}
+}
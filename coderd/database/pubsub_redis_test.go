@@ -0,0 +1,93 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/redistest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPubsubRedis(t *testing.T) {
+	t.Parallel()
+
+	t.Run("PublishSubscribe", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		server := redistest.New(t, "hunter2")
+		pubsub, err := database.NewPubsubRedis(ctx, server.URL())
+		require.NoError(t, err)
+		defer pubsub.Close()
+
+		messageChannel := make(chan []byte, 1)
+		cancelSub, err := pubsub.Subscribe("test", func(ctx context.Context, message []byte) {
+			messageChannel <- message
+		})
+		require.NoError(t, err)
+		defer cancelSub()
+
+		// Subscribe waits for the server to confirm the subscription, so the
+		// first message is received. Messages aren't limited in size like
+		// PostgreSQL NOTIFY.
+		data := bytes.Repeat([]byte("a"), 64*1024)
+		err = pubsub.Publish("test", data)
+		require.NoError(t, err)
+		select {
+		case message := <-messageChannel:
+			require.Equal(t, data, message)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for message")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		server := redistest.New(t, "hunter2")
+		_, err := database.NewPubsubRedis(ctx, "redis://:wrong@"+server.URL()[len("redis://:hunter2@"):])
+		require.ErrorContains(t, err, "WRONGPASS")
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		server := redistest.New(t, "")
+		pubsub, err := database.NewPubsubRedis(ctx, server.URL())
+		require.NoError(t, err)
+		defer pubsub.Close()
+
+		messageChannel := make(chan []byte, 1)
+		cancelSub, err := pubsub.Subscribe("test", func(ctx context.Context, message []byte) {
+			messageChannel <- message
+		})
+		require.NoError(t, err)
+		defer cancelSub()
+
+		server.DropConnections()
+
+		// Messages published while reconnecting are lost, so publish until
+		// one arrives.
+		require.Eventually(t, func() bool {
+			err := pubsub.Publish("test", []byte("testing"))
+			require.NoError(t, err)
+			select {
+			case message := <-messageChannel:
+				require.Equal(t, "testing", string(message))
+				return true
+			case <-time.After(testutil.IntervalFast):
+				return false
+			}
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+}
@@ -19,16 +19,41 @@ type sqlcQuerier interface {
 	// multiple provisioners from acquiring the same jobs. See:
 	// https://www.postgresql.org/docs/9.5/sql-select.html#SQL-FOR-UPDATE-SHARE
 	AcquireProvisionerJob(ctx context.Context, arg AcquireProvisionerJobParams) (ProvisionerJob, error)
+	// Claims the oldest incomplete batch whose replica stopped heartbeating, so
+	// it can be resumed. SKIP LOCKED keeps replicas from claiming the same batch.
+	AcquireStaleWorkspaceBatch(ctx context.Context, arg AcquireStaleWorkspaceBatchParams) (WorkspaceBatch, error)
+	// Deletes the invitation and returns it, so only one request can use it.
+	ConsumeUserInvitationByID(ctx context.Context, id string) (UserInvitation, error)
+	// Deletes the password reset and returns it, so only one request can use it.
+	ConsumeUserPasswordResetByID(ctx context.Context, id string) (UserPasswordReset, error)
+	ConsumeUserTwoFactorRecoveryCode(ctx context.Context, arg ConsumeUserTwoFactorRecoveryCodeParams) (UserTwoFactor, error)
 	DeleteAPIKeyByID(ctx context.Context, id string) error
 	DeleteAPIKeysByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteDERPNodeByName(ctx context.Context, name string) error
+	// Deletes the region along with its nodes.
+	DeleteDERPRegionByID(ctx context.Context, id int32) error
 	DeleteGitSSHKey(ctx context.Context, userID uuid.UUID) error
 	DeleteGroupByID(ctx context.Context, id uuid.UUID) error
 	DeleteGroupMember(ctx context.Context, userID uuid.UUID) error
+	DeleteGroupMemberFromGroup(ctx context.Context, arg DeleteGroupMemberFromGroupParams) error
 	DeleteLicense(ctx context.Context, id int32) (int32, error)
 	DeleteOldAgentStats(ctx context.Context) error
+	DeleteOldWorkspaceBatches(ctx context.Context, completedBefore time.Time) error
 	DeleteParameterValueByID(ctx context.Context, id uuid.UUID) error
 	DeleteReplicasUpdatedBefore(ctx context.Context, updatedAt time.Time) error
+	DeleteSessionsByUserID(ctx context.Context, arg DeleteSessionsByUserIDParams) error
+	DeleteTailnetAgent(ctx context.Context, arg DeleteTailnetAgentParams) error
+	DeleteTailnetClient(ctx context.Context, arg DeleteTailnetClientParams) error
+	DeleteTailnetCoordinator(ctx context.Context, id uuid.UUID) error
+	DeleteTailnetCoordinatorsHeartbeatBefore(ctx context.Context, heartbeatAt time.Time) error
+	DeleteUserInvitationByID(ctx context.Context, id string) error
+	DeleteUserPasswordResetsByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteUserTwoFactorByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteWorkspaceScheduleSkipDatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error
+	DeleteWorkspaceScheduleWindowsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error
+	DeleteWorkspaceTransferByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error
 	GetAPIKeyByID(ctx context.Context, id string) (APIKey, error)
+	GetAPIKeys(ctx context.Context, arg GetAPIKeysParams) ([]APIKey, error)
 	GetAPIKeysByLoginType(ctx context.Context, loginType LoginType) ([]APIKey, error)
 	GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.Time) ([]APIKey, error)
 	GetActiveUserCount(ctx context.Context) (int64, error)
@@ -41,6 +66,10 @@ type sqlcQuerier interface {
 	// are included.
 	GetAuthorizationUserRoles(ctx context.Context, userID uuid.UUID) (GetAuthorizationUserRolesRow, error)
 	GetDERPMeshKey(ctx context.Context) (string, error)
+	GetDERPNodeByName(ctx context.Context, name string) (DERPNode, error)
+	GetDERPNodes(ctx context.Context) ([]DERPNode, error)
+	GetDERPRegionByID(ctx context.Context, id int32) (DERPRegion, error)
+	GetDERPRegions(ctx context.Context) ([]DERPRegion, error)
 	GetDeploymentID(ctx context.Context) (string, error)
 	GetFileByHashAndCreator(ctx context.Context, arg GetFileByHashAndCreatorParams) (File, error)
 	GetFileByID(ctx context.Context, id uuid.UUID) (File, error)
@@ -53,9 +82,11 @@ type sqlcQuerier interface {
 	GetGroupsByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]Group, error)
 	GetLatestAgentStat(ctx context.Context, agentID uuid.UUID) (AgentStat, error)
 	GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceBuild, error)
+	GetLatestWorkspaceBuildByWorkspaceIDAndReason(ctx context.Context, arg GetLatestWorkspaceBuildByWorkspaceIDAndReasonParams) (WorkspaceBuild, error)
 	GetLatestWorkspaceBuilds(ctx context.Context) ([]WorkspaceBuild, error)
 	GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceBuild, error)
 	GetLicenses(ctx context.Context) ([]License, error)
+	GetNotificationsByUserID(ctx context.Context, arg GetNotificationsByUserIDParams) ([]Notification, error)
 	GetOrganizationByID(ctx context.Context, id uuid.UUID) (Organization, error)
 	GetOrganizationByName(ctx context.Context, name string) (Organization, error)
 	GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error)
@@ -73,6 +104,16 @@ type sqlcQuerier interface {
 	GetProvisionerJobsCreatedAfter(ctx context.Context, createdAt time.Time) ([]ProvisionerJob, error)
 	GetProvisionerLogsByIDBetween(ctx context.Context, arg GetProvisionerLogsByIDBetweenParams) ([]ProvisionerJobLog, error)
 	GetReplicasUpdatedAfter(ctx context.Context, updatedAt time.Time) ([]Replica, error)
+	// Sessions are the unexpired API keys created by logging in, as opposed to
+	// tokens.
+	GetSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	// Returns the agent on every coordinator it's connected to, most recently
+	// updated first.
+	GetTailnetAgents(ctx context.Context, id uuid.UUID) ([]TailnetAgent, error)
+	// Returns the agents on every coordinator they're connected to, most recently
+	// updated first.
+	GetTailnetAgentsByIDs(ctx context.Context, ids []uuid.UUID) ([]TailnetAgent, error)
+	GetTailnetClientsForAgent(ctx context.Context, agentID uuid.UUID) ([]TailnetClient, error)
 	GetTemplateAverageBuildTime(ctx context.Context, arg GetTemplateAverageBuildTimeParams) (GetTemplateAverageBuildTimeRow, error)
 	GetTemplateByID(ctx context.Context, id uuid.UUID) (Template, error)
 	GetTemplateByOrganizationAndName(ctx context.Context, arg GetTemplateByOrganizationAndNameParams) (Template, error)
@@ -89,8 +130,13 @@ type sqlcQuerier interface {
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserCount(ctx context.Context) (int64, error)
 	GetUserGroups(ctx context.Context, userID uuid.UUID) ([]Group, error)
+	GetUserInvitationByID(ctx context.Context, id string) (UserInvitation, error)
+	GetUserInvitations(ctx context.Context) ([]UserInvitation, error)
 	GetUserLinkByLinkedID(ctx context.Context, linkedID string) (UserLink, error)
 	GetUserLinkByUserIDLoginType(ctx context.Context, arg GetUserLinkByUserIDLoginTypeParams) (UserLink, error)
+	GetUserNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]UserNotificationPreference, error)
+	GetUserPasswordResetByID(ctx context.Context, id string) (UserPasswordReset, error)
+	GetUserTwoFactorByUserID(ctx context.Context, userID uuid.UUID) (UserTwoFactor, error)
 	GetUsers(ctx context.Context, arg GetUsersParams) ([]User, error)
 	// This shouldn't check for deleted, because it's frequently used
 	// to look up references to actions. eg. a user could build a workspace
@@ -105,9 +151,13 @@ type sqlcQuerier interface {
 	GetWorkspaceAppsByAgentID(ctx context.Context, agentID uuid.UUID) ([]WorkspaceApp, error)
 	GetWorkspaceAppsByAgentIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceApp, error)
 	GetWorkspaceAppsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceApp, error)
+	GetWorkspaceBatchByID(ctx context.Context, id uuid.UUID) (WorkspaceBatch, error)
+	GetWorkspaceBatchItemsByBatchID(ctx context.Context, batchID uuid.UUID) ([]WorkspaceBatchItem, error)
 	GetWorkspaceBuildByID(ctx context.Context, id uuid.UUID) (WorkspaceBuild, error)
 	GetWorkspaceBuildByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceBuild, error)
 	GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx context.Context, arg GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams) (WorkspaceBuild, error)
+	GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error)
+	GetWorkspaceBuildRecoveryByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (WorkspaceBuildRecovery, error)
 	GetWorkspaceBuildsByWorkspaceID(ctx context.Context, arg GetWorkspaceBuildsByWorkspaceIDParams) ([]WorkspaceBuild, error)
 	GetWorkspaceBuildsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceBuild, error)
 	GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Workspace, error)
@@ -121,6 +171,10 @@ type sqlcQuerier interface {
 	GetWorkspaceResourcesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceResource, error)
 	GetWorkspaceResourcesByJobIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResource, error)
 	GetWorkspaceResourcesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResource, error)
+	GetWorkspaceScheduleSkipDatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceScheduleSkipDate, error)
+	GetWorkspaceScheduleWindowsByWorkspaceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceScheduleWindow, error)
+	GetWorkspaceTransferByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceTransfer, error)
+	GetWorkspaceTransfersByToUserID(ctx context.Context, toUserID uuid.UUID) ([]WorkspaceTransfer, error)
 	GetWorkspaces(ctx context.Context, arg GetWorkspacesParams) ([]Workspace, error)
 	InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (APIKey, error)
 	InsertAgentStat(ctx context.Context, arg InsertAgentStatParams) (AgentStat, error)
@@ -130,6 +184,8 @@ type sqlcQuerier interface {
 	InsertAllUsersGroup(ctx context.Context, organizationID uuid.UUID) (Group, error)
 	InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) (AuditLog, error)
 	InsertDERPMeshKey(ctx context.Context, value string) error
+	InsertDERPNode(ctx context.Context, arg InsertDERPNodeParams) (DERPNode, error)
+	InsertDERPRegion(ctx context.Context, arg InsertDERPRegionParams) (DERPRegion, error)
 	InsertDeploymentID(ctx context.Context, value string) error
 	InsertFile(ctx context.Context, arg InsertFileParams) (File, error)
 	InsertGitAuthLink(ctx context.Context, arg InsertGitAuthLinkParams) (GitAuthLink, error)
@@ -137,6 +193,7 @@ type sqlcQuerier interface {
 	InsertGroup(ctx context.Context, arg InsertGroupParams) (Group, error)
 	InsertGroupMember(ctx context.Context, arg InsertGroupMemberParams) error
 	InsertLicense(ctx context.Context, arg InsertLicenseParams) (License, error)
+	InsertNotification(ctx context.Context, arg InsertNotificationParams) (Notification, error)
 	InsertOrganization(ctx context.Context, arg InsertOrganizationParams) (Organization, error)
 	InsertOrganizationMember(ctx context.Context, arg InsertOrganizationMemberParams) (OrganizationMember, error)
 	InsertParameterSchema(ctx context.Context, arg InsertParameterSchemaParams) (ParameterSchema, error)
@@ -148,25 +205,39 @@ type sqlcQuerier interface {
 	InsertTemplate(ctx context.Context, arg InsertTemplateParams) (Template, error)
 	InsertTemplateVersion(ctx context.Context, arg InsertTemplateVersionParams) (TemplateVersion, error)
 	InsertUser(ctx context.Context, arg InsertUserParams) (User, error)
+	InsertUserInvitation(ctx context.Context, arg InsertUserInvitationParams) (UserInvitation, error)
 	InsertUserLink(ctx context.Context, arg InsertUserLinkParams) (UserLink, error)
+	InsertUserPasswordReset(ctx context.Context, arg InsertUserPasswordResetParams) (UserPasswordReset, error)
+	InsertUserTwoFactor(ctx context.Context, arg InsertUserTwoFactorParams) (UserTwoFactor, error)
 	InsertWorkspace(ctx context.Context, arg InsertWorkspaceParams) (Workspace, error)
 	InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspaceAgentParams) (WorkspaceAgent, error)
 	InsertWorkspaceApp(ctx context.Context, arg InsertWorkspaceAppParams) (WorkspaceApp, error)
+	InsertWorkspaceBatch(ctx context.Context, arg InsertWorkspaceBatchParams) (WorkspaceBatch, error)
+	InsertWorkspaceBatchItem(ctx context.Context, arg InsertWorkspaceBatchItemParams) (WorkspaceBatchItem, error)
 	InsertWorkspaceBuild(ctx context.Context, arg InsertWorkspaceBuildParams) (WorkspaceBuild, error)
+	InsertWorkspaceBuildParameters(ctx context.Context, arg InsertWorkspaceBuildParametersParams) error
+	InsertWorkspaceBuildRecovery(ctx context.Context, arg InsertWorkspaceBuildRecoveryParams) error
 	InsertWorkspaceResource(ctx context.Context, arg InsertWorkspaceResourceParams) (WorkspaceResource, error)
 	InsertWorkspaceResourceMetadata(ctx context.Context, arg InsertWorkspaceResourceMetadataParams) (WorkspaceResourceMetadatum, error)
+	InsertWorkspaceScheduleSkipDate(ctx context.Context, arg InsertWorkspaceScheduleSkipDateParams) (WorkspaceScheduleSkipDate, error)
+	InsertWorkspaceScheduleWindow(ctx context.Context, arg InsertWorkspaceScheduleWindowParams) (WorkspaceScheduleWindow, error)
 	ParameterValue(ctx context.Context, id uuid.UUID) (ParameterValue, error)
 	ParameterValues(ctx context.Context, arg ParameterValuesParams) ([]ParameterValue, error)
+	RegenerateWorkspaceAgentAuthTokensByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error
 	UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDParams) error
+	UpdateDERPNodeByName(ctx context.Context, arg UpdateDERPNodeByNameParams) (DERPNode, error)
+	UpdateDERPRegionByID(ctx context.Context, arg UpdateDERPRegionByIDParams) (DERPRegion, error)
 	UpdateGitAuthLink(ctx context.Context, arg UpdateGitAuthLinkParams) error
 	UpdateGitSSHKey(ctx context.Context, arg UpdateGitSSHKeyParams) (GitSSHKey, error)
 	UpdateGroupByID(ctx context.Context, arg UpdateGroupByIDParams) (Group, error)
 	UpdateMemberRoles(ctx context.Context, arg UpdateMemberRolesParams) (OrganizationMember, error)
+	UpdateNotificationsReadAtByUserID(ctx context.Context, arg UpdateNotificationsReadAtByUserIDParams) error
 	UpdateProvisionerDaemonByID(ctx context.Context, arg UpdateProvisionerDaemonByIDParams) error
 	UpdateProvisionerJobByID(ctx context.Context, arg UpdateProvisionerJobByIDParams) error
 	UpdateProvisionerJobWithCancelByID(ctx context.Context, arg UpdateProvisionerJobWithCancelByIDParams) error
 	UpdateProvisionerJobWithCompleteByID(ctx context.Context, arg UpdateProvisionerJobWithCompleteByIDParams) error
 	UpdateReplica(ctx context.Context, arg UpdateReplicaParams) (Replica, error)
+	UpdateTailnetCoordinatorHeartbeat(ctx context.Context, arg UpdateTailnetCoordinatorHeartbeatParams) (TailnetCoordinator, error)
 	UpdateTemplateACLByID(ctx context.Context, arg UpdateTemplateACLByIDParams) (Template, error)
 	UpdateTemplateActiveVersionByID(ctx context.Context, arg UpdateTemplateActiveVersionByIDParams) error
 	UpdateTemplateDeletedByID(ctx context.Context, arg UpdateTemplateDeletedByIDParams) error
@@ -181,15 +252,28 @@ type sqlcQuerier interface {
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
 	UpdateUserRoles(ctx context.Context, arg UpdateUserRolesParams) (User, error)
 	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error)
+	UpdateUserTwoFactorEnabled(ctx context.Context, arg UpdateUserTwoFactorEnabledParams) (UserTwoFactor, error)
+	UpdateUserTwoFactorLastTOTPStep(ctx context.Context, arg UpdateUserTwoFactorLastTOTPStepParams) (UserTwoFactor, error)
 	UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams) (Workspace, error)
 	UpdateWorkspaceAgentConnectionByID(ctx context.Context, arg UpdateWorkspaceAgentConnectionByIDParams) error
 	UpdateWorkspaceAgentVersionByID(ctx context.Context, arg UpdateWorkspaceAgentVersionByIDParams) error
 	UpdateWorkspaceAppHealthByID(ctx context.Context, arg UpdateWorkspaceAppHealthByIDParams) error
+	UpdateWorkspaceAutomaticUpdates(ctx context.Context, arg UpdateWorkspaceAutomaticUpdatesParams) error
 	UpdateWorkspaceAutostart(ctx context.Context, arg UpdateWorkspaceAutostartParams) error
+	UpdateWorkspaceBatchCompletedAt(ctx context.Context, arg UpdateWorkspaceBatchCompletedAtParams) error
+	UpdateWorkspaceBatchHeartbeat(ctx context.Context, arg UpdateWorkspaceBatchHeartbeatParams) error
+	UpdateWorkspaceBatchItem(ctx context.Context, arg UpdateWorkspaceBatchItemParams) error
 	UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWorkspaceBuildByIDParams) (WorkspaceBuild, error)
 	UpdateWorkspaceDeletedByID(ctx context.Context, arg UpdateWorkspaceDeletedByIDParams) error
+	UpdateWorkspaceDormantAt(ctx context.Context, arg UpdateWorkspaceDormantAtParams) error
 	UpdateWorkspaceLastUsedAt(ctx context.Context, arg UpdateWorkspaceLastUsedAtParams) error
+	UpdateWorkspaceOwner(ctx context.Context, arg UpdateWorkspaceOwnerParams) error
 	UpdateWorkspaceTTL(ctx context.Context, arg UpdateWorkspaceTTLParams) error
+	UpsertTailnetAgent(ctx context.Context, arg UpsertTailnetAgentParams) (TailnetAgent, error)
+	UpsertTailnetClient(ctx context.Context, arg UpsertTailnetClientParams) (TailnetClient, error)
+	UpsertTailnetCoordinator(ctx context.Context, arg UpsertTailnetCoordinatorParams) (TailnetCoordinator, error)
+	UpsertUserNotificationPreference(ctx context.Context, arg UpsertUserNotificationPreferenceParams) (UserNotificationPreference, error)
+	UpsertWorkspaceTransfer(ctx context.Context, arg UpsertWorkspaceTransferParams) (WorkspaceTransfer, error)
 }
 
 var _ sqlcQuerier = (*sqlQuerier)(nil)
@@ -157,9 +157,28 @@ func (q *sqlQuerier) DeleteAPIKeysByUserID(ctx context.Context, userID uuid.UUID
 	return err
 }
 
+const deleteSessionsByUserID = `-- name: DeleteSessionsByUserID :exec
+DELETE FROM
+	api_keys
+WHERE
+	user_id = $1
+	AND login_type != 'token'
+	AND id != $2
+`
+
+type DeleteSessionsByUserIDParams struct {
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	ExceptID string    `db:"except_id" json:"except_id"`
+}
+
+func (q *sqlQuerier) DeleteSessionsByUserID(ctx context.Context, arg DeleteSessionsByUserIDParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSessionsByUserID, arg.UserID, arg.ExceptID)
+	return err
+}
+
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
 SELECT
-	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent
 FROM
 	api_keys
 WHERE
@@ -183,12 +202,119 @@ func (q *sqlQuerier) GetAPIKeyByID(ctx context.Context, id string) (APIKey, erro
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		&i.UserAgent,
 	)
 	return i, err
 }
 
+const getAPIKeys = `-- name: GetAPIKeys :many
+SELECT
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent
+FROM
+	api_keys
+WHERE
+	-- Filter by user_id
+	CASE
+		WHEN $1 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			user_id = $1
+		ELSE true
+	END
+	-- Filter by login_type
+	AND CASE
+		WHEN cardinality($2 :: login_type[]) > 0 THEN
+			login_type = ANY($2 :: login_type[])
+		ELSE true
+	END
+	-- Filter by scope
+	AND CASE
+		WHEN cardinality($3 :: api_key_scope[]) > 0 THEN
+			scope = ANY($3 :: api_key_scope[])
+		ELSE true
+	END
+	-- Filter by last_used_before
+	AND CASE
+		WHEN $4 :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			last_used < $4
+		ELSE true
+	END
+	-- Filter by last_used_after
+	AND CASE
+		WHEN $5 :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			last_used > $5
+		ELSE true
+	END
+	-- Filter by expires_before
+	AND CASE
+		WHEN $6 :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			expires_at < $6
+		ELSE true
+	END
+	-- Filter by expires_after
+	AND CASE
+		WHEN $7 :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			expires_at > $7
+		ELSE true
+	END
+ORDER BY
+	(created_at, id) ASC
+`
+
+type GetAPIKeysParams struct {
+	UserID         uuid.UUID     `db:"user_id" json:"user_id"`
+	LoginType      []LoginType   `db:"login_type" json:"login_type"`
+	Scope          []APIKeyScope `db:"scope" json:"scope"`
+	LastUsedBefore time.Time     `db:"last_used_before" json:"last_used_before"`
+	LastUsedAfter  time.Time     `db:"last_used_after" json:"last_used_after"`
+	ExpiresBefore  time.Time     `db:"expires_before" json:"expires_before"`
+	ExpiresAfter   time.Time     `db:"expires_after" json:"expires_after"`
+}
+
+func (q *sqlQuerier) GetAPIKeys(ctx context.Context, arg GetAPIKeysParams) ([]APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeys,
+		arg.UserID,
+		pq.Array(arg.LoginType),
+		pq.Array(arg.Scope),
+		arg.LastUsedBefore,
+		arg.LastUsedAfter,
+		arg.ExpiresBefore,
+		arg.ExpiresAfter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedSecret,
+			&i.UserID,
+			&i.LastUsed,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LoginType,
+			&i.LifetimeSeconds,
+			&i.IPAddress,
+			&i.Scope,
+			&i.UserAgent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAPIKeysByLoginType = `-- name: GetAPIKeysByLoginType :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE login_type = $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent FROM api_keys WHERE login_type = $1
 `
 
 func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginType) ([]APIKey, error) {
@@ -212,6 +338,7 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			&i.UserAgent,
 		); err != nil {
 			return nil, err
 		}
@@ -227,7 +354,7 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 }
 
 const getAPIKeysLastUsedAfter = `-- name: GetAPIKeysLastUsedAfter :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE last_used > $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent FROM api_keys WHERE last_used > $1
 `
 
 func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.Time) ([]APIKey, error) {
@@ -251,6 +378,58 @@ func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			&i.UserAgent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSessionsByUserID = `-- name: GetSessionsByUserID :many
+SELECT
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent
+FROM
+	api_keys
+WHERE
+	user_id = $1
+	AND login_type != 'token'
+	AND expires_at > now()
+ORDER BY
+	last_used DESC
+`
+
+// Sessions are the unexpired API keys created by logging in, as opposed to
+// tokens.
+func (q *sqlQuerier) GetSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedSecret,
+			&i.UserID,
+			&i.LastUsed,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LoginType,
+			&i.LifetimeSeconds,
+			&i.IPAddress,
+			&i.Scope,
+			&i.UserAgent,
 		); err != nil {
 			return nil, err
 		}
@@ -278,7 +457,8 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		user_agent
 	)
 VALUES
 	($1,
@@ -287,7 +467,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE $2::bigint
 	 END
-	 , $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	 , $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, user_agent
 `
 
 type InsertAPIKeyParams struct {
@@ -302,6 +482,7 @@ type InsertAPIKeyParams struct {
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 	LoginType       LoginType   `db:"login_type" json:"login_type"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	UserAgent       string      `db:"user_agent" json:"user_agent"`
 }
 
 func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (APIKey, error) {
@@ -317,6 +498,7 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		arg.UpdatedAt,
 		arg.LoginType,
 		arg.Scope,
+		arg.UserAgent,
 	)
 	var i APIKey
 	err := row.Scan(
@@ -331,6 +513,7 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		&i.UserAgent,
 	)
 	return i, err
 }
@@ -341,7 +524,8 @@ UPDATE
 SET
 	last_used = $2,
 	expires_at = $3,
-	ip_address = $4
+	ip_address = $4,
+	user_agent = $5
 WHERE
 	id = $1
 `
@@ -351,6 +535,7 @@ type UpdateAPIKeyByIDParams struct {
 	LastUsed  time.Time   `db:"last_used" json:"last_used"`
 	ExpiresAt time.Time   `db:"expires_at" json:"expires_at"`
 	IPAddress pqtype.Inet `db:"ip_address" json:"ip_address"`
+	UserAgent string      `db:"user_agent" json:"user_agent"`
 }
 
 func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDParams) error {
@@ -359,6 +544,7 @@ func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDP
 		arg.LastUsed,
 		arg.ExpiresAt,
 		arg.IPAddress,
+		arg.UserAgent,
 	)
 	return err
 }
@@ -691,89 +877,421 @@ func (q *sqlQuerier) InsertAuditLog(ctx context.Context, arg InsertAuditLogParam
 	return i, err
 }
 
-const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
-SELECT
-	hash, created_at, created_by, mimetype, data, id
-FROM
-	files
-WHERE
-	hash = $1
-AND
-	created_by = $2
-LIMIT
-	1
+const deleteDERPNodeByName = `-- name: DeleteDERPNodeByName :exec
+DELETE FROM derp_nodes WHERE name = $1
 `
 
-type GetFileByHashAndCreatorParams struct {
-	Hash      string    `db:"hash" json:"hash"`
-	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+func (q *sqlQuerier) DeleteDERPNodeByName(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deleteDERPNodeByName, name)
+	return err
 }
 
-func (q *sqlQuerier) GetFileByHashAndCreator(ctx context.Context, arg GetFileByHashAndCreatorParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, getFileByHashAndCreator, arg.Hash, arg.CreatedBy)
-	var i File
+const deleteDERPRegionByID = `-- name: DeleteDERPRegionByID :exec
+DELETE FROM derp_regions WHERE id = $1
+`
+
+// Deletes the region along with its nodes.
+func (q *sqlQuerier) DeleteDERPRegionByID(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteDERPRegionByID, id)
+	return err
+}
+
+const getDERPNodeByName = `-- name: GetDERPNodeByName :one
+SELECT name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at FROM derp_nodes WHERE name = $1
+`
+
+func (q *sqlQuerier) GetDERPNodeByName(ctx context.Context, name string) (DERPNode, error) {
+	row := q.db.QueryRowContext(ctx, getDERPNodeByName, name)
+	var i DERPNode
 	err := row.Scan(
-		&i.Hash,
+		&i.Name,
+		&i.RegionID,
+		&i.HostName,
+		&i.IPv4,
+		&i.IPv6,
+		&i.DERPPort,
+		&i.STUNPort,
+		&i.STUNOnly,
+		&i.ForceHTTP,
+		&i.Disabled,
 		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.Mimetype,
-		&i.Data,
-		&i.ID,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getFileByID = `-- name: GetFileByID :one
-SELECT
-	hash, created_at, created_by, mimetype, data, id
-FROM
-	files
-WHERE
-	id = $1
-LIMIT
-	1
+const getDERPNodes = `-- name: GetDERPNodes :many
+SELECT name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at FROM derp_nodes ORDER BY region_id, name
 `
 
-func (q *sqlQuerier) GetFileByID(ctx context.Context, id uuid.UUID) (File, error) {
-	row := q.db.QueryRowContext(ctx, getFileByID, id)
-	var i File
+func (q *sqlQuerier) GetDERPNodes(ctx context.Context) ([]DERPNode, error) {
+	rows, err := q.db.QueryContext(ctx, getDERPNodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DERPNode
+	for rows.Next() {
+		var i DERPNode
+		if err := rows.Scan(
+			&i.Name,
+			&i.RegionID,
+			&i.HostName,
+			&i.IPv4,
+			&i.IPv6,
+			&i.DERPPort,
+			&i.STUNPort,
+			&i.STUNOnly,
+			&i.ForceHTTP,
+			&i.Disabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDERPRegionByID = `-- name: GetDERPRegionByID :one
+SELECT id, code, name, disabled, created_at, updated_at FROM derp_regions WHERE id = $1
+`
+
+func (q *sqlQuerier) GetDERPRegionByID(ctx context.Context, id int32) (DERPRegion, error) {
+	row := q.db.QueryRowContext(ctx, getDERPRegionByID, id)
+	var i DERPRegion
 	err := row.Scan(
-		&i.Hash,
-		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.Mimetype,
-		&i.Data,
 		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const insertFile = `-- name: InsertFile :one
+const getDERPRegions = `-- name: GetDERPRegions :many
+SELECT id, code, name, disabled, created_at, updated_at FROM derp_regions ORDER BY id
+`
+
+func (q *sqlQuerier) GetDERPRegions(ctx context.Context) ([]DERPRegion, error) {
+	rows, err := q.db.QueryContext(ctx, getDERPRegions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DERPRegion
+	for rows.Next() {
+		var i DERPRegion
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Disabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertDERPNode = `-- name: InsertDERPNode :one
 INSERT INTO
-	files (id, hash, created_at, created_by, mimetype, "data")
+	derp_nodes (name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at)
 VALUES
-	($1, $2, $3, $4, $5, $6) RETURNING hash, created_at, created_by, mimetype, data, id
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at
 `
 
-type InsertFileParams struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Hash      string    `db:"hash" json:"hash"`
+type InsertDERPNodeParams struct {
+	Name      string    `db:"name" json:"name"`
+	RegionID  int32     `db:"region_id" json:"region_id"`
+	HostName  string    `db:"host_name" json:"host_name"`
+	IPv4      string    `db:"ipv4" json:"ipv4"`
+	IPv6      string    `db:"ipv6" json:"ipv6"`
+	DERPPort  int32     `db:"derp_port" json:"derp_port"`
+	STUNPort  int32     `db:"stun_port" json:"stun_port"`
+	STUNOnly  bool      `db:"stun_only" json:"stun_only"`
+	ForceHTTP bool      `db:"force_http" json:"force_http"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
-	Mimetype  string    `db:"mimetype" json:"mimetype"`
-	Data      []byte    `db:"data" json:"data"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
-func (q *sqlQuerier) InsertFile(ctx context.Context, arg InsertFileParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, insertFile,
-		arg.ID,
-		arg.Hash,
+func (q *sqlQuerier) InsertDERPNode(ctx context.Context, arg InsertDERPNodeParams) (DERPNode, error) {
+	row := q.db.QueryRowContext(ctx, insertDERPNode,
+		arg.Name,
+		arg.RegionID,
+		arg.HostName,
+		arg.IPv4,
+		arg.IPv6,
+		arg.DERPPort,
+		arg.STUNPort,
+		arg.STUNOnly,
+		arg.ForceHTTP,
+		arg.Disabled,
 		arg.CreatedAt,
-		arg.CreatedBy,
-		arg.Mimetype,
-		arg.Data,
+		arg.UpdatedAt,
 	)
-	var i File
+	var i DERPNode
+	err := row.Scan(
+		&i.Name,
+		&i.RegionID,
+		&i.HostName,
+		&i.IPv4,
+		&i.IPv6,
+		&i.DERPPort,
+		&i.STUNPort,
+		&i.STUNOnly,
+		&i.ForceHTTP,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertDERPRegion = `-- name: InsertDERPRegion :one
+INSERT INTO
+	derp_regions (id, code, name, disabled, created_at, updated_at)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+RETURNING id, code, name, disabled, created_at, updated_at
+`
+
+type InsertDERPRegionParams struct {
+	ID        int32     `db:"id" json:"id"`
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertDERPRegion(ctx context.Context, arg InsertDERPRegionParams) (DERPRegion, error) {
+	row := q.db.QueryRowContext(ctx, insertDERPRegion,
+		arg.ID,
+		arg.Code,
+		arg.Name,
+		arg.Disabled,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i DERPRegion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateDERPNodeByName = `-- name: UpdateDERPNodeByName :one
+UPDATE
+	derp_nodes
+SET
+	host_name = $2,
+	ipv4 = $3,
+	ipv6 = $4,
+	derp_port = $5,
+	stun_port = $6,
+	stun_only = $7,
+	force_http = $8,
+	disabled = $9,
+	updated_at = $10
+WHERE
+	name = $1
+RETURNING name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at
+`
+
+type UpdateDERPNodeByNameParams struct {
+	Name      string    `db:"name" json:"name"`
+	HostName  string    `db:"host_name" json:"host_name"`
+	IPv4      string    `db:"ipv4" json:"ipv4"`
+	IPv6      string    `db:"ipv6" json:"ipv6"`
+	DERPPort  int32     `db:"derp_port" json:"derp_port"`
+	STUNPort  int32     `db:"stun_port" json:"stun_port"`
+	STUNOnly  bool      `db:"stun_only" json:"stun_only"`
+	ForceHTTP bool      `db:"force_http" json:"force_http"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateDERPNodeByName(ctx context.Context, arg UpdateDERPNodeByNameParams) (DERPNode, error) {
+	row := q.db.QueryRowContext(ctx, updateDERPNodeByName,
+		arg.Name,
+		arg.HostName,
+		arg.IPv4,
+		arg.IPv6,
+		arg.DERPPort,
+		arg.STUNPort,
+		arg.STUNOnly,
+		arg.ForceHTTP,
+		arg.Disabled,
+		arg.UpdatedAt,
+	)
+	var i DERPNode
+	err := row.Scan(
+		&i.Name,
+		&i.RegionID,
+		&i.HostName,
+		&i.IPv4,
+		&i.IPv6,
+		&i.DERPPort,
+		&i.STUNPort,
+		&i.STUNOnly,
+		&i.ForceHTTP,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateDERPRegionByID = `-- name: UpdateDERPRegionByID :one
+UPDATE
+	derp_regions
+SET
+	code = $2,
+	name = $3,
+	disabled = $4,
+	updated_at = $5
+WHERE
+	id = $1
+RETURNING id, code, name, disabled, created_at, updated_at
+`
+
+type UpdateDERPRegionByIDParams struct {
+	ID        int32     `db:"id" json:"id"`
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Disabled  bool      `db:"disabled" json:"disabled"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateDERPRegionByID(ctx context.Context, arg UpdateDERPRegionByIDParams) (DERPRegion, error) {
+	row := q.db.QueryRowContext(ctx, updateDERPRegionByID,
+		arg.ID,
+		arg.Code,
+		arg.Name,
+		arg.Disabled,
+		arg.UpdatedAt,
+	)
+	var i DERPRegion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
+SELECT
+	hash, created_at, created_by, mimetype, data, id
+FROM
+	files
+WHERE
+	hash = $1
+AND
+	created_by = $2
+LIMIT
+	1
+`
+
+type GetFileByHashAndCreatorParams struct {
+	Hash      string    `db:"hash" json:"hash"`
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+}
+
+func (q *sqlQuerier) GetFileByHashAndCreator(ctx context.Context, arg GetFileByHashAndCreatorParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileByHashAndCreator, arg.Hash, arg.CreatedBy)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.Data,
+		&i.ID,
+	)
+	return i, err
+}
+
+const getFileByID = `-- name: GetFileByID :one
+SELECT
+	hash, created_at, created_by, mimetype, data, id
+FROM
+	files
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetFileByID(ctx context.Context, id uuid.UUID) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileByID, id)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.Data,
+		&i.ID,
+	)
+	return i, err
+}
+
+const insertFile = `-- name: InsertFile :one
+INSERT INTO
+	files (id, hash, created_at, created_by, mimetype, "data")
+VALUES
+	($1, $2, $3, $4, $5, $6) RETURNING hash, created_at, created_by, mimetype, data, id
+`
+
+type InsertFileParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Hash      string    `db:"hash" json:"hash"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+	Mimetype  string    `db:"mimetype" json:"mimetype"`
+	Data      []byte    `db:"data" json:"data"`
+}
+
+func (q *sqlQuerier) InsertFile(ctx context.Context, arg InsertFileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, insertFile,
+		arg.ID,
+		arg.Hash,
+		arg.CreatedAt,
+		arg.CreatedBy,
+		arg.Mimetype,
+		arg.Data,
+	)
+	var i File
 	err := row.Scan(
 		&i.Hash,
 		&i.CreatedAt,
@@ -1028,6 +1546,24 @@ func (q *sqlQuerier) DeleteGroupMember(ctx context.Context, userID uuid.UUID) er
 	return err
 }
 
+const deleteGroupMemberFromGroup = `-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1 AND
+	group_id = $2
+`
+
+type DeleteGroupMemberFromGroupParams struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
+func (q *sqlQuerier) DeleteGroupMemberFromGroup(ctx context.Context, arg DeleteGroupMemberFromGroupParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupMemberFromGroup, arg.UserID, arg.GroupID)
+	return err
+}
+
 const getAllOrganizationMembers = `-- name: GetAllOrganizationMembers :many
 SELECT
 	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at
@@ -1481,32 +2017,49 @@ func (q *sqlQuerier) InsertLicense(ctx context.Context, arg InsertLicenseParams)
 	return i, err
 }
 
-const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
+const getNotificationsByUserID = `-- name: GetNotificationsByUserID :many
 SELECT
-    user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
+	id, user_id, workspace_id, kind, title, body, created_at, read_at
 FROM
-    organization_members
+	notifications
 WHERE
-    user_id = ANY($1 :: uuid [ ])
-GROUP BY
-    user_id
+	user_id = $1
+	AND CASE
+		WHEN $2 :: boolean THEN read_at IS NULL
+		ELSE true
+	END
+ORDER BY
+	created_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($3 :: int, 0)
 `
 
-type GetOrganizationIDsByMemberIDsRow struct {
-	UserID          uuid.UUID   `db:"user_id" json:"user_id"`
-	OrganizationIDs []uuid.UUID `db:"organization_IDs" json:"organization_IDs"`
+type GetNotificationsByUserIDParams struct {
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	Unread   bool      `db:"unread" json:"unread"`
+	LimitOpt int32     `db:"limit_opt" json:"limit_opt"`
 }
 
-func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getOrganizationIDsByMemberIDs, pq.Array(ids))
+func (q *sqlQuerier) GetNotificationsByUserID(ctx context.Context, arg GetNotificationsByUserIDParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationsByUserID, arg.UserID, arg.Unread, arg.LimitOpt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetOrganizationIDsByMemberIDsRow
+	var items []Notification
 	for rows.Next() {
-		var i GetOrganizationIDsByMemberIDsRow
-		if err := rows.Scan(&i.UserID, pq.Array(&i.OrganizationIDs)); err != nil {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.WorkspaceID,
+			&i.Kind,
+			&i.Title,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1520,60 +2073,30 @@ func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uu
 	return items, nil
 }
 
-const getOrganizationMemberByUserID = `-- name: GetOrganizationMemberByUserID :one
-SELECT
-	user_id, organization_id, created_at, updated_at, roles
-FROM
-	organization_members
-WHERE
-	organization_id = $1
-	AND user_id = $2
-LIMIT
-	1
-`
-
-type GetOrganizationMemberByUserIDParams struct {
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	UserID         uuid.UUID `db:"user_id" json:"user_id"`
-}
-
-func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetOrganizationMemberByUserIDParams) (OrganizationMember, error) {
-	row := q.db.QueryRowContext(ctx, getOrganizationMemberByUserID, arg.OrganizationID, arg.UserID)
-	var i OrganizationMember
-	err := row.Scan(
-		&i.UserID,
-		&i.OrganizationID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		pq.Array(&i.Roles),
-	)
-	return i, err
-}
-
-const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+const getUserNotificationPreferences = `-- name: GetUserNotificationPreferences :many
 SELECT
-	user_id, organization_id, created_at, updated_at, roles
+	user_id, kind, inbox, email, webhook
 FROM
-	organization_members
+	user_notification_preferences
 WHERE
-  user_id = $1
+	user_id = $1
 `
 
-func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
-	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+func (q *sqlQuerier) GetUserNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]UserNotificationPreference, error) {
+	rows, err := q.db.QueryContext(ctx, getUserNotificationPreferences, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []OrganizationMember
+	var items []UserNotificationPreference
 	for rows.Next() {
-		var i OrganizationMember
+		var i UserNotificationPreference
 		if err := rows.Scan(
 			&i.UserID,
-			&i.OrganizationID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			pq.Array(&i.Roles),
+			&i.Kind,
+			&i.Inbox,
+			&i.Email,
+			&i.Webhook,
 		); err != nil {
 			return nil, err
 		}
@@ -1588,22 +2111,251 @@ func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, use
 	return items, nil
 }
 
-const insertOrganizationMember = `-- name: InsertOrganizationMember :one
+const insertNotification = `-- name: InsertNotification :one
 INSERT INTO
-	organization_members (
-		organization_id,
+	notifications (
+		id,
 		user_id,
-		created_at,
-		updated_at,
-		roles
+		workspace_id,
+		kind,
+		title,
+		body,
+		created_at
 	)
 VALUES
-	($1, $2, $3, $4, $5) RETURNING user_id, organization_id, created_at, updated_at, roles
+	($1, $2, $3, $4, $5, $6, $7) RETURNING id, user_id, workspace_id, kind, title, body, created_at, read_at
 `
 
-type InsertOrganizationMemberParams struct {
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+type InsertNotificationParams struct {
+	ID          uuid.UUID        `db:"id" json:"id"`
+	UserID      uuid.UUID        `db:"user_id" json:"user_id"`
+	WorkspaceID uuid.NullUUID    `db:"workspace_id" json:"workspace_id"`
+	Kind        NotificationKind `db:"kind" json:"kind"`
+	Title       string           `db:"title" json:"title"`
+	Body        string           `db:"body" json:"body"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertNotification(ctx context.Context, arg InsertNotificationParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, insertNotification,
+		arg.ID,
+		arg.UserID,
+		arg.WorkspaceID,
+		arg.Kind,
+		arg.Title,
+		arg.Body,
+		arg.CreatedAt,
+	)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WorkspaceID,
+		&i.Kind,
+		&i.Title,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const updateNotificationsReadAtByUserID = `-- name: UpdateNotificationsReadAtByUserID :exec
+UPDATE
+	notifications
+SET
+	read_at = $1
+WHERE
+	user_id = $2
+	AND read_at IS NULL
+	AND CASE
+		-- An empty list of IDs marks every notification of the user as read.
+		WHEN cardinality($3 :: uuid [ ]) > 0 THEN id = ANY($3 :: uuid [ ])
+		ELSE true
+	END
+`
+
+type UpdateNotificationsReadAtByUserIDParams struct {
+	ReadAt sql.NullTime `db:"read_at" json:"read_at"`
+	UserID uuid.UUID    `db:"user_id" json:"user_id"`
+	IDs    []uuid.UUID  `db:"ids" json:"ids"`
+}
+
+func (q *sqlQuerier) UpdateNotificationsReadAtByUserID(ctx context.Context, arg UpdateNotificationsReadAtByUserIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateNotificationsReadAtByUserID, arg.ReadAt, arg.UserID, pq.Array(arg.IDs))
+	return err
+}
+
+const upsertUserNotificationPreference = `-- name: UpsertUserNotificationPreference :one
+INSERT INTO
+	user_notification_preferences (
+		user_id,
+		kind,
+		inbox,
+		email,
+		webhook
+	)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT
+	(user_id, kind)
+DO UPDATE SET
+	inbox = $3,
+	email = $4,
+	webhook = $5
+RETURNING user_id, kind, inbox, email, webhook
+`
+
+type UpsertUserNotificationPreferenceParams struct {
+	UserID  uuid.UUID        `db:"user_id" json:"user_id"`
+	Kind    NotificationKind `db:"kind" json:"kind"`
+	Inbox   bool             `db:"inbox" json:"inbox"`
+	Email   bool             `db:"email" json:"email"`
+	Webhook bool             `db:"webhook" json:"webhook"`
+}
+
+func (q *sqlQuerier) UpsertUserNotificationPreference(ctx context.Context, arg UpsertUserNotificationPreferenceParams) (UserNotificationPreference, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserNotificationPreference,
+		arg.UserID,
+		arg.Kind,
+		arg.Inbox,
+		arg.Email,
+		arg.Webhook,
+	)
+	var i UserNotificationPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.Kind,
+		&i.Inbox,
+		&i.Email,
+		&i.Webhook,
+	)
+	return i, err
+}
+
+const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
+SELECT
+    user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
+FROM
+    organization_members
+WHERE
+    user_id = ANY($1 :: uuid [ ])
+GROUP BY
+    user_id
+`
+
+type GetOrganizationIDsByMemberIDsRow struct {
+	UserID          uuid.UUID   `db:"user_id" json:"user_id"`
+	OrganizationIDs []uuid.UUID `db:"organization_IDs" json:"organization_IDs"`
+}
+
+func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationIDsByMemberIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrganizationIDsByMemberIDsRow
+	for rows.Next() {
+		var i GetOrganizationIDsByMemberIDsRow
+		if err := rows.Scan(&i.UserID, pq.Array(&i.OrganizationIDs)); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrganizationMemberByUserID = `-- name: GetOrganizationMemberByUserID :one
+SELECT
+	user_id, organization_id, created_at, updated_at, roles
+FROM
+	organization_members
+WHERE
+	organization_id = $1
+	AND user_id = $2
+LIMIT
+	1
+`
+
+type GetOrganizationMemberByUserIDParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetOrganizationMemberByUserIDParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationMemberByUserID, arg.OrganizationID, arg.UserID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.UserID,
+		&i.OrganizationID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Roles),
+	)
+	return i, err
+}
+
+const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+SELECT
+	user_id, organization_id, created_at, updated_at, roles
+FROM
+	organization_members
+WHERE
+  user_id = $1
+`
+
+func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationMember
+	for rows.Next() {
+		var i OrganizationMember
+		if err := rows.Scan(
+			&i.UserID,
+			&i.OrganizationID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			pq.Array(&i.Roles),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertOrganizationMember = `-- name: InsertOrganizationMember :one
+INSERT INTO
+	organization_members (
+		organization_id,
+		user_id,
+		created_at,
+		updated_at,
+		roles
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING user_id, organization_id, created_at, updated_at, roles
+`
+
+type InsertOrganizationMemberParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
 	CreatedAt      time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
 	Roles          []string  `db:"roles" json:"roles"`
@@ -2970,103 +3722,378 @@ func (q *sqlQuerier) InsertDeploymentID(ctx context.Context, value string) error
 	return err
 }
 
-const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
-WITH build_times AS (
-SELECT
-	EXTRACT(EPOCH FROM (pj.completed_at - pj.started_at))::FLOAT AS exec_time_sec,
-	workspace_builds.transition
-FROM
-	workspace_builds
-JOIN template_versions ON
-	workspace_builds.template_version_id = template_versions.id
-JOIN provisioner_jobs pj ON
-	workspace_builds.job_id = pj.id
-WHERE
-	template_versions.template_id = $1 AND
-		(pj.completed_at IS NOT NULL) AND (pj.started_at IS NOT NULL) AND
-		(pj.started_at > $2) AND
-		(pj.canceled_at IS NULL) AND
-		((pj.error IS NULL) OR (pj.error = ''))
-ORDER BY
-	workspace_builds.created_at DESC
-)
-SELECT
-	-- Postgres offers no clear way to DRY this short of a function or other
-	-- complexities.
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'start')), -1)::FLOAT AS start_median,
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'stop')), -1)::FLOAT AS stop_median,
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'delete')), -1)::FLOAT AS delete_median
-FROM build_times
+const deleteTailnetAgent = `-- name: DeleteTailnetAgent :exec
+DELETE FROM tailnet_agents WHERE id = $1 AND coordinator_id = $2
 `
 
-type GetTemplateAverageBuildTimeParams struct {
-	TemplateID uuid.NullUUID `db:"template_id" json:"template_id"`
-	StartTime  sql.NullTime  `db:"start_time" json:"start_time"`
+type DeleteTailnetAgentParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CoordinatorID uuid.UUID `db:"coordinator_id" json:"coordinator_id"`
 }
 
-type GetTemplateAverageBuildTimeRow struct {
-	StartMedian  float64 `db:"start_median" json:"start_median"`
-	StopMedian   float64 `db:"stop_median" json:"stop_median"`
-	DeleteMedian float64 `db:"delete_median" json:"delete_median"`
+func (q *sqlQuerier) DeleteTailnetAgent(ctx context.Context, arg DeleteTailnetAgentParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetAgent, arg.ID, arg.CoordinatorID)
+	return err
 }
 
-func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTemplateAverageBuildTimeParams) (GetTemplateAverageBuildTimeRow, error) {
-	row := q.db.QueryRowContext(ctx, getTemplateAverageBuildTime, arg.TemplateID, arg.StartTime)
-	var i GetTemplateAverageBuildTimeRow
-	err := row.Scan(&i.StartMedian, &i.StopMedian, &i.DeleteMedian)
-	return i, err
+const deleteTailnetClient = `-- name: DeleteTailnetClient :exec
+DELETE FROM tailnet_clients WHERE id = $1 AND coordinator_id = $2
+`
+
+type DeleteTailnetClientParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CoordinatorID uuid.UUID `db:"coordinator_id" json:"coordinator_id"`
 }
 
-const getTemplateByID = `-- name: GetTemplateByID :one
-SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
-FROM
-	templates
-WHERE
-	id = $1
-LIMIT
-	1
+func (q *sqlQuerier) DeleteTailnetClient(ctx context.Context, arg DeleteTailnetClientParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetClient, arg.ID, arg.CoordinatorID)
+	return err
+}
+
+const deleteTailnetCoordinator = `-- name: DeleteTailnetCoordinator :exec
+DELETE FROM tailnet_coordinators WHERE id = $1
 `
 
-func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Template, error) {
-	row := q.db.QueryRowContext(ctx, getTemplateByID, id)
-	var i Template
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.OrganizationID,
-		&i.Deleted,
-		&i.Name,
-		&i.Provisioner,
-		&i.ActiveVersionID,
-		&i.Description,
-		&i.DefaultTtl,
-		&i.CreatedBy,
-		&i.Icon,
-		&i.UserACL,
-		&i.GroupACL,
-		&i.DisplayName,
-	)
-	return i, err
+func (q *sqlQuerier) DeleteTailnetCoordinator(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetCoordinator, id)
+	return err
 }
 
-const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
-SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
-FROM
-	templates
-WHERE
-	organization_id = $1
-	AND deleted = $2
-	AND LOWER("name") = LOWER($3)
-LIMIT
-	1
+const deleteTailnetCoordinatorsHeartbeatBefore = `-- name: DeleteTailnetCoordinatorsHeartbeatBefore :exec
+DELETE FROM tailnet_coordinators WHERE heartbeat_at < $1
 `
 
-type GetTemplateByOrganizationAndNameParams struct {
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	Deleted        bool      `db:"deleted" json:"deleted"`
+func (q *sqlQuerier) DeleteTailnetCoordinatorsHeartbeatBefore(ctx context.Context, heartbeatAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetCoordinatorsHeartbeatBefore, heartbeatAt)
+	return err
+}
+
+const getTailnetAgents = `-- name: GetTailnetAgents :many
+SELECT id, coordinator_id, updated_at, node FROM tailnet_agents WHERE id = $1 ORDER BY updated_at DESC
+`
+
+// Returns the agent on every coordinator it's connected to, most recently
+// updated first.
+func (q *sqlQuerier) GetTailnetAgents(ctx context.Context, id uuid.UUID) ([]TailnetAgent, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetAgents, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetAgent
+	for rows.Next() {
+		var i TailnetAgent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTailnetAgentsByIDs = `-- name: GetTailnetAgentsByIDs :many
+SELECT id, coordinator_id, updated_at, node FROM tailnet_agents WHERE id = ANY($1 :: uuid [ ]) ORDER BY updated_at DESC
+`
+
+// Returns the agents on every coordinator they're connected to, most recently
+// updated first.
+func (q *sqlQuerier) GetTailnetAgentsByIDs(ctx context.Context, ids []uuid.UUID) ([]TailnetAgent, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetAgentsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetAgent
+	for rows.Next() {
+		var i TailnetAgent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTailnetClientsForAgent = `-- name: GetTailnetClientsForAgent :many
+SELECT id, coordinator_id, agent_id, updated_at, node FROM tailnet_clients WHERE agent_id = $1
+`
+
+func (q *sqlQuerier) GetTailnetClientsForAgent(ctx context.Context, agentID uuid.UUID) ([]TailnetClient, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetClientsForAgent, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetClient
+	for rows.Next() {
+		var i TailnetClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.AgentID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTailnetCoordinatorHeartbeat = `-- name: UpdateTailnetCoordinatorHeartbeat :one
+UPDATE tailnet_coordinators SET heartbeat_at = $2 WHERE id = $1 RETURNING id, heartbeat_at
+`
+
+type UpdateTailnetCoordinatorHeartbeatParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
+func (q *sqlQuerier) UpdateTailnetCoordinatorHeartbeat(ctx context.Context, arg UpdateTailnetCoordinatorHeartbeatParams) (TailnetCoordinator, error) {
+	row := q.db.QueryRowContext(ctx, updateTailnetCoordinatorHeartbeat, arg.ID, arg.HeartbeatAt)
+	var i TailnetCoordinator
+	err := row.Scan(
+		&i.ID,
+		&i.HeartbeatAt,
+	)
+	return i, err
+}
+
+const upsertTailnetAgent = `-- name: UpsertTailnetAgent :one
+INSERT INTO
+	tailnet_agents (id, coordinator_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET updated_at = EXCLUDED.updated_at, node = EXCLUDED.node
+RETURNING id, coordinator_id, updated_at, node
+`
+
+type UpsertTailnetAgentParams struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+func (q *sqlQuerier) UpsertTailnetAgent(ctx context.Context, arg UpsertTailnetAgentParams) (TailnetAgent, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetAgent,
+		arg.ID,
+		arg.CoordinatorID,
+		arg.UpdatedAt,
+		arg.Node,
+	)
+	var i TailnetAgent
+	err := row.Scan(
+		&i.ID,
+		&i.CoordinatorID,
+		&i.UpdatedAt,
+		&i.Node,
+	)
+	return i, err
+}
+
+const upsertTailnetClient = `-- name: UpsertTailnetClient :one
+INSERT INTO
+	tailnet_clients (id, coordinator_id, agent_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET agent_id = EXCLUDED.agent_id, updated_at = EXCLUDED.updated_at, node = EXCLUDED.node
+RETURNING id, coordinator_id, agent_id, updated_at, node
+`
+
+type UpsertTailnetClientParams struct {
+	ID            uuid.UUID             `db:"id" json:"id"`
+	CoordinatorID uuid.UUID             `db:"coordinator_id" json:"coordinator_id"`
+	AgentID       uuid.UUID             `db:"agent_id" json:"agent_id"`
+	UpdatedAt     time.Time             `db:"updated_at" json:"updated_at"`
+	Node          pqtype.NullRawMessage `db:"node" json:"node"`
+}
+
+func (q *sqlQuerier) UpsertTailnetClient(ctx context.Context, arg UpsertTailnetClientParams) (TailnetClient, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetClient,
+		arg.ID,
+		arg.CoordinatorID,
+		arg.AgentID,
+		arg.UpdatedAt,
+		arg.Node,
+	)
+	var i TailnetClient
+	err := row.Scan(
+		&i.ID,
+		&i.CoordinatorID,
+		&i.AgentID,
+		&i.UpdatedAt,
+		&i.Node,
+	)
+	return i, err
+}
+
+const upsertTailnetCoordinator = `-- name: UpsertTailnetCoordinator :one
+INSERT INTO
+	tailnet_coordinators (id, heartbeat_at)
+VALUES
+	($1, $2)
+ON CONFLICT (id)
+DO UPDATE SET heartbeat_at = EXCLUDED.heartbeat_at
+RETURNING id, heartbeat_at
+`
+
+type UpsertTailnetCoordinatorParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
+func (q *sqlQuerier) UpsertTailnetCoordinator(ctx context.Context, arg UpsertTailnetCoordinatorParams) (TailnetCoordinator, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetCoordinator, arg.ID, arg.HeartbeatAt)
+	var i TailnetCoordinator
+	err := row.Scan(
+		&i.ID,
+		&i.HeartbeatAt,
+	)
+	return i, err
+}
+
+const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
+WITH build_times AS (
+SELECT
+	EXTRACT(EPOCH FROM (pj.completed_at - pj.started_at))::FLOAT AS exec_time_sec,
+	workspace_builds.transition
+FROM
+	workspace_builds
+JOIN template_versions ON
+	workspace_builds.template_version_id = template_versions.id
+JOIN provisioner_jobs pj ON
+	workspace_builds.job_id = pj.id
+WHERE
+	template_versions.template_id = $1 AND
+		(pj.completed_at IS NOT NULL) AND (pj.started_at IS NOT NULL) AND
+		(pj.started_at > $2) AND
+		(pj.canceled_at IS NULL) AND
+		((pj.error IS NULL) OR (pj.error = ''))
+ORDER BY
+	workspace_builds.created_at DESC
+)
+SELECT
+	-- Postgres offers no clear way to DRY this short of a function or other
+	-- complexities.
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'start')), -1)::FLOAT AS start_median,
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'stop')), -1)::FLOAT AS stop_median,
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'delete')), -1)::FLOAT AS delete_median
+FROM build_times
+`
+
+type GetTemplateAverageBuildTimeParams struct {
+	TemplateID uuid.NullUUID `db:"template_id" json:"template_id"`
+	StartTime  sql.NullTime  `db:"start_time" json:"start_time"`
+}
+
+type GetTemplateAverageBuildTimeRow struct {
+	StartMedian  float64 `db:"start_median" json:"start_median"`
+	StopMedian   float64 `db:"stop_median" json:"stop_median"`
+	DeleteMedian float64 `db:"delete_median" json:"delete_median"`
+}
+
+func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTemplateAverageBuildTimeParams) (GetTemplateAverageBuildTimeRow, error) {
+	row := q.db.QueryRowContext(ctx, getTemplateAverageBuildTime, arg.TemplateID, arg.StartTime)
+	var i GetTemplateAverageBuildTimeRow
+	err := row.Scan(&i.StartMedian, &i.StopMedian, &i.DeleteMedian)
+	return i, err
+}
+
+const getTemplateByID = `-- name: GetTemplateByID :one
+SELECT
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
+FROM
+	templates
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Template, error) {
+	row := q.db.QueryRowContext(ctx, getTemplateByID, id)
+	var i Template
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.Deleted,
+		&i.Name,
+		&i.Provisioner,
+		&i.ActiveVersionID,
+		&i.Description,
+		&i.DefaultTtl,
+		&i.CreatedBy,
+		&i.Icon,
+		&i.UserACL,
+		&i.GroupACL,
+		&i.DisplayName,
+		&i.MaxTtl,
+		&i.MinAutostartInterval,
+		&i.QuietHoursSchedule,
+		&i.AllowUserAutostop,
+		&i.InactivityTtl,
+		&i.DormancyDeletionTtl,
+		&i.ActivityBump,
+		&i.ActivityBumpThreshold,
+		&i.RequireActiveVersion,
+		&i.BuildRetries,
+		&i.BuildRetryBackoff,
+		&i.RollbackFailedUpdates,
+		&i.AllowWorkspaceNetworking,
+	)
+	return i, err
+}
+
+const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
+SELECT
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
+FROM
+	templates
+WHERE
+	organization_id = $1
+	AND deleted = $2
+	AND LOWER("name") = LOWER($3)
+LIMIT
+	1
+`
+
+type GetTemplateByOrganizationAndNameParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Deleted        bool      `db:"deleted" json:"deleted"`
 	Name           string    `db:"name" json:"name"`
 }
 
@@ -3089,12 +4116,25 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.MaxTtl,
+		&i.MinAutostartInterval,
+		&i.QuietHoursSchedule,
+		&i.AllowUserAutostop,
+		&i.InactivityTtl,
+		&i.DormancyDeletionTtl,
+		&i.ActivityBump,
+		&i.ActivityBumpThreshold,
+		&i.RequireActiveVersion,
+		&i.BuildRetries,
+		&i.BuildRetryBackoff,
+		&i.RollbackFailedUpdates,
+		&i.AllowWorkspaceNetworking,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -3123,6 +4163,19 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.UserACL,
 			&i.GroupACL,
 			&i.DisplayName,
+			&i.MaxTtl,
+			&i.MinAutostartInterval,
+			&i.QuietHoursSchedule,
+			&i.AllowUserAutostop,
+			&i.InactivityTtl,
+			&i.DormancyDeletionTtl,
+			&i.ActivityBump,
+			&i.ActivityBumpThreshold,
+			&i.RequireActiveVersion,
+			&i.BuildRetries,
+			&i.BuildRetryBackoff,
+			&i.RollbackFailedUpdates,
+			&i.AllowWorkspaceNetworking,
 		); err != nil {
 			return nil, err
 		}
@@ -3139,7 +4192,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
 FROM
 	templates
 WHERE
@@ -3203,6 +4256,19 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.UserACL,
 			&i.GroupACL,
 			&i.DisplayName,
+			&i.MaxTtl,
+			&i.MinAutostartInterval,
+			&i.QuietHoursSchedule,
+			&i.AllowUserAutostop,
+			&i.InactivityTtl,
+			&i.DormancyDeletionTtl,
+			&i.ActivityBump,
+			&i.ActivityBumpThreshold,
+			&i.RequireActiveVersion,
+			&i.BuildRetries,
+			&i.BuildRetryBackoff,
+			&i.RollbackFailedUpdates,
+			&i.AllowWorkspaceNetworking,
 		); err != nil {
 			return nil, err
 		}
@@ -3233,27 +4299,53 @@ INSERT INTO
 		icon,
 		user_acl,
 		group_acl,
-		display_name
+		display_name,
+		max_ttl,
+		min_autostart_interval,
+		quiet_hours_schedule,
+		allow_user_autostop,
+		inactivity_ttl,
+		dormancy_deletion_ttl,
+		activity_bump,
+		activity_bump_threshold,
+		require_active_version,
+		build_retries,
+		build_retry_backoff,
+		rollback_failed_updates,
+		allow_workspace_networking
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
 `
 
 type InsertTemplateParams struct {
-	ID              uuid.UUID       `db:"id" json:"id"`
-	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
-	OrganizationID  uuid.UUID       `db:"organization_id" json:"organization_id"`
-	Name            string          `db:"name" json:"name"`
-	Provisioner     ProvisionerType `db:"provisioner" json:"provisioner"`
-	ActiveVersionID uuid.UUID       `db:"active_version_id" json:"active_version_id"`
-	Description     string          `db:"description" json:"description"`
-	DefaultTtl      int64           `db:"default_ttl" json:"default_ttl"`
-	CreatedBy       uuid.UUID       `db:"created_by" json:"created_by"`
-	Icon            string          `db:"icon" json:"icon"`
-	UserACL         TemplateACL     `db:"user_acl" json:"user_acl"`
-	GroupACL        TemplateACL     `db:"group_acl" json:"group_acl"`
-	DisplayName     string          `db:"display_name" json:"display_name"`
+	ID                       uuid.UUID       `db:"id" json:"id"`
+	CreatedAt                time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt                time.Time       `db:"updated_at" json:"updated_at"`
+	OrganizationID           uuid.UUID       `db:"organization_id" json:"organization_id"`
+	Name                     string          `db:"name" json:"name"`
+	Provisioner              ProvisionerType `db:"provisioner" json:"provisioner"`
+	ActiveVersionID          uuid.UUID       `db:"active_version_id" json:"active_version_id"`
+	Description              string          `db:"description" json:"description"`
+	DefaultTtl               int64           `db:"default_ttl" json:"default_ttl"`
+	CreatedBy                uuid.UUID       `db:"created_by" json:"created_by"`
+	Icon                     string          `db:"icon" json:"icon"`
+	UserACL                  TemplateACL     `db:"user_acl" json:"user_acl"`
+	GroupACL                 TemplateACL     `db:"group_acl" json:"group_acl"`
+	DisplayName              string          `db:"display_name" json:"display_name"`
+	MaxTtl                   int64           `db:"max_ttl" json:"max_ttl"`
+	MinAutostartInterval     int64           `db:"min_autostart_interval" json:"min_autostart_interval"`
+	QuietHoursSchedule       string          `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	AllowUserAutostop        bool            `db:"allow_user_autostop" json:"allow_user_autostop"`
+	InactivityTtl            int64           `db:"inactivity_ttl" json:"inactivity_ttl"`
+	DormancyDeletionTtl      int64           `db:"dormancy_deletion_ttl" json:"dormancy_deletion_ttl"`
+	ActivityBump             int64           `db:"activity_bump" json:"activity_bump"`
+	ActivityBumpThreshold    int64           `db:"activity_bump_threshold" json:"activity_bump_threshold"`
+	RequireActiveVersion     bool            `db:"require_active_version" json:"require_active_version"`
+	BuildRetries             int32           `db:"build_retries" json:"build_retries"`
+	BuildRetryBackoff        int64           `db:"build_retry_backoff" json:"build_retry_backoff"`
+	RollbackFailedUpdates    bool            `db:"rollback_failed_updates" json:"rollback_failed_updates"`
+	AllowWorkspaceNetworking bool            `db:"allow_workspace_networking" json:"allow_workspace_networking"`
 }
 
 func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParams) (Template, error) {
@@ -3272,6 +4364,19 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		arg.UserACL,
 		arg.GroupACL,
 		arg.DisplayName,
+		arg.MaxTtl,
+		arg.MinAutostartInterval,
+		arg.QuietHoursSchedule,
+		arg.AllowUserAutostop,
+		arg.InactivityTtl,
+		arg.DormancyDeletionTtl,
+		arg.ActivityBump,
+		arg.ActivityBumpThreshold,
+		arg.RequireActiveVersion,
+		arg.BuildRetries,
+		arg.BuildRetryBackoff,
+		arg.RollbackFailedUpdates,
+		arg.AllowWorkspaceNetworking,
 	)
 	var i Template
 	err := row.Scan(
@@ -3290,6 +4395,19 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.MaxTtl,
+		&i.MinAutostartInterval,
+		&i.QuietHoursSchedule,
+		&i.AllowUserAutostop,
+		&i.InactivityTtl,
+		&i.DormancyDeletionTtl,
+		&i.ActivityBump,
+		&i.ActivityBumpThreshold,
+		&i.RequireActiveVersion,
+		&i.BuildRetries,
+		&i.BuildRetryBackoff,
+		&i.RollbackFailedUpdates,
+		&i.AllowWorkspaceNetworking,
 	)
 	return i, err
 }
@@ -3303,7 +4421,7 @@ SET
 WHERE
 	id = $3
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
 `
 
 type UpdateTemplateACLByIDParams struct {
@@ -3331,6 +4449,19 @@ func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTempla
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.MaxTtl,
+		&i.MinAutostartInterval,
+		&i.QuietHoursSchedule,
+		&i.AllowUserAutostop,
+		&i.InactivityTtl,
+		&i.DormancyDeletionTtl,
+		&i.ActivityBump,
+		&i.ActivityBumpThreshold,
+		&i.RequireActiveVersion,
+		&i.BuildRetries,
+		&i.BuildRetryBackoff,
+		&i.RollbackFailedUpdates,
+		&i.AllowWorkspaceNetworking,
 	)
 	return i, err
 }
@@ -3386,21 +4517,47 @@ SET
 	default_ttl = $4,
 	name = $5,
 	icon = $6,
-	display_name = $7
+	display_name = $7,
+	max_ttl = $8,
+	min_autostart_interval = $9,
+	quiet_hours_schedule = $10,
+	allow_user_autostop = $11,
+	inactivity_ttl = $12,
+	dormancy_deletion_ttl = $13,
+	activity_bump = $14,
+	activity_bump_threshold = $15,
+	require_active_version = $16,
+	build_retries = $17,
+	build_retry_backoff = $18,
+	rollback_failed_updates = $19,
+	allow_workspace_networking = $20
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, max_ttl, min_autostart_interval, quiet_hours_schedule, allow_user_autostop, inactivity_ttl, dormancy_deletion_ttl, activity_bump, activity_bump_threshold, require_active_version, build_retries, build_retry_backoff, rollback_failed_updates, allow_workspace_networking
 `
 
 type UpdateTemplateMetaByIDParams struct {
-	ID          uuid.UUID `db:"id" json:"id"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
-	Description string    `db:"description" json:"description"`
-	DefaultTtl  int64     `db:"default_ttl" json:"default_ttl"`
-	Name        string    `db:"name" json:"name"`
-	Icon        string    `db:"icon" json:"icon"`
-	DisplayName string    `db:"display_name" json:"display_name"`
+	ID                       uuid.UUID `db:"id" json:"id"`
+	UpdatedAt                time.Time `db:"updated_at" json:"updated_at"`
+	Description              string    `db:"description" json:"description"`
+	DefaultTtl               int64     `db:"default_ttl" json:"default_ttl"`
+	Name                     string    `db:"name" json:"name"`
+	Icon                     string    `db:"icon" json:"icon"`
+	DisplayName              string    `db:"display_name" json:"display_name"`
+	MaxTtl                   int64     `db:"max_ttl" json:"max_ttl"`
+	MinAutostartInterval     int64     `db:"min_autostart_interval" json:"min_autostart_interval"`
+	QuietHoursSchedule       string    `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	AllowUserAutostop        bool      `db:"allow_user_autostop" json:"allow_user_autostop"`
+	InactivityTtl            int64     `db:"inactivity_ttl" json:"inactivity_ttl"`
+	DormancyDeletionTtl      int64     `db:"dormancy_deletion_ttl" json:"dormancy_deletion_ttl"`
+	ActivityBump             int64     `db:"activity_bump" json:"activity_bump"`
+	ActivityBumpThreshold    int64     `db:"activity_bump_threshold" json:"activity_bump_threshold"`
+	RequireActiveVersion     bool      `db:"require_active_version" json:"require_active_version"`
+	BuildRetries             int32     `db:"build_retries" json:"build_retries"`
+	BuildRetryBackoff        int64     `db:"build_retry_backoff" json:"build_retry_backoff"`
+	RollbackFailedUpdates    bool      `db:"rollback_failed_updates" json:"rollback_failed_updates"`
+	AllowWorkspaceNetworking bool      `db:"allow_workspace_networking" json:"allow_workspace_networking"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) (Template, error) {
@@ -3412,6 +4569,19 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.Name,
 		arg.Icon,
 		arg.DisplayName,
+		arg.MaxTtl,
+		arg.MinAutostartInterval,
+		arg.QuietHoursSchedule,
+		arg.AllowUserAutostop,
+		arg.InactivityTtl,
+		arg.DormancyDeletionTtl,
+		arg.ActivityBump,
+		arg.ActivityBumpThreshold,
+		arg.RequireActiveVersion,
+		arg.BuildRetries,
+		arg.BuildRetryBackoff,
+		arg.RollbackFailedUpdates,
+		arg.AllowWorkspaceNetworking,
 	)
 	var i Template
 	err := row.Scan(
@@ -3430,6 +4600,19 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.MaxTtl,
+		&i.MinAutostartInterval,
+		&i.QuietHoursSchedule,
+		&i.AllowUserAutostop,
+		&i.InactivityTtl,
+		&i.DormancyDeletionTtl,
+		&i.ActivityBump,
+		&i.ActivityBumpThreshold,
+		&i.RequireActiveVersion,
+		&i.BuildRetries,
+		&i.BuildRetryBackoff,
+		&i.RollbackFailedUpdates,
+		&i.AllowWorkspaceNetworking,
 	)
 	return i, err
 }
@@ -3733,6 +4916,153 @@ func (q *sqlQuerier) UpdateTemplateVersionDescriptionByJobID(ctx context.Context
 	return err
 }
 
+const consumeUserInvitationByID = `-- name: ConsumeUserInvitationByID :one
+DELETE FROM
+	user_invitations
+WHERE
+	id = $1 RETURNING id, hashed_secret, email, organization_id, created_by, created_at, expires_at
+`
+
+// Deletes the invitation and returns it, so only one request can use it.
+func (q *sqlQuerier) ConsumeUserInvitationByID(ctx context.Context, id string) (UserInvitation, error) {
+	row := q.db.QueryRowContext(ctx, consumeUserInvitationByID, id)
+	var i UserInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.Email,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteUserInvitationByID = `-- name: DeleteUserInvitationByID :exec
+DELETE FROM
+	user_invitations
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteUserInvitationByID(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserInvitationByID, id)
+	return err
+}
+
+const getUserInvitationByID = `-- name: GetUserInvitationByID :one
+SELECT
+	id, hashed_secret, email, organization_id, created_by, created_at, expires_at
+FROM
+	user_invitations
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetUserInvitationByID(ctx context.Context, id string) (UserInvitation, error) {
+	row := q.db.QueryRowContext(ctx, getUserInvitationByID, id)
+	var i UserInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.Email,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getUserInvitations = `-- name: GetUserInvitations :many
+SELECT
+	id, hashed_secret, email, organization_id, created_by, created_at, expires_at
+FROM
+	user_invitations
+ORDER BY
+	created_at ASC
+`
+
+func (q *sqlQuerier) GetUserInvitations(ctx context.Context) ([]UserInvitation, error) {
+	rows, err := q.db.QueryContext(ctx, getUserInvitations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserInvitation
+	for rows.Next() {
+		var i UserInvitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedSecret,
+			&i.Email,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertUserInvitation = `-- name: InsertUserInvitation :one
+INSERT INTO
+	user_invitations (
+		id,
+		hashed_secret,
+		email,
+		organization_id,
+		created_by,
+		created_at,
+		expires_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING id, hashed_secret, email, organization_id, created_by, created_at, expires_at
+`
+
+type InsertUserInvitationParams struct {
+	ID             string    `db:"id" json:"id"`
+	HashedSecret   []byte    `db:"hashed_secret" json:"hashed_secret"`
+	Email          string    `db:"email" json:"email"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	CreatedBy      uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *sqlQuerier) InsertUserInvitation(ctx context.Context, arg InsertUserInvitationParams) (UserInvitation, error) {
+	row := q.db.QueryRowContext(ctx, insertUserInvitation,
+		arg.ID,
+		arg.HashedSecret,
+		arg.Email,
+		arg.OrganizationID,
+		arg.CreatedBy,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var i UserInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.Email,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
 const getUserLinkByLinkedID = `-- name: GetUserLinkByLinkedID :one
 SELECT
 	user_id, login_type, linked_id, oauth_access_token, oauth_refresh_token, oauth_expiry
@@ -3896,6 +5226,276 @@ func (q *sqlQuerier) UpdateUserLinkedID(ctx context.Context, arg UpdateUserLinke
 	return i, err
 }
 
+const consumeUserPasswordResetByID = `-- name: ConsumeUserPasswordResetByID :one
+DELETE FROM
+	user_password_resets
+WHERE
+	id = $1 RETURNING id, hashed_secret, user_id, created_at, expires_at
+`
+
+// Deletes the password reset and returns it, so only one request can use it.
+func (q *sqlQuerier) ConsumeUserPasswordResetByID(ctx context.Context, id string) (UserPasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, consumeUserPasswordResetByID, id)
+	var i UserPasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteUserPasswordResetsByUserID = `-- name: DeleteUserPasswordResetsByUserID :exec
+DELETE FROM
+	user_password_resets
+WHERE
+	user_id = $1
+`
+
+func (q *sqlQuerier) DeleteUserPasswordResetsByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserPasswordResetsByUserID, userID)
+	return err
+}
+
+const getUserPasswordResetByID = `-- name: GetUserPasswordResetByID :one
+SELECT
+	id, hashed_secret, user_id, created_at, expires_at
+FROM
+	user_password_resets
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetUserPasswordResetByID(ctx context.Context, id string) (UserPasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, getUserPasswordResetByID, id)
+	var i UserPasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const insertUserPasswordReset = `-- name: InsertUserPasswordReset :one
+INSERT INTO
+	user_password_resets (
+		id,
+		hashed_secret,
+		user_id,
+		created_at,
+		expires_at
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING id, hashed_secret, user_id, created_at, expires_at
+`
+
+type InsertUserPasswordResetParams struct {
+	ID           string    `db:"id" json:"id"`
+	HashedSecret []byte    `db:"hashed_secret" json:"hashed_secret"`
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *sqlQuerier) InsertUserPasswordReset(ctx context.Context, arg InsertUserPasswordResetParams) (UserPasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, insertUserPasswordReset,
+		arg.ID,
+		arg.HashedSecret,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var i UserPasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const consumeUserTwoFactorRecoveryCode = `-- name: ConsumeUserTwoFactorRecoveryCode :one
+UPDATE
+	user_two_factors
+SET
+	hashed_recovery_codes = array_remove(hashed_recovery_codes, $1 :: text),
+	updated_at = $2
+WHERE
+	user_id = $3
+	-- Codes can only be used once.
+	AND $1 :: text = ANY(hashed_recovery_codes) RETURNING user_id, created_at, updated_at, totp_secret, hashed_recovery_codes, enabled, last_totp_step
+`
+
+type ConsumeUserTwoFactorRecoveryCodeParams struct {
+	Code      string    `db:"code" json:"code"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) ConsumeUserTwoFactorRecoveryCode(ctx context.Context, arg ConsumeUserTwoFactorRecoveryCodeParams) (UserTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, consumeUserTwoFactorRecoveryCode, arg.Code, arg.UpdatedAt, arg.UserID)
+	var i UserTwoFactor
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TOTPSecret,
+		pq.Array(&i.HashedRecoveryCodes),
+		&i.Enabled,
+		&i.LastTOTPStep,
+	)
+	return i, err
+}
+
+const deleteUserTwoFactorByUserID = `-- name: DeleteUserTwoFactorByUserID :exec
+DELETE FROM
+	user_two_factors
+WHERE
+	user_id = $1
+`
+
+func (q *sqlQuerier) DeleteUserTwoFactorByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserTwoFactorByUserID, userID)
+	return err
+}
+
+const getUserTwoFactorByUserID = `-- name: GetUserTwoFactorByUserID :one
+SELECT
+	user_id, created_at, updated_at, totp_secret, hashed_recovery_codes, enabled, last_totp_step
+FROM
+	user_two_factors
+WHERE
+	user_id = $1
+`
+
+func (q *sqlQuerier) GetUserTwoFactorByUserID(ctx context.Context, userID uuid.UUID) (UserTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, getUserTwoFactorByUserID, userID)
+	var i UserTwoFactor
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TOTPSecret,
+		pq.Array(&i.HashedRecoveryCodes),
+		&i.Enabled,
+		&i.LastTOTPStep,
+	)
+	return i, err
+}
+
+const insertUserTwoFactor = `-- name: InsertUserTwoFactor :one
+INSERT INTO
+	user_two_factors (
+		user_id,
+		created_at,
+		updated_at,
+		totp_secret,
+		hashed_recovery_codes
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING user_id, created_at, updated_at, totp_secret, hashed_recovery_codes, enabled, last_totp_step
+`
+
+type InsertUserTwoFactorParams struct {
+	UserID              uuid.UUID `db:"user_id" json:"user_id"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+	TOTPSecret          string    `db:"totp_secret" json:"totp_secret"`
+	HashedRecoveryCodes []string  `db:"hashed_recovery_codes" json:"hashed_recovery_codes"`
+}
+
+func (q *sqlQuerier) InsertUserTwoFactor(ctx context.Context, arg InsertUserTwoFactorParams) (UserTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, insertUserTwoFactor,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.TOTPSecret,
+		pq.Array(arg.HashedRecoveryCodes),
+	)
+	var i UserTwoFactor
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TOTPSecret,
+		pq.Array(&i.HashedRecoveryCodes),
+		&i.Enabled,
+		&i.LastTOTPStep,
+	)
+	return i, err
+}
+
+const updateUserTwoFactorEnabled = `-- name: UpdateUserTwoFactorEnabled :one
+UPDATE
+	user_two_factors
+SET
+	enabled = $2,
+	updated_at = $3
+WHERE
+	user_id = $1 RETURNING user_id, created_at, updated_at, totp_secret, hashed_recovery_codes, enabled, last_totp_step
+`
+
+type UpdateUserTwoFactorEnabledParams struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateUserTwoFactorEnabled(ctx context.Context, arg UpdateUserTwoFactorEnabledParams) (UserTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTwoFactorEnabled, arg.UserID, arg.Enabled, arg.UpdatedAt)
+	var i UserTwoFactor
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TOTPSecret,
+		pq.Array(&i.HashedRecoveryCodes),
+		&i.Enabled,
+		&i.LastTOTPStep,
+	)
+	return i, err
+}
+
+const updateUserTwoFactorLastTOTPStep = `-- name: UpdateUserTwoFactorLastTOTPStep :one
+UPDATE
+	user_two_factors
+SET
+	last_totp_step = $2,
+	updated_at = $3
+WHERE
+	user_id = $1
+	-- Codes can only be used once.
+	AND last_totp_step < $2 RETURNING user_id, created_at, updated_at, totp_secret, hashed_recovery_codes, enabled, last_totp_step
+`
+
+type UpdateUserTwoFactorLastTOTPStepParams struct {
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	LastTOTPStep int64     `db:"last_totp_step" json:"last_totp_step"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateUserTwoFactorLastTOTPStep(ctx context.Context, arg UpdateUserTwoFactorLastTOTPStepParams) (UserTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTwoFactorLastTOTPStep, arg.UserID, arg.LastTOTPStep, arg.UpdatedAt)
+	var i UserTwoFactor
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TOTPSecret,
+		pq.Array(&i.HashedRecoveryCodes),
+		&i.Enabled,
+		&i.LastTOTPStep,
+	)
+	return i, err
+}
+
 const getActiveUserCount = `-- name: GetActiveUserCount :one
 SELECT
 	COUNT(*)
@@ -4828,6 +6428,29 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 	return i, err
 }
 
+const regenerateWorkspaceAgentAuthTokensByWorkspaceID = `-- name: RegenerateWorkspaceAgentAuthTokensByWorkspaceID :exec
+UPDATE
+	workspace_agents
+SET
+	auth_token = gen_random_uuid()
+WHERE
+	resource_id IN (
+		SELECT
+			workspace_resources.id
+		FROM
+			workspace_resources
+		INNER JOIN
+			workspace_builds ON workspace_builds.job_id = workspace_resources.job_id
+		WHERE
+			workspace_builds.workspace_id = $1
+	)
+`
+
+func (q *sqlQuerier) RegenerateWorkspaceAgentAuthTokensByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, regenerateWorkspaceAgentAuthTokensByWorkspaceID, workspaceID)
+	return err
+}
+
 const updateWorkspaceAgentConnectionByID = `-- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
 	workspace_agents
@@ -5123,13 +6746,438 @@ WHERE
 	id = $1
 `
 
-type UpdateWorkspaceAppHealthByIDParams struct {
-	ID     uuid.UUID          `db:"id" json:"id"`
-	Health WorkspaceAppHealth `db:"health" json:"health"`
+type UpdateWorkspaceAppHealthByIDParams struct {
+	ID     uuid.UUID          `db:"id" json:"id"`
+	Health WorkspaceAppHealth `db:"health" json:"health"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAppHealthByID(ctx context.Context, arg UpdateWorkspaceAppHealthByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAppHealthByID, arg.ID, arg.Health)
+	return err
+}
+
+const acquireStaleWorkspaceBatch = `-- name: AcquireStaleWorkspaceBatch :one
+UPDATE
+	workspace_batches
+SET
+	heartbeat_at = $1 :: timestamptz
+WHERE
+	id = (
+		SELECT
+			id
+		FROM
+			workspace_batches AS nested
+		WHERE
+			nested.completed_at IS NULL
+			AND nested.heartbeat_at < $2 :: timestamptz
+		ORDER BY
+			nested.created_at FOR
+		UPDATE
+			SKIP LOCKED
+		LIMIT
+			1
+	) RETURNING id, created_at, initiator_id, action, query, concurrency, heartbeat_at, completed_at, scope
+`
+
+type AcquireStaleWorkspaceBatchParams struct {
+	Now         time.Time `db:"now" json:"now"`
+	StaleBefore time.Time `db:"stale_before" json:"stale_before"`
+}
+
+// Claims the oldest incomplete batch whose replica stopped heartbeating, so
+// it can be resumed. SKIP LOCKED keeps replicas from claiming the same batch.
+func (q *sqlQuerier) AcquireStaleWorkspaceBatch(ctx context.Context, arg AcquireStaleWorkspaceBatchParams) (WorkspaceBatch, error) {
+	row := q.db.QueryRowContext(ctx, acquireStaleWorkspaceBatch, arg.Now, arg.StaleBefore)
+	var i WorkspaceBatch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.InitiatorID,
+		&i.Action,
+		&i.Query,
+		&i.Concurrency,
+		&i.HeartbeatAt,
+		&i.CompletedAt,
+		&i.Scope,
+	)
+	return i, err
+}
+
+const deleteOldWorkspaceBatches = `-- name: DeleteOldWorkspaceBatches :exec
+DELETE FROM
+	workspace_batches
+WHERE
+	completed_at < $1 :: timestamptz
+`
+
+func (q *sqlQuerier) DeleteOldWorkspaceBatches(ctx context.Context, completedBefore time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteOldWorkspaceBatches, completedBefore)
+	return err
+}
+
+const getWorkspaceBatchByID = `-- name: GetWorkspaceBatchByID :one
+SELECT
+	id, created_at, initiator_id, action, query, concurrency, heartbeat_at, completed_at, scope
+FROM
+	workspace_batches
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceBatchByID(ctx context.Context, id uuid.UUID) (WorkspaceBatch, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceBatchByID, id)
+	var i WorkspaceBatch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.InitiatorID,
+		&i.Action,
+		&i.Query,
+		&i.Concurrency,
+		&i.HeartbeatAt,
+		&i.CompletedAt,
+		&i.Scope,
+	)
+	return i, err
+}
+
+const getWorkspaceBatchItemsByBatchID = `-- name: GetWorkspaceBatchItemsByBatchID :many
+SELECT
+	batch_id, workspace_id, position, workspace_name, owner_name, status, build_id, error
+FROM
+	workspace_batch_items
+WHERE
+	batch_id = $1
+ORDER BY
+	position ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceBatchItemsByBatchID(ctx context.Context, batchID uuid.UUID) ([]WorkspaceBatchItem, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBatchItemsByBatchID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBatchItem
+	for rows.Next() {
+		var i WorkspaceBatchItem
+		if err := rows.Scan(
+			&i.BatchID,
+			&i.WorkspaceID,
+			&i.Position,
+			&i.WorkspaceName,
+			&i.OwnerName,
+			&i.Status,
+			&i.BuildID,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBatch = `-- name: InsertWorkspaceBatch :one
+INSERT INTO
+	workspace_batches (
+		id,
+		created_at,
+		initiator_id,
+		action,
+		query,
+		concurrency,
+		heartbeat_at,
+		scope
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, initiator_id, action, query, concurrency, heartbeat_at, completed_at, scope
+`
+
+type InsertWorkspaceBatchParams struct {
+	ID          uuid.UUID   `db:"id" json:"id"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	InitiatorID uuid.UUID   `db:"initiator_id" json:"initiator_id"`
+	Action      string      `db:"action" json:"action"`
+	Query       string      `db:"query" json:"query"`
+	Concurrency int32       `db:"concurrency" json:"concurrency"`
+	HeartbeatAt time.Time   `db:"heartbeat_at" json:"heartbeat_at"`
+	Scope       APIKeyScope `db:"scope" json:"scope"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBatch(ctx context.Context, arg InsertWorkspaceBatchParams) (WorkspaceBatch, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceBatch,
+		arg.ID,
+		arg.CreatedAt,
+		arg.InitiatorID,
+		arg.Action,
+		arg.Query,
+		arg.Concurrency,
+		arg.HeartbeatAt,
+		arg.Scope,
+	)
+	var i WorkspaceBatch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.InitiatorID,
+		&i.Action,
+		&i.Query,
+		&i.Concurrency,
+		&i.HeartbeatAt,
+		&i.CompletedAt,
+		&i.Scope,
+	)
+	return i, err
+}
+
+const insertWorkspaceBatchItem = `-- name: InsertWorkspaceBatchItem :one
+INSERT INTO
+	workspace_batch_items (
+		batch_id,
+		workspace_id,
+		position,
+		workspace_name,
+		owner_name,
+		status,
+		error
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING batch_id, workspace_id, position, workspace_name, owner_name, status, build_id, error
+`
+
+type InsertWorkspaceBatchItemParams struct {
+	BatchID       uuid.UUID `db:"batch_id" json:"batch_id"`
+	WorkspaceID   uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	Position      int32     `db:"position" json:"position"`
+	WorkspaceName string    `db:"workspace_name" json:"workspace_name"`
+	OwnerName     string    `db:"owner_name" json:"owner_name"`
+	Status        string    `db:"status" json:"status"`
+	Error         string    `db:"error" json:"error"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBatchItem(ctx context.Context, arg InsertWorkspaceBatchItemParams) (WorkspaceBatchItem, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceBatchItem,
+		arg.BatchID,
+		arg.WorkspaceID,
+		arg.Position,
+		arg.WorkspaceName,
+		arg.OwnerName,
+		arg.Status,
+		arg.Error,
+	)
+	var i WorkspaceBatchItem
+	err := row.Scan(
+		&i.BatchID,
+		&i.WorkspaceID,
+		&i.Position,
+		&i.WorkspaceName,
+		&i.OwnerName,
+		&i.Status,
+		&i.BuildID,
+		&i.Error,
+	)
+	return i, err
+}
+
+const updateWorkspaceBatchCompletedAt = `-- name: UpdateWorkspaceBatchCompletedAt :exec
+UPDATE
+	workspace_batches
+SET
+	completed_at = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceBatchCompletedAtParams struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceBatchCompletedAt(ctx context.Context, arg UpdateWorkspaceBatchCompletedAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceBatchCompletedAt, arg.ID, arg.CompletedAt)
+	return err
+}
+
+const updateWorkspaceBatchHeartbeat = `-- name: UpdateWorkspaceBatchHeartbeat :exec
+UPDATE
+	workspace_batches
+SET
+	heartbeat_at = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceBatchHeartbeatParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceBatchHeartbeat(ctx context.Context, arg UpdateWorkspaceBatchHeartbeatParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceBatchHeartbeat, arg.ID, arg.HeartbeatAt)
+	return err
+}
+
+const updateWorkspaceBatchItem = `-- name: UpdateWorkspaceBatchItem :exec
+UPDATE
+	workspace_batch_items
+SET
+	status = $3,
+	build_id = $4,
+	error = $5
+WHERE
+	batch_id = $1
+	AND workspace_id = $2
+`
+
+type UpdateWorkspaceBatchItemParams struct {
+	BatchID     uuid.UUID     `db:"batch_id" json:"batch_id"`
+	WorkspaceID uuid.UUID     `db:"workspace_id" json:"workspace_id"`
+	Status      string        `db:"status" json:"status"`
+	BuildID     uuid.NullUUID `db:"build_id" json:"build_id"`
+	Error       string        `db:"error" json:"error"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceBatchItem(ctx context.Context, arg UpdateWorkspaceBatchItemParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceBatchItem,
+		arg.BatchID,
+		arg.WorkspaceID,
+		arg.Status,
+		arg.BuildID,
+		arg.Error,
+	)
+	return err
+}
+
+const getWorkspaceBuildParameters = `-- name: GetWorkspaceBuildParameters :many
+SELECT
+	workspace_build_id, name, source_scheme, source_value, destination_scheme
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildParameters, workspaceBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBuildParameter
+	for rows.Next() {
+		var i WorkspaceBuildParameter
+		if err := rows.Scan(
+			&i.WorkspaceBuildID,
+			&i.Name,
+			&i.SourceScheme,
+			&i.SourceValue,
+			&i.DestinationScheme,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBuildParameters = `-- name: InsertWorkspaceBuildParameters :exec
+INSERT INTO
+	workspace_build_parameters (
+		workspace_build_id,
+		name,
+		source_scheme,
+		source_value,
+		destination_scheme
+	)
+SELECT
+	$1 :: uuid,
+	name,
+	source_scheme,
+	source_value,
+	destination_scheme
+FROM
+	parameter_values
+WHERE
+	scope = 'workspace'
+	AND scope_id = $2 :: uuid
+`
+
+type InsertWorkspaceBuildParametersParams struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBuildParameters(ctx context.Context, arg InsertWorkspaceBuildParametersParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceBuildParameters, arg.WorkspaceBuildID, arg.WorkspaceID)
+	return err
+}
+
+const getWorkspaceBuildRecoveryByBuildID = `-- name: GetWorkspaceBuildRecoveryByBuildID :one
+SELECT
+	workspace_build_id, reason, template_version_id, target_build_id, recover_at
+FROM
+	workspace_build_recoveries
+WHERE
+	workspace_build_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceBuildRecoveryByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (WorkspaceBuildRecovery, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceBuildRecoveryByBuildID, workspaceBuildID)
+	var i WorkspaceBuildRecovery
+	err := row.Scan(
+		&i.WorkspaceBuildID,
+		&i.Reason,
+		&i.TemplateVersionID,
+		&i.TargetBuildID,
+		&i.RecoverAt,
+	)
+	return i, err
+}
+
+const insertWorkspaceBuildRecovery = `-- name: InsertWorkspaceBuildRecovery :exec
+INSERT INTO
+	workspace_build_recoveries (
+		workspace_build_id,
+		reason,
+		template_version_id,
+		target_build_id,
+		recover_at
+	)
+VALUES
+	($1, $2, $3, $4, $5)
+`
+
+type InsertWorkspaceBuildRecoveryParams struct {
+	WorkspaceBuildID  uuid.UUID     `db:"workspace_build_id" json:"workspace_build_id"`
+	Reason            BuildReason   `db:"reason" json:"reason"`
+	TemplateVersionID uuid.UUID     `db:"template_version_id" json:"template_version_id"`
+	TargetBuildID     uuid.NullUUID `db:"target_build_id" json:"target_build_id"`
+	RecoverAt         time.Time     `db:"recover_at" json:"recover_at"`
 }
 
-func (q *sqlQuerier) UpdateWorkspaceAppHealthByID(ctx context.Context, arg UpdateWorkspaceAppHealthByIDParams) error {
-	_, err := q.db.ExecContext(ctx, updateWorkspaceAppHealthByID, arg.ID, arg.Health)
+func (q *sqlQuerier) InsertWorkspaceBuildRecovery(ctx context.Context, arg InsertWorkspaceBuildRecoveryParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceBuildRecovery,
+		arg.WorkspaceBuildID,
+		arg.Reason,
+		arg.TemplateVersionID,
+		arg.TargetBuildID,
+		arg.RecoverAt,
+	)
 	return err
 }
 
@@ -5166,6 +7214,45 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, w
 	return i, err
 }
 
+const getLatestWorkspaceBuildByWorkspaceIDAndReason = `-- name: GetLatestWorkspaceBuildByWorkspaceIDAndReason :one
+SELECT
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+FROM
+	workspace_builds
+WHERE
+	workspace_id = $1
+	AND reason = $2
+ORDER BY
+    build_number desc
+LIMIT
+	1
+`
+
+type GetLatestWorkspaceBuildByWorkspaceIDAndReasonParams struct {
+	WorkspaceID uuid.UUID   `db:"workspace_id" json:"workspace_id"`
+	Reason      BuildReason `db:"reason" json:"reason"`
+}
+
+func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceIDAndReason(ctx context.Context, arg GetLatestWorkspaceBuildByWorkspaceIDAndReasonParams) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, getLatestWorkspaceBuildByWorkspaceIDAndReason, arg.WorkspaceID, arg.Reason)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.TemplateVersionID,
+		&i.BuildNumber,
+		&i.Transition,
+		&i.InitiatorID,
+		&i.ProvisionerState,
+		&i.JobID,
+		&i.Deadline,
+		&i.Reason,
+	)
+	return i, err
+}
+
 const getLatestWorkspaceBuilds = `-- name: GetLatestWorkspaceBuilds :many
 SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason
 FROM (
@@ -5936,7 +8023,7 @@ func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg In
 
 const getWorkspaceByID = `-- name: GetWorkspaceByID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at, automatic_updates
 FROM
 	workspaces
 WHERE
@@ -5960,13 +8047,15 @@ func (q *sqlQuerier) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Worksp
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
+		&i.AutomaticUpdates,
 	)
 	return i, err
 }
 
 const getWorkspaceByOwnerIDAndName = `-- name: GetWorkspaceByOwnerIDAndName :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at, automatic_updates
 FROM
 	workspaces
 WHERE
@@ -5997,6 +8086,8 @@ func (q *sqlQuerier) GetWorkspaceByOwnerIDAndName(ctx context.Context, arg GetWo
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
+		&i.AutomaticUpdates,
 	)
 	return i, err
 }
@@ -6063,7 +8154,7 @@ func (q *sqlQuerier) GetWorkspaceOwnerCountsByTemplateIDs(ctx context.Context, i
 
 const getWorkspaces = `-- name: GetWorkspaces :many
 SELECT
-	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, workspaces.dormant_at, workspaces.automatic_updates
 FROM
 	workspaces
 LEFT JOIN LATERAL (
@@ -6185,17 +8276,23 @@ WHERE
 			name ILIKE '%' || $7 || '%'
 		ELSE true
 	END
+	-- Filter by dormancy
+	AND CASE
+		WHEN $8 :: boolean THEN
+			dormant_at IS NOT NULL
+		ELSE true
+	END
 	-- Authorize Filter clause will be injected below in GetAuthorizedWorkspaces
 	-- @authorize_filter
 ORDER BY
     last_used_at DESC
 LIMIT
     CASE
-        WHEN $9 :: integer > 0 THEN
-            $9
+        WHEN $10 :: integer > 0 THEN
+            $10
     END
 OFFSET
-    $8
+    $9
 `
 
 type GetWorkspacesParams struct {
@@ -6206,6 +8303,7 @@ type GetWorkspacesParams struct {
 	TemplateName  string      `db:"template_name" json:"template_name"`
 	TemplateIds   []uuid.UUID `db:"template_ids" json:"template_ids"`
 	Name          string      `db:"name" json:"name"`
+	Dormant       bool        `db:"dormant" json:"dormant"`
 	Offset        int32       `db:"offset_" json:"offset_"`
 	Limit         int32       `db:"limit_" json:"limit_"`
 }
@@ -6219,6 +8317,7 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 		arg.TemplateName,
 		pq.Array(arg.TemplateIds),
 		arg.Name,
+		arg.Dormant,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -6241,6 +8340,8 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.DormantAt,
+			&i.AutomaticUpdates,
 		); err != nil {
 			return nil, err
 		}
@@ -6269,7 +8370,7 @@ INSERT INTO
 		ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at, automatic_updates
 `
 
 type InsertWorkspaceParams struct {
@@ -6309,6 +8410,8 @@ func (q *sqlQuerier) InsertWorkspace(ctx context.Context, arg InsertWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
+		&i.AutomaticUpdates,
 	)
 	return i, err
 }
@@ -6321,7 +8424,7 @@ SET
 WHERE
 	id = $1
 	AND deleted = false
-RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at, automatic_updates
 `
 
 type UpdateWorkspaceParams struct {
@@ -6344,10 +8447,31 @@ func (q *sqlQuerier) UpdateWorkspace(ctx context.Context, arg UpdateWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
+		&i.AutomaticUpdates,
 	)
 	return i, err
 }
 
+const updateWorkspaceAutomaticUpdates = `-- name: UpdateWorkspaceAutomaticUpdates :exec
+UPDATE
+	workspaces
+SET
+	automatic_updates = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAutomaticUpdatesParams struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	AutomaticUpdates bool      `db:"automatic_updates" json:"automatic_updates"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAutomaticUpdates(ctx context.Context, arg UpdateWorkspaceAutomaticUpdatesParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAutomaticUpdates, arg.ID, arg.AutomaticUpdates)
+	return err
+}
+
 const updateWorkspaceAutostart = `-- name: UpdateWorkspaceAutostart :exec
 UPDATE
 	workspaces
@@ -6386,6 +8510,25 @@ func (q *sqlQuerier) UpdateWorkspaceDeletedByID(ctx context.Context, arg UpdateW
 	return err
 }
 
+const updateWorkspaceDormantAt = `-- name: UpdateWorkspaceDormantAt :exec
+UPDATE
+	workspaces
+SET
+	dormant_at = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceDormantAtParams struct {
+	ID        uuid.UUID    `db:"id" json:"id"`
+	DormantAt sql.NullTime `db:"dormant_at" json:"dormant_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceDormantAt(ctx context.Context, arg UpdateWorkspaceDormantAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceDormantAt, arg.ID, arg.DormantAt)
+	return err
+}
+
 const updateWorkspaceLastUsedAt = `-- name: UpdateWorkspaceLastUsedAt :exec
 UPDATE
 	workspaces
@@ -6405,6 +8548,25 @@ func (q *sqlQuerier) UpdateWorkspaceLastUsedAt(ctx context.Context, arg UpdateWo
 	return err
 }
 
+const updateWorkspaceOwner = `-- name: UpdateWorkspaceOwner :exec
+UPDATE
+	workspaces
+SET
+	owner_id = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceOwnerParams struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	OwnerID uuid.UUID `db:"owner_id" json:"owner_id"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceOwner(ctx context.Context, arg UpdateWorkspaceOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceOwner, arg.ID, arg.OwnerID)
+	return err
+}
+
 const updateWorkspaceTTL = `-- name: UpdateWorkspaceTTL :exec
 UPDATE
 	workspaces
@@ -6423,3 +8585,276 @@ func (q *sqlQuerier) UpdateWorkspaceTTL(ctx context.Context, arg UpdateWorkspace
 	_, err := q.db.ExecContext(ctx, updateWorkspaceTTL, arg.ID, arg.Ttl)
 	return err
 }
+
+const deleteWorkspaceScheduleSkipDatesByWorkspaceID = `-- name: DeleteWorkspaceScheduleSkipDatesByWorkspaceID :exec
+DELETE FROM
+	workspace_schedule_skip_dates
+WHERE
+	workspace_id = $1
+`
+
+func (q *sqlQuerier) DeleteWorkspaceScheduleSkipDatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceScheduleSkipDatesByWorkspaceID, workspaceID)
+	return err
+}
+
+const deleteWorkspaceScheduleWindowsByWorkspaceID = `-- name: DeleteWorkspaceScheduleWindowsByWorkspaceID :exec
+DELETE FROM
+	workspace_schedule_windows
+WHERE
+	workspace_id = $1
+`
+
+func (q *sqlQuerier) DeleteWorkspaceScheduleWindowsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceScheduleWindowsByWorkspaceID, workspaceID)
+	return err
+}
+
+const getWorkspaceScheduleSkipDatesByWorkspaceID = `-- name: GetWorkspaceScheduleSkipDatesByWorkspaceID :many
+SELECT
+	workspace_id, date
+FROM
+	workspace_schedule_skip_dates
+WHERE
+	workspace_id = $1
+ORDER BY
+	date ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceScheduleSkipDatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceScheduleSkipDate, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceScheduleSkipDatesByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceScheduleSkipDate
+	for rows.Next() {
+		var i WorkspaceScheduleSkipDate
+		if err := rows.Scan(&i.WorkspaceID, &i.Date); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceScheduleWindowsByWorkspaceIDs = `-- name: GetWorkspaceScheduleWindowsByWorkspaceIDs :many
+SELECT
+	id, workspace_id, start_schedule, stop_schedule, created_at
+FROM
+	workspace_schedule_windows
+WHERE
+	workspace_id = ANY($1 :: uuid [ ])
+ORDER BY
+	created_at ASC, id ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceScheduleWindowsByWorkspaceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceScheduleWindow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceScheduleWindowsByWorkspaceIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceScheduleWindow
+	for rows.Next() {
+		var i WorkspaceScheduleWindow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.StartSchedule,
+			&i.StopSchedule,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceScheduleSkipDate = `-- name: InsertWorkspaceScheduleSkipDate :one
+INSERT INTO
+	workspace_schedule_skip_dates (
+		workspace_id,
+		date
+	)
+VALUES
+	($1, $2) RETURNING workspace_id, date
+`
+
+type InsertWorkspaceScheduleSkipDateParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	Date        time.Time `db:"date" json:"date"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceScheduleSkipDate(ctx context.Context, arg InsertWorkspaceScheduleSkipDateParams) (WorkspaceScheduleSkipDate, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceScheduleSkipDate, arg.WorkspaceID, arg.Date)
+	var i WorkspaceScheduleSkipDate
+	err := row.Scan(&i.WorkspaceID, &i.Date)
+	return i, err
+}
+
+const insertWorkspaceScheduleWindow = `-- name: InsertWorkspaceScheduleWindow :one
+INSERT INTO
+	workspace_schedule_windows (
+		id,
+		workspace_id,
+		start_schedule,
+		stop_schedule,
+		created_at
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING id, workspace_id, start_schedule, stop_schedule, created_at
+`
+
+type InsertWorkspaceScheduleWindowParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	WorkspaceID   uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	StartSchedule string    `db:"start_schedule" json:"start_schedule"`
+	StopSchedule  string    `db:"stop_schedule" json:"stop_schedule"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceScheduleWindow(ctx context.Context, arg InsertWorkspaceScheduleWindowParams) (WorkspaceScheduleWindow, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceScheduleWindow,
+		arg.ID,
+		arg.WorkspaceID,
+		arg.StartSchedule,
+		arg.StopSchedule,
+		arg.CreatedAt,
+	)
+	var i WorkspaceScheduleWindow
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.StartSchedule,
+		&i.StopSchedule,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWorkspaceTransferByWorkspaceID = `-- name: DeleteWorkspaceTransferByWorkspaceID :exec
+DELETE FROM
+	workspace_transfers
+WHERE
+	workspace_id = $1
+`
+
+func (q *sqlQuerier) DeleteWorkspaceTransferByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceTransferByWorkspaceID, workspaceID)
+	return err
+}
+
+const getWorkspaceTransferByWorkspaceID = `-- name: GetWorkspaceTransferByWorkspaceID :one
+SELECT
+	workspace_id, from_user_id, to_user_id, created_at
+FROM
+	workspace_transfers
+WHERE
+	workspace_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceTransferByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceTransferByWorkspaceID, workspaceID)
+	var i WorkspaceTransfer
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceTransfersByToUserID = `-- name: GetWorkspaceTransfersByToUserID :many
+SELECT
+	workspace_id, from_user_id, to_user_id, created_at
+FROM
+	workspace_transfers
+WHERE
+	to_user_id = $1
+ORDER BY
+	created_at ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceTransfersByToUserID(ctx context.Context, toUserID uuid.UUID) ([]WorkspaceTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceTransfersByToUserID, toUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceTransfer
+	for rows.Next() {
+		var i WorkspaceTransfer
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.FromUserID,
+			&i.ToUserID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertWorkspaceTransfer = `-- name: UpsertWorkspaceTransfer :one
+INSERT INTO
+	workspace_transfers (
+		workspace_id,
+		from_user_id,
+		to_user_id,
+		created_at
+	)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (workspace_id) DO UPDATE SET
+	from_user_id = $2,
+	to_user_id = $3,
+	created_at = $4
+RETURNING workspace_id, from_user_id, to_user_id, created_at
+`
+
+type UpsertWorkspaceTransferParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	FromUserID  uuid.UUID `db:"from_user_id" json:"from_user_id"`
+	ToUserID    uuid.UUID `db:"to_user_id" json:"to_user_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) UpsertWorkspaceTransfer(ctx context.Context, arg UpsertWorkspaceTransferParams) (WorkspaceTransfer, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceTransfer,
+		arg.WorkspaceID,
+		arg.FromUserID,
+		arg.ToUserID,
+		arg.CreatedAt,
+	)
+	var i WorkspaceTransfer
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
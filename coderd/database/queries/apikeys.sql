@@ -14,6 +14,71 @@ SELECT * FROM api_keys WHERE last_used > $1;
 -- name: GetAPIKeysByLoginType :many
 SELECT * FROM api_keys WHERE login_type = $1;
 
+-- name: GetAPIKeys :many
+SELECT
+	*
+FROM
+	api_keys
+WHERE
+	-- Filter by user_id
+	CASE
+		WHEN @user_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			user_id = @user_id
+		ELSE true
+	END
+	-- Filter by login_type
+	AND CASE
+		WHEN cardinality(@login_type :: login_type[]) > 0 THEN
+			login_type = ANY(@login_type :: login_type[])
+		ELSE true
+	END
+	-- Filter by scope
+	AND CASE
+		WHEN cardinality(@scope :: api_key_scope[]) > 0 THEN
+			scope = ANY(@scope :: api_key_scope[])
+		ELSE true
+	END
+	-- Filter by last_used_before
+	AND CASE
+		WHEN @last_used_before :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			last_used < @last_used_before
+		ELSE true
+	END
+	-- Filter by last_used_after
+	AND CASE
+		WHEN @last_used_after :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			last_used > @last_used_after
+		ELSE true
+	END
+	-- Filter by expires_before
+	AND CASE
+		WHEN @expires_before :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			expires_at < @expires_before
+		ELSE true
+	END
+	-- Filter by expires_after
+	AND CASE
+		WHEN @expires_after :: timestamp with time zone != '0001-01-01 00:00:00' THEN
+			expires_at > @expires_after
+		ELSE true
+	END
+ORDER BY
+	(created_at, id) ASC;
+
+-- name: GetSessionsByUserID :many
+-- Sessions are the unexpired API keys created by logging in, as opposed to
+-- tokens.
+SELECT
+	*
+FROM
+	api_keys
+WHERE
+	user_id = @user_id
+	AND login_type != 'token'
+	AND expires_at > now()
+ORDER BY
+	last_used DESC;
+
 -- name: InsertAPIKey :one
 INSERT INTO
 	api_keys (
@@ -27,7 +92,8 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		user_agent
 	)
 VALUES
 	(@id,
@@ -36,7 +102,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE @lifetime_seconds::bigint
 	 END
-	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope) RETURNING *;
+	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope, @user_agent) RETURNING *;
 
 -- name: UpdateAPIKeyByID :exec
 UPDATE
@@ -44,7 +110,8 @@ UPDATE
 SET
 	last_used = $2,
 	expires_at = $3,
-	ip_address = $4
+	ip_address = $4,
+	user_agent = $5
 WHERE
 	id = $1;
 
@@ -60,3 +127,11 @@ DELETE FROM
 	api_keys
 WHERE
 	user_id = $1;
+
+-- name: DeleteSessionsByUserID :exec
+DELETE FROM
+	api_keys
+WHERE
+	user_id = @user_id
+	AND login_type != 'token'
+	AND id != @except_id;
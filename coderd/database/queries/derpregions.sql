@@ -0,0 +1,61 @@
+-- name: GetDERPRegions :many
+SELECT * FROM derp_regions ORDER BY id;
+
+-- name: GetDERPRegionByID :one
+SELECT * FROM derp_regions WHERE id = $1;
+
+-- name: InsertDERPRegion :one
+INSERT INTO
+	derp_regions (id, code, name, disabled, created_at, updated_at)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateDERPRegionByID :one
+UPDATE
+	derp_regions
+SET
+	code = $2,
+	name = $3,
+	disabled = $4,
+	updated_at = $5
+WHERE
+	id = $1
+RETURNING *;
+
+-- name: DeleteDERPRegionByID :exec
+-- Deletes the region along with its nodes.
+DELETE FROM derp_regions WHERE id = $1;
+
+-- name: GetDERPNodes :many
+SELECT * FROM derp_nodes ORDER BY region_id, name;
+
+-- name: GetDERPNodeByName :one
+SELECT * FROM derp_nodes WHERE name = $1;
+
+-- name: InsertDERPNode :one
+INSERT INTO
+	derp_nodes (name, region_id, host_name, ipv4, ipv6, derp_port, stun_port, stun_only, force_http, disabled, created_at, updated_at)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING *;
+
+-- name: UpdateDERPNodeByName :one
+UPDATE
+	derp_nodes
+SET
+	host_name = $2,
+	ipv4 = $3,
+	ipv6 = $4,
+	derp_port = $5,
+	stun_port = $6,
+	stun_only = $7,
+	force_http = $8,
+	disabled = $9,
+	updated_at = $10
+WHERE
+	name = $1
+RETURNING *;
+
+-- name: DeleteDERPNodeByName :exec
+DELETE FROM derp_nodes WHERE name = $1;
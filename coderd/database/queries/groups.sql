@@ -115,6 +115,13 @@ DELETE FROM
 WHERE
 	user_id = $1;
 
+-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1 AND
+	group_id = $2;
+
 -- name: DeleteGroupByID :exec
 DELETE FROM
 	groups
@@ -0,0 +1,71 @@
+-- name: GetNotificationsByUserID :many
+SELECT
+	*
+FROM
+	notifications
+WHERE
+	user_id = @user_id
+	AND CASE
+		WHEN @unread :: boolean THEN read_at IS NULL
+		ELSE true
+	END
+ORDER BY
+	created_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0);
+
+-- name: GetUserNotificationPreferences :many
+SELECT
+	*
+FROM
+	user_notification_preferences
+WHERE
+	user_id = $1;
+
+-- name: InsertNotification :one
+INSERT INTO
+	notifications (
+		id,
+		user_id,
+		workspace_id,
+		kind,
+		title,
+		body,
+		created_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: UpdateNotificationsReadAtByUserID :exec
+UPDATE
+	notifications
+SET
+	read_at = @read_at
+WHERE
+	user_id = @user_id
+	AND read_at IS NULL
+	AND CASE
+		-- An empty list of IDs marks every notification of the user as read.
+		WHEN cardinality(@ids :: uuid [ ]) > 0 THEN id = ANY(@ids :: uuid [ ])
+		ELSE true
+	END;
+
+-- name: UpsertUserNotificationPreference :one
+INSERT INTO
+	user_notification_preferences (
+		user_id,
+		kind,
+		inbox,
+		email,
+		webhook
+	)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT
+	(user_id, kind)
+DO UPDATE SET
+	inbox = $3,
+	email = $4,
+	webhook = $5
+RETURNING *;
@@ -0,0 +1,54 @@
+-- name: UpsertTailnetCoordinator :one
+INSERT INTO
+	tailnet_coordinators (id, heartbeat_at)
+VALUES
+	($1, $2)
+ON CONFLICT (id)
+DO UPDATE SET heartbeat_at = EXCLUDED.heartbeat_at
+RETURNING *;
+
+-- name: UpdateTailnetCoordinatorHeartbeat :one
+UPDATE tailnet_coordinators SET heartbeat_at = $2 WHERE id = $1 RETURNING *;
+
+-- name: DeleteTailnetCoordinator :exec
+DELETE FROM tailnet_coordinators WHERE id = $1;
+
+-- name: DeleteTailnetCoordinatorsHeartbeatBefore :exec
+DELETE FROM tailnet_coordinators WHERE heartbeat_at < $1;
+
+-- name: UpsertTailnetAgent :one
+INSERT INTO
+	tailnet_agents (id, coordinator_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET updated_at = EXCLUDED.updated_at, node = EXCLUDED.node
+RETURNING *;
+
+-- name: DeleteTailnetAgent :exec
+DELETE FROM tailnet_agents WHERE id = $1 AND coordinator_id = $2;
+
+-- name: GetTailnetAgents :many
+-- Returns the agent on every coordinator it's connected to, most recently
+-- updated first.
+SELECT * FROM tailnet_agents WHERE id = $1 ORDER BY updated_at DESC;
+
+-- name: GetTailnetAgentsByIDs :many
+-- Returns the agents on every coordinator they're connected to, most recently
+-- updated first.
+SELECT * FROM tailnet_agents WHERE id = ANY(@ids :: uuid [ ]) ORDER BY updated_at DESC;
+
+-- name: UpsertTailnetClient :one
+INSERT INTO
+	tailnet_clients (id, coordinator_id, agent_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET agent_id = EXCLUDED.agent_id, updated_at = EXCLUDED.updated_at, node = EXCLUDED.node
+RETURNING *;
+
+-- name: DeleteTailnetClient :exec
+DELETE FROM tailnet_clients WHERE id = $1 AND coordinator_id = $2;
+
+-- name: GetTailnetClientsForAgent :many
+SELECT * FROM tailnet_clients WHERE agent_id = $1;
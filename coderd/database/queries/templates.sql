@@ -70,10 +70,23 @@ INSERT INTO
 		icon,
 		user_acl,
 		group_acl,
-		display_name
+		display_name,
+		max_ttl,
+		min_autostart_interval,
+		quiet_hours_schedule,
+		allow_user_autostop,
+		inactivity_ttl,
+		dormancy_deletion_ttl,
+		activity_bump,
+		activity_bump_threshold,
+		require_active_version,
+		build_retries,
+		build_retry_backoff,
+		rollback_failed_updates,
+		allow_workspace_networking
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27) RETURNING *;
 
 -- name: UpdateTemplateActiveVersionByID :exec
 UPDATE
@@ -102,7 +115,20 @@ SET
 	default_ttl = $4,
 	name = $5,
 	icon = $6,
-	display_name = $7
+	display_name = $7,
+	max_ttl = $8,
+	min_autostart_interval = $9,
+	quiet_hours_schedule = $10,
+	allow_user_autostop = $11,
+	inactivity_ttl = $12,
+	dormancy_deletion_ttl = $13,
+	activity_bump = $14,
+	activity_bump_threshold = $15,
+	require_active_version = $16,
+	build_retries = $17,
+	build_retry_backoff = $18,
+	rollback_failed_updates = $19,
+	allow_workspace_networking = $20
 WHERE
 	id = $1
 RETURNING
@@ -0,0 +1,42 @@
+-- name: GetUserInvitationByID :one
+SELECT
+	*
+FROM
+	user_invitations
+WHERE
+	id = $1;
+
+-- name: GetUserInvitations :many
+SELECT
+	*
+FROM
+	user_invitations
+ORDER BY
+	created_at ASC;
+
+-- name: InsertUserInvitation :one
+INSERT INTO
+	user_invitations (
+		id,
+		hashed_secret,
+		email,
+		organization_id,
+		created_by,
+		created_at,
+		expires_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: DeleteUserInvitationByID :exec
+DELETE FROM
+	user_invitations
+WHERE
+	id = $1;
+
+-- name: ConsumeUserInvitationByID :one
+-- Deletes the invitation and returns it, so only one request can use it.
+DELETE FROM
+	user_invitations
+WHERE
+	id = $1 RETURNING *;
@@ -0,0 +1,32 @@
+-- name: GetUserPasswordResetByID :one
+SELECT
+	*
+FROM
+	user_password_resets
+WHERE
+	id = $1;
+
+-- name: InsertUserPasswordReset :one
+INSERT INTO
+	user_password_resets (
+		id,
+		hashed_secret,
+		user_id,
+		created_at,
+		expires_at
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ConsumeUserPasswordResetByID :one
+-- Deletes the password reset and returns it, so only one request can use it.
+DELETE FROM
+	user_password_resets
+WHERE
+	id = $1 RETURNING *;
+
+-- name: DeleteUserPasswordResetsByUserID :exec
+DELETE FROM
+	user_password_resets
+WHERE
+	user_id = $1;
@@ -0,0 +1,56 @@
+-- name: GetUserTwoFactorByUserID :one
+SELECT
+	*
+FROM
+	user_two_factors
+WHERE
+	user_id = $1;
+
+-- name: InsertUserTwoFactor :one
+INSERT INTO
+	user_two_factors (
+		user_id,
+		created_at,
+		updated_at,
+		totp_secret,
+		hashed_recovery_codes
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: UpdateUserTwoFactorEnabled :one
+UPDATE
+	user_two_factors
+SET
+	enabled = $2,
+	updated_at = $3
+WHERE
+	user_id = $1 RETURNING *;
+
+-- name: UpdateUserTwoFactorLastTOTPStep :one
+UPDATE
+	user_two_factors
+SET
+	last_totp_step = $2,
+	updated_at = $3
+WHERE
+	user_id = $1
+	-- Codes can only be used once.
+	AND last_totp_step < $2 RETURNING *;
+
+-- name: ConsumeUserTwoFactorRecoveryCode :one
+UPDATE
+	user_two_factors
+SET
+	hashed_recovery_codes = array_remove(hashed_recovery_codes, @code :: text),
+	updated_at = @updated_at
+WHERE
+	user_id = @user_id
+	-- Codes can only be used once.
+	AND @code :: text = ANY(hashed_recovery_codes) RETURNING *;
+
+-- name: DeleteUserTwoFactorByUserID :exec
+DELETE FROM
+	user_two_factors
+WHERE
+	user_id = $1;
@@ -79,3 +79,20 @@ SET
 	version = $2
 WHERE
 	id = $1;
+
+-- name: RegenerateWorkspaceAgentAuthTokensByWorkspaceID :exec
+UPDATE
+	workspace_agents
+SET
+	auth_token = gen_random_uuid()
+WHERE
+	resource_id IN (
+		SELECT
+			workspace_resources.id
+		FROM
+			workspace_resources
+		INNER JOIN
+			workspace_builds ON workspace_builds.job_id = workspace_resources.job_id
+		WHERE
+			workspace_builds.workspace_id = $1
+	);
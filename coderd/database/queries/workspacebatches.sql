@@ -0,0 +1,103 @@
+-- name: InsertWorkspaceBatch :one
+INSERT INTO
+	workspace_batches (
+		id,
+		created_at,
+		initiator_id,
+		action,
+		query,
+		concurrency,
+		heartbeat_at,
+		scope
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: InsertWorkspaceBatchItem :one
+INSERT INTO
+	workspace_batch_items (
+		batch_id,
+		workspace_id,
+		position,
+		workspace_name,
+		owner_name,
+		status,
+		error
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: GetWorkspaceBatchByID :one
+SELECT
+	*
+FROM
+	workspace_batches
+WHERE
+	id = $1;
+
+-- name: GetWorkspaceBatchItemsByBatchID :many
+SELECT
+	*
+FROM
+	workspace_batch_items
+WHERE
+	batch_id = $1
+ORDER BY
+	position ASC;
+
+-- name: UpdateWorkspaceBatchItem :exec
+UPDATE
+	workspace_batch_items
+SET
+	status = $3,
+	build_id = $4,
+	error = $5
+WHERE
+	batch_id = $1
+	AND workspace_id = $2;
+
+-- name: UpdateWorkspaceBatchHeartbeat :exec
+UPDATE
+	workspace_batches
+SET
+	heartbeat_at = $2
+WHERE
+	id = $1;
+
+-- name: UpdateWorkspaceBatchCompletedAt :exec
+UPDATE
+	workspace_batches
+SET
+	completed_at = $2
+WHERE
+	id = $1;
+
+-- Claims the oldest incomplete batch whose replica stopped heartbeating, so
+-- it can be resumed. SKIP LOCKED keeps replicas from claiming the same batch.
+-- name: AcquireStaleWorkspaceBatch :one
+UPDATE
+	workspace_batches
+SET
+	heartbeat_at = @now :: timestamptz
+WHERE
+	id = (
+		SELECT
+			id
+		FROM
+			workspace_batches AS nested
+		WHERE
+			nested.completed_at IS NULL
+			AND nested.heartbeat_at < @stale_before :: timestamptz
+		ORDER BY
+			nested.created_at FOR
+		UPDATE
+			SKIP LOCKED
+		LIMIT
+			1
+	) RETURNING *;
+
+-- name: DeleteOldWorkspaceBatches :exec
+DELETE FROM
+	workspace_batches
+WHERE
+	completed_at < @completed_before :: timestamptz;
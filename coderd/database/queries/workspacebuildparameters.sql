@@ -0,0 +1,30 @@
+-- name: GetWorkspaceBuildParameters :many
+SELECT
+	*
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	name ASC;
+
+-- name: InsertWorkspaceBuildParameters :exec
+INSERT INTO
+	workspace_build_parameters (
+		workspace_build_id,
+		name,
+		source_scheme,
+		source_value,
+		destination_scheme
+	)
+SELECT
+	@workspace_build_id :: uuid,
+	name,
+	source_scheme,
+	source_value,
+	destination_scheme
+FROM
+	parameter_values
+WHERE
+	scope = 'workspace'
+	AND scope_id = @workspace_id :: uuid;
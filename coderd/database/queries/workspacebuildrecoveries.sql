@@ -0,0 +1,19 @@
+-- name: InsertWorkspaceBuildRecovery :exec
+INSERT INTO
+	workspace_build_recoveries (
+		workspace_build_id,
+		reason,
+		template_version_id,
+		target_build_id,
+		recover_at
+	)
+VALUES
+	($1, $2, $3, $4, $5);
+
+-- name: GetWorkspaceBuildRecoveryByBuildID :one
+SELECT
+	*
+FROM
+	workspace_build_recoveries
+WHERE
+	workspace_build_id = $1;
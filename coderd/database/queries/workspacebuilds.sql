@@ -75,6 +75,19 @@ ORDER BY
 LIMIT
 	1;
 
+-- name: GetLatestWorkspaceBuildByWorkspaceIDAndReason :one
+SELECT
+	*
+FROM
+	workspace_builds
+WHERE
+	workspace_id = $1
+	AND reason = $2
+ORDER BY
+    build_number desc
+LIMIT
+	1;
+
 -- name: GetLatestWorkspaceBuildsByWorkspaceIDs :many
 SELECT wb.*
 FROM (
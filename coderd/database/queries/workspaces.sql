@@ -132,6 +132,12 @@ WHERE
 			name ILIKE '%' || @name || '%'
 		ELSE true
 	END
+	-- Filter by dormancy
+	AND CASE
+		WHEN @dormant :: boolean THEN
+			dormant_at IS NOT NULL
+		ELSE true
+	END
 	-- Authorize Filter clause will be injected below in GetAuthorizedWorkspaces
 	-- @authorize_filter
 ORDER BY
@@ -213,6 +219,14 @@ WHERE
 	AND deleted = false
 RETURNING *;
 
+-- name: UpdateWorkspaceAutomaticUpdates :exec
+UPDATE
+	workspaces
+SET
+	automatic_updates = $2
+WHERE
+	id = $1;
+
 -- name: UpdateWorkspaceAutostart :exec
 UPDATE
 	workspaces
@@ -221,6 +235,22 @@ SET
 WHERE
 	id = $1;
 
+-- name: UpdateWorkspaceOwner :exec
+UPDATE
+	workspaces
+SET
+	owner_id = $2
+WHERE
+	id = $1;
+
+-- name: UpdateWorkspaceDormantAt :exec
+UPDATE
+	workspaces
+SET
+	dormant_at = $2
+WHERE
+	id = $1;
+
 -- name: UpdateWorkspaceTTL :exec
 UPDATE
 	workspaces
@@ -0,0 +1,52 @@
+-- name: GetWorkspaceScheduleWindowsByWorkspaceIDs :many
+SELECT
+	*
+FROM
+	workspace_schedule_windows
+WHERE
+	workspace_id = ANY(@ids :: uuid [ ])
+ORDER BY
+	created_at ASC, id ASC;
+
+-- name: InsertWorkspaceScheduleWindow :one
+INSERT INTO
+	workspace_schedule_windows (
+		id,
+		workspace_id,
+		start_schedule,
+		stop_schedule,
+		created_at
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: DeleteWorkspaceScheduleWindowsByWorkspaceID :exec
+DELETE FROM
+	workspace_schedule_windows
+WHERE
+	workspace_id = $1;
+
+-- name: GetWorkspaceScheduleSkipDatesByWorkspaceID :many
+SELECT
+	*
+FROM
+	workspace_schedule_skip_dates
+WHERE
+	workspace_id = $1
+ORDER BY
+	date ASC;
+
+-- name: InsertWorkspaceScheduleSkipDate :one
+INSERT INTO
+	workspace_schedule_skip_dates (
+		workspace_id,
+		date
+	)
+VALUES
+	($1, $2) RETURNING *;
+
+-- name: DeleteWorkspaceScheduleSkipDatesByWorkspaceID :exec
+DELETE FROM
+	workspace_schedule_skip_dates
+WHERE
+	workspace_id = $1;
@@ -0,0 +1,39 @@
+-- name: GetWorkspaceTransferByWorkspaceID :one
+SELECT
+	*
+FROM
+	workspace_transfers
+WHERE
+	workspace_id = $1;
+
+-- name: GetWorkspaceTransfersByToUserID :many
+SELECT
+	*
+FROM
+	workspace_transfers
+WHERE
+	to_user_id = $1
+ORDER BY
+	created_at ASC;
+
+-- name: UpsertWorkspaceTransfer :one
+INSERT INTO
+	workspace_transfers (
+		workspace_id,
+		from_user_id,
+		to_user_id,
+		created_at
+	)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (workspace_id) DO UPDATE SET
+	from_user_id = $2,
+	to_user_id = $3,
+	created_at = $4
+RETURNING *;
+
+-- name: DeleteWorkspaceTransferByWorkspaceID :exec
+DELETE FROM
+	workspace_transfers
+WHERE
+	workspace_id = $1;
@@ -0,0 +1,236 @@
+// Package redistest provides an in-memory server that speaks the subset of
+// the Redis protocol used by database.NewPubsubRedis.
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Server is an in-memory Redis pubsub broker.
+type Server struct {
+	listener net.Listener
+	password string
+
+	mutex sync.Mutex
+	conns map[*conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// New starts a server that's closed when the test ends. If password isn't
+// empty, clients must authenticate with it.
+func New(t testing.TB, password string) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &Server{
+		listener: listener,
+		password: password,
+		conns:    map[*conn]struct{}{},
+	}
+	server.wg.Add(1)
+	go server.serve()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// URL returns the URL clients connect to the server with.
+func (s *Server) URL() string {
+	if s.password != "" {
+		return "redis://:" + s.password + "@" + s.listener.Addr().String()
+	}
+	return "redis://" + s.listener.Addr().String()
+}
+
+// DropConnections closes the connections of all clients, e.g. to test that
+// they reconnect.
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+// Close stops the server and closes the connections of all clients.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.DropConnections()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &conn{
+			Conn:          netConn,
+			authenticated: s.password == "",
+			channels:      map[string]struct{}{},
+		}
+		s.mutex.Lock()
+		s.conns[c] = struct{}{}
+		s.mutex.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(c)
+			s.mutex.Lock()
+			delete(s.conns, c)
+			s.mutex.Unlock()
+			_ = c.Close()
+		}()
+	}
+}
+
+func (s *Server) handle(c *conn) {
+	reader := bufio.NewReader(c)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				_ = c.writeError("ERR " + err.Error())
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		command := strings.ToUpper(args[0])
+		if !c.authenticated && command != "AUTH" {
+			_ = c.writeError("NOAUTH Authentication required.")
+			continue
+		}
+		switch command {
+		case "AUTH":
+			if args[len(args)-1] != s.password {
+				_ = c.writeError("WRONGPASS invalid username-password pair")
+				continue
+			}
+			c.authenticated = true
+			_ = c.write("+OK\r\n")
+		case "PING":
+			_ = c.write("+PONG\r\n")
+		case "PUBLISH":
+			if len(args) != 3 {
+				_ = c.writeError("ERR wrong number of arguments for 'publish' command")
+				continue
+			}
+			_ = c.write(":" + strconv.Itoa(s.publish(args[1], args[2])) + "\r\n")
+		case "SUBSCRIBE", "UNSUBSCRIBE":
+			kind := strings.ToLower(command)
+			for _, channel := range args[1:] {
+				s.mutex.Lock()
+				if command == "SUBSCRIBE" {
+					c.channels[channel] = struct{}{}
+				} else {
+					delete(c.channels, channel)
+				}
+				count := len(c.channels)
+				s.mutex.Unlock()
+				_ = c.write("*3\r\n" + bulk(kind) + bulk(channel) + ":" + strconv.Itoa(count) + "\r\n")
+			}
+		case "QUIT":
+			_ = c.write("+OK\r\n")
+			return
+		default:
+			_ = c.writeError("ERR unknown command '" + args[0] + "'")
+		}
+	}
+}
+
+// publish sends a message to the subscribers of a channel, and returns how
+// many there are.
+func (s *Server) publish(channel, message string) int {
+	s.mutex.Lock()
+	subscribers := make([]*conn, 0)
+	for c := range s.conns {
+		if _, ok := c.channels[channel]; ok {
+			subscribers = append(subscribers, c)
+		}
+	}
+	s.mutex.Unlock()
+	for _, c := range subscribers {
+		_ = c.write("*3\r\n" + bulk("message") + bulk(channel) + bulk(message))
+	}
+	return len(subscribers)
+}
+
+type conn struct {
+	net.Conn
+	writeMutex    sync.Mutex
+	authenticated bool
+	// channels is guarded by the mutex of the server.
+	channels map[string]struct{}
+}
+
+func (c *conn) write(reply string) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, err := io.WriteString(c, reply)
+	return err
+}
+
+func (c *conn) writeError(message string) error {
+	return c.write("-" + message + "\r\n")
+}
+
+func bulk(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		// Inline commands, e.g. from telnet.
+		return strings.Fields(line), nil
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, errors.New("invalid multibulk length")
+	}
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errors.New("expected '$'")
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+	return args, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
@@ -0,0 +1,196 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+)
+
+// debugCoordinator returns the agents and clients connected to the tailnet
+// coordinator of this replica. Browsers are served an HTML page.
+func (api *API) debugCoordinator(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceDebugInfo) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	debug, err := api.convertCoordinatorDebug(ctx, (*api.TailnetCoordinator.Load()).Debug())
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching coordinator agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		httpapi.Write(ctx, rw, http.StatusOK, debug)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+	err = debugCoordinatorTemplate.Execute(rw, debug)
+	if err != nil {
+		api.Logger.Warn(ctx, "render coordinator debug page", slog.Error(err))
+	}
+}
+
+// convertCoordinatorDebug looks up the workspaces of the agents known to
+// the coordinator. Agents that aren't in the database, e.g. because their
+// build was deleted, are returned without them.
+func (api *API) convertCoordinatorDebug(ctx context.Context, debug tailnet.CoordinatorDebug) (codersdk.DebugCoordinator, error) {
+	converted := codersdk.DebugCoordinator{
+		ReplicaID: debug.ReplicaID,
+		Agents:    make([]codersdk.DebugCoordinatorAgent, 0, len(debug.Agents)),
+	}
+	for _, agent := range debug.Agents {
+		apiAgent := codersdk.DebugCoordinatorAgent{
+			ID:        agent.ID,
+			Connected: agent.Connected,
+			ReplicaID: agent.ReplicaID,
+			Node:      convertDebugNode(agent.Node),
+			Clients:   make([]codersdk.DebugCoordinatorClient, 0, len(agent.Clients)),
+		}
+		for _, client := range agent.Clients {
+			apiAgent.Clients = append(apiAgent.Clients, codersdk.DebugCoordinatorClient{
+				ID:   client.ID,
+				Node: convertDebugNode(client.Node),
+			})
+		}
+		err := api.debugAgentWorkspace(ctx, &apiAgent)
+		if err != nil {
+			return codersdk.DebugCoordinator{}, err
+		}
+		converted.Agents = append(converted.Agents, apiAgent)
+	}
+	return converted, nil
+}
+
+func (api *API) debugAgentWorkspace(ctx context.Context, agent *codersdk.DebugCoordinatorAgent) error {
+	dbAgent, err := api.Database.GetWorkspaceAgentByID(ctx, agent.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get workspace agent: %w", err)
+	}
+	agent.Name = dbAgent.Name
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, dbAgent.ResourceID)
+	if err != nil {
+		return xerrors.Errorf("get workspace resource: %w", err)
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Agents of template version imports don't belong to workspaces.
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get workspace build: %w", err)
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		return xerrors.Errorf("get workspace: %w", err)
+	}
+	owner, err := api.Database.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		return xerrors.Errorf("get workspace owner: %w", err)
+	}
+	agent.WorkspaceID = workspace.ID
+	agent.WorkspaceName = workspace.Name
+	agent.OwnerName = owner.Username
+	return nil
+}
+
+func convertDebugNode(node *tailnet.Node) *codersdk.DebugNode {
+	if node == nil {
+		return nil
+	}
+	return &codersdk.DebugNode{
+		AsOf:          node.AsOf,
+		PreferredDERP: node.PreferredDERP,
+		DERPLatency:   node.DERPLatency,
+		Addresses:     convertPrefixes(node.Addresses),
+		Endpoints:     node.Endpoints,
+	}
+}
+
+func convertPrefixes(prefixes []netip.Prefix) []string {
+	converted := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		converted = append(converted, prefix.String())
+	}
+	return converted
+}
+
+var debugCoordinatorTemplate = template.Must(template.New("coordinator").Funcs(template.FuncMap{
+	"isNil": func(id uuid.UUID) bool {
+		return id == uuid.Nil
+	},
+	"since": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+	"latency": func(seconds float64) string {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond * 100).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Tailnet coordinator</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>Tailnet coordinator</h1>
+<p>{{ if isNil .ReplicaID }}Single replica{{ else }}Replica {{ .ReplicaID }}{{ end }}</p>
+<h2>Agents ({{ len .Agents }})</h2>
+{{ define "node" }}{{ if . }}
+<div>Preferred DERP: {{ .PreferredDERP }}</div>
+<div>DERP latency:{{ range $region, $latency := .DERPLatency }} {{ $region }}={{ latency $latency }}{{ end }}</div>
+<div>Endpoints:{{ range .Endpoints }} {{ . }}{{ else }} <span class="muted">none</span>{{ end }}</div>
+<div>Addresses:{{ range .Addresses }} {{ . }}{{ end }}</div>
+<div>As of: {{ since .AsOf }} ago</div>
+{{ else }}<span class="muted">not registered</span>{{ end }}{{ end }}
+<table>
+<tr><th>Agent</th><th>Connection</th><th>Node</th><th>Clients</th></tr>
+{{ range .Agents }}
+<tr>
+<td>
+<div>{{ if .WorkspaceName }}{{ .OwnerName }}/{{ .WorkspaceName }}.{{ .Name }}{{ else }}<span class="muted">unknown workspace</span>{{ end }}</div>
+<div class="muted">{{ .ID }}</div>
+</td>
+<td>
+{{ if .Connected }}Connected to this replica{{ else if isNil .ReplicaID }}Not connected{{ else }}Connected to replica {{ .ReplicaID }}{{ end }}
+</td>
+<td>{{ template "node" .Node }}</td>
+<td>
+{{ range .Clients }}
+<div><b>{{ .ID }}</b></div>
+{{ template "node" .Node }}
+{{ else }}<span class="muted">none</span>{{ end }}
+</td>
+</tr>
+{{ end }}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,101 @@
+package coderd_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/coder/testutil"
+)
+
+func TestDebugCoordinator(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "main",
+							Auth: &proto.Agent_Token{Token: uuid.NewString()},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	workspace, err := client.Workspace(ctx, workspace.ID)
+	require.NoError(t, err)
+	agentID := workspace.LatestBuild.Resources[0].Agents[0].ID
+
+	// Register the agent with the coordinator, like a connecting agent.
+	coordinator := *api.TailnetCoordinator.Load()
+	agentConn, serverConn := net.Pipe()
+	defer agentConn.Close()
+	sendNode, _ := tailnet.ServeCoordinator(agentConn, func(nodes []*tailnet.Node) error {
+		return nil
+	})
+	go func() {
+		_ = coordinator.ServeAgent(serverConn, agentID)
+	}()
+	sendNode(&tailnet.Node{PreferredDERP: 1})
+	require.Eventually(t, func() bool {
+		return coordinator.Node(agentID) != nil
+	}, testutil.WaitShort, testutil.IntervalFast)
+
+	debug, err := client.DebugCoordinator(ctx)
+	require.NoError(t, err)
+	require.Len(t, debug.Agents, 1)
+	agent := debug.Agents[0]
+	require.Equal(t, agentID, agent.ID)
+	require.Equal(t, "main", agent.Name)
+	require.Equal(t, workspace.ID, agent.WorkspaceID)
+	require.Equal(t, workspace.Name, agent.WorkspaceName)
+	require.Equal(t, workspace.OwnerName, agent.OwnerName)
+	require.True(t, agent.Connected)
+	require.NotNil(t, agent.Node)
+	require.Equal(t, 1, agent.Node.PreferredDERP)
+
+	// Browsers are served a page.
+	res, err := client.Request(ctx, http.MethodGet, "/api/v2/debug/coordinator", nil, func(r *http.Request) {
+		r.Header.Set("Accept", "text/html")
+	})
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Contains(t, res.Header.Get("Content-Type"), "text/html")
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), workspace.Name+".main")
+
+	// Only owners may see it.
+	member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	_, err = member.DebugCoordinator(ctx)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
@@ -0,0 +1,193 @@
+package coderd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	"tailscale.com/derp/derphttp"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+)
+
+// derpNodeCheckTimeout is how long a DERP node has to accept a connection
+// and answer a ping.
+const derpNodeCheckTimeout = 10 * time.Second
+
+// derpHealthChecker connects to every DERP node of the DERP map periodically
+// and whenever the map changes, and records whether the nodes are reachable
+// from this replica.
+type derpHealthChecker struct {
+	logger  slog.Logger
+	derpMap func() *tailcfg.DERPMap
+
+	mutex   sync.RWMutex
+	regions map[int]derpRegionHealth
+
+	closeFunc context.CancelFunc
+	closed    chan struct{}
+}
+
+type derpRegionHealth struct {
+	region codersdk.DERPRegionHealth
+	nodes  map[string]codersdk.DERPNodeHealth
+}
+
+// newDERPHealthChecker checks the nodes of derpMap every interval, and when
+// changed receives a value.
+func newDERPHealthChecker(logger slog.Logger, interval time.Duration, derpMap func() *tailcfg.DERPMap, changed <-chan struct{}) *derpHealthChecker {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	checker := &derpHealthChecker{
+		logger:    logger,
+		derpMap:   derpMap,
+		regions:   map[int]derpRegionHealth{},
+		closeFunc: cancelFunc,
+		closed:    make(chan struct{}),
+	}
+	go checker.run(ctx, interval, changed)
+	return checker
+}
+
+func (h *derpHealthChecker) run(ctx context.Context, interval time.Duration, changed <-chan struct{}) {
+	defer close(h.closed)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// check connects to the DERP nodes of every region concurrently. Results of
+// regions that were removed from the map are dropped.
+func (h *derpHealthChecker) check(ctx context.Context) {
+	derpMap := h.derpMap()
+	if derpMap == nil {
+		return
+	}
+	var (
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		regions = make(map[int]derpRegionHealth, len(derpMap.Regions))
+	)
+	for _, region := range derpMap.Regions {
+		regionHealth := derpRegionHealth{
+			nodes: map[string]codersdk.DERPNodeHealth{},
+		}
+		regions[region.RegionID] = regionHealth
+		for _, node := range region.Nodes {
+			if node.STUNOnly {
+				continue
+			}
+			region, node := region, node
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				latency, err := h.checkNode(ctx, region, node)
+				nodeHealth := codersdk.DERPNodeHealth{
+					Healthy:             err == nil,
+					CheckedAt:           database.Now(),
+					LatencyMilliseconds: float64(latency.Microseconds()) / 1000,
+				}
+				if err != nil {
+					nodeHealth.Error = err.Error()
+				}
+				mutex.Lock()
+				regionHealth.nodes[node.Name] = nodeHealth
+				mutex.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	for regionID, regionHealth := range regions {
+		if len(regionHealth.nodes) == 0 {
+			// Regions that only serve STUN aren't checked.
+			continue
+		}
+		errs := make([]string, 0, len(regionHealth.nodes))
+		for name, nodeHealth := range regionHealth.nodes {
+			if nodeHealth.CheckedAt.After(regionHealth.region.CheckedAt) {
+				regionHealth.region.CheckedAt = nodeHealth.CheckedAt
+			}
+			if nodeHealth.Healthy {
+				regionHealth.region.Healthy = true
+				continue
+			}
+			errs = append(errs, name+": "+nodeHealth.Error)
+		}
+		if !regionHealth.region.Healthy {
+			sort.Strings(errs)
+			regionHealth.region.Error = strings.Join(errs, "; ")
+		}
+		regions[regionID] = regionHealth
+	}
+
+	h.mutex.Lock()
+	h.regions = regions
+	h.mutex.Unlock()
+}
+
+// checkNode connects to a DERP node and returns the round trip time of a ping.
+func (h *derpHealthChecker) checkNode(ctx context.Context, region *tailcfg.DERPRegion, node *tailcfg.DERPNode) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, derpNodeCheckTimeout)
+	defer cancel()
+
+	client := derphttp.NewRegionClient(key.NewNode(), tailnet.Logger(h.logger.Named("derphttp")), func() *tailcfg.DERPRegion {
+		return &tailcfg.DERPRegion{
+			RegionID:   region.RegionID,
+			RegionCode: region.RegionCode,
+			RegionName: region.RegionName,
+			Nodes:      []*tailcfg.DERPNode{node},
+		}
+	})
+	defer client.Close()
+	err := client.Connect(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("connect: %w", err)
+	}
+	// Pongs are only handled while receiving.
+	go func() {
+		for {
+			_, err := client.Recv()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	start := time.Now()
+	err = client.Ping(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("ping: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+// health returns the results of the last check of a region and its nodes.
+func (h *derpHealthChecker) health(regionID int) (codersdk.DERPRegionHealth, map[string]codersdk.DERPNodeHealth) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	regionHealth := h.regions[regionID]
+	return regionHealth.region, regionHealth.nodes
+}
+
+func (h *derpHealthChecker) Close() {
+	h.closeFunc()
+	<-h.closed
+}
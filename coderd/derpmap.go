@@ -0,0 +1,699 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/codersdk"
+)
+
+// derpMapChannel is published to whenever a replica changes the DERP regions
+// managed through the API.
+const derpMapChannel = "derp_map"
+
+// CurrentDERPMap returns the DERP map served to agents and clients. It's the
+// static DERP map merged with the enabled regions managed through the API.
+func (api *API) CurrentDERPMap() *tailcfg.DERPMap {
+	return api.currentDERPMap.Load()
+}
+
+// refreshDERPMap merges the regions in the database into the static DERP
+// map, and notifies the subscribers of the DERP map if it changed.
+func (api *API) refreshDERPMap(ctx context.Context) error {
+	regions, err := api.Database.GetDERPRegions(ctx)
+	if err != nil {
+		return xerrors.Errorf("get derp regions: %w", err)
+	}
+	nodes, err := api.Database.GetDERPNodes(ctx)
+	if err != nil {
+		return xerrors.Errorf("get derp nodes: %w", err)
+	}
+	derpMap := mergeDERPMap(api.DERPMap, regions, nodes)
+
+	api.derpMapMutex.Lock()
+	defer api.derpMapMutex.Unlock()
+	if reflect.DeepEqual(api.currentDERPMap.Load(), derpMap) {
+		return nil
+	}
+	api.currentDERPMap.Store(derpMap)
+	for listener := range api.derpMapListeners {
+		select {
+		case listener <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// publishDERPMap refreshes the DERP map of this replica and notifies the
+// other replicas after the regions in the database changed.
+func (api *API) publishDERPMap(ctx context.Context) {
+	err := api.refreshDERPMap(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "refresh derp map", slog.Error(err))
+	}
+	err = api.Pubsub.Publish(derpMapChannel, []byte{})
+	if err != nil {
+		api.Logger.Warn(ctx, "publish derp map update", slog.Error(err))
+	}
+}
+
+// subscribeDERPMap returns a channel that receives a value whenever the DERP
+// map changes. Changes in quick succession may be coalesced.
+func (api *API) subscribeDERPMap() (<-chan struct{}, func()) {
+	listener := make(chan struct{}, 1)
+	api.derpMapMutex.Lock()
+	api.derpMapListeners[listener] = struct{}{}
+	api.derpMapMutex.Unlock()
+	return listener, func() {
+		api.derpMapMutex.Lock()
+		delete(api.derpMapListeners, listener)
+		api.derpMapMutex.Unlock()
+	}
+}
+
+// mergeDERPMap adds the enabled regions and nodes managed through the API to
+// a copy of the static DERP map. Regions without enabled nodes are left out.
+func mergeDERPMap(static *tailcfg.DERPMap, regions []database.DERPRegion, nodes []database.DERPNode) *tailcfg.DERPMap {
+	derpMap := &tailcfg.DERPMap{}
+	if static != nil {
+		derpMap = static.Clone()
+	}
+	if derpMap.Regions == nil {
+		derpMap.Regions = map[int]*tailcfg.DERPRegion{}
+	}
+	for _, region := range regions {
+		if region.Disabled {
+			continue
+		}
+		if _, exists := derpMap.Regions[int(region.ID)]; exists {
+			continue
+		}
+		derpRegion := &tailcfg.DERPRegion{
+			RegionID:   int(region.ID),
+			RegionCode: region.Code,
+			RegionName: region.Name,
+		}
+		for _, node := range nodes {
+			if node.RegionID != region.ID || node.Disabled {
+				continue
+			}
+			derpRegion.Nodes = append(derpRegion.Nodes, &tailcfg.DERPNode{
+				Name:      node.Name,
+				RegionID:  int(node.RegionID),
+				HostName:  node.HostName,
+				IPv4:      node.IPv4,
+				IPv6:      node.IPv6,
+				DERPPort:  int(node.DERPPort),
+				STUNPort:  int(node.STUNPort),
+				STUNOnly:  node.STUNOnly,
+				ForceHTTP: node.ForceHTTP,
+			})
+		}
+		if len(derpRegion.Nodes) == 0 {
+			continue
+		}
+		derpMap.Regions[derpRegion.RegionID] = derpRegion
+	}
+	return derpMap
+}
+
+// workspaceAgentClientWatchDERPMap streams the DERP map to clients connected
+// to an agent.
+func (api *API) workspaceAgentClientWatchDERPMap(rw http.ResponseWriter, r *http.Request) {
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	api.watchDERPMap(rw, r)
+}
+
+// watchDERPMap streams the DERP map as server-sent events, starting with the
+// current map.
+func (api *API) watchDERPMap(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	updates, unsubscribe := api.subscribeDERPMap()
+	defer unsubscribe()
+	for {
+		err = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: api.CurrentDERPMap(),
+		})
+		if err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-senderClosed:
+			return
+		case <-updates:
+		}
+	}
+}
+
+func (api *API) derpRegions(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	regions, err := api.convertDERPRegions(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching DERP regions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, regions)
+}
+
+func (api *API) derpRegion(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	regionID, ok := parseDERPRegionParam(rw, r)
+	if !ok {
+		return
+	}
+
+	regions, err := api.convertDERPRegions(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching DERP regions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	for _, region := range regions {
+		if region.ID == int(regionID) {
+			httpapi.Write(ctx, rw, http.StatusOK, region)
+			return
+		}
+	}
+	httpapi.ResourceNotFound(rw)
+}
+
+func (api *API) postDERPRegion(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CreateDERPRegionRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if api.staticDERPRegionConflict(req.ID, req.Code) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A region of the static DERP map already uses the ID %d or the code %q.", req.ID, req.Code),
+		})
+		return
+	}
+
+	now := database.Now()
+	region, err := api.Database.InsertDERPRegion(ctx, database.InsertDERPRegionParams{
+		ID:        int32(req.ID),
+		Code:      req.Code,
+		Name:      req.Name,
+		Disabled:  req.Disabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A DERP region with the ID %d or the code %q already exists.", req.ID, req.Code),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting DERP region.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, api.convertDERPRegion(region, nil))
+}
+
+func (api *API) patchDERPRegion(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	region, ok := api.managedDERPRegionParam(rw, r)
+	if !ok {
+		return
+	}
+
+	var req codersdk.UpdateDERPRegionRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	params := database.UpdateDERPRegionByIDParams{
+		ID:        region.ID,
+		Code:      region.Code,
+		Name:      region.Name,
+		Disabled:  region.Disabled,
+		UpdatedAt: database.Now(),
+	}
+	if req.Code != "" {
+		params.Code = req.Code
+	}
+	if req.Name != "" {
+		params.Name = req.Name
+	}
+	if req.Disabled != nil {
+		params.Disabled = *req.Disabled
+	}
+	if api.staticDERPRegionConflict(0, params.Code) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A region of the static DERP map already uses the code %q.", params.Code),
+		})
+		return
+	}
+
+	region, err := api.Database.UpdateDERPRegionByID(ctx, params)
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A DERP region with the code %q already exists.", params.Code),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating DERP region.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	nodes, err := api.Database.GetDERPNodes(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching DERP nodes.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusOK, api.convertDERPRegion(region, nodes))
+}
+
+func (api *API) deleteDERPRegion(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	region, ok := api.managedDERPRegionParam(rw, r)
+	if !ok {
+		return
+	}
+
+	err := api.Database.DeleteDERPRegionByID(ctx, region.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting DERP region.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) postDERPNode(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	region, ok := api.managedDERPRegionParam(rw, r)
+	if !ok {
+		return
+	}
+
+	var req codersdk.CreateDERPNodeRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if api.staticDERPNodeConflict(req.Name) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A node of the static DERP map is already named %q.", req.Name),
+		})
+		return
+	}
+
+	now := database.Now()
+	node, err := api.Database.InsertDERPNode(ctx, database.InsertDERPNodeParams{
+		Name:      req.Name,
+		RegionID:  region.ID,
+		HostName:  req.HostName,
+		IPv4:      req.IPv4,
+		IPv6:      req.IPv6,
+		DERPPort:  int32(req.DERPPort),
+		STUNPort:  int32(req.STUNPort),
+		STUNOnly:  req.STUNOnly,
+		ForceHTTP: req.ForceHTTP,
+		Disabled:  req.Disabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A DERP node named %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting DERP node.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, api.convertDERPNode(node))
+}
+
+func (api *API) patchDERPNode(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	node, ok := api.managedDERPNodeParam(rw, r)
+	if !ok {
+		return
+	}
+
+	var req codersdk.UpdateDERPNodeRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	params := database.UpdateDERPNodeByNameParams{
+		Name:      node.Name,
+		HostName:  node.HostName,
+		IPv4:      node.IPv4,
+		IPv6:      node.IPv6,
+		DERPPort:  node.DERPPort,
+		STUNPort:  node.STUNPort,
+		STUNOnly:  node.STUNOnly,
+		ForceHTTP: node.ForceHTTP,
+		Disabled:  node.Disabled,
+		UpdatedAt: database.Now(),
+	}
+	if req.HostName != "" {
+		params.HostName = req.HostName
+	}
+	if req.IPv4 != nil {
+		params.IPv4 = *req.IPv4
+	}
+	if req.IPv6 != nil {
+		params.IPv6 = *req.IPv6
+	}
+	if req.DERPPort != nil {
+		params.DERPPort = int32(*req.DERPPort)
+	}
+	if req.STUNPort != nil {
+		params.STUNPort = int32(*req.STUNPort)
+	}
+	if req.STUNOnly != nil {
+		params.STUNOnly = *req.STUNOnly
+	}
+	if req.ForceHTTP != nil {
+		params.ForceHTTP = *req.ForceHTTP
+	}
+	if req.Disabled != nil {
+		params.Disabled = *req.Disabled
+	}
+
+	node, err := api.Database.UpdateDERPNodeByName(ctx, params)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating DERP node.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusOK, api.convertDERPNode(node))
+}
+
+func (api *API) deleteDERPNode(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceDERPRegion) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	node, ok := api.managedDERPNodeParam(rw, r)
+	if !ok {
+		return
+	}
+
+	err := api.Database.DeleteDERPNodeByName(ctx, node.Name)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting DERP node.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishDERPMap(ctx)
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func parseDERPRegionParam(rw http.ResponseWriter, r *http.Request) (int32, bool) {
+	regionID, err := strconv.ParseInt(chi.URLParam(r, "region"), 10, 32)
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid DERP region ID.",
+			Detail:  err.Error(),
+		})
+		return 0, false
+	}
+	return int32(regionID), true
+}
+
+// managedDERPRegionParam fetches the region of the URL. Regions of the static
+// DERP map can't be changed.
+func (api *API) managedDERPRegionParam(rw http.ResponseWriter, r *http.Request) (database.DERPRegion, bool) {
+	ctx := r.Context()
+	regionID, ok := parseDERPRegionParam(rw, r)
+	if !ok {
+		return database.DERPRegion{}, false
+	}
+	if api.DERPMap != nil && api.DERPMap.Regions[int(regionID)] != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Regions of the static DERP map can't be changed through the API.",
+		})
+		return database.DERPRegion{}, false
+	}
+	region, err := api.Database.GetDERPRegionByID(ctx, regionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return database.DERPRegion{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching DERP region.",
+			Detail:  err.Error(),
+		})
+		return database.DERPRegion{}, false
+	}
+	return region, true
+}
+
+// managedDERPNodeParam fetches the node of the URL, which must belong to the
+// region of the URL.
+func (api *API) managedDERPNodeParam(rw http.ResponseWriter, r *http.Request) (database.DERPNode, bool) {
+	ctx := r.Context()
+	region, ok := api.managedDERPRegionParam(rw, r)
+	if !ok {
+		return database.DERPNode{}, false
+	}
+	node, err := api.Database.GetDERPNodeByName(ctx, chi.URLParam(r, "node"))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && node.RegionID != region.ID) {
+		httpapi.ResourceNotFound(rw)
+		return database.DERPNode{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching DERP node.",
+			Detail:  err.Error(),
+		})
+		return database.DERPNode{}, false
+	}
+	return node, true
+}
+
+// staticDERPRegionConflict returns whether a region of the static DERP map
+// has the ID or code. An ID of 0 is ignored.
+func (api *API) staticDERPRegionConflict(id int, code string) bool {
+	if api.DERPMap == nil {
+		return false
+	}
+	for regionID, region := range api.DERPMap.Regions {
+		if (id != 0 && regionID == id) || region.RegionCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// staticDERPNodeConflict returns whether a node of the static DERP map has
+// the name.
+func (api *API) staticDERPNodeConflict(name string) bool {
+	if api.DERPMap == nil {
+		return false
+	}
+	for _, region := range api.DERPMap.Regions {
+		for _, node := range region.Nodes {
+			if node.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convertDERPRegions returns the regions of the static DERP map followed by
+// the regions managed through the API, sorted by ID.
+func (api *API) convertDERPRegions(ctx context.Context) ([]codersdk.DERPMapRegion, error) {
+	regions, err := api.Database.GetDERPRegions(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get derp regions: %w", err)
+	}
+	nodes, err := api.Database.GetDERPNodes(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get derp nodes: %w", err)
+	}
+
+	converted := make([]codersdk.DERPMapRegion, 0, len(regions))
+	if api.DERPMap != nil {
+		for _, region := range api.DERPMap.Regions {
+			regionHealth, nodeHealth := api.derpHealth.health(region.RegionID)
+			apiRegion := codersdk.DERPMapRegion{
+				ID:     region.RegionID,
+				Code:   region.RegionCode,
+				Name:   region.RegionName,
+				Nodes:  make([]codersdk.DERPMapNode, 0, len(region.Nodes)),
+				Health: regionHealth,
+			}
+			for _, node := range region.Nodes {
+				apiRegion.Nodes = append(apiRegion.Nodes, codersdk.DERPMapNode{
+					Name:      node.Name,
+					RegionID:  node.RegionID,
+					HostName:  node.HostName,
+					IPv4:      node.IPv4,
+					IPv6:      node.IPv6,
+					DERPPort:  node.DERPPort,
+					STUNPort:  node.STUNPort,
+					STUNOnly:  node.STUNOnly,
+					ForceHTTP: node.ForceHTTP,
+					Health:    nodeHealth[node.Name],
+				})
+			}
+			converted = append(converted, apiRegion)
+		}
+	}
+	for _, region := range regions {
+		if api.DERPMap != nil && api.DERPMap.Regions[int(region.ID)] != nil {
+			// The static region takes precedence.
+			continue
+		}
+		converted = append(converted, api.convertDERPRegion(region, nodes))
+	}
+	sort.Slice(converted, func(i, j int) bool {
+		return converted[i].ID < converted[j].ID
+	})
+	return converted, nil
+}
+
+// convertDERPRegion converts a region managed through the API with those of
+// the nodes that belong to it.
+func (api *API) convertDERPRegion(region database.DERPRegion, nodes []database.DERPNode) codersdk.DERPMapRegion {
+	regionHealth, _ := api.derpHealth.health(int(region.ID))
+	converted := codersdk.DERPMapRegion{
+		ID:        int(region.ID),
+		Code:      region.Code,
+		Name:      region.Name,
+		Managed:   true,
+		Disabled:  region.Disabled,
+		Nodes:     []codersdk.DERPMapNode{},
+		Health:    regionHealth,
+		CreatedAt: region.CreatedAt,
+		UpdatedAt: region.UpdatedAt,
+	}
+	for _, node := range nodes {
+		if node.RegionID != region.ID {
+			continue
+		}
+		converted.Nodes = append(converted.Nodes, api.convertDERPNode(node))
+	}
+	return converted
+}
+
+func (api *API) convertDERPNode(node database.DERPNode) codersdk.DERPMapNode {
+	_, nodeHealth := api.derpHealth.health(int(node.RegionID))
+	return codersdk.DERPMapNode{
+		Name:      node.Name,
+		RegionID:  int(node.RegionID),
+		HostName:  node.HostName,
+		IPv4:      node.IPv4,
+		IPv6:      node.IPv6,
+		DERPPort:  int(node.DERPPort),
+		STUNPort:  int(node.STUNPort),
+		STUNOnly:  node.STUNOnly,
+		ForceHTTP: node.ForceHTTP,
+		Disabled:  node.Disabled,
+		Health:    nodeHealth[node.Name],
+	}
+}
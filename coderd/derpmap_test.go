@@ -0,0 +1,324 @@
+package coderd_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/derp"
+	"tailscale.com/derp/derphttp"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/coder/testutil"
+)
+
+func TestDERPRegions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Manage", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client, _, api := coderdtest.NewWithAPI(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		region, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "custom",
+			Name: "Custom",
+		})
+		require.NoError(t, err)
+		require.True(t, region.Managed)
+		require.Empty(t, region.Nodes)
+		// Regions without nodes are left out of the DERP map.
+		require.Nil(t, api.CurrentDERPMap().Regions[900])
+
+		node, err := client.CreateDERPNode(ctx, region.ID, derpNodeRequest(t, "custom-1"))
+		require.NoError(t, err)
+		require.Equal(t, 900, node.RegionID)
+		require.NotNil(t, api.CurrentDERPMap().Regions[900])
+		require.Equal(t, "custom-1", api.CurrentDERPMap().Regions[900].Nodes[0].Name)
+
+		regions, err := client.DERPRegions(ctx)
+		require.NoError(t, err)
+		require.Len(t, regions, 2)
+		require.Equal(t, 1, regions[0].ID)
+		require.False(t, regions[0].Managed)
+		require.Equal(t, 900, regions[1].ID)
+		require.Len(t, regions[1].Nodes, 1)
+
+		disabled := true
+		region, err = client.UpdateDERPRegion(ctx, region.ID, codersdk.UpdateDERPRegionRequest{
+			Name:     "Renamed",
+			Disabled: &disabled,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Renamed", region.Name)
+		require.Equal(t, "custom", region.Code)
+		require.True(t, region.Disabled)
+		require.Nil(t, api.CurrentDERPMap().Regions[900])
+
+		disabled = false
+		_, err = client.UpdateDERPRegion(ctx, region.ID, codersdk.UpdateDERPRegionRequest{
+			Disabled: &disabled,
+		})
+		require.NoError(t, err)
+		node, err = client.UpdateDERPNode(ctx, region.ID, node.Name, codersdk.UpdateDERPNodeRequest{
+			Disabled: &disabled,
+			STUNOnly: &disabled,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, api.CurrentDERPMap().Regions[900])
+
+		err = client.DeleteDERPNode(ctx, region.ID, node.Name)
+		require.NoError(t, err)
+		require.Nil(t, api.CurrentDERPMap().Regions[900])
+
+		err = client.DeleteDERPRegion(ctx, region.ID)
+		require.NoError(t, err)
+		_, err = client.DERPRegion(ctx, region.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		// The static DERP map of coderdtest has region 1 with the code
+		// "coder" and the node "1a".
+		_, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   1,
+			Code: "other",
+			Name: "Other",
+		})
+		requireStatusCode(t, err, http.StatusConflict)
+		_, err = client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "coder",
+			Name: "Coder",
+		})
+		requireStatusCode(t, err, http.StatusConflict)
+		_, err = client.UpdateDERPRegion(ctx, 1, codersdk.UpdateDERPRegionRequest{
+			Name: "Renamed",
+		})
+		requireStatusCode(t, err, http.StatusBadRequest)
+
+		region, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "custom",
+			Name: "Custom",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   901,
+			Code: "custom",
+			Name: "Custom",
+		})
+		requireStatusCode(t, err, http.StatusConflict)
+		_, err = client.CreateDERPNode(ctx, region.ID, derpNodeRequest(t, "1a"))
+		requireStatusCode(t, err, http.StatusConflict)
+		_, err = client.CreateDERPNode(ctx, region.ID, derpNodeRequest(t, "custom-1"))
+		require.NoError(t, err)
+		_, err = client.CreateDERPNode(ctx, region.ID, derpNodeRequest(t, "custom-1"))
+		requireStatusCode(t, err, http.StatusConflict)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		healthy, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "healthy",
+			Name: "Healthy",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateDERPNode(ctx, healthy.ID, derpNodeRequest(t, "healthy-1"))
+		require.NoError(t, err)
+
+		unhealthy, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   901,
+			Code: "unhealthy",
+			Name: "Unhealthy",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateDERPNode(ctx, unhealthy.ID, codersdk.CreateDERPNodeRequest{
+			Name:      "unhealthy-1",
+			HostName:  "127.0.0.1",
+			IPv4:      "127.0.0.1",
+			IPv6:      "none",
+			DERPPort:  closedPort(t),
+			STUNPort:  -1,
+			ForceHTTP: true,
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			regions, err := client.DERPRegions(ctx)
+			if err != nil || len(regions) != 3 {
+				return false
+			}
+			return regions[0].Health.Healthy &&
+				regions[1].Health.Healthy && regions[1].Nodes[0].Health.Healthy &&
+				!regions[2].Health.CheckedAt.IsZero()
+		}, testutil.WaitLong, testutil.IntervalMedium)
+
+		region, err := client.DERPRegion(ctx, unhealthy.ID)
+		require.NoError(t, err)
+		require.False(t, region.Health.Healthy)
+		require.Contains(t, region.Health.Error, "unhealthy-1")
+		require.False(t, region.Nodes[0].Health.Healthy)
+		require.NotEmpty(t, region.Nodes[0].Health.Error)
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		authToken := uuid.NewString()
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse:         echo.ParseComplete,
+			ProvisionPlan: echo.ProvisionComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "example",
+							Type: "aws_instance",
+							Agents: []*proto.Agent{{
+								Id: uuid.NewString(),
+								Auth: &proto.Agent_Token{
+									Token: authToken,
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		workspace, err := client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		agentID := workspace.LatestBuild.Resources[0].Agents[0].ID
+
+		agentClient := codersdk.New(client.URL)
+		agentClient.SetSessionToken(authToken)
+		agentMaps, err := agentClient.WorkspaceAgentWatchDERPMap(ctx)
+		require.NoError(t, err)
+		clientMaps, err := client.WatchWorkspaceAgentDERPMap(ctx, agentID)
+		require.NoError(t, err)
+
+		nextMap := func(maps <-chan *tailcfg.DERPMap) *tailcfg.DERPMap {
+			select {
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for the DERP map")
+				return nil
+			case derpMap, ok := <-maps:
+				require.True(t, ok, "DERP map stream closed")
+				return derpMap
+			}
+		}
+		require.Len(t, nextMap(agentMaps).Regions, 1)
+		require.Len(t, nextMap(clientMaps).Regions, 1)
+
+		region, err := client.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "custom",
+			Name: "Custom",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateDERPNode(ctx, region.ID, derpNodeRequest(t, "custom-1"))
+		require.NoError(t, err)
+
+		derpMap := nextMap(agentMaps)
+		require.Len(t, derpMap.Regions, 2)
+		require.Equal(t, "custom-1", derpMap.Regions[900].Nodes[0].Name)
+		derpMap = nextMap(clientMaps)
+		require.Len(t, derpMap.Regions, 2)
+	})
+
+	t.Run("MemberForbidden", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		_, err := member.DERPRegions(ctx)
+		requireStatusCode(t, err, http.StatusNotFound)
+		_, err = member.CreateDERPRegion(ctx, codersdk.CreateDERPRegionRequest{
+			ID:   900,
+			Code: "custom",
+			Name: "Custom",
+		})
+		requireStatusCode(t, err, http.StatusNotFound)
+	})
+}
+
+// derpNodeRequest returns a node of a DERP server that serves plain HTTP.
+func derpNodeRequest(t *testing.T, name string) codersdk.CreateDERPNodeRequest {
+	t.Helper()
+	server := derp.NewServer(key.NewNode(), tailnet.Logger(slogtest.Make(t, nil).Named("derp")))
+	httpServer := httptest.NewServer(derphttp.Handler(server))
+	t.Cleanup(func() {
+		httpServer.CloseClientConnections()
+		httpServer.Close()
+		_ = server.Close()
+	})
+	tcpAddr, ok := httpServer.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+	return codersdk.CreateDERPNodeRequest{
+		Name:      name,
+		HostName:  "127.0.0.1",
+		IPv4:      "127.0.0.1",
+		IPv6:      "none",
+		DERPPort:  tcpAddr.Port,
+		STUNPort:  -1,
+		ForceHTTP: true,
+	}
+}
+
+// closedPort returns a port nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcpAddr, ok := listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+	_ = listener.Close()
+	return tcpAddr.Port
+}
+
+func requireStatusCode(t *testing.T, err error, statusCode int) {
+	t.Helper()
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, statusCode, apiErr.StatusCode())
+}
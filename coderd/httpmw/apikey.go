@@ -104,6 +104,11 @@ type ExtractAPIKeyConfig struct {
 	// will be deleted and the request will continue. If the request is not a
 	// cookie-based request, the request will be rejected with a 401.
 	Optional bool
+
+	// MaxTokenLifetime is how long after their creation keys stop working,
+	// no matter how often they're refreshed. There is no maximum when it's
+	// zero.
+	MaxTokenLifetime time.Duration
 }
 
 // ExtractAPIKey requires authentication using a valid API key. It handles
@@ -238,6 +243,16 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				}
 			}
 
+			// Keys are refreshed when used, but never past the maximum
+			// lifetime of the deployment.
+			var maxExpiresAt time.Time
+			if cfg.MaxTokenLifetime > 0 {
+				maxExpiresAt = key.CreatedAt.Add(cfg.MaxTokenLifetime)
+				if key.ExpiresAt.After(maxExpiresAt) {
+					key.ExpiresAt = maxExpiresAt
+				}
+			}
+
 			// Checking if the key is expired.
 			if key.ExpiresAt.Before(now) {
 				optionalWrite(http.StatusUnauthorized, codersdk.Response{
@@ -247,9 +262,12 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Only update LastUsed once an hour to prevent database spam.
+			// Only update LastUsed once an hour to prevent database spam. The
+			// IP address and user agent are updated alongside it, so sessions
+			// can be told apart.
 			if now.Sub(key.LastUsed) > time.Hour {
 				key.LastUsed = now
+				key.UserAgent = r.UserAgent()
 				remoteIP := net.ParseIP(r.RemoteAddr)
 				if remoteIP == nil {
 					remoteIP = net.IPv4(0, 0, 0, 0)
@@ -268,8 +286,14 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 			// We extend the ExpiresAt to reduce re-authentication.
 			apiKeyLifetime := time.Duration(key.LifetimeSeconds) * time.Second
 			if key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
-				key.ExpiresAt = now.Add(apiKeyLifetime)
-				changed = true
+				expiresAt := now.Add(apiKeyLifetime)
+				if !maxExpiresAt.IsZero() && expiresAt.After(maxExpiresAt) {
+					expiresAt = maxExpiresAt
+				}
+				if expiresAt.After(key.ExpiresAt) {
+					key.ExpiresAt = expiresAt
+					changed = true
+				}
 			}
 			if changed {
 				err := cfg.DB.UpdateAPIKeyByID(r.Context(), database.UpdateAPIKeyByIDParams{
@@ -277,6 +301,7 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 					LastUsed:  key.LastUsed,
 					ExpiresAt: key.ExpiresAt,
 					IPAddress: key.IPAddress,
+					UserAgent: key.UserAgent,
 				})
 				if err != nil {
 					write(http.StatusInternalServerError, codersdk.Response{
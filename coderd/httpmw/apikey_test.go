@@ -339,6 +339,7 @@ func TestAPIKey(t *testing.T) {
 			user       = createUser(r.Context(), t, db)
 		)
 		r.Header.Set(codersdk.SessionCustomHeader, fmt.Sprintf("%s-%s", id, secret))
+		r.Header.Set("User-Agent", "coder-test")
 
 		sentAPIKey, err := db.InsertAPIKey(r.Context(), database.InsertAPIKeyParams{
 			ID:           id,
@@ -363,6 +364,7 @@ func TestAPIKey(t *testing.T) {
 
 		require.NotEqual(t, sentAPIKey.LastUsed, gotAPIKey.LastUsed)
 		require.Equal(t, sentAPIKey.ExpiresAt, gotAPIKey.ExpiresAt)
+		require.Equal(t, "coder-test", gotAPIKey.UserAgent)
 	})
 
 	t.Run("ValidUpdateExpiry", func(t *testing.T) {
@@ -402,6 +404,80 @@ func TestAPIKey(t *testing.T) {
 		require.NotEqual(t, sentAPIKey.ExpiresAt, gotAPIKey.ExpiresAt)
 	})
 
+	t.Run("PastMaxTokenLifetime", func(t *testing.T) {
+		t.Parallel()
+		var (
+			db         = databasefake.New()
+			id, secret = randomAPIKeyParts()
+			hashed     = sha256.Sum256([]byte(secret))
+			r          = httptest.NewRequest("GET", "/", nil)
+			rw         = httptest.NewRecorder()
+			user       = createUser(r.Context(), t, db)
+		)
+		r.Header.Set(codersdk.SessionCustomHeader, fmt.Sprintf("%s-%s", id, secret))
+
+		// The key was refreshed by use, but was created longer ago than
+		// the maximum lifetime.
+		_, err := db.InsertAPIKey(r.Context(), database.InsertAPIKeyParams{
+			ID:              id,
+			HashedSecret:    hashed[:],
+			CreatedAt:       database.Now().Add(-2 * time.Hour),
+			LastUsed:        database.Now(),
+			ExpiresAt:       database.Now().Add(time.Hour),
+			LifetimeSeconds: int64(time.Hour.Seconds()),
+			UserID:          user.ID,
+			LoginType:       database.LoginTypePassword,
+			Scope:           database.APIKeyScopeAll,
+		})
+		require.NoError(t, err)
+		httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
+			DB:               db,
+			MaxTokenLifetime: time.Hour,
+		})(successHandler).ServeHTTP(rw, r)
+		res := rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("RefreshWithinMaxTokenLifetime", func(t *testing.T) {
+		t.Parallel()
+		var (
+			db         = databasefake.New()
+			id, secret = randomAPIKeyParts()
+			hashed     = sha256.Sum256([]byte(secret))
+			r          = httptest.NewRequest("GET", "/", nil)
+			rw         = httptest.NewRecorder()
+			user       = createUser(r.Context(), t, db)
+			createdAt  = database.Now().Add(-time.Hour)
+		)
+		r.Header.Set(codersdk.SessionCustomHeader, fmt.Sprintf("%s-%s", id, secret))
+
+		_, err := db.InsertAPIKey(r.Context(), database.InsertAPIKeyParams{
+			ID:              id,
+			HashedSecret:    hashed[:],
+			CreatedAt:       createdAt,
+			LastUsed:        database.Now(),
+			ExpiresAt:       database.Now().Add(time.Minute),
+			LifetimeSeconds: int64((24 * time.Hour).Seconds()),
+			UserID:          user.ID,
+			LoginType:       database.LoginTypePassword,
+			Scope:           database.APIKeyScopeAll,
+		})
+		require.NoError(t, err)
+		httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
+			DB:               db,
+			MaxTokenLifetime: 2 * time.Hour,
+		})(successHandler).ServeHTTP(rw, r)
+		res := rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		// The key is refreshed up to the maximum lifetime only.
+		gotAPIKey, err := db.GetAPIKeyByID(r.Context(), id)
+		require.NoError(t, err)
+		require.WithinDuration(t, createdAt.Add(2*time.Hour), gotAPIKey.ExpiresAt, time.Second)
+	})
+
 	t.Run("OAuthNotExpired", func(t *testing.T) {
 		t.Parallel()
 		var (
@@ -0,0 +1,174 @@
+package ldapauth
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"golang.org/x/xerrors"
+)
+
+// ErrInvalidCredentials is returned when the user could not be found in
+// the directory or the password they provided was rejected.
+var ErrInvalidCredentials = xerrors.New("invalid ldap credentials")
+
+// Config is used for authenticating users against an LDAP directory.
+type Config struct {
+	// URL is the address of the directory. Both "ldap://" and
+	// "ldaps://" schemes are supported.
+	URL string
+	// StartTLS upgrades an "ldap://" connection to TLS before binding.
+	StartTLS bool
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool
+	// BindDN and BindPassword are the credentials of the service account
+	// used to search for users.
+	BindDN       string
+	BindPassword string
+	// SearchBaseDN is the root of the subtree that users are searched in.
+	SearchBaseDN string
+	// SearchFilter is the filter used to find a user. Every "%s" is
+	// replaced with the escaped login the user provided.
+	SearchFilter string
+	// UsernameAttribute and EmailAttribute map directory attributes to the
+	// Coder username and email.
+	UsernameAttribute string
+	EmailAttribute    string
+	// GroupAttribute lists the groups of a user, e.g. "memberOf".
+	GroupAttribute string
+	// GroupSync mirrors the directory groups of a user to Coder groups with
+	// the same name on every login.
+	GroupSync    bool
+	AllowSignups bool
+}
+
+// Entry is a user that was authenticated against the directory.
+type Entry struct {
+	DN       string
+	Username string
+	Email    string
+	// Groups are the names of the groups the user is a member of. When the
+	// group attribute contains distinguished names, the value of the first
+	// RDN is used (e.g. "developers" for "cn=developers,ou=groups").
+	Groups []string
+}
+
+// Authenticate searches the directory for the login provided and verifies
+// the password by binding as the matching entry.
+func (c *Config) Authenticate(ctx context.Context, login, password string) (Entry, error) {
+	// Directories allow unauthenticated binds with an empty password, which
+	// would otherwise succeed for any existing user.
+	if login == "" || password == "" {
+		return Entry{}, ErrInvalidCredentials
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer conn.Close()
+
+	// The LDAP client doesn't accept a context, so close the connection to
+	// abort any in-flight request when the context is canceled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if c.BindDN != "" {
+		err = conn.Bind(c.BindDN, c.BindPassword)
+		if err != nil {
+			return Entry{}, xerrors.Errorf("bind service account: %w", err)
+		}
+	}
+
+	attributes := []string{"dn", c.UsernameAttribute, c.EmailAttribute}
+	if c.GroupAttribute != "" {
+		attributes = append(attributes, c.GroupAttribute)
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		c.SearchBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		// Only two entries are requested to detect ambiguous filters.
+		2, 0, false,
+		strings.ReplaceAll(c.SearchFilter, "%s", ldap.EscapeFilter(login)),
+		attributes,
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return Entry{}, xerrors.Errorf("search user: %w", err)
+	}
+	if res == nil || len(res.Entries) == 0 {
+		return Entry{}, ErrInvalidCredentials
+	}
+	if len(res.Entries) > 1 {
+		return Entry{}, xerrors.Errorf("search filter matched multiple entries for %q", login)
+	}
+	found := res.Entries[0]
+
+	err = conn.Bind(found.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return Entry{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Entry{}, xerrors.Errorf("bind user: %w", err)
+	}
+
+	entry := Entry{
+		DN:       found.DN,
+		Username: found.GetAttributeValue(c.UsernameAttribute),
+		Email:    found.GetAttributeValue(c.EmailAttribute),
+		Groups:   []string{},
+	}
+	if c.GroupAttribute != "" {
+		for _, group := range found.GetAttributeValues(c.GroupAttribute) {
+			entry.Groups = append(entry.Groups, groupName(group))
+		}
+	}
+	if entry.Email == "" {
+		return Entry{}, xerrors.Errorf("entry %q has no %q attribute", found.DN, c.EmailAttribute)
+	}
+	return entry, nil
+}
+
+func (c *Config) dial(ctx context.Context) (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // Explicitly configured by the administrator.
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(c.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, xerrors.Errorf("dial %q: %w", c.URL, err)
+	}
+	if c.StartTLS {
+		err = conn.StartTLS(tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, xerrors.Errorf("start tls: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// groupName returns the value of the first RDN of a distinguished name, or
+// the value itself if it isn't one.
+func groupName(value string) string {
+	dn, err := ldap.ParseDN(value)
+	if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+		return value
+	}
+	return dn.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,128 @@
+package ldapauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/ldapauth"
+	"github.com/coder/coder/coderd/ldapauth/ldaptest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) *ldapauth.Config {
+		srv := ldaptest.New(t, ldaptest.Entry{
+			DN:       "cn=coder,dc=example,dc=com",
+			Password: "service",
+		}, ldaptest.Entry{
+			DN:       "uid=kyle,ou=people,dc=example,dc=com",
+			Password: "hunter2",
+			Attributes: map[string][]string{
+				"uid":      {"kyle"},
+				"mail":     {"kyle@example.com"},
+				"memberOf": {"cn=developers,ou=groups,dc=example,dc=com", "admins"},
+			},
+		}, ldaptest.Entry{
+			DN:       "uid=duplicate,ou=people,dc=example,dc=com",
+			Password: "hunter2",
+			Attributes: map[string][]string{
+				"uid":  {"duplicate"},
+				"mail": {"shared@example.com"},
+			},
+		}, ldaptest.Entry{
+			DN:       "uid=duplicate2,ou=people,dc=example,dc=com",
+			Password: "hunter2",
+			Attributes: map[string][]string{
+				"uid":  {"duplicate2"},
+				"mail": {"shared@example.com"},
+			},
+		})
+		return &ldapauth.Config{
+			URL:               srv.URL,
+			BindDN:            "cn=coder,dc=example,dc=com",
+			BindPassword:      "service",
+			SearchBaseDN:      "ou=people,dc=example,dc=com",
+			SearchFilter:      "(|(uid=%s)(mail=%s))",
+			UsernameAttribute: "uid",
+			EmailAttribute:    "mail",
+			GroupAttribute:    "memberOf",
+		}
+	}
+
+	t.Run("Username", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		entry, err := setup(t).Authenticate(ctx, "kyle", "hunter2")
+		require.NoError(t, err)
+		require.Equal(t, "uid=kyle,ou=people,dc=example,dc=com", entry.DN)
+		require.Equal(t, "kyle", entry.Username)
+		require.Equal(t, "kyle@example.com", entry.Email)
+		require.Equal(t, []string{"developers", "admins"}, entry.Groups)
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		entry, err := setup(t).Authenticate(ctx, "kyle@example.com", "hunter2")
+		require.NoError(t, err)
+		require.Equal(t, "kyle", entry.Username)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := setup(t).Authenticate(ctx, "kyle", "wrong")
+		require.ErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := setup(t).Authenticate(ctx, "kyle", "")
+		require.ErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := setup(t).Authenticate(ctx, "nobody", "hunter2")
+		require.ErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+
+	t.Run("FilterInjection", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := setup(t).Authenticate(ctx, "*)(uid=kyle", "hunter2")
+		require.ErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := setup(t).Authenticate(ctx, "shared@example.com", "hunter2")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+
+	t.Run("WrongServiceAccount", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cfg := setup(t)
+		cfg.BindPassword = "wrong"
+		_, err := cfg.Authenticate(ctx, "kyle", "hunter2")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+	})
+}
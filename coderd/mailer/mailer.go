@@ -0,0 +1,151 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Message is a plain text email sent to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to users.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP sends emails through an SMTP relay.
+type SMTP struct {
+	// Addr is the "host:port" address of the relay.
+	Addr string
+	// From is the address emails are sent from, e.g.
+	// "Coder <coder@example.com>".
+	From string
+	// Username and Password authenticate with PLAIN auth when set.
+	Username string
+	Password string
+	// ForceTLS connects with implicit TLS (usually on port 465) instead of
+	// upgrading the connection with STARTTLS when the relay supports it.
+	ForceTLS bool
+	// InsecureSkipVerify disables verification of the relay certificate.
+	InsecureSkipVerify bool
+}
+
+var _ Mailer = &SMTP{}
+
+// Send delivers the message to the relay. The relay is dialed for every
+// message, since emails are only sent occasionally.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return xerrors.Errorf("parse from address %q: %w", s.From, err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return xerrors.Errorf("parse to address %q: %w", msg.To, err)
+	}
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return xerrors.Errorf("split host port %q: %w", s.Addr, err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: host,
+		//nolint:gosec // Explicitly configured by the administrator.
+		InsecureSkipVerify: s.InsecureSkipVerify,
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if s.ForceTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", s.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return xerrors.Errorf("dial %q: %w", s.Addr, err)
+	}
+	// The SMTP client doesn't accept a context, so close the connection to
+	// abort any in-flight command when the context is canceled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return xerrors.Errorf("create client: %w", err)
+	}
+	defer client.Close()
+
+	if !s.ForceTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			err = client.StartTLS(tlsConfig)
+			if err != nil {
+				return xerrors.Errorf("start tls: %w", err)
+			}
+		}
+	}
+	if s.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", s.Username, s.Password, host))
+		if err != nil {
+			return xerrors.Errorf("auth: %w", err)
+		}
+	}
+	err = client.Mail(from.Address)
+	if err != nil {
+		return xerrors.Errorf("mail from: %w", err)
+	}
+	err = client.Rcpt(to.Address)
+	if err != nil {
+		return xerrors.Errorf("rcpt to: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return xerrors.Errorf("data: %w", err)
+	}
+	_, err = writer.Write(format(from, to, msg))
+	if err != nil {
+		return xerrors.Errorf("write message: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return xerrors.Errorf("close message: %w", err)
+	}
+	return client.Quit()
+}
+
+// format renders the message in the RFC 5322 format.
+func format(from, to *mail.Address, msg Message) []byte {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	_, _ = fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	_, _ = fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	_, _ = fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	_, _ = buf.WriteString("MIME-Version: 1.0\r\n")
+	_, _ = buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	_, _ = buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	_, _ = buf.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	_, _ = buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return buf.Bytes()
+}
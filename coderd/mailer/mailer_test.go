@@ -0,0 +1,64 @@
+package mailer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSMTP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Send", func(t *testing.T) {
+		t.Parallel()
+		srv := mailertest.New(t)
+		smtp := &mailer.SMTP{
+			Addr:     srv.Addr,
+			From:     "Coder <coder@example.com>",
+			Username: "coder",
+			Password: "hunter2",
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := smtp.Send(ctx, mailer.Message{
+			To:      "kyle@example.com",
+			Subject: "Welcome to Coder",
+			Body:    "Hello!\nSee you soon.",
+		})
+		require.NoError(t, err)
+
+		messages := srv.Messages()
+		require.Len(t, messages, 1)
+		require.Equal(t, "coder", messages[0].Username)
+		require.Equal(t, "coder@example.com", messages[0].From)
+		require.Equal(t, []string{"kyle@example.com"}, messages[0].To)
+		require.Equal(t, "Welcome to Coder", messages[0].Subject)
+		require.Equal(t, "Hello!\nSee you soon.", messages[0].Body)
+	})
+
+	t.Run("InvalidRecipient", func(t *testing.T) {
+		t.Parallel()
+		srv := mailertest.New(t)
+		smtp := &mailer.SMTP{
+			Addr: srv.Addr,
+			From: "coder@example.com",
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := smtp.Send(ctx, mailer.Message{
+			To:      "not an address",
+			Subject: "Welcome to Coder",
+		})
+		require.Error(t, err)
+		require.Empty(t, srv.Messages())
+	})
+}
@@ -0,0 +1,188 @@
+package mailertest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Message is an email received by Server.
+type Message struct {
+	// Username is the user the client authenticated as, if any.
+	Username string
+	From     string
+	To       []string
+	Subject  string
+	Body     string
+}
+
+// Server is an in-process SMTP server that accepts every message it
+// receives. It supports the subset of the protocol used by net/smtp
+// without STARTTLS.
+type Server struct {
+	// Addr is the "host:port" address the server is listening on.
+	Addr string
+
+	listener net.Listener
+	mutex    sync.Mutex
+	messages []Message
+	conns    map[net.Conn]struct{}
+}
+
+// New starts a server. It's closed when the test completes.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+		conns:    map[net.Conn]struct{}{},
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			srv.mutex.Lock()
+			srv.conns[conn] = struct{}{}
+			srv.mutex.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				srv.serve(conn)
+				srv.mutex.Lock()
+				delete(srv.conns, conn)
+				srv.mutex.Unlock()
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		srv.mutex.Lock()
+		for conn := range srv.conns {
+			_ = conn.Close()
+		}
+		srv.mutex.Unlock()
+		wg.Wait()
+	})
+	return srv
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(code int, msg string) bool {
+		return text.PrintfLine("%d %s", code, msg) == nil
+	}
+
+	if !reply(220, "localhost ESMTP mailertest") {
+		return
+	}
+	var msg Message
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			err = text.PrintfLine("250-localhost\r\n250-8BITMIME\r\n250 AUTH PLAIN")
+			if err != nil {
+				return
+			}
+		case "HELO", "NOOP":
+			reply(250, "OK")
+		case "AUTH":
+			username, ok := plainUsername(arg)
+			if !ok {
+				reply(501, "Malformed AUTH")
+				continue
+			}
+			msg.Username = username
+			reply(235, "Authentication successful")
+		case "MAIL":
+			msg.From = address(arg)
+			reply(250, "OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			reply(250, "OK")
+		case "DATA":
+			if !reply(354, "End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			parsed, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				reply(554, fmt.Sprintf("Malformed message: %s", err))
+				continue
+			}
+			body, _ := io.ReadAll(parsed.Body)
+			msg.Subject = parsed.Header.Get("Subject")
+			// Clients end the data with a newline before the terminating dot.
+			msg.Body = strings.TrimSuffix(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+			s.mutex.Lock()
+			s.messages = append(s.messages, msg)
+			s.mutex.Unlock()
+			msg = Message{Username: msg.Username}
+			reply(250, "OK")
+		case "RSET":
+			msg = Message{Username: msg.Username}
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// address extracts the address from "FROM:<addr>" or "TO:<addr>".
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+// plainUsername decodes the username of an "AUTH PLAIN" initial response.
+func plainUsername(arg string) (string, bool) {
+	mechanism, resp, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 {
+		return "", false
+	}
+	return parts[1], true
+}
@@ -0,0 +1,201 @@
+package coderd
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) notifications(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	queryParams := r.URL.Query()
+	parser := httpapi.NewQueryParamParser()
+	unread := parser.Boolean(queryParams, false, "unread")
+	limit := parser.Int(queryParams, 0, "limit")
+	if limit < 0 {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  "limit",
+			Detail: "Query param \"limit\" must be a non-negative integer.",
+		})
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	dbNotifications, err := api.Database.GetNotificationsByUserID(ctx, database.GetNotificationsByUserIDParams{
+		UserID:   user.ID,
+		Unread:   unread,
+		LimitOpt: int32(limit),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching notifications.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	converted := make([]codersdk.Notification, 0, len(dbNotifications))
+	for _, notification := range dbNotifications {
+		converted = append(converted, notifications.Convert(notification))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func (api *API) putNotificationsRead(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.MarkNotificationsReadRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	err := api.Database.UpdateNotificationsReadAtByUserID(ctx, database.UpdateNotificationsReadAtByUserIDParams{
+		ReadAt: sql.NullTime{Time: database.Now(), Valid: true},
+		UserID: user.ID,
+		IDs:    req.IDs,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error marking notifications read.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func (api *API) notificationPreferences(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	preferences, err := notifications.Preferences(ctx, api.Database, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching notification preferences.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationPreferences(preferences))
+}
+
+func (api *API) putNotificationPreferences(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateNotificationPreferencesRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var validErrs []codersdk.ValidationError
+	for i, preference := range req.Preferences {
+		if !slices.Contains(notifications.Kinds, database.NotificationKind(preference.Kind)) {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  fmt.Sprintf("preferences[%d].kind", i),
+				Detail: fmt.Sprintf("%q is not a valid notification kind.", preference.Kind),
+			})
+		}
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid request to update notification preferences.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	err := api.Database.InTx(func(tx database.Store) error {
+		for _, preference := range req.Preferences {
+			_, err := tx.UpsertUserNotificationPreference(ctx, database.UpsertUserNotificationPreferenceParams{
+				UserID:  user.ID,
+				Kind:    database.NotificationKind(preference.Kind),
+				Inbox:   preference.Inbox,
+				Email:   preference.Email,
+				Webhook: preference.Webhook,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating notification preferences.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	preferences, err := notifications.Preferences(ctx, api.Database, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching notification preferences.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationPreferences(preferences))
+}
+
+func convertNotificationPreferences(preferences []database.UserNotificationPreference) []codersdk.NotificationPreference {
+	converted := make([]codersdk.NotificationPreference, 0, len(preferences))
+	for _, preference := range preferences {
+		converted = append(converted, codersdk.NotificationPreference{
+			Kind:    codersdk.NotificationKind(preference.Kind),
+			Inbox:   preference.Inbox,
+			Email:   preference.Email,
+			Webhook: preference.Webhook,
+		})
+	}
+	return converted
+}
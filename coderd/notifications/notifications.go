@@ -0,0 +1,265 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/codersdk"
+)
+
+// Kinds are all kinds of notifications.
+var Kinds = []database.NotificationKind{
+	database.NotificationKindWorkspaceAutostop,
+	database.NotificationKindWorkspaceAutostartFailed,
+	database.NotificationKindWorkspaceBuildFailed,
+	database.NotificationKindWorkspaceDormant,
+	database.NotificationKindWorkspaceUpdateFailed,
+	database.NotificationKindWorkspaceTransfer,
+}
+
+// Notification is an event to deliver to a user.
+type Notification struct {
+	Kind   database.NotificationKind
+	UserID uuid.UUID
+	// WorkspaceID is uuid.Nil if the notification isn't about a workspace.
+	WorkspaceID uuid.UUID
+	Title       string
+	Body        string
+}
+
+// WebhookPayload is the body of the requests sent to the notification
+// webhook.
+type WebhookPayload struct {
+	codersdk.Notification
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type Options struct {
+	Database database.Store
+	Logger   slog.Logger
+	// Mailer sends notifications by email. Emails aren't sent if nil.
+	Mailer mailer.Mailer
+	// WebhookURL receives a POST request with a WebhookPayload for every
+	// notification. Webhooks aren't sent if nil.
+	WebhookURL *url.URL
+	HTTPClient *http.Client
+}
+
+// Notifier delivers notifications to the inbox of users, and by email and
+// webhook as the users prefer.
+type Notifier struct {
+	options Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a notifier. Close it to wait for pending deliveries.
+func New(options Options) *Notifier {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Notifier{
+		options: options,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Notify delivers the notification. The notification is added to the inbox
+// of the user before Notify returns, while emails and webhooks are sent in
+// the background. Errors are logged rather than returned, since a failed
+// notification must not fail the operation that caused it. A nil Notifier
+// discards notifications.
+func (n *Notifier) Notify(ctx context.Context, notification Notification) {
+	if n == nil {
+		return
+	}
+	log := n.options.Logger.With(
+		slog.F("kind", notification.Kind),
+		slog.F("user_id", notification.UserID),
+		slog.F("workspace_id", notification.WorkspaceID),
+	)
+	user, err := n.options.Database.GetUserByID(ctx, notification.UserID)
+	if err != nil {
+		log.Error(ctx, "get notification user", slog.Error(err))
+		return
+	}
+	preference, err := Preference(ctx, n.options.Database, user.ID, notification.Kind)
+	if err != nil {
+		log.Error(ctx, "get notification preference", slog.Error(err))
+		return
+	}
+
+	dbNotification := database.Notification{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		WorkspaceID: uuid.NullUUID{UUID: notification.WorkspaceID, Valid: notification.WorkspaceID != uuid.Nil},
+		Kind:        notification.Kind,
+		Title:       notification.Title,
+		Body:        notification.Body,
+		CreatedAt:   database.Now(),
+	}
+	if preference.Inbox {
+		dbNotification, err = n.options.Database.InsertNotification(ctx, database.InsertNotificationParams{
+			ID:          dbNotification.ID,
+			UserID:      dbNotification.UserID,
+			WorkspaceID: dbNotification.WorkspaceID,
+			Kind:        dbNotification.Kind,
+			Title:       dbNotification.Title,
+			Body:        dbNotification.Body,
+			CreatedAt:   dbNotification.CreatedAt,
+		})
+		if err != nil {
+			log.Error(ctx, "insert notification", slog.Error(err))
+		}
+	}
+	if preference.Email && n.options.Mailer != nil && user.Email != "" {
+		n.deliver(log, "email", func(ctx context.Context) error {
+			return n.options.Mailer.Send(ctx, mailer.Message{
+				To:      user.Email,
+				Subject: dbNotification.Title,
+				Body:    dbNotification.Body,
+			})
+		})
+	}
+	if preference.Webhook && n.options.WebhookURL != nil {
+		n.deliver(log, "webhook", func(ctx context.Context) error {
+			return n.sendWebhook(ctx, WebhookPayload{
+				Notification: Convert(dbNotification),
+				Username:     user.Username,
+				Email:        user.Email,
+			})
+		})
+	}
+}
+
+// Close waits for pending deliveries to finish, and cancels them once ctx
+// is done.
+func (n *Notifier) Close(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+	}
+	n.cancel()
+}
+
+func (n *Notifier) deliver(log slog.Logger, channel string, send func(ctx context.Context) error) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ctx, cancel := context.WithTimeout(n.ctx, time.Minute)
+		defer cancel()
+		err := send(ctx)
+		if err != nil {
+			log.Warn(ctx, "deliver notification", slog.F("channel", channel), slog.Error(err))
+		}
+	}()
+}
+
+func (n *Notifier) sendWebhook(ctx context.Context, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return xerrors.Errorf("marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.options.WebhookURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.options.HTTPClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("send request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Preference returns the channels the user receives notifications of the
+// kind through. Every channel is enabled by default.
+func Preference(ctx context.Context, db database.Store, userID uuid.UUID, kind database.NotificationKind) (database.UserNotificationPreference, error) {
+	preferences, err := Preferences(ctx, db, userID)
+	if err != nil {
+		return database.UserNotificationPreference{}, err
+	}
+	for _, preference := range preferences {
+		if preference.Kind == kind {
+			return preference, nil
+		}
+	}
+	return defaultPreference(userID, kind), nil
+}
+
+// Preferences returns the preferences of the user for every kind of
+// notification, in the order of Kinds.
+func Preferences(ctx context.Context, db database.Store, userID uuid.UUID) ([]database.UserNotificationPreference, error) {
+	stored, err := db.GetUserNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, xerrors.Errorf("get user notification preferences: %w", err)
+	}
+	preferences := make([]database.UserNotificationPreference, 0, len(Kinds))
+	for _, kind := range Kinds {
+		preference := defaultPreference(userID, kind)
+		for _, s := range stored {
+			if s.Kind == kind {
+				preference = s
+				break
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+func defaultPreference(userID uuid.UUID, kind database.NotificationKind) database.UserNotificationPreference {
+	return database.UserNotificationPreference{
+		UserID:  userID,
+		Kind:    kind,
+		Inbox:   true,
+		Email:   true,
+		Webhook: true,
+	}
+}
+
+// Convert converts a notification to its API representation.
+func Convert(notification database.Notification) codersdk.Notification {
+	converted := codersdk.Notification{
+		ID:        notification.ID,
+		UserID:    notification.UserID,
+		Kind:      codersdk.NotificationKind(notification.Kind),
+		Title:     notification.Title,
+		Body:      notification.Body,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.WorkspaceID.Valid {
+		converted.WorkspaceID = &notification.WorkspaceID.UUID
+	}
+	if notification.ReadAt.Valid {
+		converted.ReadAt = &notification.ReadAt.Time
+	}
+	return converted
+}
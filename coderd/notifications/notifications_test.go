@@ -0,0 +1,156 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+		var notifier *notifications.Notifier
+		notifier.Notify(context.Background(), notifications.Notification{})
+	})
+
+	t.Run("AllChannels", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		user := insertUser(t, db)
+		srv := mailertest.New(t)
+		webhooks := make(chan notifications.WebhookPayload, 1)
+		notifier := newNotifier(t, db, srv, webhooks)
+
+		workspaceID := uuid.New()
+		notifier.Notify(ctx, notifications.Notification{
+			Kind:        database.NotificationKindWorkspaceAutostop,
+			UserID:      user.ID,
+			WorkspaceID: workspaceID,
+			Title:       "Workspace is stopping soon",
+			Body:        "Workspace will stop in 30 minutes.",
+		})
+		notifier.Close(ctx)
+
+		inbox, err := db.GetNotificationsByUserID(ctx, database.GetNotificationsByUserIDParams{UserID: user.ID})
+		require.NoError(t, err)
+		require.Len(t, inbox, 1)
+		require.Equal(t, "Workspace is stopping soon", inbox[0].Title)
+
+		messages := srv.Messages()
+		require.Len(t, messages, 1)
+		require.Equal(t, []string{user.Email}, messages[0].To)
+		require.Equal(t, "Workspace is stopping soon", messages[0].Subject)
+
+		payload := <-webhooks
+		require.Equal(t, inbox[0].ID, payload.ID)
+		require.Equal(t, codersdk.NotificationKindWorkspaceAutostop, payload.Kind)
+		require.Equal(t, workspaceID, *payload.WorkspaceID)
+		require.Equal(t, user.Username, payload.Username)
+	})
+
+	t.Run("Preferences", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		user := insertUser(t, db)
+		srv := mailertest.New(t)
+		webhooks := make(chan notifications.WebhookPayload, 1)
+		notifier := newNotifier(t, db, srv, webhooks)
+
+		_, err := db.UpsertUserNotificationPreference(ctx, database.UpsertUserNotificationPreferenceParams{
+			UserID:  user.ID,
+			Kind:    database.NotificationKindWorkspaceBuildFailed,
+			Inbox:   true,
+			Email:   false,
+			Webhook: false,
+		})
+		require.NoError(t, err)
+
+		notifier.Notify(ctx, notifications.Notification{
+			Kind:   database.NotificationKindWorkspaceBuildFailed,
+			UserID: user.ID,
+			Title:  "Build failed",
+		})
+		notifier.Close(ctx)
+
+		inbox, err := db.GetNotificationsByUserID(ctx, database.GetNotificationsByUserIDParams{UserID: user.ID})
+		require.NoError(t, err)
+		require.Len(t, inbox, 1)
+		require.False(t, inbox[0].WorkspaceID.Valid)
+		require.Empty(t, srv.Messages())
+		require.Empty(t, webhooks)
+
+		preferences, err := notifications.Preferences(ctx, db, user.ID)
+		require.NoError(t, err)
+		require.Len(t, preferences, len(notifications.Kinds))
+		for _, preference := range preferences {
+			enabled := preference.Kind != database.NotificationKindWorkspaceBuildFailed
+			require.True(t, preference.Inbox)
+			require.Equal(t, enabled, preference.Email)
+			require.Equal(t, enabled, preference.Webhook)
+		}
+	})
+}
+
+func insertUser(t *testing.T, db database.Store) database.User {
+	t.Helper()
+
+	user, err := db.InsertUser(context.Background(), database.InsertUserParams{
+		ID:        uuid.New(),
+		Email:     "testuser@coder.com",
+		Username:  "testuser",
+		LoginType: database.LoginTypePassword,
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func newNotifier(t *testing.T, db database.Store, srv *mailertest.Server, webhooks chan<- notifications.WebhookPayload) *notifications.Notifier {
+	t.Helper()
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var payload notifications.WebhookPayload
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		webhooks <- payload
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(webhook.Close)
+	webhookURL, err := url.Parse(webhook.URL)
+	require.NoError(t, err)
+
+	return notifications.New(notifications.Options{
+		Database: db,
+		Logger:   slogtest.Make(t, nil),
+		Mailer: &mailer.SMTP{
+			Addr: srv.Addr,
+			From: "coder@coder.com",
+		},
+		WebhookURL: webhookURL,
+	})
+}
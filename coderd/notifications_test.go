@@ -0,0 +1,147 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+
+	t.Run("BuildFailed", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse:         echo.ParseComplete,
+			ProvisionPlan: echo.ProvisionComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Error: "test error",
+					},
+				},
+			}},
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// The notification is sent after the job is marked failed.
+		var notifications []codersdk.Notification
+		require.Eventually(t, func() bool {
+			var err error
+			notifications, err = client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{Unread: true})
+			return err == nil && len(notifications) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Equal(t, codersdk.NotificationKindWorkspaceBuildFailed, notifications[0].Kind)
+		require.Equal(t, workspace.ID, *notifications[0].WorkspaceID)
+		require.Contains(t, notifications[0].Body, "test error")
+		require.Nil(t, notifications[0].ReadAt)
+
+		err := client.MarkNotificationsRead(ctx, codersdk.Me, codersdk.MarkNotificationsReadRequest{})
+		require.NoError(t, err)
+		notifications, err = client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{Unread: true})
+		require.NoError(t, err)
+		require.Empty(t, notifications)
+		notifications, err = client.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		require.NotNil(t, notifications[0].ReadAt)
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		_, err := other.Notifications(ctx, user.UserID.String(), codersdk.NotificationsFilter{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		preferences, err := client.NotificationPreferences(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.NotEmpty(t, preferences)
+		for _, preference := range preferences {
+			require.True(t, preference.Inbox)
+			require.True(t, preference.Email)
+			require.True(t, preference.Webhook)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		updated, err := client.UpdateNotificationPreferences(ctx, codersdk.Me, codersdk.UpdateNotificationPreferencesRequest{
+			Preferences: []codersdk.NotificationPreference{{
+				Kind:  codersdk.NotificationKindWorkspaceAutostop,
+				Inbox: true,
+			}},
+		})
+		require.NoError(t, err)
+
+		preferences, err := client.NotificationPreferences(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, updated, preferences)
+		for _, preference := range preferences {
+			enabled := preference.Kind != codersdk.NotificationKindWorkspaceAutostop
+			require.True(t, preference.Inbox)
+			require.Equal(t, enabled, preference.Email)
+			require.Equal(t, enabled, preference.Webhook)
+		}
+	})
+
+	t.Run("InvalidKind", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		_, err := client.UpdateNotificationPreferences(ctx, codersdk.Me, codersdk.UpdateNotificationPreferencesRequest{
+			Preferences: []codersdk.NotificationPreference{{
+				Kind: "workspace_exploded",
+			}},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
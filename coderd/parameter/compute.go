@@ -143,6 +143,35 @@ func Compute(ctx context.Context, db database.Store, scope ComputeScope, options
 	return values, nil
 }
 
+// Missing returns the names of the parameters of the template version that
+// have no value in the scope, e.g. parameters without a default that were
+// added after a workspace was created.
+func Missing(ctx context.Context, db database.Store, scope ComputeScope) ([]string, error) {
+	values, err := Compute(ctx, db, scope, nil)
+	if err != nil {
+		return nil, err
+	}
+	parameterSchemas, err := db.GetParameterSchemasByJobID(ctx, scope.TemplateImportJobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("get template parameters: %w", err)
+	}
+
+	computed := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		computed[value.Name] = struct{}{}
+	}
+	var missing []string
+	for _, parameterSchema := range parameterSchemas {
+		if _, ok := computed[parameterSchema.Name]; !ok {
+			missing = append(missing, parameterSchema.Name)
+		}
+	}
+	return missing, nil
+}
+
 type compute struct {
 	options                 *ComputeOptions
 	db                      database.Store
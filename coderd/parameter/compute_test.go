@@ -183,3 +183,44 @@ func TestCompute(t *testing.T) {
 		require.Equal(t, computedValue.SourceValue, "")
 	})
 }
+
+func TestMissing(t *testing.T) {
+	t.Parallel()
+	db := databasefake.New()
+	scope := parameter.ComputeScope{
+		TemplateImportJobID: uuid.New(),
+		WorkspaceID: uuid.NullUUID{
+			UUID:  uuid.New(),
+			Valid: true,
+		},
+	}
+	for _, name := range []string{"region", "size", "image"} {
+		_, err := db.InsertParameterSchema(context.Background(), database.InsertParameterSchemaParams{
+			ID:                  uuid.New(),
+			JobID:               scope.TemplateImportJobID,
+			Name:                name,
+			DefaultSourceScheme: database.ParameterSourceSchemeNone,
+		})
+		require.NoError(t, err)
+	}
+	_, err := db.InsertParameterValue(context.Background(), database.InsertParameterValueParams{
+		ID:                uuid.New(),
+		Name:              "region",
+		Scope:             database.ParameterScopeWorkspace,
+		ScopeID:           scope.WorkspaceID.UUID,
+		SourceScheme:      database.ParameterSourceSchemeData,
+		SourceValue:       "eu",
+		DestinationScheme: database.ParameterDestinationSchemeProvisionerVariable,
+	})
+	require.NoError(t, err)
+	scope.AdditionalParameterValues = []database.ParameterValue{{
+		Name:              "image",
+		SourceScheme:      database.ParameterSourceSchemeData,
+		SourceValue:       "ubuntu",
+		DestinationScheme: database.ParameterDestinationSchemeProvisionerVariable,
+	}}
+
+	missing, err := parameter.Missing(context.Background(), db, scope)
+	require.NoError(t, err)
+	require.Equal(t, []string{"size"}, missing)
+}
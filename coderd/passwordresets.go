@@ -0,0 +1,220 @@
+package coderd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/userpassword"
+	"github.com/coder/coder/codersdk"
+)
+
+// postForgotPassword emails a password reset token to the user. The
+// response is the same whether or not the user exists, so it can't be used
+// to detect which emails are registered.
+func (api *API) postForgotPassword(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req codersdk.ForgotPasswordRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	if api.Mailer == nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Password reset by email isn't enabled.",
+			Detail:  "Contact an administrator to reset your password.",
+		})
+		return
+	}
+
+	user, err := api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+		Email: req.Email,
+	})
+	if errors.Is(err, sql.ErrNoRows) ||
+		(err == nil && (user.LoginType != database.LoginTypePassword || user.Status != database.UserStatusActive)) {
+		httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	id, secret, err := generateAPIKeyIDSecret()
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	var reset database.UserPasswordReset
+	err = api.Database.InTx(func(tx database.Store) error {
+		// Only the most recently requested token is valid.
+		err := tx.DeleteUserPasswordResetsByUserID(ctx, user.ID)
+		if err != nil {
+			return xerrors.Errorf("delete previous resets: %w", err)
+		}
+		reset, err = tx.InsertUserPasswordReset(ctx, database.InsertUserPasswordResetParams{
+			ID:           id,
+			HashedSecret: hashed[:],
+			UserID:       user.ID,
+			CreatedAt:    database.Now(),
+			ExpiresAt:    database.Now().Add(api.PasswordResetLifetime),
+		})
+		if err != nil {
+			return xerrors.Errorf("insert reset: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating password reset.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// The email is sent in the background so the response takes as long for
+	// registered emails as for unknown ones.
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Coder password",
+		Body: fmt.Sprintf(`A password reset was requested for your account on %s.
+
+Your password reset code is:
+
+    %s
+
+Enter it when prompted by "coder forgot-password" to choose a new password.
+The code can only be used once and expires on %s.
+
+If you didn't request a password reset, you can ignore this email.
+`, api.AccessURL, id+"-"+secret, reset.ExpiresAt.Format(time.RFC1123)),
+	}
+	api.mailWaitGroup.Add(1)
+	go func() {
+		defer api.mailWaitGroup.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := api.Mailer.Send(ctx, msg)
+		if err != nil {
+			// Failing the request would reveal that the user exists.
+			api.Logger.Error(ctx, "send password reset email", slog.F("user_id", user.ID), slog.Error(err))
+		}
+	}()
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// postResetPassword changes the password of a user with the token from a
+// password reset email. Every session of the user is revoked.
+func (api *API) postResetPassword(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req codersdk.ResetPasswordRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	id, secret, ok := parseEmailToken(req.Token)
+	reset, err := api.Database.GetUserPasswordResetByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching password reset.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	if !ok || err != nil ||
+		subtle.ConstantTimeCompare(reset.HashedSecret, hashed[:]) != 1 ||
+		database.Now().After(reset.ExpiresAt) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Password reset code is invalid or has expired.",
+			Detail:  "Request a new code with \"coder forgot-password\".",
+		})
+		return
+	}
+
+	err = userpassword.Validate(req.Password)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid password.",
+			Validations: []codersdk.ValidationError{
+				{
+					Field:  "password",
+					Detail: err.Error(),
+				},
+			},
+		})
+		return
+	}
+
+	hashedPassword, err := userpassword.Hash(req.Password)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error hashing new password.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		// Consuming the reset first locks it, so a concurrent request with
+		// the same token waits and then finds nothing to consume.
+		_, err := tx.ConsumeUserPasswordResetByID(ctx, reset.ID)
+		if err != nil {
+			return xerrors.Errorf("consume password reset: %w", err)
+		}
+
+		err = tx.UpdateUserHashedPassword(ctx, database.UpdateUserHashedPasswordParams{
+			ID:             reset.UserID,
+			HashedPassword: []byte(hashedPassword),
+		})
+		if err != nil {
+			return xerrors.Errorf("update user hashed password: %w", err)
+		}
+
+		err = tx.DeleteUserPasswordResetsByUserID(ctx, reset.UserID)
+		if err != nil {
+			return xerrors.Errorf("delete password resets by user ID: %w", err)
+		}
+
+		err = tx.DeleteAPIKeysByUserID(ctx, reset.UserID)
+		if err != nil {
+			return xerrors.Errorf("delete api keys by user ID: %w", err)
+		}
+
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Password reset code is invalid or has expired.",
+			Detail:  "Request a new code with \"coder forgot-password\".",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating user's password.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
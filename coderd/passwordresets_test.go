@@ -0,0 +1,187 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestResetPasswordByEmail(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		anonymous := codersdk.New(client.URL)
+		err := anonymous.ForgotPassword(ctx, codersdk.ForgotPasswordRequest{
+			Email: coderdtest.FirstUserParams.Email,
+		})
+		require.NoError(t, err)
+		messages := waitMessages(t, srv, 1)
+		require.Equal(t, []string{coderdtest.FirstUserParams.Email}, messages[0].To)
+		token := emailToken(t, messages[0])
+
+		err = anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+			Token:    token,
+			Password: "MyNewSecurePassword!",
+		})
+		require.NoError(t, err)
+
+		// Existing sessions are revoked.
+		_, err = client.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		_, err = anonymous.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    coderdtest.FirstUserParams.Email,
+			Password: "MyNewSecurePassword!",
+		})
+		require.NoError(t, err)
+
+		// Tokens can only be used once.
+		err = anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+			Token:    token,
+			Password: "AnotherSecurePassword!",
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("OnlyLatestToken", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		anonymous := codersdk.New(client.URL)
+		for i := 0; i < 2; i++ {
+			err := anonymous.ForgotPassword(ctx, codersdk.ForgotPasswordRequest{
+				Email: coderdtest.FirstUserParams.Email,
+			})
+			require.NoError(t, err)
+			// Wait for each email so they arrive in order.
+			waitMessages(t, srv, i+1)
+		}
+		messages := srv.Messages()
+
+		err := anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+			Token:    emailToken(t, messages[0]),
+			Password: "MyNewSecurePassword!",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		err = anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+			Token:    emailToken(t, messages[1]),
+			Password: "MyNewSecurePassword!",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		anonymous := codersdk.New(client.URL)
+		err := anonymous.ForgotPassword(ctx, codersdk.ForgotPasswordRequest{
+			Email: coderdtest.FirstUserParams.Email,
+		})
+		require.NoError(t, err)
+		token := emailToken(t, waitMessages(t, srv, 1)[0])
+
+		// Only one of the requests racing with the same token may succeed.
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int64
+		)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+					Token:    token,
+					Password: "MyNewSecurePassword!",
+				})
+				if err == nil {
+					succeeded.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		require.EqualValues(t, 1, succeeded.Load())
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The response doesn't reveal whether the user exists.
+		err := codersdk.New(client.URL).ForgotPassword(ctx, codersdk.ForgotPasswordRequest{
+			Email: "unknown@coder.com",
+		})
+		require.NoError(t, err)
+		require.Empty(t, srv.Messages())
+	})
+
+	t.Run("WeakPassword", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		anonymous := codersdk.New(client.URL)
+		err := anonymous.ForgotPassword(ctx, codersdk.ForgotPasswordRequest{
+			Email: coderdtest.FirstUserParams.Email,
+		})
+		require.NoError(t, err)
+
+		err = anonymous.ResetPassword(ctx, codersdk.ResetPasswordRequest{
+			Token:    emailToken(t, waitMessages(t, srv, 1)[0]),
+			Password: "weak",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+// waitMessages waits for the server to receive count emails, which are sent
+// in the background.
+func waitMessages(t *testing.T, srv *mailertest.Server, count int) []mailertest.Message {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return len(srv.Messages()) >= count
+	}, testutil.WaitShort, testutil.IntervalFast)
+	messages := srv.Messages()
+	require.Len(t, messages, count)
+	return messages
+}
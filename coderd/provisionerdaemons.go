@@ -84,6 +84,7 @@ func (api *API) ListenProvisionerDaemon(ctx context.Context, acquireJobDebounce
 		Pubsub:             api.Pubsub,
 		Provisioners:       daemon.Provisioners,
 		Telemetry:          api.Telemetry,
+		Notifier:           api.Notifier,
 		Logger:             api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
 		AcquireJobDebounce: acquireJobDebounce,
 	})
@@ -18,7 +18,9 @@ import (
 
 	"cdr.dev/slog"
 
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/codersdk"
@@ -41,6 +43,8 @@ type Server struct {
 	Database     database.Store
 	Pubsub       database.Pubsub
 	Telemetry    telemetry.Reporter
+	// Notifier notifies workspace owners of failed builds.
+	Notifier *notifications.Notifier
 
 	AcquireJobDebounce time.Duration
 }
@@ -486,6 +490,14 @@ func (server *Server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*p
 		}
 	case *proto.FailedJob_TemplateImport_:
 	}
+	// Canceled builds fail as well, but the user asked for them to stop.
+	if job.Type == database.ProvisionerJobTypeWorkspaceBuild && !job.CanceledAt.Valid {
+		server.notifyBuildFailed(ctx, job)
+		// Provisioners mark failures unrelated to the build, like a lost
+		// connection, as transient. Errors of the build itself, like
+		// invalid templates or failed applies, fail the same way every time.
+		server.scheduleBuildRecovery(ctx, job, failJob.Transient)
+	}
 
 	data, err := json.Marshal(ProvisionerJobLogsNotifyMessage{EndOfLogs: true})
 	if err != nil {
@@ -499,6 +511,168 @@ func (server *Server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*p
 	return &proto.Empty{}, nil
 }
 
+// notifyBuildFailed notifies the owner of the workspace that the build of the
+// job failed. Errors are logged, since they must not fail the job.
+func (server *Server) notifyBuildFailed(ctx context.Context, job database.ProvisionerJob) {
+	var input WorkspaceProvisionJob
+	err := json.Unmarshal(job.Input, &input)
+	if err != nil {
+		server.Logger.Warn(ctx, "unmarshal workspace provision input", slog.F("job_id", job.ID), slog.Error(err))
+		return
+	}
+	build, err := server.Database.GetWorkspaceBuildByID(ctx, input.WorkspaceBuildID)
+	if err != nil {
+		server.Logger.Warn(ctx, "get workspace build", slog.F("job_id", job.ID), slog.Error(err))
+		return
+	}
+	workspace, err := server.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		server.Logger.Warn(ctx, "get workspace", slog.F("job_id", job.ID), slog.Error(err))
+		return
+	}
+
+	notification := notifications.Notification{
+		Kind:        database.NotificationKindWorkspaceBuildFailed,
+		UserID:      workspace.OwnerID,
+		WorkspaceID: workspace.ID,
+		Title:       fmt.Sprintf("Workspace %q failed to %s", workspace.Name, build.Transition),
+		Body:        fmt.Sprintf("Build #%d of workspace %q failed: %s", build.BuildNumber, workspace.Name, job.Error.String),
+	}
+	if build.Reason == database.BuildReasonAutostart {
+		notification.Kind = database.NotificationKindWorkspaceAutostartFailed
+		notification.Title = fmt.Sprintf("Workspace %q failed to start", workspace.Name)
+		notification.Body = fmt.Sprintf("Workspace %q couldn't be started on schedule: %s", workspace.Name, job.Error.String)
+	}
+	server.Notifier.Notify(ctx, notification)
+}
+
+// scheduleBuildRecovery decides how a workspace whose start build failed is
+// recovered, following the policy of its template at the time of the
+// failure. Transient failures are retried with backoff. Once they can't be
+// retried, failed updates are rolled back to the template version of the
+// last successful build. The lifecycle executor runs the recovery once it's
+// due. Errors are logged, since they must not fail the job.
+func (server *Server) scheduleBuildRecovery(ctx context.Context, job database.ProvisionerJob, transient bool) {
+	logger := server.Logger.With(slog.F("job_id", job.ID))
+	var input WorkspaceProvisionJob
+	err := json.Unmarshal(job.Input, &input)
+	if err != nil {
+		logger.Warn(ctx, "unmarshal workspace provision input", slog.Error(err))
+		return
+	}
+	build, err := server.Database.GetWorkspaceBuildByID(ctx, input.WorkspaceBuildID)
+	if err != nil {
+		logger.Warn(ctx, "get workspace build", slog.Error(err))
+		return
+	}
+	// A failed rollback is left for the owner to fix, since building the
+	// workspace again would most likely fail again.
+	if build.Transition != database.WorkspaceTransitionStart || build.Reason == database.BuildReasonRollback {
+		return
+	}
+	workspace, err := server.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		logger.Warn(ctx, "get workspace", slog.Error(err))
+		return
+	}
+	template, err := server.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		logger.Warn(ctx, "get template", slog.Error(err))
+		return
+	}
+	policy, err := schedule.Policy(template)
+	if err != nil {
+		logger.Warn(ctx, "get template policy", slog.Error(err))
+		return
+	}
+	if policy.BuildRetries <= 0 && !policy.RollbackFailedUpdates {
+		return
+	}
+
+	recovery, ok, err := getBuildRecovery(ctx, server.Database, policy, build, job.CompletedAt.Time, transient)
+	if err != nil {
+		logger.Warn(ctx, "get workspace build recovery", slog.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+	err = server.Database.InsertWorkspaceBuildRecovery(ctx, recovery)
+	if err != nil {
+		logger.Warn(ctx, "insert workspace build recovery", slog.Error(err))
+	}
+}
+
+// getBuildRecovery returns the recovery of a failed start build, if it's
+// recovered at all.
+func getBuildRecovery(
+	ctx context.Context,
+	store database.Store,
+	policy schedule.TemplatePolicy,
+	build database.WorkspaceBuild,
+	failedAt time.Time,
+	transient bool,
+) (database.InsertWorkspaceBuildRecoveryParams, bool, error) {
+	// Count the retries since the build that failed first.
+	failed := build
+	retries := 0
+	for failed.Reason == database.BuildReasonRetry && failed.BuildNumber > 1 {
+		previous, err := store.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, database.GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams{
+			WorkspaceID: failed.WorkspaceID,
+			BuildNumber: failed.BuildNumber - 1,
+		})
+		if err != nil {
+			return database.InsertWorkspaceBuildRecoveryParams{}, false, xerrors.Errorf("get workspace build %d: %w", failed.BuildNumber-1, err)
+		}
+		failed = previous
+		retries++
+	}
+	if transient {
+		retryAt := policy.RetryAt(failedAt, retries)
+		if !retryAt.IsZero() {
+			return database.InsertWorkspaceBuildRecoveryParams{
+				WorkspaceBuildID:  build.ID,
+				Reason:            database.BuildReasonRetry,
+				TemplateVersionID: build.TemplateVersionID,
+				RecoverAt:         retryAt,
+			}, true, nil
+		}
+	}
+	if !policy.RollbackFailedUpdates {
+		return database.InsertWorkspaceBuildRecoveryParams{}, false, nil
+	}
+
+	for buildNumber := failed.BuildNumber - 1; buildNumber > 0; buildNumber-- {
+		target, err := store.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, database.GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams{
+			WorkspaceID: failed.WorkspaceID,
+			BuildNumber: buildNumber,
+		})
+		if err != nil {
+			return database.InsertWorkspaceBuildRecoveryParams{}, false, xerrors.Errorf("get workspace build %d: %w", buildNumber, err)
+		}
+		targetJob, err := store.GetProvisionerJobByID(ctx, target.JobID)
+		if err != nil {
+			return database.InsertWorkspaceBuildRecoveryParams{}, false, xerrors.Errorf("get provisioner job of workspace build %d: %w", buildNumber, err)
+		}
+		if !targetJob.CompletedAt.Valid || targetJob.Error.String != "" || targetJob.CanceledAt.Valid {
+			continue
+		}
+		// Only updates are rolled back. Failures on the version the
+		// workspace was already running on have nothing to return to.
+		if target.TemplateVersionID == build.TemplateVersionID {
+			return database.InsertWorkspaceBuildRecoveryParams{}, false, nil
+		}
+		return database.InsertWorkspaceBuildRecoveryParams{
+			WorkspaceBuildID:  build.ID,
+			Reason:            database.BuildReasonRollback,
+			TemplateVersionID: target.TemplateVersionID,
+			TargetBuildID:     uuid.NullUUID{UUID: target.ID, Valid: true},
+			RecoverAt:         failedAt,
+		}, true, nil
+	}
+	return database.InsertWorkspaceBuildRecoveryParams{}, false, nil
+}
+
 // CompleteJob is triggered by a provision daemon to mark a provisioner job as completed.
 func (server *Server) CompleteJob(ctx context.Context, completed *proto.CompletedJob) (*proto.Empty, error) {
 	jobID, err := uuid.Parse(completed.JobId)
@@ -573,6 +747,12 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 				if workspace.Ttl.Valid {
 					workspaceDeadline = now.Add(time.Duration(workspace.Ttl.Int64))
 				}
+				if workspaceBuild.Transition == database.WorkspaceTransitionStart {
+					workspaceDeadline, err = templatePolicyDeadline(ctx, db, workspace, now, workspaceDeadline)
+					if err != nil {
+						return xerrors.Errorf("apply template schedule policy: %w", err)
+					}
+				}
 			} else {
 				// Huh? Did the workspace get deleted?
 				// In any case, since this is just for the TTL, try and continue anyway.
@@ -680,6 +860,25 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 	return &proto.Empty{}, nil
 }
 
+// templatePolicyDeadline shortens the deadline of a workspace build that
+// started at now to comply with the scheduling policy of its template.
+func templatePolicyDeadline(ctx context.Context, db database.Store, workspace database.Workspace, now, deadline time.Time) (time.Time, error) {
+	template, err := db.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("get template: %w", err)
+	}
+	policy, err := schedule.Policy(template)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("parse policy: %w", err)
+	}
+	// The template may have stopped allowing users to disable autostop
+	// after the workspace TTL was unset.
+	if deadline.IsZero() && !policy.AllowUserAutostop && template.DefaultTtl > 0 {
+		deadline = now.Add(time.Duration(template.DefaultTtl))
+	}
+	return policy.ClampDeadline(now, deadline), nil
+}
+
 func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.UUID, transition database.WorkspaceTransition, protoResource *sdkproto.Resource, snapshot *telemetry.Snapshot) error {
 	resource, err := db.InsertWorkspaceResource(ctx, database.InsertWorkspaceResourceParams{
 		ID:         uuid.New(),
@@ -524,6 +524,89 @@ func TestFailJob(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "some state", string(build.ProvisionerState))
 	})
+	t.Run("TransientRetried", func(t *testing.T) {
+		t.Parallel()
+		srv := setup(t)
+		build, job := setupFailedStartBuild(ctx, t, srv)
+
+		_, err := srv.FailJob(ctx, &proto.FailedJob{
+			JobId:     job.ID.String(),
+			Error:     "recv workspace provision: EOF",
+			Transient: true,
+		})
+		require.NoError(t, err)
+		recovery, err := srv.Database.GetWorkspaceBuildRecoveryByBuildID(ctx, build.ID)
+		require.NoError(t, err)
+		require.Equal(t, database.BuildReasonRetry, recovery.Reason)
+		require.Equal(t, build.TemplateVersionID, recovery.TemplateVersionID)
+	})
+	t.Run("BuildErrorNotRetried", func(t *testing.T) {
+		t.Parallel()
+		srv := setup(t)
+		build, job := setupFailedStartBuild(ctx, t, srv)
+
+		// Errors returned by terraform along with the state fail the
+		// same way when retried.
+		_, err := srv.FailJob(ctx, &proto.FailedJob{
+			JobId: job.ID.String(),
+			Error: "terraform apply: exit status 1",
+			Type: &proto.FailedJob_WorkspaceBuild_{
+				WorkspaceBuild: &proto.FailedJob_WorkspaceBuild{
+					State: []byte("some state"),
+				},
+			},
+		})
+		require.NoError(t, err)
+		_, err = srv.Database.GetWorkspaceBuildRecoveryByBuildID(ctx, build.ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+// setupFailedStartBuild inserts a start build of a workspace whose template
+// retries failed builds, and acquires its job.
+func setupFailedStartBuild(ctx context.Context, t *testing.T, srv *provisionerdserver.Server) (database.WorkspaceBuild, database.ProvisionerJob) {
+	t.Helper()
+	template, err := srv.Database.InsertTemplate(ctx, database.InsertTemplateParams{
+		ID:           uuid.New(),
+		Name:         "template",
+		Provisioner:  database.ProvisionerTypeEcho,
+		BuildRetries: 1,
+	})
+	require.NoError(t, err)
+	workspace, err := srv.Database.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+		ID:         uuid.New(),
+		TemplateID: template.ID,
+	})
+	require.NoError(t, err)
+	build, err := srv.Database.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+		ID:                uuid.New(),
+		WorkspaceID:       workspace.ID,
+		TemplateVersionID: uuid.New(),
+		BuildNumber:       1,
+		Transition:        database.WorkspaceTransitionStart,
+		Reason:            database.BuildReasonInitiator,
+	})
+	require.NoError(t, err)
+	input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
+		WorkspaceBuildID: build.ID,
+	})
+	require.NoError(t, err)
+	job, err := srv.Database.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:          uuid.New(),
+		Provisioner: database.ProvisionerTypeEcho,
+		Type:        database.ProvisionerJobTypeWorkspaceBuild,
+		Input:       input,
+	})
+	require.NoError(t, err)
+	_, err = srv.Database.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
+		WorkerID: uuid.NullUUID{
+			UUID:  srv.ID,
+			Valid: true,
+		},
+		Types: []database.ProvisionerType{database.ProvisionerTypeEcho},
+	})
+	require.NoError(t, err)
+	return build, job
 }
 
 func TestCompleteJob(t *testing.T) {
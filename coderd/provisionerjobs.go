@@ -248,6 +248,7 @@ func (api *API) provisionerJobResources(rw http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	agentNodes := api.agentNodes(resourceAgents)
 	apiResources := make([]codersdk.WorkspaceResource, 0)
 	for _, resource := range resources {
 		agents := make([]codersdk.WorkspaceAgent, 0)
@@ -262,7 +263,7 @@ func (api *API) provisionerJobResources(rw http.ResponseWriter, r *http.Request,
 				}
 			}
 
-			apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), agent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
+			apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), agentNodes[agent.ID], agent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
 			if err != nil {
 				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 					Message: "Internal error reading job agent.",
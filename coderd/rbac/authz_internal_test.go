@@ -503,6 +503,42 @@ func TestAuthorizeDomain(t *testing.T) {
 		}),
 	)
 
+	user = subject{
+		UserID: "me",
+		Scope:  must(ScopeRole(ScopeTwoFactorEnrollment)),
+		Roles: []Role{
+			must(RoleByName(RoleOrgMember(defOrg))),
+			must(RoleByName(RoleMember())),
+		},
+	}
+
+	testAuthorize(t, "TwoFactorEnrollmentToken", user,
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionRead, ActionUpdate}
+			return c
+		}, []authTestCase{
+			{resource: ResourceUserData.WithOwner(user.UserID), allow: true},
+			{resource: ResourceUserData.WithOwner("not-me"), allow: false},
+		}),
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionCreate, ActionDelete}
+			c.allow = false
+			return c
+		}, []authTestCase{
+			{resource: ResourceUserData.WithOwner(user.UserID)},
+			{resource: ResourceAPIKey.WithOwner(user.UserID)},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID)},
+		}),
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionRead}
+			return c
+		}, []authTestCase{
+			{resource: ResourceUser, allow: true},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID), allow: false},
+			{resource: ResourceTemplate.InOrg(defOrg), allow: false},
+		}),
+	)
+
 	// In practice this is a token scope on a regular subject
 	user = subject{
 		UserID: "me",
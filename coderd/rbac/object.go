@@ -150,6 +150,21 @@ var (
 	ResourceReplicas = Object{
 		Type: "replicas",
 	}
+
+	// ResourceDebugInfo is the internal state of the deployment, e.g. the
+	// agents and clients connected to the tailnet coordinator.
+	//	read = view debug info
+	ResourceDebugInfo = Object{
+		Type: "debug_info",
+	}
+
+	// ResourceDERPRegion is a DERP region and its nodes managed through the API.
+	//	create/delete = add or remove a region or node
+	//	read = view regions and their health
+	//	update = change a region or node
+	ResourceDERPRegion = Object{
+		Type: "derp_region",
+	}
 )
 
 // Object is used to create objects for authz checks when you have none in
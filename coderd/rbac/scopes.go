@@ -11,6 +11,9 @@ type Scope string
 const (
 	ScopeAll                Scope = "all"
 	ScopeApplicationConnect Scope = "application_connect"
+	// ScopeTwoFactorEnrollment is given to sessions of users that must enroll
+	// in two-factor authentication before they can do anything else.
+	ScopeTwoFactorEnrollment Scope = "two_factor_enrollment"
 )
 
 var builtinScopes map[Scope]Role = map[Scope]Role{
@@ -35,6 +38,17 @@ var builtinScopes map[Scope]Role = map[Scope]Role{
 		Org:  map[string][]Permission{},
 		User: []Permission{},
 	},
+
+	ScopeTwoFactorEnrollment: {
+		Name:        fmt.Sprintf("Scope_%s", ScopeTwoFactorEnrollment),
+		DisplayName: "Ability to enroll in two-factor authentication",
+		Site: permissions(map[string][]Action{
+			ResourceUser.Type:     {ActionRead},
+			ResourceUserData.Type: {ActionRead, ActionUpdate},
+		}),
+		Org:  map[string][]Permission{},
+		User: []Permission{},
+	},
 }
 
 func ScopeRole(scope Scope) (Role, error) {
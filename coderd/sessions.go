@@ -0,0 +1,124 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) sessions(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		user   = httpmw.UserParam(r)
+		apiKey = httpmw.APIKey(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	keys, err := api.Database.GetSessionsByUserID(ctx, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching sessions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	sessions := make([]codersdk.Session, 0, len(keys))
+	for _, key := range keys {
+		sessions = append(sessions, convertSession(key, apiKey.ID))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, sessions)
+}
+
+func (api *API) deleteSession(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	sessionID := chi.URLParam(r, "session")
+	key, err := api.Database.GetAPIKeyByID(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) ||
+		(err == nil && (key.UserID != user.ID || key.LoginType == database.LoginTypeToken)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Database.DeleteAPIKeyByID(ctx, key.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting session.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// deleteSessions logs out every session of the user except the one making the
+// request, so users can log out everywhere else.
+func (api *API) deleteSessions(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		user   = httpmw.UserParam(r)
+		apiKey = httpmw.APIKey(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	err := api.Database.DeleteSessionsByUserID(ctx, database.DeleteSessionsByUserIDParams{
+		UserID:   user.ID,
+		ExceptID: apiKey.ID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting sessions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func convertSession(key database.APIKey, currentID string) codersdk.Session {
+	return codersdk.Session{
+		ID:        key.ID,
+		LoginType: codersdk.LoginType(key.LoginType),
+		Scope:     codersdk.APIKeyScope(key.Scope),
+		IPAddress: key.IPAddress.IPNet.IP.String(),
+		UserAgent: key.UserAgent,
+		CreatedAt: key.CreatedAt,
+		LastUsed:  key.LastUsed,
+		ExpiresAt: key.ExpiresAt,
+		Current:   key.ID == currentID,
+	}
+}
@@ -0,0 +1,157 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		// Tokens aren't sessions.
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+
+		sessions, err := client.Sessions(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		require.True(t, sessions[0].Current)
+		require.Equal(t, strings.Split(client.SessionToken(), "-")[0], sessions[0].ID)
+		require.Equal(t, codersdk.LoginTypePassword, sessions[0].LoginType)
+		require.Contains(t, sessions[0].UserAgent, "Go-http-client")
+		require.NotEmpty(t, sessions[0].IPAddress)
+	})
+
+	t.Run("DeleteOne", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		res, err := client.CreateAPIKey(ctx, codersdk.Me)
+		require.NoError(t, err)
+		other := codersdk.New(client.URL)
+		other.SetSessionToken(res.Key)
+
+		sessions, err := client.Sessions(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		err = client.DeleteSession(ctx, codersdk.Me, strings.Split(res.Key, "-")[0])
+		require.NoError(t, err)
+		_, err = other.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		token, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+		err = client.DeleteSession(ctx, codersdk.Me, strings.Split(token.Key, "-")[0])
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("DeleteAllOthers", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		res, err := client.CreateAPIKey(ctx, codersdk.Me)
+		require.NoError(t, err)
+		other := codersdk.New(client.URL)
+		other.SetSessionToken(res.Key)
+		token, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+		tokenClient := codersdk.New(client.URL)
+		tokenClient.SetSessionToken(token.Key)
+
+		err = client.DeleteSessions(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		// The current session and tokens keep working.
+		sessions, err := client.Sessions(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		require.True(t, sessions[0].Current)
+		_, err = tokenClient.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		_, err = other.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		otherUser, err := other.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		_, err = other.Sessions(ctx, user.UserID.String())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Sessions of other users can't be deleted through your own routes.
+		err = other.DeleteSession(ctx, codersdk.Me, strings.Split(client.SessionToken(), "-")[0])
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Owners can log other users out everywhere.
+		err = client.DeleteSessions(ctx, otherUser.ID.String())
+		require.NoError(t, err)
+		_, err = other.User(ctx, codersdk.Me)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("Suspend", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		otherUser, err := other.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		_, err = client.UpdateUserStatus(ctx, otherUser.ID.String(), codersdk.UserStatusSuspended)
+		require.NoError(t, err)
+		_, err = client.UpdateUserStatus(ctx, otherUser.ID.String(), codersdk.UserStatusActive)
+		require.NoError(t, err)
+
+		// Reactivating the user doesn't bring their sessions back.
+		_, err = other.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+		sessions, err := client.Sessions(ctx, otherUser.ID.String())
+		require.NoError(t, err)
+		require.Empty(t, sessions)
+	})
+}
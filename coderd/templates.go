@@ -17,6 +17,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -220,6 +221,53 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	policy := database.Template{
+		DefaultTtl:            int64(ttl),
+		AllowUserAutostop:     true,
+		ActivityBump:          int64(schedule.DefaultActivityBump),
+		ActivityBumpThreshold: int64(schedule.DefaultActivityBumpThreshold),
+	}
+	if createTemplate.MaxTTLMillis != nil {
+		policy.MaxTtl = int64(time.Duration(*createTemplate.MaxTTLMillis) * time.Millisecond)
+	}
+	if createTemplate.MinAutostartIntervalMillis != nil {
+		policy.MinAutostartInterval = int64(time.Duration(*createTemplate.MinAutostartIntervalMillis) * time.Millisecond)
+	}
+	if createTemplate.QuietHoursSchedule != nil {
+		policy.QuietHoursSchedule = *createTemplate.QuietHoursSchedule
+	}
+	if createTemplate.AllowUserAutostop != nil {
+		policy.AllowUserAutostop = *createTemplate.AllowUserAutostop
+	}
+	if createTemplate.InactivityTTLMillis != nil {
+		policy.InactivityTtl = int64(time.Duration(*createTemplate.InactivityTTLMillis) * time.Millisecond)
+	}
+	if createTemplate.DormancyDeletionTTLMillis != nil {
+		policy.DormancyDeletionTtl = int64(time.Duration(*createTemplate.DormancyDeletionTTLMillis) * time.Millisecond)
+	}
+	if createTemplate.ActivityBumpMillis != nil {
+		policy.ActivityBump = int64(time.Duration(*createTemplate.ActivityBumpMillis) * time.Millisecond)
+	}
+	if createTemplate.ActivityBumpThresholdMillis != nil {
+		policy.ActivityBumpThreshold = int64(time.Duration(*createTemplate.ActivityBumpThresholdMillis) * time.Millisecond)
+	}
+	if createTemplate.BuildRetries != nil {
+		policy.BuildRetries = int32(*createTemplate.BuildRetries)
+	}
+	if createTemplate.BuildRetryBackoffMillis != nil {
+		policy.BuildRetryBackoff = int64(time.Duration(*createTemplate.BuildRetryBackoffMillis) * time.Millisecond)
+	}
+	if createTemplate.RollbackFailedUpdates != nil {
+		policy.RollbackFailedUpdates = *createTemplate.RollbackFailedUpdates
+	}
+	if validErrs := validateTemplateSchedulePolicy(policy); len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid create template request.",
+			Validations: validErrs,
+		})
+		return
+	}
+
 	var dbTemplate database.Template
 	var template codersdk.Template
 	err = api.Database.InTx(func(tx database.Store) error {
@@ -239,6 +287,19 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 			GroupACL: database.TemplateACL{
 				organization.ID.String(): []rbac.Action{rbac.ActionRead},
 			},
+			MaxTtl:                   policy.MaxTtl,
+			MinAutostartInterval:     policy.MinAutostartInterval,
+			QuietHoursSchedule:       policy.QuietHoursSchedule,
+			AllowUserAutostop:        policy.AllowUserAutostop,
+			InactivityTtl:            policy.InactivityTtl,
+			DormancyDeletionTtl:      policy.DormancyDeletionTtl,
+			ActivityBump:             policy.ActivityBump,
+			ActivityBumpThreshold:    policy.ActivityBumpThreshold,
+			RequireActiveVersion:     createTemplate.RequireActiveVersion,
+			BuildRetries:             policy.BuildRetries,
+			BuildRetryBackoff:        policy.BuildRetryBackoff,
+			RollbackFailedUpdates:    policy.RollbackFailedUpdates,
+			AllowWorkspaceNetworking: createTemplate.AllowWorkspaceNetworking,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %s", err)
@@ -446,6 +507,55 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must be a positive integer."})
 	}
 
+	// The policy is validated as a whole since its fields depend on each
+	// other, e.g. the default TTL must not exceed the maximum TTL.
+	policy := template
+	policy.DefaultTtl = int64(time.Duration(req.DefaultTTLMillis) * time.Millisecond)
+	if req.MaxTTLMillis != nil {
+		policy.MaxTtl = int64(time.Duration(*req.MaxTTLMillis) * time.Millisecond)
+	}
+	if req.MinAutostartIntervalMillis != nil {
+		policy.MinAutostartInterval = int64(time.Duration(*req.MinAutostartIntervalMillis) * time.Millisecond)
+	}
+	if req.QuietHoursSchedule != nil {
+		policy.QuietHoursSchedule = *req.QuietHoursSchedule
+	}
+	if req.AllowUserAutostop != nil {
+		policy.AllowUserAutostop = *req.AllowUserAutostop
+	}
+	if req.InactivityTTLMillis != nil {
+		policy.InactivityTtl = int64(time.Duration(*req.InactivityTTLMillis) * time.Millisecond)
+	}
+	if req.DormancyDeletionTTLMillis != nil {
+		policy.DormancyDeletionTtl = int64(time.Duration(*req.DormancyDeletionTTLMillis) * time.Millisecond)
+	}
+	if req.ActivityBumpMillis != nil {
+		policy.ActivityBump = int64(time.Duration(*req.ActivityBumpMillis) * time.Millisecond)
+	}
+	if req.ActivityBumpThresholdMillis != nil {
+		policy.ActivityBumpThreshold = int64(time.Duration(*req.ActivityBumpThresholdMillis) * time.Millisecond)
+	}
+	if req.BuildRetries != nil {
+		policy.BuildRetries = int32(*req.BuildRetries)
+	}
+	if req.BuildRetryBackoffMillis != nil {
+		policy.BuildRetryBackoff = int64(time.Duration(*req.BuildRetryBackoffMillis) * time.Millisecond)
+	}
+	if req.RollbackFailedUpdates != nil {
+		policy.RollbackFailedUpdates = *req.RollbackFailedUpdates
+	}
+	requireActiveVersion := template.RequireActiveVersion
+	if req.RequireActiveVersion != nil {
+		requireActiveVersion = *req.RequireActiveVersion
+	}
+	allowWorkspaceNetworking := template.AllowWorkspaceNetworking
+	if req.AllowWorkspaceNetworking != nil {
+		allowWorkspaceNetworking = *req.AllowWorkspaceNetworking
+	}
+	if policy.DefaultTtl >= 0 {
+		validErrs = append(validErrs, validateTemplateSchedulePolicy(policy)...)
+	}
+
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid request to update template metadata!",
@@ -474,7 +584,20 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.Description == template.Description &&
 			req.DisplayName == template.DisplayName &&
 			req.Icon == template.Icon &&
-			req.DefaultTTLMillis == time.Duration(template.DefaultTtl).Milliseconds() {
+			req.DefaultTTLMillis == time.Duration(template.DefaultTtl).Milliseconds() &&
+			policy.MaxTtl == template.MaxTtl &&
+			policy.MinAutostartInterval == template.MinAutostartInterval &&
+			policy.QuietHoursSchedule == template.QuietHoursSchedule &&
+			policy.AllowUserAutostop == template.AllowUserAutostop &&
+			policy.InactivityTtl == template.InactivityTtl &&
+			policy.DormancyDeletionTtl == template.DormancyDeletionTtl &&
+			policy.ActivityBump == template.ActivityBump &&
+			policy.ActivityBumpThreshold == template.ActivityBumpThreshold &&
+			policy.BuildRetries == template.BuildRetries &&
+			policy.BuildRetryBackoff == template.BuildRetryBackoff &&
+			policy.RollbackFailedUpdates == template.RollbackFailedUpdates &&
+			requireActiveVersion == template.RequireActiveVersion &&
+			allowWorkspaceNetworking == template.AllowWorkspaceNetworking {
 			return nil
 		}
 
@@ -496,13 +619,26 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		}
 
 		updated, err = tx.UpdateTemplateMetaByID(ctx, database.UpdateTemplateMetaByIDParams{
-			ID:          template.ID,
-			UpdatedAt:   database.Now(),
-			Name:        name,
-			DisplayName: displayName,
-			Description: desc,
-			Icon:        icon,
-			DefaultTtl:  int64(maxTTL),
+			ID:                       template.ID,
+			UpdatedAt:                database.Now(),
+			Name:                     name,
+			DisplayName:              displayName,
+			Description:              desc,
+			Icon:                     icon,
+			DefaultTtl:               int64(maxTTL),
+			MaxTtl:                   policy.MaxTtl,
+			MinAutostartInterval:     policy.MinAutostartInterval,
+			QuietHoursSchedule:       policy.QuietHoursSchedule,
+			AllowUserAutostop:        policy.AllowUserAutostop,
+			InactivityTtl:            policy.InactivityTtl,
+			DormancyDeletionTtl:      policy.DormancyDeletionTtl,
+			ActivityBump:             policy.ActivityBump,
+			ActivityBumpThreshold:    policy.ActivityBumpThreshold,
+			RequireActiveVersion:     requireActiveVersion,
+			BuildRetries:             policy.BuildRetries,
+			BuildRetryBackoff:        policy.BuildRetryBackoff,
+			RollbackFailedUpdates:    policy.RollbackFailedUpdates,
+			AllowWorkspaceNetworking: allowWorkspaceNetworking,
 		})
 		if err != nil {
 			return err
@@ -652,6 +788,9 @@ func (api *API) autoImportTemplate(ctx context.Context, opts autoImportTemplateO
 			GroupACL: database.TemplateACL{
 				opts.orgID.String(): []rbac.Action{rbac.ActionRead},
 			},
+			AllowUserAutostop:     true,
+			ActivityBump:          int64(schedule.DefaultActivityBump),
+			ActivityBumpThreshold: int64(schedule.DefaultActivityBumpThreshold),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %w", err)
@@ -739,21 +878,81 @@ func (api *API) convertTemplate(
 	buildTimeStats := api.metricsCache.TemplateBuildTimeStats(template.ID)
 
 	return codersdk.Template{
-		ID:                  template.ID,
-		CreatedAt:           template.CreatedAt,
-		UpdatedAt:           template.UpdatedAt,
-		OrganizationID:      template.OrganizationID,
-		Name:                template.Name,
-		DisplayName:         template.DisplayName,
-		Provisioner:         codersdk.ProvisionerType(template.Provisioner),
-		ActiveVersionID:     template.ActiveVersionID,
-		WorkspaceOwnerCount: workspaceOwnerCount,
-		ActiveUserCount:     activeCount,
-		BuildTimeStats:      buildTimeStats,
-		Description:         template.Description,
-		Icon:                template.Icon,
-		DefaultTTLMillis:    time.Duration(template.DefaultTtl).Milliseconds(),
-		CreatedByID:         template.CreatedBy,
-		CreatedByName:       createdByName,
+		ID:                       template.ID,
+		CreatedAt:                template.CreatedAt,
+		UpdatedAt:                template.UpdatedAt,
+		OrganizationID:           template.OrganizationID,
+		Name:                     template.Name,
+		DisplayName:              template.DisplayName,
+		Provisioner:              codersdk.ProvisionerType(template.Provisioner),
+		ActiveVersionID:          template.ActiveVersionID,
+		WorkspaceOwnerCount:      workspaceOwnerCount,
+		ActiveUserCount:          activeCount,
+		BuildTimeStats:           buildTimeStats,
+		Description:              template.Description,
+		Icon:                     template.Icon,
+		DefaultTTLMillis:         time.Duration(template.DefaultTtl).Milliseconds(),
+		CreatedByID:              template.CreatedBy,
+		CreatedByName:            createdByName,
+		RequireActiveVersion:     template.RequireActiveVersion,
+		AllowWorkspaceNetworking: template.AllowWorkspaceNetworking,
+		TemplateSchedulePolicy: codersdk.TemplateSchedulePolicy{
+			MaxTTLMillis:                time.Duration(template.MaxTtl).Milliseconds(),
+			MinAutostartIntervalMillis:  time.Duration(template.MinAutostartInterval).Milliseconds(),
+			QuietHoursSchedule:          template.QuietHoursSchedule,
+			AllowUserAutostop:           template.AllowUserAutostop,
+			InactivityTTLMillis:         time.Duration(template.InactivityTtl).Milliseconds(),
+			DormancyDeletionTTLMillis:   time.Duration(template.DormancyDeletionTtl).Milliseconds(),
+			ActivityBumpMillis:          time.Duration(template.ActivityBump).Milliseconds(),
+			ActivityBumpThresholdMillis: time.Duration(template.ActivityBumpThreshold).Milliseconds(),
+			BuildRetries:                int(template.BuildRetries),
+			BuildRetryBackoffMillis:     time.Duration(template.BuildRetryBackoff).Milliseconds(),
+			RollbackFailedUpdates:       template.RollbackFailedUpdates,
+		},
+	}
+}
+
+// validateTemplateSchedulePolicy validates the scheduling policy fields of
+// the template, including the default TTL they constrain.
+func validateTemplateSchedulePolicy(template database.Template) []codersdk.ValidationError {
+	var validErrs []codersdk.ValidationError
+	if template.MaxTtl < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "max_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if template.MaxTtl > 0 && template.DefaultTtl > template.MaxTtl {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must not be greater than max_ttl_ms."})
+	}
+	if template.MinAutostartInterval < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "min_autostart_interval_ms", Detail: "Must be a positive integer."})
+	}
+	if template.QuietHoursSchedule != "" {
+		if _, err := schedule.Weekly(template.QuietHoursSchedule); err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "quiet_hours_schedule", Detail: err.Error()})
+		}
+	}
+	if template.InactivityTtl < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if template.DormancyDeletionTtl < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "dormancy_deletion_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if template.ActivityBump < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "activity_bump_ms", Detail: "Must be a positive integer."})
+	}
+	if template.ActivityBumpThreshold < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "activity_bump_threshold_ms", Detail: "Must be a positive integer."})
+	}
+	if template.ActivityBump > 0 && template.ActivityBumpThreshold > template.ActivityBump {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "activity_bump_threshold_ms", Detail: "Must not be greater than activity_bump_ms."})
+	}
+	if template.BuildRetries < 0 || template.BuildRetries > schedule.MaxBuildRetries {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "build_retries", Detail: fmt.Sprintf("Must be between 0 and %d.", schedule.MaxBuildRetries)})
+	}
+	if template.BuildRetryBackoff < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "build_retry_backoff_ms", Detail: "Must be a positive integer."})
+	}
+	if !template.AllowUserAutostop && template.DefaultTtl == 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "allow_user_autostop", Detail: "Requires default_ttl_ms to be set."})
 	}
+	return validErrs
 }
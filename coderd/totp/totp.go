@@ -0,0 +1,143 @@
+// Package totp implements time-based one-time passwords as described in
+// RFC 6238, compatible with common authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //#nosec // RFC 6238 defaults to SHA1, and authenticator apps expect it.
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cryptorand"
+)
+
+const (
+	// Period is the number of seconds each code is valid for.
+	Period = 30
+	// Digits is the length of generated codes.
+	Digits = 6
+	// Skew is the number of periods before and after the current one that
+	// are accepted, to allow for clock drift between the server and the
+	// user's device.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random base32 encoded secret.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", xerrors.Errorf("read random bytes: %w", err)
+	}
+	return base32Encoding.EncodeToString(secret), nil
+}
+
+// Code returns the code for the secret at the time provided.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := base32Encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", xerrors.Errorf("decode secret: %w", err)
+	}
+	return code(key, uint64(t.Unix())/Period), nil
+}
+
+// Validate reports whether the code is valid for the secret at the time
+// provided.
+func Validate(secret, passcode string, t time.Time) bool {
+	_, valid := ValidateStep(secret, passcode, t)
+	return valid
+}
+
+// ValidateStep is like Validate, but also returns the time step the code
+// belongs to, so callers can reject codes that were accepted before.
+func ValidateStep(secret, passcode string, t time.Time) (int64, bool) {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false
+	}
+	key, err := base32Encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	counter := t.Unix() / Period
+	var step int64
+	valid := false
+	for i := int64(-Skew); i <= Skew; i++ {
+		// Every period is compared to avoid leaking which one matched.
+		expected := code(key, uint64(counter+i))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(passcode)) == 1 {
+			step = counter + i
+			valid = true
+		}
+	}
+	return step, valid
+}
+
+// URL returns the "otpauth://" URL that authenticator apps consume, usually
+// through a QR code.
+func URL(issuer, account, secret string) string {
+	return (&url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/" + issuer + ":" + account,
+		RawQuery: url.Values{
+			"secret":    {secret},
+			"issuer":    {issuer},
+			"algorithm": {"SHA1"},
+			"digits":    {fmt.Sprint(Digits)},
+			"period":    {fmt.Sprint(Period)},
+		}.Encode(),
+	}).String()
+}
+
+// GenerateRecoveryCodes returns count single-use codes that can be used in
+// place of a TOTP code, and their hashes for storage.
+func GenerateRecoveryCodes(count int) (codes []string, hashed []string, err error) {
+	codes = make([]string, 0, count)
+	hashed = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		raw, err := cryptorand.StringCharset(cryptorand.Human, 10)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("generate recovery code: %w", err)
+		}
+		recoveryCode := raw[:5] + "-" + raw[5:]
+		codes = append(codes, recoveryCode)
+		hashed = append(hashed, HashRecoveryCode(recoveryCode))
+	}
+	return codes, hashed, nil
+}
+
+// HashRecoveryCode returns the hex encoded SHA256 hash of a recovery code.
+// Codes are normalized so they can be entered without the dash or in any
+// case.
+func HashRecoveryCode(recoveryCode string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(recoveryCode), "-", ""))
+	hashed := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hashed[:])
+}
+
+// code implements HOTP from RFC 4226.
+func code(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
@@ -0,0 +1,83 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/totp"
+)
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors from RFC 6238 Appendix B, truncated to six digits.
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	for _, tc := range []struct {
+		Unix int64
+		Code string
+	}{
+		{Unix: 59, Code: "287082"},
+		{Unix: 1111111109, Code: "081804"},
+		{Unix: 1111111111, Code: "050471"},
+		{Unix: 1234567890, Code: "005924"},
+		{Unix: 2000000000, Code: "279037"},
+		{Unix: 20000000000, Code: "353130"},
+	} {
+		code, err := totp.Code(secret, time.Unix(tc.Unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.Code, code, "time %d", tc.Unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	code, err := totp.Code(secret, now)
+	require.NoError(t, err)
+
+	require.True(t, totp.Validate(secret, code, now))
+	require.True(t, totp.Validate(secret, " "+code+" ", now))
+	require.True(t, totp.Validate(secret, code, now.Add(totp.Period*time.Second)))
+	require.True(t, totp.Validate(secret, code, now.Add(-totp.Period*time.Second)))
+	require.False(t, totp.Validate(secret, code, now.Add(3*totp.Period*time.Second)))
+	require.False(t, totp.Validate(secret, "", now))
+	require.False(t, totp.Validate(secret, "12345", now))
+	require.False(t, totp.Validate("not base32!", code, now))
+
+	step, valid := totp.ValidateStep(secret, code, now.Add(totp.Period*time.Second))
+	require.True(t, valid)
+	require.Equal(t, now.Unix()/totp.Period, step)
+}
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := url.Parse(totp.URL("Coder", "kyle@coder.com", "SECRET"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", parsed.Scheme)
+	require.Equal(t, "totp", parsed.Host)
+	require.Equal(t, "/Coder:kyle@coder.com", parsed.Path)
+	require.Equal(t, "SECRET", parsed.Query().Get("secret"))
+	require.Equal(t, "Coder", parsed.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	codes, hashed, err := totp.GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	require.Len(t, hashed, 10)
+	for i, code := range codes {
+		require.Len(t, code, 11)
+		require.Equal(t, hashed[i], totp.HashRecoveryCode(code))
+	}
+	require.Equal(t, totp.HashRecoveryCode("abcde-fghij"), totp.HashRecoveryCode(" ABCDEFGHIJ"))
+}
@@ -0,0 +1,368 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/totp"
+	"github.com/coder/coder/coderd/userpassword"
+	"github.com/coder/coder/codersdk"
+)
+
+// recoveryCodeCount is the number of recovery codes generated on enrollment.
+const recoveryCodeCount = 10
+
+func (api *API) userTwoFactor(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpmw.UserParam(r)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	twoFactor, err := api.Database.GetUserTwoFactorByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserTwoFactor(twoFactor))
+}
+
+func (api *API) postUserTwoFactor(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.UserTwoFactor](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	// The secret is returned in the response, so only the user themselves
+	// can enroll.
+	if apiKey.UserID != user.ID {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Users can only enroll themselves in two-factor authentication.",
+		})
+		return
+	}
+	if user.LoginType != database.LoginTypePassword {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Two-factor authentication is only supported for password logins.",
+			Detail:  "Users of login type \"" + string(user.LoginType) + "\" are authenticated by their identity provider.",
+		})
+		return
+	}
+
+	existing, err := api.Database.GetUserTwoFactorByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if existing.Enabled {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: "Two-factor authentication is already enabled.",
+			Detail:  "Reset two-factor authentication before enrolling again.",
+		})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	recoveryCodes, hashedRecoveryCodes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	var twoFactor database.UserTwoFactor
+	err = api.Database.InTx(func(tx database.Store) error {
+		// A pending enrollment is replaced, since the secret it was created
+		// with may have been lost.
+		err := tx.DeleteUserTwoFactorByUserID(ctx, user.ID)
+		if err != nil {
+			return xerrors.Errorf("delete pending enrollment: %w", err)
+		}
+		twoFactor, err = tx.InsertUserTwoFactor(ctx, database.InsertUserTwoFactorParams{
+			UserID:              user.ID,
+			CreatedAt:           database.Now(),
+			UpdatedAt:           database.Now(),
+			TOTPSecret:          secret,
+			HashedRecoveryCodes: hashedRecoveryCodes,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert enrollment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error enrolling in two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = twoFactor
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.UserTwoFactorEnrollment{
+		Secret:        secret,
+		URL:           totp.URL("Coder", user.Email, secret),
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (api *API) postUserTwoFactorVerify(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.UserTwoFactor](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if apiKey.UserID != user.ID {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Users can only enroll themselves in two-factor authentication.",
+		})
+		return
+	}
+
+	var req codersdk.VerifyUserTwoFactorRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	twoFactor, err := api.Database.GetUserTwoFactorByUserID(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "No two-factor authentication enrollment is pending.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = twoFactor
+	if twoFactor.Enabled {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Two-factor authentication is already enabled.",
+		})
+		return
+	}
+	step, valid := totp.ValidateStep(twoFactor.TOTPSecret, req.Code, database.Now())
+	if valid {
+		valid, err = api.consumeTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error verifying two-factor authentication code.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+	if !valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Incorrect two-factor authentication code.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "code",
+				Detail: "The code doesn't match the secret. Check the clock on your device is correct.",
+			}},
+		})
+		return
+	}
+
+	twoFactor, err = api.Database.UpdateUserTwoFactorEnabled(ctx, database.UpdateUserTwoFactorEnabledParams{
+		UserID:    user.ID,
+		Enabled:   true,
+		UpdatedAt: database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error enabling two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = twoFactor
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserTwoFactor(twoFactor))
+}
+
+func (api *API) deleteUserTwoFactor(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.UserTwoFactor](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+
+	// Admins can reset two-factor authentication for users that lost their
+	// device. Users can only remove it themselves when it isn't required.
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUser) {
+		if api.TwoFactorRequired || !api.Authorize(r, rbac.ActionDelete, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+			httpapi.Forbidden(rw)
+			return
+		}
+	}
+
+	var req codersdk.ResetUserTwoFactorRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	twoFactor, err := api.Database.GetUserTwoFactorByUserID(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: "User isn't enrolled in two-factor authentication.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = twoFactor
+
+	// A stolen session mustn't be enough to remove two-factor authentication
+	// from an account, so users prove they are in control of it.
+	if twoFactor.Enabled && apiKey.UserID == user.ID {
+		valid := false
+		if req.Code != "" {
+			valid, err = api.verifyTwoFactorCode(ctx, twoFactor, req.Code)
+		} else if req.Password != "" {
+			valid, err = userpassword.Compare(string(user.HashedPassword), req.Password)
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error verifying your identity.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !valid {
+			httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+				Message: "Incorrect two-factor authentication code or password.",
+				Detail:  "Provide a code from your authenticator app, a recovery code or your password to remove two-factor authentication.",
+			})
+			return
+		}
+	}
+
+	err = api.Database.DeleteUserTwoFactorByUserID(ctx, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error resetting two-factor authentication.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// verifyTwoFactorCode checks a TOTP or recovery code provided on login.
+// Both can only be used once.
+func (api *API) verifyTwoFactorCode(ctx context.Context, twoFactor database.UserTwoFactor, code string) (bool, error) {
+	if step, valid := totp.ValidateStep(twoFactor.TOTPSecret, code, database.Now()); valid {
+		return api.consumeTOTPStep(ctx, twoFactor.UserID, step)
+	}
+
+	// The code is removed only if it's still there, so concurrent logins
+	// can't both use it.
+	_, err := api.Database.ConsumeUserTwoFactorRecoveryCode(ctx, database.ConsumeUserTwoFactorRecoveryCodeParams{
+		UserID:    twoFactor.UserID,
+		Code:      totp.HashRecoveryCode(code),
+		UpdatedAt: database.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("consume recovery code: %w", err)
+	}
+	return true, nil
+}
+
+// consumeTOTPStep records the time step of an accepted TOTP code. It
+// reports false if a code of the same or a later step was accepted before,
+// so a code that was observed can't be replayed within its window.
+func (api *API) consumeTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	_, err := api.Database.UpdateUserTwoFactorLastTOTPStep(ctx, database.UpdateUserTwoFactorLastTOTPStepParams{
+		UserID:       userID,
+		LastTOTPStep: step,
+		UpdatedAt:    database.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("record code: %w", err)
+	}
+	return true, nil
+}
+
+func convertUserTwoFactor(twoFactor database.UserTwoFactor) codersdk.UserTwoFactor {
+	converted := codersdk.UserTwoFactor{
+		Enabled:   twoFactor.Enabled,
+		UpdatedAt: twoFactor.UpdatedAt,
+	}
+	// Recovery codes of a pending enrollment can't be used yet.
+	if twoFactor.Enabled {
+		converted.RecoveryCodesRemaining = len(twoFactor.HashedRecoveryCodes)
+	}
+	return converted
+}
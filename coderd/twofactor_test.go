@@ -0,0 +1,320 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/totp"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestUserTwoFactor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Enroll", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		status, err := client.UserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.False(t, status.Enabled)
+
+		enrollment, err := client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.NotEmpty(t, enrollment.Secret)
+		require.Contains(t, enrollment.URL, "otpauth://totp/")
+		require.Len(t, enrollment.RecoveryCodes, 10)
+
+		// Logging in doesn't require a code until enrollment is verified.
+		_, err = client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    coderdtest.FirstUserParams.Email,
+			Password: coderdtest.FirstUserParams.Password,
+		})
+		require.NoError(t, err)
+
+		_, err = client.VerifyUserTwoFactor(ctx, codersdk.Me, codersdk.VerifyUserTwoFactorRequest{
+			Code: "000000",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		status = verifyTwoFactor(ctx, t, client, enrollment.Secret)
+		require.True(t, status.Enabled)
+		require.Equal(t, 10, status.RecoveryCodesRemaining)
+
+		_, err = client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		// Enrolling, the failed verification and the successful one.
+		require.Len(t, auditor.AuditLogs, 3)
+		require.Equal(t, database.AuditActionCreate, auditor.AuditLogs[0].Action)
+		require.Equal(t, database.ResourceTypeUserTwoFactor, auditor.AuditLogs[0].ResourceType)
+		require.Equal(t, database.AuditActionWrite, auditor.AuditLogs[2].Action)
+		require.Equal(t, int32(http.StatusOK), auditor.AuditLogs[2].StatusCode)
+	})
+
+	t.Run("OnlySelf", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		first := coderdtest.CreateFirstUser(t, client)
+		_, other := coderdtest.CreateAnotherUserWithUser(t, client, first.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.EnrollUserTwoFactor(ctx, other.ID.String())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		enrollment, err := client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, client, enrollment.Secret)
+
+		req := codersdk.LoginWithPasswordRequest{
+			Email:    coderdtest.FirstUserParams.Email,
+			Password: coderdtest.FirstUserParams.Password,
+		}
+		_, err = client.LoginWithPassword(ctx, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "two_factor_code", apiErr.Validations[0].Field)
+
+		req.TwoFactorCode = "000000"
+		_, err = client.LoginWithPassword(ctx, req)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		req.TwoFactorCode, err = totp.Code(enrollment.Secret, time.Now())
+		require.NoError(t, err)
+		_, err = client.LoginWithPassword(ctx, req)
+		require.NoError(t, err)
+
+		// Codes can't be replayed.
+		_, err = client.LoginWithPassword(ctx, req)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("RecoveryCode", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		enrollment, err := client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, client, enrollment.Secret)
+
+		req := codersdk.LoginWithPasswordRequest{
+			Email:         coderdtest.FirstUserParams.Email,
+			Password:      coderdtest.FirstUserParams.Password,
+			TwoFactorCode: enrollment.RecoveryCodes[0],
+		}
+		_, err = client.LoginWithPassword(ctx, req)
+		require.NoError(t, err)
+
+		// Recovery codes can only be used once.
+		_, err = client.LoginWithPassword(ctx, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		status, err := client.UserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, 9, status.RecoveryCodesRemaining)
+	})
+
+	t.Run("RecoveryCodeConcurrent", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		enrollment, err := client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, client, enrollment.Secret)
+
+		req := codersdk.LoginWithPasswordRequest{
+			Email:         coderdtest.FirstUserParams.Email,
+			Password:      coderdtest.FirstUserParams.Password,
+			TwoFactorCode: enrollment.RecoveryCodes[0],
+		}
+		// Only one of the logins racing with the same code may succeed.
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int64
+		)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.LoginWithPassword(ctx, req)
+				if err == nil {
+					succeeded.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		require.EqualValues(t, 1, succeeded.Load())
+
+		status, err := client.UserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, 9, status.RecoveryCodesRemaining)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		first := coderdtest.CreateFirstUser(t, client)
+		other, user := coderdtest.CreateAnotherUserWithUser(t, client, first.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		enrollment, err := other.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, other, enrollment.Secret)
+
+		// Members can't reset the two-factor authentication of others.
+		third := coderdtest.CreateAnotherUser(t, client, first.OrganizationID)
+		err = third.ResetUserTwoFactor(ctx, user.ID.String(), codersdk.ResetUserTwoFactorRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		err = client.ResetUserTwoFactor(ctx, user.ID.String(), codersdk.ResetUserTwoFactorRequest{})
+		require.NoError(t, err)
+		status, err := other.UserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.False(t, status.Enabled)
+
+		err = client.ResetUserTwoFactor(ctx, user.ID.String(), codersdk.ResetUserTwoFactorRequest{})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		require.Equal(t, database.AuditActionDelete, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+		require.Equal(t, user.ID, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceID)
+	})
+
+	t.Run("ResetSelf", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		first := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, first.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		enrollment, err := member.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, member, enrollment.Secret)
+
+		// The session alone isn't enough to remove two-factor authentication.
+		err = member.ResetUserTwoFactor(ctx, codersdk.Me, codersdk.ResetUserTwoFactorRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+		err = member.ResetUserTwoFactor(ctx, codersdk.Me, codersdk.ResetUserTwoFactorRequest{
+			Password: "wrong password",
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		code, err := totp.Code(enrollment.Secret, time.Now())
+		require.NoError(t, err)
+		err = member.ResetUserTwoFactor(ctx, codersdk.Me, codersdk.ResetUserTwoFactorRequest{
+			Code: code,
+		})
+		require.NoError(t, err)
+		status, err := member.UserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.False(t, status.Enabled)
+	})
+
+	t.Run("Required", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{TwoFactorRequired: true})
+		_, err := client.CreateFirstUser(context.Background(), coderdtest.FirstUserParams)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		req := codersdk.LoginWithPasswordRequest{
+			Email:    coderdtest.FirstUserParams.Email,
+			Password: coderdtest.FirstUserParams.Password,
+		}
+		login, err := client.LoginWithPassword(ctx, req)
+		require.NoError(t, err)
+		require.True(t, login.TwoFactorEnrollmentRequired)
+		client.SetSessionToken(login.SessionToken)
+
+		// The session can only be used to enroll.
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.Error(t, err)
+		err = client.ResetUserTwoFactor(ctx, codersdk.Me, codersdk.ResetUserTwoFactorRequest{})
+		require.Error(t, err)
+
+		enrollment, err := client.EnrollUserTwoFactor(ctx, codersdk.Me)
+		require.NoError(t, err)
+		verifyTwoFactor(ctx, t, client, enrollment.Secret)
+
+		req.TwoFactorCode, err = totp.Code(enrollment.Secret, time.Now())
+		require.NoError(t, err)
+		login, err = client.LoginWithPassword(ctx, req)
+		require.NoError(t, err)
+		require.False(t, login.TwoFactorEnrollmentRequired)
+		client.SetSessionToken(login.SessionToken)
+
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+	})
+}
+
+func verifyTwoFactor(ctx context.Context, t *testing.T, client *codersdk.Client, secret string) codersdk.UserTwoFactor {
+	t.Helper()
+
+	// The code of the previous period is used, so tests can log in with the
+	// current one right away.
+	code, err := totp.Code(secret, time.Now().Add(-totp.Period*time.Second))
+	require.NoError(t, err)
+	status, err := client.VerifyUserTwoFactor(ctx, codersdk.Me, codersdk.VerifyUserTwoFactorRequest{
+		Code: code,
+	})
+	require.NoError(t, err)
+	return status
+}
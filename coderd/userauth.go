@@ -20,6 +20,7 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ldapauth"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -47,6 +48,7 @@ func (api *API) userAuthMethods(rw http.ResponseWriter, r *http.Request) {
 		Password: true,
 		Github:   api.GithubOAuth2Config != nil,
 		OIDC:     api.OIDCConfig != nil,
+		LDAP:     api.LDAPConfig != nil,
 	})
 }
 
@@ -319,6 +321,69 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 	http.Redirect(rw, r, redirect, http.StatusTemporaryRedirect)
 }
 
+// userLDAP authenticates the credentials provided to postLogin against the
+// LDAP directory. The directory entry is linked to a Coder user the same way
+// OAuth identities are.
+func (api *API) userLDAP(rw http.ResponseWriter, r *http.Request, req codersdk.LoginWithPasswordRequest) {
+	ctx := r.Context()
+
+	entry, err := api.LDAPConfig.Authenticate(ctx, req.Email, req.Password)
+	if errors.Is(err, ldapauth.ErrInvalidCredentials) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Incorrect email or password.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to authenticate with LDAP.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	username := entry.Username
+	if httpapi.NameValid(username) != nil {
+		if username == "" {
+			username = entry.Email
+		}
+		username = httpapi.UsernameFrom(username)
+	}
+
+	cookie, err := api.oauthLogin(r, oauthLoginParams{
+		// LDAP doesn't issue tokens, so the link is stored without any.
+		State:        httpmw.OAuth2State{Token: &oauth2.Token{}},
+		LinkedID:     ldapLinkedID(api.LDAPConfig, entry),
+		LoginType:    database.LoginTypeLDAP,
+		AllowSignups: api.LDAPConfig.AllowSignups,
+		Email:        entry.Email,
+		Username:     username,
+		GroupSync:    api.LDAPConfig.GroupSync,
+		Groups:       entry.Groups,
+	})
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
+			Message: httpErr.msg,
+			Detail:  httpErr.detail,
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to process LDAP login.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	http.SetCookie(rw, cookie)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.LoginWithPasswordResponse{
+		SessionToken: cookie.Value,
+	})
+}
+
 type oauthLoginParams struct {
 	State     httpmw.OAuth2State
 	LinkedID  string
@@ -330,12 +395,18 @@ type oauthLoginParams struct {
 	Email        string
 	Username     string
 	AvatarURL    string
+
+	// GroupSync replaces the user's group memberships with the groups
+	// named in Groups.
+	GroupSync bool
+	Groups    []string
 }
 
 type httpError struct {
-	code   int
-	msg    string
-	detail string
+	code        int
+	msg         string
+	detail      string
+	validations []codersdk.ValidationError
 }
 
 func (e httpError) Error() string {
@@ -500,6 +571,13 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 			needsUpdate = true
 		}
 
+		if params.GroupSync {
+			err = syncUserGroups(ctx, tx, user.ID, params.Groups)
+			if err != nil {
+				return xerrors.Errorf("sync user groups: %w", err)
+			}
+		}
+
 		if needsUpdate {
 			// TODO(JonA): Since we're processing updates to a user's upstream
 			// email/username, it's possible for a different built-in user to
@@ -529,6 +607,7 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 		UserID:     user.ID,
 		LoginType:  params.LoginType,
 		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("create API key: %w", err)
@@ -548,6 +627,61 @@ func oidcLinkedID(tok *oidc.IDToken) string {
 	return strings.Join([]string{tok.Issuer, tok.Subject}, "||")
 }
 
+// ldapLinkedID returns the unique ID for an LDAP user.
+func ldapLinkedID(cfg *ldapauth.Config, entry ldapauth.Entry) string {
+	return strings.Join([]string{cfg.URL, entry.DN}, "||")
+}
+
+// syncUserGroups adds the user to the groups of their organizations that are
+// named in groups, and removes them from the ones that aren't. Groups that
+// don't exist in Coder are ignored.
+func syncUserGroups(ctx context.Context, db database.Store, userID uuid.UUID, groups []string) error {
+	want := make(map[string]struct{}, len(groups))
+	for _, group := range groups {
+		want[group] = struct{}{}
+	}
+
+	current, err := db.GetUserGroups(ctx, userID)
+	if err != nil {
+		return xerrors.Errorf("get user groups: %w", err)
+	}
+	isMember := make(map[uuid.UUID]struct{}, len(current))
+	for _, group := range current {
+		isMember[group.ID] = struct{}{}
+	}
+
+	organizations, err := db.GetOrganizationsByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get user organizations: %w", err)
+	}
+	for _, organization := range organizations {
+		orgGroups, err := db.GetGroupsByOrganizationID(ctx, organization.ID)
+		if err != nil {
+			return xerrors.Errorf("get organization groups: %w", err)
+		}
+		for _, group := range orgGroups {
+			_, wanted := want[group.Name]
+			_, member := isMember[group.ID]
+			switch {
+			case wanted && !member:
+				err = db.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+					UserID:  userID,
+					GroupID: group.ID,
+				})
+			case !wanted && member:
+				err = db.DeleteGroupMemberFromGroup(ctx, database.DeleteGroupMemberFromGroupParams{
+					UserID:  userID,
+					GroupID: group.ID,
+				})
+			}
+			if err != nil {
+				return xerrors.Errorf("update membership of group %q: %w", group.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // findLinkedUser tries to find a user by their unique OAuth-linked ID.
 // If it doesn't not find it, it returns the user by their email.
 func findLinkedUser(ctx context.Context, db database.Store, linkedID string, emails ...string) (database.User, database.UserLink, error) {
@@ -14,6 +14,7 @@ import (
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/go-github/v43/github"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
@@ -22,6 +23,9 @@ import (
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/ldapauth"
+	"github.com/coder/coder/coderd/ldapauth/ldaptest"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -94,6 +98,185 @@ func TestUserAuthMethods(t *testing.T) {
 		require.True(t, methods.Password)
 		require.True(t, methods.Github)
 	})
+	t.Run("LDAP", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			LDAPConfig: &ldapauth.Config{},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		methods, err := client.AuthMethods(ctx)
+		require.NoError(t, err)
+		require.True(t, methods.Password)
+		require.True(t, methods.LDAP)
+	})
+}
+
+func TestUserLDAP(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, allowSignups, groupSync bool) (*codersdk.Client, database.Store) {
+		srv := ldaptest.New(t, ldaptest.Entry{
+			DN:       "cn=coder,dc=example,dc=com",
+			Password: "service",
+		}, ldaptest.Entry{
+			DN:       "uid=kyle,ou=people,dc=example,dc=com",
+			Password: "hunter2",
+			Attributes: map[string][]string{
+				"uid":      {"kyle"},
+				"mail":     {"kyle@coder.com"},
+				"memberOf": {"cn=developers,ou=groups,dc=example,dc=com"},
+			},
+		})
+		db, pubsub := dbtestutil.NewDB(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			Database: db,
+			Pubsub:   pubsub,
+			LDAPConfig: &ldapauth.Config{
+				URL:               srv.URL,
+				BindDN:            "cn=coder,dc=example,dc=com",
+				BindPassword:      "service",
+				SearchBaseDN:      "ou=people,dc=example,dc=com",
+				SearchFilter:      "(mail=%s)",
+				UsernameAttribute: "uid",
+				EmailAttribute:    "mail",
+				GroupAttribute:    "memberOf",
+				GroupSync:         groupSync,
+				AllowSignups:      allowSignups,
+			},
+		})
+		return client, db
+	}
+
+	t.Run("Signup", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setup(t, true, false)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		res, err := client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "hunter2",
+		})
+		require.NoError(t, err)
+		client.SetSessionToken(res.SessionToken)
+		user, err := client.User(ctx, "me")
+		require.NoError(t, err)
+		require.Equal(t, "kyle", user.Username)
+		require.Equal(t, "kyle@coder.com", user.Email)
+
+		// Logging in again must reuse the linked user.
+		res, err = client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "hunter2",
+		})
+		require.NoError(t, err)
+		client.SetSessionToken(res.SessionToken)
+		again, err := client.User(ctx, "me")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, again.ID)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setup(t, true, false)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "wrong",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("NoSignups", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setup(t, false, false)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "hunter2",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("PasswordUsersUnaffected", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setup(t, true, false)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    coderdtest.FirstUserParams.Email,
+			Password: coderdtest.FirstUserParams.Password,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("GroupSync", func(t *testing.T) {
+		t.Parallel()
+		client, db := setup(t, true, true)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		developers, err := db.InsertGroup(ctx, database.InsertGroupParams{
+			ID:             uuid.New(),
+			Name:           "developers",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+		stale, err := db.InsertGroup(ctx, database.InsertGroupParams{
+			ID:             uuid.New(),
+			Name:           "contractors",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+
+		res, err := client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "hunter2",
+		})
+		require.NoError(t, err)
+		client.SetSessionToken(res.SessionToken)
+		user, err := client.User(ctx, "me")
+		require.NoError(t, err)
+
+		err = db.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+			UserID:  user.ID,
+			GroupID: stale.ID,
+		})
+		require.NoError(t, err)
+
+		_, err = client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "kyle@coder.com",
+			Password: "hunter2",
+		})
+		require.NoError(t, err)
+
+		groups, err := db.GetUserGroups(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, developers.ID, groups[0].ID)
+	})
 }
 
 // nolint:bodyclose
@@ -0,0 +1,344 @@
+package coderd
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/telemetry"
+	"github.com/coder/coder/coderd/userpassword"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) userInvitations(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Invitations can only be managed by those that can create users.
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceUser) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	invitations, err := api.Database.GetUserInvitations(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user invitations.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	converted := make([]codersdk.UserInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		converted = append(converted, convertUserInvitation(invitation))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func (api *API) postUserInvitation(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		apiKey = httpmw.APIKey(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceUser) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	var req codersdk.CreateUserInvitationRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	if !api.Authorize(r, rbac.ActionCreate,
+		rbac.ResourceOrganizationMember.InOrg(req.OrganizationID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	if api.Mailer == nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Sending emails isn't configured.",
+			Detail:  "Set an SMTP relay with --smtp-address to invite users by email.",
+		})
+		return
+	}
+
+	_, err := api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+		Email: req.Email,
+	})
+	if err == nil {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: "A user with that email already exists.",
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	_, err = api.Database.GetOrganizationByID(ctx, req.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Organization does not exist with the provided id %q.", req.OrganizationID),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	id, secret, err := generateAPIKeyIDSecret()
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	invitation, err := api.Database.InsertUserInvitation(ctx, database.InsertUserInvitationParams{
+		ID:             id,
+		HashedSecret:   hashed[:],
+		Email:          req.Email,
+		OrganizationID: req.OrganizationID,
+		CreatedBy:      apiKey.UserID,
+		CreatedAt:      database.Now(),
+		ExpiresAt:      database.Now().Add(api.UserInvitationLifetime),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating user invitation.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Mailer.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: "You've been invited to Coder",
+		Body: fmt.Sprintf(`You've been invited to join Coder at %s.
+
+To create your account, install the Coder CLI and run:
+
+    coder login %s --invitation %s
+
+The invitation can only be used once and expires on %s.
+`, api.AccessURL, api.AccessURL, id+"-"+secret, invitation.ExpiresAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		// The invitation is useless if it couldn't be delivered.
+		_ = api.Database.DeleteUserInvitationByID(ctx, invitation.ID)
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error sending invitation email.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertUserInvitation(invitation))
+}
+
+func (api *API) deleteUserInvitation(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceUser) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	invitationID := chi.URLParam(r, "invitation")
+	_, err := api.Database.GetUserInvitationByID(ctx, invitationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user invitation.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Database.DeleteUserInvitationByID(ctx, invitationID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting user invitation.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// postUserInvitationAccept creates the invited user. The token from the
+// invitation email authorizes the request, so no session is required.
+func (api *API) postUserInvitationAccept(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req codersdk.AcceptUserInvitationRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	id, secret, ok := parseEmailToken(req.Token)
+	invitation, err := api.Database.GetUserInvitationByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user invitation.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	if !ok || err != nil ||
+		subtle.ConstantTimeCompare(invitation.HashedSecret, hashed[:]) != 1 ||
+		database.Now().After(invitation.ExpiresAt) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Invitation is invalid or has expired.",
+			Detail:  "Ask an administrator to invite you again.",
+		})
+		return
+	}
+
+	err = userpassword.Validate(req.Password)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid password.",
+			Validations: []codersdk.ValidationError{
+				{
+					Field:  "password",
+					Detail: err.Error(),
+				},
+			},
+		})
+		return
+	}
+
+	_, err = api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+		Username: req.Username,
+		Email:    invitation.Email,
+	})
+	if err == nil {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: "User already exists.",
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	var user database.User
+	err = api.Database.InTx(func(tx database.Store) error {
+		// Consuming the invitation first locks it, so a concurrent request
+		// with the same token waits and then finds nothing to consume.
+		_, err := tx.ConsumeUserInvitationByID(ctx, invitation.ID)
+		if err != nil {
+			return xerrors.Errorf("consume invitation: %w", err)
+		}
+		user, _, err = api.CreateUser(ctx, tx, CreateUserRequest{
+			CreateUserRequest: codersdk.CreateUserRequest{
+				Email:          invitation.Email,
+				Username:       req.Username,
+				Password:       req.Password,
+				OrganizationID: invitation.OrganizationID,
+			},
+			LoginType: database.LoginTypePassword,
+		})
+		if err != nil {
+			return xerrors.Errorf("create user: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Invitation is invalid or has expired.",
+			Detail:  "Ask an administrator to invite you again.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	api.Telemetry.Report(&telemetry.Snapshot{
+		Users: []telemetry.User{telemetry.ConvertUser(user)},
+	})
+
+	params := createAPIKeyParams{
+		UserID:     user.ID,
+		LoginType:  database.LoginTypePassword,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	}
+	if api.TwoFactorRequired {
+		params.Scope = database.APIKeyScopeTwoFactorEnrollment
+		params.ExpiresAt = database.Now().Add(time.Hour)
+	}
+	cookie, err := api.createAPIKey(ctx, params)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to create API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	http.SetCookie(rw, cookie)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.LoginWithPasswordResponse{
+		SessionToken:                cookie.Value,
+		TwoFactorEnrollmentRequired: params.Scope == database.APIKeyScopeTwoFactorEnrollment,
+	})
+}
+
+// parseEmailToken splits a token sent by email into the ID and the secret
+// of the row it refers to. Tokens use the same format as API keys.
+func parseEmailToken(token string) (id string, secret string, ok bool) {
+	id, secret, ok = strings.Cut(token, "-")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func convertUserInvitation(invitation database.UserInvitation) codersdk.UserInvitation {
+	return codersdk.UserInvitation{
+		ID:             invitation.ID,
+		Email:          invitation.Email,
+		OrganizationID: invitation.OrganizationID,
+		CreatedBy:      invitation.CreatedBy,
+		CreatedAt:      invitation.CreatedAt,
+		ExpiresAt:      invitation.ExpiresAt,
+	}
+}
@@ -0,0 +1,241 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/mailer"
+	"github.com/coder/coder/coderd/mailer/mailertest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestUserInvitations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Accept", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		invitation, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "invited@coder.com", invitation.Email)
+		require.Equal(t, first.UserID, invitation.CreatedBy)
+
+		invitations, err := client.UserInvitations(ctx)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		require.Equal(t, invitation.ID, invitations[0].ID)
+
+		messages := srv.Messages()
+		require.Len(t, messages, 1)
+		require.Equal(t, []string{"invited@coder.com"}, messages[0].To)
+		token := emailToken(t, messages[0])
+
+		// The token authorizes the request, not a session.
+		anonymous := codersdk.New(client.URL)
+		req := codersdk.AcceptUserInvitationRequest{
+			Token:    token,
+			Username: "invited",
+			Password: "SomeSecurePassword!",
+		}
+		login, err := anonymous.AcceptUserInvitation(ctx, req)
+		require.NoError(t, err)
+		anonymous.SetSessionToken(login.SessionToken)
+		user, err := anonymous.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, "invited", user.Username)
+		require.Equal(t, "invited@coder.com", user.Email)
+		require.Equal(t, []uuid.UUID{first.OrganizationID}, user.OrganizationIDs)
+
+		// Invitations can only be used once.
+		req.Username = "invited2"
+		_, err = codersdk.New(client.URL).AcceptUserInvitation(ctx, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		invitations, err = client.UserInvitations(ctx)
+		require.NoError(t, err)
+		require.Empty(t, invitations)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+		token := emailToken(t, srv.Messages()[0])
+
+		// Only one of the requests can use the invitation.
+		errs := make(chan error, 2)
+		for _, username := range []string{"invited", "invited2"} {
+			username := username
+			go func() {
+				_, err := codersdk.New(client.URL).AcceptUserInvitation(ctx, codersdk.AcceptUserInvitationRequest{
+					Token:    token,
+					Username: username,
+					Password: "SomeSecurePassword!",
+				})
+				errs <- err
+			}()
+		}
+		var accepted int
+		for i := 0; i < 2; i++ {
+			if <-errs == nil {
+				accepted++
+			}
+		}
+		require.Equal(t, 1, accepted)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+		token := emailToken(t, srv.Messages()[0])
+
+		for _, invalid := range []string{"invalid", token[:11] + "wrongsecret", token + "x"} {
+			_, err = codersdk.New(client.URL).AcceptUserInvitation(ctx, codersdk.AcceptUserInvitationRequest{
+				Token:    invalid,
+				Username: "invited",
+				Password: "SomeSecurePassword!",
+			})
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		t.Parallel()
+		client, srv := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		invitation, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+		err = client.DeleteUserInvitation(ctx, invitation.ID)
+		require.NoError(t, err)
+
+		_, err = codersdk.New(client.URL).AcceptUserInvitation(ctx, codersdk.AcceptUserInvitationRequest{
+			Token:    emailToken(t, srv.Messages()[0]),
+			Username: "invited",
+			Password: "SomeSecurePassword!",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("MemberForbidden", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, first.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := member.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("ExistingUser", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newMailerClient(t)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          coderdtest.FirstUserParams.Email,
+			OrganizationID: first.OrganizationID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("NoMailer", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateUserInvitation(ctx, codersdk.CreateUserInvitationRequest{
+			Email:          "invited@coder.com",
+			OrganizationID: first.OrganizationID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+func newMailerClient(t *testing.T) (*codersdk.Client, *mailertest.Server) {
+	t.Helper()
+
+	srv := mailertest.New(t)
+	client := coderdtest.New(t, &coderdtest.Options{
+		Mailer: &mailer.SMTP{
+			Addr: srv.Addr,
+			From: "coder@coder.com",
+		},
+	})
+	return client, srv
+}
+
+var emailTokenRegex = regexp.MustCompile(`[0-9a-zA-Z]{10}-[0-9a-zA-Z]{22}`)
+
+// emailToken extracts the token from an invitation or password reset email.
+func emailToken(t *testing.T, msg mailertest.Message) string {
+	t.Helper()
+
+	token := emailTokenRegex.FindString(msg.Body)
+	require.NotEmpty(t, token, "no token in email body: %s", msg.Body)
+	return token
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -572,10 +573,28 @@ func (api *API) putUserStatus(status database.UserStatus) func(rw http.ResponseW
 			}
 		}
 
-		suspendedUser, err := api.Database.UpdateUserStatus(ctx, database.UpdateUserStatusParams{
-			ID:        user.ID,
-			Status:    status,
-			UpdatedAt: database.Now(),
+		var suspendedUser database.User
+		err := api.Database.InTx(func(tx database.Store) error {
+			var err error
+			suspendedUser, err = tx.UpdateUserStatus(ctx, database.UpdateUserStatusParams{
+				ID:        user.ID,
+				Status:    status,
+				UpdatedAt: database.Now(),
+			})
+			if err != nil {
+				return xerrors.Errorf("update user status: %w", err)
+			}
+			if status == database.UserStatusSuspended {
+				// Log the user out everywhere, so they have to log in again
+				// if they are reactivated.
+				err = tx.DeleteSessionsByUserID(ctx, database.DeleteSessionsByUserIDParams{
+					UserID: user.ID,
+				})
+				if err != nil {
+					return xerrors.Errorf("delete sessions: %w", err)
+				}
+			}
+			return nil
 		})
 		if err != nil {
 			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -943,6 +962,12 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Users that don't exist yet may be signing up through the directory.
+	if api.LDAPConfig != nil && (user.ID == uuid.Nil || user.LoginType == database.LoginTypeLDAP) {
+		api.userLDAP(rw, r, loginWithPassword)
+		return
+	}
+
 	// If the user doesn't exist, it will be a default struct.
 	equal, err := userpassword.Compare(string(user.HashedPassword), loginWithPassword.Password)
 	if err != nil {
@@ -975,11 +1000,54 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	twoFactor, err := api.Database.GetUserTwoFactorByUserID(ctx, user.ID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error.",
+		})
+		return
+	}
+
+	params := createAPIKeyParams{
 		UserID:     user.ID,
 		LoginType:  database.LoginTypePassword,
 		RemoteAddr: r.RemoteAddr,
-	})
+		UserAgent:  r.UserAgent(),
+	}
+	switch {
+	case twoFactor.Enabled:
+		if loginWithPassword.TwoFactorCode == "" {
+			httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+				Message: "Two-factor authentication code required.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "two_factor_code",
+					Detail: "Provide a code from your authenticator app or a recovery code.",
+				}},
+			})
+			return
+		}
+		ok, err := api.verifyTwoFactorCode(ctx, twoFactor, loginWithPassword.TwoFactorCode)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+				Message: "Incorrect two-factor authentication code.",
+			})
+			return
+		}
+	case api.TwoFactorRequired:
+		// The user must enroll before they can do anything else, so the
+		// session is restricted and short-lived.
+		params.Scope = database.APIKeyScopeTwoFactorEnrollment
+		params.ExpiresAt = database.Now().Add(time.Hour)
+	}
+
+	cookie, err := api.createAPIKey(ctx, params)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Failed to create API key.",
@@ -991,7 +1059,8 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 	http.SetCookie(rw, cookie)
 
 	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.LoginWithPasswordResponse{
-		SessionToken: cookie.Value,
+		SessionToken:                cookie.Value,
+		TwoFactorEnrollmentRequired: params.Scope == database.APIKeyScopeTwoFactorEnrollment,
 	})
 }
 
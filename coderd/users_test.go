@@ -220,15 +220,24 @@ func TestPostLogin(t *testing.T) {
 
 		memberUser, err := member.User(ctx, codersdk.Me)
 		require.NoError(t, err, "fetch member user")
+		token, err := member.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err, "create member token")
+		tokenClient := codersdk.New(client.URL)
+		tokenClient.SetSessionToken(token.Key)
 
 		_, err = client.UpdateUserStatus(ctx, memberUser.Username, codersdk.UserStatusSuspended)
 		require.NoError(t, err, "suspend member")
 
-		// Test an existing session
+		// Test an existing session, which is deleted on suspension
 		_, err = member.User(ctx, codersdk.Me)
 		var apiErr *codersdk.Error
 		require.ErrorAs(t, err, &apiErr)
 		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		// Test an existing token, which is kept but rejected
+		_, err = tokenClient.User(ctx, codersdk.Me)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
 		require.Contains(t, apiErr.Message, "Contact an admin")
 
 		// Test a new session
@@ -0,0 +1,264 @@
+package coderd
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// workspaceAgentPeers lists the agents of the owner's other workspaces that
+// the authenticated agent may dial through workspace networking.
+func (api *API) workspaceAgentPeers(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	workspace, owner, ok := api.workspaceAgentNetworkingSource(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+
+	workspaces, err := api.Database.GetWorkspaces(ctx, database.GetWorkspacesParams{
+		OwnerID: workspace.OwnerID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspaceIDs := make([]uuid.UUID, 0, len(workspaces))
+	templateIDs := make([]uuid.UUID, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		workspaceIDs = append(workspaceIDs, workspace.ID)
+		templateIDs = append(templateIDs, workspace.TemplateID)
+	}
+	templates, err := api.Database.GetTemplatesWithFilter(ctx, database.GetTemplatesWithFilterParams{
+		IDs: templateIDs,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching templates.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	allowedTemplates := map[uuid.UUID]bool{}
+	for _, template := range templates {
+		allowedTemplates[template.ID] = template.AllowWorkspaceNetworking
+	}
+	builds, err := api.Database.GetLatestWorkspaceBuildsByWorkspaceIDs(ctx, workspaceIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace builds.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Agents are only listed for started workspaces of templates that allow
+	// workspace networking, and that the owner may connect to.
+	workspacesByID := map[uuid.UUID]database.Workspace{}
+	for _, workspace := range workspaces {
+		workspacesByID[workspace.ID] = workspace
+	}
+	workspacesByJobID := map[uuid.UUID]database.Workspace{}
+	jobIDs := make([]uuid.UUID, 0, len(builds))
+	for _, build := range builds {
+		if build.Transition != database.WorkspaceTransitionStart {
+			continue
+		}
+		peer := workspacesByID[build.WorkspaceID]
+		if !allowedTemplates[peer.TemplateID] {
+			continue
+		}
+		if !api.authorizeWorkspaceAgentPeer(ctx, owner, peer) {
+			continue
+		}
+		workspacesByJobID[build.JobID] = peer
+		jobIDs = append(jobIDs, build.JobID)
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobIDs(ctx, jobIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspacesByResourceID := map[uuid.UUID]database.Workspace{}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		workspacesByResourceID[resource.ID] = workspacesByJobID[resource.JobID]
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	peers := make([]codersdk.WorkspaceAgentPeer, 0, len(agents))
+	for _, agent := range agents {
+		if agent.ID == workspaceAgent.ID {
+			continue
+		}
+		peer := workspacesByResourceID[agent.ResourceID]
+		peers = append(peers, codersdk.WorkspaceAgentPeer{
+			WorkspaceID:   peer.ID,
+			WorkspaceName: peer.Name,
+			AgentID:       agent.ID,
+			AgentName:     agent.Name,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].WorkspaceName != peers[j].WorkspaceName {
+			return peers[i].WorkspaceName < peers[j].WorkspaceName
+		}
+		return peers[i].AgentName < peers[j].AgentName
+	})
+
+	httpapi.Write(ctx, rw, http.StatusOK, peers)
+}
+
+// workspaceAgentPeerCoordinate coordinates a connection from the
+// authenticated agent to another agent, as if the agent were a client.
+func (api *API) workspaceAgentPeerCoordinate(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	peerAgent := httpmw.WorkspaceAgentParam(r)
+	peer := httpmw.WorkspaceParam(r)
+	_, owner, ok := api.workspaceAgentNetworkingSource(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+	if peerAgent.ID == workspaceAgent.ID || !api.authorizeWorkspaceAgentPeer(ctx, owner, peer) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	template, err := api.Database.GetTemplateByID(ctx, peer.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !template.AllowWorkspaceNetworking {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "The template of the workspace doesn't allow workspace networking.",
+		})
+		return
+	}
+	// This is used by Enterprise code to control the functionality of this route.
+	override := api.WorkspaceClientCoordinateOverride.Load()
+	if override != nil {
+		overrideFunc := *override
+		if overrideFunc != nil && overrideFunc(rw) {
+			return
+		}
+	}
+
+	api.websocketWaitMutex.Lock()
+	api.websocketWaitGroup.Add(1)
+	api.websocketWaitMutex.Unlock()
+	defer api.websocketWaitGroup.Done()
+
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	go httpapi.Heartbeat(ctx, conn)
+
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	err = (*api.TailnetCoordinator.Load()).ServeClient(websocket.NetConn(ctx, conn, websocket.MessageBinary), uuid.New(), peerAgent.ID)
+	if err != nil {
+		_ = conn.Close(websocket.StatusInternalError, err.Error())
+		return
+	}
+}
+
+// workspaceAgentNetworkingSource returns the workspace of an agent and the
+// roles of its owner, if the template of the workspace allows workspace
+// networking. Otherwise an error is written and false is returned.
+func (api *API) workspaceAgentNetworkingSource(rw http.ResponseWriter, r *http.Request, workspaceAgent database.WorkspaceAgent) (database.Workspace, database.GetAuthorizationUserRolesRow, bool) {
+	ctx := r.Context()
+	workspace, err := api.workspaceByAgent(ctx, workspaceAgent)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return database.Workspace{}, database.GetAuthorizationUserRolesRow{}, false
+	}
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return database.Workspace{}, database.GetAuthorizationUserRolesRow{}, false
+	}
+	if !template.AllowWorkspaceNetworking {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "The template of this workspace doesn't allow workspace networking.",
+		})
+		return database.Workspace{}, database.GetAuthorizationUserRolesRow{}, false
+	}
+	owner, err := api.Database.GetAuthorizationUserRoles(ctx, workspace.OwnerID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace owner roles.",
+			Detail:  err.Error(),
+		})
+		return database.Workspace{}, database.GetAuthorizationUserRolesRow{}, false
+	}
+	if owner.Status != database.UserStatusActive {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "The owner of this workspace is not active.",
+		})
+		return database.Workspace{}, database.GetAuthorizationUserRolesRow{}, false
+	}
+	return workspace, owner, true
+}
+
+// authorizeWorkspaceAgentPeer returns whether the owner of a workspace may
+// connect to another workspace. Agents act on behalf of their owner, so they
+// may only dial what the owner could dial themselves.
+func (api *API) authorizeWorkspaceAgentPeer(ctx context.Context, owner database.GetAuthorizationUserRolesRow, peer database.Workspace) bool {
+	err := api.Authorizer.ByRoleName(ctx, owner.ID.String(), owner.Roles, rbac.ScopeAll, owner.Groups, rbac.ActionCreate, peer.ExecutionRBAC())
+	return err == nil
+}
+
+// workspaceByAgent returns the workspace of the build an agent belongs to.
+func (api *API) workspaceByAgent(ctx context.Context, workspaceAgent database.WorkspaceAgent) (database.Workspace, error) {
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace resource: %w", err)
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace build: %w", err)
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace: %w", err)
+	}
+	return workspace, nil
+}
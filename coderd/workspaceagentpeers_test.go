@@ -0,0 +1,133 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceAgentPeers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		_, agentClient, _ := createPeerWorkspace(t, client, client, user.OrganizationID, false)
+		_, _, peerID := createPeerWorkspace(t, client, client, user.OrganizationID, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := agentClient.WorkspaceAgentPeers(ctx)
+		requirePeerStatusCode(t, err, http.StatusForbidden)
+		_, err = agentClient.DialWorkspaceAgentPeer(ctx, peerID)
+		requirePeerStatusCode(t, err, http.StatusForbidden)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		_, agentClient, agentID := createPeerWorkspace(t, client, client, user.OrganizationID, true)
+		peer, _, peerID := createPeerWorkspace(t, client, client, user.OrganizationID, true)
+		// Workspaces of templates that don't allow networking, and of other
+		// users, aren't listed.
+		_, _, disabledID := createPeerWorkspace(t, client, client, user.OrganizationID, false)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		createPeerWorkspace(t, client, member, user.OrganizationID, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		peers, err := agentClient.WorkspaceAgentPeers(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceAgentPeer{{
+			WorkspaceID:   peer.ID,
+			WorkspaceName: peer.Name,
+			AgentID:       peerID,
+			AgentName:     "dev",
+		}}, peers)
+
+		conn, err := agentClient.DialWorkspaceAgentPeer(ctx, peerID)
+		require.NoError(t, err)
+		_ = conn.Close()
+		_, err = agentClient.DialWorkspaceAgentPeer(ctx, agentID)
+		requirePeerStatusCode(t, err, http.StatusNotFound)
+		_, err = agentClient.DialWorkspaceAgentPeer(ctx, disabledID)
+		requirePeerStatusCode(t, err, http.StatusForbidden)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, agentClient, _ := createPeerWorkspace(t, client, member, user.OrganizationID, true)
+		_, _, peerID := createPeerWorkspace(t, client, client, user.OrganizationID, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Members can't connect to the workspaces of the owner, so neither can
+		// their agents.
+		_, err := agentClient.DialWorkspaceAgentPeer(ctx, peerID)
+		requirePeerStatusCode(t, err, http.StatusNotFound)
+	})
+}
+
+// createPeerWorkspace creates a workspace with a single agent named "dev"
+// for the user of client, and returns a client authenticated as the agent.
+func createPeerWorkspace(t *testing.T, adminClient, client *codersdk.Client, organizationID uuid.UUID, allowNetworking bool) (codersdk.Workspace, *codersdk.Client, uuid.UUID) {
+	t.Helper()
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, adminClient, organizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "dev",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, adminClient, organizationID, version.ID, func(request *codersdk.CreateTemplateRequest) {
+		request.AllowWorkspaceNetworking = allowNetworking
+	})
+	coderdtest.AwaitTemplateVersionJob(t, adminClient, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, organizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	workspace, err := client.Workspace(context.Background(), workspace.ID)
+	require.NoError(t, err)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	return workspace, agentClient, workspace.LatestBuild.Resources[0].Agents[0].ID
+}
+
+func requirePeerStatusCode(t *testing.T, err error, statusCode int) {
+	t.Helper()
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, statusCode, apiErr.StatusCode())
+}
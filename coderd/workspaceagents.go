@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -52,7 +53,7 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), (*api.TailnetCoordinator.Load()).Node(workspaceAgent.ID), workspaceAgent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -67,7 +68,7 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), (*api.TailnetCoordinator.Load()).Node(workspaceAgent.ID), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -115,6 +116,14 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	template, err := api.Database.GetTemplateByID(r.Context(), workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	vscodeProxyURI := strings.ReplaceAll(api.AppHostname, "*",
 		fmt.Sprintf("%s://{{port}}--%s--%s--%s",
@@ -125,20 +134,23 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		))
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentMetadata{
-		Apps:                 convertApps(dbApps),
-		DERPMap:              api.DERPMap,
-		GitAuthConfigs:       len(api.GitAuthConfigs),
-		EnvironmentVariables: apiAgent.EnvironmentVariables,
-		StartupScript:        apiAgent.StartupScript,
-		Directory:            apiAgent.Directory,
-		VSCodePortProxyURI:   vscodeProxyURI,
+		Apps:                     convertApps(dbApps),
+		DERPMap:                  api.CurrentDERPMap(),
+		GitAuthConfigs:           len(api.GitAuthConfigs),
+		EnvironmentVariables:     apiAgent.EnvironmentVariables,
+		StartupScript:            apiAgent.StartupScript,
+		Directory:                apiAgent.Directory,
+		VSCodePortProxyURI:       vscodeProxyURI,
+		DisableDirectConnections: api.DERPBlockDirect,
+		AgentID:                  workspaceAgent.ID,
+		WorkspaceNetworking:      template.AllowWorkspaceNetworking,
 	})
 }
 
 func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), (*api.TailnetCoordinator.Load()).Node(workspaceAgent.ID), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -192,7 +204,7 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), (*api.TailnetCoordinator.Load()).Node(workspaceAgent.ID), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -269,7 +281,7 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), (*api.TailnetCoordinator.Load()).Node(workspaceAgent.ID), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -359,15 +371,150 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 	httpapi.Write(ctx, rw, http.StatusOK, portsResponse)
 }
 
-func (api *API) dialWorkspaceAgentTailnet(r *http.Request, agentID uuid.UUID) (*codersdk.AgentConn, error) {
+// agentTailnet is the tailnet connection shared by the connections coderd
+// makes to workspace agents, so they share a single magicsock and DERP
+// connection. Every agent has codersdk.TailnetIP, so agents are dialed at
+// codersdk.WorkspaceAgentIP instead, like cli/vpn.go does.
+type agentTailnet struct {
+	mutex    sync.Mutex
+	conn     *tailnet.Conn
+	node     *tailnet.Node
+	sessions map[uuid.UUID]*agentTailnetSession
+}
+
+// agentTailnetSession coordinates the shared tailnet connection with an
+// agent.
+type agentTailnetSession struct {
+	// sendMutex keeps the latest node from being overwritten by an older
+	// one sent concurrently.
+	sendMutex sync.Mutex
+	sendNode  func(node *tailnet.Node)
+}
+
+// ensureAgentTailnet returns the shared tailnet connection to agents, and
+// creates it on first use.
+func (api *API) ensureAgentTailnet() (*tailnet.Conn, error) {
+	api.agentTailnet.mutex.Lock()
+	defer api.agentTailnet.mutex.Unlock()
+	if api.agentTailnet.conn != nil {
+		return api.agentTailnet.conn, nil
+	}
+
+	derpMapUpdates, unsubscribeDERPMap := api.subscribeDERPMap()
+	conn, err := tailnet.NewConn(&tailnet.Options{
+		Addresses:      []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
+		DERPMap:        proxyEmbeddedDERP(api.CurrentDERPMap()),
+		Logger:         api.Logger.Named("tailnet"),
+		BlockEndpoints: api.DERPBlockDirect,
+	})
+	if err != nil {
+		unsubscribeDERPMap()
+		return nil, xerrors.Errorf("create tailnet conn: %w", err)
+	}
+	go func() {
+		defer unsubscribeDERPMap()
+		for {
+			select {
+			case <-conn.Closed():
+				return
+			case <-derpMapUpdates:
+				conn.SetDERPMap(proxyEmbeddedDERP(api.CurrentDERPMap()))
+			}
+		}
+	}()
+	conn.SetNodeCallback(api.setAgentTailnetNode)
+	api.agentTailnet.conn = conn
+	api.agentTailnet.sessions = make(map[uuid.UUID]*agentTailnetSession)
+	return conn, nil
+}
+
+// setAgentTailnetNode sends the node of the shared tailnet connection to
+// every agent it's connected to.
+func (api *API) setAgentTailnetNode(node *tailnet.Node) {
+	api.agentTailnet.mutex.Lock()
+	api.agentTailnet.node = node
+	sessions := make([]*agentTailnetSession, 0, len(api.agentTailnet.sessions))
+	for _, session := range api.agentTailnet.sessions {
+		sessions = append(sessions, session)
+	}
+	api.agentTailnet.mutex.Unlock()
+
+	for _, session := range sessions {
+		api.sendAgentTailnetNode(session)
+	}
+}
+
+// sendAgentTailnetNode sends the latest node of the shared tailnet
+// connection to an agent, if it's known yet.
+func (api *API) sendAgentTailnetNode(session *agentTailnetSession) {
+	session.sendMutex.Lock()
+	defer session.sendMutex.Unlock()
+	api.agentTailnet.mutex.Lock()
+	node := api.agentTailnet.node
+	api.agentTailnet.mutex.Unlock()
+	if node != nil {
+		session.sendNode(node)
+	}
+}
+
+// closeAgentTailnet closes the shared tailnet connection. Connections to
+// agents must be closed before.
+func (api *API) closeAgentTailnet() error {
+	api.agentTailnet.mutex.Lock()
+	defer api.agentTailnet.mutex.Unlock()
+	if api.agentTailnet.conn == nil {
+		return nil
+	}
+	return api.agentTailnet.conn.Close()
+}
+
+// dialWorkspaceAgentTailnet connects to an agent over the shared tailnet
+// connection. It coordinates with the agent until the returned connection
+// is closed, which closing the shared connection doesn't do.
+func (api *API) dialWorkspaceAgentTailnet(_ *http.Request, agentID uuid.UUID) (*codersdk.AgentConn, error) {
+	conn, err := api.ensureAgentTailnet()
+	if err != nil {
+		return nil, err
+	}
+
 	clientConn, serverConn := net.Pipe()
+	sendNode, _ := tailnet.ServeCoordinator(clientConn, func(nodes []*tailnet.Node) error {
+		return conn.UpdateNodes(codersdk.WithoutTailnetIP(nodes))
+	})
+	sessionID := uuid.New()
+	session := &agentTailnetSession{
+		sendNode: sendNode,
+	}
+	api.agentTailnet.mutex.Lock()
+	api.agentTailnet.sessions[sessionID] = session
+	api.agentTailnet.mutex.Unlock()
+	agentConn := &codersdk.AgentConn{
+		Conn:    conn,
+		AgentIP: codersdk.WorkspaceAgentIP(agentID),
+		Shared:  true,
+		CloseFunc: func() {
+			api.agentTailnet.mutex.Lock()
+			delete(api.agentTailnet.sessions, sessionID)
+			api.agentTailnet.mutex.Unlock()
+			_ = clientConn.Close()
+			_ = serverConn.Close()
+		},
+	}
 	go func() {
-		<-r.Context().Done()
-		_ = clientConn.Close()
-		_ = serverConn.Close()
+		err := (*api.TailnetCoordinator.Load()).ServeClient(serverConn, sessionID, agentID)
+		if err != nil {
+			api.Logger.Warn(context.Background(), "tailnet coordinator client error", slog.Error(err))
+		}
+		_ = agentConn.Close()
 	}()
+	go api.sendAgentTailnetNode(session)
+	return agentConn, nil
+}
 
-	derpMap := api.DERPMap.Clone()
+// proxyEmbeddedDERP returns a copy of the DERP map with nodes that reach the
+// embedded relays of the DERP map through localhost.
+func proxyEmbeddedDERP(derpMap *tailcfg.DERPMap) *tailcfg.DERPMap {
+	derpMap = derpMap.Clone()
 	for _, region := range derpMap.Regions {
 		if !region.EmbeddedRelay {
 			continue
@@ -401,30 +548,7 @@ func (api *API) dialWorkspaceAgentTailnet(r *http.Request, agentID uuid.UUID) (*
 		cloned.ForceHTTP = true
 		region.Nodes = append(region.Nodes, cloned)
 	}
-
-	conn, err := tailnet.NewConn(&tailnet.Options{
-		Addresses: []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
-		DERPMap:   derpMap,
-		Logger:    api.Logger.Named("tailnet"),
-	})
-	if err != nil {
-		return nil, xerrors.Errorf("create tailnet conn: %w", err)
-	}
-
-	sendNodes, _ := tailnet.ServeCoordinator(clientConn, func(node []*tailnet.Node) error {
-		return conn.UpdateNodes(node)
-	})
-	conn.SetNodeCallback(sendNodes)
-	go func() {
-		err := (*api.TailnetCoordinator.Load()).ServeClient(serverConn, uuid.New(), agentID)
-		if err != nil {
-			api.Logger.Warn(r.Context(), "tailnet coordinator client error", slog.Error(err))
-			_ = conn.Close()
-		}
-	}()
-	return &codersdk.AgentConn{
-		Conn: conn,
-	}, nil
+	return derpMap
 }
 
 func (api *API) workspaceAgentConnection(rw http.ResponseWriter, r *http.Request) {
@@ -435,7 +559,8 @@ func (api *API) workspaceAgentConnection(rw http.ResponseWriter, r *http.Request
 		return
 	}
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentConnectionInfo{
-		DERPMap: api.DERPMap,
+		DERPMap:                  api.CurrentDERPMap(),
+		DisableDirectConnections: api.DERPBlockDirect,
 	})
 }
 
@@ -660,7 +785,16 @@ func convertApps(dbApps []database.WorkspaceApp) []codersdk.WorkspaceApp {
 	return apps
 }
 
-func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordinator, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, agentInactiveDisconnectTimeout time.Duration) (codersdk.WorkspaceAgent, error) {
+// agentNodes returns the tailnet nodes of agents, read in one batch.
+func (api *API) agentNodes(agents []database.WorkspaceAgent) map[uuid.UUID]*tailnet.Node {
+	ids := make([]uuid.UUID, 0, len(agents))
+	for _, agent := range agents {
+		ids = append(ids, agent.ID)
+	}
+	return (*api.TailnetCoordinator.Load()).Nodes(ids)
+}
+
+func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, node *tailnet.Node, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, agentInactiveDisconnectTimeout time.Duration) (codersdk.WorkspaceAgent, error) {
 	var envs map[string]string
 	if dbAgent.EnvironmentVariables.Valid {
 		err := json.Unmarshal(dbAgent.EnvironmentVariables.RawMessage, &envs)
@@ -685,7 +819,6 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 		ConnectionTimeoutSeconds: dbAgent.ConnectionTimeoutSeconds,
 		TroubleshootingURL:       dbAgent.TroubleshootingURL,
 	}
-	node := coordinator.Node(dbAgent.ID)
 	if node != nil {
 		workspaceAgent.DERPLatency = map[string]codersdk.DERPRegion{}
 		for rawRegion, latency := range node.DERPLatency {
@@ -854,8 +987,6 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 		// We will see duplicate reports when on idle connections
 		// (e.g. web terminal left open) or when there are no connections at
 		// all.
-		// We also don't want to update the workspace last used at on duplicate
-		// reports.
 		updateDB := !reflect.DeepEqual(lastReport, rep)
 
 		api.Logger.Debug(ctx, "read stats report",
@@ -867,9 +998,30 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 			slog.F("payload", rep),
 		)
 
-		if updateDB {
+		// Activity is measured by the sessions open on the agent rather
+		// than by traffic, so a long-running session keeps the workspace
+		// running even while idle. Sessions opened and closed since the
+		// last report count as well.
+		active := rep.SessionCount() > 0 || rep.NumConns > lastReport.NumConns
+		if active {
 			go activityBumpWorkspace(api.Logger.Named("activity_bump"), api.Database, workspace)
+		}
 
+		// Idle sessions report the same stats over and over, but the
+		// workspace is still in use, and mustn't be considered dormant.
+		if active || updateDB {
+			err = api.Database.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
+				ID:         build.WorkspaceID,
+				LastUsedAt: database.Now(),
+			})
+			if err != nil {
+				api.Logger.Debug(ctx, "update workspace last used at", slog.Error(err))
+				conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("update workspace last used at: %s", err))
+				return
+			}
+		}
+
+		if updateDB {
 			lastReport = rep
 
 			_, err = api.Database.InsertAgentStat(ctx, database.InsertAgentStatParams{
@@ -886,16 +1038,6 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 				conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("insert agent stat: %s", err))
 				return
 			}
-
-			err = api.Database.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
-				ID:         build.WorkspaceID,
-				LastUsedAt: database.Now(),
-			})
-			if err != nil {
-				api.Logger.Debug(ctx, "update workspace last used at", slog.Error(err))
-				conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("update workspace last used at: %s", err))
-				return
-			}
 		}
 	}
 }
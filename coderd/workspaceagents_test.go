@@ -321,6 +321,76 @@ func TestWorkspaceAgentTailnet(t *testing.T) {
 	require.Equal(t, "test", strings.TrimSpace(string(output)))
 }
 
+func TestWorkspaceAgentBlockDirect(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+		DERPBlockDirect:          true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, metadata.DisableDirectConnections)
+
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer agentCloser.Close()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	conn, err := client.DialWorkspaceAgent(ctx, resources[0].Agents[0].ID, &codersdk.DialWorkspaceAgentOptions{
+		Logger: slogtest.Make(t, nil).Named("client").Leveled(slog.LevelDebug),
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	require.True(t, conn.BlockEndpoints())
+	require.Eventually(t, func() bool {
+		// Pings sent before the agent's node arrived are never answered.
+		pingCtx, cancel := context.WithTimeout(ctx, testutil.IntervalMedium)
+		defer cancel()
+		_, err := conn.Path(pingCtx)
+		return err == nil
+	}, testutil.WaitLong, testutil.IntervalFast)
+	for i := 0; i < 3; i++ {
+		path, err := conn.Path(ctx)
+		require.NoError(t, err)
+		require.False(t, path.Direct())
+		require.NotZero(t, path.DERPRegionID)
+	}
+}
+
 func TestWorkspaceAgentPTY(t *testing.T) {
 	t.Parallel()
 	if runtime.GOOS == "windows" {
@@ -418,6 +488,90 @@ func TestWorkspaceAgentPTY(t *testing.T) {
 	expectLine(matchEchoOutput)
 }
 
+func TestWorkspaceAgentPTYSharedTailnet(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authTokens := []string{uuid.NewString(), uuid.NewString()}
+	agents := make([]*proto.Agent, 0, len(authTokens))
+	for i, authToken := range authTokens {
+		agents = append(agents, &proto.Agent{
+			Id:   uuid.NewString(),
+			Name: fmt.Sprintf("agent%d", i),
+			Env: map[string]string{
+				"AGENT_NAME": fmt.Sprintf("agent%d", i),
+			},
+			Auth: &proto.Agent_Token{
+				Token: authToken,
+			},
+		})
+	}
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name:   "example",
+						Type:   "aws_instance",
+						Agents: agents,
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	for _, authToken := range authTokens {
+		agentClient := codersdk.New(client.URL)
+		agentClient.SetSessionToken(authToken)
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+		})
+		defer func() {
+			_ = agentCloser.Close()
+		}()
+	}
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Both agents are dialed through the same tailnet connection,
+	// so each command must be routed to the agent it was sent to.
+	for _, workspaceAgent := range resources[0].Agents {
+		conn, err := client.WorkspaceAgentReconnectingPTY(ctx, workspaceAgent.ID, uuid.New(), 80, 80, "/bin/bash")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+			Data: "echo name-$AGENT_NAME\r\n",
+		})
+		require.NoError(t, err)
+		_, err = conn.Write(data)
+		require.NoError(t, err)
+
+		bufRead := bufio.NewReader(conn)
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			if strings.Contains(line, "name-agent") && !strings.Contains(line, "echo") {
+				require.Contains(t, line, "name-"+workspaceAgent.Name)
+				break
+			}
+		}
+	}
+}
+
 func TestWorkspaceAgentListeningPorts(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,715 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// workspaceBatchRetention is how long completed batches are kept for
+	// their initiators to check on.
+	workspaceBatchRetention = 24 * time.Hour
+	// workspaceBatchPollInterval is how often a batch checks whether the
+	// builds it started have completed.
+	workspaceBatchPollInterval = time.Second
+)
+
+// workspaceBatches runs the batches claimed by this replica. Batches and
+// their progress are stored in the database, so any replica can report on
+// them, and batches abandoned by a replica that stopped are resumed by
+// another.
+type workspaceBatches struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWorkspaceBatches() *workspaceBatches {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workspaceBatches{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Close cancels running batches and waits for them to return. Cancelled
+// batches are left incomplete for another replica to resume.
+func (b *workspaceBatches) Close() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+func convertWorkspaceBatch(batch database.WorkspaceBatch, items []database.WorkspaceBatchItem) codersdk.WorkspaceBatch {
+	converted := codersdk.WorkspaceBatch{
+		ID:          batch.ID,
+		CreatedAt:   batch.CreatedAt,
+		InitiatorID: batch.InitiatorID,
+		Action:      codersdk.WorkspaceBatchAction(batch.Action),
+		Query:       batch.Query,
+		Concurrency: int(batch.Concurrency),
+		Items:       make([]codersdk.WorkspaceBatchItem, 0, len(items)),
+	}
+	if batch.CompletedAt.Valid {
+		converted.CompletedAt = &batch.CompletedAt.Time
+	}
+	for _, item := range items {
+		convertedItem := codersdk.WorkspaceBatchItem{
+			WorkspaceID:   item.WorkspaceID,
+			WorkspaceName: item.WorkspaceName,
+			OwnerName:     item.OwnerName,
+			Status:        codersdk.WorkspaceBatchItemStatus(item.Status),
+			Error:         item.Error,
+		}
+		if item.BuildID.Valid {
+			buildID := item.BuildID.UUID
+			convertedItem.BuildID = &buildID
+		}
+		converted.Items = append(converted.Items, convertedItem)
+	}
+	return converted
+}
+
+// workspaceBatchTransition returns the transition a batch action builds
+// workspaces with. Updates keep the transition of the latest build, so this
+// is only used for authorization.
+func workspaceBatchTransition(action codersdk.WorkspaceBatchAction) codersdk.WorkspaceTransition {
+	switch action {
+	case codersdk.WorkspaceBatchActionStop:
+		return codersdk.WorkspaceTransitionStop
+	case codersdk.WorkspaceBatchActionDelete:
+		return codersdk.WorkspaceTransitionDelete
+	default:
+		return codersdk.WorkspaceTransitionStart
+	}
+}
+
+// workspaceBatchSkipReason returns why an action doesn't apply to a
+// workspace, or an empty string if it does.
+func workspaceBatchSkipReason(action codersdk.WorkspaceBatchAction, workspace codersdk.Workspace) string {
+	build := workspace.LatestBuild
+	switch build.Job.Status {
+	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning, codersdk.ProvisionerJobCanceling:
+		return "A build is already in progress."
+	}
+	if workspace.DormantAt != nil && action != codersdk.WorkspaceBatchActionDelete {
+		return "The workspace is dormant."
+	}
+	succeeded := build.Job.Status == codersdk.ProvisionerJobSucceeded
+	switch action {
+	case codersdk.WorkspaceBatchActionStart:
+		if succeeded && build.Transition == codersdk.WorkspaceTransitionStart {
+			return "The workspace is already started."
+		}
+	case codersdk.WorkspaceBatchActionStop:
+		if succeeded && build.Transition == codersdk.WorkspaceTransitionStop {
+			return "The workspace is already stopped."
+		}
+	case codersdk.WorkspaceBatchActionUpdate:
+		if !workspace.Outdated {
+			return "The workspace is already on the active template version."
+		}
+	}
+	return ""
+}
+
+func (api *API) postWorkspaceBatch(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	apiKey := httpmw.APIKey(r)
+	var req codersdk.CreateWorkspaceBatchRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	if req.Concurrency == 0 {
+		req.Concurrency = codersdk.DefaultWorkspaceBatchConcurrency
+	}
+	if req.Concurrency < 0 || req.Concurrency > codersdk.MaxWorkspaceBatchConcurrency {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid concurrency.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "concurrency",
+				Detail: fmt.Sprintf("Must be between 1 and %d.", codersdk.MaxWorkspaceBatchConcurrency),
+			}},
+		})
+		return
+	}
+
+	filter, errs := workspaceSearchQuery(req.Query, codersdk.Pagination{})
+	if len(errs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid workspace search query.",
+			Validations: errs,
+		})
+		return
+	}
+	if filter.OwnerUsername == "me" {
+		filter.OwnerID = apiKey.UserID
+		filter.OwnerUsername = ""
+	}
+
+	sqlFilter, err := api.HTTPAuth.AuthorizeSQLFilter(r, rbac.ActionRead, rbac.ResourceWorkspace.Type)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error preparing sql filter.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspaces, err := api.Database.GetAuthorizedWorkspaces(ctx, filter, sqlFilter)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Workspaces the user can read but not transition are left out, as if
+	// they didn't match.
+	transition := workspaceBatchTransition(req.Action)
+	action, _ := workspaceTransitionAction(transition)
+	workspaces, err = AuthorizeFilter(api.HTTPAuth, r, action, workspaces)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error authorizing workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	data, err := api.workspaceData(ctx, workspaces)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	apiWorkspaces, err := convertWorkspaces(workspaces, data)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	batch := &codersdk.WorkspaceBatch{
+		ID:          uuid.New(),
+		CreatedAt:   database.Now(),
+		InitiatorID: apiKey.UserID,
+		Action:      req.Action,
+		Query:       req.Query,
+		Concurrency: req.Concurrency,
+		DryRun:      req.DryRun,
+		Items:       make([]codersdk.WorkspaceBatchItem, 0, len(apiWorkspaces)),
+	}
+	for _, workspace := range apiWorkspaces {
+		item := codersdk.WorkspaceBatchItem{
+			WorkspaceID:   workspace.ID,
+			WorkspaceName: workspace.Name,
+			OwnerName:     workspace.OwnerName,
+			Status:        codersdk.WorkspaceBatchItemStatusPending,
+		}
+		if reason := workspaceBatchSkipReason(req.Action, workspace); reason != "" {
+			item.Status = codersdk.WorkspaceBatchItemStatusSkipped
+			item.Error = reason
+		}
+		batch.Items = append(batch.Items, item)
+	}
+
+	if req.DryRun {
+		httpapi.Write(ctx, rw, http.StatusOK, batch)
+		return
+	}
+
+	var (
+		dbBatch database.WorkspaceBatch
+		dbItems []database.WorkspaceBatchItem
+	)
+	err = api.Database.InTx(func(tx database.Store) error {
+		var err error
+		dbBatch, err = tx.InsertWorkspaceBatch(ctx, database.InsertWorkspaceBatchParams{
+			ID:          batch.ID,
+			CreatedAt:   batch.CreatedAt,
+			InitiatorID: batch.InitiatorID,
+			Action:      string(batch.Action),
+			Query:       batch.Query,
+			Concurrency: int32(batch.Concurrency),
+			HeartbeatAt: batch.CreatedAt,
+			Scope:       apiKey.Scope,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert workspace batch: %w", err)
+		}
+		dbItems = make([]database.WorkspaceBatchItem, 0, len(batch.Items))
+		for i, item := range batch.Items {
+			dbItem, err := tx.InsertWorkspaceBatchItem(ctx, database.InsertWorkspaceBatchItemParams{
+				BatchID:       batch.ID,
+				WorkspaceID:   item.WorkspaceID,
+				Position:      int32(i),
+				WorkspaceName: item.WorkspaceName,
+				OwnerName:     item.OwnerName,
+				Status:        string(item.Status),
+				Error:         item.Error,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert workspace batch item: %w", err)
+			}
+			dbItems = append(dbItems, dbItem)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting workspace batch.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// The builds outlive the request, so they're authorized and audited on
+	// behalf of the request that started them.
+	roles := httpmw.UserAuthorization(r)
+	auditParams := batchAuditRequest{
+		userID:    apiKey.UserID,
+		requestID: httpmw.RequestID(r),
+		ip:        r.RemoteAddr,
+		userAgent: r.UserAgent(),
+	}
+	api.workspaceBatches.wg.Add(1)
+	go func() {
+		defer api.workspaceBatches.wg.Done()
+		api.runWorkspaceBatch(api.workspaceBatches.ctx, dbBatch, roles, auditParams)
+	}()
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceBatch(dbBatch, dbItems))
+}
+
+func (api *API) workspaceBatch(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	apiKey := httpmw.APIKey(r)
+
+	batchID, err := uuid.Parse(chi.URLParam(r, "batch"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid batch ID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	batch, err := api.Database.GetWorkspaceBatchByID(ctx, batchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace batch.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Batches are only visible to the users that started them.
+	if batch.InitiatorID != apiKey.UserID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	items, err := api.Database.GetWorkspaceBatchItemsByBatchID(ctx, batch.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace batch items.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceBatch(batch, items))
+}
+
+// batchAuditRequest is the request a batch was started by.
+type batchAuditRequest struct {
+	userID    uuid.UUID
+	requestID uuid.UUID
+	ip        string
+	userAgent string
+}
+
+// resumeWorkspaceBatches periodically claims batches whose replica stopped
+// heartbeating and runs them to completion. It also deletes batches that
+// completed long ago.
+func (api *API) resumeWorkspaceBatches(ctx context.Context) {
+	logger := api.Logger.Named("workspace_batches")
+	ticker := time.NewTicker(api.WorkspaceBatchHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := database.Now()
+		err := api.Database.DeleteOldWorkspaceBatches(ctx, now.Add(-workspaceBatchRetention))
+		if err != nil && ctx.Err() == nil {
+			logger.Warn(ctx, "delete old workspace batches", slog.Error(err))
+		}
+		for {
+			batch, err := api.Database.AcquireStaleWorkspaceBatch(ctx, database.AcquireStaleWorkspaceBatchParams{
+				Now:         now,
+				StaleBefore: now.Add(-3 * api.WorkspaceBatchHeartbeatInterval),
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Warn(ctx, "acquire stale workspace batch", slog.Error(err))
+				}
+				break
+			}
+			err = api.resumeWorkspaceBatch(ctx, batch)
+			if err != nil {
+				logger.Warn(ctx, "resume workspace batch", slog.F("batch_id", batch.ID), slog.Error(err))
+			}
+		}
+	}
+}
+
+// resumeWorkspaceBatch runs a batch claimed from another replica with the
+// current roles of its initiator, limited to the scope of the API key that
+// started it.
+func (api *API) resumeWorkspaceBatch(ctx context.Context, batch database.WorkspaceBatch) error {
+	api.Logger.Info(ctx, "resuming workspace batch", slog.F("batch_id", batch.ID))
+	user, err := api.Database.GetAuthorizationUserRoles(ctx, batch.InitiatorID)
+	if err != nil {
+		return xerrors.Errorf("get initiator roles: %w", err)
+	}
+	roles := httpmw.Authorization{
+		ID:       user.ID,
+		Username: user.Username,
+		Roles:    user.Roles,
+		Groups:   user.Groups,
+		Scope:    batch.Scope,
+	}
+	// Suspended users can't build workspaces, so the remaining builds fail
+	// authorization like they would if the user retried them.
+	if user.Status == database.UserStatusSuspended {
+		roles.Roles = []string{}
+		roles.Groups = []string{}
+	}
+	auditParams := batchAuditRequest{
+		userID:    batch.InitiatorID,
+		requestID: uuid.New(),
+	}
+	api.workspaceBatches.wg.Add(1)
+	go func() {
+		defer api.workspaceBatches.wg.Done()
+		api.runWorkspaceBatch(ctx, batch, roles, auditParams)
+	}()
+	return nil
+}
+
+// runWorkspaceBatch builds the pending workspaces of a batch, at most
+// batch.Concurrency at a time, and waits for builds a previous run started.
+// The batch is heartbeated while it runs, and is left incomplete if ctx is
+// cancelled so another replica can resume it.
+func (api *API) runWorkspaceBatch(ctx context.Context, batch database.WorkspaceBatch, roles httpmw.Authorization, req batchAuditRequest) {
+	logger := api.Logger.With(slog.F("batch_id", batch.ID))
+	authorize := func(action rbac.Action, object rbac.Objecter) bool {
+		err := api.HTTPAuth.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope.ToRBAC(), roles.Groups, action, object.RBACObject())
+		return err == nil
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(api.WorkspaceBatchHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := api.Database.UpdateWorkspaceBatchHeartbeat(heartbeatCtx, database.UpdateWorkspaceBatchHeartbeatParams{
+				ID:          batch.ID,
+				HeartbeatAt: database.Now(),
+			})
+			if err != nil && heartbeatCtx.Err() == nil {
+				logger.Warn(heartbeatCtx, "heartbeat workspace batch", slog.Error(err))
+			}
+		}
+	}()
+	defer func() {
+		stopHeartbeat()
+		<-heartbeatDone
+	}()
+
+	items, err := api.Database.GetWorkspaceBatchItemsByBatchID(ctx, batch.ID)
+	if err != nil {
+		logger.Warn(ctx, "get workspace batch items", slog.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, batch.Concurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		switch codersdk.WorkspaceBatchItemStatus(item.Status) {
+		case codersdk.WorkspaceBatchItemStatusPending, codersdk.WorkspaceBatchItemStatusBuilding:
+		default:
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(item database.WorkspaceBatchItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			buildID, err := api.runWorkspaceBatchItem(ctx, batch, item, authorize, req)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			logger.Warn(ctx, "build workspace in batch", slog.F("workspace_id", item.WorkspaceID), slog.Error(err))
+			err = api.Database.UpdateWorkspaceBatchItem(ctx, database.UpdateWorkspaceBatchItemParams{
+				BatchID:     batch.ID,
+				WorkspaceID: item.WorkspaceID,
+				Status:      string(codersdk.WorkspaceBatchItemStatusFailed),
+				BuildID:     buildID,
+				Error:       err.Error(),
+			})
+			if err != nil {
+				logger.Warn(ctx, "update workspace batch item", slog.F("workspace_id", item.WorkspaceID), slog.Error(err))
+			}
+		}(item)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	err = api.Database.UpdateWorkspaceBatchCompletedAt(ctx, database.UpdateWorkspaceBatchCompletedAtParams{
+		ID: batch.ID,
+		CompletedAt: sql.NullTime{
+			Time:  database.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		logger.Warn(ctx, "complete workspace batch", slog.Error(err))
+	}
+}
+
+// runWorkspaceBatchItem builds a workspace of a batch and waits for the
+// build to complete. Items that were already building when the batch was
+// resumed only wait for their build. It returns the build, if one was
+// created.
+func (api *API) runWorkspaceBatchItem(
+	ctx context.Context,
+	batch database.WorkspaceBatch,
+	item database.WorkspaceBatchItem,
+	authorize func(action rbac.Action, object rbac.Objecter) bool,
+	req batchAuditRequest,
+) (uuid.NullUUID, error) {
+	var build database.WorkspaceBuild
+	if item.BuildID.Valid {
+		var err error
+		build, err = api.Database.GetWorkspaceBuildByID(ctx, item.BuildID.UUID)
+		if err != nil {
+			return item.BuildID, xerrors.Errorf("get workspace build: %w", err)
+		}
+	} else {
+		var err error
+		build, err = api.startWorkspaceBatchItem(ctx, batch, item, authorize, req)
+		if err != nil {
+			return item.BuildID, err
+		}
+	}
+	buildID := uuid.NullUUID{UUID: build.ID, Valid: true}
+	job, err := api.Database.GetProvisionerJobByID(ctx, build.JobID)
+	if err != nil {
+		return buildID, xerrors.Errorf("get provisioner job: %w", err)
+	}
+
+	ticker := time.NewTicker(workspaceBatchPollInterval)
+	defer ticker.Stop()
+	for !job.CompletedAt.Valid {
+		select {
+		case <-ctx.Done():
+			return buildID, ctx.Err()
+		case <-ticker.C:
+		}
+		job, err = api.Database.GetProvisionerJobByID(ctx, job.ID)
+		if err != nil {
+			return buildID, xerrors.Errorf("get provisioner job: %w", err)
+		}
+	}
+
+	if convertProvisionerJob(job).Status != codersdk.ProvisionerJobSucceeded {
+		return buildID, xerrors.Errorf("build failed: %s", job.Error.String)
+	}
+	err = api.Database.UpdateWorkspaceBatchItem(ctx, database.UpdateWorkspaceBatchItemParams{
+		BatchID:     batch.ID,
+		WorkspaceID: item.WorkspaceID,
+		Status:      string(codersdk.WorkspaceBatchItemStatusSucceeded),
+		BuildID:     buildID,
+	})
+	if err != nil {
+		return buildID, xerrors.Errorf("update workspace batch item: %w", err)
+	}
+	return buildID, nil
+}
+
+// startWorkspaceBatchItem creates the build of a workspace in a batch and
+// records it on the item, so a resumed batch waits for it instead of
+// building again.
+func (api *API) startWorkspaceBatchItem(
+	ctx context.Context,
+	batch database.WorkspaceBatch,
+	item database.WorkspaceBatchItem,
+	authorize func(action rbac.Action, object rbac.Objecter) bool,
+	req batchAuditRequest,
+) (database.WorkspaceBuild, error) {
+	// The workspace may have changed since the batch was planned.
+	workspace, err := api.Database.GetWorkspaceByID(ctx, item.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceBuild{}, xerrors.Errorf("get workspace: %w", err)
+	}
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return database.WorkspaceBuild{}, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+
+	action := codersdk.WorkspaceBatchAction(batch.Action)
+	createBuild := codersdk.CreateWorkspaceBuildRequest{
+		Transition: workspaceBatchTransition(action),
+	}
+	if action == codersdk.WorkspaceBatchActionUpdate {
+		createBuild.Transition = codersdk.WorkspaceTransition(latestBuild.Transition)
+		template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			return database.WorkspaceBuild{}, xerrors.Errorf("get template: %w", err)
+		}
+		createBuild.TemplateVersionID = template.ActiveVersionID
+	}
+	// The initiator's roles may have changed since the batch was planned,
+	// e.g. if it's resumed by another replica.
+	rbacAction, ok := workspaceTransitionAction(createBuild.Transition)
+	if !ok || !authorize(rbacAction, workspace) {
+		return database.WorkspaceBuild{}, xerrors.New("Not authorized to build the workspace.")
+	}
+
+	// The build is recorded along with it, so a replica that crashes right
+	// after can't leave the item pending to be built again.
+	build, _, err := api.createWorkspaceBuild(ctx, req.userID, workspace, createBuild, database.BuildReasonInitiator, authorize, func(db database.Store, build database.WorkspaceBuild) error {
+		err := db.UpdateWorkspaceBatchItem(ctx, database.UpdateWorkspaceBatchItemParams{
+			BatchID:     batch.ID,
+			WorkspaceID: item.WorkspaceID,
+			Status:      string(codersdk.WorkspaceBatchItemStatusBuilding),
+			BuildID:     uuid.NullUUID{UUID: build.ID, Valid: true},
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace batch item: %w", err)
+		}
+		return nil
+	})
+	api.auditWorkspaceBatchItem(ctx, batch, workspace, latestBuild, build, err, req)
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		return database.WorkspaceBuild{}, xerrors.New(httpErr.msg)
+	}
+	if err != nil {
+		return database.WorkspaceBuild{}, err
+	}
+	return build, nil
+}
+
+// auditWorkspaceBatchItem audits a build of a batch the same way
+// postWorkspaceBuilds does.
+func (api *API) auditWorkspaceBatchItem(
+	ctx context.Context,
+	batch database.WorkspaceBatch,
+	workspace database.Workspace,
+	latestBuild, build database.WorkspaceBuild,
+	buildErr error,
+	req batchAuditRequest,
+) {
+	status := http.StatusCreated
+	var httpErr httpError
+	if xerrors.As(buildErr, &httpErr) {
+		status = httpErr.code
+	} else if buildErr != nil {
+		status = http.StatusInternalServerError
+	}
+
+	auditor := *api.Auditor.Load()
+	action := codersdk.WorkspaceBatchAction(batch.Action)
+	if action == codersdk.WorkspaceBatchActionDelete {
+		audit.BackgroundAudit(ctx, &audit.BackgroundAuditParams[database.Workspace]{
+			Audit:     auditor,
+			Log:       api.Logger,
+			UserID:    req.userID,
+			RequestID: req.requestID,
+			IP:        req.ip,
+			UserAgent: req.userAgent,
+			Status:    status,
+			Action:    database.AuditActionDelete,
+			Old:       workspace,
+		})
+		return
+	}
+
+	auditAction := database.AuditActionWrite
+	switch action {
+	case codersdk.WorkspaceBatchActionStart:
+		auditAction = database.AuditActionStart
+	case codersdk.WorkspaceBatchActionStop:
+		auditAction = database.AuditActionStop
+	}
+	additionalFields, err := json.Marshal(map[string]string{
+		"workspaceName": workspace.Name,
+	})
+	if err != nil {
+		api.Logger.Error(ctx, "could not marshal workspace name", slog.Error(err))
+	}
+	audit.BackgroundAudit(ctx, &audit.BackgroundAuditParams[database.WorkspaceBuild]{
+		Audit:            auditor,
+		Log:              api.Logger,
+		UserID:           req.userID,
+		RequestID:        req.requestID,
+		IP:               req.ip,
+		UserAgent:        req.userAgent,
+		Status:           status,
+		Action:           auditAction,
+		AdditionalFields: additionalFields,
+		Old:              latestBuild,
+		New:              build,
+	})
+}
@@ -0,0 +1,259 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		other := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		first := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		second := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		unmatched := coderdtest.CreateWorkspace(t, client, user.OrganizationID, other.ID)
+		for _, workspace := range []codersdk.Workspace{first, second, unmatched} {
+			coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		}
+
+		// A dry run only plans the batch.
+		plan, err := client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:  "template:" + template.Name,
+			Action: codersdk.WorkspaceBatchActionStop,
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		require.True(t, plan.DryRun)
+		require.Len(t, plan.Items, 2)
+		for _, item := range plan.Items {
+			require.Equal(t, codersdk.WorkspaceBatchItemStatusPending, item.Status)
+			require.NotEqual(t, unmatched.ID, item.WorkspaceID)
+		}
+		_, err = client.WorkspaceBatch(ctx, plan.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		workspace, err := client.Workspace(ctx, first.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+
+		batch, err := client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:       "template:" + template.Name,
+			Action:      codersdk.WorkspaceBatchActionStop,
+			Concurrency: 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, batch.Concurrency)
+		require.Len(t, batch.Items, 2)
+
+		require.Eventually(t, func() bool {
+			batch, err = client.WorkspaceBatch(ctx, batch.ID)
+			return err == nil && batch.CompletedAt != nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+		for _, item := range batch.Items {
+			require.Equal(t, codersdk.WorkspaceBatchItemStatusSucceeded, item.Status, item.Error)
+			require.NotNil(t, item.BuildID)
+			workspace, err := client.Workspace(ctx, item.WorkspaceID)
+			require.NoError(t, err)
+			require.Equal(t, codersdk.WorkspaceTransitionStop, workspace.LatestBuild.Transition)
+			require.Equal(t, *item.BuildID, workspace.LatestBuild.ID)
+		}
+		workspace, err = client.Workspace(ctx, unmatched.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+
+		// Stopped workspaces are skipped.
+		plan, err = client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:  "template:" + template.Name,
+			Action: codersdk.WorkspaceBatchActionStop,
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		for _, item := range plan.Items {
+			require.Equal(t, codersdk.WorkspaceBatchItemStatusSkipped, item.Status)
+			require.NotEmpty(t, item.Error)
+		}
+	})
+
+	t.Run("OtherUsers", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// Members can't see the workspaces of others, so they don't match.
+		batch, err := member.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:  "template:" + template.Name,
+			Action: codersdk.WorkspaceBatchActionStop,
+		})
+		require.NoError(t, err)
+		require.Empty(t, batch.Items)
+
+		// Nor can they see the batches of others.
+		batch, err = client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:  "template:" + template.Name,
+			Action: codersdk.WorkspaceBatchActionStop,
+		})
+		require.NoError(t, err)
+		_, err = member.WorkspaceBatch(ctx, batch.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		_, err = client.WorkspaceBatch(ctx, uuid.New())
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{
+			IncludeProvisionerDaemon:        true,
+			WorkspaceBatchHeartbeatInterval: testutil.IntervalFast,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// A batch left behind by a replica that stopped heartbeating.
+		createdAt := database.Now().Add(-time.Hour)
+		batch, err := api.Database.InsertWorkspaceBatch(ctx, database.InsertWorkspaceBatchParams{
+			ID:          uuid.New(),
+			CreatedAt:   createdAt,
+			InitiatorID: user.UserID,
+			Action:      string(codersdk.WorkspaceBatchActionStop),
+			Query:       "template:" + template.Name,
+			Concurrency: 1,
+			HeartbeatAt: createdAt,
+			Scope:       database.APIKeyScopeAll,
+		})
+		require.NoError(t, err)
+		_, err = api.Database.InsertWorkspaceBatchItem(ctx, database.InsertWorkspaceBatchItemParams{
+			BatchID:       batch.ID,
+			WorkspaceID:   workspace.ID,
+			WorkspaceName: workspace.Name,
+			OwnerName:     workspace.OwnerName,
+			Status:        string(codersdk.WorkspaceBatchItemStatusPending),
+		})
+		require.NoError(t, err)
+
+		var resumed codersdk.WorkspaceBatch
+		require.Eventually(t, func() bool {
+			resumed, err = client.WorkspaceBatch(ctx, batch.ID)
+			return err == nil && resumed.CompletedAt != nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Len(t, resumed.Items, 1)
+		require.Equal(t, codersdk.WorkspaceBatchItemStatusSucceeded, resumed.Items[0].Status, resumed.Items[0].Error)
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStop, workspace.LatestBuild.Transition)
+	})
+
+	t.Run("ResumeWithScope", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{
+			IncludeProvisionerDaemon:        true,
+			WorkspaceBatchHeartbeatInterval: testutil.IntervalFast,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// A batch started with an API key that can only connect to
+		// applications can't build workspaces when it's resumed either.
+		createdAt := database.Now().Add(-time.Hour)
+		batch, err := api.Database.InsertWorkspaceBatch(ctx, database.InsertWorkspaceBatchParams{
+			ID:          uuid.New(),
+			CreatedAt:   createdAt,
+			InitiatorID: user.UserID,
+			Action:      string(codersdk.WorkspaceBatchActionStop),
+			Query:       "template:" + template.Name,
+			Concurrency: 1,
+			HeartbeatAt: createdAt,
+			Scope:       database.APIKeyScopeApplicationConnect,
+		})
+		require.NoError(t, err)
+		_, err = api.Database.InsertWorkspaceBatchItem(ctx, database.InsertWorkspaceBatchItemParams{
+			BatchID:       batch.ID,
+			WorkspaceID:   workspace.ID,
+			WorkspaceName: workspace.Name,
+			OwnerName:     workspace.OwnerName,
+			Status:        string(codersdk.WorkspaceBatchItemStatusPending),
+		})
+		require.NoError(t, err)
+
+		var resumed codersdk.WorkspaceBatch
+		require.Eventually(t, func() bool {
+			resumed, err = client.WorkspaceBatch(ctx, batch.ID)
+			return err == nil && resumed.CompletedAt != nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Len(t, resumed.Items, 1)
+		require.Equal(t, codersdk.WorkspaceBatchItemStatusFailed, resumed.Items[0].Status)
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_, err := client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:  "owner:name:extra",
+			Action: codersdk.WorkspaceBatchActionStop,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		_, err = client.CreateWorkspaceBatch(ctx, codersdk.CreateWorkspaceBatchRequest{
+			Query:       "owner:me",
+			Action:      codersdk.WorkspaceBatchActionStop,
+			Concurrency: codersdk.MaxWorkspaceBatchConcurrency + 1,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -20,9 +21,12 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
 )
 
 func (api *API) workspaceBuild(rw http.ResponseWriter, r *http.Request) {
@@ -53,6 +57,7 @@ func (api *API) workspaceBuild(rw http.ResponseWriter, r *http.Request) {
 		data.metadata,
 		data.agents,
 		data.apps,
+		api.agentNodes(data.agents),
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -241,6 +246,7 @@ func (api *API) workspaceBuildByBuildNumber(rw http.ResponseWriter, r *http.Requ
 		data.metadata,
 		data.agents,
 		data.apps,
+		api.agentNodes(data.agents),
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -263,13 +269,8 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rbac action depends on the transition
-	var action rbac.Action
-	switch createBuild.Transition {
-	case codersdk.WorkspaceTransitionDelete:
-		action = rbac.ActionDelete
-	case codersdk.WorkspaceTransitionStart, codersdk.WorkspaceTransitionStop:
-		action = rbac.ActionUpdate
-	default:
+	action, ok := workspaceTransitionAction(createBuild.Transition)
+	if !ok {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: fmt.Sprintf("Transition %q not supported.", createBuild.Transition),
 		})
@@ -295,10 +296,10 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		aReq.Old = workspace
 	}
 
-	latestBuild, latestBuildErr := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
-
 	// if a user starts/stops a workspace, audit the workspace build
 	if action == rbac.ActionUpdate {
+		latestBuild, _ := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+
 		var auditAction database.AuditAction
 		if createBuild.Transition == codersdk.WorkspaceTransitionStart {
 			auditAction = database.AuditActionStart
@@ -331,101 +332,244 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		aReq.Old = latestBuild
 	}
 
-	if createBuild.TemplateVersionID == uuid.Nil {
-		if latestBuildErr != nil {
-			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-				Message: "Internal error fetching the latest workspace build.",
-				Detail:  latestBuildErr.Error(),
-			})
-			return
-		}
-		createBuild.TemplateVersionID = latestBuild.TemplateVersionID
+	workspaceBuild, provisionerJob, err := api.createWorkspaceBuild(ctx, apiKey.UserID, workspace, createBuild, database.BuildReasonInitiator, func(action rbac.Action, object rbac.Objecter) bool {
+		return api.Authorize(r, action, object)
+	}, nil)
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
+			Message:     httpErr.msg,
+			Detail:      httpErr.detail,
+			Validations: httpErr.validations,
+		})
+		return
 	}
-
-	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, createBuild.TemplateVersionID)
-	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "Template version not found.",
-			Validations: []codersdk.ValidationError{{
-				Field:  "template_version_id",
-				Detail: "template version not found",
-			}},
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting workspace build.",
+			Detail:  err.Error(),
 		})
 		return
 	}
+
+	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{
+		workspace.OwnerID,
+		workspaceBuild.InitiatorID,
+	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version.",
+			Message: "Internal error getting user.",
 			Detail:  err.Error(),
 		})
 		return
 	}
 
-	template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
+	apiBuild, err := api.convertWorkspaceBuild(
+		workspaceBuild,
+		workspace,
+		provisionerJob,
+		users,
+		[]database.WorkspaceResource{},
+		[]database.WorkspaceResourceMetadatum{},
+		[]database.WorkspaceAgent{},
+		[]database.WorkspaceApp{},
+		nil,
+	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Failed to get template",
+			Message: "Internal error converting workspace build.",
 			Detail:  err.Error(),
 		})
 		return
 	}
 
+	httpapi.Write(ctx, rw, http.StatusCreated, apiBuild)
+}
+
+// workspaceTransitionAction returns the rbac action a transition of a
+// workspace requires.
+func workspaceTransitionAction(transition codersdk.WorkspaceTransition) (rbac.Action, bool) {
+	switch transition {
+	case codersdk.WorkspaceTransitionDelete:
+		return rbac.ActionDelete, true
+	case codersdk.WorkspaceTransitionStart, codersdk.WorkspaceTransitionStop:
+		return rbac.ActionUpdate, true
+	default:
+		return "", false
+	}
+}
+
+// createWorkspaceBuild creates a build of the workspace that initiatorID
+// is authorized to transition. authorize checks the other permissions the
+// build may require, e.g. for custom provisioner state. inTx is optional,
+// and is called with the inserted build in the transaction inserting it.
+// Errors the client is responsible for are returned as httpError.
+func (api *API) createWorkspaceBuild(
+	ctx context.Context,
+	initiatorID uuid.UUID,
+	workspace database.Workspace,
+	createBuild codersdk.CreateWorkspaceBuildRequest,
+	reason database.BuildReason,
+	authorize func(action rbac.Action, object rbac.Objecter) bool,
+	inTx func(db database.Store, build database.WorkspaceBuild) error,
+) (database.WorkspaceBuild, database.ProvisionerJob, error) {
+	if workspace.DormantAt.Valid && createBuild.Transition != codersdk.WorkspaceTransitionDelete {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code:   http.StatusForbidden,
+			msg:    fmt.Sprintf("Workspace %q is dormant.", workspace.Name),
+			detail: "Confirm the workspace is still in use before building it.",
+		}
+	}
+
+	explicitVersion := createBuild.TemplateVersionID != uuid.Nil
+	if !explicitVersion {
+		latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get latest workspace build: %w", err)
+		}
+		createBuild.TemplateVersionID = latestBuild.TemplateVersionID
+	}
+
+	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, createBuild.TemplateVersionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusBadRequest,
+			msg:  "Template version not found.",
+			validations: []codersdk.ValidationError{{
+				Field:  "template_version_id",
+				Detail: "template version not found",
+			}},
+		}
+	}
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get template version: %w", err)
+	}
+
+	template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get template: %w", err)
+	}
+
+	// Workspaces are started on the active version of their template if
+	// either asks for it, unless a version was requested explicitly.
+	var updateFailed *notifications.Notification
+	if createBuild.Transition == codersdk.WorkspaceTransitionStart && templateVersion.ID != template.ActiveVersionID {
+		switch {
+		case explicitVersion:
+			if template.RequireActiveVersion && !authorize(rbac.ActionUpdate, template.RBACObject()) {
+				return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+					code: http.StatusForbidden,
+					msg:  fmt.Sprintf("Template %q requires workspaces to be started on its active version.", template.Name),
+				}
+			}
+		case template.RequireActiveVersion || workspace.AutomaticUpdates:
+			activeVersion, err := api.Database.GetTemplateVersionByID(ctx, template.ActiveVersionID)
+			if err != nil {
+				return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get active template version: %w", err)
+			}
+			additionalValues := make([]database.ParameterValue, 0, len(createBuild.ParameterValues))
+			for _, param := range createBuild.ParameterValues {
+				additionalValues = append(additionalValues, database.ParameterValue{
+					Name:              param.Name,
+					Scope:             database.ParameterScopeWorkspace,
+					ScopeID:           workspace.ID,
+					SourceScheme:      database.ParameterSourceScheme(param.SourceScheme),
+					SourceValue:       param.SourceValue,
+					DestinationScheme: database.ParameterDestinationScheme(param.DestinationScheme),
+				})
+			}
+			missing, err := parameter.Missing(ctx, api.Database, parameter.ComputeScope{
+				TemplateImportJobID:       activeVersion.JobID,
+				TemplateID:                uuid.NullUUID{UUID: template.ID, Valid: true},
+				WorkspaceID:               uuid.NullUUID{UUID: workspace.ID, Valid: true},
+				AdditionalParameterValues: additionalValues,
+			})
+			if err != nil {
+				return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("compute parameters of the active template version: %w", err)
+			}
+
+			switch {
+			case len(missing) == 0:
+				templateVersion = activeVersion
+			case template.RequireActiveVersion:
+				validations := make([]codersdk.ValidationError, 0, len(missing))
+				for _, name := range missing {
+					validations = append(validations, codersdk.ValidationError{
+						Field:  name,
+						Detail: "Required by the active template version.",
+					})
+				}
+				return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+					code:        http.StatusBadRequest,
+					msg:         fmt.Sprintf("The active version of template %q requires values for new parameters.", template.Name),
+					validations: validations,
+				}
+			default:
+				// The workspace is started on its current version, and its
+				// owner is told why it wasn't updated.
+				updateFailed = &notifications.Notification{
+					Kind:        database.NotificationKindWorkspaceUpdateFailed,
+					UserID:      workspace.OwnerID,
+					WorkspaceID: workspace.ID,
+					Title:       fmt.Sprintf("Workspace %q couldn't be updated", workspace.Name),
+					Body: fmt.Sprintf("The active version of template %q requires values for parameters %s, so workspace %q was started on its current version. Run \"coder update %s\" to set them.",
+						template.Name, strings.Join(missing, ", "), workspace.Name, workspace.Name),
+				}
+			}
+		}
+	}
+
 	var state []byte
 	// If custom state, deny request since user could be corrupting or leaking
 	// cloud state.
 	if createBuild.ProvisionerState != nil || createBuild.Orphan {
-		if !api.Authorize(r, rbac.ActionUpdate, template.RBACObject()) {
-			httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
-				Message: "Only template managers may provide custom state",
-			})
-			return
+		if !authorize(rbac.ActionUpdate, template.RBACObject()) {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+				code: http.StatusForbidden,
+				msg:  "Only template managers may provide custom state",
+			}
 		}
 		state = createBuild.ProvisionerState
 	}
 
 	if createBuild.Orphan {
 		if createBuild.Transition != codersdk.WorkspaceTransitionDelete {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-				Message: "Orphan is only permitted when deleting a workspace.",
-				Detail:  err.Error(),
-			})
-			return
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+				code: http.StatusBadRequest,
+				msg:  "Orphan is only permitted when deleting a workspace.",
+			}
 		}
 
 		if createBuild.ProvisionerState != nil && createBuild.Orphan {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-				Message: "ProvisionerState cannot be set alongside Orphan since state intent is unclear.",
-			})
-			return
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+				code: http.StatusBadRequest,
+				msg:  "ProvisionerState cannot be set alongside Orphan since state intent is unclear.",
+			}
 		}
 		state = []byte{}
 	}
 
 	templateVersionJob, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching provisioner job.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get template version job: %w", err)
 	}
 	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
 	switch templateVersionJobStatus {
 	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
-		httpapi.Write(ctx, rw, http.StatusNotAcceptable, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusNotAcceptable,
+			msg:  fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
+		}
 	case codersdk.ProvisionerJobFailed:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version %q has failed to import: %q. You cannot build workspaces with it!", templateVersion.Name, templateVersionJob.Error.String),
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusPreconditionFailed,
+			msg:  fmt.Sprintf("The provided template version %q has failed to import: %q. You cannot build workspaces with it!", templateVersion.Name, templateVersionJob.Error.String),
+		}
 	case codersdk.ProvisionerJobCanceled:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: "The provided template version was canceled during import. You cannot builds workspaces with it!",
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusPreconditionFailed,
+			msg:  "The provided template version was canceled during import. You cannot builds workspaces with it!",
+		}
 	}
 
 	// Store prior build number to compute new build number
@@ -434,19 +578,15 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		priorJob, err := api.Database.GetProvisionerJobByID(ctx, priorHistory.JobID)
 		if err == nil && convertProvisionerJob(priorJob).Status.Active() {
-			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
-				Message: "A workspace build is already active.",
-			})
-			return
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+				code: http.StatusConflict,
+				msg:  "A workspace build is already active.",
+			}
 		}
 
 		priorBuildNum = priorHistory.BuildNumber
 	} else if !errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching prior workspace build.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get prior workspace build: %w", err)
 	}
 
 	if state == nil {
@@ -506,7 +646,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			ID:             uuid.New(),
 			CreatedAt:      database.Now(),
 			UpdatedAt:      database.Now(),
-			InitiatorID:    apiKey.UserID,
+			InitiatorID:    initiatorID,
 			OrganizationID: template.OrganizationID,
 			Provisioner:    template.Provisioner,
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
@@ -526,58 +666,38 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			TemplateVersionID: templateVersion.ID,
 			BuildNumber:       priorBuildNum + 1,
 			ProvisionerState:  state,
-			InitiatorID:       apiKey.UserID,
+			InitiatorID:       initiatorID,
 			Transition:        database.WorkspaceTransition(createBuild.Transition),
 			JobID:             provisionerJob.ID,
-			Reason:            database.BuildReasonInitiator,
+			Reason:            reason,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert workspace build: %w", err)
 		}
-
-		return nil
-	})
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error inserting workspace build.",
-			Detail:  err.Error(),
+		// Builds remember their parameter values, so workspaces can be
+		// rolled back to them if a later update fails.
+		err = db.InsertWorkspaceBuildParameters(ctx, database.InsertWorkspaceBuildParametersParams{
+			WorkspaceBuildID: workspaceBuild.ID,
+			WorkspaceID:      workspace.ID,
 		})
-		return
-	}
+		if err != nil {
+			return xerrors.Errorf("insert workspace build parameters: %w", err)
+		}
 
-	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{
-		workspace.OwnerID,
-		workspaceBuild.InitiatorID,
+		if inTx != nil {
+			return inTx(db, workspaceBuild)
+		}
+		return nil
 	})
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error getting user.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
-	apiBuild, err := api.convertWorkspaceBuild(
-		workspaceBuild,
-		workspace,
-		provisionerJob,
-		users,
-		[]database.WorkspaceResource{},
-		[]database.WorkspaceResourceMetadatum{},
-		[]database.WorkspaceAgent{},
-		[]database.WorkspaceApp{},
-	)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error converting workspace build.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, err
 	}
 
 	api.publishWorkspaceUpdate(ctx, workspace.ID)
-
-	httpapi.Write(ctx, rw, http.StatusCreated, apiBuild)
+	if updateFailed != nil {
+		api.Notifier.Notify(ctx, *updateFailed)
+	}
+	return workspaceBuild, provisionerJob, nil
 }
 
 func (api *API) patchCancelWorkspaceBuild(rw http.ResponseWriter, r *http.Request) {
@@ -823,6 +943,7 @@ func (api *API) convertWorkspaceBuilds(
 	for _, job := range jobs {
 		jobByID[job.ID] = job
 	}
+	agentNodes := api.agentNodes(resourceAgents)
 
 	var apiBuilds []codersdk.WorkspaceBuild
 	for _, build := range workspaceBuilds {
@@ -844,6 +965,7 @@ func (api *API) convertWorkspaceBuilds(
 			resourceMetadata,
 			resourceAgents,
 			agentApps,
+			agentNodes,
 		)
 		if err != nil {
 			return nil, xerrors.Errorf("converting workspace build: %w", err)
@@ -864,6 +986,7 @@ func (api *API) convertWorkspaceBuild(
 	resourceMetadata []database.WorkspaceResourceMetadatum,
 	resourceAgents []database.WorkspaceAgent,
 	agentApps []database.WorkspaceApp,
+	agentNodes map[uuid.UUID]*tailnet.Node,
 ) (codersdk.WorkspaceBuild, error) {
 	userByID := map[uuid.UUID]database.User{}
 	for _, user := range users {
@@ -902,7 +1025,7 @@ func (api *API) convertWorkspaceBuild(
 		apiAgents := make([]codersdk.WorkspaceAgent, 0)
 		for _, agent := range agents {
 			apps := appsByAgentID[agent.ID]
-			apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), agent, convertApps(apps), api.AgentInactiveDisconnectTimeout)
+			apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), agentNodes[agent.ID], agent, convertApps(apps), api.AgentInactiveDisconnectTimeout)
 			if err != nil {
 				return codersdk.WorkspaceBuild{}, xerrors.Errorf("converting workspace agent: %w", err)
 			}
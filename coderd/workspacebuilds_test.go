@@ -16,6 +16,7 @@ import (
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
@@ -600,3 +601,134 @@ func TestWorkspaceBuildStatus(t *testing.T) {
 	require.Len(t, auditor.AuditLogs, numLogs)
 	require.Equal(t, database.AuditActionDelete, auditor.AuditLogs[numLogs-1].Action)
 }
+
+func TestWorkspaceBuildActiveVersion(t *testing.T) {
+	t.Parallel()
+
+	// setup returns a member with a stopped workspace, and a new active
+	// version of its template.
+	setup := func(t *testing.T, newVersion *echo.Responses) (*codersdk.Client, *codersdk.Client, codersdk.Workspace, codersdk.TemplateVersion) {
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, member, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, member, workspace.LatestBuild.ID)
+		workspace = coderdtest.MustTransitionWorkspace(t, member, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+
+		active := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, newVersion, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, active.ID)
+		err := client.UpdateActiveTemplateVersion(context.Background(), template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: active.ID,
+		})
+		require.NoError(t, err)
+		return client, member, workspace, active
+	}
+
+	t.Run("AutomaticUpdates", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, member, workspace, active := setup(t, nil)
+		err := member.UpdateWorkspaceAutomaticUpdates(ctx, workspace.ID, codersdk.UpdateWorkspaceAutomaticUpdatesRequest{
+			AutomaticUpdates: true,
+		})
+		require.NoError(t, err)
+		workspace = coderdtest.MustWorkspace(t, member, workspace.ID)
+		require.True(t, workspace.AutomaticUpdates)
+
+		build, err := member.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+		})
+		require.NoError(t, err)
+		require.Equal(t, active.ID, build.TemplateVersionID)
+	})
+
+	t.Run("RequireActiveVersion", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client, member, workspace, active := setup(t, nil)
+		template, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+			RequireActiveVersion: ptr.Ref(true),
+		})
+		require.NoError(t, err)
+		require.True(t, template.RequireActiveVersion)
+
+		// Members can't start the workspace on an old version.
+		_, err = member.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			TemplateVersionID: workspace.LatestBuild.TemplateVersionID,
+			Transition:        codersdk.WorkspaceTransitionStart,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		build, err := member.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+		})
+		require.NoError(t, err)
+		require.Equal(t, active.ID, build.TemplateVersionID)
+	})
+
+	t.Run("MissingParameter", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The parameter has no value, so the version fails to import, but
+		// it may still be promoted.
+		client, member, workspace, _ := setup(t, &echo.Responses{
+			Parse: []*proto.Parse_Response{{
+				Type: &proto.Parse_Response_Complete{
+					Complete: &proto.Parse_Complete{
+						ParameterSchemas: []*proto.ParameterSchema{{
+							Name: "region",
+							DefaultDestination: &proto.ParameterDestination{
+								Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+							},
+						}},
+					},
+				},
+			}},
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+			RequireActiveVersion: ptr.Ref(true),
+		})
+		require.NoError(t, err)
+
+		_, err = member.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "region", apiErr.Validations[0].Field)
+
+		// Without automatic updates required, the workspace is started on
+		// its current version instead.
+		_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+			RequireActiveVersion: ptr.Ref(false),
+		})
+		require.NoError(t, err)
+		err = member.UpdateWorkspaceAutomaticUpdates(ctx, workspace.ID, codersdk.UpdateWorkspaceAutomaticUpdatesRequest{
+			AutomaticUpdates: true,
+		})
+		require.NoError(t, err)
+		build, err := member.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+		})
+		require.NoError(t, err)
+		require.Equal(t, workspace.LatestBuild.TemplateVersionID, build.TemplateVersionID)
+		notifications, err := member.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		require.Equal(t, codersdk.NotificationKindWorkspaceUpdateFailed, notifications[0].Kind)
+	})
+}
@@ -301,102 +301,175 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	dbAutostartSchedule, err := validWorkspaceSchedule(createWorkspace.AutostartSchedule)
+	workspace, workspaceBuild, provisionerJob, err := api.createWorkspace(ctx, newWorkspace{
+		initiatorID:       apiKey.UserID,
+		ownerID:           user.ID,
+		template:          template,
+		templateVersionID: template.ActiveVersionID,
+		name:              createWorkspace.Name,
+		autostartSchedule: createWorkspace.AutostartSchedule,
+		ttlMillis:         createWorkspace.TTLMillis,
+		parameterValues:   createWorkspace.ParameterValues,
+	})
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
+			Message:     httpErr.msg,
+			Detail:      httpErr.detail,
+			Validations: httpErr.validations,
+		})
+		return
+	}
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message:     "Invalid Autostart Schedule.",
-			Validations: []codersdk.ValidationError{{Field: "schedule", Detail: err.Error()}},
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating workspace.",
+			Detail:  err.Error(),
 		})
 		return
 	}
+	aReq.New = workspace
 
-	dbTTL, err := validWorkspaceTTLMillis(createWorkspace.TTLMillis, template.DefaultTtl)
+	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{user.ID, workspaceBuild.InitiatorID})
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message:     "Invalid Workspace Time to Shutdown.",
-			Validations: []codersdk.ValidationError{{Field: "ttl_ms", Detail: err.Error()}},
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
 		})
 		return
 	}
 
-	workspace, err := api.Database.GetWorkspaceByOwnerIDAndName(ctx, database.GetWorkspaceByOwnerIDAndNameParams{
-		OwnerID: user.ID,
-		Name:    createWorkspace.Name,
+	apiBuild, err := api.convertWorkspaceBuild(
+		workspaceBuild,
+		workspace,
+		provisionerJob,
+		users,
+		[]database.WorkspaceResource{},
+		[]database.WorkspaceResourceMetadatum{},
+		[]database.WorkspaceAgent{},
+		[]database.WorkspaceApp{},
+		nil,
+	)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspace(
+		workspace,
+		apiBuild,
+		template,
+		findUser(user.ID, users),
+	))
+}
+
+// newWorkspace is a workspace to create with its first build.
+type newWorkspace struct {
+	initiatorID       uuid.UUID
+	ownerID           uuid.UUID
+	template          database.Template
+	templateVersionID uuid.UUID
+	name              string
+	autostartSchedule *string
+	ttlMillis         *int64
+	parameterValues   []codersdk.CreateParameterRequest
+	// init is called in the transaction that creates the workspace, e.g. to
+	// copy the schedule of the workspace it's cloned from.
+	init func(db database.Store, workspace database.Workspace) error
+}
+
+// createWorkspace creates a workspace and starts its first build. Errors the
+// client is responsible for are returned as httpError.
+func (api *API) createWorkspace(ctx context.Context, req newWorkspace) (database.Workspace, database.WorkspaceBuild, database.ProvisionerJob, error) {
+	template := req.template
+	policy, err := schedule.Policy(template)
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("parse template schedule policy: %w", err)
+	}
+
+	dbAutostartSchedule, err := validWorkspaceSchedule(req.autostartSchedule, policy)
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code:        http.StatusBadRequest,
+			msg:         "Invalid Autostart Schedule.",
+			validations: []codersdk.ValidationError{{Field: "schedule", Detail: err.Error()}},
+		}
+	}
+
+	dbTTL, err := validWorkspaceTTLMillis(req.ttlMillis, template.DefaultTtl, policy)
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code:        http.StatusBadRequest,
+			msg:         "Invalid Workspace Time to Shutdown.",
+			validations: []codersdk.ValidationError{{Field: "ttl_ms", Detail: err.Error()}},
+		}
+	}
+
+	_, err = api.Database.GetWorkspaceByOwnerIDAndName(ctx, database.GetWorkspaceByOwnerIDAndNameParams{
+		OwnerID: req.ownerID,
+		Name:    req.name,
 	})
 	if err == nil {
 		// If the workspace already exists, don't allow creation.
-		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
-			Message: fmt.Sprintf("Workspace %q already exists.", createWorkspace.Name),
-			Validations: []codersdk.ValidationError{{
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusConflict,
+			msg:  fmt.Sprintf("Workspace %q already exists.", req.name),
+			validations: []codersdk.ValidationError{{
 				Field:  "name",
 				Detail: "This value is already in use and should be unique.",
 			}},
-		})
-		return
+		}
 	}
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: fmt.Sprintf("Internal error fetching workspace by name %q.", createWorkspace.Name),
-			Detail:  err.Error(),
-		})
-		return
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get workspace by name %q: %w", req.name, err)
 	}
 
-	workspaceCount, err := api.Database.GetWorkspaceCountByUserID(ctx, user.ID)
+	workspaceCount, err := api.Database.GetWorkspaceCountByUserID(ctx, req.ownerID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching workspace count.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get workspace count: %w", err)
 	}
 
 	// make sure the user has not hit their quota limit
 	e := *api.WorkspaceQuotaEnforcer.Load()
 	canCreate := e.CanCreateWorkspace(int(workspaceCount))
 	if !canCreate {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: fmt.Sprintf("User workspace limit of %d is already reached.", e.UserWorkspaceLimit()),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusBadRequest,
+			msg:  fmt.Sprintf("User workspace limit of %d is already reached.", e.UserWorkspaceLimit()),
+		}
 	}
 
-	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, template.ActiveVersionID)
+	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, req.templateVersionID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get template version: %w", err)
 	}
 	templateVersionJob, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version job.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, xerrors.Errorf("get template version job: %w", err)
 	}
 	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
 	switch templateVersionJobStatus {
 	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
-		httpapi.Write(ctx, rw, http.StatusNotAcceptable, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusNotAcceptable,
+			msg:  fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
+		}
 	case codersdk.ProvisionerJobFailed:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version %q has failed to import. You cannot create workspaces using it!", templateVersion.Name),
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusPreconditionFailed,
+			msg:  fmt.Sprintf("The provided template version %q has failed to import. You cannot create workspaces using it!", templateVersion.Name),
+		}
 	case codersdk.ProvisionerJobCanceled:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: "The provided template version was canceled during import. You cannot create workspaces using it!",
-		})
-		return
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, httpError{
+			code: http.StatusPreconditionFailed,
+			msg:  "The provided template version was canceled during import. You cannot create workspaces using it!",
+		}
 	}
 
 	var (
+		workspace      database.Workspace
 		provisionerJob database.ProvisionerJob
 		workspaceBuild database.WorkspaceBuild
 	)
@@ -408,17 +481,17 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			ID:                uuid.New(),
 			CreatedAt:         now,
 			UpdatedAt:         now,
-			OwnerID:           user.ID,
+			OwnerID:           req.ownerID,
 			OrganizationID:    template.OrganizationID,
 			TemplateID:        template.ID,
-			Name:              createWorkspace.Name,
+			Name:              req.name,
 			AutostartSchedule: dbAutostartSchedule,
 			Ttl:               dbTTL,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert workspace: %w", err)
 		}
-		for _, parameterValue := range createWorkspace.ParameterValues {
+		for _, parameterValue := range req.parameterValues {
 			// If the value is empty, we don't want to save it on database so
 			// Terraform can use the default value
 			if parameterValue.SourceValue == "" {
@@ -440,6 +513,12 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 				return xerrors.Errorf("insert parameter value: %w", err)
 			}
 		}
+		if req.init != nil {
+			err = req.init(db, workspace)
+			if err != nil {
+				return err
+			}
+		}
 
 		input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
 			WorkspaceBuildID: workspaceBuildID,
@@ -451,7 +530,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			ID:             uuid.New(),
 			CreatedAt:      now,
 			UpdatedAt:      now,
-			InitiatorID:    apiKey.UserID,
+			InitiatorID:    req.initiatorID,
 			OrganizationID: template.OrganizationID,
 			Provisioner:    template.Provisioner,
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
@@ -468,7 +547,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			UpdatedAt:         now,
 			WorkspaceID:       workspace.ID,
 			TemplateVersionID: templateVersion.ID,
-			InitiatorID:       apiKey.UserID,
+			InitiatorID:       req.initiatorID,
 			Transition:        database.WorkspaceTransitionStart,
 			JobID:             provisionerJob.ID,
 			BuildNumber:       1,           // First build!
@@ -478,30 +557,241 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		if err != nil {
 			return xerrors.Errorf("insert workspace build: %w", err)
 		}
+		err = db.InsertWorkspaceBuildParameters(ctx, database.InsertWorkspaceBuildParametersParams{
+			WorkspaceBuildID: workspaceBuild.ID,
+			WorkspaceID:      workspace.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert workspace build parameters: %w", err)
+		}
 		return nil
 	})
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, database.ProvisionerJob{}, err
+	}
+
+	api.Telemetry.Report(&telemetry.Snapshot{
+		Workspaces:      []telemetry.Workspace{telemetry.ConvertWorkspace(workspace)},
+		WorkspaceBuilds: []telemetry.WorkspaceBuild{telemetry.ConvertWorkspaceBuild(workspaceBuild)},
+	})
+	return workspace, workspaceBuild, provisionerJob, nil
+}
+
+// postWorkspaceClone creates a workspace for the authenticated user with the
+// template version, parameter values and schedule of another workspace.
+func (api *API) postWorkspaceClone(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		source            = httpmw.WorkspaceParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	// Cloning copies the parameter values of the source workspace, which
+	// aren't revealed to those who can only read it.
+	if !api.Authorize(r, rbac.ActionUpdate, source) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if !api.Authorize(r, rbac.ActionCreate,
+		rbac.ResourceWorkspace.InOrg(source.OrganizationID).WithOwner(apiKey.UserID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CloneWorkspaceRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	template, err := api.Database.GetTemplateByID(ctx, source.TemplateID)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error creating workspace.",
+			Message: "Internal error fetching template.",
 			Detail:  err.Error(),
 		})
 		return
 	}
-	aReq.New = workspace
+	if template.Deleted {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Template %q has been deleted!", template.Name),
+		})
+		return
+	}
+	if !api.Authorize(r, rbac.ActionRead, template) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
 
-	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{user.ID, workspaceBuild.InitiatorID})
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, source.ID)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching user.",
+			Message: "Internal error fetching latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if template.RequireActiveVersion && latestBuild.TemplateVersionID != template.ActiveVersionID && !api.Authorize(r, rbac.ActionUpdate, template) {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: fmt.Sprintf("Template %q requires workspaces to be started on its active version.", template.Name),
+			Detail:  "Update the source workspace before cloning it.",
+		})
+		return
+	}
+	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, latestBuild.TemplateVersionID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version.",
 			Detail:  err.Error(),
 		})
 		return
 	}
 
-	api.Telemetry.Report(&telemetry.Snapshot{
-		Workspaces:      []telemetry.Workspace{telemetry.ConvertWorkspace(workspace)},
-		WorkspaceBuilds: []telemetry.WorkspaceBuild{telemetry.ConvertWorkspaceBuild(workspaceBuild)},
+	// Parameter values given in the request take precedence over those of
+	// the source workspace.
+	sourceValues, err := api.Database.ParameterValues(ctx, database.ParameterValuesParams{
+		Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+		ScopeIds: []uuid.UUID{source.ID},
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching parameter values.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	parameterValues := make([]codersdk.CreateParameterRequest, 0, len(sourceValues)+len(req.ParameterValues)+1)
+	overridden := make(map[string]struct{}, len(req.ParameterValues)+1)
+	for _, value := range req.ParameterValues {
+		overridden[value.Name] = struct{}{}
+	}
+	parameterValues = append(parameterValues, req.ParameterValues...)
+
+	schemas, err := api.Database.GetParameterSchemasByJobID(ctx, templateVersion.JobID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	for _, schema := range schemas {
+		if schema.Name != codersdk.SourceWorkspaceIDParameter {
+			continue
+		}
+		overridden[schema.Name] = struct{}{}
+		parameterValues = append(parameterValues, codersdk.CreateParameterRequest{
+			Name:              schema.Name,
+			SourceValue:       source.ID.String(),
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: codersdk.ParameterDestinationScheme(schema.DefaultDestinationScheme),
+		})
+	}
+	for _, value := range sourceValues {
+		if _, ok := overridden[value.Name]; ok {
+			continue
+		}
+		parameterValues = append(parameterValues, codersdk.CreateParameterRequest{
+			Name:              value.Name,
+			SourceValue:       value.SourceValue,
+			SourceScheme:      codersdk.ParameterSourceScheme(value.SourceScheme),
+			DestinationScheme: codersdk.ParameterDestinationScheme(value.DestinationScheme),
+		})
+	}
+
+	windows, err := api.Database.GetWorkspaceScheduleWindowsByWorkspaceIDs(ctx, []uuid.UUID{source.ID})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule windows.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	skipDates, err := api.Database.GetWorkspaceScheduleSkipDatesByWorkspaceID(ctx, source.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule skip dates.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	autostartSchedule := req.AutostartSchedule
+	if autostartSchedule == nil && source.AutostartSchedule.Valid {
+		autostartSchedule = &source.AutostartSchedule.String
+	}
+	ttlMillis := req.TTLMillis
+	if ttlMillis == nil {
+		ttlMillis = convertWorkspaceTTLMillis(source.Ttl)
+	}
+
+	workspace, workspaceBuild, provisionerJob, err := api.createWorkspace(ctx, newWorkspace{
+		initiatorID:       apiKey.UserID,
+		ownerID:           apiKey.UserID,
+		template:          template,
+		templateVersionID: templateVersion.ID,
+		name:              req.Name,
+		autostartSchedule: autostartSchedule,
+		ttlMillis:         ttlMillis,
+		parameterValues:   parameterValues,
+		init: func(db database.Store, workspace database.Workspace) error {
+			for _, window := range windows {
+				_, err := db.InsertWorkspaceScheduleWindow(ctx, database.InsertWorkspaceScheduleWindowParams{
+					ID:            uuid.New(),
+					WorkspaceID:   workspace.ID,
+					StartSchedule: window.StartSchedule,
+					StopSchedule:  window.StopSchedule,
+					CreatedAt:     window.CreatedAt,
+				})
+				if err != nil {
+					return xerrors.Errorf("insert schedule window: %w", err)
+				}
+			}
+			for _, skipDate := range skipDates {
+				_, err := db.InsertWorkspaceScheduleSkipDate(ctx, database.InsertWorkspaceScheduleSkipDateParams{
+					WorkspaceID: workspace.ID,
+					Date:        skipDate.Date,
+				})
+				if err != nil {
+					return xerrors.Errorf("insert schedule skip date: %w", err)
+				}
+			}
+			return nil
+		},
 	})
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
+			Message:     httpErr.msg,
+			Detail:      httpErr.detail,
+			Validations: httpErr.validations,
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error cloning workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = workspace
+
+	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{apiKey.UserID})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	apiBuild, err := api.convertWorkspaceBuild(
 		workspaceBuild,
@@ -512,6 +802,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		[]database.WorkspaceResourceMetadatum{},
 		[]database.WorkspaceAgent{},
 		[]database.WorkspaceApp{},
+		nil,
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -525,7 +816,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		workspace,
 		apiBuild,
 		template,
-		findUser(user.ID, users),
+		findUser(apiKey.UserID, users),
 	))
 }
 
@@ -634,7 +925,24 @@ func (api *API) putWorkspaceAutostart(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbSched, err := validWorkspaceSchedule(req.Schedule)
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	policy, err := schedule.Policy(template)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing template schedule policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	dbSched, err := validWorkspaceSchedule(req.Schedule, policy)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid autostart schedule.",
@@ -698,7 +1006,12 @@ func (api *API) putWorkspaceTTL(rw http.ResponseWriter, r *http.Request) {
 			return xerrors.Errorf("fetch workspace template: %w", err)
 		}
 
-		dbTTL, err = validWorkspaceTTLMillis(req.TTLMillis, template.DefaultTtl)
+		policy, err := schedule.Policy(template)
+		if err != nil {
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
+
+		dbTTL, err = validWorkspaceTTLMillis(req.TTLMillis, template.DefaultTtl, policy)
 		if err != nil {
 			return codersdk.ValidationError{Field: "ttl_ms", Detail: err.Error()}
 		}
@@ -784,8 +1097,22 @@ func (api *API) putExtendWorkspace(rw http.ResponseWriter, r *http.Request) {
 			return xerrors.Errorf("workspace shutdown is manual")
 		}
 
+		template, err := s.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			code = http.StatusInternalServerError
+			resp.Message = "Error fetching workspace template."
+			return xerrors.Errorf("get workspace template: %w", err)
+		}
+
+		policy, err := schedule.Policy(template)
+		if err != nil {
+			code = http.StatusInternalServerError
+			resp.Message = "Error parsing template schedule policy."
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
+
 		newDeadline := req.Deadline.UTC()
-		if err := validWorkspaceDeadline(job.CompletedAt.Time, newDeadline); err != nil {
+		if err := validWorkspaceDeadline(job.CompletedAt.Time, newDeadline, policy); err != nil {
 			// NOTE(Cian): Putting the error in the Message field on request from the FE folks.
 			// Normally, we would put the validation error in Validations, but this endpoint is
 			// not tied to a form or specific named user input on the FE.
@@ -814,6 +1141,116 @@ func (api *API) putExtendWorkspace(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, code, resp)
 }
 
+func (api *API) putWorkspaceDormancy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = workspace
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateWorkspaceDormancyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	newWorkspace := workspace
+	if req.Dormant != workspace.DormantAt.Valid {
+		now := database.Now()
+		newWorkspace.DormantAt = sql.NullTime{Time: now, Valid: req.Dormant}
+		err := api.Database.InTx(func(tx database.Store) error {
+			err := tx.UpdateWorkspaceDormantAt(ctx, database.UpdateWorkspaceDormantAtParams{
+				ID:        workspace.ID,
+				DormantAt: newWorkspace.DormantAt,
+			})
+			if err != nil {
+				return xerrors.Errorf("update workspace dormant at: %w", err)
+			}
+			if req.Dormant {
+				return nil
+			}
+			// Confirming the workspace counts as using it, otherwise it
+			// would be marked dormant again on the next autobuild tick.
+			err = tx.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
+				ID:         workspace.ID,
+				LastUsedAt: now,
+			})
+			if err != nil {
+				return xerrors.Errorf("update workspace last used at: %w", err)
+			}
+			newWorkspace.LastUsedAt = now
+			return nil
+		})
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error updating workspace dormancy.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		api.publishWorkspaceUpdate(ctx, workspace.ID)
+	}
+	aReq.New = newWorkspace
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) putWorkspaceAutomaticUpdates(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = workspace
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateWorkspaceAutomaticUpdatesRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceAutomaticUpdates(ctx, database.UpdateWorkspaceAutomaticUpdatesParams{
+		ID:               workspace.ID,
+		AutomaticUpdates: req.AutomaticUpdates,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating workspace automatic updates.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	newWorkspace := workspace
+	newWorkspace.AutomaticUpdates = req.AutomaticUpdates
+	aReq.New = newWorkspace
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
 func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspace := httpmw.WorkspaceParam(r)
@@ -1028,6 +1465,14 @@ func convertWorkspace(
 		autostartSchedule = &workspace.AutostartSchedule.String
 	}
 
+	var dormantAt, deletingAt *time.Time
+	if workspace.DormantAt.Valid {
+		dormantAt = &workspace.DormantAt.Time
+		if template.DormancyDeletionTtl > 0 {
+			deletingAt = ptr.Ref(workspace.DormantAt.Time.Add(time.Duration(template.DormancyDeletionTtl)))
+		}
+	}
+
 	ttlMillis := convertWorkspaceTTLMillis(workspace.Ttl)
 	return codersdk.Workspace{
 		ID:                workspace.ID,
@@ -1044,6 +1489,9 @@ func convertWorkspace(
 		AutostartSchedule: autostartSchedule,
 		TTLMillis:         ttlMillis,
 		LastUsedAt:        workspace.LastUsedAt,
+		DormantAt:         dormantAt,
+		DeletingAt:        deletingAt,
+		AutomaticUpdates:  workspace.AutomaticUpdates,
 	}
 }
 
@@ -1056,8 +1504,11 @@ func convertWorkspaceTTLMillis(i sql.NullInt64) *int64 {
 	return &millis
 }
 
-func validWorkspaceTTLMillis(millis *int64, def int64) (sql.NullInt64, error) {
+func validWorkspaceTTLMillis(millis *int64, def int64, policy schedule.TemplatePolicy) (sql.NullInt64, error) {
 	if ptr.NilOrZero(millis) {
+		if err := policy.ValidateTTL(time.Duration(def)); err != nil {
+			return sql.NullInt64{}, err
+		}
 		if def == 0 {
 			return sql.NullInt64{}, nil
 		}
@@ -1078,13 +1529,17 @@ func validWorkspaceTTLMillis(millis *int64, def int64) (sql.NullInt64, error) {
 		return sql.NullInt64{}, errTTLMax
 	}
 
+	if err := policy.ValidateTTL(truncated); err != nil {
+		return sql.NullInt64{}, err
+	}
+
 	return sql.NullInt64{
 		Valid: true,
 		Int64: int64(truncated),
 	}, nil
 }
 
-func validWorkspaceDeadline(startedAt, newDeadline time.Time) error {
+func validWorkspaceDeadline(startedAt, newDeadline time.Time, policy schedule.TemplatePolicy) error {
 	soon := time.Now().Add(29 * time.Minute)
 	if newDeadline.Before(soon) {
 		return errDeadlineTooSoon
@@ -1095,19 +1550,27 @@ func validWorkspaceDeadline(startedAt, newDeadline time.Time) error {
 		return errDeadlineBeforeStart
 	}
 
+	if maxDeadline := policy.MaxDeadline(startedAt); !maxDeadline.IsZero() && newDeadline.After(maxDeadline) {
+		return xerrors.Errorf("new deadline must not be after %s, as required by the template", maxDeadline.Format(time.RFC3339))
+	}
+
 	return nil
 }
 
-func validWorkspaceSchedule(s *string) (sql.NullString, error) {
+func validWorkspaceSchedule(s *string, policy schedule.TemplatePolicy) (sql.NullString, error) {
 	if ptr.NilOrEmpty(s) {
 		return sql.NullString{}, nil
 	}
 
-	_, err := schedule.Weekly(*s)
+	sched, err := schedule.Weekly(*s)
 	if err != nil {
 		return sql.NullString{}, err
 	}
 
+	if err := policy.ValidateAutostart(sched); err != nil {
+		return sql.NullString{}, err
+	}
+
 	return sql.NullString{
 		Valid:  true,
 		String: *s,
@@ -1164,6 +1627,7 @@ func workspaceSearchQuery(query string, page codersdk.Pagination) (database.GetW
 	filter.TemplateName = parser.String(searchParams, "", "template")
 	filter.Name = parser.String(searchParams, "", "name")
 	filter.Status = parser.String(searchParams, "", "status")
+	filter.Dormant = parser.Boolean(searchParams, false, "dormant")
 
 	return filter, parser.Errors
 }
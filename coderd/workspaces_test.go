@@ -20,6 +20,7 @@ import (
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbtestutil"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
@@ -1161,6 +1162,21 @@ func TestWorkspaceUpdateTTL(t *testing.T) {
 			ttlMillis:     ptr.Ref((24*7*time.Hour + time.Minute).Milliseconds()),
 			expectedError: "time until shutdown must be less than 7 days",
 		},
+		{
+			name:      "template maximum ttl",
+			ttlMillis: ptr.Ref((8 * time.Hour).Milliseconds()),
+			modifyTemplate: func(ctr *codersdk.CreateTemplateRequest) {
+				ctr.MaxTTLMillis = ptr.Ref((8 * time.Hour).Milliseconds())
+			},
+		},
+		{
+			name:      "above template maximum ttl",
+			ttlMillis: ptr.Ref((9 * time.Hour).Milliseconds()),
+			modifyTemplate: func(ctr *codersdk.CreateTemplateRequest) {
+				ctr.MaxTTLMillis = ptr.Ref((8 * time.Hour).Milliseconds())
+			},
+			expectedError: "time until shutdown must be at most 8h0m0s, the maximum allowed by the template",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1298,6 +1314,282 @@ func TestWorkspaceExtend(t *testing.T) {
 	require.WithinDuration(t, oldDeadline.Add(-time.Hour), updated.LatestBuild.Deadline.Time, time.Minute)
 }
 
+func TestWorkspaceTemplateSchedulePolicy(t *testing.T) {
+	t.Parallel()
+	var (
+		client   = coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user     = coderdtest.CreateFirstUser(t, client)
+		version  = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_        = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.DefaultTTLMillis = ptr.Ref((4 * time.Hour).Milliseconds())
+			ctr.MaxTTLMillis = ptr.Ref((8 * time.Hour).Milliseconds())
+			ctr.MinAutostartIntervalMillis = ptr.Ref((24 * time.Hour).Milliseconds())
+			ctr.AllowUserAutostop = ptr.Ref(false)
+		})
+	)
+	require.Equal(t, (8 * time.Hour).Milliseconds(), template.MaxTTLMillis)
+	require.Equal(t, (24 * time.Hour).Milliseconds(), template.MinAutostartIntervalMillis)
+	require.False(t, template.AllowUserAutostop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Autostart schedules must respect the minimum interval.
+	_, err := client.CreateWorkspace(ctx, user.OrganizationID, codersdk.Me, codersdk.CreateWorkspaceRequest{
+		TemplateID:        template.ID,
+		Name:              "hourly",
+		AutostartSchedule: ptr.Ref("CRON_TZ=UTC 0 * * * *"),
+	})
+	require.ErrorContains(t, err, "autostart must be at least 24h0m0s apart")
+
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+		cwr.AutostartSchedule = ptr.Ref("CRON_TZ=UTC 0 9 * * *")
+		cwr.TTLMillis = nil
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, ptr.Ref((4 * time.Hour).Milliseconds()), workspace.TTLMillis)
+
+	err = client.UpdateWorkspaceAutostart(ctx, workspace.ID, codersdk.UpdateWorkspaceAutostartRequest{
+		Schedule: ptr.Ref("CRON_TZ=UTC 0 9,13 * * *"),
+	})
+	require.ErrorContains(t, err, "autostart must be at least 24h0m0s apart")
+
+	// Workspaces can't be extended past the maximum TTL.
+	startedAt := *workspace.LatestBuild.Job.CompletedAt
+	err = client.PutExtendWorkspace(ctx, workspace.ID, codersdk.PutExtendWorkspaceRequest{
+		Deadline: startedAt.Add(9 * time.Hour),
+	})
+	require.ErrorContains(t, err, "as required by the template")
+	err = client.PutExtendWorkspace(ctx, workspace.ID, codersdk.PutExtendWorkspaceRequest{
+		Deadline: startedAt.Add(7 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Lowering the maximum TTL shortens the deadline of new builds.
+	_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		DefaultTTLMillis: (4 * time.Hour).Milliseconds(),
+		MaxTTLMillis:     ptr.Ref((2 * time.Hour).Milliseconds()),
+	})
+	require.ErrorContains(t, err, "Must not be greater than max_ttl_ms")
+	_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		DefaultTTLMillis: time.Hour.Milliseconds(),
+		MaxTTLMillis:     ptr.Ref((2 * time.Hour).Milliseconds()),
+	})
+	require.NoError(t, err)
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStop, database.WorkspaceTransitionStart)
+	require.WithinDuration(t, workspace.LatestBuild.Job.CompletedAt.Add(2*time.Hour), workspace.LatestBuild.Deadline.Time, time.Minute)
+}
+
+func TestWorkspaceDormancy(t *testing.T) {
+	t.Parallel()
+	var (
+		client    = coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user      = coderdtest.CreateFirstUser(t, client)
+		version   = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_         = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template  = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace = coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		_         = coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	)
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	err := client.UpdateWorkspaceDormancy(ctx, workspace.ID, codersdk.UpdateWorkspaceDormancyRequest{
+		Dormant: true,
+	})
+	require.NoError(t, err)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DormantAt)
+	require.Nil(t, workspace.DeletingAt)
+
+	res, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{Dormant: true})
+	require.NoError(t, err)
+	require.Len(t, res.Workspaces, 1)
+	require.Equal(t, workspace.ID, res.Workspaces[0].ID)
+
+	// Dormant workspaces can't be started until their owner confirms them.
+	_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStart,
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+	err = client.UpdateWorkspaceDormancy(ctx, workspace.ID, codersdk.UpdateWorkspaceDormancyRequest{
+		Dormant: false,
+	})
+	require.NoError(t, err)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Nil(t, workspace.DormantAt)
+	res, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{Dormant: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Workspaces)
+
+	_ = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStop, database.WorkspaceTransitionStart)
+}
+
+func TestWorkspaceClone(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+			Database:                 db,
+			Pubsub:                   pubsub,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		parameters := &echo.Responses{
+			Parse: []*proto.Parse_Response{{
+				Type: &proto.Parse_Response_Complete{
+					Complete: &proto.Parse_Complete{
+						ParameterSchemas: []*proto.ParameterSchema{{
+							Name: "region",
+							DefaultSource: &proto.ParameterSource{
+								Scheme: proto.ParameterSource_DATA,
+								Value:  "us",
+							},
+							DefaultDestination: &proto.ParameterDestination{
+								Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+							},
+						}, {
+							Name: codersdk.SourceWorkspaceIDParameter,
+							DefaultSource: &proto.ParameterSource{
+								Scheme: proto.ParameterSource_DATA,
+							},
+							DefaultDestination: &proto.ParameterDestination{
+								Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+							},
+						}},
+					},
+				},
+			}},
+			ProvisionApply: echo.ProvisionComplete,
+		}
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, parameters)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		source := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+			req.TTLMillis = ptr.Ref(time.Hour.Milliseconds())
+			req.ParameterValues = []codersdk.CreateParameterRequest{{
+				Name:              "region",
+				SourceValue:       "eu",
+				SourceScheme:      codersdk.ParameterSourceSchemeData,
+				DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+			}}
+		})
+		coderdtest.AwaitWorkspaceBuildJob(t, client, source.LatestBuild.ID)
+		sched := codersdk.WorkspaceSchedule{
+			Windows: []codersdk.WorkspaceScheduleWindow{
+				{StartSchedule: "CRON_TZ=UTC 0 8 * * 1-5", StopSchedule: "CRON_TZ=UTC 0 12 * * 1-5"},
+			},
+			SkipDates: []string{"2022-12-25"},
+		}
+		_, err := client.UpdateWorkspaceSchedule(ctx, source.ID, sched)
+		require.NoError(t, err)
+
+		// The clone is built with the version of the source workspace, not
+		// the active version.
+		active := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, parameters, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, active.ID)
+		err = client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{ID: active.ID})
+		require.NoError(t, err)
+
+		clone, err := client.CloneWorkspace(ctx, source.ID, codersdk.CloneWorkspaceRequest{
+			Name: "clone",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "clone", clone.Name)
+		require.Equal(t, version.ID, clone.LatestBuild.TemplateVersionID)
+		require.Equal(t, source.AutostartSchedule, clone.AutostartSchedule)
+		require.Equal(t, ptr.Ref(time.Hour.Milliseconds()), clone.TTLMillis)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, clone.LatestBuild.ID)
+
+		cloneSched, err := client.WorkspaceSchedule(ctx, clone.ID)
+		require.NoError(t, err)
+		require.Equal(t, sched, cloneSched)
+
+		values, err := db.ParameterValues(ctx, database.ParameterValuesParams{
+			Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+			ScopeIds: []uuid.UUID{clone.ID},
+		})
+		require.NoError(t, err)
+		valueByName := map[string]string{}
+		for _, value := range values {
+			valueByName[value.Name] = value.SourceValue
+		}
+		require.Equal(t, map[string]string{
+			"region":                            "eu",
+			codersdk.SourceWorkspaceIDParameter: source.ID.String(),
+		}, valueByName)
+
+		_, err = client.CloneWorkspace(ctx, source.ID, codersdk.CloneWorkspaceRequest{
+			Name: "clone",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		source := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, source.LatestBuild.ID)
+
+		// Members can't read the workspaces of others.
+		_, err := member.CloneWorkspace(ctx, source.ID, codersdk.CloneWorkspaceRequest{
+			Name: "clone",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		templateAdmin := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleTemplateAdmin())
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		source := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, source.LatestBuild.ID)
+
+		// Template admins can read the workspace, but not copy its
+		// parameter values.
+		_, err := templateAdmin.Workspace(ctx, source.ID)
+		require.NoError(t, err)
+		_, err = templateAdmin.CloneWorkspace(ctx, source.ID, codersdk.CloneWorkspaceRequest{
+			Name: "clone",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
 func TestWorkspaceWatcher(t *testing.T) {
 	t.Parallel()
 	client, closeFunc := coderdtest.NewWithProvisionerCloser(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
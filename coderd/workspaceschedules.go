@@ -0,0 +1,287 @@
+package coderd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/autobuild/schedule"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// skipDateLayout is the format of workspace schedule skip dates.
+const skipDateLayout = "2006-01-02"
+
+func (api *API) workspaceSchedule(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	sched, err := getWorkspaceSchedule(ctx, api.Database, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, sched)
+}
+
+func (api *API) putWorkspaceSchedule(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.WorkspaceSchedule
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	policy, err := schedule.Policy(template)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing template schedule policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	var validErrs []codersdk.ValidationError
+	for i, window := range req.Windows {
+		start, err := schedule.Cron(window.StartSchedule)
+		if err == nil {
+			err = policy.ValidateAutostart(start)
+		}
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  fmt.Sprintf("windows[%d].start_schedule", i),
+				Detail: err.Error(),
+			})
+		}
+		_, err = schedule.Cron(window.StopSchedule)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  fmt.Sprintf("windows[%d].stop_schedule", i),
+				Detail: err.Error(),
+			})
+		}
+	}
+	skipDates := make(map[string]struct{}, len(req.SkipDates))
+	for i, skipDate := range req.SkipDates {
+		_, err := time.Parse(skipDateLayout, skipDate)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  fmt.Sprintf("skip_dates[%d]", i),
+				Detail: fmt.Sprintf("Date %q must be in the YYYY-MM-DD format.", skipDate),
+			})
+		}
+		skipDates[skipDate] = struct{}{}
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid workspace schedule.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		err := tx.DeleteWorkspaceScheduleWindowsByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			return xerrors.Errorf("delete windows: %w", err)
+		}
+		now := database.Now()
+		for i, window := range req.Windows {
+			_, err = tx.InsertWorkspaceScheduleWindow(ctx, database.InsertWorkspaceScheduleWindowParams{
+				ID:            uuid.New(),
+				WorkspaceID:   workspace.ID,
+				StartSchedule: window.StartSchedule,
+				StopSchedule:  window.StopSchedule,
+				// Windows are returned in the order they were created in.
+				CreatedAt: now.Add(time.Duration(i) * time.Microsecond),
+			})
+			if err != nil {
+				return xerrors.Errorf("insert window: %w", err)
+			}
+		}
+
+		err = tx.DeleteWorkspaceScheduleSkipDatesByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			return xerrors.Errorf("delete skip dates: %w", err)
+		}
+		for skipDate := range skipDates {
+			date, _ := time.Parse(skipDateLayout, skipDate)
+			_, err = tx.InsertWorkspaceScheduleSkipDate(ctx, database.InsertWorkspaceScheduleSkipDateParams{
+				WorkspaceID: workspace.ID,
+				Date:        date,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert skip date: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating workspace schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	sched, err := getWorkspaceSchedule(ctx, api.Database, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, sched)
+}
+
+func (api *API) workspaceScheduleUpcoming(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	parser := httpapi.NewQueryParamParser()
+	count := parser.Int(r.URL.Query(), 10, "count")
+	if count < 1 || count > 100 {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  "count",
+			Detail: "Query param \"count\" must be between 1 and 100.",
+		})
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	windows, err := api.Database.GetWorkspaceScheduleWindowsByWorkspaceIDs(ctx, []uuid.UUID{workspace.ID})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule windows.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	skipDates, err := api.Database.GetWorkspaceScheduleSkipDatesByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace schedule skip dates.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	sched, err := schedule.ForWorkspace(workspace, windows, skipDates)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing workspace schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	now := database.Now()
+	var upcoming []schedule.Transition
+	if build.Transition == database.WorkspaceTransitionStart && build.Deadline.After(now) {
+		// The running build stops at its deadline, which isn't part of the
+		// schedule, or when a window ends before it. The workspace can't be
+		// started until then.
+		stop := build.Deadline
+		if next := sched.NextStop(now); !next.IsZero() && next.Before(stop) {
+			stop = next
+		}
+		upcoming = append(upcoming, schedule.Transition{
+			Transition: database.WorkspaceTransitionStop,
+			At:         stop,
+		})
+		upcoming = append(upcoming, sched.Upcoming(stop, count-1)...)
+	} else {
+		upcoming = sched.Upcoming(now, count)
+	}
+
+	transitions := make([]codersdk.WorkspaceScheduledTransition, 0, len(upcoming))
+	for _, transition := range upcoming {
+		transitions = append(transitions, codersdk.WorkspaceScheduledTransition{
+			Transition: codersdk.WorkspaceTransition(transition.Transition),
+			At:         transition.At,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, transitions)
+}
+
+func getWorkspaceSchedule(ctx context.Context, db database.Store, workspaceID uuid.UUID) (codersdk.WorkspaceSchedule, error) {
+	windows, err := db.GetWorkspaceScheduleWindowsByWorkspaceIDs(ctx, []uuid.UUID{workspaceID})
+	if err != nil {
+		return codersdk.WorkspaceSchedule{}, xerrors.Errorf("get windows: %w", err)
+	}
+	skipDates, err := db.GetWorkspaceScheduleSkipDatesByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return codersdk.WorkspaceSchedule{}, xerrors.Errorf("get skip dates: %w", err)
+	}
+
+	sched := codersdk.WorkspaceSchedule{
+		Windows:   make([]codersdk.WorkspaceScheduleWindow, 0, len(windows)),
+		SkipDates: make([]string, 0, len(skipDates)),
+	}
+	for _, window := range windows {
+		sched.Windows = append(sched.Windows, codersdk.WorkspaceScheduleWindow{
+			StartSchedule: window.StartSchedule,
+			StopSchedule:  window.StopSchedule,
+		})
+	}
+	for _, skipDate := range skipDates {
+		sched.SkipDates = append(sched.SkipDates, skipDate.Date.Format(skipDateLayout))
+	}
+	return sched, nil
+}
@@ -0,0 +1,110 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSchedule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		// A daily autostart keeps the upcoming transitions independent of
+		// the day of the week.
+		err := client.UpdateWorkspaceAutostart(ctx, workspace.ID, codersdk.UpdateWorkspaceAutostartRequest{
+			Schedule: ptr.Ref("CRON_TZ=UTC 30 9 * * *"),
+		})
+		require.NoError(t, err)
+
+		sched, err := client.WorkspaceSchedule(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Empty(t, sched.Windows)
+		require.Empty(t, sched.SkipDates)
+
+		want := codersdk.WorkspaceSchedule{
+			Windows: []codersdk.WorkspaceScheduleWindow{
+				{StartSchedule: "CRON_TZ=UTC 0 8 * * 1-5", StopSchedule: "CRON_TZ=UTC 0 12 * * 1-5"},
+				{StartSchedule: "CRON_TZ=UTC 0 16 * * 1-5", StopSchedule: "CRON_TZ=UTC 0 20 * * 1-5"},
+			},
+			SkipDates: []string{"2022-12-25", "2022-12-26"},
+		}
+		updated, err := client.UpdateWorkspaceSchedule(ctx, workspace.ID, codersdk.WorkspaceSchedule{
+			Windows: want.Windows,
+			// Duplicates are ignored.
+			SkipDates: []string{"2022-12-26", "2022-12-25", "2022-12-26"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, want, updated)
+		sched, err = client.WorkspaceSchedule(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, want, sched)
+
+		transitions, err := client.UpcomingWorkspaceTransitions(ctx, workspace.ID, 4)
+		require.NoError(t, err)
+		require.Len(t, transitions, 4)
+		// The workspace is running, so it's stopped before it's started
+		// again, whatever the time of day.
+		require.Equal(t, codersdk.WorkspaceTransitionStop, transitions[0].Transition)
+		for i := 1; i < len(transitions); i++ {
+			require.False(t, transitions[i].At.Before(transitions[i-1].At))
+		}
+
+		_, err = client.UpdateWorkspaceSchedule(ctx, workspace.ID, codersdk.WorkspaceSchedule{})
+		require.NoError(t, err)
+		transitions, err = client.UpcomingWorkspaceTransitions(ctx, workspace.ID, 4)
+		require.NoError(t, err)
+		// The running build stops at its deadline, and the workspace is
+		// started by its autostart schedule afterwards.
+		require.Len(t, transitions, 4)
+		workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+		require.Equal(t, codersdk.WorkspaceTransitionStop, transitions[0].Transition)
+		require.True(t, workspace.LatestBuild.Deadline.Time.Equal(transitions[0].At))
+		for _, transition := range transitions[1:] {
+			require.Equal(t, codersdk.WorkspaceTransitionStart, transition.Transition)
+			require.True(t, transition.At.After(transitions[0].At))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+
+		_, err := client.UpdateWorkspaceSchedule(ctx, workspace.ID, codersdk.WorkspaceSchedule{
+			Windows: []codersdk.WorkspaceScheduleWindow{
+				{StartSchedule: "0 8", StopSchedule: "CRON_TZ=Imaginary/Place 0 12 * * *"},
+			},
+			SkipDates: []string{"25/12/2022"},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 3)
+	})
+}
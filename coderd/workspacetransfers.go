@@ -0,0 +1,444 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) postWorkspaceTransfer(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	aReq.Old = workspace
+
+	var req codersdk.TransferWorkspaceRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.OwnerID == workspace.OwnerID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Workspace is already owned by this user.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "owner_id",
+				Detail: "The new owner must be another user.",
+			}},
+		})
+		return
+	}
+	toUser, err := api.Database.GetUserByID(ctx, req.OwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "User not found.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "owner_id",
+				Detail: "user not found",
+			}},
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	_, err = api.Database.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+		OrganizationID: workspace.OrganizationID,
+		UserID:         toUser.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("User %q isn't a member of the workspace's organization.", toUser.Username),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	fromUser, err := api.Database.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace owner.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Users who may create workspaces for the new owner, e.g. admins, don't
+	// need them to accept the transfer.
+	if api.Authorize(r, rbac.ActionCreate,
+		rbac.ResourceWorkspace.InOrg(workspace.OrganizationID).WithOwner(toUser.ID.String())) {
+		transferred, build, err := api.transferWorkspace(ctx, apiKey.UserID, workspace, toUser.ID, func(action rbac.Action, object rbac.Objecter) bool {
+			return api.Authorize(r, action, object)
+		})
+		if !writeTransferWorkspaceError(ctx, rw, err) {
+			return
+		}
+		aReq.New = transferred
+		httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceTransfer(database.WorkspaceTransfer{
+			WorkspaceID: workspace.ID,
+			FromUserID:  fromUser.ID,
+			ToUserID:    toUser.ID,
+			CreatedAt:   build.CreatedAt,
+		}, workspace, fromUser, toUser, &build.ID))
+		return
+	}
+
+	transfer, err := api.Database.UpsertWorkspaceTransfer(ctx, database.UpsertWorkspaceTransferParams{
+		WorkspaceID: workspace.ID,
+		FromUserID:  fromUser.ID,
+		ToUserID:    toUser.ID,
+		CreatedAt:   database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating workspace transfer.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = workspace
+	api.Notifier.Notify(ctx, notifications.Notification{
+		Kind:        database.NotificationKindWorkspaceTransfer,
+		UserID:      toUser.ID,
+		WorkspaceID: workspace.ID,
+		Title:       fmt.Sprintf("%s wants to transfer workspace %q to you", fromUser.Username, workspace.Name),
+		Body: fmt.Sprintf("Run \"coder transfer accept %s/%s\" to become the owner of workspace %q, or \"coder transfer decline %s/%s\" to decline it.",
+			fromUser.Username, workspace.Name, workspace.Name, fromUser.Username, workspace.Name),
+	})
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceTransfer(transfer, workspace, fromUser, toUser, nil))
+}
+
+func (api *API) workspaceTransfer(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+		apiKey    = httpmw.APIKey(r)
+	)
+
+	// The new owner can see the transfer of a workspace they can't read yet.
+	canRead := api.Authorize(r, rbac.ActionRead, workspace)
+	transfer, ok := api.fetchWorkspaceTransfer(rw, r, workspace)
+	if !ok {
+		return
+	}
+	if !canRead && transfer.ToUserID != apiKey.UserID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	converted, err := api.convertWorkspaceTransferUsers(ctx, transfer, workspace)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace transfer users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+// deleteWorkspaceTransfer cancels a transfer if called by a user who may
+// update the workspace, or declines it if called by the new owner.
+func (api *API) deleteWorkspaceTransfer(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+		apiKey    = httpmw.APIKey(r)
+	)
+
+	canUpdate := api.Authorize(r, rbac.ActionUpdate, workspace)
+	transfer, ok := api.fetchWorkspaceTransfer(rw, r, workspace)
+	if !ok {
+		return
+	}
+	if !canUpdate && transfer.ToUserID != apiKey.UserID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	err := api.Database.DeleteWorkspaceTransferByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting workspace transfer.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func (api *API) postWorkspaceTransferAccept(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		apiKey            = httpmw.APIKey(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate,
+		rbac.ResourceWorkspace.InOrg(workspace.OrganizationID).WithOwner(apiKey.UserID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	transfer, ok := api.fetchWorkspaceTransfer(rw, r, workspace)
+	if !ok {
+		return
+	}
+	if transfer.ToUserID != apiKey.UserID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	aReq.Old = workspace
+
+	transferred, build, err := api.transferWorkspace(ctx, apiKey.UserID, workspace, apiKey.UserID, func(action rbac.Action, object rbac.Objecter) bool {
+		return api.Authorize(r, action, object)
+	})
+	if !writeTransferWorkspaceError(ctx, rw, err) {
+		return
+	}
+	aReq.New = transferred
+
+	converted, err := api.convertWorkspaceTransferUsers(ctx, transfer, workspace)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace transfer users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	converted.Status = codersdk.WorkspaceTransferStatusCompleted
+	converted.BuildID = &build.ID
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+// workspaceTransfers returns the pending transfers of workspaces to a user.
+func (api *API) workspaceTransfers(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	transfers, err := api.Database.GetWorkspaceTransfersByToUserID(ctx, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace transfers.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	converted := make([]codersdk.WorkspaceTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		workspace, err := api.Database.GetWorkspaceByID(ctx, transfer.WorkspaceID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if workspace.Deleted {
+			continue
+		}
+		apiTransfer, err := api.convertWorkspaceTransferUsers(ctx, transfer, workspace)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace transfer users.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		converted = append(converted, apiTransfer)
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+// fetchWorkspaceTransfer writes a 404 if the workspace has no pending
+// transfer.
+func (api *API) fetchWorkspaceTransfer(rw http.ResponseWriter, r *http.Request, workspace database.Workspace) (database.WorkspaceTransfer, bool) {
+	ctx := r.Context()
+	transfer, err := api.Database.GetWorkspaceTransferByWorkspaceID(ctx, workspace.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return database.WorkspaceTransfer{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace transfer.",
+			Detail:  err.Error(),
+		})
+		return database.WorkspaceTransfer{}, false
+	}
+	return transfer, true
+}
+
+// transferWorkspace reassigns a workspace to toUserID, and builds it again
+// with the latest transition so it's provisioned for its new owner. The
+// tokens of the workspace's agents are regenerated, so agents of the
+// previous owner's build can't authenticate. Errors the client is
+// responsible for are returned as httpError.
+func (api *API) transferWorkspace(
+	ctx context.Context,
+	initiatorID uuid.UUID,
+	workspace database.Workspace,
+	toUserID uuid.UUID,
+	authorize func(action rbac.Action, object rbac.Objecter) bool,
+) (database.Workspace, database.WorkspaceBuild, error) {
+	_, err := api.Database.GetWorkspaceByOwnerIDAndName(ctx, database.GetWorkspaceByOwnerIDAndNameParams{
+		OwnerID: toUserID,
+		Name:    workspace.Name,
+	})
+	if err == nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, httpError{
+			code:   http.StatusConflict,
+			msg:    fmt.Sprintf("The new owner already has a workspace named %q.", workspace.Name),
+			detail: "Rename the workspace before transferring it.",
+		}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Workspace{}, database.WorkspaceBuild{}, xerrors.Errorf("get workspace by name %q: %w", workspace.Name, err)
+	}
+
+	workspaceCount, err := api.Database.GetWorkspaceCountByUserID(ctx, toUserID)
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, xerrors.Errorf("get workspace count: %w", err)
+	}
+	e := *api.WorkspaceQuotaEnforcer.Load()
+	if !e.CanCreateWorkspace(int(workspaceCount)) {
+		return database.Workspace{}, database.WorkspaceBuild{}, httpError{
+			code: http.StatusBadRequest,
+			msg:  fmt.Sprintf("The new owner's workspace limit of %d is already reached.", e.UserWorkspaceLimit()),
+		}
+	}
+
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+
+	transferred := workspace
+	transferred.OwnerID = toUserID
+	build, _, err := api.createWorkspaceBuild(ctx, initiatorID, transferred, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransition(latestBuild.Transition),
+	}, database.BuildReasonTransfer, authorize, func(db database.Store, _ database.WorkspaceBuild) error {
+		err := db.UpdateWorkspaceOwner(ctx, database.UpdateWorkspaceOwnerParams{
+			ID:      workspace.ID,
+			OwnerID: toUserID,
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace owner: %w", err)
+		}
+		err = db.RegenerateWorkspaceAgentAuthTokensByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			return xerrors.Errorf("regenerate workspace agent tokens: %w", err)
+		}
+		err = db.DeleteWorkspaceTransferByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			return xerrors.Errorf("delete workspace transfer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return database.Workspace{}, database.WorkspaceBuild{}, err
+	}
+	return transferred, build, nil
+}
+
+// writeTransferWorkspaceError writes the error of transferWorkspace, and
+// returns whether there was none.
+func writeTransferWorkspaceError(ctx context.Context, rw http.ResponseWriter, err error) bool {
+	var httpErr httpError
+	if xerrors.As(err, &httpErr) {
+		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
+			Message:     httpErr.msg,
+			Detail:      httpErr.detail,
+			Validations: httpErr.validations,
+		})
+		return false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error transferring workspace.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func (api *API) convertWorkspaceTransferUsers(ctx context.Context, transfer database.WorkspaceTransfer, workspace database.Workspace) (codersdk.WorkspaceTransfer, error) {
+	fromUser, err := api.Database.GetUserByID(ctx, transfer.FromUserID)
+	if err != nil {
+		return codersdk.WorkspaceTransfer{}, xerrors.Errorf("get user %s: %w", transfer.FromUserID, err)
+	}
+	toUser, err := api.Database.GetUserByID(ctx, transfer.ToUserID)
+	if err != nil {
+		return codersdk.WorkspaceTransfer{}, xerrors.Errorf("get user %s: %w", transfer.ToUserID, err)
+	}
+	return convertWorkspaceTransfer(transfer, workspace, fromUser, toUser, nil), nil
+}
+
+func convertWorkspaceTransfer(transfer database.WorkspaceTransfer, workspace database.Workspace, fromUser, toUser database.User, buildID *uuid.UUID) codersdk.WorkspaceTransfer {
+	status := codersdk.WorkspaceTransferStatusPending
+	if buildID != nil {
+		status = codersdk.WorkspaceTransferStatusCompleted
+	}
+	return codersdk.WorkspaceTransfer{
+		WorkspaceID:   transfer.WorkspaceID,
+		WorkspaceName: workspace.Name,
+		FromUserID:    fromUser.ID,
+		FromUsername:  fromUser.Username,
+		ToUserID:      toUser.ID,
+		ToUsername:    toUser.Username,
+		CreatedAt:     transfer.CreatedAt,
+		Status:        status,
+		BuildID:       buildID,
+	}
+}
@@ -0,0 +1,212 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceTransfer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Admin", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+			Database:                 db,
+			Pubsub:                   pubsub,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		authToken := uuid.NewString()
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse: echo.ParseComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "example",
+							Type: "aws_instance",
+							Agents: []*proto.Agent{{
+								Id:   uuid.NewString(),
+								Auth: &proto.Agent_Token{Token: authToken},
+							}},
+						}},
+					},
+				},
+			}},
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		workspace, err := client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		agentID := workspace.LatestBuild.Resources[0].Agents[0].ID
+
+		transfer, err := client.TransferWorkspace(ctx, workspace.ID, codersdk.TransferWorkspaceRequest{
+			OwnerID: member.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransferStatusCompleted, transfer.Status)
+		require.Equal(t, member.Username, transfer.ToUsername)
+		require.NotNil(t, transfer.BuildID)
+
+		build, err := client.WorkspaceBuild(ctx, *transfer.BuildID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.BuildReasonTransfer, build.Reason)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, build.Transition)
+		require.Equal(t, user.UserID, build.InitiatorID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, member.ID, workspace.OwnerID)
+		require.Equal(t, build.ID, workspace.LatestBuild.ID)
+
+		// Agents of the previous owner's build can't authenticate.
+		agent, err := db.GetWorkspaceAgentByID(ctx, agentID)
+		require.NoError(t, err)
+		require.NotEqual(t, authToken, agent.AuthToken.String())
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		fromClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		toClient, toUser := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, fromClient, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// Members can't create workspaces for others, so the new owner has
+		// to accept the transfer.
+		transfer, err := fromClient.TransferWorkspace(ctx, workspace.ID, codersdk.TransferWorkspaceRequest{
+			OwnerID: toUser.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransferStatusPending, transfer.Status)
+		require.Nil(t, transfer.BuildID)
+		workspace, err = fromClient.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, toUser.ID, workspace.OwnerID)
+
+		transfers, err := toClient.WorkspaceTransfers(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, workspace.ID, transfers[0].WorkspaceID)
+		require.Equal(t, workspace.Name, transfers[0].WorkspaceName)
+		_, err = toClient.WorkspaceTransfer(ctx, workspace.ID)
+		require.NoError(t, err)
+		notifications, err := toClient.Notifications(ctx, codersdk.Me, codersdk.NotificationsFilter{})
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		require.Equal(t, codersdk.NotificationKindWorkspaceTransfer, notifications[0].Kind)
+
+		// Only the new owner may accept it.
+		_, err = fromClient.AcceptWorkspaceTransfer(ctx, workspace.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		transfer, err = toClient.AcceptWorkspaceTransfer(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransferStatusCompleted, transfer.Status)
+		require.NotNil(t, transfer.BuildID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, *transfer.BuildID)
+
+		workspace, err = toClient.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, toUser.ID, workspace.OwnerID)
+		require.Equal(t, codersdk.BuildReasonTransfer, workspace.LatestBuild.Reason)
+		_, err = fromClient.Workspace(ctx, workspace.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		transfers, err = toClient.WorkspaceTransfers(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		fromClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		toClient, toUser := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, fromClient, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		_, err := fromClient.TransferWorkspace(ctx, workspace.ID, codersdk.TransferWorkspaceRequest{
+			OwnerID: toUser.ID,
+		})
+		require.NoError(t, err)
+		err = toClient.DeleteWorkspaceTransfer(ctx, workspace.ID)
+		require.NoError(t, err)
+
+		_, err = fromClient.WorkspaceTransfer(ctx, workspace.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		_, err = toClient.AcceptWorkspaceTransfer(ctx, workspace.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("NameConflict", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		existing := coderdtest.CreateWorkspace(t, memberClient, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+			req.Name = workspace.Name
+		})
+		coderdtest.AwaitWorkspaceBuildJob(t, client, existing.LatestBuild.ID)
+
+		_, err := client.TransferWorkspace(ctx, workspace.ID, codersdk.TransferWorkspaceRequest{
+			OwnerID: member.ID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		_, err = client.TransferWorkspace(ctx, workspace.ID, codersdk.TransferWorkspaceRequest{
+			OwnerID: user.UserID,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
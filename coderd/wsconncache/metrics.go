@@ -0,0 +1,65 @@
+package wsconncache
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons connections are evicted from the cache, used as the "reason"
+// label of the evictions metric.
+const (
+	evictReasonInactive = "inactive"
+	evictReasonCapacity = "capacity"
+	evictReasonClosed   = "closed"
+)
+
+type metrics struct {
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	evictions     *prometheus.CounterVec
+	dialLatencies prometheus.Histogram
+	conns         prometheus.Gauge
+}
+
+func newMetrics(registerer prometheus.Registerer) *metrics {
+	auto := promauto.With(registerer)
+	return &metrics{
+		hits: auto.NewCounter(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "agent_conn_cache",
+			Name:      "hits_total",
+			Help:      "The number of agent connections acquired from the cache.",
+		}),
+		misses: auto.NewCounter(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "agent_conn_cache",
+			Name:      "misses_total",
+			Help:      "The number of agent connections that weren't cached and had to be dialed.",
+		}),
+		evictions: auto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "agent_conn_cache",
+			Name:      "evictions_total",
+			Help:      "The number of agent connections closed by the cache, by whether they were inactive, over capacity or the cache closed.",
+		}, []string{"reason"}),
+		dialLatencies: auto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "coderd",
+			Subsystem: "agent_conn_cache",
+			Name:      "dial_latencies_ms",
+			Help:      "Latency distribution of successful agent dials in milliseconds.",
+			Buckets:   []float64{1, 5, 10, 25, 50, 100, 500, 1000, 5000, 10000, 30000},
+		}),
+		conns: auto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "agent_conn_cache",
+			Name:      "conns",
+			Help:      "The number of cached agent connections.",
+		}),
+	}
+}
+
+func durationToFloatMs(d time.Duration) float64 {
+	return float64(d.Milliseconds())
+}
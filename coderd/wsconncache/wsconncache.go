@@ -2,12 +2,14 @@
 package wsconncache
 
 import (
+	"container/list"
 	"context"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 	"golang.org/x/sync/singleflight"
 	"golang.org/x/xerrors"
@@ -21,17 +23,51 @@ import (
 // Agent connections are cached due to WebRTC negotiation
 // taking a few hundred milliseconds.
 func New(dialer Dialer, inactiveTimeout time.Duration) *Cache {
-	if inactiveTimeout == 0 {
-		inactiveTimeout = 5 * time.Minute
+	return NewWithOptions(dialer, Options{
+		InactiveTimeout: inactiveTimeout,
+	})
+}
+
+// Options configures a Cache.
+type Options struct {
+	// InactiveTimeout is how long connections without locks are kept.
+	// Defaults to 5 minutes.
+	InactiveTimeout time.Duration
+	// MaxConns limits the number of cached connections. When a new
+	// connection exceeds it, the least recently used connections without
+	// locks are closed. Connections in use are never closed, so the limit
+	// may be exceeded while they are. There is no limit when it's zero.
+	MaxConns int
+	// DrainTimeout is how long Close waits for connections in use to be
+	// released before closing them.
+	DrainTimeout time.Duration
+	// Registerer records the metrics of the cache. They aren't exported
+	// when it's nil.
+	Registerer prometheus.Registerer
+}
+
+// NewWithOptions creates a new workspace connection cache.
+func NewWithOptions(dialer Dialer, options Options) *Cache {
+	if options.InactiveTimeout == 0 {
+		options.InactiveTimeout = 5 * time.Minute
+	}
+	if options.Registerer == nil {
+		options.Registerer = prometheus.NewRegistry()
 	}
 	return &Cache{
 		closed:          make(chan struct{}),
+		draining:        make(chan struct{}),
 		dialer:          dialer,
-		inactiveTimeout: inactiveTimeout,
+		inactiveTimeout: options.InactiveTimeout,
+		maxConns:        options.MaxConns,
+		drainTimeout:    options.DrainTimeout,
+		lru:             list.New(),
+		metrics:         newMetrics(options.Registerer),
 	}
 }
 
-// Dialer creates a new agent connection by ID.
+// Dialer creates a new agent connection by ID. Connections may share a
+// tailnet connection, see codersdk.AgentConn.Shared.
 type Dialer func(r *http.Request, id uuid.UUID) (*codersdk.AgentConn, error)
 
 // Conn wraps an agent connection with a reusable HTTP transport.
@@ -43,6 +79,12 @@ type Conn struct {
 	timeout       *time.Timer
 	timeoutCancel context.CancelFunc
 	transport     *http.Transport
+	// evictReason is why the connection was removed from the cache, and
+	// is empty if the agent closed it. It's guarded by timeoutMutex.
+	evictReason string
+	// element is the position of the connection in the LRU list. It's
+	// guarded by Cache.lruMutex and nil once the connection is evicted.
+	element *list.Element
 }
 
 func (c *Conn) HTTPTransport() *http.Transport {
@@ -62,14 +104,44 @@ func (c *Conn) CloseWithError(err error) error {
 	return c.AgentConn.CloseWithError(err)
 }
 
+// evict removes the connection from the cache unless it's in use. New
+// connections are in use until they're released for the first time.
+func (c *Conn) evict(reason string) bool {
+	c.timeoutMutex.Lock()
+	defer c.timeoutMutex.Unlock()
+	if c.locks.Load() != 0 || c.timeout == nil {
+		return false
+	}
+	c.cancelTimeout(reason)
+	return true
+}
+
+// cancelTimeout removes the connection from the cache. The timeout mutex
+// must be held.
+func (c *Conn) cancelTimeout(reason string) {
+	if c.evictReason == "" {
+		c.evictReason = reason
+	}
+	c.timeoutCancel()
+}
+
 type Cache struct {
 	closed          chan struct{}
+	draining        chan struct{}
 	closeMutex      sync.Mutex
 	closeGroup      sync.WaitGroup
+	lockGroup       sync.WaitGroup
 	connGroup       singleflight.Group
 	connMap         sync.Map
 	dialer          Dialer
 	inactiveTimeout time.Duration
+	maxConns        int
+	drainTimeout    time.Duration
+	metrics         *metrics
+
+	// lru holds the cached connections, most recently acquired first.
+	lruMutex sync.Mutex
+	lru      *list.List
 }
 
 // Acquire gets or establishes a connection with the dialer using the ID provided.
@@ -79,27 +151,41 @@ type Cache struct {
 // locks exist on a connection, the inactive timeout will begin to tick down.
 // After the time expires, the connection will be cleared from the cache.
 func (c *Cache) Acquire(r *http.Request, id uuid.UUID) (*Conn, func(), error) {
+	// Each lock is tracked so Close can wait for them to be released.
+	c.closeMutex.Lock()
+	select {
+	case <-c.draining:
+		c.closeMutex.Unlock()
+		return nil, nil, xerrors.New("closed")
+	default:
+	}
+	c.lockGroup.Add(1)
+	c.closeMutex.Unlock()
+
 	rawConn, found := c.connMap.Load(id.String())
 	// If the connection isn't found, establish a new one!
 	if !found {
+		c.metrics.misses.Inc()
 		var err error
 		// A singleflight group is used to allow for concurrent requests to the
 		// same identifier to resolve.
 		rawConn, err, _ = c.connGroup.Do(id.String(), func() (interface{}, error) {
 			c.closeMutex.Lock()
 			select {
-			case <-c.closed:
+			case <-c.draining:
 				c.closeMutex.Unlock()
 				return nil, xerrors.New("closed")
 			default:
 			}
 			c.closeGroup.Add(1)
 			c.closeMutex.Unlock()
+			start := time.Now()
 			agentConn, err := c.dialer(r, id)
 			if err != nil {
 				c.closeGroup.Done()
 				return nil, xerrors.Errorf("dial: %w", err)
 			}
+			c.metrics.dialLatencies.Observe(durationToFloatMs(time.Since(start)))
 			timeoutCtx, timeoutCancelFunc := context.WithCancel(context.Background())
 			defaultTransport, valid := http.DefaultTransport.(*http.Transport)
 			if !valid {
@@ -112,57 +198,160 @@ func (c *Cache) Acquire(r *http.Request, id uuid.UUID) (*Conn, func(), error) {
 				timeoutCancel: timeoutCancelFunc,
 				transport:     transport,
 			}
+			c.connMap.Store(id.String(), conn)
+			c.metrics.conns.Inc()
+			c.lruMutex.Lock()
+			conn.element = c.lru.PushFront(conn)
+			c.lruMutex.Unlock()
 			go func() {
 				defer c.closeGroup.Done()
 				var err error
 				select {
 				case <-timeoutCtx.Done():
-					err = xerrors.New("cache timeout")
 				case <-c.closed:
-					err = xerrors.New("cache closed")
+					conn.timeoutMutex.Lock()
+					conn.cancelTimeout(evictReasonClosed)
+					conn.timeoutMutex.Unlock()
 				case <-conn.Closed():
 				}
+				conn.timeoutMutex.Lock()
+				reason := conn.evictReason
+				conn.timeoutMutex.Unlock()
+				if reason != "" {
+					err = xerrors.Errorf("cache eviction: %s", reason)
+					c.metrics.evictions.WithLabelValues(reason).Inc()
+				}
 
+				c.lruMutex.Lock()
+				if conn.element != nil {
+					c.lru.Remove(conn.element)
+					conn.element = nil
+				}
+				c.lruMutex.Unlock()
 				c.connMap.Delete(id.String())
 				c.connGroup.Forget(id.String())
+				c.metrics.conns.Dec()
 				_ = conn.CloseWithError(err)
 			}()
+			c.evictLeastRecentlyUsed()
 			return conn, nil
 		})
 		if err != nil {
+			c.lockGroup.Done()
 			return nil, nil, err
 		}
-		c.connMap.Store(id.String(), rawConn)
+	} else {
+		c.metrics.hits.Inc()
 	}
 
 	conn, _ := rawConn.(*Conn)
+	c.lruMutex.Lock()
+	if conn.element != nil {
+		c.lru.MoveToFront(conn.element)
+	}
+	c.lruMutex.Unlock()
 	conn.timeoutMutex.Lock()
 	defer conn.timeoutMutex.Unlock()
 	if conn.timeout != nil {
 		conn.timeout.Stop()
 	}
 	conn.locks.Inc()
+	var releaseOnce sync.Once
 	return conn, func() {
+		releaseOnce.Do(func() {
+			defer c.lockGroup.Done()
+			if c.release(conn) {
+				// The cache may have grown past its limit while the
+				// connection was in use.
+				c.evictLeastRecentlyUsed()
+			}
+		})
+	}, nil
+}
+
+// release releases a lock on a connection, and starts its inactive timeout
+// once no locks remain. It reports whether the connection became idle and
+// is still cached.
+func (c *Cache) release(conn *Conn) bool {
+	conn.timeoutMutex.Lock()
+	defer conn.timeoutMutex.Unlock()
+	if conn.timeout != nil {
+		conn.timeout.Stop()
+	}
+	conn.locks.Dec()
+	if conn.locks.Load() != 0 {
+		return false
+	}
+	select {
+	case <-c.draining:
+		// Idle connections aren't kept while the cache drains.
+		conn.cancelTimeout(evictReasonClosed)
+		return false
+	default:
+	}
+	conn.timeout = time.AfterFunc(c.inactiveTimeout, func() {
 		conn.timeoutMutex.Lock()
 		defer conn.timeoutMutex.Unlock()
-		if conn.timeout != nil {
-			conn.timeout.Stop()
-		}
-		conn.locks.Dec()
+		// The connection may have been acquired since.
 		if conn.locks.Load() == 0 {
-			conn.timeout = time.AfterFunc(c.inactiveTimeout, conn.timeoutCancel)
+			conn.cancelTimeout(evictReasonInactive)
 		}
-	}, nil
+	})
+	return true
 }
 
+// evictLeastRecentlyUsed closes the least recently used connections
+// without locks until the cache holds at most maxConns.
+func (c *Cache) evictLeastRecentlyUsed() {
+	if c.maxConns <= 0 {
+		return
+	}
+	c.lruMutex.Lock()
+	defer c.lruMutex.Unlock()
+	element := c.lru.Back()
+	for c.lru.Len() > c.maxConns && element != nil {
+		conn, _ := element.Value.(*Conn)
+		previous := element.Prev()
+		if conn.evict(evictReasonCapacity) {
+			c.lru.Remove(element)
+			conn.element = nil
+		}
+		element = previous
+	}
+}
+
+// Close drains the cache and closes every connection. Acquire fails once
+// Close is called. Idle connections are closed right away, and connections
+// in use when they're released or after the drain timeout.
 func (c *Cache) Close() error {
 	c.closeMutex.Lock()
-	defer c.closeMutex.Unlock()
 	select {
-	case <-c.closed:
+	case <-c.draining:
+		c.closeMutex.Unlock()
 		return nil
 	default:
 	}
+	close(c.draining)
+	c.closeMutex.Unlock()
+
+	c.connMap.Range(func(_, rawConn interface{}) bool {
+		conn, _ := rawConn.(*Conn)
+		conn.evict(evictReasonClosed)
+		return true
+	})
+	if c.drainTimeout > 0 {
+		drained := make(chan struct{})
+		go func() {
+			c.lockGroup.Wait()
+			close(drained)
+		}()
+		timer := time.NewTimer(c.drainTimeout)
+		select {
+		case <-drained:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
 	close(c.closed)
 	c.closeGroup.Wait()
 	return nil
@@ -16,10 +16,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 	"go.uber.org/goleak"
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
@@ -28,6 +31,7 @@ import (
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/tailnet"
 	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
 )
 
 func TestMain(m *testing.M) {
@@ -142,6 +146,137 @@ func TestCache(t *testing.T) {
 		}
 		wg.Wait()
 	})
+	t.Run("EvictLeastRecentlyUsed", func(t *testing.T) {
+		t.Parallel()
+		registry := prometheus.NewRegistry()
+		cache := wsconncache.NewWithOptions(func(r *http.Request, id uuid.UUID) (*codersdk.AgentConn, error) {
+			return setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0), nil
+		}, wsconncache.Options{
+			MaxConns:   2,
+			Registerer: registry,
+		})
+		defer func() {
+			_ = cache.Close()
+		}()
+		acquire := func(id uuid.UUID) *wsconncache.Conn {
+			conn, release, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), id)
+			require.NoError(t, err)
+			release()
+			return conn
+		}
+		first, second, third := uuid.New(), uuid.New(), uuid.New()
+		firstConn := acquire(first)
+		secondConn := acquire(second)
+		// Using the first connection makes the second the least recently used.
+		require.True(t, firstConn == acquire(first))
+		acquire(third)
+		<-secondConn.Closed()
+		require.True(t, firstConn == acquire(first))
+
+		// Connections in use aren't evicted.
+		_, release, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), first)
+		require.NoError(t, err)
+		defer release()
+		acquire(second)
+		require.Eventually(t, func() bool {
+			return gatherCacheMetrics(t, registry)["coderd_agent_conn_cache_conns"] == 2
+		}, testutil.WaitShort, testutil.IntervalFast)
+		select {
+		case <-firstConn.Closed():
+			t.Fatal("connection in use was evicted")
+		default:
+		}
+
+		values := gatherCacheMetrics(t, registry)
+		require.Equal(t, float64(3), values["coderd_agent_conn_cache_hits_total"])
+		require.Equal(t, float64(4), values["coderd_agent_conn_cache_misses_total"])
+		require.Equal(t, float64(2), values["coderd_agent_conn_cache_evictions_total"])
+	})
+	t.Run("EvictOnRelease", func(t *testing.T) {
+		t.Parallel()
+		cache := wsconncache.NewWithOptions(func(r *http.Request, id uuid.UUID) (*codersdk.AgentConn, error) {
+			return setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0), nil
+		}, wsconncache.Options{
+			MaxConns: 1,
+		})
+		defer func() {
+			_ = cache.Close()
+		}()
+		// Both connections are in use, so the cache exceeds its limit.
+		firstConn, releaseFirst, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), uuid.New())
+		require.NoError(t, err)
+		secondConn, releaseSecond, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), uuid.New())
+		require.NoError(t, err)
+		defer releaseSecond()
+
+		// Releasing the least recently used connection evicts it.
+		releaseFirst()
+		select {
+		case <-firstConn.Closed():
+		case <-time.After(testutil.WaitShort):
+			t.Fatal("released connection wasn't evicted")
+		}
+		select {
+		case <-secondConn.Closed():
+			t.Fatal("connection in use was evicted")
+		default:
+		}
+	})
+	t.Run("Drain", func(t *testing.T) {
+		t.Parallel()
+		cache := wsconncache.NewWithOptions(func(r *http.Request, id uuid.UUID) (*codersdk.AgentConn, error) {
+			return setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0), nil
+		}, wsconncache.Options{
+			DrainTimeout: testutil.WaitLong,
+		})
+		idle, release, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), uuid.New())
+		require.NoError(t, err)
+		release()
+		conn, release, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), uuid.New())
+		require.NoError(t, err)
+
+		closed := make(chan struct{})
+		go func() {
+			_ = cache.Close()
+			close(closed)
+		}()
+		// Idle connections are closed right away, and no new locks are handed out.
+		<-idle.Closed()
+		require.Eventually(t, func() bool {
+			_, _, err := cache.Acquire(httptest.NewRequest(http.MethodGet, "/", nil), uuid.New())
+			return err != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+		select {
+		case <-conn.Closed():
+			t.Fatal("connection in use was closed")
+		case <-closed:
+			t.Fatal("cache closed while a connection was in use")
+		default:
+		}
+		release()
+		<-conn.Closed()
+		<-closed
+	})
+}
+
+// gatherCacheMetrics sums the values of the counters and gauges in the
+// registry by name.
+func gatherCacheMetrics(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range metrics {
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.GetCounter() != nil:
+				values[family.GetName()] += metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				values[family.GetName()] += metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
 }
 
 func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeout time.Duration) *codersdk.AgentConn {
@@ -221,3 +356,20 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (*client) WorkspaceAgentWatchDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	maps := make(chan *tailcfg.DERPMap)
+	go func() {
+		<-ctx.Done()
+		close(maps)
+	}()
+	return maps, nil
+}
+
+func (*client) WorkspaceAgentPeers(_ context.Context) ([]codersdk.WorkspaceAgentPeer, error) {
+	return nil, nil
+}
+
+func (*client) DialWorkspaceAgentPeer(_ context.Context, _ uuid.UUID) (net.Conn, error) {
+	return nil, xerrors.New("workspace networking is not supported")
+}
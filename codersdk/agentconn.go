@@ -12,8 +12,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 	"tailscale.com/ipn/ipnstate"
@@ -48,6 +50,46 @@ var (
 	MinimumListeningPort = 9
 )
 
+// WorkspaceAgentIP returns the address an agent is reachable at by other
+// agents through workspace networking. Unlike TailnetIP it is unique to the
+// agent, since an agent may be connected to several peers at once.
+func WorkspaceAgentIP(agentID uuid.UUID) netip.Addr {
+	var addr [16]byte
+	copy(addr[:], workspaceAgentIPPrefix)
+	copy(addr[8:], agentID[8:])
+	return netip.AddrFrom16(addr)
+}
+
+// workspaceAgentIPPrefix is the /64 the addresses of WorkspaceAgentIP are
+// taken from. It has the Tailscale prefix, but is distinct from the network
+// of TailnetIP.
+var workspaceAgentIPPrefix = []byte{0xfd, 0x7a, 0x11, 0x5c, 0xa1, 0xe0, 0xb1, 0xb3}
+
+// WithoutTailnetIP removes TailnetIP from the addresses of agent nodes. Every
+// agent has TailnetIP, so it can't be routed when connected to several agents
+// at once, or by an agent that has the address itself.
+func WithoutTailnetIP(nodes []*tailnet.Node) []*tailnet.Node {
+	filtered := make([]*tailnet.Node, 0, len(nodes))
+	for _, node := range nodes {
+		peerNode := *node
+		peerNode.Addresses = withoutTailnetIP(node.Addresses)
+		peerNode.AllowedIPs = withoutTailnetIP(node.AllowedIPs)
+		filtered = append(filtered, &peerNode)
+	}
+	return filtered
+}
+
+func withoutTailnetIP(prefixes []netip.Prefix) []netip.Prefix {
+	filtered := make([]netip.Prefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix.Contains(TailnetIP) {
+			continue
+		}
+		filtered = append(filtered, prefix)
+	}
+	return filtered
+}
+
 // IgnoredListeningPorts contains a list of ports in the global ignore list.
 // This list contains common TCP ports that are not HTTP servers, such as
 // databases, SSH, FTP, etc.
@@ -131,28 +173,107 @@ type ReconnectingPTYRequest struct {
 type AgentConn struct {
 	*tailnet.Conn
 	CloseFunc func()
+	// AgentIP is the address the agent is dialed at. It defaults to
+	// TailnetIP, which every agent has, so Conn must only be connected to
+	// this agent then. Shared connections use WorkspaceAgentIP.
+	AgentIP netip.Addr
+	// Shared is true when Conn is shared with the connections to other
+	// agents. Closing the AgentConn then only calls CloseFunc, and Conn is
+	// closed by its owner.
+	Shared bool
+
+	closedOnce sync.Once
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+// agentIP returns the address the agent is dialed at.
+func (c *AgentConn) agentIP() netip.Addr {
+	if c.AgentIP.IsValid() {
+		return c.AgentIP
+	}
+	return TailnetIP
+}
+
+// Closed is closed once the connection to the agent is closed.
+func (c *AgentConn) Closed() <-chan struct{} {
+	if !c.Shared {
+		return c.Conn.Closed()
+	}
+	return c.closedChan()
+}
+
+func (c *AgentConn) closedChan() chan struct{} {
+	c.closedOnce.Do(func() {
+		c.closed = make(chan struct{})
+	})
+	return c.closed
 }
 
 func (c *AgentConn) Ping(ctx context.Context) (time.Duration, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
+	path, err := c.Path(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return path.Latency, nil
+}
+
+// AgentConnPath describes how packets travel to the agent.
+// @typescript-ignore AgentConnPath
+type AgentConnPath struct {
+	// Endpoint is the address of the agent when the connection is direct.
+	// It's empty when packets are relayed through DERP.
+	Endpoint string
+	// DERPRegionID and DERPRegionCode identify the relay when the
+	// connection isn't direct.
+	DERPRegionID   int
+	DERPRegionCode string
+	Latency        time.Duration
+}
+
+// Direct returns whether packets are sent to the agent without a relay.
+func (p AgentConnPath) Direct() bool {
+	return p.Endpoint != ""
+}
+
+func (p AgentConnPath) String() string {
+	if p.Direct() {
+		return fmt.Sprintf("direct to %s (%dms)", p.Endpoint, p.Latency.Milliseconds())
+	}
+	return fmt.Sprintf("relayed through DERP region %q (%dms)", p.DERPRegionCode, p.Latency.Milliseconds())
+}
+
+// Path pings the agent and returns the path the ping took. Connections
+// start out relayed and switch to direct once the peers find each other,
+// unless direct connections are blocked.
+func (c *AgentConn) Path(ctx context.Context) (AgentConnPath, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
 	errCh := make(chan error, 1)
-	durCh := make(chan time.Duration, 1)
-	go c.Conn.Ping(TailnetIP, tailcfg.PingDisco, func(pr *ipnstate.PingResult) {
+	pathCh := make(chan AgentConnPath, 1)
+	go c.Conn.Ping(c.agentIP(), tailcfg.PingDisco, func(pr *ipnstate.PingResult) {
 		if pr.Err != "" {
 			errCh <- xerrors.New(pr.Err)
 			return
 		}
-		durCh <- time.Duration(pr.LatencySeconds * float64(time.Second))
+		pathCh <- AgentConnPath{
+			Endpoint:       pr.Endpoint,
+			DERPRegionID:   pr.DERPRegionID,
+			DERPRegionCode: pr.DERPRegionCode,
+			Latency:        time.Duration(pr.LatencySeconds * float64(time.Second)),
+		}
 	})
 	select {
 	case err := <-errCh:
-		return 0, err
+		return AgentConnPath{}, err
 	case <-ctx.Done():
-		return 0, ctx.Err()
-	case dur := <-durCh:
-		return dur, nil
+		return AgentConnPath{}, ctx.Err()
+	case path := <-pathCh:
+		return path, nil
 	}
 }
 
@@ -164,6 +285,12 @@ func (c *AgentConn) Close() error {
 	if c.CloseFunc != nil {
 		c.CloseFunc()
 	}
+	if c.Shared {
+		c.closeOnce.Do(func() {
+			close(c.closedChan())
+		})
+		return nil
+	}
 	return c.Conn.Close()
 }
 
@@ -179,7 +306,7 @@ func (c *AgentConn) ReconnectingPTY(ctx context.Context, id string, height, widt
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	conn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(TailnetIP, uint16(TailnetReconnectingPTYPort)))
+	conn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(c.agentIP(), uint16(TailnetReconnectingPTYPort)))
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +334,7 @@ func (c *AgentConn) ReconnectingPTY(ctx context.Context, id string, height, widt
 func (c *AgentConn) SSH(ctx context.Context) (net.Conn, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
-	return c.DialContextTCP(ctx, netip.AddrPortFrom(TailnetIP, uint16(TailnetSSHPort)))
+	return c.DialContextTCP(ctx, netip.AddrPortFrom(c.agentIP(), uint16(TailnetSSHPort)))
 }
 
 // SSHClient calls SSH to create a client that uses a weak cipher
@@ -234,7 +361,7 @@ func (c *AgentConn) SSHClient(ctx context.Context) (*ssh.Client, error) {
 func (c *AgentConn) Speedtest(ctx context.Context, direction speedtest.Direction, duration time.Duration) ([]speedtest.Result, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
-	speedConn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(TailnetIP, uint16(TailnetSpeedtestPort)))
+	speedConn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(c.agentIP(), uint16(TailnetSpeedtestPort)))
 	if err != nil {
 		return nil, xerrors.Errorf("dial speedtest: %w", err)
 	}
@@ -253,7 +380,7 @@ func (c *AgentConn) DialContext(ctx context.Context, network string, addr string
 	}
 	_, rawPort, _ := net.SplitHostPort(addr)
 	port, _ := strconv.Atoi(rawPort)
-	ipp := netip.AddrPortFrom(TailnetIP, uint16(port))
+	ipp := netip.AddrPortFrom(c.agentIP(), uint16(port))
 	if network == "udp" {
 		return c.Conn.DialContextUDP(ctx, ipp)
 	}
@@ -274,13 +401,13 @@ func (c *AgentConn) statisticsClient() *http.Client {
 				if err != nil {
 					return nil, xerrors.Errorf("split host port %q: %w", addr, err)
 				}
-				// Verify that host is TailnetIP and port is
+				// Verify that host is the agent and port is
 				// TailnetStatisticsPort.
-				if host != TailnetIP.String() || port != strconv.Itoa(TailnetStatisticsPort) {
+				if host != c.agentIP().String() || port != strconv.Itoa(TailnetStatisticsPort) {
 					return nil, xerrors.Errorf("request %q does not appear to be for statistics server", addr)
 				}
 
-				conn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(TailnetIP, uint16(TailnetStatisticsPort)))
+				conn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(c.agentIP(), uint16(TailnetStatisticsPort)))
 				if err != nil {
 					return nil, xerrors.Errorf("dial statistics: %w", err)
 				}
@@ -294,7 +421,7 @@ func (c *AgentConn) statisticsClient() *http.Client {
 func (c *AgentConn) doStatisticsRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
-	host := net.JoinHostPort(TailnetIP.String(), strconv.Itoa(TailnetStatisticsPort))
+	host := net.JoinHostPort(c.agentIP().String(), strconv.Itoa(TailnetStatisticsPort))
 	url := fmt.Sprintf("http://%s%s", host, path)
 
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
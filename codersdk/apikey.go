@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,17 +31,85 @@ const (
 	LoginTypeGithub   LoginType = "github"
 	LoginTypeOIDC     LoginType = "oidc"
 	LoginTypeToken    LoginType = "token"
+	LoginTypeLDAP     LoginType = "ldap"
 )
 
 type APIKeyScope string
 
 const (
-	APIKeyScopeAll                APIKeyScope = "all"
-	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	APIKeyScopeAll                 APIKeyScope = "all"
+	APIKeyScopeApplicationConnect  APIKeyScope = "application_connect"
+	APIKeyScopeTwoFactorEnrollment APIKeyScope = "two_factor_enrollment"
 )
 
 type CreateTokenRequest struct {
 	Scope APIKeyScope `json:"scope"`
+	// Lifetime defaults to the maximum token lifetime of the deployment, or
+	// 100 years if there is none.
+	Lifetime time.Duration `json:"lifetime"`
+}
+
+// APIKeyWithOwner is an API key along with the username of the user that
+// owns it.
+type APIKeyWithOwner struct {
+	APIKey
+	Username string `json:"username"`
+}
+
+// APIKeysFilter filters the API keys of every user.
+type APIKeysFilter struct {
+	// User is a username or user ID.
+	User string `json:"user,omitempty" typescript:"-"`
+	// LoginType is the login type of the session, or "token".
+	LoginType LoginType `json:"login_type,omitempty" typescript:"-"`
+	// Scope is the scope of the keys.
+	Scope APIKeyScope `json:"scope,omitempty" typescript:"-"`
+	// LastUsedBefore, LastUsedAfter, ExpiresBefore and ExpiresAfter are
+	// dates with the format YYYY-MM-DD.
+	LastUsedBefore string `json:"last_used_before,omitempty" typescript:"-"`
+	LastUsedAfter  string `json:"last_used_after,omitempty" typescript:"-"`
+	ExpiresBefore  string `json:"expires_before,omitempty" typescript:"-"`
+	ExpiresAfter   string `json:"expires_after,omitempty" typescript:"-"`
+	// FilterQuery supports a raw filter query string
+	FilterQuery string `json:"q,omitempty"`
+}
+
+// asRequestOption returns a function that can be used in (*Client).Request.
+// It modifies the request query parameters.
+func (f APIKeysFilter) asRequestOption() RequestOption {
+	return func(r *http.Request) {
+		var params []string
+		// Make sure all user input is quoted to ensure it's parsed as a single
+		// string.
+		if f.User != "" {
+			params = append(params, fmt.Sprintf("user:%q", f.User))
+		}
+		if f.LoginType != "" {
+			params = append(params, fmt.Sprintf("login_type:%q", f.LoginType))
+		}
+		if f.Scope != "" {
+			params = append(params, fmt.Sprintf("scope:%q", f.Scope))
+		}
+		if f.LastUsedBefore != "" {
+			params = append(params, fmt.Sprintf("last_used_before:%q", f.LastUsedBefore))
+		}
+		if f.LastUsedAfter != "" {
+			params = append(params, fmt.Sprintf("last_used_after:%q", f.LastUsedAfter))
+		}
+		if f.ExpiresBefore != "" {
+			params = append(params, fmt.Sprintf("expires_before:%q", f.ExpiresBefore))
+		}
+		if f.ExpiresAfter != "" {
+			params = append(params, fmt.Sprintf("expires_after:%q", f.ExpiresAfter))
+		}
+		if f.FilterQuery != "" {
+			params = append(params, f.FilterQuery)
+		}
+
+		q := r.URL.Query()
+		q.Set("q", strings.Join(params, " "))
+		r.URL.RawQuery = q.Encode()
+	}
 }
 
 // GenerateAPIKeyResponse contains an API key for a user.
@@ -48,7 +117,7 @@ type GenerateAPIKeyResponse struct {
 	Key string `json:"key"`
 }
 
-// CreateToken generates an API key that doesn't expire.
+// CreateToken generates an API key that lasts for the requested lifetime.
 func (c *Client) CreateToken(ctx context.Context, userID string, req CreateTokenRequest) (GenerateAPIKeyResponse, error) {
 	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/keys/tokens", userID), req)
 	if err != nil {
@@ -119,3 +188,31 @@ func (c *Client) DeleteAPIKey(ctx context.Context, userID string, id string) err
 	}
 	return nil
 }
+
+// APIKeys lists the API keys of every user that match the filter. Only site
+// owners can list the keys of other users.
+func (c *Client) APIKeys(ctx context.Context, filter APIKeysFilter) ([]APIKeyWithOwner, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/users/keys", nil, filter.asRequestOption())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var apiKeys []APIKeyWithOwner
+	return apiKeys, json.NewDecoder(res.Body).Decode(&apiKeys)
+}
+
+// RevokeAPIKey deletes an API key by ID, regardless of the user that owns it.
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/keys/%s", id), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ const (
 	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
 	ResourceTypeAPIKey          ResourceType = "api_key"
 	ResourceTypeGroup           ResourceType = "group"
+	ResourceTypeUserTwoFactor   ResourceType = "user_two_factor"
 )
 
 func (r ResourceType) FriendlyString() string {
@@ -45,6 +46,8 @@ func (r ResourceType) FriendlyString() string {
 		return "api key"
 	case ResourceTypeGroup:
 		return "group"
+	case ResourceTypeUserTwoFactor:
+		return "two-factor authentication"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,74 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// DebugCoordinator is the state of the tailnet coordinator of the replica
+// that served the request.
+type DebugCoordinator struct {
+	// ReplicaID is uuid.Nil unless high availability is enabled.
+	ReplicaID uuid.UUID               `json:"replica_id"`
+	Agents    []DebugCoordinatorAgent `json:"agents"`
+}
+
+// DebugCoordinatorAgent is an agent connected to the coordinator, or that
+// clients connected to it want to reach.
+type DebugCoordinatorAgent struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	WorkspaceID   uuid.UUID `json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	OwnerName     string    `json:"owner_name"`
+	// Connected is whether the agent is connected to this replica.
+	Connected bool `json:"connected"`
+	// ReplicaID is the replica the agent is connected to, if it's known.
+	ReplicaID uuid.UUID `json:"replica_id"`
+	// Node is nil if the agent never registered with the coordinator.
+	Node *DebugNode `json:"node,omitempty"`
+	// Clients are the clients connected to this replica that want to reach
+	// the agent.
+	Clients []DebugCoordinatorClient `json:"clients"`
+}
+
+// DebugCoordinatorClient is a client that wants to reach an agent.
+type DebugCoordinatorClient struct {
+	ID   uuid.UUID  `json:"id"`
+	Node *DebugNode `json:"node,omitempty"`
+}
+
+// DebugNode is how a peer may be reached on the tailnet.
+type DebugNode struct {
+	// AsOf is when the peer last updated its node.
+	AsOf time.Time `json:"as_of"`
+	// PreferredDERP is the ID of the DERP region peers meet the peer at.
+	PreferredDERP int `json:"preferred_derp"`
+	// DERPLatency is the latency in seconds of the peer to each DERP
+	// server.
+	DERPLatency map[string]float64 `json:"derp_latency"`
+	Addresses   []string           `json:"addresses"`
+	// Endpoints are the ip:port combinations peers may reach the peer at
+	// directly.
+	Endpoints []string `json:"endpoints"`
+}
+
+// DebugCoordinator returns the agents and clients connected to the tailnet
+// coordinator.
+func (c *Client) DebugCoordinator(ctx context.Context) (DebugCoordinator, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/debug/coordinator", nil)
+	if err != nil {
+		return DebugCoordinator{}, xerrors.Errorf("get coordinator debug info: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return DebugCoordinator{}, readBodyAsError(res)
+	}
+	var debug DebugCoordinator
+	return debug, json.NewDecoder(res.Body).Decode(&debug)
+}
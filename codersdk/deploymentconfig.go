@@ -24,16 +24,25 @@ type DeploymentConfig struct {
 	CacheDirectory              *DeploymentConfigField[string]          `json:"cache_directory" typescript:",notnull"`
 	InMemoryDatabase            *DeploymentConfigField[bool]            `json:"in_memory_database" typescript:",notnull"`
 	PostgresURL                 *DeploymentConfigField[string]          `json:"pg_connection_url" typescript:",notnull"`
+	Pubsub                      *PubsubConfig                           `json:"pubsub" typescript:",notnull"`
 	OAuth2                      *OAuth2Config                           `json:"oauth2" typescript:",notnull"`
 	OIDC                        *OIDCConfig                             `json:"oidc" typescript:",notnull"`
+	LDAP                        *LDAPConfig                             `json:"ldap" typescript:",notnull"`
+	SMTP                        *SMTPConfig                             `json:"smtp" typescript:",notnull"`
+	NotificationWebhookURL      *DeploymentConfigField[string]          `json:"notification_webhook_url" typescript:",notnull"`
 	Telemetry                   *TelemetryConfig                        `json:"telemetry" typescript:",notnull"`
 	TLS                         *TLSConfig                              `json:"tls" typescript:",notnull"`
 	Trace                       *TraceConfig                            `json:"trace" typescript:",notnull"`
 	SecureAuthCookie            *DeploymentConfigField[bool]            `json:"secure_auth_cookie" typescript:",notnull"`
+	TwoFactorRequired           *DeploymentConfigField[bool]            `json:"two_factor_required" typescript:",notnull"`
+	UserInvitationLifetime      *DeploymentConfigField[time.Duration]   `json:"user_invitation_lifetime" typescript:",notnull"`
+	PasswordResetLifetime       *DeploymentConfigField[time.Duration]   `json:"password_reset_lifetime" typescript:",notnull"`
+	MaxTokenLifetime            *DeploymentConfigField[time.Duration]   `json:"max_token_lifetime" typescript:",notnull"`
 	SSHKeygenAlgorithm          *DeploymentConfigField[string]          `json:"ssh_keygen_algorithm" typescript:",notnull"`
 	AutoImportTemplates         *DeploymentConfigField[[]string]        `json:"auto_import_templates" typescript:",notnull"`
 	MetricsCacheRefreshInterval *DeploymentConfigField[time.Duration]   `json:"metrics_cache_refresh_interval" typescript:",notnull"`
 	AgentStatRefreshInterval    *DeploymentConfigField[time.Duration]   `json:"agent_stat_refresh_interval" typescript:",notnull"`
+	AgentConnectionCacheSize    *DeploymentConfigField[int]             `json:"agent_connection_cache_size" typescript:",notnull"`
 	AuditLogging                *DeploymentConfigField[bool]            `json:"audit_logging" typescript:",notnull"`
 	BrowserOnly                 *DeploymentConfigField[bool]            `json:"browser_only" typescript:",notnull"`
 	SCIMAPIKey                  *DeploymentConfigField[string]          `json:"scim_api_key" typescript:",notnull"`
@@ -58,8 +67,9 @@ type DERPServerConfig struct {
 }
 
 type DERPConfig struct {
-	URL  *DeploymentConfigField[string] `json:"url" typescript:",notnull"`
-	Path *DeploymentConfigField[string] `json:"path" typescript:",notnull"`
+	URL         *DeploymentConfigField[string] `json:"url" typescript:",notnull"`
+	Path        *DeploymentConfigField[string] `json:"path" typescript:",notnull"`
+	BlockDirect *DeploymentConfigField[bool]   `json:"block_direct" typescript:",notnull"`
 }
 
 type PrometheusConfig struct {
@@ -72,6 +82,11 @@ type PprofConfig struct {
 	Address *DeploymentConfigField[string] `json:"address" typescript:",notnull"`
 }
 
+type PubsubConfig struct {
+	Backend  *DeploymentConfigField[string] `json:"backend" typescript:",notnull"`
+	RedisURL *DeploymentConfigField[string] `json:"redis_url" typescript:",notnull"`
+}
+
 type OAuth2Config struct {
 	Github *OAuth2GithubConfig `json:"github" typescript:",notnull"`
 }
@@ -94,6 +109,30 @@ type OIDCConfig struct {
 	Scopes       *DeploymentConfigField[[]string] `json:"scopes" typescript:",notnull"`
 }
 
+type LDAPConfig struct {
+	URL                *DeploymentConfigField[string] `json:"url" typescript:",notnull"`
+	StartTLS           *DeploymentConfigField[bool]   `json:"start_tls" typescript:",notnull"`
+	InsecureSkipVerify *DeploymentConfigField[bool]   `json:"insecure_skip_verify" typescript:",notnull"`
+	BindDN             *DeploymentConfigField[string] `json:"bind_dn" typescript:",notnull"`
+	BindPassword       *DeploymentConfigField[string] `json:"bind_password" typescript:",notnull"`
+	SearchBaseDN       *DeploymentConfigField[string] `json:"search_base_dn" typescript:",notnull"`
+	SearchFilter       *DeploymentConfigField[string] `json:"search_filter" typescript:",notnull"`
+	UsernameAttribute  *DeploymentConfigField[string] `json:"username_attribute" typescript:",notnull"`
+	EmailAttribute     *DeploymentConfigField[string] `json:"email_attribute" typescript:",notnull"`
+	GroupAttribute     *DeploymentConfigField[string] `json:"group_attribute" typescript:",notnull"`
+	GroupSync          *DeploymentConfigField[bool]   `json:"group_sync" typescript:",notnull"`
+	AllowSignups       *DeploymentConfigField[bool]   `json:"allow_signups" typescript:",notnull"`
+}
+
+type SMTPConfig struct {
+	Address            *DeploymentConfigField[string] `json:"address" typescript:",notnull"`
+	From               *DeploymentConfigField[string] `json:"from" typescript:",notnull"`
+	Username           *DeploymentConfigField[string] `json:"username" typescript:",notnull"`
+	Password           *DeploymentConfigField[string] `json:"password" typescript:",notnull"`
+	ForceTLS           *DeploymentConfigField[bool]   `json:"force_tls" typescript:",notnull"`
+	InsecureSkipVerify *DeploymentConfigField[bool]   `json:"insecure_skip_verify" typescript:",notnull"`
+}
+
 type TelemetryConfig struct {
 	Enable *DeploymentConfigField[bool]   `json:"enable" typescript:",notnull"`
 	Trace  *DeploymentConfigField[bool]   `json:"trace" typescript:",notnull"`
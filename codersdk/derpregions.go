@@ -0,0 +1,265 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"tailscale.com/tailcfg"
+
+	"github.com/coder/coder/coderd/tracing"
+)
+
+// DERPMapRegion is a region of the DERP map of the deployment.
+type DERPMapRegion struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Managed is true for regions added through the API. Regions of the
+	// static DERP map can't be changed through the API.
+	Managed bool `json:"managed"`
+	// Disabled regions are left out of the DERP map.
+	Disabled  bool             `json:"disabled"`
+	Nodes     []DERPMapNode    `json:"nodes"`
+	Health    DERPRegionHealth `json:"health"`
+	CreatedAt time.Time        `json:"created_at,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at,omitempty"`
+}
+
+// DERPMapNode is a server of a DERP region.
+type DERPMapNode struct {
+	Name     string `json:"name"`
+	RegionID int    `json:"region_id"`
+	HostName string `json:"host_name"`
+	IPv4     string `json:"ipv4,omitempty"`
+	IPv6     string `json:"ipv6,omitempty"`
+	DERPPort int    `json:"derp_port,omitempty"`
+	// STUNPort is the STUN port of the node. 0 uses the default port, and
+	// -1 disables STUN.
+	STUNPort  int            `json:"stun_port,omitempty"`
+	STUNOnly  bool           `json:"stun_only"`
+	ForceHTTP bool           `json:"force_http"`
+	Disabled  bool           `json:"disabled"`
+	Health    DERPNodeHealth `json:"health"`
+}
+
+// DERPRegionHealth is the result of the last health check of a region by
+// the replica that served the request. A region is healthy when any of its
+// DERP nodes is.
+type DERPRegionHealth struct {
+	Healthy bool `json:"healthy"`
+	// CheckedAt is zero until the region was checked.
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// DERPNodeHealth is the result of the last health check of a node. Nodes
+// that only serve STUN aren't checked.
+type DERPNodeHealth struct {
+	Healthy             bool      `json:"healthy"`
+	CheckedAt           time.Time `json:"checked_at"`
+	LatencyMilliseconds float64   `json:"latency_ms"`
+	Error               string    `json:"error,omitempty"`
+}
+
+type CreateDERPRegionRequest struct {
+	// ID must not be used by a region of the static DERP map.
+	ID       int    `json:"id" validate:"required,min=1"`
+	Code     string `json:"code" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Disabled bool   `json:"disabled"`
+}
+
+// UpdateDERPRegionRequest changes a region. Fields that are unset are left
+// unchanged.
+type UpdateDERPRegionRequest struct {
+	Code     string `json:"code,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Disabled *bool  `json:"disabled,omitempty"`
+}
+
+type CreateDERPNodeRequest struct {
+	// Name must be unique across all regions.
+	Name      string `json:"name" validate:"required"`
+	HostName  string `json:"host_name" validate:"required"`
+	IPv4      string `json:"ipv4,omitempty"`
+	IPv6      string `json:"ipv6,omitempty"`
+	DERPPort  int    `json:"derp_port,omitempty" validate:"min=0,max=65535"`
+	STUNPort  int    `json:"stun_port,omitempty" validate:"min=-1,max=65535"`
+	STUNOnly  bool   `json:"stun_only"`
+	ForceHTTP bool   `json:"force_http"`
+	Disabled  bool   `json:"disabled"`
+}
+
+// UpdateDERPNodeRequest changes a node. Fields that are unset are left
+// unchanged.
+type UpdateDERPNodeRequest struct {
+	HostName  string  `json:"host_name,omitempty"`
+	IPv4      *string `json:"ipv4,omitempty"`
+	IPv6      *string `json:"ipv6,omitempty"`
+	DERPPort  *int    `json:"derp_port,omitempty" validate:"omitempty,min=0,max=65535"`
+	STUNPort  *int    `json:"stun_port,omitempty" validate:"omitempty,min=-1,max=65535"`
+	STUNOnly  *bool   `json:"stun_only,omitempty"`
+	ForceHTTP *bool   `json:"force_http,omitempty"`
+	Disabled  *bool   `json:"disabled,omitempty"`
+}
+
+// DERPRegions returns the regions of the DERP map, including disabled ones.
+func (c *Client) DERPRegions(ctx context.Context) ([]DERPMapRegion, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/derp/regions", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var regions []DERPMapRegion
+	return regions, json.NewDecoder(res.Body).Decode(&regions)
+}
+
+func (c *Client) DERPRegion(ctx context.Context, id int) (DERPMapRegion, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/derp/regions/%d", id), nil)
+	if err != nil {
+		return DERPMapRegion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return DERPMapRegion{}, readBodyAsError(res)
+	}
+	var region DERPMapRegion
+	return region, json.NewDecoder(res.Body).Decode(&region)
+}
+
+func (c *Client) CreateDERPRegion(ctx context.Context, req CreateDERPRegionRequest) (DERPMapRegion, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/derp/regions", req)
+	if err != nil {
+		return DERPMapRegion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return DERPMapRegion{}, readBodyAsError(res)
+	}
+	var region DERPMapRegion
+	return region, json.NewDecoder(res.Body).Decode(&region)
+}
+
+func (c *Client) UpdateDERPRegion(ctx context.Context, id int, req UpdateDERPRegionRequest) (DERPMapRegion, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/derp/regions/%d", id), req)
+	if err != nil {
+		return DERPMapRegion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return DERPMapRegion{}, readBodyAsError(res)
+	}
+	var region DERPMapRegion
+	return region, json.NewDecoder(res.Body).Decode(&region)
+}
+
+// DeleteDERPRegion deletes a region along with its nodes.
+func (c *Client) DeleteDERPRegion(ctx context.Context, id int) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/derp/regions/%d", id), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+func (c *Client) CreateDERPNode(ctx context.Context, regionID int, req CreateDERPNodeRequest) (DERPMapNode, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/derp/regions/%d/nodes", regionID), req)
+	if err != nil {
+		return DERPMapNode{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return DERPMapNode{}, readBodyAsError(res)
+	}
+	var node DERPMapNode
+	return node, json.NewDecoder(res.Body).Decode(&node)
+}
+
+func (c *Client) UpdateDERPNode(ctx context.Context, regionID int, name string, req UpdateDERPNodeRequest) (DERPMapNode, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/derp/regions/%d/nodes/%s", regionID, name), req)
+	if err != nil {
+		return DERPMapNode{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return DERPMapNode{}, readBodyAsError(res)
+	}
+	var node DERPMapNode
+	return node, json.NewDecoder(res.Body).Decode(&node)
+}
+
+func (c *Client) DeleteDERPNode(ctx context.Context, regionID int, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/derp/regions/%d/nodes/%s", regionID, name), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WatchWorkspaceAgentDERPMap streams the DERP map used to connect to an
+// agent. The current map is sent first, followed by every change. The
+// channel is closed when ctx is canceled or the connection is lost.
+func (c *Client) WatchWorkspaceAgentDERPMap(ctx context.Context, agentID uuid.UUID) (<-chan *tailcfg.DERPMap, error) {
+	return c.watchDERPMap(ctx, fmt.Sprintf("/api/v2/workspaceagents/%s/derp-map", agentID))
+}
+
+func (c *Client) watchDERPMap(ctx context.Context, path string) (<-chan *tailcfg.DERPMap, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	//nolint:bodyclose
+	res, err := c.Request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, readBodyAsError(res)
+	}
+	nextEvent := ServerSentEventReader(ctx, res.Body)
+
+	maps := make(chan *tailcfg.DERPMap, 1)
+	go func() {
+		defer close(maps)
+		defer res.Body.Close()
+
+		for {
+			sse, err := nextEvent()
+			if err != nil {
+				return
+			}
+			if sse.Type != ServerSentEventTypeData {
+				continue
+			}
+			b, ok := sse.Data.([]byte)
+			if !ok {
+				return
+			}
+			var derpMap tailcfg.DERPMap
+			err = json.Unmarshal(b, &derpMap)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case maps <- &derpMap:
+			}
+		}
+	}()
+	return maps, nil
+}
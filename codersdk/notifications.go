@@ -0,0 +1,140 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationKind string
+
+const (
+	NotificationKindWorkspaceAutostop        NotificationKind = "workspace_autostop"
+	NotificationKindWorkspaceAutostartFailed NotificationKind = "workspace_autostart_failed"
+	NotificationKindWorkspaceBuildFailed     NotificationKind = "workspace_build_failed"
+	NotificationKindWorkspaceDormant         NotificationKind = "workspace_dormant"
+	NotificationKindWorkspaceUpdateFailed    NotificationKind = "workspace_update_failed"
+	NotificationKindWorkspaceTransfer        NotificationKind = "workspace_transfer"
+)
+
+// Notification is an event about the workspaces of a user, e.g. a workspace
+// that is about to be stopped automatically.
+type Notification struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"user_id"`
+	WorkspaceID *uuid.UUID       `json:"workspace_id,omitempty"`
+	Kind        NotificationKind `json:"kind"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	CreatedAt   time.Time        `json:"created_at"`
+	// ReadAt is nil if the notification is unread.
+	ReadAt *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationsFilter filters the notifications in the inbox of a user.
+type NotificationsFilter struct {
+	// Unread only returns notifications that haven't been read.
+	Unread bool `json:"unread,omitempty"`
+	// Limit is the maximum number of notifications returned, most recent
+	// first. Zero returns every notification.
+	Limit int `json:"limit,omitempty"`
+}
+
+// asRequestOption returns a function that can be used in (*Client).Request.
+// It modifies the request query parameters.
+func (f NotificationsFilter) asRequestOption() RequestOption {
+	return func(r *http.Request) {
+		q := r.URL.Query()
+		if f.Unread {
+			q.Set("unread", "true")
+		}
+		if f.Limit > 0 {
+			q.Set("limit", strconv.Itoa(f.Limit))
+		}
+		r.URL.RawQuery = q.Encode()
+	}
+}
+
+// MarkNotificationsReadRequest marks notifications in the inbox of a user
+// as read.
+type MarkNotificationsReadRequest struct {
+	// IDs of the notifications to mark read. Every notification is marked
+	// read if empty.
+	IDs []uuid.UUID `json:"ids,omitempty"`
+}
+
+// NotificationPreference is the channels a user receives notifications of
+// a kind through.
+type NotificationPreference struct {
+	Kind    NotificationKind `json:"kind" validate:"required"`
+	Inbox   bool             `json:"inbox"`
+	Email   bool             `json:"email"`
+	Webhook bool             `json:"webhook"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"dive"`
+}
+
+// Notifications returns the notifications in the inbox of a user, most
+// recent first.
+func (c *Client) Notifications(ctx context.Context, user string, filter NotificationsFilter) ([]Notification, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/notifications", user), nil, filter.asRequestOption())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var notifications []Notification
+	return notifications, json.NewDecoder(res.Body).Decode(&notifications)
+}
+
+// MarkNotificationsRead marks notifications in the inbox of a user as read.
+func (c *Client) MarkNotificationsRead(ctx context.Context, user string, req MarkNotificationsReadRequest) error {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/notifications/read", user), req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// NotificationPreferences returns the preferences of a user for every kind
+// of notification.
+func (c *Client) NotificationPreferences(ctx context.Context, user string) ([]NotificationPreference, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/notifications/preferences", user), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var preferences []NotificationPreference
+	return preferences, json.NewDecoder(res.Body).Decode(&preferences)
+}
+
+// UpdateNotificationPreferences updates the preferences of a user for the
+// given kinds of notifications, and returns the preferences for every kind.
+func (c *Client) UpdateNotificationPreferences(ctx context.Context, user string, req UpdateNotificationPreferencesRequest) ([]NotificationPreference, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/notifications/preferences", user), req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var preferences []NotificationPreference
+	return preferences, json.NewDecoder(res.Body).Decode(&preferences)
+}
@@ -71,6 +71,27 @@ type CreateTemplateRequest struct {
 	// DefaultTTLMillis allows optionally specifying the default TTL
 	// for all workspaces created from this template.
 	DefaultTTLMillis *int64 `json:"default_ttl_ms,omitempty"`
+
+	// The remaining fields optionally set the scheduling policy of the
+	// template. See TemplateSchedulePolicy.
+	MaxTTLMillis                *int64  `json:"max_ttl_ms,omitempty"`
+	MinAutostartIntervalMillis  *int64  `json:"min_autostart_interval_ms,omitempty"`
+	QuietHoursSchedule          *string `json:"quiet_hours_schedule,omitempty"`
+	AllowUserAutostop           *bool   `json:"allow_user_autostop,omitempty"`
+	InactivityTTLMillis         *int64  `json:"inactivity_ttl_ms,omitempty"`
+	DormancyDeletionTTLMillis   *int64  `json:"dormancy_deletion_ttl_ms,omitempty"`
+	ActivityBumpMillis          *int64  `json:"activity_bump_ms,omitempty"`
+	ActivityBumpThresholdMillis *int64  `json:"activity_bump_threshold_ms,omitempty"`
+	BuildRetries                *int    `json:"build_retries,omitempty"`
+	BuildRetryBackoffMillis     *int64  `json:"build_retry_backoff_ms,omitempty"`
+	RollbackFailedUpdates       *bool   `json:"rollback_failed_updates,omitempty"`
+
+	// RequireActiveVersion makes workspaces always start on the active
+	// version of the template.
+	RequireActiveVersion bool `json:"require_active_version,omitempty"`
+	// AllowWorkspaceNetworking lets agents of workspaces created from the
+	// template connect to the other workspaces of their owner.
+	AllowWorkspaceNetworking bool `json:"allow_workspace_networking,omitempty"`
 }
 
 // CreateWorkspaceRequest provides options for creating a new workspace.
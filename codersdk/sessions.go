@@ -0,0 +1,65 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Session is an API key created by logging in with the browser or CLI, as
+// opposed to a token.
+type Session struct {
+	ID        string      `json:"id"`
+	LoginType LoginType   `json:"login_type"`
+	Scope     APIKeyScope `json:"scope"`
+	IPAddress string      `json:"ip_address"`
+	UserAgent string      `json:"user_agent"`
+	CreatedAt time.Time   `json:"created_at"`
+	LastUsed  time.Time   `json:"last_used"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	// Current is true for the session that made the request.
+	Current bool `json:"current"`
+}
+
+// Sessions lists the unexpired sessions of a user, most recently used first.
+func (c *Client) Sessions(ctx context.Context, user string) ([]Session, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/sessions", user), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var sessions []Session
+	return sessions, json.NewDecoder(res.Body).Decode(&sessions)
+}
+
+// DeleteSession logs out a single session of a user.
+func (c *Client) DeleteSession(ctx context.Context, user string, id string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/%s/sessions/%s", user, id), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// DeleteSessions logs out every session of a user, except the session making
+// the request.
+func (c *Client) DeleteSessions(ctx context.Context, user string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/%s/sessions", user), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -31,6 +31,53 @@ type Template struct {
 	DefaultTTLMillis int64                  `json:"default_ttl_ms"`
 	CreatedByID      uuid.UUID              `json:"created_by_id"`
 	CreatedByName    string                 `json:"created_by_name"`
+	// RequireActiveVersion is whether workspaces are always started on the
+	// active version of the template.
+	RequireActiveVersion bool `json:"require_active_version"`
+	// AllowWorkspaceNetworking is whether agents of workspaces created from
+	// the template may connect to the other workspaces of their owner.
+	AllowWorkspaceNetworking bool `json:"allow_workspace_networking"`
+	TemplateSchedulePolicy
+}
+
+// TemplateSchedulePolicy is the scheduling policy a template enforces on
+// the workspaces created from it.
+type TemplateSchedulePolicy struct {
+	// MaxTTLMillis is the longest a workspace may run before it is stopped,
+	// regardless of its TTL and activity. Zero means no limit.
+	MaxTTLMillis int64 `json:"max_ttl_ms"`
+	// MinAutostartIntervalMillis is the shortest interval allowed between
+	// two autostarts of a workspace. Zero means no limit.
+	MinAutostartIntervalMillis int64 `json:"min_autostart_interval_ms"`
+	// QuietHoursSchedule is a cron schedule, e.g. "CRON_TZ=Europe/London 0 2 * * *",
+	// at which running workspaces are stopped.
+	QuietHoursSchedule string `json:"quiet_hours_schedule"`
+	// AllowUserAutostop is whether users may disable autostop for their
+	// workspaces.
+	AllowUserAutostop bool `json:"allow_user_autostop"`
+	// InactivityTTLMillis is how long a workspace may go unused before it is
+	// marked dormant and stopped. Zero disables dormancy.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms"`
+	// DormancyDeletionTTLMillis is how long a workspace may stay dormant
+	// before it is deleted. Zero disables automatic deletion.
+	DormancyDeletionTTLMillis int64 `json:"dormancy_deletion_ttl_ms"`
+	// ActivityBumpMillis is how far the deadline of a running workspace is
+	// pushed back while it has active sessions. Zero disables activity
+	// bumping.
+	ActivityBumpMillis int64 `json:"activity_bump_ms"`
+	// ActivityBumpThresholdMillis is how close the deadline must be before
+	// activity pushes it back.
+	ActivityBumpThresholdMillis int64 `json:"activity_bump_threshold_ms"`
+	// BuildRetries is how many times a failed start build of a workspace is
+	// retried.
+	BuildRetries int `json:"build_retries"`
+	// BuildRetryBackoffMillis is the delay before the first retry of a
+	// failed start build. It doubles with every retry.
+	BuildRetryBackoffMillis int64 `json:"build_retry_backoff_ms"`
+	// RollbackFailedUpdates is whether workspaces are rolled back to the
+	// template version and parameters of their last successful build when an
+	// update fails and can't be retried.
+	RollbackFailedUpdates bool `json:"rollback_failed_updates"`
 }
 
 type TemplateBuildTimeStats struct {
@@ -77,6 +124,22 @@ type UpdateTemplateMeta struct {
 	Description      string `json:"description,omitempty"`
 	Icon             string `json:"icon,omitempty"`
 	DefaultTTLMillis int64  `json:"default_ttl_ms,omitempty"`
+	// The scheduling policy is left unchanged for nil fields.
+	MaxTTLMillis                *int64  `json:"max_ttl_ms,omitempty"`
+	MinAutostartIntervalMillis  *int64  `json:"min_autostart_interval_ms,omitempty"`
+	QuietHoursSchedule          *string `json:"quiet_hours_schedule,omitempty"`
+	AllowUserAutostop           *bool   `json:"allow_user_autostop,omitempty"`
+	InactivityTTLMillis         *int64  `json:"inactivity_ttl_ms,omitempty"`
+	DormancyDeletionTTLMillis   *int64  `json:"dormancy_deletion_ttl_ms,omitempty"`
+	ActivityBumpMillis          *int64  `json:"activity_bump_ms,omitempty"`
+	ActivityBumpThresholdMillis *int64  `json:"activity_bump_threshold_ms,omitempty"`
+	BuildRetries                *int    `json:"build_retries,omitempty"`
+	BuildRetryBackoffMillis     *int64  `json:"build_retry_backoff_ms,omitempty"`
+	RollbackFailedUpdates       *bool   `json:"rollback_failed_updates,omitempty"`
+	// RequireActiveVersion is left unchanged if nil.
+	RequireActiveVersion *bool `json:"require_active_version,omitempty"`
+	// AllowWorkspaceNetworking is left unchanged if nil.
+	AllowWorkspaceNetworking *bool `json:"allow_workspace_networking,omitempty"`
 }
 
 // Template returns a single template.
@@ -234,4 +297,16 @@ type AgentStatsReportResponse struct {
 	RxBytes int64 `json:"rx_bytes"`
 	// TxBytes is the number of received bytes.
 	TxBytes int64 `json:"tx_bytes"`
+	// SessionCountSSH is the number of open SSH connections.
+	SessionCountSSH int64 `json:"session_count_ssh"`
+	// SessionCountReconnectingPTY is the number of open web terminals.
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	// SessionCountApp is the number of open connections to apps and
+	// forwarded ports.
+	SessionCountApp int64 `json:"session_count_app"`
+}
+
+// SessionCount returns the number of sessions open on the agent.
+func (r AgentStatsReportResponse) SessionCount() int64 {
+	return r.SessionCountSSH + r.SessionCountReconnectingPTY + r.SessionCountApp
 }
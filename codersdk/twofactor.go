@@ -0,0 +1,103 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// UserTwoFactor is the two-factor authentication status of a user.
+type UserTwoFactor struct {
+	// Enabled is true once enrollment has been verified with a code.
+	Enabled                bool      `json:"enabled"`
+	RecoveryCodesRemaining int       `json:"recovery_codes_remaining"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// UserTwoFactorEnrollment is returned when a user begins enrolling in
+// two-factor authentication. The secret and recovery codes are only ever
+// returned once.
+type UserTwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	// URL is an "otpauth://" URL that authenticator apps can import,
+	// usually by scanning it as a QR code.
+	URL           string   `json:"url"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type VerifyUserTwoFactorRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// ResetUserTwoFactorRequest proves that users removing two-factor
+// authentication from their own account are in control of it. Either a
+// code or the password is required. Admins resetting other users leave
+// both empty.
+type ResetUserTwoFactorRequest struct {
+	// Code is a TOTP or recovery code.
+	Code     string `json:"code,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// UserTwoFactor returns the two-factor authentication status of a user.
+func (c *Client) UserTwoFactor(ctx context.Context, user string) (UserTwoFactor, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/twofactor", user), nil)
+	if err != nil {
+		return UserTwoFactor{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UserTwoFactor{}, readBodyAsError(res)
+	}
+	var twoFactor UserTwoFactor
+	return twoFactor, json.NewDecoder(res.Body).Decode(&twoFactor)
+}
+
+// EnrollUserTwoFactor generates a new TOTP secret and recovery codes for the
+// user. Two-factor authentication isn't enforced until the enrollment is
+// verified with VerifyUserTwoFactor.
+func (c *Client) EnrollUserTwoFactor(ctx context.Context, user string) (UserTwoFactorEnrollment, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/twofactor", user), nil)
+	if err != nil {
+		return UserTwoFactorEnrollment{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return UserTwoFactorEnrollment{}, readBodyAsError(res)
+	}
+	var enrollment UserTwoFactorEnrollment
+	return enrollment, json.NewDecoder(res.Body).Decode(&enrollment)
+}
+
+// VerifyUserTwoFactor completes enrollment with a code generated from the
+// secret.
+func (c *Client) VerifyUserTwoFactor(ctx context.Context, user string, req VerifyUserTwoFactorRequest) (UserTwoFactor, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/twofactor/verify", user), req)
+	if err != nil {
+		return UserTwoFactor{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UserTwoFactor{}, readBodyAsError(res)
+	}
+	var twoFactor UserTwoFactor
+	return twoFactor, json.NewDecoder(res.Body).Decode(&twoFactor)
+}
+
+// ResetUserTwoFactor removes two-factor authentication from a user so they
+// can enroll again.
+func (c *Client) ResetUserTwoFactor(ctx context.Context, user string, req ResetUserTwoFactorRequest) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/%s/twofactor", user), req)
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// UserInvitation is a pending invitation for someone to sign up. The signup
+// token is only ever sent to the email address invited.
+type UserInvitation struct {
+	ID             string    `json:"id"`
+	Email          string    `json:"email"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type CreateUserInvitationRequest struct {
+	Email          string    `json:"email" validate:"required,email"`
+	OrganizationID uuid.UUID `json:"organization_id" validate:"required"`
+}
+
+// AcceptUserInvitationRequest creates a user with the email the invitation
+// was sent to.
+type AcceptUserInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Username string `json:"username" validate:"required,username"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ForgotPasswordRequest emails a password reset token to the user with the
+// email provided, if one exists.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// CreateUserInvitation emails a signup link to the address provided.
+func (c *Client) CreateUserInvitation(ctx context.Context, req CreateUserInvitationRequest) (UserInvitation, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/users/invitations", req)
+	if err != nil {
+		return UserInvitation{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return UserInvitation{}, readBodyAsError(res)
+	}
+	var invitation UserInvitation
+	return invitation, json.NewDecoder(res.Body).Decode(&invitation)
+}
+
+// UserInvitations returns the invitations that haven't been accepted yet.
+func (c *Client) UserInvitations(ctx context.Context) ([]UserInvitation, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/users/invitations", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var invitations []UserInvitation
+	return invitations, json.NewDecoder(res.Body).Decode(&invitations)
+}
+
+// DeleteUserInvitation revokes an invitation.
+func (c *Client) DeleteUserInvitation(ctx context.Context, id string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/invitations/%s", id), nil)
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// AcceptUserInvitation creates the invited user and returns a session token
+// for them. It doesn't require authentication.
+func (c *Client) AcceptUserInvitation(ctx context.Context, req AcceptUserInvitationRequest) (LoginWithPasswordResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/users/invitations/accept", req)
+	if err != nil {
+		return LoginWithPasswordResponse{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return LoginWithPasswordResponse{}, readBodyAsError(res)
+	}
+	var resp LoginWithPasswordResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// ForgotPassword requests a password reset token to be emailed. It succeeds
+// whether or not a user with the email exists.
+func (c *Client) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/users/forgot-password", req)
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// ResetPassword changes the password of a user with a token from a
+// password reset email.
+func (c *Client) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/users/reset-password", req)
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -103,11 +103,18 @@ type UserRoles struct {
 type LoginWithPasswordRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// TwoFactorCode is a TOTP or recovery code. It's required for users that
+	// have enrolled in two-factor authentication.
+	TwoFactorCode string `json:"two_factor_code,omitempty"`
 }
 
 // LoginWithPasswordResponse contains a session token for the newly authenticated user.
 type LoginWithPasswordResponse struct {
 	SessionToken string `json:"session_token" validate:"required"`
+	// TwoFactorEnrollmentRequired is true when the deployment requires
+	// two-factor authentication and the user hasn't enrolled yet. The session
+	// token can only be used to enroll until the user logs in again.
+	TwoFactorEnrollmentRequired bool `json:"two_factor_enrollment_required,omitempty"`
 }
 
 type CreateOrganizationRequest struct {
@@ -119,6 +126,7 @@ type AuthMethods struct {
 	Password bool `json:"password"`
 	Github   bool `json:"github"`
 	OIDC     bool `json:"oidc"`
+	LDAP     bool `json:"ldap"`
 }
 
 // HasFirstUser returns whether the first user has been created.
@@ -113,6 +113,9 @@ type WorkspaceAgentAuthenticateResponse struct {
 // @typescript-ignore WorkspaceAgentConnectionInfo
 type WorkspaceAgentConnectionInfo struct {
 	DERPMap *tailcfg.DERPMap `json:"derp_map"`
+	// DisableDirectConnections is true when the deployment only allows
+	// connections relayed through DERP.
+	DisableDirectConnections bool `json:"disable_direct_connections"`
 }
 
 // @typescript-ignore PostWorkspaceAgentVersionRequest
@@ -132,6 +135,25 @@ type WorkspaceAgentMetadata struct {
 	EnvironmentVariables map[string]string `json:"environment_variables"`
 	StartupScript        string            `json:"startup_script"`
 	Directory            string            `json:"directory"`
+	// DisableDirectConnections is true when the deployment only allows
+	// connections relayed through DERP.
+	DisableDirectConnections bool `json:"disable_direct_connections"`
+	// AgentID is the ID of the authenticated agent. Together with
+	// WorkspaceAgentIP it gives the agent its address for workspace
+	// networking.
+	AgentID uuid.UUID `json:"agent_id"`
+	// WorkspaceNetworking is true when the template of the workspace allows
+	// its agents to dial the other workspaces of the owner.
+	WorkspaceNetworking bool `json:"workspace_networking"`
+}
+
+// WorkspaceAgentPeer is an agent that a workspace agent can dial through
+// workspace networking, at <workspace>.<agent>.coder.
+type WorkspaceAgentPeer struct {
+	WorkspaceID   uuid.UUID `json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	AgentID       uuid.UUID `json:"agent_id"`
+	AgentName     string    `json:"agent_name"`
 }
 
 // AuthWorkspaceGoogleInstanceIdentity uses the Google Compute Engine Metadata API to
@@ -277,6 +299,48 @@ func (c *Client) WorkspaceAgentMetadata(ctx context.Context) (WorkspaceAgentMeta
 	if err != nil {
 		return WorkspaceAgentMetadata{}, err
 	}
+	err = c.rewriteEmbeddedDERP(agentMetadata.DERPMap)
+	if err != nil {
+		return WorkspaceAgentMetadata{}, err
+	}
+	return agentMetadata, nil
+}
+
+// WorkspaceAgentWatchDERPMap streams the DERP map of the deployment to an
+// agent. The current map is sent first, followed by every change. The
+// channel is closed when ctx is canceled or the connection is lost.
+func (c *Client) WorkspaceAgentWatchDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	maps, err := c.watchDERPMap(ctx, "/api/v2/workspaceagents/me/derp-map")
+	if err != nil {
+		return nil, err
+	}
+	rewritten := make(chan *tailcfg.DERPMap, 1)
+	go func() {
+		defer close(rewritten)
+		for derpMap := range maps {
+			if c.rewriteEmbeddedDERP(derpMap) != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case rewritten <- derpMap:
+			}
+		}
+	}()
+	return rewritten, nil
+}
+
+// rewriteEmbeddedDERP points the embedded relays of a DERP map at the URL of
+// the client.
+//
+// Agents can provide an arbitrary access URL that may be different
+// that the globally configured one. This breaks the built-in DERP,
+// which would continue to reference the global access URL.
+func (c *Client) rewriteEmbeddedDERP(derpMap *tailcfg.DERPMap) error {
+	if derpMap == nil {
+		return nil
+	}
 	accessingPort := c.URL.Port()
 	if accessingPort == "" {
 		accessingPort = "80"
@@ -286,15 +350,9 @@ func (c *Client) WorkspaceAgentMetadata(ctx context.Context) (WorkspaceAgentMeta
 	}
 	accessPort, err := strconv.Atoi(accessingPort)
 	if err != nil {
-		return WorkspaceAgentMetadata{}, xerrors.Errorf("convert accessing port %q: %w", accessingPort, err)
+		return xerrors.Errorf("convert accessing port %q: %w", accessingPort, err)
 	}
-	// Agents can provide an arbitrary access URL that may be different
-	// that the globally configured one. This breaks the built-in DERP,
-	// which would continue to reference the global access URL.
-	//
-	// This converts all built-in DERPs to use the access URL that the
-	// metadata request was performed with.
-	for _, region := range agentMetadata.DERPMap.Regions {
+	for _, region := range derpMap.Regions {
 		if !region.EmbeddedRelay {
 			continue
 		}
@@ -308,11 +366,38 @@ func (c *Client) WorkspaceAgentMetadata(ctx context.Context) (WorkspaceAgentMeta
 			node.ForceHTTP = c.URL.Scheme == "http"
 		}
 	}
-	return agentMetadata, nil
+	return nil
 }
 
 func (c *Client) ListenWorkspaceAgent(ctx context.Context) (net.Conn, error) {
-	coordinateURL, err := c.URL.Parse("/api/v2/workspaceagents/me/coordinate")
+	return c.dialAgentCoordinator(ctx, "/api/v2/workspaceagents/me/coordinate")
+}
+
+// WorkspaceAgentPeers lists the agents the currently authenticated workspace
+// agent may dial through workspace networking.
+func (c *Client) WorkspaceAgentPeers(ctx context.Context) ([]WorkspaceAgentPeer, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/peers", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var peers []WorkspaceAgentPeer
+	return peers, json.NewDecoder(res.Body).Decode(&peers)
+}
+
+// DialWorkspaceAgentPeer coordinates a connection from the currently
+// authenticated workspace agent to another agent, as a client of that agent.
+func (c *Client) DialWorkspaceAgentPeer(ctx context.Context, agentID uuid.UUID) (net.Conn, error) {
+	return c.dialAgentCoordinator(ctx, fmt.Sprintf("/api/v2/workspaceagents/me/peers/%s/coordinate", agentID))
+}
+
+// dialAgentCoordinator opens a coordination websocket authenticated with the
+// session token of the client.
+func (c *Client) dialAgentCoordinator(ctx context.Context, path string) (net.Conn, error) {
+	coordinateURL, err := c.URL.Parse(path)
 	if err != nil {
 		return nil, xerrors.Errorf("parse url: %w", err)
 	}
@@ -342,10 +427,39 @@ func (c *Client) ListenWorkspaceAgent(ctx context.Context) (net.Conn, error) {
 	return websocket.NetConn(ctx, conn, websocket.MessageBinary), nil
 }
 
+// WorkspaceAgentConnectionInfo returns what's needed to create a tailnet
+// connection to an agent.
+func (c *Client) WorkspaceAgentConnectionInfo(ctx context.Context, agentID uuid.UUID) (WorkspaceAgentConnectionInfo, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/connection", agentID), nil)
+	if err != nil {
+		return WorkspaceAgentConnectionInfo{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentConnectionInfo{}, readBodyAsError(res)
+	}
+	var connInfo WorkspaceAgentConnectionInfo
+	err = json.NewDecoder(res.Body).Decode(&connInfo)
+	if err != nil {
+		return WorkspaceAgentConnectionInfo{}, xerrors.Errorf("decode conn info: %w", err)
+	}
+	return connInfo, nil
+}
+
+// DialWorkspaceAgentCoordinator coordinates a connection to an agent for a
+// tailnet connection that is managed by the caller, e.g. to connect to
+// several agents at once. Agents are reachable at WorkspaceAgentIP once the
+// nodes received are passed through WithoutTailnetIP.
+func (c *Client) DialWorkspaceAgentCoordinator(ctx context.Context, agentID uuid.UUID) (net.Conn, error) {
+	return c.dialAgentCoordinator(ctx, fmt.Sprintf("/api/v2/workspaceagents/%s/coordinate", agentID))
+}
+
 // @typescript-ignore DialWorkspaceAgentOptions
 type DialWorkspaceAgentOptions struct {
 	Logger slog.Logger
-	// BlockEndpoints forced a direct connection through DERP.
+	// BlockEndpoints forces the connection through DERP, even when a direct
+	// connection is possible. It's implied when the deployment disables
+	// direct connections.
 	BlockEndpoints bool
 }
 
@@ -353,26 +467,17 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 	if options == nil {
 		options = &DialWorkspaceAgentOptions{}
 	}
-	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/connection", agentID), nil)
+	connInfo, err := c.WorkspaceAgentConnectionInfo(ctx, agentID)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, readBodyAsError(res)
-	}
-	var connInfo WorkspaceAgentConnectionInfo
-	err = json.NewDecoder(res.Body).Decode(&connInfo)
-	if err != nil {
-		return nil, xerrors.Errorf("decode conn info: %w", err)
-	}
 
 	ip := tailnet.IP()
 	conn, err := tailnet.NewConn(&tailnet.Options{
 		Addresses:      []netip.Prefix{netip.PrefixFrom(ip, 128)},
 		DERPMap:        connInfo.DERPMap,
 		Logger:         options.Logger,
-		BlockEndpoints: options.BlockEndpoints,
+		BlockEndpoints: options.BlockEndpoints || connInfo.DisableDirectConnections,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("create tailnet: %w", err)
@@ -447,11 +552,26 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 		_ = conn.Close()
 		return nil, err
 	}
+	// Regions managed through the API change the DERP map while the
+	// connection is open.
+	derpMapClosed := make(chan struct{})
+	go func() {
+		defer close(derpMapClosed)
+		maps, err := c.WatchWorkspaceAgentDERPMap(ctx, agentID)
+		if err != nil {
+			options.Logger.Debug(ctx, "failed to watch the derp map", slog.Error(err))
+			return
+		}
+		for derpMap := range maps {
+			conn.SetDERPMap(derpMap)
+		}
+	}()
 	return &AgentConn{
 		Conn: conn,
 		CloseFunc: func() {
 			cancelFunc()
 			<-closed
+			<-derpMapClosed
 		},
 	}, err
 }
@@ -551,6 +671,10 @@ type AgentStats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+
+	SessionCountSSH             int64 `json:"session_count_ssh"`
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	SessionCountApp             int64 `json:"session_count_app"`
 }
 
 // AgentReportStats begins a stat streaming connection with the Coder server.
@@ -615,9 +739,12 @@ func (c *Client) AgentReportStats(
 					s := stats()
 
 					resp := AgentStatsReportResponse{
-						NumConns: s.NumConns,
-						RxBytes:  s.RxBytes,
-						TxBytes:  s.TxBytes,
+						NumConns:                    s.NumConns,
+						RxBytes:                     s.RxBytes,
+						TxBytes:                     s.TxBytes,
+						SessionCountSSH:             s.SessionCountSSH,
+						SessionCountReconnectingPTY: s.SessionCountReconnectingPTY,
+						SessionCountApp:             s.SessionCountApp,
 					}
 
 					err = wsjson.Write(ctx, conn, resp)
@@ -0,0 +1,113 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// WorkspaceBatchAction is the action a batch applies to every workspace
+// matching its query.
+type WorkspaceBatchAction string
+
+const (
+	WorkspaceBatchActionStart WorkspaceBatchAction = "start"
+	WorkspaceBatchActionStop  WorkspaceBatchAction = "stop"
+	// WorkspaceBatchActionUpdate builds workspaces on the active version of
+	// their template, leaving them started or stopped.
+	WorkspaceBatchActionUpdate WorkspaceBatchAction = "update"
+	WorkspaceBatchActionDelete WorkspaceBatchAction = "delete"
+)
+
+const (
+	// DefaultWorkspaceBatchConcurrency is the number of builds a batch runs
+	// at once if no concurrency is requested.
+	DefaultWorkspaceBatchConcurrency = 10
+	// MaxWorkspaceBatchConcurrency is the most builds a batch may run at
+	// once.
+	MaxWorkspaceBatchConcurrency = 100
+)
+
+// CreateWorkspaceBatchRequest applies an action to every workspace matching
+// a workspace search query, e.g. "template:docker status:running".
+type CreateWorkspaceBatchRequest struct {
+	Query  string               `json:"q" validate:"required"`
+	Action WorkspaceBatchAction `json:"action" validate:"oneof=start stop update delete,required"`
+	// Concurrency is the number of builds run at once. Defaults to
+	// DefaultWorkspaceBatchConcurrency.
+	Concurrency int `json:"concurrency,omitempty"`
+	// DryRun returns the workspaces the batch would build without building
+	// them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+type WorkspaceBatchItemStatus string
+
+const (
+	WorkspaceBatchItemStatusPending   WorkspaceBatchItemStatus = "pending"
+	WorkspaceBatchItemStatusBuilding  WorkspaceBatchItemStatus = "building"
+	WorkspaceBatchItemStatusSucceeded WorkspaceBatchItemStatus = "succeeded"
+	WorkspaceBatchItemStatusFailed    WorkspaceBatchItemStatus = "failed"
+	// WorkspaceBatchItemStatusSkipped is set on workspaces the action
+	// doesn't apply to, e.g. stopping a workspace that's already stopped.
+	WorkspaceBatchItemStatusSkipped WorkspaceBatchItemStatus = "skipped"
+)
+
+// WorkspaceBatchItem is the progress of a batch on a single workspace.
+type WorkspaceBatchItem struct {
+	WorkspaceID   uuid.UUID                `json:"workspace_id"`
+	WorkspaceName string                   `json:"workspace_name"`
+	OwnerName     string                   `json:"owner_name"`
+	Status        WorkspaceBatchItemStatus `json:"status"`
+	BuildID       *uuid.UUID               `json:"build_id,omitempty"`
+	// Error is why the workspace failed to build or was skipped.
+	Error string `json:"error,omitempty"`
+}
+
+// WorkspaceBatch is an action applied to a set of workspaces.
+type WorkspaceBatch struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	InitiatorID uuid.UUID            `json:"initiator_id"`
+	Action      WorkspaceBatchAction `json:"action"`
+	Query       string               `json:"q"`
+	Concurrency int                  `json:"concurrency"`
+	DryRun      bool                 `json:"dry_run"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty"`
+	Items       []WorkspaceBatchItem `json:"items"`
+}
+
+// CreateWorkspaceBatch applies an action to every workspace matching the
+// query. The builds run in the background; poll WorkspaceBatch for their
+// progress.
+func (c *Client) CreateWorkspaceBatch(ctx context.Context, req CreateWorkspaceBatchRequest) (WorkspaceBatch, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaces/batch", req)
+	if err != nil {
+		return WorkspaceBatch{}, xerrors.Errorf("create workspace batch: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return WorkspaceBatch{}, readBodyAsError(res)
+	}
+	var batch WorkspaceBatch
+	return batch, json.NewDecoder(res.Body).Decode(&batch)
+}
+
+// WorkspaceBatch returns the progress of a batch.
+func (c *Client) WorkspaceBatch(ctx context.Context, id uuid.UUID) (WorkspaceBatch, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/batch/%s", id), nil)
+	if err != nil {
+		return WorkspaceBatch{}, xerrors.Errorf("get workspace batch: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceBatch{}, readBodyAsError(res)
+	}
+	var batch WorkspaceBatch
+	return batch, json.NewDecoder(res.Body).Decode(&batch)
+}
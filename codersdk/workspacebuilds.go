@@ -46,6 +46,27 @@ const (
 	// "autostop" is used when a build to stop a workspace is triggered by Autostop.
 	// The initiator id/username in this case is the workspace owner and can be ignored.
 	BuildReasonAutostop BuildReason = "autostop"
+	// "dormancy" is used when a build to stop a workspace is triggered by the
+	// workspace being marked dormant for inactivity.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonDormancy BuildReason = "dormancy"
+	// "autodelete" is used when a build to delete a dormant workspace is
+	// triggered after the template's dormancy deletion TTL.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonAutodelete BuildReason = "autodelete"
+	// "retry" is used when a build to start a workspace is triggered by a
+	// previous start build failing, as allowed by the template.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonRetry BuildReason = "retry"
+	// "rollback" is used when a build to start a workspace on the template
+	// version and parameters of its last successful build is triggered by an
+	// update failing.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonRollback BuildReason = "rollback"
+	// "transfer" is used when a workspace is rebuilt for its new owner after
+	// being transferred. The initiator id/username is the user who
+	// transferred or accepted the workspace.
+	BuildReasonTransfer BuildReason = "transfer"
 )
 
 // WorkspaceBuild is an at-point representation of a workspace state.
@@ -31,6 +31,21 @@ type Workspace struct {
 	AutostartSchedule *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis         *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt        time.Time      `json:"last_used_at"`
+	// DormantAt is set when the workspace was marked dormant for
+	// inactivity. Dormant workspaces can't be built until their owner
+	// confirms they are still in use.
+	DormantAt *time.Time `json:"dormant_at,omitempty"`
+	// DeletingAt is when a dormant workspace will be deleted automatically.
+	DeletingAt *time.Time `json:"deleting_at,omitempty"`
+	// AutomaticUpdates is whether the workspace is updated to the active
+	// version of its template when it's started.
+	AutomaticUpdates bool `json:"automatic_updates"`
+}
+
+// UpdateWorkspaceDormancyRequest marks a workspace dormant, or confirms a
+// dormant workspace is still in use.
+type UpdateWorkspaceDormancyRequest struct {
+	Dormant bool `json:"dormant"`
 }
 
 type WorkspacesRequest struct {
@@ -240,6 +255,73 @@ func (c *Client) UpdateWorkspaceTTL(ctx context.Context, id uuid.UUID, req Updat
 	return nil
 }
 
+// UpdateWorkspaceDormancy marks the workspace dormant, or confirms a dormant
+// workspace is still in use so it can be built again.
+func (c *Client) UpdateWorkspaceDormancy(ctx context.Context, id uuid.UUID, req UpdateWorkspaceDormancyRequest) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/dormant", id.String())
+	res, err := c.Request(ctx, http.MethodPut, path, req)
+	if err != nil {
+		return xerrors.Errorf("update workspace dormancy: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// UpdateWorkspaceAutomaticUpdatesRequest is a request to enable or disable
+// automatic updates of a workspace.
+type UpdateWorkspaceAutomaticUpdatesRequest struct {
+	AutomaticUpdates bool `json:"automatic_updates"`
+}
+
+// UpdateWorkspaceAutomaticUpdates sets whether the workspace is updated to
+// the active version of its template when it's started.
+func (c *Client) UpdateWorkspaceAutomaticUpdates(ctx context.Context, id uuid.UUID, req UpdateWorkspaceAutomaticUpdatesRequest) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/autoupdates", id.String())
+	res, err := c.Request(ctx, http.MethodPut, path, req)
+	if err != nil {
+		return xerrors.Errorf("update workspace automatic updates: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// SourceWorkspaceIDParameter is the parameter a cloned workspace is given
+// the ID of the workspace it was cloned from in, if its template declares
+// it. Templates can use it to copy data from the source workspace.
+const SourceWorkspaceIDParameter = "coder_source_workspace_id"
+
+// CloneWorkspaceRequest creates a workspace for the authenticated user with
+// the template version, parameter values and schedule of another workspace.
+type CloneWorkspaceRequest struct {
+	Name string `json:"name" validate:"workspace_name,required"`
+	// AutostartSchedule and TTLMillis override those of the source workspace.
+	AutostartSchedule *string `json:"autostart_schedule,omitempty"`
+	TTLMillis         *int64  `json:"ttl_ms,omitempty"`
+	// ParameterValues override those of the source workspace.
+	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+}
+
+// CloneWorkspace creates a copy of the workspace owned by the authenticated
+// user.
+func (c *Client) CloneWorkspace(ctx context.Context, id uuid.UUID, req CloneWorkspaceRequest) (Workspace, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/clone", id), req)
+	if err != nil {
+		return Workspace{}, xerrors.Errorf("clone workspace: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return Workspace{}, readBodyAsError(res)
+	}
+	var workspace Workspace
+	return workspace, json.NewDecoder(res.Body).Decode(&workspace)
+}
+
 // PutExtendWorkspaceRequest is a request to extend the deadline of
 // the active workspace build.
 type PutExtendWorkspaceRequest struct {
@@ -269,6 +351,8 @@ type WorkspaceFilter struct {
 	Name string `json:"name,omitempty" typescript:"-"`
 	// Status is a workspace status, which is really the status of the latest build
 	Status string `json:"status,omitempty" typescript:"-"`
+	// Dormant only returns dormant workspaces
+	Dormant bool `json:"dormant,omitempty" typescript:"-"`
 	// Offset is the number of workspaces to skip before returning results.
 	Offset int `json:"offset,omitempty" typescript:"-"`
 	// Limit is a limit on the number of workspaces returned.
@@ -296,6 +380,9 @@ func (f WorkspaceFilter) asRequestOption() RequestOption {
 		if f.Status != "" {
 			params = append(params, fmt.Sprintf("status:%q", f.Status))
 		}
+		if f.Dormant {
+			params = append(params, "dormant:true")
+		}
 		if f.FilterQuery != "" {
 			// If custom stuff is added, just add it on here.
 			params = append(params, f.FilterQuery)
@@ -0,0 +1,86 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// WorkspaceScheduleWindow is a period a workspace runs in. The workspace is
+// started every time StartSchedule fires, and stopped when StopSchedule
+// fires. Both are standard five-field cron specs, optionally prefixed by a
+// timezone, e.g. "CRON_TZ=Europe/Dublin 0 9 * * 1-5".
+type WorkspaceScheduleWindow struct {
+	StartSchedule string `json:"start_schedule" validate:"required"`
+	StopSchedule  string `json:"stop_schedule" validate:"required"`
+}
+
+// WorkspaceSchedule is the schedule of a workspace in addition to its
+// autostart schedule and TTL.
+type WorkspaceSchedule struct {
+	Windows []WorkspaceScheduleWindow `json:"windows"`
+	// SkipDates are dates in the YYYY-MM-DD format the workspace isn't
+	// started automatically on, e.g. holidays.
+	SkipDates []string `json:"skip_dates"`
+}
+
+// WorkspaceScheduledTransition is an upcoming automatic start or stop of a
+// workspace.
+type WorkspaceScheduledTransition struct {
+	Transition WorkspaceTransition `json:"transition"`
+	At         time.Time           `json:"at"`
+}
+
+// WorkspaceSchedule returns the windows and skip dates of the workspace.
+func (c *Client) WorkspaceSchedule(ctx context.Context, id uuid.UUID) (WorkspaceSchedule, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/schedule", id), nil)
+	if err != nil {
+		return WorkspaceSchedule{}, xerrors.Errorf("get workspace schedule: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceSchedule{}, readBodyAsError(res)
+	}
+	var sched WorkspaceSchedule
+	return sched, json.NewDecoder(res.Body).Decode(&sched)
+}
+
+// UpdateWorkspaceSchedule replaces the windows and skip dates of the
+// workspace.
+func (c *Client) UpdateWorkspaceSchedule(ctx context.Context, id uuid.UUID, req WorkspaceSchedule) (WorkspaceSchedule, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/workspaces/%s/schedule", id), req)
+	if err != nil {
+		return WorkspaceSchedule{}, xerrors.Errorf("update workspace schedule: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceSchedule{}, readBodyAsError(res)
+	}
+	var sched WorkspaceSchedule
+	return sched, json.NewDecoder(res.Body).Decode(&sched)
+}
+
+// UpcomingWorkspaceTransitions returns up to count upcoming automatic starts
+// and stops of the workspace, in chronological order.
+func (c *Client) UpcomingWorkspaceTransitions(ctx context.Context, id uuid.UUID, count int) ([]WorkspaceScheduledTransition, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/schedule/upcoming", id), nil, func(r *http.Request) {
+		q := r.URL.Query()
+		q.Set("count", strconv.Itoa(count))
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get upcoming workspace transitions: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var transitions []WorkspaceScheduledTransition
+	return transitions, json.NewDecoder(res.Body).Decode(&transitions)
+}
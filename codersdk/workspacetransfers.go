@@ -0,0 +1,117 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// TransferWorkspaceRequest transfers a workspace to another user. Users who
+// may create workspaces for the new owner transfer it immediately; otherwise
+// the new owner has to accept the transfer.
+type TransferWorkspaceRequest struct {
+	OwnerID uuid.UUID `json:"owner_id" validate:"required"`
+}
+
+type WorkspaceTransferStatus string
+
+const (
+	// WorkspaceTransferStatusPending transfers await the acceptance of the
+	// new owner.
+	WorkspaceTransferStatusPending WorkspaceTransferStatus = "pending"
+	// WorkspaceTransferStatusCompleted transfers have reassigned the
+	// workspace, and started a build for its new owner.
+	WorkspaceTransferStatusCompleted WorkspaceTransferStatus = "completed"
+)
+
+// WorkspaceTransfer is a transfer of a workspace between owners.
+type WorkspaceTransfer struct {
+	WorkspaceID   uuid.UUID               `json:"workspace_id"`
+	WorkspaceName string                  `json:"workspace_name"`
+	FromUserID    uuid.UUID               `json:"from_user_id"`
+	FromUsername  string                  `json:"from_username"`
+	ToUserID      uuid.UUID               `json:"to_user_id"`
+	ToUsername    string                  `json:"to_username"`
+	CreatedAt     time.Time               `json:"created_at"`
+	Status        WorkspaceTransferStatus `json:"status"`
+	// BuildID is the build that updated the workspace for its new owner. It's
+	// set once the transfer is completed.
+	BuildID *uuid.UUID `json:"build_id,omitempty"`
+}
+
+// TransferWorkspace transfers a workspace to another user, or asks them to
+// accept it.
+func (c *Client) TransferWorkspace(ctx context.Context, id uuid.UUID, req TransferWorkspaceRequest) (WorkspaceTransfer, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/transfer", id), req)
+	if err != nil {
+		return WorkspaceTransfer{}, xerrors.Errorf("transfer workspace: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return WorkspaceTransfer{}, readBodyAsError(res)
+	}
+	var transfer WorkspaceTransfer
+	return transfer, json.NewDecoder(res.Body).Decode(&transfer)
+}
+
+// WorkspaceTransfer returns the pending transfer of a workspace.
+func (c *Client) WorkspaceTransfer(ctx context.Context, id uuid.UUID) (WorkspaceTransfer, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/transfer", id), nil)
+	if err != nil {
+		return WorkspaceTransfer{}, xerrors.Errorf("get workspace transfer: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceTransfer{}, readBodyAsError(res)
+	}
+	var transfer WorkspaceTransfer
+	return transfer, json.NewDecoder(res.Body).Decode(&transfer)
+}
+
+// AcceptWorkspaceTransfer completes the pending transfer of a workspace to
+// the authenticated user.
+func (c *Client) AcceptWorkspaceTransfer(ctx context.Context, id uuid.UUID) (WorkspaceTransfer, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/transfer/accept", id), nil)
+	if err != nil {
+		return WorkspaceTransfer{}, xerrors.Errorf("accept workspace transfer: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceTransfer{}, readBodyAsError(res)
+	}
+	var transfer WorkspaceTransfer
+	return transfer, json.NewDecoder(res.Body).Decode(&transfer)
+}
+
+// DeleteWorkspaceTransfer cancels the pending transfer of a workspace if
+// called by its owner, or declines it if called by the new owner.
+func (c *Client) DeleteWorkspaceTransfer(ctx context.Context, id uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaces/%s/transfer", id), nil)
+	if err != nil {
+		return xerrors.Errorf("delete workspace transfer: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceTransfers returns the pending transfers of workspaces to a user.
+func (c *Client) WorkspaceTransfers(ctx context.Context, user string) ([]WorkspaceTransfer, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/workspacetransfers", user), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace transfers: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var transfers []WorkspaceTransfer
+	return transfers, json.NewDecoder(res.Body).Decode(&transfers)
+}
@@ -33,6 +33,29 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"private_key": ActionSecret, // We don't want to expose private keys in diffs.
 		"public_key":  ActionTrack,  // Public keys are ok to expose in a diff.
 	},
+	&database.UserTwoFactor{}: {
+		"user_id":               ActionTrack,
+		"created_at":            ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":            ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"totp_secret":           ActionSecret, // We don't want to expose secrets in diffs.
+		"hashed_recovery_codes": ActionSecret, // We don't want to expose recovery codes in diffs.
+		"enabled":               ActionTrack,
+		"last_totp_step":        ActionIgnore, // Changes on every login.
+	},
+	&database.APIKey{}: {
+		"id":               ActionTrack,
+		"hashed_secret":    ActionSecret, // We don't want to expose secrets in diffs.
+		"user_id":          ActionTrack,
+		"last_used":        ActionTrack,
+		"expires_at":       ActionTrack,
+		"created_at":       ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":       ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"login_type":       ActionTrack,
+		"lifetime_seconds": ActionTrack,
+		"ip_address":       ActionIgnore, // Changes whenever the key is used.
+		"scope":            ActionTrack,
+		"user_agent":       ActionIgnore, // Changes whenever the key is used.
+	},
 	&database.OrganizationMember{}: {
 		"user_id":         ActionTrack,
 		"organization_id": ActionTrack,
@@ -48,23 +71,35 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"updated_at":  ActionIgnore, // Changes, but is implicit and not helpful in a diff.
 	},
 	&database.Template{}: {
-		"id":                     ActionTrack,
-		"created_at":             ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
-		"updated_at":             ActionIgnore, // Changes, but is implicit and not helpful in a diff.
-		"organization_id":        ActionIgnore, /// Never changes.
-		"deleted":                ActionIgnore, // Changes, but is implicit when a delete event is fired.
-		"name":                   ActionTrack,
-		"display_name":           ActionTrack,
-		"provisioner":            ActionTrack,
-		"active_version_id":      ActionTrack,
-		"description":            ActionTrack,
-		"icon":                   ActionTrack,
-		"default_ttl":            ActionTrack,
-		"min_autostart_interval": ActionTrack,
-		"created_by":             ActionTrack,
-		"is_private":             ActionTrack,
-		"group_acl":              ActionTrack,
-		"user_acl":               ActionTrack,
+		"id":                         ActionTrack,
+		"created_at":                 ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":                 ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"organization_id":            ActionIgnore, /// Never changes.
+		"deleted":                    ActionIgnore, // Changes, but is implicit when a delete event is fired.
+		"name":                       ActionTrack,
+		"display_name":               ActionTrack,
+		"provisioner":                ActionTrack,
+		"active_version_id":          ActionTrack,
+		"description":                ActionTrack,
+		"icon":                       ActionTrack,
+		"default_ttl":                ActionTrack,
+		"max_ttl":                    ActionTrack,
+		"min_autostart_interval":     ActionTrack,
+		"quiet_hours_schedule":       ActionTrack,
+		"allow_user_autostop":        ActionTrack,
+		"inactivity_ttl":             ActionTrack,
+		"dormancy_deletion_ttl":      ActionTrack,
+		"activity_bump":              ActionTrack,
+		"activity_bump_threshold":    ActionTrack,
+		"require_active_version":     ActionTrack,
+		"build_retries":              ActionTrack,
+		"build_retry_backoff":        ActionTrack,
+		"rollback_failed_updates":    ActionTrack,
+		"allow_workspace_networking": ActionTrack,
+		"created_by":                 ActionTrack,
+		"is_private":                 ActionTrack,
+		"group_acl":                  ActionTrack,
+		"user_acl":                   ActionTrack,
 	},
 	&database.TemplateVersion{}: {
 		"id":              ActionTrack,
@@ -103,6 +138,8 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"autostart_schedule": ActionTrack,
 		"ttl":                ActionTrack,
 		"last_used_at":       ActionIgnore,
+		"dormant_at":         ActionTrack,
+		"automatic_updates":  ActionTrack,
 	},
 	&database.Group{}: {
 		"id":              ActionTrack,
@@ -57,9 +57,10 @@ func New(ctx context.Context, options *Options) (*API, error) {
 		OIDC:   options.OIDCConfig,
 	}
 	apiKeyMiddleware := httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
-		DB:              options.Database,
-		OAuth2Configs:   oauthConfigs,
-		RedirectToLogin: false,
+		DB:               options.Database,
+		OAuth2Configs:    oauthConfigs,
+		RedirectToLogin:  false,
+		MaxTokenLifetime: options.MaxTokenLifetime,
 	})
 
 	api.AGPL.APIHandler.Group(func(r chi.Router) {
@@ -273,7 +274,7 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 	if changed, enabled := featureChanged(codersdk.FeatureHighAvailability); changed {
 		coordinator := agpltailnet.NewCoordinator()
 		if enabled {
-			haCoordinator, err := tailnet.NewCoordinator(api.Logger, api.Pubsub)
+			haCoordinator, err := tailnet.NewPGCoord(api.Logger, api.AGPL.ID, api.Database, api.Pubsub, nil)
 			if err != nil {
 				api.Logger.Error(ctx, "unable to set up high availability coordinator", slog.Error(err))
 				// If we try to setup the HA coordinator and it fails, nothing
@@ -0,0 +1,805 @@
+package tailnet
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tabbed/pqtype"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	agpl "github.com/coder/coder/tailnet"
+)
+
+const (
+	// pgCoordEventAgent tells a coordinator that the node of an agent its
+	// clients want to reach changed.
+	pgCoordEventAgent = "agent"
+	// pgCoordEventClients tells a coordinator that the node of a client of an
+	// agent connected to it changed.
+	pgCoordEventClients = "clients"
+)
+
+// PGCoordOptions configures a coordinator created by NewPGCoord.
+type PGCoordOptions struct {
+	// HeartbeatInterval is how often the coordinator tells the other
+	// coordinators it's alive. Coordinators that miss three heartbeats are
+	// deleted along with their agents and clients. Defaults to 5 seconds.
+	HeartbeatInterval time.Duration
+}
+
+// NewPGCoord creates a high availability coordinator that stores the nodes of
+// agents and clients in the database. Nodes aren't broadcast to every replica:
+// a replica is only notified of the IDs of the agents its clients want to
+// reach, and reads their nodes from the database.
+func NewPGCoord(logger slog.Logger, replicaID uuid.UUID, db database.Store, pubsub database.Pubsub, options *PGCoordOptions) (agpl.Coordinator, error) {
+	if options == nil {
+		options = &PGCoordOptions{}
+	}
+	if options.HeartbeatInterval == 0 {
+		options.HeartbeatInterval = 5 * time.Second
+	}
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	coord := &pgCoord{
+		id:                       replicaID,
+		log:                      logger,
+		db:                       db,
+		pubsub:                   pubsub,
+		heartbeatInterval:        options.HeartbeatInterval,
+		ctx:                      ctx,
+		closeFunc:                cancelFunc,
+		nodes:                    map[uuid.UUID]*agpl.Node{},
+		agentSockets:             map[uuid.UUID]net.Conn{},
+		agentToConnectionSockets: map[uuid.UUID]map[uuid.UUID]net.Conn{},
+		agentReplicas:            map[uuid.UUID]uuid.UUID{},
+	}
+
+	_, err := db.UpsertTailnetCoordinator(ctx, database.UpsertTailnetCoordinatorParams{
+		ID:          replicaID,
+		HeartbeatAt: database.Now(),
+	})
+	if err != nil {
+		cancelFunc()
+		return nil, xerrors.Errorf("register coordinator: %w", err)
+	}
+	err = coord.subscribe()
+	if err != nil {
+		cancelFunc()
+		return nil, xerrors.Errorf("subscribe: %w", err)
+	}
+	coord.closeWait.Add(1)
+	go coord.heartbeatLoop()
+	return coord, nil
+}
+
+type pgCoord struct {
+	id                uuid.UUID
+	log               slog.Logger
+	db                database.Store
+	pubsub            database.Pubsub
+	heartbeatInterval time.Duration
+
+	// ctx is canceled when the coordinator is closed.
+	ctx       context.Context
+	closeFunc context.CancelFunc
+	closeWait sync.WaitGroup
+
+	mutex  sync.RWMutex
+	closed bool
+	// nodes maps the IDs of the agents and clients connected to this replica,
+	// and of the agents its clients want to reach, to their node.
+	nodes map[uuid.UUID]*agpl.Node
+	// agentSockets maps agent IDs to their open websocket.
+	agentSockets map[uuid.UUID]net.Conn
+	// agentToConnectionSockets maps agent IDs to connection IDs of conns that
+	// are subscribed to updates for that agent.
+	agentToConnectionSockets map[uuid.UUID]map[uuid.UUID]net.Conn
+	// agentReplicas maps the IDs of agents connected to other replicas to
+	// the replica they were last read from.
+	agentReplicas map[uuid.UUID]uuid.UUID
+}
+
+// pgCoordChannel is the pubsub channel the coordinator of a replica is
+// notified on.
+func pgCoordChannel(replicaID uuid.UUID) string {
+	return "tailnet_coordinator:" + replicaID.String()
+}
+
+// Node returns the node of an agent or client connected to this replica, or
+// of an agent connected to any replica.
+func (c *pgCoord) Node(id uuid.UUID) *agpl.Node {
+	c.mutex.RLock()
+	node, ok := c.nodes[id]
+	c.mutex.RUnlock()
+	if ok {
+		return node
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+	node, _, err := c.agentNode(ctx, id)
+	if err != nil {
+		c.log.Warn(ctx, "get agent node", slog.F("agent_id", id), slog.Error(err))
+		return nil
+	}
+	return node
+}
+
+// Nodes returns the nodes of agents and clients connected to this replica,
+// and reads the nodes of the other agents from the database in one query.
+func (c *pgCoord) Nodes(ids []uuid.UUID) map[uuid.UUID]*agpl.Node {
+	nodes := make(map[uuid.UUID]*agpl.Node, len(ids))
+	remote := make([]uuid.UUID, 0)
+	c.mutex.RLock()
+	for _, id := range ids {
+		node, ok := c.nodes[id]
+		if ok {
+			nodes[id] = node
+			continue
+		}
+		remote = append(remote, id)
+	}
+	c.mutex.RUnlock()
+	if len(remote) == 0 {
+		return nodes
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+	agents, err := c.db.GetTailnetAgentsByIDs(ctx, remote)
+	if err != nil {
+		c.log.Warn(ctx, "get agent nodes", slog.Error(err))
+		return nodes
+	}
+	for _, agent := range agents {
+		// Agents are ordered by the most recently updated first.
+		if _, ok := nodes[agent.ID]; ok {
+			continue
+		}
+		var node agpl.Node
+		err = json.Unmarshal(agent.Node, &node)
+		if err != nil {
+			c.log.Warn(ctx, "unmarshal agent node", slog.F("agent_id", agent.ID), slog.Error(err))
+			continue
+		}
+		nodes[agent.ID] = &node
+	}
+	return nodes
+}
+
+// Debug returns a snapshot of the agents and clients connected to this
+// replica, and of the agents its clients want to reach.
+func (c *pgCoord) Debug() agpl.CoordinatorDebug {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	debug := agpl.DebugSnapshot(c.nodes, c.agentSockets, c.agentToConnectionSockets)
+	debug.ReplicaID = c.id
+	for i, agent := range debug.Agents {
+		if agent.Connected {
+			debug.Agents[i].ReplicaID = c.id
+			continue
+		}
+		debug.Agents[i].ReplicaID = c.agentReplicas[agent.ID]
+	}
+	return debug
+}
+
+// ServeClient accepts a WebSocket connection that wants to connect to an agent
+// with the specified ID.
+func (c *pgCoord) ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID) error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return xerrors.New("coordinator is closed")
+	}
+	connectionSockets, ok := c.agentToConnectionSockets[agent]
+	if !ok {
+		connectionSockets = map[uuid.UUID]net.Conn{}
+		c.agentToConnectionSockets[agent] = connectionSockets
+	}
+	// Insert this connection into a map so the agent can publish node updates.
+	connectionSockets[id] = conn
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.nodes, id)
+		connectionSockets, ok := c.agentToConnectionSockets[agent]
+		if ok {
+			delete(connectionSockets, id)
+			if len(connectionSockets) == 0 {
+				delete(c.agentToConnectionSockets, agent)
+				// Forget agents of other replicas nobody here wants to reach.
+				if _, ok := c.agentSockets[agent]; !ok {
+					delete(c.nodes, agent)
+					delete(c.agentReplicas, agent)
+				}
+			}
+		}
+		c.mutex.Unlock()
+
+		err := c.db.DeleteTailnetClient(context.Background(), database.DeleteTailnetClientParams{
+			ID:            id,
+			CoordinatorID: c.id,
+		})
+		if err != nil {
+			c.log.Warn(context.Background(), "delete tailnet client", slog.F("client_id", id), slog.Error(err))
+		}
+	}()
+
+	// Register the client before reading the node of the agent, so an agent
+	// that registers in between reads the client.
+	err := c.upsertClient(id, agent, nil)
+	if err != nil {
+		return xerrors.Errorf("register client: %w", err)
+	}
+
+	// When a new connection is requested, we update it with the latest
+	// node of the agent. This allows the connection to establish.
+	c.mutex.RLock()
+	node, ok := c.nodes[agent]
+	c.mutex.RUnlock()
+	if !ok {
+		node, err = c.refreshAgentNode(agent)
+		if err != nil {
+			return xerrors.Errorf("get agent node: %w", err)
+		}
+	}
+	if node != nil {
+		data, err := json.Marshal([]*agpl.Node{node})
+		if err != nil {
+			return xerrors.Errorf("marshal node: %w", err)
+		}
+		_, err = conn.Write(data)
+		if err != nil {
+			return xerrors.Errorf("write nodes: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(conn)
+	// Indefinitely handle messages from the client websocket.
+	for {
+		err := c.handleNextClientMessage(id, agent, decoder)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return xerrors.Errorf("handle next client message: %w", err)
+		}
+	}
+}
+
+func (c *pgCoord) handleNextClientMessage(id, agent uuid.UUID, decoder *json.Decoder) error {
+	var node agpl.Node
+	err := decoder.Decode(&node)
+	if err != nil {
+		return xerrors.Errorf("read json: %w", err)
+	}
+
+	c.mutex.Lock()
+	c.nodes[id] = &node
+	agentSocket, ok := c.agentSockets[agent]
+	c.mutex.Unlock()
+
+	err = c.upsertClient(id, agent, &node)
+	if err != nil {
+		return xerrors.Errorf("update client: %w", err)
+	}
+
+	if !ok {
+		// Notify the replicas the agent is connected to, which read the node
+		// from the database.
+		agents, err := c.db.GetTailnetAgents(c.ctx, agent)
+		if err != nil {
+			return xerrors.Errorf("get tailnet agents: %w", err)
+		}
+		coordinators := make([]uuid.UUID, 0, len(agents))
+		for _, agent := range agents {
+			coordinators = append(coordinators, agent.CoordinatorID)
+		}
+		return c.notify(coordinators, pgCoordEventClients, agent)
+	}
+
+	// Write the new node from this client to the actively
+	// connected agent.
+	data, err := json.Marshal([]*agpl.Node{&node})
+	if err != nil {
+		return xerrors.Errorf("marshal nodes: %w", err)
+	}
+	_, err = agentSocket.Write(data)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) {
+			return nil
+		}
+		return xerrors.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+// ServeAgent accepts a WebSocket connection to an agent that listens to
+// incoming connections and publishes node updates.
+func (c *pgCoord) ServeAgent(conn net.Conn, id uuid.UUID) error {
+	// If an old agent socket is connected, we close it
+	// to avoid any leaks. This shouldn't ever occur because
+	// we expect one agent to be running.
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return xerrors.New("coordinator is closed")
+	}
+	oldAgentSocket, ok := c.agentSockets[id]
+	if ok {
+		_ = oldAgentSocket.Close()
+	}
+	c.agentSockets[id] = conn
+	delete(c.agentReplicas, id)
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		// Don't clean up after an agent that replaced this socket.
+		if c.agentSockets[id] != conn {
+			c.mutex.Unlock()
+			return
+		}
+		delete(c.agentSockets, id)
+		delete(c.nodes, id)
+		c.mutex.Unlock()
+
+		err := c.db.DeleteTailnetAgent(context.Background(), database.DeleteTailnetAgentParams{
+			ID:            id,
+			CoordinatorID: c.id,
+		})
+		if err != nil {
+			c.log.Warn(context.Background(), "delete tailnet agent", slog.F("agent_id", id), slog.Error(err))
+		}
+	}()
+
+	// Publish the nodes of the clients that already want to connect.
+	err := c.sendClientNodes(id, conn)
+	if err != nil {
+		return xerrors.Errorf("send client nodes: %w", err)
+	}
+
+	registered := false
+	decoder := json.NewDecoder(conn)
+	for {
+		err := c.handleNextAgentMessage(id, decoder)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return xerrors.Errorf("handle next agent message: %w", err)
+		}
+		if registered {
+			continue
+		}
+		// Clients that registered after the nodes were sent above, but
+		// before the agent was registered, didn't notify this replica.
+		registered = true
+		err = c.sendClientNodes(id, conn)
+		if err != nil {
+			return xerrors.Errorf("send client nodes: %w", err)
+		}
+	}
+}
+
+func (c *pgCoord) handleNextAgentMessage(id uuid.UUID, decoder *json.Decoder) error {
+	var node agpl.Node
+	err := decoder.Decode(&node)
+	if err != nil {
+		return xerrors.Errorf("read json: %w", err)
+	}
+
+	c.mutex.Lock()
+	oldNode := c.nodes[id]
+	if oldNode != nil && oldNode.AsOf.After(node.AsOf) {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.nodes[id] = &node
+	c.mutex.Unlock()
+
+	err = c.sendAgentNode(id, &node)
+	if err != nil {
+		return xerrors.Errorf("send agent node: %w", err)
+	}
+
+	data, err := json.Marshal(node)
+	if err != nil {
+		return xerrors.Errorf("marshal node: %w", err)
+	}
+	_, err = c.db.UpsertTailnetAgent(c.ctx, database.UpsertTailnetAgentParams{
+		ID:            id,
+		CoordinatorID: c.id,
+		UpdatedAt:     database.Now(),
+		Node:          data,
+	})
+	if err != nil {
+		return xerrors.Errorf("update agent: %w", err)
+	}
+	return c.notifyAgentClients(id)
+}
+
+// notifyAgentClients notifies the replicas with clients that want to reach
+// the agent that its node changed.
+func (c *pgCoord) notifyAgentClients(id uuid.UUID) error {
+	clients, err := c.db.GetTailnetClientsForAgent(c.ctx, id)
+	if err != nil {
+		return xerrors.Errorf("get tailnet clients: %w", err)
+	}
+	coordinators := make([]uuid.UUID, 0, len(clients))
+	for _, client := range clients {
+		coordinators = append(coordinators, client.CoordinatorID)
+	}
+	return c.notify(coordinators, pgCoordEventAgent, id)
+}
+
+// sendAgentNode writes the node of an agent to the clients connected to this
+// replica that want to reach it.
+func (c *pgCoord) sendAgentNode(id uuid.UUID, node *agpl.Node) error {
+	data, err := json.Marshal([]*agpl.Node{node})
+	if err != nil {
+		return xerrors.Errorf("marshal nodes: %w", err)
+	}
+
+	c.mutex.RLock()
+	connectionSockets := c.agentToConnectionSockets[id]
+	// Publish the new node to every listening socket.
+	var wg sync.WaitGroup
+	wg.Add(len(connectionSockets))
+	for _, connectionSocket := range connectionSockets {
+		connectionSocket := connectionSocket
+		go func() {
+			defer wg.Done()
+			_ = connectionSocket.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			_, _ = connectionSocket.Write(data)
+		}()
+	}
+	c.mutex.RUnlock()
+	wg.Wait()
+	return nil
+}
+
+// sendClientNodes writes the nodes of the clients that want to reach an agent
+// connected to this replica to the agent.
+func (c *pgCoord) sendClientNodes(id uuid.UUID, conn net.Conn) error {
+	clients, err := c.db.GetTailnetClientsForAgent(c.ctx, id)
+	if err != nil {
+		return xerrors.Errorf("get tailnet clients: %w", err)
+	}
+	nodes := make([]*agpl.Node, 0, len(clients))
+	for _, client := range clients {
+		if !client.Node.Valid {
+			continue
+		}
+		var node agpl.Node
+		err := json.Unmarshal(client.Node.RawMessage, &node)
+		if err != nil {
+			return xerrors.Errorf("unmarshal node of client %s: %w", client.ID, err)
+		}
+		nodes = append(nodes, &node)
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(nodes)
+	if err != nil {
+		return xerrors.Errorf("marshal nodes: %w", err)
+	}
+	_, err = conn.Write(data)
+	if err != nil {
+		return xerrors.Errorf("write nodes: %w", err)
+	}
+	return nil
+}
+
+func (c *pgCoord) upsertClient(id, agent uuid.UUID, node *agpl.Node) error {
+	var raw pqtype.NullRawMessage
+	if node != nil {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return xerrors.Errorf("marshal node: %w", err)
+		}
+		raw = pqtype.NullRawMessage{RawMessage: data, Valid: true}
+	}
+	_, err := c.db.UpsertTailnetClient(c.ctx, database.UpsertTailnetClientParams{
+		ID:            id,
+		CoordinatorID: c.id,
+		AgentID:       agent,
+		UpdatedAt:     database.Now(),
+		Node:          raw,
+	})
+	return err
+}
+
+// agentNode reads the most recent node of an agent from the database. The
+// node is nil if the agent isn't connected to any replica.
+func (c *pgCoord) agentNode(ctx context.Context, id uuid.UUID) (*agpl.Node, uuid.UUID, error) {
+	agents, err := c.db.GetTailnetAgents(ctx, id)
+	if err != nil {
+		return nil, uuid.Nil, xerrors.Errorf("get tailnet agents: %w", err)
+	}
+	if len(agents) == 0 {
+		return nil, uuid.Nil, nil
+	}
+	var node agpl.Node
+	err = json.Unmarshal(agents[0].Node, &node)
+	if err != nil {
+		return nil, uuid.Nil, xerrors.Errorf("unmarshal node: %w", err)
+	}
+	return &node, agents[0].CoordinatorID, nil
+}
+
+// refreshAgentNode reads the node of an agent connected to another replica
+// and remembers it for the clients of this replica.
+func (c *pgCoord) refreshAgentNode(id uuid.UUID) (*agpl.Node, error) {
+	node, replicaID, err := c.agentNode(c.ctx, id)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.agentSockets[id]; ok {
+		// The agent connected to this replica in the meantime.
+		return c.nodes[id], nil
+	}
+	if _, ok := c.agentToConnectionSockets[id]; !ok {
+		// Nobody here wants to reach the agent anymore.
+		return node, nil
+	}
+	oldNode := c.nodes[id]
+	if oldNode != nil && oldNode.AsOf.After(node.AsOf) {
+		return oldNode, nil
+	}
+	c.nodes[id] = node
+	c.agentReplicas[id] = replicaID
+	return node, nil
+}
+
+// notify publishes an event about an agent to the coordinators of other
+// replicas. Each coordinator is notified once.
+//
+// format: <event>|<agent id>
+func (c *pgCoord) notify(coordinators []uuid.UUID, event string, agent uuid.UUID) error {
+	message := []byte(event + "|" + agent.String())
+	notified := map[uuid.UUID]struct{}{c.id: {}}
+	for _, coordinator := range coordinators {
+		if _, ok := notified[coordinator]; ok {
+			continue
+		}
+		notified[coordinator] = struct{}{}
+		err := c.pubsub.Publish(pgCoordChannel(coordinator), message)
+		if err != nil {
+			return xerrors.Errorf("publish to coordinator %s: %w", coordinator, err)
+		}
+	}
+	return nil
+}
+
+func (c *pgCoord) subscribe() error {
+	messageQueue := make(chan []byte, 64)
+	cancelSub, err := c.pubsub.Subscribe(pgCoordChannel(c.id), func(ctx context.Context, message []byte) {
+		select {
+		case messageQueue <- message:
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		}
+	})
+	if err != nil {
+		return xerrors.Errorf("subscribe to coordinator channel: %w", err)
+	}
+	c.closeWait.Add(1)
+	go func() {
+		defer c.closeWait.Done()
+		defer cancelSub()
+		for {
+			var message []byte
+			select {
+			case <-c.ctx.Done():
+				return
+			case message = <-messageQueue:
+			}
+			c.handlePubsubMessage(message)
+		}
+	}()
+	return nil
+}
+
+func (c *pgCoord) handlePubsubMessage(message []byte) {
+	sp := bytes.Split(message, []byte("|"))
+	if len(sp) != 2 {
+		c.log.Error(c.ctx, "invalid coordinator message", slog.F("msg", string(message)))
+		return
+	}
+	agentID, err := uuid.ParseBytes(sp[1])
+	if err != nil {
+		c.log.Error(c.ctx, "invalid agent id", slog.F("id", string(sp[1])))
+		return
+	}
+
+	switch string(sp[0]) {
+	case pgCoordEventAgent:
+		c.mutex.RLock()
+		_, ok := c.agentToConnectionSockets[agentID]
+		c.mutex.RUnlock()
+		if !ok {
+			return
+		}
+		node, err := c.refreshAgentNode(agentID)
+		if err != nil {
+			c.log.Error(c.ctx, "refresh agent node", slog.F("agent_id", agentID), slog.Error(err))
+			return
+		}
+		if node == nil {
+			return
+		}
+		err = c.sendAgentNode(agentID, node)
+		if err != nil {
+			c.log.Error(c.ctx, "send agent node", slog.F("agent_id", agentID), slog.Error(err))
+		}
+	case pgCoordEventClients:
+		c.mutex.RLock()
+		agentSocket, ok := c.agentSockets[agentID]
+		c.mutex.RUnlock()
+		if !ok {
+			return
+		}
+		err = c.sendClientNodes(agentID, agentSocket)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+			c.log.Error(c.ctx, "send client nodes", slog.F("agent_id", agentID), slog.Error(err))
+		}
+	default:
+		c.log.Error(c.ctx, "unknown coordinator event", slog.F("name", string(sp[0])))
+	}
+}
+
+// heartbeatLoop tells the other coordinators this one is alive, and deletes
+// coordinators that stopped heartbeating, e.g. because their replica crashed.
+func (c *pgCoord) heartbeatLoop() {
+	defer c.closeWait.Done()
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := c.heartbeat()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.log.Warn(c.ctx, "tailnet coordinator heartbeat", slog.Error(err))
+		}
+	}
+}
+
+func (c *pgCoord) heartbeat() error {
+	_, err := c.db.UpdateTailnetCoordinatorHeartbeat(c.ctx, database.UpdateTailnetCoordinatorHeartbeatParams{
+		ID:          c.id,
+		HeartbeatAt: database.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Another replica thought this one was dead, and deleted its peers.
+		c.log.Warn(c.ctx, "tailnet coordinator was deleted, registering again")
+		err = c.register()
+	}
+	if err != nil {
+		return xerrors.Errorf("update heartbeat: %w", err)
+	}
+	err = c.db.DeleteTailnetCoordinatorsHeartbeatBefore(c.ctx, database.Now().Add(-3*c.heartbeatInterval))
+	if err != nil {
+		return xerrors.Errorf("delete stale coordinators: %w", err)
+	}
+	return nil
+}
+
+// register registers the coordinator and the agents and clients connected to
+// it.
+func (c *pgCoord) register() error {
+	_, err := c.db.UpsertTailnetCoordinator(c.ctx, database.UpsertTailnetCoordinatorParams{
+		ID:          c.id,
+		HeartbeatAt: database.Now(),
+	})
+	if err != nil {
+		return xerrors.Errorf("register coordinator: %w", err)
+	}
+
+	type client struct {
+		id, agent uuid.UUID
+		node      *agpl.Node
+	}
+	c.mutex.RLock()
+	agents := map[uuid.UUID]*agpl.Node{}
+	for id := range c.agentSockets {
+		if node, ok := c.nodes[id]; ok {
+			agents[id] = node
+		}
+	}
+	clients := make([]client, 0)
+	for agent, sockets := range c.agentToConnectionSockets {
+		for id := range sockets {
+			clients = append(clients, client{id: id, agent: agent, node: c.nodes[id]})
+		}
+	}
+	c.mutex.RUnlock()
+
+	for _, client := range clients {
+		err := c.upsertClient(client.id, client.agent, client.node)
+		if err != nil {
+			return xerrors.Errorf("register client %s: %w", client.id, err)
+		}
+	}
+	for id, node := range agents {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return xerrors.Errorf("marshal node: %w", err)
+		}
+		_, err = c.db.UpsertTailnetAgent(c.ctx, database.UpsertTailnetAgentParams{
+			ID:            id,
+			CoordinatorID: c.id,
+			UpdatedAt:     database.Now(),
+			Node:          data,
+		})
+		if err != nil {
+			return xerrors.Errorf("register agent %s: %w", id, err)
+		}
+		err = c.notifyAgentClients(id)
+		if err != nil {
+			return xerrors.Errorf("notify clients of agent %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Close closes all of the open connections in the coordinator, stops the
+// coordinator from accepting new connections and deletes its agents and
+// clients from the database.
+func (c *pgCoord) Close() error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.closeFunc()
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(c.agentSockets))
+	for _, socket := range c.agentSockets {
+		socket := socket
+		go func() {
+			_ = socket.Close()
+			wg.Done()
+		}()
+	}
+	for _, connMap := range c.agentToConnectionSockets {
+		wg.Add(len(connMap))
+		for _, socket := range connMap {
+			socket := socket
+			go func() {
+				_ = socket.Close()
+				wg.Done()
+			}()
+		}
+	}
+	c.mutex.Unlock()
+	wg.Wait()
+	c.closeWait.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.db.DeleteTailnetCoordinator(ctx, c.id)
+	if err != nil {
+		return xerrors.Errorf("delete coordinator: %w", err)
+	}
+	return nil
+}
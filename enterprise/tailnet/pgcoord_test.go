@@ -0,0 +1,579 @@
+package tailnet_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/enterprise/tailnet"
+	agpl "github.com/coder/coder/tailnet"
+	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPGCoordSingle(t *testing.T) {
+	t.Parallel()
+	t.Run("ClientWithoutAgent", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		client, server := net.Pipe()
+		sendNode, errChan := agpl.ServeCoordinator(client, func(node []*agpl.Node) error {
+			return nil
+		})
+		id := uuid.New()
+		closeChan := make(chan struct{})
+		go func() {
+			err := coordinator.ServeClient(server, id, uuid.New())
+			assert.NoError(t, err)
+			close(closeChan)
+		}()
+		sendNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			return coordinator.Node(id) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		err = client.Close()
+		require.NoError(t, err)
+		<-errChan
+		<-closeChan
+	})
+
+	t.Run("AgentWithoutClients", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		client, server := net.Pipe()
+		sendNode, errChan := agpl.ServeCoordinator(client, func(node []*agpl.Node) error {
+			return nil
+		})
+		id := uuid.New()
+		closeChan := make(chan struct{})
+		go func() {
+			err := coordinator.ServeAgent(server, id)
+			assert.NoError(t, err)
+			close(closeChan)
+		}()
+		sendNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			return coordinator.Node(id) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+		err = client.Close()
+		require.NoError(t, err)
+		<-errChan
+		<-closeChan
+	})
+
+	t.Run("AgentWithClient", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node)
+		sendAgentNode, agentErrChan := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		agentID := uuid.New()
+		closeAgentChan := make(chan struct{})
+		go func() {
+			err := coordinator.ServeAgent(agentServerWS, agentID)
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		sendAgentNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			return coordinator.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		defer clientServerWS.Close()
+		clientNodeChan := make(chan []*agpl.Node)
+		sendClientNode, clientErrChan := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		clientID := uuid.New()
+		closeClientChan := make(chan struct{})
+		go func() {
+			err := coordinator.ServeClient(clientServerWS, clientID, agentID)
+			assert.NoError(t, err)
+			close(closeClientChan)
+		}()
+		agentNodes := <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+		sendClientNode(&agpl.Node{})
+		clientNodes := <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+
+		// Ensure an update to the agent node reaches the client!
+		sendAgentNode(&agpl.Node{})
+		agentNodes = <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+
+		// Close the agent WebSocket so a new one can connect.
+		err = agentWS.Close()
+		require.NoError(t, err)
+		<-agentErrChan
+		<-closeAgentChan
+
+		// Create a new agent connection. This is to simulate a reconnect!
+		agentWS, agentServerWS = net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan = make(chan []*agpl.Node)
+		_, agentErrChan = agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		closeAgentChan = make(chan struct{})
+		go func() {
+			err := coordinator.ServeAgent(agentServerWS, agentID)
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		// Ensure the existing listening client sends it's node immediately!
+		clientNodes = <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+
+		err = agentWS.Close()
+		require.NoError(t, err)
+		<-agentErrChan
+		<-closeAgentChan
+
+		err = clientWS.Close()
+		require.NoError(t, err)
+		<-clientErrChan
+		<-closeClientChan
+	})
+}
+
+func TestPGCoordHA(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AgentWithClient", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+
+		coordinator1, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node)
+		sendAgentNode, agentErrChan := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		agentID := uuid.New()
+		closeAgentChan := make(chan struct{})
+		go func() {
+			err := coordinator1.ServeAgent(agentServerWS, agentID)
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		sendAgentNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			return coordinator1.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		coordinator2, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		defer clientServerWS.Close()
+		clientNodeChan := make(chan []*agpl.Node)
+		sendClientNode, clientErrChan := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		clientID := uuid.New()
+		closeClientChan := make(chan struct{})
+		go func() {
+			err := coordinator2.ServeClient(clientServerWS, clientID, agentID)
+			assert.NoError(t, err)
+			close(closeClientChan)
+		}()
+		agentNodes := <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+		sendClientNode(&agpl.Node{})
+		_ = sendClientNode
+		clientNodes := <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+
+		// Ensure an update to the agent node reaches the client!
+		sendAgentNode(&agpl.Node{})
+		agentNodes = <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+
+		// Close the agent WebSocket so a new one can connect.
+		require.NoError(t, agentWS.Close())
+		require.NoError(t, agentServerWS.Close())
+		<-agentErrChan
+		<-closeAgentChan
+
+		// Create a new agent connection. This is to simulate a reconnect!
+		agentWS, agentServerWS = net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan = make(chan []*agpl.Node)
+		_, agentErrChan = agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		closeAgentChan = make(chan struct{})
+		go func() {
+			err := coordinator1.ServeAgent(agentServerWS, agentID)
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		// Ensure the existing listening client sends it's node immediately!
+		clientNodes = <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+
+		err = agentWS.Close()
+		require.NoError(t, err)
+		<-agentErrChan
+		<-closeAgentChan
+
+		err = clientWS.Close()
+		require.NoError(t, err)
+		<-clientErrChan
+		<-closeClientChan
+	})
+	t.Run("Debug", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		replica1 := uuid.New()
+		coordinator1, err := tailnet.NewPGCoord(slogtest.Make(t, nil), replica1, db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		replica2 := uuid.New()
+		coordinator2, err := tailnet.NewPGCoord(slogtest.Make(t, nil), replica2, db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, _ := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		go func() {
+			_ = coordinator1.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+		require.Eventually(t, func() bool {
+			return coordinator1.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		clientNodeChan := make(chan []*agpl.Node, 1)
+		_, _ = agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		clientID := uuid.New()
+		go func() {
+			_ = coordinator2.ServeClient(clientServerWS, clientID, agentID)
+		}()
+		<-clientNodeChan
+
+		debug := coordinator1.Debug()
+		require.Equal(t, replica1, debug.ReplicaID)
+		require.Len(t, debug.Agents, 1)
+		require.True(t, debug.Agents[0].Connected)
+		require.Equal(t, replica1, debug.Agents[0].ReplicaID)
+		require.Empty(t, debug.Agents[0].Clients)
+
+		// The replica of the client knows which replica the agent is
+		// connected to.
+		require.Eventually(t, func() bool {
+			debug = coordinator2.Debug()
+			return len(debug.Agents) == 1 && debug.Agents[0].ReplicaID == replica1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.False(t, debug.Agents[0].Connected)
+		require.NotNil(t, debug.Agents[0].Node)
+		require.Equal(t, 1, debug.Agents[0].Node.PreferredDERP)
+		require.Len(t, debug.Agents[0].Clients, 1)
+		require.Equal(t, clientID, debug.Agents[0].Clients[0].ID)
+	})
+
+	t.Run("ClientBeforeAgent", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator1, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		agentID := uuid.New()
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		clientNodeChan := make(chan []*agpl.Node, 1)
+		sendClientNode, _ := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		go func() {
+			_ = coordinator2.ServeClient(clientServerWS, uuid.New(), agentID)
+		}()
+		sendClientNode(&agpl.Node{PreferredDERP: 2})
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node, 1)
+		sendAgentNode, _ := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		go func() {
+			_ = coordinator1.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+
+		clientNodes := <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+		require.Equal(t, 2, clientNodes[0].PreferredDERP)
+		agentNodes := <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+		require.Equal(t, 1, agentNodes[0].PreferredDERP)
+	})
+
+	t.Run("OnlyInterestedReplicas", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator1, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+		replica3 := uuid.New()
+		coordinator3, err := tailnet.NewPGCoord(slogtest.Make(t, nil), replica3, db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator3.Close()
+
+		var uninterested atomic.Int64
+		cancel, err := pubsub.Subscribe("tailnet_coordinator:"+replica3.String(), func(_ context.Context, _ []byte) {
+			uninterested.Add(1)
+		})
+		require.NoError(t, err)
+		defer cancel()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node, 1)
+		sendAgentNode, _ := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		agentID := uuid.New()
+		go func() {
+			_ = coordinator1.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			return coordinator1.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		clientNodeChan := make(chan []*agpl.Node, 1)
+		sendClientNode, _ := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		go func() {
+			_ = coordinator2.ServeClient(clientServerWS, uuid.New(), agentID)
+		}()
+		<-clientNodeChan
+		sendClientNode(&agpl.Node{})
+		<-agentNodeChan
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+		agentNodes := <-clientNodeChan
+		require.Equal(t, 1, agentNodes[0].PreferredDERP)
+
+		require.Zero(t, uninterested.Load())
+	})
+
+	t.Run("Nodes", func(t *testing.T) {
+		t.Parallel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		coordinator1, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, _ := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		go func() {
+			_ = coordinator1.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+		require.Eventually(t, func() bool {
+			return coordinator1.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// The node of an agent on another replica is read from the database.
+		nodes := coordinator2.Nodes([]uuid.UUID{agentID, uuid.New()})
+		require.Len(t, nodes, 1)
+		require.Equal(t, 1, nodes[agentID].PreferredDERP)
+	})
+
+	t.Run("CleanupStaleReplicas", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		// A replica that crashed without deleting its agents.
+		crashedID := uuid.New()
+		_, err := db.UpsertTailnetCoordinator(ctx, database.UpsertTailnetCoordinatorParams{
+			ID:          crashedID,
+			HeartbeatAt: database.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		agentID := uuid.New()
+		_, err = db.UpsertTailnetAgent(ctx, database.UpsertTailnetAgentParams{
+			ID:            agentID,
+			CoordinatorID: crashedID,
+			UpdatedAt:     database.Now().Add(-time.Hour),
+			Node:          []byte(`{"preferred_derp":1}`),
+		})
+		require.NoError(t, err)
+
+		coordinator, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, &tailnet.PGCoordOptions{
+			HeartbeatInterval: testutil.IntervalFast,
+		})
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		require.Eventually(t, func() bool {
+			agents, err := db.GetTailnetAgents(ctx, agentID)
+			return err == nil && len(agents) == 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Nil(t, coordinator.Node(agentID))
+	})
+
+	t.Run("RegisterAgainAfterCleanup", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db, pubsub := dbtestutil.NewDB(t)
+		replicaID := uuid.New()
+		coordinator, err := tailnet.NewPGCoord(slogtest.Make(t, nil), replicaID, db, pubsub, &tailnet.PGCoordOptions{
+			HeartbeatInterval: testutil.IntervalFast,
+		})
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, _ := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		go func() {
+			_ = coordinator.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&agpl.Node{})
+		require.Eventually(t, func() bool {
+			agents, err := db.GetTailnetAgents(ctx, agentID)
+			return err == nil && len(agents) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// Another replica mistook this one for dead.
+		err = db.DeleteTailnetCoordinator(ctx, replicaID)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			agents, err := db.GetTailnetAgents(ctx, agentID)
+			return err == nil && len(agents) == 1 && agents[0].CoordinatorID == replicaID
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+}
+
+func TestPGCoordLoad(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	coordinators := newPGCoords(t, 3)
+	logs := bytes.NewBuffer(nil)
+	err := tailnettest.RunCoordinatorLoad(ctx, tailnettest.CoordinatorLoadConfig{
+		Agents:          10,
+		ClientsPerAgent: 3,
+		Updates:         3,
+	}, logs, coordinators...)
+	require.NoError(t, err, logs.String())
+	require.Contains(t, logs.String(), "update 3 reached all clients")
+}
+
+// BenchmarkPGCoord runs the coordinator load against three replicas. Set DB
+// to run it against PostgreSQL instead of the in-memory database.
+func BenchmarkPGCoord(b *testing.B) {
+	coordinators := newPGCoords(b, 3)
+	for i := 0; i < b.N; i++ {
+		err := tailnettest.RunCoordinatorLoad(context.Background(), tailnettest.CoordinatorLoadConfig{
+			Agents:          50,
+			ClientsPerAgent: 4,
+			Updates:         10,
+		}, io.Discard, coordinators...)
+		require.NoError(b, err)
+	}
+}
+
+func newPGCoords(t testing.TB, count int) []agpl.Coordinator {
+	db, pubsub := dbtestutil.NewDB(t)
+	coordinators := make([]agpl.Coordinator, 0, count)
+	for i := 0; i < count; i++ {
+		coord, err := tailnet.NewPGCoord(slogtest.Make(t, nil), uuid.New(), db, pubsub, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = coord.Close()
+		})
+		coordinators = append(coordinators, coord)
+	}
+	return coordinators
+}
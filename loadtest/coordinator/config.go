@@ -0,0 +1,27 @@
+package coordinator
+
+import "golang.org/x/xerrors"
+
+type Config struct {
+	// Agents is the number of agents to connect to the coordinators.
+	Agents int `json:"agents"`
+	// ClientsPerAgent is the number of clients that connect to each agent.
+	ClientsPerAgent int `json:"clients_per_agent"`
+	// Updates is the number of times every agent updates its node once its
+	// clients are connected.
+	Updates int `json:"updates"`
+}
+
+func (c Config) Validate() error {
+	if c.Agents <= 0 {
+		return xerrors.New("agents must be greater than 0")
+	}
+	if c.ClientsPerAgent <= 0 {
+		return xerrors.New("clients_per_agent must be greater than 0")
+	}
+	if c.Updates < 0 {
+		return xerrors.New("updates must be a positive value")
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package coordinator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/loadtest/coordinator"
+)
+
+func Test_Config(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		config      coordinator.Config
+		errContains string
+	}{
+		{
+			name: "OK",
+			config: coordinator.Config{
+				Agents:          10,
+				ClientsPerAgent: 2,
+				Updates:         5,
+			},
+		},
+		{
+			name: "NoUpdates",
+			config: coordinator.Config{
+				Agents:          1,
+				ClientsPerAgent: 1,
+			},
+		},
+		{
+			name: "NoAgents",
+			config: coordinator.Config{
+				ClientsPerAgent: 1,
+			},
+			errContains: "agents must be greater than 0",
+		},
+		{
+			name: "NoClients",
+			config: coordinator.Config{
+				Agents: 1,
+			},
+			errContains: "clients_per_agent must be greater than 0",
+		},
+		{
+			name: "NegativeUpdates",
+			config: coordinator.Config{
+				Agents:          1,
+				ClientsPerAgent: 1,
+				Updates:         -1,
+			},
+			errContains: "updates must be a positive value",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := c.config.Validate()
+			if c.errContains != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
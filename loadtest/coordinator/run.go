@@ -0,0 +1,39 @@
+package coordinator
+
+import (
+	"context"
+	"io"
+
+	"github.com/coder/coder/loadtest/harness"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/coder/tailnet/tailnettest"
+)
+
+type Runner struct {
+	cfg          Config
+	coordinators []tailnet.Coordinator
+}
+
+var _ harness.Runnable = &Runner{}
+
+// NewRunner creates a new coordinator loadtest Runner. Agents are spread
+// evenly across the coordinators, and the clients of an agent connect to the
+// coordinators the agent isn't connected to first, so nodes have to travel
+// between coordinators whenever there's more than one.
+func NewRunner(cfg Config, coordinators ...tailnet.Coordinator) *Runner {
+	return &Runner{
+		cfg:          cfg,
+		coordinators: coordinators,
+	}
+}
+
+// Run implements Runnable. It connects the agents and clients, and then
+// measures how long it takes the node updates of the agents to reach every
+// client.
+func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
+	return tailnettest.RunCoordinatorLoad(ctx, tailnettest.CoordinatorLoadConfig{
+		Agents:          r.cfg.Agents,
+		ClientsPerAgent: r.cfg.ClientsPerAgent,
+		Updates:         r.cfg.Updates,
+	}, logs, r.coordinators...)
+}
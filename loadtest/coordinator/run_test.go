@@ -0,0 +1,50 @@
+package coordinator_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/loadtest/coordinator"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/coder/testutil"
+)
+
+func Test_Runner(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	coord := tailnet.NewCoordinator()
+	defer coord.Close()
+	r := coordinator.NewRunner(coordinator.Config{
+		Agents:          5,
+		ClientsPerAgent: 3,
+		Updates:         2,
+	}, coord)
+
+	logs := bytes.NewBuffer(nil)
+	err := r.Run(ctx, "1", logs)
+	require.NoError(t, err)
+	require.Contains(t, logs.String(), "connected 5 agents and 15 clients to 1 coordinators")
+	require.Contains(t, logs.String(), "update 2 reached all clients")
+}
+
+func BenchmarkRunner(b *testing.B) {
+	coord := tailnet.NewCoordinator()
+	defer coord.Close()
+	r := coordinator.NewRunner(coordinator.Config{
+		Agents:          50,
+		ClientsPerAgent: 4,
+		Updates:         10,
+	}, coord)
+
+	for i := 0; i < b.N; i++ {
+		err := r.Run(context.Background(), "", io.Discard)
+		require.NoError(b, err)
+	}
+}
@@ -309,9 +309,12 @@ func (p *Server) acquireJob(ctx context.Context) {
 
 	provisioner, ok := p.opts.Provisioners[job.Provisioner]
 	if !ok {
+		// Another daemon may have the provisioner, so the job may succeed
+		// when retried.
 		err := p.FailJob(ctx, &proto.FailedJob{
-			JobId: job.JobId,
-			Error: fmt.Sprintf("no provisioner %s", job.Provisioner),
+			JobId:     job.JobId,
+			Error:     fmt.Sprintf("no provisioner %s", job.Provisioner),
+			Transient: true,
 		})
 		if err != nil {
 			p.opts.Logger.Error(ctx, "fail job", slog.F("job_id", job.JobId), slog.Error(err))
@@ -456,7 +459,8 @@ func (p *Server) closeWithError(err error) error {
 	if p.activeJob != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
-		failErr := p.activeJob.Fail(ctx, &proto.FailedJob{Error: errMsg})
+		// The job didn't fail by itself, the daemon went away.
+		failErr := p.activeJob.Fail(ctx, &proto.FailedJob{Error: errMsg, Transient: true})
 		if failErr != nil {
 			p.activeJob.ForceStop()
 		}
@@ -485,6 +485,7 @@ func TestProvisionerd(t *testing.T) {
 		t.Parallel()
 		var (
 			didFail       atomic.Bool
+			transient     atomic.Bool
 			didAcquireJob atomic.Bool
 			completeChan  = make(chan struct{})
 			completeOnce  sync.Once
@@ -514,6 +515,7 @@ func TestProvisionerd(t *testing.T) {
 				updateJob: noopUpdateJob,
 				failJob: func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error) {
 					didFail.Store(true)
+					transient.Store(job.Transient)
 					return &proto.Empty{}, nil
 				},
 			}), nil
@@ -532,6 +534,8 @@ func TestProvisionerd(t *testing.T) {
 		})
 		require.Condition(t, closedWithin(completeChan, testutil.WaitShort))
 		require.True(t, didFail.Load())
+		// The provisioner failed the build, so it would fail again.
+		require.False(t, transient.Load())
 		require.NoError(t, closer.Close())
 	})
 
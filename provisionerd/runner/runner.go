@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
@@ -22,6 +23,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.11.0"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/xerrors"
+	"storj.io/drpc"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/tracing"
@@ -341,7 +343,7 @@ func (r *Runner) do(ctx context.Context) (*proto.CompletedJob, *proto.FailedJob)
 
 	err := r.filesystem.MkdirAll(r.workDirectory, 0700)
 	if err != nil {
-		return nil, r.failedJobf("create work directory %q: %s", r.workDirectory, err)
+		return nil, r.transientFailedJobf("create work directory %q: %s", r.workDirectory, err)
 	}
 
 	r.queueLog(ctx, &proto.Log{
@@ -453,7 +455,7 @@ func (r *Runner) heartbeat(ctx context.Context) {
 			JobId: r.job.JobId,
 		})
 		if err != nil {
-			err = r.Fail(ctx, r.failedJobf("send periodic update: %s", err))
+			err = r.Fail(ctx, r.transientFailedJobf("send periodic update: %s", err))
 			if err != nil {
 				r.logger.Error(ctx, "failed to call FailJob", slog.Error(err))
 			}
@@ -784,7 +786,7 @@ func (r *Runner) buildWorkspace(ctx context.Context, stage string, req *sdkproto
 	// will still be available for us to send the cancel to the provisioner
 	stream, err := r.provisioner.Provision(ctx)
 	if err != nil {
-		return nil, r.failedJobf("provision: %s", err)
+		return nil, r.transientFailedJobf("provision: %s", err)
 	}
 	defer stream.Close()
 	go func() {
@@ -802,13 +804,17 @@ func (r *Runner) buildWorkspace(ctx context.Context, stage string, req *sdkproto
 
 	err = stream.Send(req)
 	if err != nil {
-		return nil, r.failedJobf("start provision: %s", err)
+		return nil, r.transientFailedJobf("start provision: %s", err)
 	}
 
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
-			return nil, r.failedJobf("recv workspace provision: %s", err)
+			// Errors returned by the provisioner, like invalid templates,
+			// fail the same way every time. Losing the stream doesn't.
+			failed := r.failedJobf("recv workspace provision: %s", err)
+			failed.Transient = isStreamClosed(err)
+			return nil, failed
 		}
 		switch msgType := msg.Type.(type) {
 		case *sdkproto.Provision_Response_Log:
@@ -926,6 +932,26 @@ func (r *Runner) failedJobf(format string, args ...interface{}) *proto.FailedJob
 	}
 }
 
+// transientFailedJobf fails the job for reasons other than the job itself,
+// so it may succeed when retried.
+func (r *Runner) transientFailedJobf(format string, args ...interface{}) *proto.FailedJob {
+	failed := r.failedJobf(format, args...)
+	failed.Transient = true
+	return failed
+}
+
+// isStreamClosed reports whether a provisioner stream failed because the
+// connection to the provisioner was lost or timed out, e.g. because it
+// crashed.
+func isStreamClosed(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		drpc.ClosedError.Has(err)
+}
+
 func (r *Runner) startTrace(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	return r.tracer.Start(ctx, name, append(opts, trace.WithAttributes(
 		semconv.ServiceNameKey.String("coderd.provisionerd"),
@@ -13,6 +13,23 @@ export interface APIKey {
   readonly lifetime_seconds: number
 }
 
+// From codersdk/apikey.go
+export interface APIKeyWithOwner extends APIKey {
+  readonly username: string
+}
+
+// From codersdk/apikey.go
+export interface APIKeysFilter {
+  readonly q?: string
+}
+
+// From codersdk/userinvitations.go
+export interface AcceptUserInvitationRequest {
+  readonly token: string
+  readonly username: string
+  readonly password: string
+}
+
 // From codersdk/licenses.go
 export interface AddLicenseRequest {
   readonly license: string
@@ -29,6 +46,9 @@ export interface AgentStatsReportResponse {
   readonly num_comms: number
   readonly rx_bytes: number
   readonly tx_bytes: number
+  readonly session_count_ssh: number
+  readonly session_count_reconnecting_pty: number
+  readonly session_count_app: number
 }
 
 // From codersdk/roles.go
@@ -95,6 +115,7 @@ export interface AuthMethods {
   readonly password: boolean
   readonly github: boolean
   readonly oidc: boolean
+  readonly ldap: boolean
 }
 
 // From codersdk/authorization.go
@@ -131,6 +152,14 @@ export interface BuildInfoResponse {
   readonly version: string
 }
 
+// From codersdk/workspaces.go
+export interface CloneWorkspaceRequest {
+  readonly name: string
+  readonly autostart_schedule?: string
+  readonly ttl_ms?: number
+  readonly parameter_values?: CreateParameterRequest[]
+}
+
 // From codersdk/parameters.go
 export interface ComputedParameter extends Parameter {
   readonly source_value: string
@@ -138,6 +167,27 @@ export interface ComputedParameter extends Parameter {
   readonly default_source_value: boolean
 }
 
+// From codersdk/derpregions.go
+export interface CreateDERPNodeRequest {
+  readonly name: string
+  readonly host_name: string
+  readonly ipv4?: string
+  readonly ipv6?: string
+  readonly derp_port?: number
+  readonly stun_port?: number
+  readonly stun_only: boolean
+  readonly force_http: boolean
+  readonly disabled: boolean
+}
+
+// From codersdk/derpregions.go
+export interface CreateDERPRegionRequest {
+  readonly id: number
+  readonly code: string
+  readonly name: string
+  readonly disabled: boolean
+}
+
 // From codersdk/users.go
 export interface CreateFirstUserRequest {
   readonly email: string
@@ -181,6 +231,19 @@ export interface CreateTemplateRequest {
   readonly template_version_id: string
   readonly parameter_values?: CreateParameterRequest[]
   readonly default_ttl_ms?: number
+  readonly max_ttl_ms?: number
+  readonly min_autostart_interval_ms?: number
+  readonly quiet_hours_schedule?: string
+  readonly allow_user_autostop?: boolean
+  readonly inactivity_ttl_ms?: number
+  readonly dormancy_deletion_ttl_ms?: number
+  readonly activity_bump_ms?: number
+  readonly activity_bump_threshold_ms?: number
+  readonly build_retries?: number
+  readonly build_retry_backoff_ms?: number
+  readonly rollback_failed_updates?: boolean
+  readonly require_active_version?: boolean
+  readonly allow_workspace_networking?: boolean
 }
 
 // From codersdk/templateversions.go
@@ -210,6 +273,14 @@ export interface CreateTestAuditLogRequest {
 // From codersdk/apikey.go
 export interface CreateTokenRequest {
   readonly scope: APIKeyScope
+  // This is likely an enum in an external package ("time.Duration")
+  readonly lifetime: number
+}
+
+// From codersdk/userinvitations.go
+export interface CreateUserInvitationRequest {
+  readonly email: string
+  readonly organization_id: string
 }
 
 // From codersdk/users.go
@@ -220,6 +291,14 @@ export interface CreateUserRequest {
   readonly organization_id: string
 }
 
+// From codersdk/workspacebatches.go
+export interface CreateWorkspaceBatchRequest {
+  readonly q: string
+  readonly action: WorkspaceBatchAction
+  readonly concurrency?: number
+  readonly dry_run?: boolean
+}
+
 // From codersdk/workspaces.go
 export interface CreateWorkspaceBuildRequest {
   readonly template_version_id?: string
@@ -255,6 +334,43 @@ export interface DERP {
 export interface DERPConfig {
   readonly url: DeploymentConfigField<string>
   readonly path: DeploymentConfigField<string>
+  readonly block_direct: DeploymentConfigField<boolean>
+}
+
+// From codersdk/derpregions.go
+export interface DERPMapNode {
+  readonly name: string
+  readonly region_id: number
+  readonly host_name: string
+  readonly ipv4?: string
+  readonly ipv6?: string
+  readonly derp_port?: number
+  readonly stun_port?: number
+  readonly stun_only: boolean
+  readonly force_http: boolean
+  readonly disabled: boolean
+  readonly health: DERPNodeHealth
+}
+
+// From codersdk/derpregions.go
+export interface DERPMapRegion {
+  readonly id: number
+  readonly code: string
+  readonly name: string
+  readonly managed: boolean
+  readonly disabled: boolean
+  readonly nodes: DERPMapNode[]
+  readonly health: DERPRegionHealth
+  readonly created_at?: string
+  readonly updated_at?: string
+}
+
+// From codersdk/derpregions.go
+export interface DERPNodeHealth {
+  readonly healthy: boolean
+  readonly checked_at: string
+  readonly latency_ms: number
+  readonly error?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -263,6 +379,13 @@ export interface DERPRegion {
   readonly latency_ms: number
 }
 
+// From codersdk/derpregions.go
+export interface DERPRegionHealth {
+  readonly healthy: boolean
+  readonly checked_at: string
+  readonly error?: string
+}
+
 // From codersdk/deploymentconfig.go
 export interface DERPServerConfig {
   readonly enable: DeploymentConfigField<boolean>
@@ -273,6 +396,40 @@ export interface DERPServerConfig {
   readonly relay_url: DeploymentConfigField<string>
 }
 
+// From codersdk/debug.go
+export interface DebugCoordinator {
+  readonly replica_id: string
+  readonly agents: DebugCoordinatorAgent[]
+}
+
+// From codersdk/debug.go
+export interface DebugCoordinatorAgent {
+  readonly id: string
+  readonly name: string
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly owner_name: string
+  readonly connected: boolean
+  readonly replica_id: string
+  readonly node?: DebugNode
+  readonly clients: DebugCoordinatorClient[]
+}
+
+// From codersdk/debug.go
+export interface DebugCoordinatorClient {
+  readonly id: string
+  readonly node?: DebugNode
+}
+
+// From codersdk/debug.go
+export interface DebugNode {
+  readonly as_of: string
+  readonly preferred_derp: number
+  readonly derp_latency: Record<string, number>
+  readonly addresses: string[]
+  readonly endpoints: string[]
+}
+
 // From codersdk/deploymentconfig.go
 export interface DeploymentConfig {
   readonly access_url: DeploymentConfigField<string>
@@ -288,16 +445,25 @@ export interface DeploymentConfig {
   readonly cache_directory: DeploymentConfigField<string>
   readonly in_memory_database: DeploymentConfigField<boolean>
   readonly pg_connection_url: DeploymentConfigField<string>
+  readonly pubsub: PubsubConfig
   readonly oauth2: OAuth2Config
   readonly oidc: OIDCConfig
+  readonly ldap: LDAPConfig
+  readonly smtp: SMTPConfig
+  readonly notification_webhook_url: DeploymentConfigField<string>
   readonly telemetry: TelemetryConfig
   readonly tls: TLSConfig
   readonly trace: TraceConfig
   readonly secure_auth_cookie: DeploymentConfigField<boolean>
+  readonly two_factor_required: DeploymentConfigField<boolean>
+  readonly user_invitation_lifetime: DeploymentConfigField<number>
+  readonly password_reset_lifetime: DeploymentConfigField<number>
+  readonly max_token_lifetime: DeploymentConfigField<number>
   readonly ssh_keygen_algorithm: DeploymentConfigField<string>
   readonly auto_import_templates: DeploymentConfigField<string[]>
   readonly metrics_cache_refresh_interval: DeploymentConfigField<number>
   readonly agent_stat_refresh_interval: DeploymentConfigField<number>
+  readonly agent_connection_cache_size: DeploymentConfigField<number>
   readonly audit_logging: DeploymentConfigField<boolean>
   readonly browser_only: DeploymentConfigField<boolean>
   readonly scim_api_key: DeploymentConfigField<string>
@@ -338,6 +504,11 @@ export interface Feature {
   readonly actual?: number
 }
 
+// From codersdk/userinvitations.go
+export interface ForgotPasswordRequest {
+  readonly email: string
+}
+
 // From codersdk/apikey.go
 export interface GenerateAPIKeyResponse {
   readonly key: string
@@ -383,6 +554,22 @@ export interface Healthcheck {
   readonly threshold: number
 }
 
+// From codersdk/deploymentconfig.go
+export interface LDAPConfig {
+  readonly url: DeploymentConfigField<string>
+  readonly start_tls: DeploymentConfigField<boolean>
+  readonly insecure_skip_verify: DeploymentConfigField<boolean>
+  readonly bind_dn: DeploymentConfigField<string>
+  readonly bind_password: DeploymentConfigField<string>
+  readonly search_base_dn: DeploymentConfigField<string>
+  readonly search_filter: DeploymentConfigField<string>
+  readonly username_attribute: DeploymentConfigField<string>
+  readonly email_attribute: DeploymentConfigField<string>
+  readonly group_attribute: DeploymentConfigField<string>
+  readonly group_sync: DeploymentConfigField<boolean>
+  readonly allow_signups: DeploymentConfigField<boolean>
+}
+
 // From codersdk/licenses.go
 export interface License {
   readonly id: number
@@ -407,11 +594,44 @@ export interface ListeningPortsResponse {
 export interface LoginWithPasswordRequest {
   readonly email: string
   readonly password: string
+  readonly two_factor_code?: string
 }
 
 // From codersdk/users.go
 export interface LoginWithPasswordResponse {
   readonly session_token: string
+  readonly two_factor_enrollment_required?: boolean
+}
+
+// From codersdk/notifications.go
+export interface MarkNotificationsReadRequest {
+  readonly ids?: string[]
+}
+
+// From codersdk/notifications.go
+export interface Notification {
+  readonly id: string
+  readonly user_id: string
+  readonly workspace_id?: string
+  readonly kind: NotificationKind
+  readonly title: string
+  readonly body: string
+  readonly created_at: string
+  readonly read_at?: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationPreference {
+  readonly kind: NotificationKind
+  readonly inbox: boolean
+  readonly email: boolean
+  readonly webhook: boolean
+}
+
+// From codersdk/notifications.go
+export interface NotificationsFilter {
+  readonly unread?: boolean
+  readonly limit?: number
 }
 
 // From codersdk/deploymentconfig.go
@@ -554,6 +774,12 @@ export interface ProvisionerJobLog {
   readonly output: string
 }
 
+// From codersdk/deploymentconfig.go
+export interface PubsubConfig {
+  readonly backend: DeploymentConfigField<string>
+  readonly redis_url: DeploymentConfigField<string>
+}
+
 // From codersdk/workspaces.go
 export interface PutExtendWorkspaceRequest {
   readonly deadline: string
@@ -570,6 +796,18 @@ export interface Replica {
   readonly database_latency: number
 }
 
+// From codersdk/userinvitations.go
+export interface ResetPasswordRequest {
+  readonly token: string
+  readonly password: string
+}
+
+// From codersdk/twofactor.go
+export interface ResetUserTwoFactorRequest {
+  readonly code?: string
+  readonly password?: string
+}
+
 // From codersdk/error.go
 export interface Response {
   readonly message: string
@@ -583,6 +821,16 @@ export interface Role {
   readonly display_name: string
 }
 
+// From codersdk/deploymentconfig.go
+export interface SMTPConfig {
+  readonly address: DeploymentConfigField<string>
+  readonly from: DeploymentConfigField<string>
+  readonly username: DeploymentConfigField<string>
+  readonly password: DeploymentConfigField<string>
+  readonly force_tls: DeploymentConfigField<boolean>
+  readonly insecure_skip_verify: DeploymentConfigField<boolean>
+}
+
 // From codersdk/sse.go
 export interface ServerSentEvent {
   readonly type: ServerSentEventType
@@ -590,6 +838,19 @@ export interface ServerSentEvent {
   readonly data: any
 }
 
+// From codersdk/sessions.go
+export interface Session {
+  readonly id: string
+  readonly login_type: LoginType
+  readonly scope: APIKeyScope
+  readonly ip_address: string
+  readonly user_agent: string
+  readonly created_at: string
+  readonly last_used: string
+  readonly expires_at: string
+  readonly current: boolean
+}
+
 // From codersdk/deploymentconfig.go
 export interface TLSConfig {
   readonly enable: DeploymentConfigField<boolean>
@@ -608,7 +869,7 @@ export interface TelemetryConfig {
 }
 
 // From codersdk/templates.go
-export interface Template {
+export interface Template extends TemplateSchedulePolicy {
   readonly id: string
   readonly created_at: string
   readonly updated_at: string
@@ -625,6 +886,8 @@ export interface Template {
   readonly default_ttl_ms: number
   readonly created_by_id: string
   readonly created_by_name: string
+  readonly require_active_version: boolean
+  readonly allow_workspace_networking: boolean
 }
 
 // From codersdk/templates.go
@@ -650,6 +913,21 @@ export interface TemplateGroup extends Group {
   readonly role: TemplateRole
 }
 
+// From codersdk/templates.go
+export interface TemplateSchedulePolicy {
+  readonly max_ttl_ms: number
+  readonly min_autostart_interval_ms: number
+  readonly quiet_hours_schedule: string
+  readonly allow_user_autostop: boolean
+  readonly inactivity_ttl_ms: number
+  readonly dormancy_deletion_ttl_ms: number
+  readonly activity_bump_ms: number
+  readonly activity_bump_threshold_ms: number
+  readonly build_retries: number
+  readonly build_retry_backoff_ms: number
+  readonly rollback_failed_updates: boolean
+}
+
 // From codersdk/templates.go
 export interface TemplateUser extends User {
   readonly role: TemplateRole
@@ -680,11 +958,40 @@ export interface TraceConfig {
   readonly capture_logs: DeploymentConfigField<boolean>
 }
 
+// From codersdk/workspacetransfers.go
+export interface TransferWorkspaceRequest {
+  readonly owner_id: string
+}
+
 // From codersdk/templates.go
 export interface UpdateActiveTemplateVersion {
   readonly id: string
 }
 
+// From codersdk/derpregions.go
+export interface UpdateDERPNodeRequest {
+  readonly host_name?: string
+  readonly ipv4?: string
+  readonly ipv6?: string
+  readonly derp_port?: number
+  readonly stun_port?: number
+  readonly stun_only?: boolean
+  readonly force_http?: boolean
+  readonly disabled?: boolean
+}
+
+// From codersdk/derpregions.go
+export interface UpdateDERPRegionRequest {
+  readonly code?: string
+  readonly name?: string
+  readonly disabled?: boolean
+}
+
+// From codersdk/notifications.go
+export interface UpdateNotificationPreferencesRequest {
+  readonly preferences: NotificationPreference[]
+}
+
 // From codersdk/users.go
 export interface UpdateRoles {
   readonly roles: string[]
@@ -703,6 +1010,19 @@ export interface UpdateTemplateMeta {
   readonly description?: string
   readonly icon?: string
   readonly default_ttl_ms?: number
+  readonly max_ttl_ms?: number
+  readonly min_autostart_interval_ms?: number
+  readonly quiet_hours_schedule?: string
+  readonly allow_user_autostop?: boolean
+  readonly inactivity_ttl_ms?: number
+  readonly dormancy_deletion_ttl_ms?: number
+  readonly activity_bump_ms?: number
+  readonly activity_bump_threshold_ms?: number
+  readonly build_retries?: number
+  readonly build_retry_backoff_ms?: number
+  readonly rollback_failed_updates?: boolean
+  readonly require_active_version?: boolean
+  readonly allow_workspace_networking?: boolean
 }
 
 // From codersdk/users.go
@@ -716,11 +1036,21 @@ export interface UpdateUserProfileRequest {
   readonly username: string
 }
 
+// From codersdk/workspaces.go
+export interface UpdateWorkspaceAutomaticUpdatesRequest {
+  readonly automatic_updates: boolean
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceAutostartRequest {
   readonly schedule?: string
 }
 
+// From codersdk/workspaces.go
+export interface UpdateWorkspaceDormancyRequest {
+  readonly dormant: boolean
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceRequest {
   readonly name?: string
@@ -759,12 +1089,36 @@ export interface UserCountResponse {
   readonly count: number
 }
 
+// From codersdk/userinvitations.go
+export interface UserInvitation {
+  readonly id: string
+  readonly email: string
+  readonly organization_id: string
+  readonly created_by: string
+  readonly created_at: string
+  readonly expires_at: string
+}
+
 // From codersdk/users.go
 export interface UserRoles {
   readonly roles: string[]
   readonly organization_roles: Record<string, string[]>
 }
 
+// From codersdk/twofactor.go
+export interface UserTwoFactor {
+  readonly enabled: boolean
+  readonly recovery_codes_remaining: number
+  readonly updated_at: string
+}
+
+// From codersdk/twofactor.go
+export interface UserTwoFactorEnrollment {
+  readonly secret: string
+  readonly url: string
+  readonly recovery_codes: string[]
+}
+
 // From codersdk/users.go
 export interface UsersRequest extends Pagination {
   readonly q?: string
@@ -776,6 +1130,11 @@ export interface ValidationError {
   readonly detail: string
 }
 
+// From codersdk/twofactor.go
+export interface VerifyUserTwoFactorRequest {
+  readonly code: string
+}
+
 // From codersdk/workspaces.go
 export interface Workspace {
   readonly id: string
@@ -792,6 +1151,9 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly dormant_at?: string
+  readonly deleting_at?: string
+  readonly automatic_updates: boolean
 }
 
 // From codersdk/workspaceagents.go
@@ -838,6 +1200,14 @@ export interface WorkspaceAgentInstanceMetadata {
   readonly vnc: boolean
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentPeer {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly agent_id: string
+  readonly agent_name: string
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentResourceMetadata {
   readonly memory_total: number
@@ -860,6 +1230,29 @@ export interface WorkspaceApp {
   readonly health: WorkspaceAppHealth
 }
 
+// From codersdk/workspacebatches.go
+export interface WorkspaceBatch {
+  readonly id: string
+  readonly created_at: string
+  readonly initiator_id: string
+  readonly action: WorkspaceBatchAction
+  readonly q: string
+  readonly concurrency: number
+  readonly dry_run: boolean
+  readonly completed_at?: string
+  readonly items: WorkspaceBatchItem[]
+}
+
+// From codersdk/workspacebatches.go
+export interface WorkspaceBatchItem {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly owner_name: string
+  readonly status: WorkspaceBatchItemStatus
+  readonly build_id?: string
+  readonly error?: string
+}
+
 // From codersdk/workspacebuilds.go
 export interface WorkspaceBuild {
   readonly id: string
@@ -924,6 +1317,37 @@ export interface WorkspaceResourceMetadata {
   readonly sensitive: boolean
 }
 
+// From codersdk/workspaceschedules.go
+export interface WorkspaceSchedule {
+  readonly windows: WorkspaceScheduleWindow[]
+  readonly skip_dates: string[]
+}
+
+// From codersdk/workspaceschedules.go
+export interface WorkspaceScheduleWindow {
+  readonly start_schedule: string
+  readonly stop_schedule: string
+}
+
+// From codersdk/workspaceschedules.go
+export interface WorkspaceScheduledTransition {
+  readonly transition: WorkspaceTransition
+  readonly at: string
+}
+
+// From codersdk/workspacetransfers.go
+export interface WorkspaceTransfer {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly from_user_id: string
+  readonly from_username: string
+  readonly to_user_id: string
+  readonly to_username: string
+  readonly created_at: string
+  readonly status: WorkspaceTransferStatus
+  readonly build_id?: string
+}
+
 // From codersdk/workspaces.go
 export interface WorkspacesRequest extends Pagination {
   readonly q?: string
@@ -936,13 +1360,24 @@ export interface WorkspacesResponse {
 }
 
 // From codersdk/apikey.go
-export type APIKeyScope = "all" | "application_connect"
+export type APIKeyScope =
+  | "all"
+  | "application_connect"
+  | "two_factor_enrollment"
 
 // From codersdk/audit.go
 export type AuditAction = "create" | "delete" | "start" | "stop" | "write"
 
 // From codersdk/workspacebuilds.go
-export type BuildReason = "autostart" | "autostop" | "initiator"
+export type BuildReason =
+  | "autodelete"
+  | "autostart"
+  | "autostop"
+  | "dormancy"
+  | "initiator"
+  | "retry"
+  | "rollback"
+  | "transfer"
 
 // From codersdk/features.go
 export type Entitlement = "entitled" | "grace_period" | "not_entitled"
@@ -957,7 +1392,16 @@ export type LogLevel = "debug" | "error" | "info" | "trace" | "warn"
 export type LogSource = "provisioner" | "provisioner_daemon"
 
 // From codersdk/apikey.go
-export type LoginType = "github" | "oidc" | "password" | "token"
+export type LoginType = "github" | "ldap" | "oidc" | "password" | "token"
+
+// From codersdk/notifications.go
+export type NotificationKind =
+  | "workspace_autostart_failed"
+  | "workspace_autostop"
+  | "workspace_build_failed"
+  | "workspace_dormant"
+  | "workspace_transfer"
+  | "workspace_update_failed"
 
 // From codersdk/parameters.go
 export type ParameterDestinationScheme =
@@ -998,6 +1442,7 @@ export type ResourceType =
   | "template"
   | "template_version"
   | "user"
+  | "user_two_factor"
   | "workspace"
   | "workspace_build"
 
@@ -1027,6 +1472,17 @@ export type WorkspaceAppHealth =
 // From codersdk/workspaceapps.go
 export type WorkspaceAppSharingLevel = "authenticated" | "owner" | "public"
 
+// From codersdk/workspacebatches.go
+export type WorkspaceBatchAction = "delete" | "start" | "stop" | "update"
+
+// From codersdk/workspacebatches.go
+export type WorkspaceBatchItemStatus =
+  | "building"
+  | "failed"
+  | "pending"
+  | "skipped"
+  | "succeeded"
+
 // From codersdk/workspacebuilds.go
 export type WorkspaceStatus =
   | "canceled"
@@ -1040,6 +1496,9 @@ export type WorkspaceStatus =
   | "stopped"
   | "stopping"
 
+// From codersdk/workspacetransfers.go
+export type WorkspaceTransferStatus = "completed" | "pending"
+
 // From codersdk/workspacebuilds.go
 export type WorkspaceTransition = "delete" | "start" | "stop"
 
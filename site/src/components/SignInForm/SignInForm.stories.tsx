@@ -102,6 +102,7 @@ WithGithub.args = {
     password: true,
     github: true,
     oidc: false,
+    ldap: false,
   },
 }
 
@@ -112,6 +113,7 @@ WithOIDC.args = {
     password: true,
     github: false,
     oidc: true,
+    ldap: false,
   },
 }
 
@@ -122,5 +124,6 @@ WithGithubAndOIDC.args = {
     password: true,
     github: true,
     oidc: true,
+    ldap: false,
   },
 }
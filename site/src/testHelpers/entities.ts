@@ -553,6 +553,7 @@ export const MockAuthMethods: TypesGen.AuthMethods = {
   password: true,
   github: false,
   oidc: false,
+  ldap: false,
 }
 
 export const MockGitSSHKey: TypesGen.GitSSHKey = {
@@ -306,6 +306,12 @@ func (c *Conn) SetNodeCallback(callback func(node *Node)) {
 	c.sendNode()
 }
 
+// BlockEndpoints returns whether direct connections to peers are blocked, so
+// all traffic is relayed through DERP.
+func (c *Conn) BlockEndpoints() bool {
+	return c.blockEndpoints
+}
+
 // SetDERPMap updates the DERPMap of a connection.
 func (c *Conn) SetDERPMap(derpMap *tailcfg.DERPMap) {
 	c.mutex.Lock()
@@ -8,11 +8,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/tailnet"
 	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
 )
 
 func TestMain(m *testing.M) {
@@ -83,3 +86,82 @@ func TestTailnet(t *testing.T) {
 		w2.Close()
 	})
 }
+
+func TestTailnetRelay(t *testing.T) {
+	t.Parallel()
+	logger := slogtest.Make(t, nil).Leveled(slog.LevelDebug)
+
+	// connect creates two connections that exchange nodes directly, and
+	// returns the first along with the IP of the second.
+	connect := func(t *testing.T, derpMap *tailcfg.DERPMap, blockEndpoints bool) (*tailnet.Conn, netip.Addr) {
+		w1, err := tailnet.NewConn(&tailnet.Options{
+			Addresses:      []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
+			Logger:         logger.Named("w1"),
+			DERPMap:        derpMap,
+			BlockEndpoints: blockEndpoints,
+		})
+		require.NoError(t, err)
+		w2IP := tailnet.IP()
+		w2, err := tailnet.NewConn(&tailnet.Options{
+			Addresses: []netip.Prefix{netip.PrefixFrom(w2IP, 128)},
+			Logger:    logger.Named("w2"),
+			DERPMap:   derpMap,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = w1.Close()
+			_ = w2.Close()
+		})
+		w1.SetNodeCallback(func(node *tailnet.Node) {
+			err := w2.UpdateNodes([]*tailnet.Node{node})
+			assert.NoError(t, err)
+		})
+		w2.SetNodeCallback(func(node *tailnet.Node) {
+			err := w1.UpdateNodes([]*tailnet.Node{node})
+			assert.NoError(t, err)
+		})
+		return w1, w2IP
+	}
+
+	// ping waits for a disco ping to succeed and returns its result.
+	ping := func(t *testing.T, conn *tailnet.Conn, ip netip.Addr) *ipnstate.PingResult {
+		var result *ipnstate.PingResult
+		require.Eventually(t, func() bool {
+			results := make(chan *ipnstate.PingResult, 1)
+			conn.Ping(ip, tailcfg.PingDisco, func(pr *ipnstate.PingResult) {
+				results <- pr
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.IntervalMedium)
+			defer cancel()
+			select {
+			case result = <-results:
+				return result.Err == ""
+			case <-ctx.Done():
+				return false
+			}
+		}, testutil.WaitLong, testutil.IntervalFast)
+		return result
+	}
+
+	t.Run("WithoutSTUN", func(t *testing.T) {
+		t.Parallel()
+		conn, ip := connect(t, tailnettest.RunDERPOnly(t), false)
+		ping(t, conn, ip)
+	})
+
+	t.Run("BlockEndpoints", func(t *testing.T) {
+		t.Parallel()
+		conn, ip := connect(t, tailnettest.RunDERPAndSTUN(t), true)
+		require.True(t, conn.BlockEndpoints())
+		// Give the connection time to upgrade if it was going to.
+		for i := 0; i < 5; i++ {
+			result := ping(t, conn, ip)
+			require.Empty(t, result.Endpoint)
+			require.Equal(t, 1, result.DERPRegionID)
+		}
+		status := conn.Status()
+		for _, peer := range status.Peer {
+			require.Empty(t, peer.CurAddr)
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/netip"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,16 +25,96 @@ import (
 type Coordinator interface {
 	// Node returns an in-memory node by ID.
 	Node(id uuid.UUID) *Node
+	// Nodes returns the nodes with the given IDs. IDs without a node are
+	// omitted.
+	Nodes(ids []uuid.UUID) map[uuid.UUID]*Node
 	// ServeClient accepts a WebSocket connection that wants to connect to an agent
 	// with the specified ID.
 	ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID) error
 	// ServeAgent accepts a WebSocket connection to an agent that listens to
 	// incoming connections and publishes node updates.
 	ServeAgent(conn net.Conn, id uuid.UUID) error
+	// Debug returns a snapshot of the agents and clients connected to the
+	// coordinator.
+	Debug() CoordinatorDebug
 	// Close closes the coordinator.
 	Close() error
 }
 
+// CoordinatorDebug is a snapshot of the nodes known to a coordinator.
+type CoordinatorDebug struct {
+	// ReplicaID is the replica the coordinator runs on. It's uuid.Nil for
+	// coordinators that only support a single replica.
+	ReplicaID uuid.UUID `json:"replica_id"`
+	// Agents are the agents connected to the coordinator, and the agents
+	// that clients connected to it want to reach.
+	Agents []CoordinatorDebugAgent `json:"agents"`
+}
+
+// CoordinatorDebugAgent is an agent known to a coordinator.
+type CoordinatorDebugAgent struct {
+	ID uuid.UUID `json:"id"`
+	// Connected is whether the agent is connected to this coordinator.
+	Connected bool `json:"connected"`
+	// ReplicaID is the replica the agent is connected to, if it's known.
+	ReplicaID uuid.UUID `json:"replica_id"`
+	// Node is nil if the agent never sent its node to the coordinator.
+	Node *Node `json:"node"`
+	// Clients are the clients connected to this coordinator that want to
+	// reach the agent.
+	Clients []CoordinatorDebugClient `json:"clients"`
+}
+
+// CoordinatorDebugClient is a client known to a coordinator.
+type CoordinatorDebugClient struct {
+	ID uuid.UUID `json:"id"`
+	// Node is nil if the client never sent its node to the coordinator.
+	Node *Node `json:"node"`
+}
+
+// DebugSnapshot builds the debug snapshot of a coordinator from its
+// in-memory maps. The caller must hold the lock protecting them.
+func DebugSnapshot(
+	nodes map[uuid.UUID]*Node,
+	agentSockets map[uuid.UUID]net.Conn,
+	agentToConnectionSockets map[uuid.UUID]map[uuid.UUID]net.Conn,
+) CoordinatorDebug {
+	agentIDs := make(map[uuid.UUID]struct{}, len(agentSockets)+len(agentToConnectionSockets))
+	for id := range agentSockets {
+		agentIDs[id] = struct{}{}
+	}
+	for id := range agentToConnectionSockets {
+		agentIDs[id] = struct{}{}
+	}
+
+	debug := CoordinatorDebug{
+		Agents: make([]CoordinatorDebugAgent, 0, len(agentIDs)),
+	}
+	for id := range agentIDs {
+		_, connected := agentSockets[id]
+		agent := CoordinatorDebugAgent{
+			ID:        id,
+			Connected: connected,
+			Node:      nodes[id],
+			Clients:   make([]CoordinatorDebugClient, 0, len(agentToConnectionSockets[id])),
+		}
+		for clientID := range agentToConnectionSockets[id] {
+			agent.Clients = append(agent.Clients, CoordinatorDebugClient{
+				ID:   clientID,
+				Node: nodes[clientID],
+			})
+		}
+		sort.Slice(agent.Clients, func(i, j int) bool {
+			return agent.Clients[i].ID.String() < agent.Clients[j].ID.String()
+		})
+		debug.Agents = append(debug.Agents, agent)
+	}
+	sort.Slice(debug.Agents, func(i, j int) bool {
+		return debug.Agents[i].ID.String() < debug.Agents[j].ID.String()
+	})
+	return debug
+}
+
 // Node represents a node in the network.
 type Node struct {
 	// ID is used to identify the connection.
@@ -137,6 +218,28 @@ func (c *coordinator) Node(id uuid.UUID) *Node {
 	return c.nodes[id]
 }
 
+// Nodes returns the in-memory nodes with the given IDs.
+func (c *coordinator) Nodes(ids []uuid.UUID) map[uuid.UUID]*Node {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	nodes := make(map[uuid.UUID]*Node, len(ids))
+	for _, id := range ids {
+		node, ok := c.nodes[id]
+		if ok {
+			nodes[id] = node
+		}
+	}
+	return nodes
+}
+
+// Debug returns a snapshot of the agents and clients connected to the
+// coordinator.
+func (c *coordinator) Debug() CoordinatorDebug {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return DebugSnapshot(c.nodes, c.agentSockets, c.agentToConnectionSockets)
+}
+
 // ServeClient accepts a WebSocket connection that wants to connect to an agent
 // with the specified ID.
 func (c *coordinator) ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID) error {
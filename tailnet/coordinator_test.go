@@ -62,6 +62,52 @@ func TestCoordinator(t *testing.T) {
 		<-closeChan
 	})
 
+	t.Run("Debug", func(t *testing.T) {
+		t.Parallel()
+		coordinator := tailnet.NewCoordinator()
+		defer coordinator.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, _ := tailnet.ServeCoordinator(agentWS, func(nodes []*tailnet.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		go func() {
+			_ = coordinator.ServeAgent(agentServerWS, agentID)
+		}()
+		sendAgentNode(&tailnet.Node{PreferredDERP: 1, Endpoints: []string{"127.0.0.1:41641"}})
+		require.Eventually(t, func() bool {
+			return coordinator.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		sendClientNode, _ := tailnet.ServeCoordinator(clientWS, func(nodes []*tailnet.Node) error {
+			return nil
+		})
+		clientID := uuid.New()
+		go func() {
+			_ = coordinator.ServeClient(clientServerWS, clientID, agentID)
+		}()
+		sendClientNode(&tailnet.Node{PreferredDERP: 2})
+		require.Eventually(t, func() bool {
+			return coordinator.Node(clientID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		debug := coordinator.Debug()
+		require.Len(t, debug.Agents, 1)
+		agent := debug.Agents[0]
+		require.Equal(t, agentID, agent.ID)
+		require.True(t, agent.Connected)
+		require.NotNil(t, agent.Node)
+		require.Equal(t, 1, agent.Node.PreferredDERP)
+		require.Equal(t, []string{"127.0.0.1:41641"}, agent.Node.Endpoints)
+		require.Len(t, agent.Clients, 1)
+		require.Equal(t, clientID, agent.Clients[0].ID)
+		require.Equal(t, 2, agent.Clients[0].Node.PreferredDERP)
+	})
+
 	t.Run("AgentWithClient", func(t *testing.T) {
 		t.Parallel()
 		coordinator := tailnet.NewCoordinator()
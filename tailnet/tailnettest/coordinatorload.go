@@ -0,0 +1,255 @@
+package tailnettest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
+
+	"github.com/coder/coder/tailnet"
+)
+
+// CoordinatorLoadConfig configures RunCoordinatorLoad.
+type CoordinatorLoadConfig struct {
+	// Agents is the number of agents to connect to the coordinators.
+	Agents int
+	// ClientsPerAgent is the number of clients that connect to each agent.
+	ClientsPerAgent int
+	// Updates is the number of times every agent updates its node once its
+	// clients are connected.
+	Updates int
+}
+
+func (c CoordinatorLoadConfig) validate() error {
+	if c.Agents <= 0 {
+		return xerrors.New("agents must be greater than 0")
+	}
+	if c.ClientsPerAgent <= 0 {
+		return xerrors.New("clients per agent must be greater than 0")
+	}
+	if c.Updates < 0 {
+		return xerrors.New("updates must be a positive value")
+	}
+	return nil
+}
+
+// RunCoordinatorLoad connects agents and clients to in-process coordinators,
+// and then measures how long it takes the node updates of the agents to reach
+// every client. Agents are spread evenly across the coordinators, and the
+// clients of an agent connect to the coordinators the agent isn't connected
+// to first, so nodes have to travel between coordinators whenever there's
+// more than one. It's used to benchmark coordinator implementations.
+func RunCoordinatorLoad(ctx context.Context, cfg CoordinatorLoadConfig, logs io.Writer, coordinators ...tailnet.Coordinator) error {
+	if len(coordinators) == 0 {
+		return xerrors.New("no coordinators to run against")
+	}
+	err := cfg.validate()
+	if err != nil {
+		return xerrors.Errorf("validate config: %w", err)
+	}
+	state := newPeerState(cfg)
+	var (
+		closers []io.Closer
+		wg      sync.WaitGroup
+	)
+	defer func() {
+		for _, closer := range closers {
+			_ = closer.Close()
+		}
+		wg.Wait()
+	}()
+	serve := func(serveFunc func(net.Conn) error, updateNodes func([]*tailnet.Node) error) func(*tailnet.Node) {
+		conn, serverConn := net.Pipe()
+		closers = append(closers, conn, serverConn)
+		sendNode, _ := tailnet.ServeCoordinator(conn, updateNodes)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = serveFunc(serverConn)
+		}()
+		return sendNode
+	}
+
+	start := time.Now()
+	agentSendNodes := make([]func(*tailnet.Node), 0, cfg.Agents)
+	for agent := 0; agent < cfg.Agents; agent++ {
+		agent := agent
+		agentID := uuid.New()
+		coordinator := coordinators[agent%len(coordinators)]
+		sendNode := serve(func(conn net.Conn) error {
+			return coordinator.ServeAgent(conn, agentID)
+		}, func(nodes []*tailnet.Node) error {
+			state.agentReceived(agent, nodes)
+			return nil
+		})
+		sendNode(state.agentNode(agent, 0))
+		agentSendNodes = append(agentSendNodes, sendNode)
+
+		for client := 0; client < cfg.ClientsPerAgent; client++ {
+			client := client
+			coordinator := coordinators[(agent+client+1)%len(coordinators)]
+			clientID := uuid.New()
+			sendNode := serve(func(conn net.Conn) error {
+				return coordinator.ServeClient(conn, clientID, agentID)
+			}, func(nodes []*tailnet.Node) error {
+				state.clientReceived(agent, client, nodes)
+				return nil
+			})
+			sendNode(state.clientNode(agent, client))
+		}
+	}
+	err = state.wait(ctx, func() bool {
+		return state.connected()
+	})
+	if err != nil {
+		return xerrors.Errorf("wait for peers to connect: %w", err)
+	}
+	_, _ = fmt.Fprintf(logs, "connected %d agents and %d clients to %d coordinators in %s\n",
+		cfg.Agents, cfg.Agents*cfg.ClientsPerAgent, len(coordinators), time.Since(start))
+
+	var total time.Duration
+	for update := 1; update <= cfg.Updates; update++ {
+		start := time.Now()
+		for agent, sendNode := range agentSendNodes {
+			sendNode(state.agentNode(agent, update))
+		}
+		err := state.wait(ctx, func() bool {
+			return state.updated(update)
+		})
+		if err != nil {
+			return xerrors.Errorf("wait for update %d to reach clients: %w", update, err)
+		}
+		took := time.Since(start)
+		total += took
+		_, _ = fmt.Fprintf(logs, "update %d reached all clients in %s\n", update, took)
+	}
+	if cfg.Updates > 0 {
+		_, _ = fmt.Fprintf(logs, "updates reached all clients in %s on average\n", total/time.Duration(cfg.Updates))
+	}
+	return nil
+}
+
+// peerState tracks the nodes the agents and clients of a run received.
+type peerState struct {
+	cfg     CoordinatorLoadConfig
+	changed chan struct{}
+
+	mutex sync.Mutex
+	// agentClients is the set of client nodes each agent received.
+	agentClients []map[tailcfg.NodeID]struct{}
+	// clientUpdates is the latest update of its agent each client received,
+	// or -1 if it hasn't received a node yet.
+	clientUpdates [][]int
+}
+
+func newPeerState(cfg CoordinatorLoadConfig) *peerState {
+	state := &peerState{
+		cfg:           cfg,
+		changed:       make(chan struct{}, 1),
+		agentClients:  make([]map[tailcfg.NodeID]struct{}, cfg.Agents),
+		clientUpdates: make([][]int, cfg.Agents),
+	}
+	for agent := range state.agentClients {
+		state.agentClients[agent] = map[tailcfg.NodeID]struct{}{}
+		state.clientUpdates[agent] = make([]int, cfg.ClientsPerAgent)
+		for client := range state.clientUpdates[agent] {
+			state.clientUpdates[agent][client] = -1
+		}
+	}
+	return state
+}
+
+// agentNode is the node an agent sends for an update. Updates are told apart
+// by their preferred DERP region.
+func (s *peerState) agentNode(agent, update int) *tailnet.Node {
+	return &tailnet.Node{
+		ID:            tailcfg.NodeID(agent + 1),
+		AsOf:          time.Now(),
+		PreferredDERP: update,
+	}
+}
+
+func (s *peerState) clientNode(agent, client int) *tailnet.Node {
+	return &tailnet.Node{
+		ID:   tailcfg.NodeID(s.cfg.Agents + agent*s.cfg.ClientsPerAgent + client + 1),
+		AsOf: time.Now(),
+	}
+}
+
+func (s *peerState) agentReceived(agent int, nodes []*tailnet.Node) {
+	s.mutex.Lock()
+	for _, node := range nodes {
+		s.agentClients[agent][node.ID] = struct{}{}
+	}
+	s.mutex.Unlock()
+	s.notify()
+}
+
+func (s *peerState) clientReceived(agent, client int, nodes []*tailnet.Node) {
+	agentNodeID := s.agentNode(agent, 0).ID
+	s.mutex.Lock()
+	for _, node := range nodes {
+		if node.ID == agentNodeID && node.PreferredDERP > s.clientUpdates[agent][client] {
+			s.clientUpdates[agent][client] = node.PreferredDERP
+		}
+	}
+	s.mutex.Unlock()
+	s.notify()
+}
+
+func (s *peerState) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// connected returns whether every agent received the nodes of all of its
+// clients, and every client received the node of its agent.
+func (s *peerState) connected() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for agent, clients := range s.agentClients {
+		if len(clients) < s.cfg.ClientsPerAgent {
+			return false
+		}
+		for _, update := range s.clientUpdates[agent] {
+			if update < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// updated returns whether every client received the given update of its
+// agent.
+func (s *peerState) updated(update int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, clients := range s.clientUpdates {
+		for _, received := range clients {
+			if received < update {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *peerState) wait(ctx context.Context, done func() bool) error {
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.changed:
+		}
+	}
+	return nil
+}
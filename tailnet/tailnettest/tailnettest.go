@@ -21,19 +21,28 @@ import (
 
 // RunDERPAndSTUN creates a DERP mapping for tests.
 func RunDERPAndSTUN(t *testing.T) *tailcfg.DERPMap {
+	stunAddr, stunCleanup := stuntest.ServeWithPacketListener(t, nettype.Std{})
+	t.Cleanup(stunCleanup)
+	return runDERP(t, stunAddr.Port)
+}
+
+// RunDERPOnly creates a DERP mapping for tests without a STUN server, so
+// peers can't discover their endpoints and connect through DERP.
+func RunDERPOnly(t *testing.T) *tailcfg.DERPMap {
+	return runDERP(t, -1)
+}
+
+func runDERP(t *testing.T, stunPort int) *tailcfg.DERPMap {
 	logf := tailnet.Logger(slogtest.Make(t, nil))
 	d := derp.NewServer(key.NewNode(), logf)
 	server := httptest.NewUnstartedServer(derphttp.Handler(d))
 	server.Config.ErrorLog = tslogger.StdLogger(logf)
 	server.Config.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
 	server.StartTLS()
-
-	stunAddr, stunCleanup := stuntest.ServeWithPacketListener(t, nettype.Std{})
 	t.Cleanup(func() {
 		server.CloseClientConnections()
 		server.Close()
 		d.Close()
-		stunCleanup()
 	})
 	tcpAddr, ok := server.Listener.Addr().(*net.TCPAddr)
 	if !ok {
@@ -52,7 +61,7 @@ func RunDERPAndSTUN(t *testing.T) *tailcfg.DERPMap {
 						RegionID:         1,
 						IPv4:             "127.0.0.1",
 						IPv6:             "none",
-						STUNPort:         stunAddr.Port,
+						STUNPort:         stunPort,
 						DERPPort:         tcpAddr.Port,
 						InsecureForTests: true,
 					},
@@ -1,11 +1,17 @@
 package tailnettest_test
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 
+	"github.com/coder/coder/tailnet"
 	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
 )
 
 func TestMain(m *testing.M) {
@@ -16,3 +22,48 @@ func TestRunDERPAndSTUN(t *testing.T) {
 	t.Parallel()
 	_ = tailnettest.RunDERPAndSTUN(t)
 }
+
+func TestRunCoordinatorLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		coord := tailnet.NewCoordinator()
+		defer coord.Close()
+		logs := bytes.NewBuffer(nil)
+		err := tailnettest.RunCoordinatorLoad(ctx, tailnettest.CoordinatorLoadConfig{
+			Agents:          5,
+			ClientsPerAgent: 3,
+			Updates:         2,
+		}, logs, coord)
+		require.NoError(t, err)
+		require.Contains(t, logs.String(), "connected 5 agents and 15 clients to 1 coordinators")
+		require.Contains(t, logs.String(), "update 2 reached all clients")
+	})
+
+	t.Run("NoClients", func(t *testing.T) {
+		t.Parallel()
+		coord := tailnet.NewCoordinator()
+		defer coord.Close()
+		err := tailnettest.RunCoordinatorLoad(context.Background(), tailnettest.CoordinatorLoadConfig{
+			Agents: 1,
+		}, io.Discard, coord)
+		require.ErrorContains(t, err, "clients per agent must be greater than 0")
+	})
+}
+
+func BenchmarkCoordinator(b *testing.B) {
+	coord := tailnet.NewCoordinator()
+	defer coord.Close()
+	for i := 0; i < b.N; i++ {
+		err := tailnettest.RunCoordinatorLoad(context.Background(), tailnettest.CoordinatorLoadConfig{
+			Agents:          50,
+			ClientsPerAgent: 4,
+			Updates:         10,
+		}, io.Discard, coord)
+		require.NoError(b, err)
+	}
+}